@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -11,13 +12,28 @@ import (
 	"time"
 
 	"github.com/1psychoQAQ/genesis-pipeline/internal/api"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/audit"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/notify"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/settings"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/syncqueue"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
+
+	// Providers add themselves to parser.Default
+	_ "github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxivrss"
+	_ "github.com/1psychoQAQ/genesis-pipeline/internal/parser/biorxiv"
+	_ "github.com/1psychoQAQ/genesis-pipeline/internal/parser/openalex"
 )
 
 func main() {
 	port := flag.String("port", "8080", "API server port")
+	migrate := flag.String("migrate", "", "Schema on startup: auto, check or skip (default: DB_MIGRATE)")
 	flag.Parse()
 
 	log.Println("Genesis API Server starting...")
@@ -37,15 +53,96 @@ func main() {
 	defer pool.Close()
 	log.Println("Connected to PostgreSQL")
 
-	// Run migrations
-	if err := storage.Migrate(ctx, pool); err != nil {
+	// Run or check migrations
+	if *migrate != "" {
+		cfg.DB.Migrate = *migrate
+	}
+	if err := storage.PrepareSchema(ctx, pool, cfg.DB.Migrate); err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}
 
 	// Create dependencies
 	repo := storage.NewPaperRepository(pool)
-	client := arxiv.NewClient()
-	handler := api.NewHandler(repo, client)
+	repo.ChunkSize = cfg.DB.SaveChunkSize
+	if cfg.Pipeline.StripVersions {
+		renamed, deleted, err := repo.FoldVersions(ctx)
+		if err != nil {
+			log.Fatalf("Failed to fold versioned IDs: %v", err)
+		}
+		if renamed > 0 || deleted > 0 {
+			log.Printf("Moved %d papers to versionless IDs, deleted %d older versions", renamed, deleted)
+		}
+	}
+	syncRepo := storage.NewSyncRepository(pool)
+	client := arxiv.New(
+		arxiv.WithUserAgent(cfg.Pipeline.UserAgent),
+		arxiv.WithRateLimit(cfg.Pipeline.ProviderIntervals[model.SourceArxiv]),
+	)
+	client.StripVersions = cfg.Pipeline.StripVersions
+	client.CacheTTL = time.Duration(cfg.Pipeline.CacheTTLMinutes) * time.Minute
+	queue := syncqueue.New(syncqueue.Config{
+		MaxConcurrent: cfg.Sync.MaxConcurrent,
+		MaxDepth:      cfg.Sync.QueueDepth,
+		MaxFinished:   cfg.Sync.JobHistory,
+		FinishedTTL:   time.Duration(cfg.Sync.JobTTLMinutes) * time.Minute,
+		OnCancel: func(job *syncqueue.Job) {
+			if err := syncRepo.RecordCancelled(context.Background(), job.Query); err != nil {
+				log.Printf("Failed to record cancelled sync: %v", err)
+			}
+		},
+	})
+	handler := api.NewHandler(repo, client, queue)
+	handler.Providers = parser.Default
+	handler.ProviderOptions = parser.Options{
+		AnnounceCategories: cfg.Pipeline.AnnounceCategories,
+		UserAgent:          cfg.Pipeline.UserAgent,
+		StripVersions:      cfg.Pipeline.StripVersions,
+		Keys:               cfg.Pipeline.ProviderKeys,
+		Intervals:          cfg.Pipeline.ProviderIntervals,
+		MaxAge:             time.Duration(cfg.Pipeline.DefaultMaxAge) * 24 * time.Hour,
+	}
+	handler.SyncForm = cfg.UI.SyncForm
+	handler.History = syncRepo
+	handler.ShadowLog = storage.NewShadowRepository(pool)
+	handler.Audit = audit.NewRecorder(storage.NewAuditRepository(pool))
+	handler.APIKeys = cfg.API.Keys
+	handler.AdminToken = cfg.API.AdminToken
+	if cfg.API.FeedSecret != "" {
+		handler.Watchlists = storage.NewWatchlistRepository(pool)
+		handler.FeedSecret = cfg.API.FeedSecret
+	}
+	if days := cfg.Audit.RetentionDays; days > 0 {
+		go handler.Audit.PruneEvery(ctx, time.Duration(days)*24*time.Hour, 24*time.Hour)
+	}
+	handler.MinScore = cfg.Pipeline.DefaultMinScore
+	handler.MaxAge = time.Duration(cfg.Pipeline.DefaultMaxAge) * 24 * time.Hour
+	handler.Settings = settings.New(storage.NewSettingsRepository(pool), settings.FromConfig(cfg))
+	if err := handler.Settings.Load(ctx); err != nil {
+		log.Fatalf("Failed to load settings: %v", err)
+	}
+	handler.PageTiers = cfg.Filter.PageTiers
+	handler.MaxText = cfg.Filter.MaxText
+	handler.NearDuplicates = cfg.Pipeline.NearDuplicateDistance
+	handler.Limits.MaxAbstract = cfg.Pipeline.MaxAbstractLength
+	handler.Requests = syncRepo
+	handler.RequestRetention = time.Duration(cfg.Sync.RequestRetentionDays) * 24 * time.Hour
+	handler.Notify = notify.NewFromConfig(cfg.Notify, handler.Settings.NotifyLimits)
+	if cfg.Filter.ShadowRules != "" {
+		shadow, err := pipeline.LoadShadowRules(cfg.Filter.ShadowRules, cfg.Filter.ShadowMaxDelta)
+		if err != nil {
+			log.Fatalf("Failed to load shadow rules: %v", err)
+		}
+		handler.Shadow = shadow
+		log.Printf("Shadow mode: scoring syncs with candidate rules %q", shadow.Name)
+	}
+
+	if cfg.Pipeline.PresetsFile != "" {
+		if err := preset.LoadFile(cfg.Pipeline.PresetsFile); err != nil {
+			log.Fatalf("Failed to load presets: %v", err)
+		}
+		handler.PresetsFile = cfg.Pipeline.PresetsFile
+		go reloadPresetsOnHUP(cfg.Pipeline.PresetsFile, handler.Audit)
+	}
 
 	// Setup routes
 	mux := http.NewServeMux()
@@ -61,7 +158,10 @@ func main() {
 	}
 
 	// Graceful shutdown
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
+
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
@@ -73,24 +173,80 @@ func main() {
 		if err := server.Shutdown(ctx); err != nil {
 			log.Printf("Server shutdown error: %v", err)
 		}
+
+		// Let running syncs finish; queued ones are logged as cancelled
+		if err := queue.Shutdown(ctx); err != nil {
+			log.Printf("Sync queue shutdown error: %v", err)
+		}
+		if handler.Notify != nil {
+			if err := handler.Notify.Shutdown(ctx); err != nil {
+				log.Printf("Notification queue shutdown error: %v", err)
+			}
+		}
 	}()
 
-	log.Printf("API server listening on http://localhost:%s", *port)
+	log.Printf("API server %s listening on http://localhost:%s", version.String(), *port)
 	log.Println("Endpoints:")
+	log.Println("  GET  /                 - Web UI")
 	log.Println("  GET  /api/papers       - List papers")
-	log.Println("  GET  /api/papers/:id   - Get paper by ID")
+	log.Println("  GET  /api/papers/:id   - Get paper by ID with score explanation")
+	log.Println("  GET  /api/papers/:id/pdf - Locally archived PDF")
+	log.Println("  GET  /api/papers/:id/diff?from=&to= - Abstract diff between versions")
 	log.Println("  GET  /api/papers/search?q= - Search papers")
 	log.Println("  GET  /api/stats        - Pipeline statistics")
+	log.Println("  GET  /api/stats/ingestion?days=&bucket=day|week - Papers, abstract bytes and syncs per day or week")
+	log.Println("  GET  /api/categories?group=primary - Stored categories with paper counts")
+	log.Println("  GET  /api/export?format=csv|jsonl - Stream papers (resumable)")
+	log.Println("  GET  /api/feed.atom    - Newest papers as an Atom feed")
 	log.Println("  POST /api/sync         - Trigger sync")
+	log.Println("  GET  /api/sync/jobs/:id - Sync job status")
+	log.Println("  GET  /api/sync/history - Recent syncs with stage timings")
+	log.Println("  GET  /api/sync/:id/requests - HTTP requests made by a sync")
+	log.Println("  GET  /api/filter/shadow-report - Shadow rule set divergences")
+	log.Println("  POST /api/presets/reload - Re-read the presets file (also on SIGHUP)")
+	log.Println("  GET  /api/presets/:name/estimate - Matches per window and week of a preset")
+	log.Println("  GET  /api/presets/compare?a=&b= - Overlap of two presets' matches")
+	if cfg.API.AdminToken != "" {
+		log.Println("  GET  /api/admin/audit?limit=&action= - Audit log of mutating operations (admin token)")
+		log.Println("  GET, PUT /api/settings - Runtime defaults over the configuration (admin token)")
+	}
+	if cfg.API.FeedSecret != "" {
+		log.Println("  GET  /api/watchlists/:id/feed.atom?token= - Watchlist hits as Atom (also feed.json)")
+		if cfg.API.AdminToken != "" {
+			log.Println("  GET, POST /api/watchlists - Watchlists and their feed tokens (admin token)")
+		}
+	}
+	log.Println("  GET  /api/version      - Build of the running server")
 	log.Println("  GET  /health           - Health check")
 
 	if err := server.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatalf("Server error: %v", err)
 	}
+	<-stopped
 
 	log.Println("Server stopped")
 }
 
+// reloadPresetsOnHUP re-reads the presets file whenever the process
+// receives SIGHUP. A file that fails to load keeps the current presets.
+func reloadPresetsOnHUP(path string, rec *audit.Recorder) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		entry := storage.AuditEntry{Actor: audit.ActorSignal, Action: audit.ActionPresetsReload, Target: path, Params: "SIGHUP"}
+		err := preset.LoadFile(path)
+		if err != nil {
+			entry.Params += fmt.Sprintf(" error=%q", err)
+		}
+		rec.Record(context.Background(), entry)
+		if err != nil {
+			log.Printf("Presets reload failed: %v", err)
+			continue
+		}
+		log.Printf("Reloaded presets from %s", path)
+	}
+}
+
 func logMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -7,12 +7,16 @@ import (
 	"time"
 
 	"github.com/1psychoQAQ/genesis-pipeline/internal/benchmark"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/providertest"
 )
 
 func main() {
 	query := flag.String("query", "machine learning", "Search query for ArXiv")
 	limit := flag.Int("limit", 50, "Number of papers to fetch")
+	offline := flag.Bool("offline", false, "Fetch synthetic papers from a mock provider instead of ArXiv")
+	latency := flag.Duration("latency", 0, "Delay of each mock fetch with -offline, e.g. 500ms")
 	flag.Parse()
 
 	log.Println("Starting benchmark...")
@@ -20,8 +24,16 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	client := arxiv.NewClient()
-	runner := benchmark.NewRunner(client)
+	metrics := &arxiv.Metrics{}
+	var provider parser.Provider = arxiv.New(arxiv.WithHooks(metrics))
+	if *offline {
+		provider = &providertest.Mock{Papers: providertest.SamplePapers(*limit), Latency: *latency}
+		log.Printf("Offline: %d synthetic papers, %v latency", *limit, *latency)
+	}
+	runner := benchmark.NewRunner(provider)
+	if !*offline {
+		runner.Metrics = metrics
+	}
 
 	report, err := runner.GenerateReport(ctx, *query, *limit)
 	if err != nil {
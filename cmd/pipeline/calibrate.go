@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/calibrate"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/openalex"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// runCalibrate reports how original scores line up with later outcome
+// signals and returns the exit code.
+func runCalibrate(args []string) int {
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	months := fs.Int("months", 6, "Only check papers stored at least this many months ago")
+	limit := fs.Int("limit", 1000, "Maximum number of papers to check")
+	statePath := fs.String("state", "calibrate-state.json", "File recording fetched outcomes so interrupted runs resume")
+	minCitations := fs.Int("min-citations", 10, "OpenAlex citations that count as a positive outcome")
+	batch := fs.Int("batch", 50, "Papers per arXiv lookup")
+	interval := fs.Duration("interval", 3*time.Second, "Minimum delay between lookups")
+	keep := fs.Float64("keep", calibrate.DefaultKeep, "Share of positive papers (0-1) a suggested min_score must keep")
+	asJSON := fs.Bool("json", false, "Print machine-readable results")
+	fs.Parse(args)
+	if *keep <= 0 || *keep > 1 {
+		log.Printf("-keep must be in (0, 1], got %v", *keep)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Hour)
+	defer cancel()
+
+	pool, err := storage.NewPool(ctx, cfg.DB)
+	if err != nil {
+		log.Printf("Database connection failed: %v", err)
+		return 1
+	}
+	defer pool.Close()
+
+	if err := storage.PrepareSchema(ctx, pool, cfg.DB.Migrate); err != nil {
+		log.Printf("Migration failed: %v", err)
+		return 1
+	}
+
+	cutoff := time.Now().AddDate(0, -*months, 0)
+	papers, err := storage.NewPaperRepository(pool).ListStoredBefore(ctx, cutoff, *limit)
+	if err != nil {
+		log.Printf("Failed to list papers: %v", err)
+		return 1
+	}
+
+	state, err := calibrate.LoadState(*statePath)
+	if err != nil {
+		log.Printf("Failed to load state: %v", err)
+		return 1
+	}
+
+	// Citation counts come from OpenAlex, which needs no key
+	citations := openalex.NewClient()
+	citations.UserAgent = cfg.Pipeline.UserAgent
+	citations.APIKey = cfg.Pipeline.ProviderKeys[model.SourceOpenAlex]
+	citations.Interval = cfg.Pipeline.ProviderIntervals[model.SourceOpenAlex]
+	collector := calibrate.NewCollector(newArxivClient(cfg), citations)
+	collector.BatchSize = *batch
+	collector.Interval = *interval
+
+	log.Printf("Checking outcomes for %d papers stored before %s (%d already fetched)",
+		len(papers), cutoff.Format("2006-01-02"), len(state.Outcomes))
+	if err := collector.Collect(ctx, papers, state); err != nil {
+		log.Printf("Outcome fetch stopped: %v (rerun to resume from %s)", err, *statePath)
+		return 1
+	}
+
+	outcomes := make([]calibrate.Outcome, 0, len(papers))
+	for _, p := range papers {
+		outcomes = append(outcomes, state.Outcomes[p.ID])
+	}
+	report := calibrate.BuildReport(outcomes, nil, *minCitations, time.Now())
+	if rec, ok := calibrate.Recommend(outcomes, *minCitations, *keep); ok {
+		report.Recommendation = &rec
+	}
+
+	if *asJSON {
+		if err := report.WriteJSON(os.Stdout); err != nil {
+			log.Printf("Failed to write report: %v", err)
+			return 1
+		}
+	} else {
+		report.WriteText(os.Stdout)
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/doctor"
+)
+
+// runDoctor checks configuration and connectivity and returns the exit code.
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	withLLM := fs.Bool("with-llm", false, "Also send a trivial prompt to the LLM provider")
+	asJSON := fs.Bool("json", false, "Print machine-readable results")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	checks := []doctor.Checker{
+		doctor.ConfigCheck{Load: config.Load},
+	}
+
+	// Later checks need a config; fall back to defaults if it failed to load
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = &config.Config{}
+	}
+	checks = append(checks,
+		doctor.DatabaseCheck{Config: cfg.DB},
+		doctor.ProviderCheck{Label: "arxiv", Provider: newArxivClient(cfg)},
+		doctor.LLMCheck{Enabled: *withLLM, Config: cfg.Gemini},
+		doctor.WritableDirCheck{Dirs: []string{cfg.Pipeline.PDFDir, cfg.Output.Dir}},
+		doctor.VersionCheck{},
+	)
+
+	report := doctor.Run(ctx, checks)
+
+	if *asJSON {
+		if err := report.WriteJSON(os.Stdout); err != nil {
+			log.Printf("Failed to write report: %v", err)
+		}
+	} else {
+		report.WriteText(os.Stdout)
+	}
+
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/archive"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/audit"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// runDownload archives PDFs of high-scoring stored papers and returns the exit code.
+func runDownload(args []string) int {
+	fs := flag.NewFlagSet("download", flag.ExitOnError)
+	minScore := fs.Int("min-score", 80, "Minimum score of papers to download")
+	dir := fs.String("dir", "", "Directory for downloaded PDFs (default: PDF_DIR)")
+	limit := fs.Int("limit", 100, "Maximum number of papers to download")
+	concurrency := fs.Int("concurrency", 2, "Parallel downloads")
+	interval := fs.Duration("interval", time.Second, "Minimum delay between requests")
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+
+	if *dir == "" {
+		*dir = cfg.Pipeline.PDFDir
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	pool, err := storage.NewPool(ctx, cfg.DB)
+	if err != nil {
+		log.Printf("Database connection failed: %v", err)
+		return 1
+	}
+	defer pool.Close()
+
+	if err := storage.PrepareSchema(ctx, pool, cfg.DB.Migrate); err != nil {
+		log.Printf("Migration failed: %v", err)
+		return 1
+	}
+
+	downloader := archive.NewDownloader(*dir)
+	downloader.Concurrency = *concurrency
+	downloader.Interval = *interval
+
+	log.Printf("Downloading PDFs for papers with score >= %d into %s", *minScore, *dir)
+	results, err := archive.Archive(ctx, storage.NewPaperRepository(pool), downloader, *minScore, *limit)
+	entry := storage.AuditEntry{
+		Actor:  audit.ActorCLI,
+		Action: audit.ActionDownload,
+		Target: *dir,
+		Params: fmt.Sprintf("min_score=%d limit=%d papers=%d", *minScore, *limit, len(results)),
+	}
+	if err != nil {
+		entry.Params += fmt.Sprintf(" error=%q", err)
+	}
+	audit.NewRecorder(storage.NewAuditRepository(pool)).Record(ctx, entry)
+	if err != nil {
+		log.Printf("Download failed: %v", err)
+		return 1
+	}
+
+	var downloaded, skipped, failed int
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+			log.Printf("  ✗ %v", r.Err)
+		case r.Skipped:
+			skipped++
+		default:
+			downloaded++
+			log.Printf("  ✓ %s (%d bytes)", r.Path, r.Size)
+		}
+	}
+	log.Printf("Downloaded %d, already archived %d, failed %d", downloaded, skipped, failed)
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// downloadPassed saves the PDFs of a sync's passing papers into dir
+// through the provider each paper came from, so downloads share its rate
+// limit, and records each file in store when there is one. Papers from
+// providers without a PDF source are skipped. Failures are logged and do
+// not fail the sync.
+func downloadPassed(providers map[string]parser.Provider, store storage.PDFArchive, papers []model.Paper, dir string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	log.Printf("Downloading %d PDFs into %s", len(papers), dir)
+	var failed, skipped int
+	for _, p := range papers {
+		downloader, ok := providers[p.Source].(parser.PDFDownloader)
+		if !ok {
+			skipped++
+			continue
+		}
+		path, err := downloader.DownloadPDF(ctx, p, dir)
+		if err != nil {
+			failed++
+			log.Printf("  ✗ %v", err)
+			continue
+		}
+		log.Printf("  ✓ %s", path)
+		if store == nil {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err == nil {
+			err = store.SetPDF(ctx, p.ID, path, info.Size())
+		}
+		if err != nil {
+			failed++
+			log.Printf("  ✗ record PDF of %s: %v", p.ID, err)
+		}
+	}
+	if skipped > 0 {
+		log.Printf("Skipped %d papers whose provider has no PDF source", skipped)
+	}
+	if failed > 0 {
+		log.Printf("%d of %d PDFs failed to download", failed, len(papers))
+	}
+}
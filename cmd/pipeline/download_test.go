@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/providertest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+// pdfProvider writes a PDF named after each paper it is asked for.
+type pdfProvider struct {
+	*providertest.Mock
+	asked []string
+}
+
+func (p *pdfProvider) DownloadPDF(ctx context.Context, paper model.Paper, dir string) (string, error) {
+	p.asked = append(p.asked, paper.ID)
+	path := filepath.Join(dir, paper.ID+".pdf")
+	return path, os.WriteFile(path, []byte("%PDF-1.5"), 0o644)
+}
+
+func TestDownloadPassed(t *testing.T) {
+	papers := []model.Paper{
+		{ID: "2401.00001", Title: "From the search API", Source: model.SourceArxiv},
+		{ID: "W1000000001", Title: "From OpenAlex", Source: model.SourceOpenAlex},
+	}
+	store := memory.New()
+	if err := store.SaveBatch(context.Background(), papers); err != nil {
+		t.Fatal(err)
+	}
+	arxiv := &pdfProvider{}
+	providers := map[string]parser.Provider{
+		model.SourceArxiv:    arxiv,
+		model.SourceOpenAlex: &providertest.Mock{}, // No PDF source
+	}
+	dir := t.TempDir()
+
+	downloadPassed(providers, store, papers, dir)
+
+	if len(arxiv.asked) != 1 || arxiv.asked[0] != "2401.00001" {
+		t.Errorf("arXiv provider asked for %v, want only its own paper", arxiv.asked)
+	}
+	path, size, err := store.GetPDF(context.Background(), "2401.00001")
+	if err != nil || path != filepath.Join(dir, "2401.00001.pdf") || size != int64(len("%PDF-1.5")) {
+		t.Errorf("recorded PDF = %q, %d, %v", path, size, err)
+	}
+	if _, _, err := store.GetPDF(context.Background(), "W1000000001"); err == nil {
+		t.Error("a PDF was recorded for the paper without a PDF source")
+	}
+}
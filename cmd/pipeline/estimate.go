@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/estimate"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+)
+
+// runEstimate reports how many papers a preset's query matches per window
+// and per week, without fetching or saving papers, and returns the exit
+// code.
+func runEstimate(args []string) int {
+	fs := flag.NewFlagSet("estimate", flag.ExitOnError)
+	presetName := fs.String("preset", "", "Preset whose query is counted")
+	windowFlag := fs.String("window", "30d", "Window length: days (30d), weeks (2w) or a duration (36h)")
+	windows := fs.Int("windows", 1, fmt.Sprintf("Consecutive windows to count, newest first (1-%d)", estimate.MaxWindows))
+	fs.Parse(args)
+	if *presetName == "" {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+	if cfg.Pipeline.PresetsFile != "" {
+		if err := preset.LoadFile(cfg.Pipeline.PresetsFile); err != nil {
+			log.Printf("Failed to load presets: %v", err)
+			return 1
+		}
+	}
+	p, ok := preset.Get(*presetName)
+	if !ok {
+		log.Printf("Unknown preset %q (see -list-presets)", *presetName)
+		return 2
+	}
+	window, err := estimate.ParseWindow(*windowFlag)
+	if err != nil {
+		log.Printf("-window: %v", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	result, err := estimate.Run(ctx, newArxivClient(cfg), p.Query, window, *windows, time.Now())
+	if err != nil {
+		log.Printf("Estimate failed: %v", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stdout, "Preset %s: %q\n", p.Name, result.Query)
+	for _, w := range result.Windows {
+		fmt.Fprintf(os.Stdout, "  %s – %s  %6d matches\n", w.From.Format("2006-01-02"), w.To.Format("2006-01-02"), w.Matches)
+	}
+	fmt.Fprintf(os.Stdout, "  ≈ %.1f papers per week\n", result.PerWeek)
+	return 0
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/export"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/output"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// runExport writes every stored paper as CSV or JSON Lines and returns the
+// exit code. With -resume-from it continues a partial export in place.
+// Without -o it writes to OUTPUT_DIR when that is set, else to stdout.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "", "Output format: csv or jsonl (default: from the file extension, else jsonl)")
+	out := fs.String("o", "", "Output file (default: a new file in OUTPUT_DIR if set, else stdout)")
+	resumeFrom := fs.String("resume-from", "", "Partial export to continue; a torn last record is dropped and rewritten")
+	fs.Parse(args)
+
+	path := *out
+	if *resumeFrom != "" {
+		if path != "" && path != *resumeFrom {
+			log.Printf("-o and -resume-from name different files")
+			return 2
+		}
+		path = *resumeFrom
+	}
+	if *format == "" {
+		*format = export.FormatJSONL
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			*format = export.FormatCSV
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	pool, err := storage.NewPool(ctx, cfg.DB)
+	if err != nil {
+		log.Printf("Database connection failed: %v", err)
+		return 1
+	}
+	defer pool.Close()
+
+	if err := storage.PrepareSchema(ctx, pool, cfg.DB.Migrate); err != nil {
+		log.Printf("Migration failed: %v", err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	var after storage.Cursor
+	header := true
+
+	// Exports into OUTPUT_DIR are buffered and stored once complete, so a
+	// failed run leaves no partial file behind
+	var ow *output.Writer
+	var buf bytes.Buffer
+	if path == "" && cfg.Output.Dir != "" {
+		ow, err = output.NewWriter(output.NewFSTarget(cfg.Output.Dir), cfg.Output.ExportName)
+		if err != nil {
+			log.Printf("OUTPUT_EXPORT_NAME: %v", err)
+			return 2
+		}
+		ow.Retention = cfg.Output.Retention
+		w = &buf
+	}
+
+	switch {
+	case *resumeFrom != "":
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			log.Printf("Failed to open partial export: %v", err)
+			return 1
+		}
+		defer f.Close()
+
+		pos, err := export.Resume(f, *format)
+		if err != nil {
+			log.Printf("Failed to read partial export: %v", err)
+			return 1
+		}
+		if err := f.Truncate(pos.Offset); err != nil {
+			log.Printf("Failed to drop torn record: %v", err)
+			return 1
+		}
+		if _, err := f.Seek(pos.Offset, io.SeekStart); err != nil {
+			log.Printf("Failed to seek: %v", err)
+			return 1
+		}
+		after, header, w = pos.After, pos.Offset == 0, f
+		log.Printf("Resuming %s after %d records", path, pos.Records)
+	case path != "":
+		f, err := os.Create(path)
+		if err != nil {
+			log.Printf("Failed to create export: %v", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	ew, err := export.NewWriter(w, *format, header)
+	if err != nil {
+		log.Printf("%v", err)
+		return 2
+	}
+
+	n := 0
+	err = storage.NewPaperRepository(pool).StreamPapers(ctx, after, func(p model.Paper) error {
+		if err := ew.Write(p); err != nil {
+			return err
+		}
+		n++
+		// Flush regularly so an interrupted export keeps what it wrote
+		if n%1000 == 0 {
+			return ew.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = ew.Flush()
+	}
+	if err != nil {
+		hint := " (continue with -resume-from)"
+		if ow != nil {
+			hint = "" // Nothing was stored
+		}
+		log.Printf("Export stopped after %d papers: %v%s", n, err, hint)
+		return 1
+	}
+
+	if ow != nil {
+		fields := output.NewPathData(time.Now())
+		fields.Format = *format
+		name, err := ow.Write(ctx, fields, buf.Bytes())
+		if err != nil {
+			log.Printf("Failed to store export: %v", err)
+			return 1
+		}
+		log.Printf("Exported %d papers to %s", n, filepath.Join(cfg.Output.Dir, filepath.FromSlash(name)))
+		return 0
+	}
+	log.Printf("Exported %d papers", n)
+	return 0
+}
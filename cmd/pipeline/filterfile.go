@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/audit"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/console"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/export"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/notify"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/validation"
+)
+
+// runFilterFile validates and filters the papers of a local JSON Lines
+// file like a sync, optionally saving those that pass, and returns the
+// exit code.
+func runFilterFile(args []string) int {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("filter-file", flag.ExitOnError)
+	minScore := fs.Int("min-score", cfg.Pipeline.DefaultMinScore, "Minimum score threshold (0-100)")
+	save := fs.Bool("save", false, "Save the papers that pass to the database")
+	width := fs.Int("width", 0, "Output width in columns (0 = terminal width, or 80 when not a terminal)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pipeline filter-file <papers.jsonl> [-min-score N] [-save]")
+		fs.PrintDefaults()
+	}
+	// Flags may come before or after the file name
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	path := fs.Arg(0)
+	fs.Parse(fs.Args()[1:])
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("Failed to open input: %v", err)
+		return 1
+	}
+	papers, bad, err := export.ReadJSONL(f)
+	f.Close()
+	if err != nil {
+		log.Printf("Failed to read %s: %v", path, err)
+		return 1
+	}
+	for _, e := range bad {
+		log.Printf("Skipping %s:%d: %v", path, e.Line, e.Err)
+	}
+
+	source := model.SourceFile + filepath.Base(path)
+	if len(source) > validation.MaxSourceLength {
+		source = source[:validation.MaxSourceLength]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	svc := pipeline.NewService(map[string]parser.Provider{source: fileProvider(papers)}, nil)
+	svc.PageTiers = cfg.Filter.PageTiers
+	svc.MaxText = cfg.Filter.MaxText
+	svc.NearDuplicates = cfg.Pipeline.NearDuplicateDistance
+	svc.Limits.MaxAbstract = cfg.Pipeline.MaxAbstractLength
+
+	var auditLog *audit.Recorder
+	if *save {
+		pool, err := storage.NewPool(ctx, cfg.DB)
+		if err != nil {
+			log.Printf("Database connection failed: %v", err)
+			return 1
+		}
+		defer pool.Close()
+		if err := storage.PrepareSchema(ctx, pool, cfg.DB.Migrate); err != nil {
+			log.Printf("Migration failed: %v", err)
+			return 1
+		}
+		repo := storage.NewPaperRepository(pool)
+		repo.ChunkSize = cfg.DB.SaveChunkSize
+		svc.Store = repo
+		svc.History = storage.NewSyncRepository(pool)
+		svc.Notify = notify.NewFromConfig(cfg.Notify, nil)
+		auditLog = audit.NewRecorder(storage.NewAuditRepository(pool))
+	}
+
+	params := pipeline.RunParams{
+		Provider: source,
+		Query:    source,
+		MinScore: *minScore,
+		SkipSave: !*save,
+	}
+	result, err := svc.Run(ctx, params)
+	logRun(result, params)
+	if auditLog != nil {
+		auditRun(ctx, auditLog, params, result, err)
+	}
+	if err != nil {
+		log.Printf("Filtering %s failed: %v", path, err)
+		return 1
+	}
+
+	renderer := console.NewRenderer(os.Stdout)
+	renderer.Width = *width
+	if *width <= 0 {
+		renderer.Width = console.TerminalWidth(os.Stdout)
+	}
+	out := renderer.Begin()
+	out.FilterResults(result.FilterResults, result.Passed, false)
+	if *save {
+		out.Updates(result.Updated)
+	}
+	if err := out.Flush(); err != nil {
+		log.Printf("Failed to print results: %v", err)
+	}
+	if len(bad) > 0 {
+		log.Printf("Skipped %d malformed lines of %s", len(bad), path)
+	}
+	flushNotifications(svc.Notify)
+	return 0
+}
+
+// fileProvider serves the papers read from a file, whatever the query.
+type fileProvider []model.Paper
+
+func (p fileProvider) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	if limit > 0 && limit < len(p) {
+		return p[:limit], nil
+	}
+	return p, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Dependencies a sync flag can need.
+const (
+	needDB     = "the database"
+	needLLM    = "an LLM"
+	needSearch = "the arxiv search provider"
+)
+
+// flagNeeds maps sync flags to the dependencies they need.
+var flagNeeds = map[string][]string{
+	"diff-last":   {needDB},
+	"incremental": {needDB},
+	"question":    {needLLM},
+	"sort":        {needSearch},
+}
+
+// listFlag collects the values of a repeatable flag; each value may also
+// be a comma-separated list.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// usedFlags returns the flags of fs given on the command line, except
+// booleans turned off, in lexical order.
+func usedFlags(fs *flag.FlagSet) []string {
+	var used []string
+	fs.Visit(func(f *flag.Flag) {
+		if v := f.Value.String(); v != "false" && v != "" {
+			used = append(used, f.Name)
+		}
+	})
+	return used
+}
+
+// checkFlags reports every used flag that needs a dependency listed in
+// unavailable (dependency -> why it is missing), all in one error.
+func checkFlags(used []string, unavailable map[string]string) error {
+	var conflicts []string
+	for _, name := range used {
+		for _, dep := range flagNeeds[name] {
+			if why, ok := unavailable[dep]; ok {
+				conflicts = append(conflicts, fmt.Sprintf("-%s needs %s, but %s", name, dep, why))
+			}
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	return fmt.Errorf("conflicting flags:\n  %s", strings.Join(conflicts, "\n  "))
+}
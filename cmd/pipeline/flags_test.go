@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestCheckFlags(t *testing.T) {
+	noDB := map[string]string{needDB: "-skip-db is set"}
+	nothing := map[string]string{
+		needDB:     "-skip-db is set",
+		needLLM:    "GEMINI_API_KEY is not set",
+		needSearch: "-provider is arxiv-rss",
+	}
+	tests := []struct {
+		name        string
+		used        []string
+		unavailable map[string]string
+		expected    string
+	}{
+		{name: "no conflicts", used: []string{"diff-last", "question", "sort"}},
+		{name: "flags without needs", used: []string{"limit", "skip-db"}, unavailable: nothing},
+		{
+			name:        "diff with skip-db",
+			used:        []string{"diff-last", "skip-db"},
+			unavailable: noDB,
+			expected:    "conflicting flags:\n  -diff-last needs the database, but -skip-db is set",
+		},
+		{
+			name:        "every conflict",
+			used:        []string{"diff-last", "question", "skip-db", "sort"},
+			unavailable: nothing,
+			expected: "conflicting flags:\n" +
+				"  -diff-last needs the database, but -skip-db is set\n" +
+				"  -question needs an LLM, but GEMINI_API_KEY is not set\n" +
+				"  -sort needs the arxiv search provider, but -provider is arxiv-rss",
+		},
+	}
+
+	for _, tc := range tests {
+		err := checkFlags(tc.used, tc.unavailable)
+		if tc.expected == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("%s: got %v, want %q", tc.name, err, tc.expected)
+		}
+	}
+}
+
+func TestListFlag(t *testing.T) {
+	var l listFlag
+	for _, v := range []string{"cs.AI", "cs.LG, cs.CL", ""} {
+		l.Set(v)
+	}
+	if want := (listFlag{"cs.AI", "cs.LG", "cs.CL"}); !reflect.DeepEqual(l, want) {
+		t.Errorf("listFlag = %v, want %v", l, want)
+	}
+}
+
+func TestUsedFlags(t *testing.T) {
+	fs := flag.NewFlagSet("pipeline", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Bool("skip-db", false, "")
+	fs.Bool("diff-last", false, "")
+	fs.String("question", "", "")
+	fs.Int("limit", 10, "")
+	if err := fs.Parse([]string{"-skip-db", "-diff-last=false", "-question", "", "-limit", "5"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := usedFlags(fs), []string{"limit", "skip-db"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("usedFlags = %v, want %v", got, want)
+	}
+}
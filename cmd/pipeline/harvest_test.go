@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"reflect"
+	"testing"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// chunkRecorder records the IDs of each SaveBatch call.
+type chunkRecorder struct {
+	chunks [][]string
+	ctxErr []error
+}
+
+func (r *chunkRecorder) SaveBatch(ctx context.Context, papers []model.Paper) error {
+	var ids []string
+	for _, p := range papers {
+		ids = append(ids, p.ID)
+	}
+	r.chunks = append(r.chunks, ids)
+	r.ctxErr = append(r.ctxErr, ctx.Err())
+	return nil
+}
+
+// listed yields papers with ids, then err when it is not nil.
+func listed(err error, ids ...string) iter.Seq2[model.Paper, error] {
+	return func(yield func(model.Paper, error) bool) {
+		for _, id := range ids {
+			if !yield(model.Paper{ID: id}, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(model.Paper{}, err)
+		}
+	}
+}
+
+// validUnlessBad drops the paper with ID "bad".
+func validUnlessBad(p model.Paper) (model.Paper, bool) {
+	return p, p.ID != "bad"
+}
+
+func TestHarvestInto(t *testing.T) {
+	store := &chunkRecorder{}
+	stats, err := harvestInto(context.Background(), listed(nil, "1", "2", "bad", "3", "4", "5"), store, 2, validUnlessBad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"1", "2"}, {"3", "4"}, {"5"}}; !reflect.DeepEqual(store.chunks, want) {
+		t.Errorf("chunks = %v, want %v", store.chunks, want)
+	}
+	if want := (harvestStats{Records: 6, Invalid: 1, Saved: 5}); stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestHarvestInto_KeepsChunkOnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	store := &chunkRecorder{}
+	stats, err := harvestInto(ctx, listed(context.Canceled, "1", "2", "3"), store, 2, validUnlessBad)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want the sequence's", err)
+	}
+	if want := [][]string{{"1", "2"}, {"3"}}; !reflect.DeepEqual(store.chunks, want) || stats.Saved != 3 {
+		t.Errorf("chunks = %v (%d saved), want %v", store.chunks, stats.Saved, want)
+	}
+	// The last chunk is saved even though the harvest was interrupted
+	if last := store.ctxErr[len(store.ctxErr)-1]; last != nil {
+		t.Errorf("last chunk saved with a done context: %v", last)
+	}
+}
+
+func TestHarvestInto_WithoutStore(t *testing.T) {
+	stats, err := harvestInto(context.Background(), listed(nil, "1", "2", "3"), nil, 0, validUnlessBad)
+	if err != nil || stats.Saved != 3 || stats.Records != 3 {
+		t.Errorf("stats = %+v, err %v; want 3 counted", stats, err)
+	}
+}
@@ -5,18 +5,59 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"maps"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/1psychoQAQ/genesis-pipeline/internal/audit"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
-	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/console"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/llm"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/notify"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+
+	// Providers add themselves to parser.Default
+	_ "github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxivrss"
+	_ "github.com/1psychoQAQ/genesis-pipeline/internal/parser/biorxiv"
+	_ "github.com/1psychoQAQ/genesis-pipeline/internal/parser/openalex"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:]))
+		case "download":
+			os.Exit(runDownload(os.Args[2:]))
+		case "calibrate":
+			os.Exit(runCalibrate(os.Args[2:]))
+		case "show":
+			os.Exit(runShow(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "estimate":
+			os.Exit(runEstimate(os.Args[2:]))
+		case "filter-file":
+			os.Exit(runFilterFile(os.Args[2:]))
+		case "stats":
+			os.Exit(runStats(os.Args[2:]))
+		case "version":
+			os.Exit(runVersion(os.Args[2:]))
+		case "presets":
+			os.Exit(runPresets(os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		}
+	}
+
 	// Load configuration from .env and environment
 	cfg, err := config.Load()
 	if err != nil {
@@ -29,20 +70,98 @@ func main() {
 	limit := flag.Int("limit", cfg.Pipeline.DefaultLimit, "Number of papers to fetch")
 	minScore := flag.Int("min-score", cfg.Pipeline.DefaultMinScore, "Minimum score threshold (0-100)")
 	maxAgeDays := flag.Int("max-age", cfg.Pipeline.DefaultMaxAge, "Maximum paper age in days (0 = no limit)")
+	ageBy := flag.String("age-by", pipeline.AgeUpdated, "Timestamp -max-age applies to: updated (last revision) or published (first version)")
 	skipDB := flag.Bool("skip-db", false, "Skip database operations")
+	migrate := flag.String("migrate", cfg.DB.Migrate, "Schema on startup: auto (apply pending migrations), check (exit unless up to date) or skip")
 	skipFilter := flag.Bool("skip-filter", false, "Skip quality filtering")
+	providerName := flag.String("provider", model.SourceArxiv, "Paper source: arxiv (search API), arxiv-rss (today's announcements), openalex (OpenAlex works search), biorxiv or medrxiv (latest preprints)")
+	presetName := flag.String("preset", "", "Search preset supplying the query, min score and max age")
+	listPresets := flag.Bool("list-presets", false, "List presets with their resolved values and exit")
+	diffLast := flag.Bool("diff-last", false, "Compare the results with the previous sync of the same query")
+	incremental := flag.Bool("incremental", false, "Fetch only papers updated since the previous sync of the same query started")
+	summaryJSON := flag.String("summary-json", "", "Write a JSON summary of the run to this file (- = stdout, after the results)")
+	width := flag.Int("width", 0, "Output width in columns (0 = terminal width, or 80 when not a terminal)")
+	var categories listFlag
+	flag.Var(&categories, "categories", "Restrict results to arXiv categories, e.g. cs.CL,cs.IR (repeatable)")
+	flag.Var(&categories, "category", "Alias of -categories")
+	strictCategories := flag.Bool("strict-categories", false, "Keep only papers whose primary category is one of -categories")
+	downloadDir := flag.String("download-dir", "", "Download the PDFs of the papers that pass into this directory (default: none)")
+	sortBy := flag.String("sort", "", "arXiv result order: relevance, lastUpdatedDate or submittedDate, optionally with :asc or :desc")
+	harvestCategory := flag.String("harvest", "", "Backfill an arXiv archive or category, e.g. cs.CL, through OAI-PMH instead of searching, saving every valid paper")
+	harvestFrom := flag.String("from", "", "With -harvest, records changed on or after this date, e.g. 2024-01-01")
+	harvestUntil := flag.String("until", "", "With -harvest, records changed on or before this date")
 	flag.Parse()
 
+	if cfg.Pipeline.PresetsFile != "" {
+		if err := preset.LoadFile(cfg.Pipeline.PresetsFile); err != nil {
+			log.Fatalf("Failed to load presets: %v", err)
+		}
+	}
+	if *listPresets {
+		printPresets(os.Stdout)
+		return
+	}
+	if err := storage.ValidateMigrateMode(*migrate); err != nil {
+		log.Fatal(err)
+	}
+	cfg.DB.Migrate = *migrate
+
+	if *harvestCategory != "" {
+		os.Exit(runHarvest(cfg, harvestRequest{
+			Category: *harvestCategory,
+			From:     *harvestFrom,
+			Until:    *harvestUntil,
+			SkipDB:   *skipDB,
+		}))
+	}
+	if *harvestFrom != "" || *harvestUntil != "" {
+		log.Fatal("-from and -until need -harvest")
+	}
+
+	// Fail before any network or database work when a flag needs
+	// something this run will not have
+	unavailable := make(map[string]string)
+	if *skipDB {
+		unavailable[needDB] = "-skip-db is set"
+	}
+	if !cfg.Gemini.IsConfigured() {
+		unavailable[needLLM] = "GEMINI_API_KEY is not set"
+	}
+	if *providerName != model.SourceArxiv {
+		unavailable[needSearch] = "-provider is " + *providerName
+	}
+	if err := checkFlags(usedFlags(flag.CommandLine), unavailable); err != nil {
+		log.Fatal(err)
+	}
+
 	log.Println("Genesis Research Pipeline starting...")
 
+	// A preset supplies defaults; flags given explicitly still win
+	var categoryMinScore map[string]int
+	if *presetName != "" {
+		p, ok := preset.Get(*presetName)
+		if !ok {
+			log.Fatalf("Unknown preset %q (see -list-presets)", *presetName)
+		}
+		set := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if !set["query"] && !set["question"] {
+			*query = p.Query
+		}
+		if !set["min-score"] {
+			*minScore = p.MinScore
+		}
+		if !set["max-age"] {
+			*maxAgeDays = p.MaxAgeDays
+		}
+		categoryMinScore = p.CategoryMinScore
+		log.Printf("Using preset %q", p.Name)
+	}
+
 	// Determine search query
 	searchQuery := *query
 	if *question != "" {
 		// Use LLM to extract keywords from question
-		if !cfg.Gemini.IsConfigured() {
-			log.Fatalf("GEMINI_API_KEY not configured. Please set it in .env file")
-		}
-
 		log.Printf("Processing question: %q", *question)
 		extractor, err := llm.NewKeywordExtractor("gemini", cfg.Gemini)
 		if err != nil {
@@ -62,117 +181,279 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
 
-	// Fetch papers from ArXiv
-	client := arxiv.NewClient()
+	// Select paper source
+	maxAge := time.Duration(*maxAgeDays) * 24 * time.Hour
+	providers := parser.Default.All(parser.Options{
+		AnnounceCategories: cfg.Pipeline.AnnounceCategories,
+		UserAgent:          cfg.Pipeline.UserAgent,
+		StripVersions:      cfg.Pipeline.StripVersions,
+		Keys:               cfg.Pipeline.ProviderKeys,
+		Intervals:          cfg.Pipeline.ProviderIntervals,
+		MaxAge:             maxAge,
+	})
+	if _, ok := providers[*providerName]; !ok {
+		log.Fatalf("Unknown provider %q (expected one of %s)", *providerName, strings.Join(parser.Default.Names(), ", "))
+	}
+	if err := parser.CheckConfig(*providerName, providers[*providerName]); err != nil {
+		log.Fatal(err)
+	}
+	if *providerName == model.SourceArxivRSS {
+		log.Printf("Reading announcements for categories: %v", cfg.Pipeline.AnnounceCategories)
+	}
+	if client, ok := providers[*providerName].(*arxiv.Client); ok {
+		// arXiv's submittedDate is the first version's, so only a cutoff
+		// on the published time can also be applied server-side
+		if maxAge > 0 && *ageBy == pipeline.AgePublished {
+			client.From = time.Now().Add(-maxAge)
+		}
+		if *sortBy != "" {
+			client.Search, err = arxiv.ParseSort(*sortBy)
+			if err != nil {
+				log.Fatalf("Invalid -sort: %v", err)
+			}
+		}
+	}
+	if len(categories) > 0 {
+		log.Printf("Restricting results to categories: %v", []string(categories))
+	}
+	svc := pipeline.NewService(providers, nil)
+	svc.PageTiers = cfg.Filter.PageTiers
+	svc.MaxText = cfg.Filter.MaxText
+	svc.NearDuplicates = cfg.Pipeline.NearDuplicateDistance
+	svc.Limits.MaxAbstract = cfg.Pipeline.MaxAbstractLength
+	if cfg.Filter.ShadowRules != "" {
+		svc.Shadow, err = pipeline.LoadShadowRules(cfg.Filter.ShadowRules, cfg.Filter.ShadowMaxDelta)
+		if err != nil {
+			log.Fatalf("Failed to load shadow rules: %v", err)
+		}
+		log.Printf("Shadow mode: scoring with candidate rules %q", svc.Shadow.Name)
+	}
+
+	// Connect to database
+	var repo *storage.PaperRepository
+	var auditLog *audit.Recorder
+	if !*skipDB {
+		pool, err := storage.NewPool(ctx, cfg.DB)
+		if err != nil {
+			log.Printf("Database connection failed: %v", err)
+			log.Println("Run with -skip-db flag to skip database operations")
+			log.Println("Or start PostgreSQL with: docker-compose -f deployments/docker-compose.yml up -d")
+			return
+		}
+		defer pool.Close()
+		log.Println("Connected to PostgreSQL")
+
+		// Run or check migrations
+		if err := storage.PrepareSchema(ctx, pool, cfg.DB.Migrate); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+
+		repo = storage.NewPaperRepository(pool)
+		repo.ChunkSize = cfg.DB.SaveChunkSize
+		if err := foldVersions(ctx, cfg, repo); err != nil {
+			log.Fatalf("Failed to fold versioned IDs: %v", err)
+		}
+		svc.Store = repo
+		syncRepo := storage.NewSyncRepository(pool)
+		svc.History = syncRepo
+		svc.ShadowLog = storage.NewShadowRepository(pool)
+		svc.Requests = syncRepo
+		svc.RequestRetention = time.Duration(cfg.Sync.RequestRetentionDays) * 24 * time.Hour
+		svc.Notify = notify.NewFromConfig(cfg.Notify, nil)
+		auditLog = audit.NewRecorder(storage.NewAuditRepository(pool))
+	}
+
 	log.Printf("Fetching papers for query: %q", searchQuery)
+	params := pipeline.RunParams{
+		Provider:   *providerName,
+		Query:      searchQuery,
+		Limit:      *limit,
+		MaxAge:     maxAge,
+		AgeBy:      *ageBy,
+		MinScore:   *minScore,
+		SkipFilter: *skipFilter,
 
-	papers, err := client.FetchPapers(searchQuery, *limit)
+		CategoryMinScore: categoryMinScore,
+		Categories:       categories,
+		StrictCategories: *strictCategories,
+		SkipSave:         *skipDB,
+		Diff:             *diffLast,
+		Incremental:      *incremental,
+	}
+	// Harvests of several pages report progress as each page arrives
+	if *limit > arxiv.DefaultPageSize {
+		params.OnPage = func(pageIdx, fetched, total int) {
+			log.Printf("Page %d: fetched %d of %d papers", pageIdx+1, fetched, total)
+		}
+	}
+	result, err := svc.Run(ctx, params)
+	logRun(result, params)
+	if auditLog != nil {
+		auditRun(ctx, auditLog, params, result, err)
+	}
 	if err != nil {
-		log.Fatalf("Failed to fetch papers: %v", err)
+		log.Printf("Pipeline failed: %v", err)
+		flushNotifications(svc.Notify)
+		code := exitCode(err)
+		if err := writeSummary(*summaryJSON, result.Summary(err, code)); err != nil {
+			log.Printf("Failed to write summary: %v", err)
+		}
+		os.Exit(code)
 	}
-	log.Printf("Fetched %d papers from ArXiv", len(papers))
 
-	// Apply time filter (recency)
-	if *maxAgeDays > 0 {
-		cutoff := time.Now().AddDate(0, 0, -*maxAgeDays)
-		var recentPapers []model.Paper
-		for _, p := range papers {
-			if p.UpdatedAt.After(cutoff) {
-				recentPapers = append(recentPapers, p)
-			}
+	renderer := console.NewRenderer(os.Stdout)
+	renderer.Width = *width
+	if *width <= 0 {
+		renderer.Width = console.TerminalWidth(os.Stdout)
+	}
+	out := renderer.Begin()
+	out.FilterResults(result.FilterResults, result.Passed, *skipFilter)
+	if repo != nil {
+		// Show count
+		count, err := repo.Count(ctx, true)
+		if err != nil {
+			log.Printf("Failed to count papers: %v", err)
+		} else {
+			log.Printf("Total papers in database: %d", count)
 		}
-		log.Printf("Time filter: %d/%d papers within %d days", len(recentPapers), len(papers), *maxAgeDays)
-		papers = recentPapers
+		out.Updates(result.Updated)
 	}
-
-	// Apply quality filtering
-	var filteredPapers []model.Paper
-	var filterResults []filter.FilterResult
-	if *skipFilter {
-		filteredPapers = papers
-		log.Println("Skipping quality filter (--skip-filter)")
-	} else {
-		f := filter.NewFilter()
-		f.MinScore = *minScore
-		filterResults = f.Apply(papers)
-		filteredPapers = f.FilterPassed(papers)
-		log.Printf("Quality filter: %d/%d papers passed (min score: %d)", len(filteredPapers), len(papers), *minScore)
+	if err := out.Flush(); err != nil {
+		log.Printf("Failed to print results: %v", err)
+	}
+	log.Printf("Timings: %s", result.Timings)
+	if *downloadDir != "" {
+		var store storage.PDFArchive
+		if repo != nil {
+			store = repo
+		}
+		downloadPassed(providers, store, result.Passed, *downloadDir)
 	}
+	flushNotifications(svc.Notify)
+	if err := writeSummary(*summaryJSON, result.Summary(nil, 0)); err != nil {
+		log.Fatalf("Failed to write summary: %v", err)
+	}
+}
 
-	// Skip database if requested
-	if *skipDB {
-		printFilterResults(filterResults, filteredPapers, *skipFilter)
+// flushNotifications waits a while for queued notifications to be
+// delivered before the process exits.
+func flushNotifications(q *notify.Queue) {
+	if q == nil {
 		return
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := q.Shutdown(ctx); err != nil {
+		log.Printf("Gave up delivering notifications: %v", err)
+	}
+	if ok, failed := q.Delivered(); failed > 0 {
+		log.Printf("Notifications: %d delivered, %d failed", ok, failed)
+	}
+}
 
-	// Connect to database
-	pool, err := storage.NewPool(ctx, cfg.DB)
+// auditRun records a CLI sync in the audit log.
+func auditRun(ctx context.Context, rec *audit.Recorder, p pipeline.RunParams, r pipeline.RunResult, err error) {
+	params := fmt.Sprintf("provider=%s limit=%d min_score=%d max_age=%s skip_filter=%t saved=%d",
+		p.Provider, p.Limit, p.MinScore, p.MaxAge, p.SkipFilter, r.Saved)
 	if err != nil {
-		log.Printf("Database connection failed: %v", err)
-		log.Println("Run with -skip-db flag to skip database operations")
-		log.Println("Or start PostgreSQL with: docker-compose -f deployments/docker-compose.yml up -d")
-		return
+		params += fmt.Sprintf(" error=%q", err)
 	}
-	defer pool.Close()
-	log.Println("Connected to PostgreSQL")
+	rec.Record(ctx, storage.AuditEntry{Actor: audit.ActorCLI, Action: audit.ActionSync, Target: p.Query, Params: params})
+}
 
-	// Run migrations
-	if err := storage.Migrate(ctx, pool); err != nil {
-		log.Fatalf("Migration failed: %v", err)
+// logRun reports how many papers each stage of a run kept.
+func logRun(r pipeline.RunResult, p pipeline.RunParams) {
+	if !r.Since.IsZero() {
+		log.Printf("Incremental: fetched papers updated since %s", r.Since.Format("2006-01-02 15:04"))
 	}
-	log.Println("Database migrated")
-
-	// Save filtered papers
-	repo := storage.NewPaperRepository(pool)
-	if len(filteredPapers) > 0 {
-		if err := repo.SaveBatch(ctx, filteredPapers); err != nil {
-			log.Fatalf("Failed to save papers: %v", err)
-		}
-		log.Printf("Saved %d filtered papers to database", len(filteredPapers))
+	if r.Total > 0 {
+		log.Printf("Fetched %d of %d matching papers from %s", r.Fetched, r.Total, r.Provider)
 	} else {
+		log.Printf("Fetched %d papers from %s", r.Fetched, r.Provider)
+	}
+	for _, name := range slices.Sorted(maps.Keys(r.Sources)) {
+		log.Printf("  %d from %s", r.Sources[name], name)
+	}
+	if r.Repeats > 0 {
+		log.Printf("Skipped %d repeat listings in the feed, keeping the newest of each", r.Repeats)
+	}
+	if n := r.Rejected[pipeline.RejectDuplicate]; n > 0 {
+		log.Printf("Dropped %d duplicate papers", n)
+	}
+	if n := r.Rejected[pipeline.RejectNearDup]; n > 0 {
+		log.Printf("Merged %d papers into near-duplicates with another ID", n)
+	}
+	if n := r.Rejected[pipeline.RejectTooOld]; n > 0 {
+		log.Printf("Time filter: dropped %d papers older than %s", n, p.MaxAge)
+	}
+	if n := r.Rejected[pipeline.RejectInvalid]; n > 0 {
+		log.Printf("Validation: dropped %d papers with incomplete metadata", n)
+	}
+	if r.Truncated > 0 {
+		log.Printf("Validation: truncated %d papers to fit storage limits", r.Truncated)
+	}
+	if p.SkipFilter {
+		log.Println("Skipping quality filter (--skip-filter)")
+	} else if r.FilterResults != nil {
+		log.Printf("Quality filter: %d/%d papers passed (min score: %d)", len(r.Passed), len(r.FilterResults), p.MinScore)
+	}
+	if s := r.Shadow; s != nil {
+		log.Printf("Shadow rules %q: %d/%d papers diverge (%d would pass, %d would fail, %d scores moved)",
+			s.RuleSet, len(s.Divergences), s.Evaluated, s.ToPass, s.ToFail, s.ScoreMoved)
+	}
+	if p.SkipSave {
+		return
+	}
+	switch {
+	case r.Partial:
+		log.Printf("Save stopped part-way: %d of %d papers committed", r.Saved, len(r.Passed))
+	case r.Saved > 0:
+		log.Printf("Saved %d filtered papers to database (%d new, %d new versions, %d unchanged)",
+			r.Saved, len(r.New), len(r.Updated), r.Unchanged)
+	case len(r.Passed) == 0:
 		log.Println("No papers passed the filter, nothing saved")
 	}
-
-	// Show count
-	count, err := repo.Count(ctx)
-	if err != nil {
-		log.Fatalf("Failed to count papers: %v", err)
+	if n := r.Notifications; n != nil {
+		log.Printf("Notifications: %d messages queued for %d papers (%d over the cap summarised, %d messages dropped)",
+			n.Sent, n.Batched, n.Overflow, n.Dropped)
 	}
-	log.Printf("Total papers in database: %d", count)
-
-	printFilterResults(filterResults, filteredPapers, *skipFilter)
-}
-
-func printFilterResults(results []filter.FilterResult, passed []model.Paper, skipFilter bool) {
-	fmt.Println("")
-	fmt.Println("════════════════════════════════════════════════════════════════")
-
-	if skipFilter {
-		// No filter applied, just print papers
-		fmt.Printf("  📚 Fetched %d papers (filter skipped):\n", len(passed))
-		fmt.Println("════════════════════════════════════════════════════════════════")
-		for i, p := range passed {
-			fmt.Printf("\n[%d] %s\n", i+1, p.Title)
-			fmt.Printf("    Authors: %v\n", p.Authors)
-			fmt.Printf("    📄 Abstract: https://arxiv.org/abs/%s\n", p.ID)
-			fmt.Printf("    📥 PDF:      https://arxiv.org/pdf/%s.pdf\n", p.ID)
+	if d := r.Diff; d != nil {
+		log.Printf("Since sync #%d (%s): %d new, %d disappeared, %d scores changed",
+			d.PreviousID, d.PreviousAt.Format("2006-01-02 15:04"), len(d.New), len(d.Disappeared), len(d.ScoreChanged))
+		for _, id := range d.New {
+			log.Printf("  + %s", id)
 		}
-	} else {
-		// Only show papers that passed the filter
-		fmt.Printf("  📚 Filter Results: %d/%d papers passed\n", len(passed), len(results))
-		fmt.Println("════════════════════════════════════════════════════════════════")
-
-		for i, p := range passed {
-			fmt.Printf("\n[%d] ✅ %s\n", i+1, p.Title)
-			fmt.Printf("    Score: %d/100 | Updated: %s\n", p.Score, p.UpdatedAt.Format("2006-01-02"))
-			if len(p.ScoreDetails) > 0 {
-				fmt.Printf("    Details: %s\n", strings.Join(p.ScoreDetails, ", "))
-			}
-			fmt.Printf("    📄 Abstract: https://arxiv.org/abs/%s\n", p.ID)
-			fmt.Printf("    📥 PDF:      https://arxiv.org/pdf/%s.pdf\n", p.ID)
+		for _, id := range d.Disappeared {
+			log.Printf("  - %s", id)
+		}
+		for _, c := range d.ScoreChanged {
+			log.Printf("  ~ %s: score %d -> %d", c.ID, c.Old, c.New)
 		}
 	}
+}
 
-	fmt.Println("")
-	fmt.Println("════════════════════════════════════════════════════════════════")
+// newArxivClient returns an arXiv API client with the configured
+// User-Agent and ID versions.
+func newArxivClient(cfg *config.Config) *arxiv.Client {
+	client := arxiv.New(arxiv.WithUserAgent(cfg.Pipeline.UserAgent))
+	client.StripVersions = cfg.Pipeline.StripVersions
+	return client
+}
+
+// foldVersions moves papers stored under versioned IDs to their base IDs
+// when version stripping is on, so that stores saved before it was turned
+// on do not keep a row per version. The store folds only once, on the
+// first start with stripping on.
+func foldVersions(ctx context.Context, cfg *config.Config, repo storage.VersionFolder) error {
+	if !cfg.Pipeline.StripVersions {
+		return nil
+	}
+	renamed, deleted, err := repo.FoldVersions(ctx)
+	if err != nil {
+		return err
+	}
+	if renamed > 0 || deleted > 0 {
+		log.Printf("Moved %d papers to versionless IDs, deleted %d older versions", renamed, deleted)
+	}
+	return nil
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// runMigrate applies pending schema migrations, or prints their SQL with
+// -dry-run, and returns the exit code. Migrations modified since they
+// were applied are reported and make it fail, as check mode would.
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "Print the SQL of pending migrations instead of applying them")
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	pool, err := storage.NewPool(ctx, cfg.DB)
+	if err != nil {
+		log.Printf("Database connection failed: %v", err)
+		return 1
+	}
+	defer pool.Close()
+
+	status, err := storage.CheckMigrations(ctx, storage.NewMigrationTracker(pool), storage.Migrations)
+	if err != nil {
+		log.Printf("Failed to read migrations: %v", err)
+		return 1
+	}
+
+	if *dryRun {
+		if err := storage.WritePendingSQL(os.Stdout, status.Pending); err != nil {
+			log.Printf("Failed to write SQL: %v", err)
+			return 1
+		}
+	} else if len(status.Pending) > 0 {
+		if err := storage.Migrate(ctx, pool); err != nil {
+			log.Printf("Migration failed: %v", err)
+			return 1
+		}
+		for _, m := range status.Pending {
+			fmt.Fprintf(os.Stderr, "applied %d %s\n", m.Version, m.Name)
+		}
+	}
+	if len(status.Pending) == 0 {
+		fmt.Fprintln(os.Stderr, "no pending migrations")
+	}
+
+	if len(status.Drifted) > 0 || len(status.Unknown) > 0 {
+		status.Pending = nil
+		fmt.Fprint(os.Stderr, status.Report())
+		return 1
+	}
+	return 0
+}
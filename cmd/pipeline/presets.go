@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/estimate"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/overlap"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// printPresets lists every preset with its effective values and, for
+// presets that extend others, the chain of parents.
+func printPresets(w io.Writer) {
+	presets := preset.List()
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tMIN SCORE\tMAX AGE\tEXTENDS\tQUERY")
+	for _, p := range presets {
+		chain := "-"
+		if len(p.Parents) > 0 {
+			chain = strings.Join(p.Parents, " < ")
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%dd\t%s\t%s\n", p.Name, p.MinScore, p.MaxAgeDays, chain, p.Query)
+	}
+	tw.Flush()
+}
+
+// runPresets runs a presets subcommand (list, compare or try) and returns
+// the exit code.
+func runPresets(args []string) int {
+	usage := "Usage: pipeline presets list | compare <preset> <preset> [-window 90d] [-limit N] [-json] | try <file> [-window 30d] [-limit N] [-sample N] [-json]"
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+	if cfg.Pipeline.PresetsFile != "" {
+		if err := preset.LoadFile(cfg.Pipeline.PresetsFile); err != nil {
+			log.Printf("Failed to load presets: %v", err)
+			return 1
+		}
+	}
+
+	switch args[0] {
+	case "list":
+		printPresets(os.Stdout)
+		return 0
+	case "compare":
+		return runPresetCompare(cfg, args[1:])
+	case "try":
+		return runPresetTry(cfg, args[1:])
+	}
+	fmt.Fprintln(os.Stderr, usage)
+	return 2
+}
+
+// runPresetCompare dry-runs two presets over the same window and reports
+// how much their matches overlap.
+func runPresetCompare(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("presets compare", flag.ExitOnError)
+	windowFlag := fs.String("window", "90d", "Window of last updates to compare: days (90d), weeks (2w) or a duration (36h)")
+	limit := fs.Int("limit", cfg.Pipeline.DefaultLimit, "Papers fetched per preset")
+	asJSON := fs.Bool("json", false, "Print the comparison as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pipeline presets compare <preset> <preset> [-window 90d] [-limit N] [-json]")
+		fs.PrintDefaults()
+	}
+	// Flags may come before, between or after the preset names
+	var names []string
+	for rest := args; ; {
+		fs.Parse(rest)
+		if fs.NArg() == 0 {
+			break
+		}
+		names = append(names, fs.Arg(0))
+		rest = fs.Args()[1:]
+	}
+	if len(names) != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	presets := make([]preset.SearchPreset, len(names))
+	for i, name := range names {
+		p, ok := preset.Get(name)
+		if !ok {
+			log.Printf("Unknown preset %q (see pipeline presets list)", name)
+			return 2
+		}
+		presets[i] = p
+	}
+	window, err := estimate.ParseWindow(*windowFlag)
+	if err != nil {
+		log.Printf("-window: %v", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	svc := pipeline.NewService(map[string]parser.Provider{model.SourceArxiv: newArxivClient(cfg)}, nil)
+	svc.PageTiers = cfg.Filter.PageTiers
+	svc.MaxText = cfg.Filter.MaxText
+	svc.Limits.MaxAbstract = cfg.Pipeline.MaxAbstractLength
+
+	report, err := overlap.Run(ctx, svc, model.SourceArxiv, presets[0], presets[1], window, *limit)
+	if err != nil {
+		log.Printf("Comparison failed: %v", err)
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return 1
+		}
+		return 0
+	}
+	printComparison(os.Stdout, report, *windowFlag)
+	return 0
+}
+
+// runPresetTry dry-runs a candidate preset read from a file and reports
+// which papers it would add to and remove from what the preset of the
+// same name surfaced in its last stored sync.
+func runPresetTry(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("presets try", flag.ExitOnError)
+	windowFlag := fs.String("window", "30d", "Window of last updates to fetch: days (30d), weeks (2w) or a duration (36h)")
+	limit := fs.Int("limit", cfg.Pipeline.DefaultLimit, "Papers fetched for the candidate")
+	sample := fs.Int("sample", overlap.DefaultSample, "Base IDs listed for each of added, removed and unchanged")
+	asJSON := fs.Bool("json", false, "Print the change as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pipeline presets try <file> [-window 30d] [-limit N] [-sample N] [-json]")
+		fmt.Fprintln(fs.Output(), "The file holds one preset definition, a JSON object as in PRESETS_FILE.")
+		fs.PrintDefaults()
+	}
+	// Flags may come before or after the file
+	var files []string
+	for rest := args; ; {
+		fs.Parse(rest)
+		if fs.NArg() == 0 {
+			break
+		}
+		files = append(files, fs.Arg(0))
+		rest = fs.Args()[1:]
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	candidate, err := preset.ReadCandidate(files[0], preset.List())
+	if err != nil {
+		log.Printf("Invalid candidate: %v", err)
+		return 2
+	}
+	window, err := estimate.ParseWindow(*windowFlag)
+	if err != nil {
+		log.Printf("-window: %v", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	// Without a database every match counts as added
+	var history storage.SyncResults
+	if pool, err := storage.NewPool(ctx, cfg.DB); err != nil {
+		log.Printf("Database connection failed, comparing with nothing: %v", err)
+	} else {
+		defer pool.Close()
+		history = storage.NewSyncRepository(pool)
+	}
+
+	svc := pipeline.NewService(map[string]parser.Provider{model.SourceArxiv: newArxivClient(cfg)}, nil)
+	svc.PageTiers = cfg.Filter.PageTiers
+	svc.MaxText = cfg.Filter.MaxText
+	svc.Limits.MaxAbstract = cfg.Pipeline.MaxAbstractLength
+
+	change, err := overlap.Try(ctx, svc, model.SourceArxiv, candidate, history, window, *limit, *sample)
+	if err != nil {
+		log.Printf("Try failed: %v", err)
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(change); err != nil {
+			return 1
+		}
+		return 0
+	}
+	printChange(os.Stdout, change, *windowFlag)
+	return 0
+}
+
+// printChange writes change as counts followed by the sample of each
+// bucket.
+func printChange(w io.Writer, change overlap.Change, window string) {
+	fmt.Fprintf(w, "Candidate %s, papers updated in the last %s\n", change.Preset, window)
+	fmt.Fprintf(w, "  query:  %s\n", change.Query)
+	switch {
+	case change.StoredQuery == "":
+		fmt.Fprintln(w, "  stored: none, a new preset")
+	case change.BaselineSync == 0:
+		fmt.Fprintf(w, "  stored: %s (never synced)\n", change.StoredQuery)
+	default:
+		fmt.Fprintf(w, "  stored: %s (sync #%d)\n", change.StoredQuery, change.BaselineSync)
+	}
+	fmt.Fprintln(w)
+
+	buckets := []struct {
+		name string
+		b    overlap.Bucket
+	}{{"Added", change.Added}, {"Removed", change.Removed}, {"Unchanged", change.Unchanged}}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, bk := range buckets {
+		fmt.Fprintf(tw, "%s\t%d\n", bk.name, bk.b.Count)
+	}
+	tw.Flush()
+
+	for _, bk := range buckets {
+		if len(bk.b.Sample) == 0 {
+			continue
+		}
+		more := ""
+		if n := bk.b.Count - len(bk.b.Sample); n > 0 {
+			more = fmt.Sprintf(" (%d more)", n)
+		}
+		fmt.Fprintf(w, "\n%s%s:\n", bk.name, more)
+		for _, id := range bk.b.Sample {
+			fmt.Fprintf(w, "  %s\n", id)
+		}
+	}
+}
+
+// printComparison writes report as a table followed by the papers unique
+// to each preset.
+func printComparison(w io.Writer, report overlap.Report, window string) {
+	fmt.Fprintf(w, "%s vs %s, papers updated in the last %s\n", report.A.Preset, report.B.Preset, window)
+	fmt.Fprintf(w, "Jaccard overlap %.2f (%d shared)\n\n", report.Jaccard, len(report.Shared))
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PRESET\tMATCHED\tUNIQUE\tAVG SCORE")
+	for _, s := range []overlap.Side{report.A, report.B} {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\n", s.Preset, s.Matched, len(s.Unique), s.AvgScore)
+	}
+	tw.Flush()
+
+	for _, s := range []overlap.Side{report.A, report.B} {
+		if len(s.Unique) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nOnly in %s:\n", s.Preset)
+		for _, id := range s.Unique {
+			fmt.Fprintf(w, "  %s\n", id)
+		}
+	}
+}
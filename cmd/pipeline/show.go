@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// runShow prints one stored paper with its score explained and returns
+// the exit code.
+func runShow(args []string) int {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	lang := fs.String("lang", filter.LangEN, "Language of the score explanation: en or zh")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pipeline show [-lang en|zh] <paper-id>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pool, err := storage.NewPool(ctx, cfg.DB)
+	if err != nil {
+		log.Printf("Database connection failed: %v", err)
+		return 1
+	}
+	defer pool.Close()
+
+	id := paperid.Normalize(fs.Arg(0))
+	paper, err := storage.NewPaperRepository(pool).GetByID(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Paper %s not found", id)
+		return 1
+	}
+	if err != nil {
+		log.Printf("Failed to get paper: %v", err)
+		return 1
+	}
+
+	printPaper(os.Stdout, paper, *lang)
+	return 0
+}
+
+// printPaper writes a full single-paper view.
+func printPaper(w io.Writer, p model.Paper, lang string) {
+	fmt.Fprintf(w, "%s\n", p.Title)
+	fmt.Fprintf(w, "%s · updated %s\n", p.ID, p.UpdatedAt.Format("2006-01-02"))
+	fmt.Fprintf(w, "Authors:    %s\n", strings.Join(p.Authors, ", "))
+	fmt.Fprintf(w, "Categories: %s\n", strings.Join(p.Categories, ", "))
+	if p.Comments != "" {
+		fmt.Fprintf(w, "Comments:   %s\n", p.Comments)
+	}
+	if p.JournalRef != "" {
+		fmt.Fprintf(w, "Journal:    %s\n", p.JournalRef)
+	}
+	if extent := p.Extent(); extent != "" {
+		fmt.Fprintf(w, "Length:     %s\n", extent)
+	}
+	if p.DOI != "" {
+		fmt.Fprintf(w, "DOI:        %s\n", p.DOI)
+	}
+
+	fmt.Fprintf(w, "\n%s\n", p.Abstract)
+
+	fmt.Fprintf(w, "\n%s\n", filter.Explain(p.Score, p.ScoreDetails, lang))
+	for _, d := range p.ScoreDetails {
+		fmt.Fprintf(w, "  %s\n", d)
+	}
+
+	if u := p.AbsURL(); u != "" {
+		fmt.Fprintf(w, "\n%s\n", u)
+	}
+	// Code first: it is what readers look for
+	for _, l := range p.CodeLinks() {
+		fmt.Fprintf(w, "code: %s\n", l.URL)
+	}
+	for _, l := range p.Links {
+		if l.Type != model.LinkAbstract && l.Type != model.LinkCode {
+			fmt.Fprintf(w, "%s: %s\n", l.Type, l.URL)
+		}
+	}
+}
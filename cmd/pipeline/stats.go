@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/taxonomy"
+)
+
+// runStats prints the number of stored papers, their categories and
+// recent ingestion, as /api/stats, /api/categories and
+// /api/stats/ingestion serve them, and returns the exit code.
+func runStats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	primary := fs.Bool("primary", false, "Count only each paper's primary category")
+	days := fs.Int("days", 90, "Days of ingestion to show (0 = none)")
+	bucket := fs.String("bucket", storage.BucketWeek, "Ingestion bucket: day or week")
+	fs.Parse(args)
+
+	if *days < 0 || !storage.ValidBucket(*bucket) {
+		log.Printf("Invalid -days %d or -bucket %q: want a non-negative count and day or week", *days, *bucket)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pool, err := storage.NewPool(ctx, cfg.DB)
+	if err != nil {
+		log.Printf("Database connection failed: %v", err)
+		return 1
+	}
+	defer pool.Close()
+
+	repo := storage.NewPaperRepository(pool)
+	count, err := repo.Count(ctx, true)
+	if err != nil {
+		log.Printf("Failed to count papers: %v", err)
+		return 1
+	}
+	categories, err := repo.DistinctCategories(ctx, *primary)
+	if err != nil {
+		log.Printf("Failed to list categories: %v", err)
+		return 1
+	}
+
+	printStats(os.Stdout, count, categories)
+	if *days == 0 {
+		return 0
+	}
+
+	to := time.Now().UTC()
+	from := storage.BucketStart(to, storage.BucketDay).AddDate(0, 0, 1-*days)
+	buckets, err := repo.IngestionStats(ctx, from, to, *bucket)
+	if err != nil {
+		log.Printf("Failed to get ingestion stats: %v", err)
+		return 1
+	}
+	syncs, err := storage.NewSyncRepository(pool).CountSyncs(ctx, from, to, *bucket)
+	if err != nil {
+		log.Printf("Failed to count syncs: %v", err)
+		return 1
+	}
+	storage.AddSyncCounts(buckets, syncs)
+
+	fmt.Println()
+	printIngestion(os.Stdout, *days, *bucket, buckets)
+	return 0
+}
+
+// printStats writes the paper count and a category table.
+func printStats(w io.Writer, count int64, categories []storage.CategoryCount) {
+	fmt.Fprintf(w, "Papers:     %d\n", count)
+	fmt.Fprintf(w, "Categories: %d\n\n", len(categories))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range categories {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", c.Category, c.Count, taxonomy.Name(c.Category))
+	}
+	tw.Flush()
+}
+
+// sparks are the bar heights of a sparkline, lowest first.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// printIngestion writes a sparkline of papers first saved per bucket and
+// a row per bucket with its papers, abstract bytes and syncs.
+func printIngestion(w io.Writer, days int, bucket string, buckets []storage.IngestionBucket) {
+	var peak, papers, bytes, syncs int64
+	for _, b := range buckets {
+		peak = max(peak, b.Papers)
+		papers += b.Papers
+		bytes += b.AbstractBytes
+		syncs += b.Syncs
+	}
+	bar := func(n int64) string {
+		if peak == 0 {
+			return string(sparks[0])
+		}
+		return string(sparks[int(n*int64(len(sparks)-1)/peak)])
+	}
+
+	var line strings.Builder
+	for _, b := range buckets {
+		line.WriteString(bar(b.Papers))
+	}
+	fmt.Fprintf(w, "Ingestion, last %d days by %s: %s\n", days, bucket, line.String())
+	fmt.Fprintf(w, "Total: %d papers, %s of abstracts, %d syncs\n\n", papers, formatBytes(bytes), syncs)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%s\t\tPapers\tAbstracts\tSyncs\t\n", strings.ToUpper(bucket[:1])+bucket[1:])
+	for _, b := range buckets {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%d\t\n", b.Start.Format("2006-01-02"), bar(b.Papers), b.Papers, formatBytes(b.AbstractBytes), b.Syncs)
+	}
+	tw.Flush()
+}
+
+// formatBytes renders n bytes with a binary unit, e.g. "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
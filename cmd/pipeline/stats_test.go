@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+func TestPrintIngestion(t *testing.T) {
+	week := func(day int) time.Time { return time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC) }
+	var b strings.Builder
+	printIngestion(&b, 21, storage.BucketWeek, []storage.IngestionBucket{
+		{Start: week(4), Papers: 70, AbstractBytes: 2 << 20, Syncs: 7},
+		{Start: week(11)},
+		{Start: week(18), Papers: 140, AbstractBytes: 1536, Syncs: 14},
+	})
+
+	want := `Ingestion, last 21 days by week: ▄▁█
+Total: 210 papers, 2.0 MiB of abstracts, 21 syncs
+
+        Week     Papers  Abstracts  Syncs
+  2024-03-04  ▄      70    2.0 MiB      7
+  2024-03-11  ▁       0        0 B      0
+  2024-03-18  █     140    1.5 KiB     14
+`
+	if b.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+)
+
+// Exit codes of a failed run, so scripts can tell a query to fix from a
+// source to wait for.
+const (
+	exitFailed      = 1 // Anything not listed below
+	exitBadQuery    = 2 // The source rejected the query, as for a usage error
+	exitUnavailable = 3 // Rate limited or unavailable; try again later
+	exitDecode      = 4 // The source's response could not be decoded
+)
+
+// exitCode returns the status a run that failed with err exits with.
+func exitCode(err error) int {
+	switch parser.ErrorKind(err) {
+	case parser.KindBadQuery:
+		return exitBadQuery
+	case parser.KindRateLimited, parser.KindUnavailable:
+		return exitUnavailable
+	case parser.KindDecode:
+		return exitDecode
+	}
+	return exitFailed
+}
+
+// writeSummary writes s as indented JSON to path, or to stdout for "-".
+// An empty path writes nothing.
+func writeSummary(path string, s pipeline.Summary) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode summary: %w", err)
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		return fmt.Errorf("write summary: %w", err)
+	}
+	return nil
+}
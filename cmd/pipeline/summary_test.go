@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+)
+
+func TestWriteSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.json")
+	result := pipeline.RunResult{Provider: "arxiv", Query: "llm", Fetched: 3}
+	if err := writeSummary(path, result.Summary(nil, 0)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got pipeline.Summary
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("summary is not JSON: %v\n%s", err, data)
+	}
+	if got.Counts.Fetched != 3 || got.ExitCode != 0 || got.SyncID != 0 {
+		t.Errorf("summary = %+v", got)
+	}
+
+	if err := writeSummary("", result.Summary(nil, 0)); err != nil {
+		t.Errorf("an empty path should write nothing, got %v", err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("database down"), exitFailed},
+		{fmt.Errorf("fetch: %w", &arxiv.StatusError{StatusCode: 400}), exitBadQuery},
+		{fmt.Errorf("fetch: %w", &arxiv.StatusError{StatusCode: 429}), exitUnavailable},
+		{fmt.Errorf("fetch: %w", &arxiv.StatusError{StatusCode: 503}), exitUnavailable},
+		{fmt.Errorf("fetch: %w", &arxiv.DecodeError{Err: io.ErrUnexpectedEOF}), exitDecode},
+	}
+	for _, tc := range tests {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
+)
+
+// runVersion prints the build, as /api/version serves it, and returns
+// the exit code.
+func runVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the build as JSON")
+	fs.Parse(args)
+
+	info := version.Get()
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			return 1
+		}
+		return 0
+	}
+	fmt.Printf("pipeline %s\n", info.Version)
+	fmt.Printf("  commit:   %s\n", info.Commit)
+	fmt.Printf("  built:    %s\n", info.Date)
+	fmt.Printf("  go:       %s\n", info.GoVersion)
+	fmt.Printf("  platform: %s\n", info.Platform)
+	return 0
+}
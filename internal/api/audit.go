@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// audited records every request to next that is not a GET or HEAD in the
+// audit log, with the status it was answered with. target names what the
+// request acted on.
+func (h *Handler) audited(action string, target func(*http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.Audit == nil || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next(w, r)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next(sw, r)
+		h.Audit.Record(r.Context(), storage.AuditEntry{
+			Actor:  h.actor(r),
+			Action: action,
+			Target: target(r),
+			Params: params(r),
+			Status: sw.status,
+		})
+	}
+}
+
+// actor identifies the caller: "api:<label>" for a request bearing one of
+// APIKeys, else "api:<address>". Keys only label callers; requests without
+// one are still served.
+func (h *Handler) actor(r *http.Request) string {
+	if key := requestKey(r); key != "" {
+		for label, k := range h.APIKeys {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 {
+				return "api:" + label
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "api:" + host
+}
+
+// requestKey returns the bearer token or X-API-Key header of r.
+func requestKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// requireAdmin answers requests without the admin token with 401.
+func (h *Handler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := requestKey(r)
+		if key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(h.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "Admin token required", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// params summarises a request as its method, path and parameters, query
+// string and form values alike.
+func params(r *http.Request) string {
+	values := url.Values{}
+	for k, v := range r.URL.Query() {
+		values[k] = v
+	}
+	for k, v := range r.PostForm {
+		values[k] = append(values[k], v...)
+	}
+	summary := r.Method + " " + r.URL.Path
+	if len(values) > 0 {
+		summary += "?" + values.Encode()
+	}
+	return summary
+}
+
+// formValue returns a target function reading the named parameter.
+func formValue(name string) func(*http.Request) string {
+	return func(r *http.Request) string { return r.FormValue(name) }
+}
+
+// statusWriter remembers the status code a handler responded with.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// GET /api/admin/audit?limit=100&action= - Recent mutating operations (admin token required)
+func (h *Handler) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Audit == nil || h.Audit.Log == nil {
+		http.Error(w, "Audit log not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	entries, err := h.Audit.Log.ListAudit(ctx, r.URL.Query().Get("action"), limit)
+	if err != nil {
+		log.Printf("Error listing audit log: %v", err)
+		http.Error(w, "Failed to list audit log", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"entries": entries,
+		"failed":  h.Audit.Failed(),
+	})
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/audit"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/syncqueue"
+)
+
+func TestAudit_MutatingRoutes(t *testing.T) {
+	t.Cleanup(preset.Reset)
+	store := memory.New()
+	queue := syncqueue.New(syncqueue.Config{})
+	t.Cleanup(func() { queue.Shutdown(context.Background()) })
+
+	h := NewHandler(store, stubProvider{}, queue)
+	h.SyncForm = true
+	h.PresetsFile = filepath.Join(t.TempDir(), "presets.json")
+	os.WriteFile(h.PresetsFile, []byte(`[]`), 0o644)
+	h.Audit = audit.NewRecorder(store)
+	h.Audit.Clock = clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	h.APIKeys = map[string]string{"ops-bot": "k-ops"}
+	h.AdminToken = "admin-secret"
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	serve(httptest.NewRequest(http.MethodPost, "/api/sync?query=llm&async=true", nil))
+	form := httptest.NewRequest(http.MethodPost, "/sync", strings.NewReader(url.Values{"query": {"rag"}}.Encode()))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	serve(form)
+	reload := httptest.NewRequest(http.MethodPost, "/api/presets/reload", nil)
+	reload.Header.Set("X-API-Key", "k-ops")
+	serve(reload)
+	// Reads are not audited
+	serve(httptest.NewRequest(http.MethodGet, "/api/papers", nil))
+	serve(httptest.NewRequest(http.MethodGet, "/api/sync/history", nil))
+
+	list := func(query string) []storage.AuditEntry {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit"+query, nil)
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rec := serve(req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET audit%s = %d: %s", query, rec.Code, rec.Body)
+		}
+		var body struct{ Entries []storage.AuditEntry }
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return body.Entries
+	}
+
+	// The reload carried a key, so it is labelled; the rest by address
+	want := []storage.AuditEntry{
+		{Actor: "api:ops-bot", Action: audit.ActionPresetsReload, Target: h.PresetsFile, Params: "POST /api/presets/reload", Status: http.StatusOK},
+		{Actor: "api:192.0.2.1", Action: audit.ActionSync, Target: "rag", Params: "POST /sync?query=rag", Status: http.StatusSeeOther},
+		{Actor: "api:192.0.2.1", Action: audit.ActionSync, Target: "llm", Params: "POST /api/sync?async=true&query=llm", Status: http.StatusAccepted},
+	}
+	got := list("")
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Actor != w.Actor || g.Action != w.Action || g.Target != w.Target || g.Params != w.Params || g.Status != w.Status || g.At.IsZero() {
+			t.Errorf("entry %d = %+v, want %+v", i, g, w)
+		}
+	}
+
+	if got := list("?action=sync&limit=1"); len(got) != 1 || got[0].Target != "rag" {
+		t.Errorf("action=sync&limit=1 = %+v, want the rag sync", got)
+	}
+	if got := list("?action=delete"); len(got) != 0 {
+		t.Errorf("action=delete = %+v, want none", got)
+	}
+}
+
+// failingAuditLog is an audit log whose writes fail.
+type failingAuditLog struct {
+	*memory.Store
+}
+
+func (failingAuditLog) RecordAudit(ctx context.Context, e storage.AuditEntry) error {
+	return errors.New("connection refused")
+}
+
+func TestAudit_FailureDoesNotFailOperation(t *testing.T) {
+	t.Cleanup(preset.Reset)
+	h := NewHandler(memory.New(), nil, nil)
+	h.PresetsFile = filepath.Join(t.TempDir(), "presets.json")
+	os.WriteFile(h.PresetsFile, []byte(`[]`), 0o644)
+	h.Audit = audit.NewRecorder(failingAuditLog{memory.New()})
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/presets/reload", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("reload = %d, want 200 despite the audit failure", rec.Code)
+	}
+	if h.Audit.Failed() != 1 {
+		t.Errorf("Failed() = %d, want 1", h.Audit.Failed())
+	}
+}
+
+func TestAudit_Unavailable(t *testing.T) {
+	h := NewHandler(memory.New(), nil, nil)
+	h.AdminToken = "admin-secret"
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/audit", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without an audit log = %d, want 503", rec.Code)
+	}
+}
+
+func TestAudit_AdminToken(t *testing.T) {
+	store := memory.New()
+	h := NewHandler(store, nil, nil)
+	h.Audit = audit.NewRecorder(store)
+
+	// Without a token the endpoint does not exist
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	if rec := get(mux, "/api/admin/audit"); rec.Code == http.StatusOK || rec.Code == http.StatusUnauthorized {
+		t.Errorf("without ADMIN_TOKEN = %d, want the route unregistered", rec.Code)
+	}
+
+	h.AdminToken = "admin-secret"
+	h.APIKeys = map[string]string{"ops-bot": "k-ops"}
+	mux = http.NewServeMux()
+	h.RegisterRoutes(mux)
+	for header, want := range map[string]int{
+		"":                    http.StatusUnauthorized,
+		"Bearer wrong":        http.StatusUnauthorized,
+		"Bearer k-ops":        http.StatusUnauthorized, // Caller keys are not admin tokens
+		"Bearer admin-secret": http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Authorization %q = %d, want %d", header, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/taxonomy"
+)
+
+// categoryCache keeps the category counts of one data version, so the
+// grouped scan runs once per write rather than once per request.
+// Backends without storage.ChangeTracker are not cached.
+type categoryCache struct {
+	mu      sync.Mutex
+	version storage.DataVersion
+	counts  map[bool][]storage.CategoryCount // By primary grouping
+}
+
+// Category is a category in the /api/categories response.
+type Category struct {
+	Category string `json:"category"`
+	Name     string `json:"name"` // From the arXiv taxonomy, or the code when unknown
+	Count    int64  `json:"count"`
+}
+
+// GET /api/categories?group=primary - Categories present in the database with paper counts
+func (h *Handler) handleCategories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	group := r.URL.Query().Get("group")
+	if group != "" && group != "primary" && group != "all" {
+		http.Error(w, "group must be primary or all", http.StatusBadRequest)
+		return
+	}
+	lister, ok := h.repo.(storage.CategoryLister)
+	if !ok {
+		http.Error(w, "Categories unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	counts, err := h.categoryCounts(ctx, lister, group == "primary")
+	if err != nil {
+		log.Printf("Error listing categories: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	categories := make([]Category, len(counts))
+	for i, c := range counts {
+		categories[i] = Category{Category: c.Category, Name: taxonomy.Name(c.Category), Count: c.Count}
+	}
+	if group == "" {
+		group = "all"
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"group":      group,
+		"categories": categories,
+	})
+}
+
+// categoryCounts returns the category counts, from the cache while the
+// store's data version is unchanged.
+func (h *Handler) categoryCounts(ctx context.Context, lister storage.CategoryLister, primary bool) ([]storage.CategoryCount, error) {
+	tracker, ok := h.repo.(storage.ChangeTracker)
+	if !ok {
+		return lister.DistinctCategories(ctx, primary)
+	}
+	version, err := tracker.DataVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &h.categories
+	c.mu.Lock()
+	if c.version != version {
+		c.version, c.counts = version, nil
+	}
+	counts, ok := c.counts[primary]
+	c.mu.Unlock()
+	if ok {
+		return counts, nil
+	}
+
+	counts, err = lister.DistinctCategories(ctx, primary)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.version == version {
+		if c.counts == nil {
+			c.counts = make(map[bool][]storage.CategoryCount)
+		}
+		c.counts[primary] = counts
+	}
+	c.mu.Unlock()
+	return counts, nil
+}
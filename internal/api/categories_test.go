@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+// categoryCountingStore counts category scans so tests can assert the
+// cache answers repeated requests.
+type categoryCountingStore struct {
+	*memory.Store
+	scans atomic.Int32
+}
+
+func (s *categoryCountingStore) DistinctCategories(ctx context.Context, primary bool) ([]storage.CategoryCount, error) {
+	s.scans.Add(1)
+	return s.Store.DistinctCategories(ctx, primary)
+}
+
+func getCategories(t *testing.T, mux *http.ServeMux, path string) []Category {
+	t.Helper()
+	rec := get(mux, path)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d: %s", path, rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Categories []Category `json:"categories"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Categories
+}
+
+func TestCategories_CountsAndCacheInvalidation(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	store := &categoryCountingStore{Store: memory.New()}
+	store.Clock = clk
+	ctx := context.Background()
+	store.SaveBatch(ctx, []model.Paper{
+		{ID: "2401.00001v1", Categories: []string{"cs.CL", "cs.AI"}},
+		{ID: "2401.00002v1", Categories: []string{"cs.CL"}},
+		{ID: "2401.00003v1", Categories: []string{"cs.LG", "cs.CL", "astro-ph.GA"}},
+	})
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+
+	all := getCategories(t, mux, "/api/categories")
+	want := []Category{
+		{"cs.CL", "Computation and Language", 3},
+		{"astro-ph.GA", "astro-ph.GA", 1},
+		{"cs.AI", "Artificial Intelligence", 1},
+		{"cs.LG", "Machine Learning", 1},
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("all categories = %+v, want %+v", all, want)
+	}
+	primary := getCategories(t, mux, "/api/categories?group=primary")
+	want = []Category{{"cs.CL", "Computation and Language", 2}, {"cs.LG", "Machine Learning", 1}}
+	if !reflect.DeepEqual(primary, want) {
+		t.Errorf("primary categories = %+v, want %+v", primary, want)
+	}
+
+	getCategories(t, mux, "/api/categories")
+	getCategories(t, mux, "/api/categories?group=primary")
+	if n := store.scans.Load(); n != 2 {
+		t.Errorf("%d scans for two groupings requested twice, want 2", n)
+	}
+
+	// A save moves the data version and drops both groupings
+	clk.Advance(time.Minute)
+	store.SaveBatch(ctx, []model.Paper{{ID: "2401.00004v1", Categories: []string{"cs.IR"}}})
+	all = getCategories(t, mux, "/api/categories")
+	if len(all) != 5 || store.scans.Load() != 3 {
+		t.Errorf("after save: %+v with %d scans, want cs.IR added by a new scan", all, store.scans.Load())
+	}
+	getCategories(t, mux, "/api/categories?group=primary")
+	if n := store.scans.Load(); n != 4 {
+		t.Errorf("%d scans, want the primary grouping rescanned after the save", n)
+	}
+}
+
+func TestCategories_InvalidGroup(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(memory.New(), nil, nil).RegisterRoutes(mux)
+
+	if rec := get(mux, "/api/categories?group=base"); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET with group=base = %d, want 400", rec.Code)
+	}
+}
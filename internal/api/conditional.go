@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// checkNotModified sets ETag and Last-Modified from the store's data
+// version and answers requests that need no body: 304 for a fresh
+// conditional GET and headers only for HEAD. It reports whether the
+// response is complete. Backends without storage.ChangeTracker get no
+// validators, and their HEAD requests run the full handler (net/http drops
+// the body).
+//
+// The ETag covers deletes through the paper count; Last-Modified only
+// moves on saves, so clients that need to notice deletes should send
+// If-None-Match, which takes precedence.
+func (h *Handler) checkNotModified(w http.ResponseWriter, r *http.Request, contentType string) bool {
+	tracker, ok := h.repo.(storage.ChangeTracker)
+	if !ok {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	version, err := tracker.DataVersion(ctx)
+	if err != nil {
+		log.Printf("Error reading data version: %v", err)
+		return false
+	}
+	modified := version.Modified
+
+	etag := `W/"` + strconv.FormatInt(modified.UnixNano(), 36) + "-" + strconv.FormatInt(version.Count, 36) + `"`
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		return true
+	}
+	return false
+}
+
+// notModified evaluates If-None-Match and, when it is absent,
+// If-Modified-Since (RFC 9110 section 13.2.2).
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// HTTP dates have one-second resolution
+		return !modified.Truncate(time.Second).After(since)
+	}
+	return false
+}
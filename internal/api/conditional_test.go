@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/export"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+// countingStore counts list and export queries so tests can assert 304s
+// skip them.
+type countingStore struct {
+	*memory.Store
+	lists atomic.Int32
+}
+
+func (s *countingStore) List(ctx context.Context, limit, offset int) ([]model.Paper, error) {
+	s.lists.Add(1)
+	return s.Store.List(ctx, limit, offset)
+}
+
+func (s *countingStore) StreamPapers(ctx context.Context, after storage.Cursor, fn func(model.Paper) error) error {
+	s.lists.Add(1)
+	return s.Store.StreamPapers(ctx, after, fn)
+}
+
+// conditionalEndpoints are the endpoints that support HEAD and
+// conditional GETs, with their content types.
+var conditionalEndpoints = []struct {
+	path        string
+	contentType string
+}{
+	{"/api/papers", "application/json"},
+	{"/api/feed.atom", feedContentType},
+	{"/api/export?format=csv", export.ContentType(export.FormatCSV)},
+	{"/api/export", export.ContentType(export.FormatJSONL)},
+}
+
+func newConditionalServer(t *testing.T) (*http.ServeMux, *countingStore, *clock.Fake) {
+	t.Helper()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	store := &countingStore{Store: memory.New()}
+	store.Clock = clk
+	if err := store.SaveBatch(context.Background(), []model.Paper{{ID: "2401.00001v1", Title: "A"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+	return mux, store, clk
+}
+
+func request(mux *http.ServeMux, method, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestConditional_Head(t *testing.T) {
+	for _, ep := range conditionalEndpoints {
+		mux, store, _ := newConditionalServer(t)
+
+		rec := request(mux, http.MethodHead, ep.path, nil)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("HEAD %s = %d, want 200", ep.path, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != ep.contentType {
+			t.Errorf("HEAD %s: Content-Type = %q, want %q", ep.path, ct, ep.contentType)
+		}
+		if rec.Header().Get("ETag") == "" {
+			t.Errorf("HEAD %s: missing ETag", ep.path)
+		}
+		if lm := rec.Header().Get("Last-Modified"); lm != "Wed, 01 May 2024 12:00:00 GMT" {
+			t.Errorf("HEAD %s: Last-Modified = %q", ep.path, lm)
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("HEAD %s wrote a %d-byte body", ep.path, rec.Body.Len())
+		}
+		if n := store.lists.Load(); n != 0 {
+			t.Errorf("HEAD %s ran %d queries, want 0", ep.path, n)
+		}
+	}
+}
+
+func TestConditional_GetFresh(t *testing.T) {
+	for _, ep := range conditionalEndpoints {
+		mux, store, _ := newConditionalServer(t)
+		first := request(mux, http.MethodGet, ep.path, nil)
+		if first.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", ep.path, first.Code)
+		}
+		etag := first.Header().Get("ETag")
+
+		tests := []struct {
+			name   string
+			header map[string]string
+		}{
+			{"If-None-Match", map[string]string{"If-None-Match": etag}},
+			{"If-None-Match list", map[string]string{"If-None-Match": `"other", ` + etag}},
+			{"If-Modified-Since", map[string]string{"If-Modified-Since": first.Header().Get("Last-Modified")}},
+		}
+
+		for _, tc := range tests {
+			before := store.lists.Load()
+			rec := request(mux, http.MethodGet, ep.path, tc.header)
+			if rec.Code != http.StatusNotModified {
+				t.Errorf("%s %s: GET = %d, want 304", ep.path, tc.name, rec.Code)
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("%s %s: 304 wrote a body", ep.path, tc.name)
+			}
+			if store.lists.Load() != before {
+				t.Errorf("%s %s: 304 ran the query", ep.path, tc.name)
+			}
+		}
+	}
+}
+
+func TestConditional_GetStale(t *testing.T) {
+	for _, ep := range conditionalEndpoints {
+		mux, store, clk := newConditionalServer(t)
+		first := request(mux, http.MethodGet, ep.path, nil)
+
+		clk.Advance(time.Minute)
+		store.SaveBatch(context.Background(), []model.Paper{{ID: "2401.00002v1", Title: "B"}})
+
+		for name, header := range map[string]map[string]string{
+			"If-None-Match":     {"If-None-Match": first.Header().Get("ETag")},
+			"If-Modified-Since": {"If-Modified-Since": first.Header().Get("Last-Modified")},
+		} {
+			rec := request(mux, http.MethodGet, ep.path, header)
+			if rec.Code != http.StatusOK {
+				t.Errorf("%s %s: GET after save = %d, want 200", ep.path, name, rec.Code)
+			}
+			if rec.Header().Get("ETag") == first.Header().Get("ETag") {
+				t.Errorf("%s %s: ETag unchanged after save", ep.path, name)
+			}
+		}
+	}
+}
+
+func TestConditional_ETagChangesOnDelete(t *testing.T) {
+	mux, store, _ := newConditionalServer(t)
+	store.SaveBatch(context.Background(), []model.Paper{{ID: "2401.00002v1", Title: "B"}})
+	first := request(mux, http.MethodGet, "/api/papers", nil)
+
+	if err := store.Delete(context.Background(), "2401.00002v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := request(mux, http.MethodGet, "/api/papers", map[string]string{"If-None-Match": first.Header().Get("ETag")})
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET after delete = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/estimate"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/overlap"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// maxEstimateWindows keeps an estimate within the server's write timeout
+// at arXiv's rate of one request every three seconds.
+const maxEstimateWindows = 4
+
+// GET /api/presets/:name/estimate?window=30d&windows=1 - Matches per window and weekly rate of a preset's query
+func (h *Handler) handlePresetEstimate(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/presets/"), "/estimate")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p, ok := preset.Get(name)
+	if !ok {
+		http.Error(w, "Unknown preset", http.StatusNotFound)
+		return
+	}
+
+	window := 30 * 24 * time.Hour
+	if s := r.URL.Query().Get("window"); s != "" {
+		var err error
+		if window, err = estimate.ParseWindow(s); err != nil {
+			http.Error(w, "Invalid window", http.StatusBadRequest)
+			return
+		}
+	}
+	windows := 1
+	if s := r.URL.Query().Get("windows"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxEstimateWindows {
+			http.Error(w, "windows must be between 1 and "+strconv.Itoa(maxEstimateWindows), http.StatusBadRequest)
+			return
+		}
+		windows = n
+	}
+
+	counter, ok := h.provider.(parser.Counter)
+	if !ok {
+		http.Error(w, "Provider cannot count matches", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 14*time.Second)
+	defer cancel()
+
+	result, err := estimate.Run(ctx, counter, p.Query, window, windows, clock.Or(h.Clock).Now())
+	if err != nil {
+		log.Printf("Error estimating preset %s: %v", name, err)
+		status := http.StatusBadGateway
+		if errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		}
+		http.Error(w, "Failed to count matches", status)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"preset":   p.Name,
+		"query":    result.Query,
+		"windows":  result.Windows,
+		"per_week": result.PerWeek,
+	})
+}
+
+// maxCompareLimit keeps a comparison's two fetches within the server's
+// write timeout.
+const maxCompareLimit = 100
+
+// GET /api/presets/compare?a=&b=&window=90d&limit=50 - Overlap of the papers two presets match
+func (h *Handler) handlePresetCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	a, okA := preset.Get(q.Get("a"))
+	b, okB := preset.Get(q.Get("b"))
+	if !okA || !okB {
+		http.Error(w, "Unknown preset", http.StatusNotFound)
+		return
+	}
+
+	window := 90 * 24 * time.Hour
+	if s := q.Get("window"); s != "" {
+		var err error
+		if window, err = estimate.ParseWindow(s); err != nil {
+			http.Error(w, "Invalid window", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 50
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxCompareLimit {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxCompareLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 14*time.Second)
+	defer cancel()
+
+	svc := h.service()
+	svc.Store, svc.History, svc.Notify = nil, nil, nil
+	report, err := overlap.Run(ctx, svc, defaultProvider, a, b, window, limit)
+	if err != nil {
+		log.Printf("Error comparing presets %s and %s: %v", a.Name, b.Name, err)
+		status := http.StatusBadGateway
+		if errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		}
+		http.Error(w, "Failed to compare presets", status)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// maxPresetBody bounds the candidate definition POST /api/presets/try
+// reads.
+const maxPresetBody = 64 << 10
+
+// POST /api/presets/try?window=30d&limit=50&sample=10 - Papers a candidate preset (the body, a definition as in PRESETS_FILE) would add to and remove from what the stored preset of its name surfaced
+func (h *Handler) handlePresetTry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPresetBody))
+	if err != nil {
+		http.Error(w, "Candidate too large or unreadable", http.StatusRequestEntityTooLarge)
+		return
+	}
+	candidate, err := preset.ParseCandidate(data, preset.List())
+	if err != nil {
+		http.Error(w, "Invalid candidate: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	window := 30 * 24 * time.Hour
+	if s := q.Get("window"); s != "" {
+		if window, err = estimate.ParseWindow(s); err != nil {
+			http.Error(w, "Invalid window", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 50
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxCompareLimit {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxCompareLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	sample := overlap.DefaultSample
+	if s := q.Get("sample"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid sample", http.StatusBadRequest)
+			return
+		}
+		sample = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 14*time.Second)
+	defer cancel()
+
+	history, _ := h.History.(storage.SyncResults)
+	svc := h.service()
+	svc.Store, svc.History, svc.Notify = nil, nil, nil
+	change, err := overlap.Try(ctx, svc, defaultProvider, candidate, history, window, limit, sample)
+	if err != nil {
+		log.Printf("Error trying preset %s: %v", candidate.Name, err)
+		status := http.StatusBadGateway
+		if errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		}
+		http.Error(w, "Failed to try preset", status)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, change)
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/overlap"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/providertest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+// countingProvider counts 10 matches per day of any window.
+type countingProvider struct {
+	queries []string
+}
+
+func (p *countingProvider) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	return nil, nil
+}
+
+func (p *countingProvider) CountPapers(ctx context.Context, query string, from, to time.Time) (int, error) {
+	p.queries = append(p.queries, query)
+	return int(to.Sub(from)/(24*time.Hour)) * 10, nil
+}
+
+func TestPresetEstimate(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		code    int
+		windows int
+		perWeek float64
+	}{
+		{"default window", "/api/presets/llm-reasoning/estimate", http.StatusOK, 1, 70},
+		{"two windows of two weeks", "/api/presets/llm-reasoning/estimate?window=2w&windows=2", http.StatusOK, 2, 70},
+		{"unknown preset", "/api/presets/nope/estimate", http.StatusNotFound, 0, 0},
+		{"bad window", "/api/presets/llm-reasoning/estimate?window=month", http.StatusBadRequest, 0, 0},
+		{"too many windows", "/api/presets/llm-reasoning/estimate?windows=5", http.StatusBadRequest, 0, 0},
+		{"not an estimate", "/api/presets/llm-reasoning", http.StatusNotFound, 0, 0},
+	}
+
+	for _, tc := range tests {
+		provider := &countingProvider{}
+		h := NewHandler(memory.New(), provider, nil)
+		h.Clock = clock.NewFake(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC))
+		mux := http.NewServeMux()
+		h.RegisterRoutes(mux)
+
+		rec := get(mux, tc.path)
+		if rec.Code != tc.code {
+			t.Errorf("%s: GET %s = %d, want %d", tc.name, tc.path, rec.Code, tc.code)
+			continue
+		}
+		if tc.code != http.StatusOK {
+			continue
+		}
+		var body struct {
+			Query   string            `json:"query"`
+			Windows []json.RawMessage `json:"windows"`
+			PerWeek float64           `json:"per_week"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(body.Windows) != tc.windows || len(provider.queries) != tc.windows || body.PerWeek != tc.perWeek {
+			t.Errorf("%s: %d windows from %d requests, %v/week; want %d, %v", tc.name,
+				len(body.Windows), len(provider.queries), body.PerWeek, tc.windows, tc.perWeek)
+		}
+		if body.Query == "" || provider.queries[0] != body.Query {
+			t.Errorf("%s: counted %q, reported %q", tc.name, provider.queries, body.Query)
+		}
+	}
+}
+
+func TestPresetEstimate_ProviderCannotCount(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(memory.New(), stubProvider{}, nil).RegisterRoutes(mux)
+
+	if rec := get(mux, "/api/presets/llm-reasoning/estimate"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET estimate = %d, want 503", rec.Code)
+	}
+}
+
+func TestPresetCompare(t *testing.T) {
+	t.Cleanup(preset.Reset)
+	preset.Default.Register(preset.SearchPreset{Name: "retrieval", Query: "retrieval", MinScore: 10})
+	preset.Default.Register(preset.SearchPreset{Name: "reasoning", Query: "reasoning", MinScore: 10})
+
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	paper := func(id, title string) model.Paper {
+		return model.Paper{
+			ID: id, Title: title, Authors: []string{"A. Author"}, Comments: "Accepted at ACL 2024",
+			Abstract:  "We run experiments on a benchmark dataset and compare against a strong baseline.",
+			UpdatedAt: now.Add(-24 * time.Hour),
+		}
+	}
+	provider := providertest.Fixture{
+		paper("2402.00001v1", "Retrieval for reasoning"),
+		paper("2402.00002v1", "Retrieval at scale"),
+	}
+	store := memory.New()
+	h := NewHandler(store, provider, nil)
+	h.Clock = clock.NewFake(now)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	rec := get(mux, "/api/presets/compare?a=retrieval&b=reasoning&window=30d")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET compare = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var report overlap.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Jaccard != 0.5 || len(report.Shared) != 1 || len(report.A.Unique) != 1 || report.B.Matched != 1 {
+		t.Errorf("report = %+v, want one shared paper and one unique to retrieval", report)
+	}
+	if n, _ := store.Count(context.Background(), false); n != 0 {
+		t.Errorf("comparison saved %d papers, want a dry run", n)
+	}
+
+	for path, want := range map[string]int{
+		"/api/presets/compare?a=retrieval&b=nope":               http.StatusNotFound,
+		"/api/presets/compare?a=retrieval&b=reasoning&window=x": http.StatusBadRequest,
+		"/api/presets/compare?a=retrieval&b=reasoning&limit=0":  http.StatusBadRequest,
+	} {
+		if rec := get(mux, path); rec.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
+
+// storedHistory is a sync log whose last sync of every query returned
+// the same result set.
+type storedHistory struct {
+	recordingHistory
+	results map[string]int
+}
+
+func (h *storedHistory) RecordSyncResults(ctx context.Context, id int, results map[string]int) error {
+	return nil
+}
+
+func (h *storedHistory) PreviousSync(ctx context.Context, query string, scope storage.SyncScope, id int) (storage.SyncLog, map[string]int, error) {
+	return storage.SyncLog{ID: 3, Query: query}, h.results, nil
+}
+
+func TestPresetTry(t *testing.T) {
+	t.Cleanup(preset.Reset)
+	preset.Default.Register(preset.SearchPreset{Name: "retrieval", Query: "retrieval", MinScore: 10})
+
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	paper := func(id, title string) model.Paper {
+		return model.Paper{
+			ID: id, Title: title, Authors: []string{"A. Author"}, Comments: "Accepted at ACL 2024",
+			Abstract:  "We run experiments on a benchmark dataset and compare against a strong baseline.",
+			UpdatedAt: now.Add(-24 * time.Hour),
+		}
+	}
+	provider := providertest.Fixture{
+		paper("2402.00001v1", "Dense retrieval"),
+		paper("2402.00002v1", "Dense retrieval at scale"),
+		paper("2402.00003v1", "Sparse retrieval"),
+	}
+	store := memory.New()
+	h := NewHandler(store, provider, nil)
+	h.Clock = clock.NewFake(now)
+	h.History = &storedHistory{results: map[string]int{"2402.00001": 50, "2402.00003": 50}}
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	try := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec
+	}
+	rec := try("/api/presets/try?window=30d&sample=1", `{"name": "retrieval", "extends": "retrieval", "query": "dense retrieval"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST try = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var change overlap.Change
+	if err := json.Unmarshal(rec.Body.Bytes(), &change); err != nil {
+		t.Fatal(err)
+	}
+	want := overlap.Change{
+		Preset: "retrieval", Query: "dense retrieval", StoredQuery: "retrieval", BaselineSync: 3,
+		Added:     overlap.Bucket{Count: 1, Sample: []string{"2402.00002"}},
+		Removed:   overlap.Bucket{Count: 1, Sample: []string{"2402.00003"}},
+		Unchanged: overlap.Bucket{Count: 1, Sample: []string{"2402.00001"}},
+	}
+	if !reflect.DeepEqual(change, want) {
+		t.Errorf("change = %+v, want %+v", change, want)
+	}
+	if n, _ := store.Count(context.Background(), false); n != 0 {
+		t.Errorf("try saved %d papers, want a dry run", n)
+	}
+
+	for body, want := range map[string]int{
+		`{"name": "x", "extends": "nope"}`: http.StatusBadRequest,
+		`not json`:                         http.StatusBadRequest,
+	} {
+		if rec := try("/api/presets/try", body); rec.Code != want {
+			t.Errorf("POST try %s = %d, want %d", body, rec.Code, want)
+		}
+	}
+	if rec := try("/api/presets/try?limit=0", `{"name": "retrieval"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST try with limit 0 = %d, want 400", rec.Code)
+	}
+	if rec := get(mux, "/api/presets/try"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET try = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/export"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+const (
+	exportFlushEvery   = 200              // Records between flushes of an export stream
+	exportWriteTimeout = 30 * time.Second // Write deadline, pushed back at every flush
+)
+
+// Trailers sent at the end of an export. Clients treat a missing or false
+// X-Export-Complete as a truncated download and resume with the After
+// values as after_ts and after_id.
+const (
+	trailerExportComplete = "X-Export-Complete"
+	trailerExportAfterTS  = "X-Export-After-Ts"
+	trailerExportAfterID  = "X-Export-After-Id"
+)
+
+// GET, HEAD /api/export?format=csv|jsonl&after_ts=&after_id= - Stream every paper, oldest first, resumable after any record (conditional requests supported)
+func (h *Handler) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	streamer, ok := h.repo.(storage.PaperStreamer)
+	if !ok {
+		http.Error(w, "Export not supported by this store", http.StatusNotImplemented)
+		return
+	}
+
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = export.FormatJSONL
+	}
+	var after storage.Cursor
+	if ts, id := q.Get("after_ts"), q.Get("after_id"); ts != "" || id != "" {
+		updated, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil || id == "" {
+			http.Error(w, "after_ts (RFC 3339) and after_id must be given together", http.StatusBadRequest)
+			return
+		}
+		after = storage.Cursor{UpdatedAt: updated, ID: id}
+	}
+
+	// A resumed CSV is appended to the partial file, which has its header
+	ew, err := export.NewWriter(w, format, after.IsZero())
+	if err != nil {
+		http.Error(w, "format must be csv or jsonl", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="papers.`+format+`"`)
+	if h.checkNotModified(w, r, export.ContentType(format)) {
+		return
+	}
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Trailer", trailerExportComplete+", "+trailerExportAfterTS+", "+trailerExportAfterID)
+
+	// Large exports outlive the server's write timeout; each flush extends it
+	clk := clock.Or(h.Clock)
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(clk.Now().Add(exportWriteTimeout))
+
+	last, n := after, 0
+	err = streamer.StreamPapers(r.Context(), after, func(p model.Paper) error {
+		if err := ew.Write(p); err != nil {
+			return err
+		}
+		last = storage.CursorAfter(p)
+		n++
+		if n%exportFlushEvery != 0 {
+			return nil
+		}
+		if err := ew.Flush(); err != nil {
+			return err
+		}
+		rc.SetWriteDeadline(clk.Now().Add(exportWriteTimeout))
+		return rc.Flush()
+	})
+	if err == nil {
+		err = ew.Flush()
+	}
+	if err != nil {
+		log.Printf("Export stopped after %d papers: %v", n, err)
+	}
+
+	w.Header().Set(trailerExportComplete, strconv.FormatBool(err == nil))
+	if !last.IsZero() {
+		w.Header().Set(trailerExportAfterTS, last.UpdatedAt.UTC().Format(time.RFC3339Nano))
+		w.Header().Set(trailerExportAfterID, last.ID)
+	}
+}
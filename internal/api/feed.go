@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
+)
+
+const (
+	feedContentType     = "application/atom+xml; charset=utf-8"
+	jsonFeedContentType = "application/feed+json; charset=utf-8"
+	jsonFeedVersion     = "https://jsonfeed.org/version/1.1"
+	feedID              = "urn:genesis-pipeline:papers"
+	feedDefaultLimit    = 50
+)
+
+// Atom 1.0 structures (RFC 4287) for the paper feed.
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Links      []atomLink     `xml:"link"`
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary"`
+}
+
+// JSON Feed 1.1 structures (https://jsonfeed.org/version/1.1).
+
+type jsonFeed struct {
+	Version string         `json:"version"`
+	Title   string         `json:"title"`
+	FeedURL string         `json:"feed_url"`
+	Items   []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	Title         string               `json:"title"`
+	ContentText   string               `json:"content_text"`
+	DatePublished string               `json:"date_published,omitempty"`
+	DateModified  string               `json:"date_modified"`
+	Authors       []jsonFeedAuthor     `json:"authors,omitempty"`
+	Tags          []string             `json:"tags,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedAttachment struct {
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+}
+
+// feedSource is what a feed lists: the paper feed's newest papers or a
+// watchlist's hits.
+type feedSource struct {
+	ID     string // Atom feed ID
+	Title  string
+	Self   string // URL the feed was requested with
+	Papers []model.Paper
+}
+
+// GET, HEAD /api/feed.atom?limit= - Newest papers as an Atom feed (conditional requests supported)
+func (h *Handler) handleFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.checkNotModified(w, r, feedContentType) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	papers, err := h.repo.List(ctx, feedLimit(r), 0)
+	if err != nil {
+		log.Printf("Error listing papers: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeAtom(w, feedSource{
+		ID:     feedID,
+		Title:  "Genesis Pipeline papers",
+		Self:   r.URL.RequestURI(),
+		Papers: papers,
+	})
+}
+
+// feedLimit returns the limit= of a feed request, 1-100.
+func feedLimit(r *http.Request) int {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = feedDefaultLimit
+	}
+	return limit
+}
+
+// writeAtom answers with src as an Atom feed.
+func (h *Handler) writeAtom(w http.ResponseWriter, src feedSource) {
+	feed := atomFeed{
+		Title:   src.Title,
+		ID:      src.ID,
+		Updated: h.feedUpdated(src.Papers).Format(time.RFC3339),
+		Links:   []atomLink{{Href: src.Self, Rel: "self", Type: "application/atom+xml"}},
+	}
+	for _, p := range src.Papers {
+		feed.Entries = append(feed.Entries, feedEntry(p))
+	}
+
+	w.Header().Set("Content-Type", feedContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		log.Printf("Error encoding feed: %v", err)
+	}
+}
+
+// writeJSONFeed answers with src as a JSON Feed.
+func writeJSONFeed(w http.ResponseWriter, src feedSource) {
+	feed := jsonFeed{
+		Version: jsonFeedVersion,
+		Title:   src.Title,
+		FeedURL: src.Self,
+		Items:   make([]jsonFeedItem, 0, len(src.Papers)),
+	}
+	for _, p := range src.Papers {
+		feed.Items = append(feed.Items, jsonFeedEntry(p))
+	}
+
+	w.Header().Set("Content-Type", jsonFeedContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(feed); err != nil {
+		log.Printf("Error encoding feed: %v", err)
+	}
+}
+
+// feedUpdated dates a feed by its first paper, as listings are newest
+// first, or by the current time when it is empty.
+func (h *Handler) feedUpdated(papers []model.Paper) time.Time {
+	if len(papers) > 0 {
+		return papers[0].UpdatedAt.UTC()
+	}
+	return clock.Or(h.Clock).Now().UTC()
+}
+
+// feedGUID is the stable ID of a paper's feed entry. It is a URN rather
+// than the abstract page, which readers would take for a permalink and
+// which not every source has.
+func feedGUID(p model.Paper) string {
+	return "urn:genesis-pipeline:paper:" + p.ID
+}
+
+func feedEntry(p model.Paper) atomEntry {
+	entry := atomEntry{
+		Title:   textutil.RenderTitle(p.Title, textutil.TitlePlain),
+		ID:      feedGUID(p),
+		Updated: p.UpdatedAt.UTC().Format(time.RFC3339),
+		Summary: p.Abstract,
+	}
+	for _, link := range p.Links {
+		switch link.Type {
+		case "abstract":
+			entry.Links = append(entry.Links, atomLink{Href: link.URL, Rel: "alternate", Type: "text/html"})
+		case "pdf":
+			entry.Links = append(entry.Links, atomLink{Href: link.URL, Rel: "related", Type: "application/pdf"})
+		}
+	}
+	for _, name := range p.Authors {
+		entry.Authors = append(entry.Authors, atomPerson{Name: name})
+	}
+	for _, c := range p.Categories {
+		entry.Categories = append(entry.Categories, atomCategory{Term: c})
+	}
+	return entry
+}
+
+func jsonFeedEntry(p model.Paper) jsonFeedItem {
+	item := jsonFeedItem{
+		ID:           feedGUID(p),
+		Title:        textutil.RenderTitle(p.Title, textutil.TitlePlain),
+		ContentText:  p.Abstract,
+		DateModified: p.UpdatedAt.UTC().Format(time.RFC3339),
+		Tags:         p.Categories,
+	}
+	if !p.Published.IsZero() {
+		item.DatePublished = p.Published.UTC().Format(time.RFC3339)
+	}
+	for _, link := range p.Links {
+		switch link.Type {
+		case "abstract":
+			item.URL = link.URL
+		case "pdf":
+			item.Attachments = append(item.Attachments, jsonFeedAttachment{URL: link.URL, MimeType: "application/pdf"})
+		}
+	}
+	for _, name := range p.Authors {
+		item.Authors = append(item.Authors, jsonFeedAuthor{Name: name})
+	}
+	return item
+}
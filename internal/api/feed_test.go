@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+func TestFeed(t *testing.T) {
+	store := memory.New()
+	store.SaveBatch(context.Background(), []model.Paper{
+		{
+			ID:         "2401.00001v1",
+			Title:      "Older",
+			UpdatedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Authors:    []string{"Alice Smith"},
+			Categories: []string{"cs.CL"},
+		},
+		{
+			ID:        "2401.00002v1",
+			Title:     `Scaling $\alpha$-Divergence`,
+			Abstract:  "We evaluate <things> & more.",
+			UpdatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			Links:     []model.Link{{URL: "https://arxiv.org/abs/2401.00002v1", Type: "abstract"}},
+		},
+	})
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+
+	rec := get(mux, "/api/feed.atom?limit=1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET feed = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != feedContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("feed is not valid XML: %v", err)
+	}
+	if feed.Updated != "2024-01-02T00:00:00Z" || len(feed.Entries) != 1 {
+		t.Fatalf("feed = %+v, want the newest paper only", feed)
+	}
+	entry := feed.Entries[0]
+	if entry.ID != "urn:genesis-pipeline:paper:2401.00002v1" || entry.Summary != "We evaluate <things> & more." {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.Title != "Scaling α-Divergence" {
+		t.Errorf("title = %q, want TeX rendered as text", entry.Title)
+	}
+}
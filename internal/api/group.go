@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// groupScanSize is how many records each step of a grouped listing reads.
+const groupScanSize = 100
+
+// listGroups pages through a listing by base ID. A group takes the place
+// where its first record appears, and offset and limit count groups, so a
+// group is never split across pages.
+func (h *Handler) listGroups(ctx context.Context, list func(limit, offset int) ([]model.Paper, error), limit, offset int) ([]model.PaperGroup, error) {
+	var papers []model.Paper
+	bases := []string{}
+	for pos := 0; len(bases) < offset+limit; pos += groupScanSize {
+		batch, err := list(groupScanSize, pos)
+		if err != nil {
+			return nil, err
+		}
+		papers = append(papers, batch...)
+		bases = baseIDs(papers)
+		if len(batch) < groupScanSize {
+			break
+		}
+	}
+	page := []string{}
+	if offset < len(bases) {
+		page = bases[offset:min(offset+limit, len(bases))]
+	}
+	return h.groups(ctx, page, papers)
+}
+
+// groups returns one group per base ID, in order, holding every stored
+// version when the backend can list them and the given papers otherwise.
+func (h *Handler) groups(ctx context.Context, bases []string, papers []model.Paper) ([]model.PaperGroup, error) {
+	if len(bases) == 0 {
+		return []model.PaperGroup{}, nil
+	}
+	if vl, ok := h.repo.(storage.VariantLister); ok {
+		var err error
+		if papers, err = vl.ListByBaseIDs(ctx, bases); err != nil {
+			return nil, err
+		}
+	}
+
+	byBase := make(map[string]model.PaperGroup)
+	for _, g := range model.GroupByBaseID(papers) {
+		byBase[g.BaseID] = g
+	}
+	groups := make([]model.PaperGroup, 0, len(bases))
+	for _, base := range bases {
+		if g, ok := byBase[base]; ok {
+			groups = append(groups, g)
+		}
+	}
+	return groups, nil
+}
+
+// baseIDs returns the distinct base IDs of papers in order of appearance.
+func baseIDs(papers []model.Paper) []string {
+	var bases []string
+	seen := make(map[string]bool)
+	for _, p := range papers {
+		if base := p.BaseID(); !seen[base] {
+			seen[base] = true
+			bases = append(bases, base)
+		}
+	}
+	return bases
+}
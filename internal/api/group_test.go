@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+type groupList struct {
+	Papers []model.PaperGroup `json:"papers"`
+	Count  int                `json:"count"`
+}
+
+// groupFixture stores 2401.00001 as v2 (newest record) and v1 (oldest
+// record), with unrelated papers in between.
+func groupFixture(t *testing.T) *http.ServeMux {
+	t.Helper()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	papers := []model.Paper{
+		{ID: "2401.00001v1", Title: "Routing", UpdatedAt: base, Source: model.SourceArxiv},
+		{ID: "2401.00001v2", Title: "Routing", UpdatedAt: base.Add(10 * time.Hour), Source: model.SourceArxivRSS},
+	}
+	for i := 2; i <= 4; i++ {
+		papers = append(papers, model.Paper{
+			ID:        fmt.Sprintf("2401.%05dv1", i),
+			Title:     fmt.Sprintf("Routing %d", i),
+			UpdatedAt: base.Add(time.Duration(10-i) * time.Hour),
+		})
+	}
+	store := memory.New()
+	if err := store.SaveBatch(context.Background(), papers); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+	return mux
+}
+
+func decodeGroups(t *testing.T, mux *http.ServeMux, path string) groupList {
+	t.Helper()
+	rec := get(mux, path)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d, want 200", path, rec.Code)
+	}
+	var list groupList
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func TestPapers_GroupPagesNeverSplitGroups(t *testing.T) {
+	mux := groupFixture(t)
+
+	seen := make(map[string]int)
+	for offset := 0; offset < 6; offset += 2 {
+		list := decodeGroups(t, mux, fmt.Sprintf("/api/papers?group=base&limit=2&offset=%d", offset))
+		for _, g := range list.Papers {
+			seen[g.BaseID]++
+			if g.BaseID == "2401.00001" {
+				if len(g.Variants) != 2 || g.Paper.ID != "2401.00001v2" {
+					t.Errorf("group %s = %+v, want both versions led by v2", g.BaseID, g)
+				}
+				sources := []string{g.Variants[0].Source, g.Variants[1].Source}
+				if sources[0] != model.SourceArxivRSS || sources[1] != model.SourceArxiv {
+					t.Errorf("variant sources = %v, want per-record sources", sources)
+				}
+			}
+		}
+	}
+	if len(seen) != 4 {
+		t.Errorf("saw %d groups across pages, want 4", len(seen))
+	}
+	for base, n := range seen {
+		if n != 1 {
+			t.Errorf("group %s listed on %d pages, want 1", base, n)
+		}
+	}
+
+	if rec := get(mux, "/api/papers?group=base&cursor=abc"); rec.Code != http.StatusBadRequest {
+		t.Errorf("group with cursor = %d, want 400", rec.Code)
+	}
+}
+
+func TestSearch_Group(t *testing.T) {
+	mux := groupFixture(t)
+
+	// Only v2 is within the limit, but the group carries every version
+	list := decodeGroups(t, mux, "/api/papers/search?q=routing&group=base&limit=1")
+	if list.Count != 1 || list.Papers[0].BaseID != "2401.00001" || len(list.Papers[0].Variants) != 2 {
+		t.Errorf("search groups = %+v, want 2401.00001 with both versions", list.Papers)
+	}
+}
@@ -3,28 +3,99 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/1psychoQAQ/genesis-pipeline/internal/audit"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/diff"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/notify"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/settings"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/syncqueue"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/timing"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/validation"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
 )
 
+// defaultProvider names the provider of syncs that do not pick one.
+const defaultProvider = model.SourceArxiv
+
 // Handler holds the API dependencies.
 type Handler struct {
-	repo     *storage.PaperRepository
-	provider parser.Provider
+	repo      storage.PaperStore
+	provider  parser.Provider // Used for defaultProvider in place of building it from Providers
+	providers providerSet
+	queue     *syncqueue.Queue
+
+	categories categoryCache
+
+	// Providers, when set, are the providers a sync may pick with
+	// provider=, built with ProviderOptions on first use (default: only
+	// the provider given to NewHandler)
+	Providers       *parser.Registry
+	ProviderOptions parser.Options
+
+	SyncForm bool                // Show the sync trigger form in the web UI
+	History  storage.SyncHistory // Optional sync log; enables /api/sync/history
+	Clock    clock.Clock         // Time source for sync runs (default: system clock)
+
+	MinScore  int               // Filter threshold for syncs, as the CLI's -min-score (default: filter default)
+	MaxAge    time.Duration     // Drop synced papers last updated longer ago, as the CLI's -max-age (default: no limit)
+	PageTiers map[int]int       // Points by minimum page count for sync scoring (default: none)
+	MaxText   int               // Characters of each text field sync scoring reads (default: filter.DefaultMaxText)
+	Limits    validation.Limits // Field size caps applied to synced papers (default: validation.DefaultLimits())
+
+	NearDuplicates int // Fingerprint distance at which synced papers merge into stored ones (0 = off)
+
+	Shadow    *pipeline.ShadowRules // Optional candidate rules scored in shadow mode during syncs
+	ShadowLog storage.ShadowLog     // Optional shadow result store; enables /api/filter/shadow-report
+
+	Requests         storage.SyncRequestLog // Optional request log; enables /api/sync/:id/requests
+	RequestRetention time.Duration          // How long sync requests are kept (0 = not recorded)
+
+	Notify *notify.Queue // Optional announcements of the new papers of each sync
+
+	PresetsFile string // Presets file re-read by /api/presets/reload (empty = built-ins only)
+
+	Audit   *audit.Recorder   // Optional activity trail of mutating requests
+	APIKeys map[string]string // Label -> key; requests bearing a key are audited as api:<label> (default: none)
+
+	AdminToken string // Bearer token for /api/admin/audit and /api/settings, which are not registered without one
+
+	// Settings, when set, overrides MinScore, MaxAge and the notification
+	// limits with the values changed at runtime over /api/settings
+	Settings *settings.Service
+
+	// Watchlists, with FeedSecret, enables /api/watchlists/:id/feed.atom
+	// and feed.json, read with tokens signed with FeedSecret. Watchlists
+	// are managed with the admin token.
+	Watchlists storage.WatchlistStore
+	FeedSecret string
 }
 
 // NewHandler creates a new API handler.
-func NewHandler(repo *storage.PaperRepository, provider parser.Provider) *Handler {
+func NewHandler(repo storage.PaperStore, provider parser.Provider, queue *syncqueue.Queue) *Handler {
 	return &Handler{
 		repo:     repo,
 		provider: provider,
+		queue:    queue,
+		Clock:    clock.Real{},
 	}
 }
 
@@ -34,16 +105,45 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/papers/", h.handlePaperByID)
 	mux.HandleFunc("/api/papers/search", h.handleSearch)
 	mux.HandleFunc("/api/stats", h.handleStats)
-	mux.HandleFunc("/api/sync", h.handleSync)
+	mux.HandleFunc("/api/stats/ingestion", h.handleIngestionStats)
+	mux.HandleFunc("/api/categories", h.handleCategories)
+	mux.HandleFunc("/api/export", h.handleExport)
+	mux.HandleFunc("/api/feed.atom", h.handleFeed)
+	mux.HandleFunc("/api/sync", h.audited(audit.ActionSync, formValue("query"), h.handleSync))
+	mux.HandleFunc("/api/sync/jobs/", h.handleSyncJob)
+	mux.HandleFunc("/api/sync/history", h.handleSyncHistory)
+	mux.HandleFunc("/api/sync/", h.handleSyncRequests)
+	mux.HandleFunc("/api/filter/shadow-report", h.handleShadowReport)
+	mux.HandleFunc("/api/presets/", h.handlePresetEstimate)
+	mux.HandleFunc("/api/presets/compare", h.handlePresetCompare)
+	mux.HandleFunc("/api/presets/try", h.handlePresetTry)
+	mux.HandleFunc("/api/presets/reload", h.audited(audit.ActionPresetsReload, func(*http.Request) string { return h.PresetsFile }, h.handlePresetsReload))
+	if h.AdminToken != "" {
+		mux.HandleFunc("/api/admin/audit", h.requireAdmin(h.handleAudit))
+		if h.Settings != nil {
+			mux.HandleFunc("/api/settings", h.requireAdmin(h.handleSettings))
+		}
+	}
+	if h.Watchlists != nil && h.FeedSecret != "" {
+		mux.HandleFunc("/api/watchlists/", h.handleWatchlist)
+		if h.AdminToken != "" {
+			mux.HandleFunc("/api/watchlists", h.requireAdmin(h.audited(audit.ActionWatchlist, watchlistTarget, h.handleWatchlists)))
+		}
+	}
+	mux.HandleFunc("/api/version", h.handleVersion)
 	mux.HandleFunc("/health", h.handleHealth)
+	h.registerUIRoutes(mux)
 }
 
-// GET /api/papers - List papers with pagination
+// GET, HEAD /api/papers?author=&cursor=&min_score=&group=base - List papers with pagination (conditional requests supported)
 func (h *Handler) handlePapers(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if h.checkNotModified(w, r, "application/json") {
+		return
+	}
 
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
@@ -55,35 +155,119 @@ func (h *Handler) handlePapers(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	papers, err := h.repo.List(ctx, limit, offset)
+	author := r.URL.Query().Get("author")
+	cursor := r.URL.Query().Get("cursor")
+	minScore := r.URL.Query().Get("min_score")
+	group := r.URL.Query().Get("group") == "base"
+	if minScore != "" && (author != "" || cursor != "") {
+		http.Error(w, "min_score cannot be combined with author or cursor", http.StatusBadRequest)
+		return
+	}
+	// Grouped pages count groups, which a record cursor cannot express
+	if group && cursor != "" {
+		http.Error(w, "group cannot be combined with cursor; use offset", http.StatusBadRequest)
+		return
+	}
+
+	var list func(limit, offset int) ([]model.Paper, error)
+	switch {
+	case author != "":
+		list = func(limit, offset int) ([]model.Paper, error) {
+			return h.repo.ListByAuthor(ctx, author, limit, offset)
+		}
+	case cursor != "":
+		after, decodeErr := storage.DecodeCursor(cursor)
+		if decodeErr != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		list = func(limit, _ int) ([]model.Paper, error) {
+			return h.repo.ListAfter(ctx, after, limit)
+		}
+	case minScore != "":
+		min, parseErr := storage.ParseMinScores(minScore)
+		if parseErr != nil {
+			respondMinScoreError(w, parseErr)
+			return
+		}
+		list = func(limit, offset int) ([]model.Paper, error) {
+			return h.repo.ListMinScore(ctx, min, limit, offset)
+		}
+	default:
+		list = func(limit, offset int) ([]model.Paper, error) {
+			return h.repo.List(ctx, limit, offset)
+		}
+	}
+
+	// group=base folds records sharing a base ID into one entry with every
+	// stored version as variants; limit and offset count groups
+	if group {
+		groups, err := h.listGroups(ctx, list, limit, offset)
+		if err != nil {
+			log.Printf("Error listing papers: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{
+			"papers": groups,
+			"group":  "base",
+			"limit":  limit,
+			"offset": offset,
+			"count":  len(groups),
+		})
+		return
+	}
+
+	papers, err := list(limit, offset)
 	if err != nil {
 		log.Printf("Error listing papers: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]any{
+	resp := map[string]any{
 		"papers": papers,
 		"limit":  limit,
 		"offset": offset,
 		"count":  len(papers),
-	})
+	}
+	// A full page may have more after it; continue with ?cursor=. Author
+	// and min_score listings page by offset only
+	if author == "" && minScore == "" && len(papers) == limit {
+		resp["next_cursor"] = storage.CursorAfter(papers[len(papers)-1]).Encode()
+	}
+
+	respondJSON(w, http.StatusOK, resp)
 }
 
-// GET /api/papers/:id - Get paper by ID
+// GET /api/papers/:id?lang= - Get paper by ID, with its score explained (lang: en, zh)
 func (h *Handler) handlePaperByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Extract ID from path: /api/papers/2301.00001
+	// Extract ID from path: /api/papers/2301.00001, also as arXiv:2301.00001
 	id := strings.TrimPrefix(r.URL.Path, "/api/papers/")
 	if id == "" || id == "search" {
 		http.Error(w, "Paper ID required", http.StatusBadRequest)
 		return
 	}
 
+	if base, ok := strings.CutSuffix(id, "/versions"); ok {
+		h.handleVersions(w, r, paperid.Base(base))
+		return
+	}
+	if paperID, ok := strings.CutSuffix(id, "/pdf"); ok {
+		h.handlePDF(w, r, paperid.Normalize(paperID))
+		return
+	}
+	if base, ok := strings.CutSuffix(id, "/diff"); ok {
+		h.handleDiff(w, r, paperid.Base(base))
+		return
+	}
+	id = paperid.Normalize(id)
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
@@ -98,10 +282,164 @@ func (h *Handler) handlePaperByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, paper)
+	respondJSON(w, http.StatusOK, paperDetail{
+		Paper:       paper,
+		Explanation: filter.Explain(paper.Score, paper.ScoreDetails, r.URL.Query().Get("lang")),
+		Code:        paper.CodeLinks(),
+	})
+}
+
+// paperDetail is a paper with its score explained in prose and its code
+// repositories picked out of Links.
+type paperDetail struct {
+	model.Paper
+	Explanation string       `json:"explanation"`
+	Code        []model.Link `json:"code,omitempty"`
+}
+
+// GET /api/papers/:id/versions - List recorded arXiv version updates
+func (h *Handler) handleVersions(w http.ResponseWriter, r *http.Request, baseID string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	versions, err := h.repo.ListVersions(ctx, baseID)
+	if err != nil {
+		log.Printf("Error listing versions: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"base_id":  baseID,
+		"versions": versions,
+		"count":    len(versions),
+	})
+}
+
+// GET /api/papers/:id/diff?from=v1&to=v3 - Word diff of the abstract between two versions
+func (h *Handler) handleDiff(w http.ResponseWriter, r *http.Request, baseID string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	history, err := h.repo.ListVersions(ctx, baseID)
+	if err != nil {
+		log.Printf("Error listing versions: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	latest, err := h.repo.LatestVersions(ctx, []string{baseID})
+	if err != nil {
+		log.Printf("Error loading latest version: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	paper, ok := latest[baseID]
+	if !ok {
+		http.Error(w, "Paper not found", http.StatusNotFound)
+		return
+	}
+
+	abstracts := model.AbstractsByVersion(history, paper)
+	if len(abstracts) == 0 {
+		http.Error(w, "No abstract history", http.StatusNotFound)
+		return
+	}
+
+	// Default to the oldest and newest versions with a known abstract
+	from, to := math.MaxInt, 0
+	for v := range abstracts {
+		from = min(from, v)
+		to = max(to, v)
+	}
+
+	from, ok = versionParam(r, "from", from)
+	if !ok {
+		http.Error(w, "Invalid from version", http.StatusBadRequest)
+		return
+	}
+	to, ok = versionParam(r, "to", to)
+	if !ok {
+		http.Error(w, "Invalid to version", http.StatusBadRequest)
+		return
+	}
+
+	oldAbstract, ok := abstracts[from]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Abstract of v%d not recorded", from), http.StatusNotFound)
+		return
+	}
+	newAbstract, ok := abstracts[to]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Abstract of v%d not recorded", to), http.StatusNotFound)
+		return
+	}
+
+	segments := diff.Words(oldAbstract, newAbstract)
+	respondJSON(w, http.StatusOK, map[string]any{
+		"base_id":  baseID,
+		"from":     from,
+		"to":       to,
+		"summary":  diff.Summarize(segments),
+		"segments": segments,
+		"html":     diff.HTML(segments),
+	})
+}
+
+// versionParam parses an arXiv version query parameter ("v3" or "3"),
+// returning def when it is absent.
+func versionParam(r *http.Request, name string, def int) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, true
+	}
+	v, err := strconv.Atoi(strings.TrimPrefix(raw, "v"))
+	if err != nil || v < 1 {
+		return 0, false
+	}
+	return v, true
+}
+
+// GET /api/papers/:id/pdf - Stream the locally archived PDF
+func (h *Handler) handlePDF(w http.ResponseWriter, r *http.Request, id string) {
+	archive, ok := h.repo.(storage.PDFArchive)
+	if !ok {
+		http.Error(w, "PDF not archived", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	path, _, err := archive.GetPDF(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "PDF not archived", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error getting pdf: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("Error opening archived pdf %s: %v", path, err)
+		http.Error(w, "PDF not archived", http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	http.ServeContent(w, r, filepath.Base(path), info.ModTime(), f)
 }
 
-// GET /api/papers/search?q=query - Search papers
+// GET /api/papers/search?q=query&group=base - Search papers
 func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -129,6 +467,22 @@ func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Query().Get("group") == "base" {
+		groups, err := h.groups(ctx, baseIDs(papers), papers)
+		if err != nil {
+			log.Printf("Error grouping search results: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{
+			"query":  query,
+			"papers": groups,
+			"group":  "base",
+			"count":  len(groups),
+		})
+		return
+	}
+
 	respondJSON(w, http.StatusOK, map[string]any{
 		"query":  query,
 		"papers": papers,
@@ -136,7 +490,7 @@ func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GET /api/stats - Get pipeline statistics
+// GET /api/stats?exact=true - Get pipeline statistics
 func (h *Handler) handleStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -146,7 +500,9 @@ func (h *Handler) handleStats(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	count, err := h.repo.Count(ctx)
+	exact, _ := strconv.ParseBool(r.URL.Query().Get("exact"))
+
+	count, err := h.repo.Count(ctx, exact)
 	if err != nil {
 		log.Printf("Error getting count: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -158,15 +514,110 @@ func (h *Handler) handleStats(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Error getting latest update: %v", err)
 	}
 
+	stats := map[string]any{
+		"total_papers": count,
+		"last_sync":    latest,
+		"database":     "PostgreSQL",
+		"data_source":  "ArXiv API",
+	}
+
+	if h.queue != nil {
+		stats["sync_jobs"] = h.queue.Stats()
+	}
+
+	if cr, ok := h.provider.(parser.CacheReporter); ok {
+		stats["provider_cache"] = cr.CacheStats()
+	}
+
+	if sr, ok := h.repo.(storage.SearchIndexReporter); ok {
+		n, err := sr.CountSearchTruncated(ctx)
+		if err != nil {
+			log.Printf("Error counting truncated search index entries: %v", err)
+		} else {
+			stats["search_index_truncated"] = n
+		}
+	}
+
+	// Relation sizes are only available from backends that track them.
+	if sr, ok := h.repo.(storage.SizeReporter); ok {
+		sizes, err := sr.RelationSizes(ctx)
+		if err != nil {
+			log.Printf("Error getting relation sizes: %v", err)
+		} else {
+			stats["storage"] = sizes
+		}
+	}
+
+	respondJSON(w, http.StatusOK, stats)
+}
+
+// maxIngestionDays bounds the window of /api/stats/ingestion.
+const maxIngestionDays = 3660
+
+// GET /api/stats/ingestion?days=90&bucket=day|week - Papers first saved,
+// their abstract bytes and syncs per UTC day or week
+func (h *Handler) handleIngestionStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ir, ok := h.repo.(storage.IngestionReporter)
+	if !ok {
+		http.Error(w, "Ingestion stats unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	days := 90
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxIngestionDays {
+			http.Error(w, fmt.Sprintf("Invalid days %q: want 1 to %d", v, maxIngestionDays), http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		bucket = storage.BucketDay
+	}
+	if !storage.ValidBucket(bucket) {
+		http.Error(w, fmt.Sprintf("Invalid bucket %q: want day or week", bucket), http.StatusBadRequest)
+		return
+	}
+
+	// The window ends now and starts at the UTC midnight that makes it
+	// days long; a week bucket reaches back to the Monday before
+	to := clock.Or(h.Clock).Now().UTC()
+	from := storage.BucketStart(to, storage.BucketDay).AddDate(0, 0, 1-days)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	buckets, err := ir.IngestionStats(ctx, from, to, bucket)
+	if err != nil {
+		log.Printf("Error getting ingestion stats: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if sc, ok := h.History.(storage.SyncCounter); ok {
+		counts, err := sc.CountSyncs(ctx, from, to, bucket)
+		if err != nil {
+			log.Printf("Error counting syncs: %v", err)
+		} else {
+			storage.AddSyncCounts(buckets, counts)
+		}
+	}
+
 	respondJSON(w, http.StatusOK, map[string]any{
-		"total_papers":  count,
-		"last_sync":     latest,
-		"database":      "PostgreSQL",
-		"data_source":   "ArXiv API",
+		"days":    days,
+		"bucket":  bucket,
+		"from":    storage.BucketStart(from, bucket),
+		"to":      to,
+		"buckets": buckets,
 	})
 }
 
-// POST /api/sync - Trigger paper sync
+// POST /api/sync?async=true - Trigger paper sync
 func (h *Handler) handleSync(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -183,28 +634,418 @@ func (h *Handler) handleSync(w http.ResponseWriter, r *http.Request) {
 		limit = 20
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	var opts syncOptions
+	opts.providerName = r.URL.Query().Get("provider")
+	provider, err := h.providerFor(opts.providerName)
+	if err != nil {
+		h.respondProviderError(w, opts.providerName, err)
+		return
+	}
+	if opts.providerName == "" {
+		opts.providerName = defaultProvider
+	}
+	opts.provider = provider
+
+	// Categories end up in the provider's search syntax, so only
+	// well-formed names are accepted
+	if v := r.URL.Query().Get("categories"); v != "" {
+		for _, c := range strings.Split(v, ",") {
+			c = strings.TrimSpace(c)
+			if !categoryName.MatchString(c) {
+				http.Error(w, fmt.Sprintf("Invalid category %q", c), http.StatusBadRequest)
+				return
+			}
+			opts.categories = append(opts.categories, c)
+		}
+	}
+	if v := r.URL.Query().Get("strict_categories"); v != "" {
+		strict, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "Invalid strict_categories", http.StatusBadRequest)
+			return
+		}
+		if strict && len(opts.categories) == 0 {
+			http.Error(w, "strict_categories needs categories", http.StatusBadRequest)
+			return
+		}
+		opts.strict = strict
+	}
+	if v := r.URL.Query().Get("incremental"); v != "" {
+		incremental, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "Invalid incremental", http.StatusBadRequest)
+			return
+		}
+		if _, ok := h.History.(storage.SyncCheckpoints); incremental && !ok {
+			http.Error(w, "Incremental syncs need a sync log that keeps checkpoints", http.StatusBadRequest)
+			return
+		}
+		opts.incremental = incremental
+	}
+	if v := r.URL.Query().Get("min_score"); v != "" {
+		minScore, err := strconv.Atoi(v)
+		if err != nil || minScore < 0 || minScore > 100 {
+			http.Error(w, "Invalid min_score", http.StatusBadRequest)
+			return
+		}
+		opts.minScore = &minScore
+	}
+
+	job, res := h.newSyncJob(query, limit, opts)
+	if err := h.queue.Submit(job); err != nil {
+		if errors.Is(err, syncqueue.ErrQueueFull) {
+			http.Error(w, "Sync queue full, try again later", http.StatusTooManyRequests)
+			return
+		}
+		http.Error(w, "Sync unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	if async, _ := strconv.ParseBool(r.URL.Query().Get("async")); async {
+		status, _ := h.queue.Status(job.ID())
+		respondJSON(w, http.StatusAccepted, status)
+		return
+	}
+
+	select {
+	case <-job.Done():
+	case <-r.Context().Done():
+		return
+	}
+
+	if err := job.Err(); err != nil {
+		log.Printf("Error syncing papers: %v", err)
+
+		// Report exactly how much was saved so the client can resume
+		if errors.Is(err, storage.ErrPartialSave) {
+			status := http.StatusInternalServerError
+			if errors.Is(err, context.DeadlineExceeded) {
+				status = http.StatusGatewayTimeout
+			}
+			respondJSON(w, status, map[string]any{
+				"message": "Sync partially saved",
+				"job_id":  job.ID(),
+				"query":   query,
+				"fetched": res.Fetched,
+				"saved":   res.Saved,
+				"updated": len(res.Updated),
+			})
+			return
+		}
+
+		// Say whose fault a failed fetch was, so clients know whether
+		// to fix the query, back off or try again later
+		if d, ok := parser.RetryAfter(err); ok && parser.Temporary(err) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+		}
+		switch {
+		case errors.Is(err, parser.ErrBadQuery):
+			http.Error(w, "Query rejected by the paper source: "+err.Error(), http.StatusBadRequest)
+		case errors.Is(err, parser.ErrRateLimited):
+			http.Error(w, "Paper source rate limit reached, try again later", http.StatusTooManyRequests)
+		case errors.Is(err, parser.ErrUnavailable):
+			http.Error(w, "Paper source unavailable", http.StatusBadGateway)
+		case errors.Is(err, parser.ErrDecode):
+			http.Error(w, "Paper source sent a response that could not be read", http.StatusBadGateway)
+		default:
+			http.Error(w, "Sync failed", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := map[string]any{
+		"message":  "Sync completed",
+		"job_id":   job.ID(),
+		"query":    query,
+		"provider": opts.providerName,
+		"fetched":  res.Fetched,
+		"saved":    res.Saved,
+		"updated":  len(res.Updated),
+	}
+	if res.Total > 0 {
+		resp["total_matches"] = res.Total
+	}
+	if !res.Since.IsZero() {
+		resp["since"] = res.Since
+	}
+	if len(opts.categories) > 0 {
+		resp["categories"] = opts.categories
+		resp["out_of_scope"] = res.Rejected[pipeline.RejectOutOfScope]
+	}
+	if res.Sources != nil {
+		resp["sources"] = res.Sources
+	}
+	if res.Truncated > 0 {
+		resp["truncated"] = res.Truncated
+	}
+	if res.Shadow != nil {
+		resp["shadow"] = res.Shadow
+	}
+	if res.Diff != nil {
+		resp["diff"] = res.Diff
+	}
+	if res.Notifications != nil {
+		resp["notifications"] = res.Notifications
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// syncOptions are the optional parameters of an API sync (see
+// pipeline.RunParams).
+type syncOptions struct {
+	providerName string          // Provider to fetch from (default: defaultProvider)
+	provider     parser.Provider // Resolved providerName (default: NewHandler's provider)
+
+	categories  []string
+	strict      bool // Match the primary category only
+	incremental bool // Fetch only what changed since the last sync
+	minScore    *int // Filter threshold of this sync (default: syncDefaults)
+}
+
+// categoryName matches arXiv category names such as cs.CL, math.AG,
+// hep-th or q-bio.NC.
+var categoryName = regexp.MustCompile(`^[a-z]+(-[a-z]+)?(\.[A-Za-z]+(-[A-Za-z]+)?)?$`)
+
+// newSyncJob builds an interactive sync job for query. The returned result
+// is complete once the job is done.
+func (h *Handler) newSyncJob(query string, limit int, opts syncOptions) (*syncqueue.Job, *pipeline.RunResult) {
+	res := &pipeline.RunResult{}
+	timings := &timing.Timings{}
+	if opts.providerName == "" {
+		opts.providerName, opts.provider = defaultProvider, h.provider
+	}
+	job := &syncqueue.Job{
+		Provider: opts.providerName,
+		Query:    query,
+		Priority: syncqueue.PriorityInteractive,
+		Timings:  timings,
+	}
+	job.Summary = func() any {
+		if res.Diff == nil {
+			return nil
+		}
+		return map[string]any{"diff": res.Diff}
+	}
+	job.Run = func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+
+		// API syncs filter like the CLI, with the configured defaults,
+		// and compare with the previous sync when the log keeps results,
+		// unless only what changed since then is fetched
+		_, diff := h.History.(storage.SyncResults)
+		diff = diff && !opts.incremental
+		minScore, maxAge := h.syncDefaults()
+		if opts.minScore != nil {
+			minScore = *opts.minScore
+		}
+		svc := h.service()
+		svc.Providers = map[string]parser.Provider{opts.providerName: opts.provider}
+		var err error
+		*res, err = svc.Run(ctx, pipeline.RunParams{
+			Provider: opts.providerName,
+			Query:    query,
+			Limit:    limit,
+			MaxAge:   maxAge,
+			MinScore: minScore,
+			Diff:     diff,
+			Timings:  timings,
+
+			Categories:       opts.categories,
+			StrictCategories: opts.strict,
+			Incremental:      opts.incremental,
+		})
+		return err
+	}
+	return job, res
+}
+
+// syncDefaults returns the filter threshold and age limit of syncs that
+// set neither: the runtime settings when there are any, else the
+// configured MinScore and MaxAge.
+func (h *Handler) syncDefaults() (minScore int, maxAge time.Duration) {
+	if h.Settings == nil {
+		return h.MinScore, h.MaxAge
+	}
+	v := h.Settings.Values()
+	return v.MinScore, v.MaxAge()
+}
+
+// service returns the pipeline service for the handler's dependencies.
+func (h *Handler) service() *pipeline.Service {
+	return &pipeline.Service{
+		Providers: map[string]parser.Provider{defaultProvider: h.provider},
+		Store:     h.repo,
+		History:   h.History,
+		Clock:     h.Clock,
+		PageTiers: h.PageTiers,
+		MaxText:   h.MaxText,
+		Limits:    h.Limits,
+		Shadow:    h.Shadow,
+		ShadowLog: h.ShadowLog,
+
+		NearDuplicates: h.NearDuplicates,
+
+		Requests:         h.Requests,
+		RequestRetention: h.RequestRetention,
+		Notify:           h.Notify,
+	}
+}
+
+// GET /api/sync/jobs/:id - Get sync job status and queue position
+func (h *Handler) handleSyncJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/sync/jobs/"))
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	status, ok := h.queue.Status(id)
+	if !ok {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, status)
+}
+
+// GET /api/sync/history?limit= - Recent syncs with per-stage timings
+func (h *Handler) handleSyncHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.History == nil {
+		http.Error(w, "Sync history unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	history, err := h.History.GetSyncHistory(ctx, limit)
+	if err != nil {
+		log.Printf("Error getting sync history: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"syncs": history,
+		"count": len(history),
+	})
+}
+
+// GET /api/sync/:id/requests - HTTP requests a logged sync made
+func (h *Handler) handleSyncRequests(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/sync/"), "/requests")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.Atoi(rest)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid sync ID", http.StatusBadRequest)
+		return
+	}
+	if h.Requests == nil {
+		http.Error(w, "Sync request log unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	requests, err := h.Requests.ListSyncRequests(ctx, id)
+	if err != nil {
+		log.Printf("Error listing sync requests: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"sync_id":  id,
+		"requests": requests,
+		"count":    len(requests),
+	})
+}
+
+// GET /api/filter/shadow-report?rule_set=&limit= - Divergences of a candidate rule set
+func (h *Handler) handleShadowReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.ShadowLog == nil {
+		http.Error(w, "Shadow report unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	// Fetch papers from ArXiv
-	papers, err := h.provider.FetchPapers(query, limit)
+	report, err := h.ShadowLog.ShadowReport(ctx, r.URL.Query().Get("rule_set"), limit)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "No shadow results recorded", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		log.Printf("Error fetching papers: %v", err)
-		http.Error(w, "Failed to fetch papers", http.StatusInternalServerError)
+		log.Printf("Error getting shadow report: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	// Save to database
-	if err := h.repo.SaveBatch(ctx, papers); err != nil {
-		log.Printf("Error saving papers: %v", err)
-		http.Error(w, "Failed to save papers", http.StatusInternalServerError)
+	// The configured candidate can differ from the reported one after a change
+	candidate := ""
+	if h.Shadow != nil {
+		candidate = h.Shadow.Name
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"report":    report,
+		"candidate": candidate,
+	})
+}
+
+// POST /api/presets/reload - Re-read the presets file
+func (h *Handler) handlePresetsReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.PresetsFile == "" {
+		http.Error(w, "No presets file configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	// A file that fails to load leaves the current presets in place
+	if err := preset.LoadFile(h.PresetsFile); err != nil {
+		log.Printf("Error reloading presets: %v", err)
+		http.Error(w, "Reload failed: "+err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]any{
-		"message": "Sync completed",
-		"query":   query,
-		"fetched": len(papers),
+		"file":    h.PresetsFile,
+		"presets": len(preset.List()),
 	})
 }
 
@@ -215,12 +1056,33 @@ func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GET /api/version - Build of the running server
+func (h *Handler) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	respondJSON(w, http.StatusOK, version.Get())
+}
+
 func respondJSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
 
+// respondMinScoreError answers a malformed min_score spec with a 400 that
+// names the offending segment.
+func respondMinScoreError(w http.ResponseWriter, err error) {
+	body := map[string]any{"error": "Invalid min_score"}
+	var specErr *storage.MinScoreError
+	if errors.As(err, &specErr) {
+		body["segment"] = specErr.Segment
+		body["reason"] = specErr.Reason
+	}
+	respondJSON(w, http.StatusBadRequest, body)
+}
+
 // PaperResponse is the JSON response for a paper.
 type PaperResponse struct {
 	ID         string    `json:"id"`
@@ -228,7 +1090,9 @@ type PaperResponse struct {
 	Abstract   string    `json:"abstract"`
 	Authors    []string  `json:"authors"`
 	Categories []string  `json:"categories"`
+	Primary    string    `json:"primary_category,omitempty"`
 	UpdatedAt  time.Time `json:"updated_at"`
+	Published  time.Time `json:"published_at"`
 }
 
 // ToPaperResponse converts a model.Paper to API response.
@@ -239,6 +1103,8 @@ func ToPaperResponse(p model.Paper) PaperResponse {
 		Abstract:   p.Abstract,
 		Authors:    p.Authors,
 		Categories: p.Categories,
+		Primary:    p.PrimaryCategory,
 		UpdatedAt:  p.UpdatedAt,
+		Published:  p.Published,
 	}
 }
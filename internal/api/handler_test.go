@@ -0,0 +1,402 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/diff"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/ingest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+func TestPaperPDF(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	store.SaveBatch(ctx, []model.Paper{{ID: "2401.00001v1"}, {ID: "2401.00002v1"}})
+
+	path := filepath.Join(t.TempDir(), "2401.00001v1.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store.SetPDF(ctx, "2401.00001v1", path, 8)
+
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+
+	rec := get(mux, "/api/papers/2401.00001v1/pdf")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET pdf = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("Content-Type = %q, want application/pdf", ct)
+	}
+	if rec.Body.String() != "%PDF-1.4" {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+
+	if rec := get(mux, "/api/papers/2401.00002v1/pdf"); rec.Code != http.StatusNotFound {
+		t.Errorf("unarchived paper = %d, want 404", rec.Code)
+	}
+}
+
+func TestPaperDiff(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	v1 := model.Paper{ID: "2401.00001v1", Abstract: "We reach 81.2% accuracy."}
+	if _, err := ingest.Save(ctx, store, nil, []model.Paper{v1}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	v3 := model.Paper{ID: "2401.00001v3", Abstract: "We reach 84.7% accuracy on <b>ImageNet</b>."}
+	if _, err := ingest.Save(ctx, store, nil, []model.Paper{v3}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+
+	rec := get(mux, "/api/papers/2401.00001/diff?from=v1&to=v3")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET diff = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		From    int        `json:"from"`
+		To      int        `json:"to"`
+		Summary diff.Stats `json:"summary"`
+		HTML    string     `json:"html"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.From != 1 || body.To != 3 {
+		t.Errorf("from/to = %d/%d, want 1/3", body.From, body.To)
+	}
+	if body.Summary != (diff.Stats{Inserted: 5, Deleted: 1}) {
+		t.Errorf("summary = %+v", body.Summary)
+	}
+	if !strings.Contains(body.HTML, "<del>81.2</del><ins>84.7</ins>") || !strings.Contains(body.HTML, "&lt;b&gt;") {
+		t.Errorf("html = %q", body.HTML)
+	}
+
+	// Defaults span the oldest and newest known versions
+	if rec := get(mux, "/api/papers/2401.00001v3/diff"); rec.Code != http.StatusOK {
+		t.Errorf("GET diff without range = %d, want 200", rec.Code)
+	}
+
+	for path, want := range map[string]int{
+		"/api/papers/2401.00001/diff?from=v2": http.StatusNotFound,
+		"/api/papers/2401.00001/diff?to=vX":   http.StatusBadRequest,
+		"/api/papers/2401.99999/diff":         http.StatusNotFound,
+	} {
+		if rec := get(mux, path); rec.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
+
+func TestPaperDiff_NoAbstractHistory(t *testing.T) {
+	store := memory.New()
+	store.SaveBatch(context.Background(), []model.Paper{{ID: "2401.00001v2"}})
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+
+	rec := get(mux, "/api/papers/2401.00001/diff")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("GET diff = %d, want 404", rec.Code)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != "No abstract history" {
+		t.Errorf("body = %q, want %q", body, "No abstract history")
+	}
+}
+
+func TestPapers_MinScore(t *testing.T) {
+	store := memory.New()
+	store.SaveBatch(context.Background(), []model.Paper{
+		{ID: "2401.00001v1", Categories: []string{"cs.CL"}, Score: 65},
+		{ID: "2401.00002v1", Categories: []string{"math.NA"}, Score: 60},
+	})
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+
+	rec := get(mux, "/api/papers?min_score=cs.CL:70,default:55&limit=1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET = %d, want 200", rec.Code)
+	}
+	var list struct {
+		Papers     []PaperResponse `json:"papers"`
+		NextCursor *string         `json:"next_cursor"`
+	}
+	json.NewDecoder(rec.Body).Decode(&list)
+	if len(list.Papers) != 1 || list.Papers[0].ID != "2401.00002v1" {
+		t.Errorf("papers = %+v, want only the math.NA paper", list.Papers)
+	}
+	// min_score cannot be combined with cursor, so none is offered
+	if list.NextCursor != nil {
+		t.Errorf("next_cursor = %q with min_score, want none", *list.NextCursor)
+	}
+
+	rec = get(mux, "/api/papers?min_score=cs.CL:70,default:lots")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("malformed spec = %d, want 400", rec.Code)
+	}
+	var body map[string]string
+	json.NewDecoder(rec.Body).Decode(&body)
+	if body["segment"] != "default:lots" || body["reason"] == "" {
+		t.Errorf("error body = %v, want the bad segment named", body)
+	}
+
+	if rec := get(mux, "/api/papers?min_score=60&author=smith"); rec.Code != http.StatusBadRequest {
+		t.Errorf("min_score with author = %d, want 400", rec.Code)
+	}
+}
+
+func TestPresetsReload(t *testing.T) {
+	t.Cleanup(preset.Reset)
+	h := NewHandler(memory.New(), nil, nil)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	post := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/presets/reload", nil))
+		return rec
+	}
+
+	if rec := post(); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a presets file = %d, want 503", rec.Code)
+	}
+
+	h.PresetsFile = filepath.Join(t.TempDir(), "presets.json")
+	os.WriteFile(h.PresetsFile, []byte(`[{"name": "rag-ir", "extends": "rag", "categories": ["cs.IR"]}]`), 0o644)
+	if rec := post(); rec.Code != http.StatusOK {
+		t.Fatalf("reload = %d: %s", rec.Code, rec.Body)
+	}
+	if _, ok := preset.Get("rag-ir"); !ok {
+		t.Error("rag-ir not registered after reload")
+	}
+
+	os.WriteFile(h.PresetsFile, []byte(`[{"name": "broken", "extends": "missing"}]`), 0o644)
+	if rec := post(); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("bad file = %d, want 422", rec.Code)
+	}
+	if _, ok := preset.Get("rag-ir"); !ok {
+		t.Error("failed reload dropped the loaded presets")
+	}
+}
+
+func TestPaperByID_Explanation(t *testing.T) {
+	store := memory.New()
+	store.SaveBatch(context.Background(), []model.Paper{{
+		ID:           "2401.00001v1",
+		Score:        40,
+		ScoreDetails: []string{"+30 接收信号", "+10 代码链接"},
+		Links: []model.Link{
+			{URL: "https://arxiv.org/abs/2401.00001v1", Type: model.LinkAbstract},
+			{URL: "https://github.com/org/repo", Type: model.LinkCode, FoundIn: model.FoundInAbstract},
+		},
+	}})
+	h := NewHandler(store, nil, nil)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"", "Scored 40: accepted at a peer-reviewed venue (+30), links to code (+10)."},
+		{"?lang=zh", "得分 40：已被同行评审会议或期刊接收（+30）、附有代码链接（+10）。"},
+	}
+	for _, tc := range tests {
+		rec := get(mux, "/api/papers/2401.00001v1"+tc.query)
+		var resp struct {
+			ID          string
+			Explanation string       `json:"explanation"`
+			Code        []model.Link `json:"code"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if resp.ID != "2401.00001v1" || resp.Explanation != tc.expected {
+			t.Errorf("GET%s = %+v, want explanation %q", tc.query, resp, tc.expected)
+		}
+		// Code links are picked out of Links
+		if len(resp.Code) != 1 || resp.Code[0].URL != "https://github.com/org/repo" || resp.Code[0].FoundIn != model.FoundInAbstract {
+			t.Errorf("GET%s code = %+v, want the repository found in the abstract", tc.query, resp.Code)
+		}
+	}
+}
+
+func TestPaperByID_IDForms(t *testing.T) {
+	store := memory.New()
+	store.SaveBatch(context.Background(), []model.Paper{{ID: "2401.00001v1"}})
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+
+	for _, path := range []string{
+		"/api/papers/2401.00001v1",
+		"/api/papers/arXiv:2401.00001v1",
+		"/api/papers/oai:arXiv.org:2401.00001v1",
+	} {
+		rec := get(mux, path)
+		var resp struct{ ID string }
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if rec.Code != http.StatusOK || resp.ID != "2401.00001v1" {
+			t.Errorf("GET %s = %d %+v, want the stored paper", path, rec.Code, resp)
+		}
+	}
+	if rec := get(mux, "/api/papers/arXiv:2401.00001v1/versions"); rec.Code != http.StatusOK {
+		t.Errorf("GET versions by citation form = %d, want 200", rec.Code)
+	}
+}
+
+func TestPaperByID_OldStyleIDs(t *testing.T) {
+	store := memory.New()
+	store.SaveBatch(context.Background(), []model.Paper{{ID: "cs/0001001v2"}, {ID: "math.GT/0309136v1"}})
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+
+	for path, want := range map[string]string{
+		"/api/papers/cs/0001001v2":                    "cs/0001001v2",
+		"/api/papers/arXiv:cs/0001001v2":              "cs/0001001v2",
+		"/api/papers/math.GT/0309136v1":               "math.GT/0309136v1",
+		"/api/papers/oai:arXiv.org:math.GT/0309136v1": "math.GT/0309136v1",
+	} {
+		rec := get(mux, path)
+		var resp struct{ ID string }
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if rec.Code != http.StatusOK || resp.ID != want {
+			t.Errorf("GET %s = %d %+v, want paper %s", path, rec.Code, resp, want)
+		}
+	}
+	if rec := get(mux, "/api/papers/cs/0001001/versions"); rec.Code != http.StatusOK {
+		t.Errorf("GET versions of an old-style ID = %d, want 200", rec.Code)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(memory.New(), nil, nil).RegisterRoutes(mux)
+
+	rec := get(mux, "/api/version")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/version = %d, want 200", rec.Code)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"version", "commit", "date"} {
+		if body[key] != "dev" {
+			t.Errorf("%s = %q, want dev", key, body[key])
+		}
+	}
+	if body["go_version"] == "" || body["platform"] == "" {
+		t.Errorf("missing runtime fields: %v", body)
+	}
+	if len(body) != 5 {
+		t.Errorf("unexpected fields: %v", body)
+	}
+}
+
+func TestStats_SearchIndexTruncated(t *testing.T) {
+	store := memory.New()
+	store.SaveBatch(context.Background(), []model.Paper{
+		{ID: "2403.00001v1", Abstract: strings.Repeat("long abstract ", 10000)},
+		{ID: "2403.00002v1", Abstract: "Short."},
+	})
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+
+	rec := get(mux, "/api/stats")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/stats = %d", rec.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["search_index_truncated"] != float64(1) {
+		t.Errorf("search_index_truncated = %v, want 1", body["search_index_truncated"])
+	}
+}
+
+// countingHistory is a sync log that counts its syncs by bucket.
+type countingHistory struct {
+	recordingHistory
+	counts map[time.Time]int64
+}
+
+func (h *countingHistory) CountSyncs(ctx context.Context, from, to time.Time, bucket string) (map[time.Time]int64, error) {
+	return h.counts, nil
+}
+
+func TestStats_Ingestion(t *testing.T) {
+	ctx := context.Background()
+	// Wednesday, March 13, 2024; the first paper is 9 days older
+	now := time.Date(2024, 3, 13, 18, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now.AddDate(0, 0, -9))
+	store := memory.New()
+	store.Clock = clk
+	store.SaveBatch(ctx, []model.Paper{{ID: "2403.00001v1", Abstract: "old"}})
+	clk.Advance(9*24*time.Hour - 19*time.Hour) // 23:00 the day before
+	store.SaveBatch(ctx, []model.Paper{{ID: "2403.00002v1", Abstract: "abc"}})
+	clk.Advance(19 * time.Hour)
+	store.SaveBatch(ctx, []model.Paper{{ID: "2403.00003v1", Abstract: "defgh"}, {ID: "2403.00004v1", Abstract: "ij"}})
+
+	h := NewHandler(store, nil, nil)
+	h.Clock = clk
+	h.History = &countingHistory{counts: map[time.Time]int64{time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC): 3}}
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	var body struct {
+		Days    int
+		Bucket  string
+		From    time.Time
+		Buckets []storage.IngestionBucket
+	}
+	rec := get(mux, "/api/stats/ingestion?days=2")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/stats/ingestion = %d: %s", rec.Code, rec.Body)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := []storage.IngestionBucket{
+		{Start: time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), Papers: 1, AbstractBytes: 3},
+		{Start: time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC), Papers: 2, AbstractBytes: 7, Syncs: 3},
+	}
+	if body.Days != 2 || body.Bucket != storage.BucketDay || !body.From.Equal(want[0].Start) || len(body.Buckets) != 2 {
+		t.Fatalf("body = %+v, want 2 day buckets from March 12", body)
+	}
+	for i := range want {
+		if got := body.Buckets[i]; !got.Start.Equal(want[i].Start) || got.Papers != want[i].Papers ||
+			got.AbstractBytes != want[i].AbstractBytes || got.Syncs != want[i].Syncs {
+			t.Errorf("bucket %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	// Ten days by week reach back to Monday, March 4, the old paper's week
+	body.Buckets = nil
+	rec = get(mux, "/api/stats/ingestion?days=10&bucket=week")
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Buckets) != 2 || !body.From.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)) ||
+		body.Buckets[0].Papers != 1 || body.Buckets[1].Papers != 3 {
+		t.Errorf("weeks = %+v, want 1 paper in the week of March 4 and 3 in the week of March 11", body)
+	}
+
+	for _, q := range []string{"days=0", "days=-1", "days=x", "days=100000", "bucket=month"} {
+		if rec := get(mux, "/api/stats/ingestion?"+q); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /api/stats/ingestion?%s = %d, want 400", q, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+)
+
+// providerSet holds the providers built from Handler.Providers, one per
+// name, so each keeps its rate limit and cache across requests.
+type providerSet struct {
+	mu    sync.Mutex
+	built map[string]parser.Provider
+}
+
+// providerFor returns the provider a request named (the default one when
+// name is empty): ErrUnknownProvider when neither NewHandler's provider
+// nor Providers has it, or a parser.ConfigError when it lacks settings.
+func (h *Handler) providerFor(name string) (parser.Provider, error) {
+	if name == "" {
+		name = defaultProvider
+	}
+	var p parser.Provider
+	if name == defaultProvider && h.provider != nil {
+		p = h.provider
+	} else {
+		var err error
+		if p, err = h.buildProvider(name); err != nil {
+			return nil, err
+		}
+	}
+	if err := parser.CheckConfig(name, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// buildProvider returns the provider Providers registers under name,
+// building it on first use.
+func (h *Handler) buildProvider(name string) (parser.Provider, error) {
+	if h.Providers == nil {
+		return nil, parser.ErrUnknownProvider
+	}
+	h.providers.mu.Lock()
+	defer h.providers.mu.Unlock()
+	if p, ok := h.providers.built[name]; ok {
+		return p, nil
+	}
+	p, err := h.Providers.New(name, h.ProviderOptions)
+	if err != nil {
+		return nil, err
+	}
+	if h.providers.built == nil {
+		h.providers.built = make(map[string]parser.Provider)
+	}
+	h.providers.built[name] = p
+	return p, nil
+}
+
+// providerNames lists the names a request may pick, sorted.
+func (h *Handler) providerNames() []string {
+	var names []string
+	if h.Providers != nil {
+		names = h.Providers.Names()
+	}
+	if h.provider != nil && !slices.Contains(names, defaultProvider) {
+		names = append(names, defaultProvider)
+		slices.Sort(names)
+	}
+	return names
+}
+
+// respondProviderError answers a provider that cannot be used: 400 with
+// the names there are for an unknown one, 503 with the missing settings
+// for one that is not configured.
+func (h *Handler) respondProviderError(w http.ResponseWriter, name string, err error) {
+	var ce *parser.ConfigError
+	switch {
+	case errors.As(err, &ce):
+		respondJSON(w, http.StatusServiceUnavailable, map[string]any{
+			"error":    "Provider not configured",
+			"provider": ce.Provider,
+			"missing":  ce.Missing,
+		})
+	case errors.Is(err, parser.ErrUnknownProvider):
+		respondJSON(w, http.StatusBadRequest, map[string]any{
+			"error":     "Unknown provider",
+			"provider":  name,
+			"providers": h.providerNames(),
+		})
+	default:
+		http.Error(w, "Provider unavailable", http.StatusServiceUnavailable)
+	}
+}
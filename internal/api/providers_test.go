@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/syncqueue"
+)
+
+// keyedProvider is a stub provider that needs an API key.
+type keyedProvider struct {
+	stubProvider
+	key string
+}
+
+func (p keyedProvider) MissingConfig() []string {
+	if p.key == "" {
+		return []string{"PROVIDER_KEYS entry for keyed"}
+	}
+	return nil
+}
+
+// providerHistory is a recordingHistory that also keeps the provider of
+// each sync scope.
+type providerHistory struct {
+	recordingHistory
+	mu        sync.Mutex
+	providers map[int]string
+}
+
+func (h *providerHistory) RecordSyncScope(ctx context.Context, id int, scope storage.SyncScope) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.providers == nil {
+		h.providers = make(map[int]string)
+	}
+	h.providers[id] = scope.Provider
+	return nil
+}
+
+func stubRegistry(t *testing.T) *parser.Registry {
+	t.Helper()
+	reg := parser.NewRegistry()
+	for name, f := range map[string]parser.Factory{
+		"stub": func(parser.Options) parser.Provider {
+			return stubProvider{papers: []model.Paper{validPaper("2401.00002v1")}}
+		},
+		"keyed": func(opts parser.Options) parser.Provider {
+			return keyedProvider{stubProvider{papers: []model.Paper{validPaper("2401.00003v1")}}, opts.Keys["keyed"]}
+		},
+	} {
+		if err := reg.Register(name, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return reg
+}
+
+func TestSync_ProviderSelection(t *testing.T) {
+	store := memory.New()
+	history := &providerHistory{}
+	h := NewHandler(store, stubProvider{papers: []model.Paper{validPaper("2401.00001v1")}}, syncqueue.New(syncqueue.Config{}))
+	h.History = history
+	h.Providers = stubRegistry(t)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	sync := func(provider string) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?query=llm&provider="+provider, nil))
+		var body map[string]any
+		json.NewDecoder(rec.Body).Decode(&body)
+		return rec, body
+	}
+
+	rec, body := sync("stub")
+	if rec.Code != http.StatusOK || body["provider"] != "stub" {
+		t.Fatalf("provider=stub: %d %v", rec.Code, body)
+	}
+	if _, err := store.GetByID(context.Background(), "2401.00002v1"); err != nil {
+		t.Errorf("paper from the picked provider not saved: %v", err)
+	}
+	if _, err := store.GetByID(context.Background(), "2401.00001v1"); err == nil {
+		t.Error("the default provider was used")
+	}
+	if history.providers[1] != "stub" {
+		t.Errorf("sync log provider = %q, want stub", history.providers[1])
+	}
+
+	// No provider picks the one given to NewHandler
+	if rec, body := sync(""); rec.Code != http.StatusOK || body["provider"] != model.SourceArxiv {
+		t.Errorf("default provider: %d %v", rec.Code, body)
+	}
+
+	rec, body = sync("nope")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown provider: status %d, want 400", rec.Code)
+	}
+	if want := []any{model.SourceArxiv, "keyed", "stub"}; !reflect.DeepEqual(body["providers"], want) {
+		t.Errorf("providers listed = %v, want %v", body["providers"], want)
+	}
+}
+
+func TestSync_ProviderMissingConfig(t *testing.T) {
+	newMux := func(opts parser.Options) *http.ServeMux {
+		h := NewHandler(memory.New(), nil, syncqueue.New(syncqueue.Config{}))
+		h.Providers = stubRegistry(t)
+		h.ProviderOptions = opts
+		mux := http.NewServeMux()
+		h.RegisterRoutes(mux)
+		return mux
+	}
+
+	rec := httptest.NewRecorder()
+	newMux(parser.Options{}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?provider=keyed", nil))
+	var body struct {
+		Provider string   `json:"provider"`
+		Missing  []string `json:"missing"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusServiceUnavailable || body.Provider != "keyed" || len(body.Missing) != 1 {
+		t.Errorf("missing key: %d %+v, want 503 naming the setting", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	newMux(parser.Options{Keys: map[string]string{"keyed": "secret"}}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?provider=keyed", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("with key: status %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/audit"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/settings"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// maxSettingsBody caps the JSON body of PUT /api/settings.
+const maxSettingsBody = 64 << 10
+
+// GET /api/settings - Effective runtime defaults and the stored overrides (admin token required)
+// PUT /api/settings - Change defaults with a JSON object of key -> value, e.g. {"sync.min_score": 70} (admin token required)
+func (h *Handler) handleSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		respondJSON(w, http.StatusOK, map[string]any{
+			"values":    h.Settings.Values(),
+			"overrides": h.Settings.Overrides(),
+		})
+	case http.MethodPut:
+		h.updateSettings(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// updateSettings applies a PUT and records it in the audit log, rejected
+// ones included. The generic audited wrapper only sees query and form
+// parameters, so the entry is written here, with the changed keys and
+// values.
+func (h *Handler) updateSettings(w http.ResponseWriter, r *http.Request) {
+	var changes map[string]json.RawMessage
+	status := http.StatusOK
+	defer func() {
+		keys := slices.Sorted(maps.Keys(changes))
+		body, _ := json.Marshal(changes)
+		h.Audit.Record(r.Context(), storage.AuditEntry{
+			Actor:  h.actor(r),
+			Action: audit.ActionSettings,
+			Target: strings.Join(keys, ","),
+			Params: r.Method + " " + r.URL.Path + " " + string(body),
+			Status: status,
+		})
+	}()
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSettingsBody))
+	if err := dec.Decode(&changes); err != nil {
+		changes = nil
+		status = http.StatusBadRequest
+		http.Error(w, "Invalid JSON body: expected an object of setting values", status)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	values, err := h.Settings.Update(ctx, changes, h.actor(r))
+	switch {
+	case errors.Is(err, settings.ErrInvalid):
+		status = http.StatusBadRequest
+		http.Error(w, err.Error(), status)
+	case err != nil:
+		log.Printf("Error updating settings: %v", err)
+		status = http.StatusInternalServerError
+		http.Error(w, "Failed to update settings", status)
+	default:
+		respondJSON(w, status, map[string]any{
+			"values":    values,
+			"overrides": h.Settings.Overrides(),
+		})
+	}
+}
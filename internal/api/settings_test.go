@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/audit"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/settings"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/syncqueue"
+)
+
+func TestSync_MinScorePrecedence(t *testing.T) {
+	// validPaper scores 65; the filter's own default threshold is 60
+	tests := []struct {
+		name    string
+		config  int // Handler.MinScore and the settings' defaults
+		runtime int // Stored setting, 0 for none
+		param   string
+		saved   int
+	}{
+		{name: "filter default", saved: 1},
+		{name: "config", config: 70, saved: 0},
+		{name: "runtime over config", config: 70, runtime: 50, saved: 1},
+		{name: "request over runtime", config: 70, runtime: 50, param: "&min_score=90", saved: 0},
+		{name: "request over a stricter runtime", runtime: 90, param: "&min_score=50", saved: 1},
+	}
+
+	for _, tc := range tests {
+		store := memory.New()
+		queue := syncqueue.New(syncqueue.Config{})
+		h := NewHandler(store, stubProvider{papers: []model.Paper{validPaper("2401.00001v1")}}, queue)
+		h.MinScore = tc.config
+		h.Settings = settings.New(store, settings.Values{MinScore: tc.config, NotifyBatchSize: 10, NotifyMaxPerSync: 50})
+		if tc.runtime > 0 {
+			raw, _ := json.Marshal(tc.runtime)
+			if _, err := h.Settings.Update(context.Background(), map[string]json.RawMessage{settings.KeyMinScore: raw}, "test"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		mux := http.NewServeMux()
+		h.RegisterRoutes(mux)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?query=llm"+tc.param, nil))
+		queue.Shutdown(context.Background())
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: POST /api/sync = %d: %s", tc.name, rec.Code, rec.Body)
+		}
+		var body struct{ Saved int }
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Saved != tc.saved {
+			t.Errorf("%s: saved %d, want %d", tc.name, body.Saved, tc.saved)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	NewHandler(memory.New(), stubProvider{}, syncqueue.New(syncqueue.Config{})).RegisterRoutes(mux)
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?query=llm&min_score=101", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("min_score=101: %d, want 400", rec.Code)
+	}
+}
+
+func TestSettings_Endpoint(t *testing.T) {
+	store := memory.New()
+	h := NewHandler(store, stubProvider{}, syncqueue.New(syncqueue.Config{}))
+	h.AdminToken = "admin-secret"
+	h.Audit = audit.NewRecorder(store)
+	h.Audit.Clock = clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	h.Settings = settings.New(store, settings.Values{MinScore: 60, MaxAgeDays: 365, NotifyBatchSize: 10, NotifyMaxPerSync: 50})
+	h.Settings.Clock = clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	serve := func(method, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/settings", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		if rec := serve(method, `{"sync.min_score": 70}`, "wrong"); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s without the admin token = %d, want 401", method, rec.Code)
+		}
+	}
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"sync.min_score": 101}`, http.StatusBadRequest},
+		{`{"sync.min_score": "high"}`, http.StatusBadRequest},
+		{`{"sync.min_score": 70, "digest.hour": 8}`, http.StatusBadRequest},
+		{`{}`, http.StatusBadRequest},
+		{`[70]`, http.StatusBadRequest},
+		{`{"sync.min_score": 70, "notify.batch_size": 5}`, http.StatusOK},
+	}
+	for _, tc := range tests {
+		if rec := serve(http.MethodPut, tc.body, "admin-secret"); rec.Code != tc.code {
+			t.Errorf("PUT %s = %d, want %d: %s", tc.body, rec.Code, tc.code, rec.Body)
+		}
+	}
+
+	rec := serve(http.MethodGet, "", "admin-secret")
+	var got struct {
+		Values    settings.Values
+		Overrides []storage.Setting
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := settings.Values{MinScore: 70, MaxAgeDays: 365, NotifyBatchSize: 5, NotifyMaxPerSync: 50}
+	if got.Values != want {
+		t.Errorf("values = %+v, want %+v", got.Values, want)
+	}
+	if len(got.Overrides) != 2 || got.Overrides[0].Key != settings.KeyNotifyBatchSize || got.Overrides[1].UpdatedBy != "api:192.0.2.1" {
+		t.Errorf("overrides = %+v, want notify.batch_size and sync.min_score by the caller", got.Overrides)
+	}
+
+	// Every PUT that got past the token is audited, rejected ones included
+	entries, _ := store.ListAudit(context.Background(), audit.ActionSettings, 10)
+	if len(entries) != len(tests) {
+		t.Fatalf("%d audit entries, want %d", len(entries), len(tests))
+	}
+	last := entries[0]
+	if last.Actor != "api:192.0.2.1" || last.Target != "notify.batch_size,sync.min_score" || last.Status != http.StatusOK ||
+		last.Params != `PUT /api/settings {"notify.batch_size":5,"sync.min_score":70}` {
+		t.Errorf("audit entry = %+v", last)
+	}
+	if entries[len(entries)-1].Status != http.StatusBadRequest {
+		t.Errorf("rejected update audited with status %d", entries[len(entries)-1].Status)
+	}
+}
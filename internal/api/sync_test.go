@@ -0,0 +1,568 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/providertest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/syncqueue"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/timing"
+)
+
+// validPaper returns a paper that passes metadata validation and the
+// quality filter.
+func validPaper(id string) model.Paper {
+	return model.Paper{
+		ID:        id,
+		Title:     "Paper " + id,
+		Abstract:  "We run experiments on a new benchmark dataset against a strong baseline, with an ablation study.",
+		Authors:   []string{"A. Author"},
+		Comments:  "Accepted at ACL 2024",
+		UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+type stubProvider struct {
+	papers []model.Paper
+	err    error
+}
+
+func (p stubProvider) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	return p.papers, p.err
+}
+
+// failingStore is a memory store whose saves fail.
+type failingStore struct {
+	*memory.Store
+}
+
+func (s failingStore) SaveBatch(ctx context.Context, papers []model.Paper) error {
+	return errors.New("disk full")
+}
+
+func (s failingStore) SaveBatchInserted(ctx context.Context, papers []model.Paper) (map[string]bool, map[string]bool, error) {
+	return nil, nil, errors.New("disk full")
+}
+
+// recordingHistory is a storage.SyncHistory that keeps the last outcome.
+type recordingHistory struct {
+	mu      sync.Mutex
+	status  string
+	timings map[string]int64
+}
+
+func (h *recordingHistory) StartSync(ctx context.Context, query string) (int, error) {
+	return 1, nil
+}
+
+func (h *recordingHistory) CompleteSync(ctx context.Context, id int, fetched, newCount, updated int, timings map[string]int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.status, h.timings = "completed", timings
+	return nil
+}
+
+func (h *recordingHistory) FailSync(ctx context.Context, id int, errMsg string, timings map[string]int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.status, h.timings = "failed", timings
+	return nil
+}
+
+func (h *recordingHistory) GetSyncHistory(ctx context.Context, limit int) ([]storage.SyncLog, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return []storage.SyncLog{{ID: 1, Status: h.status, Timings: h.timings}}, nil
+}
+
+func runSync(t *testing.T, store storage.PaperStore, provider stubProvider) (*Handler, *recordingHistory, syncqueue.Status) {
+	t.Helper()
+
+	queue := syncqueue.New(syncqueue.Config{})
+	history := &recordingHistory{}
+	h := NewHandler(store, provider, queue)
+	h.History = history
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?query=llm&async=true", nil))
+
+	var accepted syncqueue.Status
+	if err := json.NewDecoder(rec.Body).Decode(&accepted); err != nil {
+		t.Fatalf("decode sync response (code %d): %v", rec.Code, err)
+	}
+
+	// Shutdown waits for the running job
+	if err := queue.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	status, ok := queue.Status(accepted.ID)
+	if !ok {
+		t.Fatalf("job %d not found", accepted.ID)
+	}
+	return h, history, status
+}
+
+func TestSync_RecordsStageTimings(t *testing.T) {
+	provider := stubProvider{papers: []model.Paper{validPaper("2401.00001v1")}}
+	_, history, status := runSync(t, memory.New(), provider)
+
+	if status.State != syncqueue.StateCompleted {
+		t.Fatalf("job state = %s, want completed", status.State)
+	}
+	for _, key := range []string{timing.StageFetch, timing.StageSave} {
+		if _, ok := status.Timings[key]; !ok {
+			t.Errorf("job status timings missing %q: %v", key, status.Timings)
+		}
+		if _, ok := history.timings[key]; !ok {
+			t.Errorf("sync log timings missing %q: %v", key, history.timings)
+		}
+	}
+	if history.status != "completed" {
+		t.Errorf("sync log status = %q, want completed", history.status)
+	}
+}
+
+func TestSync_FailureKeepsPartialTimings(t *testing.T) {
+	// Save fails: fetch and save were both timed
+	_, history, status := runSync(t, failingStore{memory.New()}, stubProvider{papers: []model.Paper{validPaper("2401.00001v1")}})
+	if status.State != syncqueue.StateFailed || history.status != "failed" {
+		t.Fatalf("state = %s, log status = %q; want failed", status.State, history.status)
+	}
+	if _, ok := history.timings[timing.StageSave]; !ok {
+		t.Errorf("failed save not timed: %v", history.timings)
+	}
+
+	// Fetch fails: only fetch was timed
+	_, history, _ = runSync(t, memory.New(), stubProvider{err: errors.New("arxiv down")})
+	if _, ok := history.timings[timing.StageFetch]; !ok || len(history.timings) != 1 {
+		t.Errorf("timings after fetch failure = %v, want only fetch", history.timings)
+	}
+}
+
+// totalProvider also reports how many papers match in all.
+type totalProvider struct {
+	stubProvider
+	total int
+}
+
+func (p totalProvider) FetchResult(ctx context.Context, query string, limit int) (parser.FetchResult, error) {
+	return parser.FetchResult{Papers: p.papers, Total: p.total}, p.err
+}
+
+func TestSync_ReportsTotalMatches(t *testing.T) {
+	queue := syncqueue.New(syncqueue.Config{})
+	t.Cleanup(func() { queue.Shutdown(context.Background()) })
+	provider := totalProvider{stubProvider{papers: []model.Paper{validPaper("2401.00001v1")}}, 12431}
+	mux := http.NewServeMux()
+	NewHandler(memory.New(), provider, queue).RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?query=llm", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /api/sync = %d: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Fetched int `json:"fetched"`
+		Total   int `json:"total_matches"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Fetched != 1 || body.Total != 12431 {
+		t.Errorf("response = %+v, want 1 fetched of 12431", body)
+	}
+}
+
+func TestSyncHistory_Endpoint(t *testing.T) {
+	h, _, _ := runSync(t, memory.New(), stubProvider{})
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sync/history", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/sync/history = %d, want 200", rec.Code)
+	}
+
+	var resp struct {
+		Syncs []storage.SyncLog `json:"syncs"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Syncs) != 1 || resp.Syncs[0].Timings == nil {
+		t.Errorf("history = %+v, want one sync with timings", resp.Syncs)
+	}
+}
+
+// fixedShadowLog serves a canned shadow report.
+type fixedShadowLog struct {
+	report storage.ShadowReport
+}
+
+func (l fixedShadowLog) RecordShadowResults(ctx context.Context, results []storage.ShadowResult) error {
+	return nil
+}
+
+func (l fixedShadowLog) ShadowReport(ctx context.Context, ruleSet string, limit int) (storage.ShadowReport, error) {
+	if ruleSet != "" && ruleSet != l.report.RuleSet {
+		return storage.ShadowReport{}, storage.ErrNotFound
+	}
+	return l.report, nil
+}
+
+func TestShadowReport_Endpoint(t *testing.T) {
+	h := NewHandler(memory.New(), nil, nil)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	if rec := get(mux, "/api/filter/shadow-report"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a shadow log = %d, want 503", rec.Code)
+	}
+
+	h.ShadowLog = fixedShadowLog{storage.ShadowReport{
+		RuleSet: "candidate", Syncs: 1, Divergences: 1, ToPass: 1,
+		Recent: []storage.ShadowResult{{RuleSet: "candidate", PaperID: "2401.00001v1", ActiveScore: 40, CandidateScore: 65, CandidatePassed: true}},
+	}}
+	rec := get(mux, "/api/filter/shadow-report")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET = %d, want 200", rec.Code)
+	}
+	var resp struct {
+		Report storage.ShadowReport `json:"report"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Report.ToPass != 1 || len(resp.Report.Recent) != 1 || resp.Report.Recent[0].CandidateScore != 65 {
+		t.Errorf("report = %+v", resp.Report)
+	}
+
+	if rec := get(mux, "/api/filter/shadow-report?rule_set=other"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown rule set = %d, want 404", rec.Code)
+	}
+}
+
+// fixedRequestLog serves canned requests for sync 7.
+type fixedRequestLog struct {
+	requests []storage.SyncRequest
+}
+
+func (l fixedRequestLog) RecordSyncRequests(ctx context.Context, id int, requests []storage.SyncRequest) error {
+	return nil
+}
+
+func (l fixedRequestLog) ListSyncRequests(ctx context.Context, id int) ([]storage.SyncRequest, error) {
+	if id != 7 {
+		return []storage.SyncRequest{}, nil
+	}
+	return l.requests, nil
+}
+
+func (l fixedRequestLog) PruneSyncRequests(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestSyncRequests_Endpoint(t *testing.T) {
+	h := NewHandler(memory.New(), nil, nil)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	if rec := get(mux, "/api/sync/7/requests"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a request log = %d, want 503", rec.Code)
+	}
+
+	h.Requests = fixedRequestLog{[]storage.SyncRequest{
+		{SyncID: 7, URL: "http://export.arxiv.org/api/query?start=0", Status: 200, Bytes: 5120},
+		{SyncID: 7, URL: "http://export.arxiv.org/api/query?start=100", Status: 503, Retries: 2},
+	}}
+	rec := get(mux, "/api/sync/7/requests")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET = %d, want 200", rec.Code)
+	}
+	var resp struct {
+		Requests []storage.SyncRequest `json:"requests"`
+		Count    int                   `json:"count"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Count != 2 || resp.Requests[1].Status != 503 || resp.Requests[1].Retries != 2 {
+		t.Errorf("response = %+v", resp)
+	}
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/api/sync/abc/requests", http.StatusBadRequest},
+		{"/api/sync/7", http.StatusNotFound},
+		{"/api/sync/history", http.StatusServiceUnavailable}, // Still routed to the history handler
+	}
+	for _, tc := range tests {
+		if rec := get(mux, tc.path); rec.Code != tc.code {
+			t.Errorf("GET %s = %d, want %d", tc.path, rec.Code, tc.code)
+		}
+	}
+}
+
+func TestSync_FiltersLikeCLI(t *testing.T) {
+	weak := validPaper("2401.00002v1")
+	weak.Comments, weak.Abstract = "", "A position paper."
+	store := memory.New()
+	provider := stubProvider{papers: []model.Paper{validPaper("2401.00001v1"), weak}}
+
+	_, _, status := runSync(t, store, provider)
+	if status.State != syncqueue.StateCompleted {
+		t.Fatalf("job state = %s, want completed", status.State)
+	}
+	if _, err := store.GetByID(context.Background(), "2401.00001v1"); err != nil {
+		t.Errorf("passing paper not saved: %v", err)
+	}
+	if _, err := store.GetByID(context.Background(), weak.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("paper failing the filter was saved (err %v)", err)
+	}
+}
+
+// scopedProvider records the categories the pipeline scoped it to.
+type scopedProvider struct {
+	stubProvider
+	mu     *sync.Mutex
+	scopes *[][]string
+}
+
+func (p scopedProvider) Scoped(categories []string) parser.Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	*p.scopes = append(*p.scopes, categories)
+	return p.stubProvider
+}
+
+func TestSync_Categories(t *testing.T) {
+	inScope, crossListed := validPaper("2401.00001v1"), validPaper("2401.00002v1")
+	inScope.Categories = []string{"cs.CL"}
+	crossListed.Categories = []string{"cs.LG", "cs.IR"}
+	var scopes [][]string
+	provider := scopedProvider{stubProvider{papers: []model.Paper{inScope, crossListed}}, &sync.Mutex{}, &scopes}
+
+	tests := []struct {
+		query      string
+		code       int
+		outOfScope int
+	}{
+		{"categories=cs.CL,cs.IR", http.StatusOK, 0},
+		{"categories=cs.CL,cs.IR&strict_categories=true", http.StatusOK, 1},
+		{"strict_categories=true", http.StatusBadRequest, 0},
+		{"categories=cs.CL)%20OR%20all:x", http.StatusBadRequest, 0},
+	}
+
+	for _, tc := range tests {
+		queue := syncqueue.New(syncqueue.Config{})
+		mux := http.NewServeMux()
+		NewHandler(memory.New(), provider, queue).RegisterRoutes(mux)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?query=llm&"+tc.query, nil))
+		queue.Shutdown(context.Background())
+		if rec.Code != tc.code {
+			t.Errorf("%s: POST /api/sync = %d, want %d: %s", tc.query, rec.Code, tc.code, rec.Body)
+			continue
+		}
+		if tc.code != http.StatusOK {
+			continue
+		}
+		var body struct {
+			Categories []string `json:"categories"`
+			OutOfScope int      `json:"out_of_scope"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Categories) != 2 || body.OutOfScope != tc.outOfScope {
+			t.Errorf("%s: response = %+v, want both categories and %d out of scope", tc.query, body, tc.outOfScope)
+		}
+	}
+	if len(scopes) != 2 {
+		t.Errorf("provider scoped %d times, want once per accepted sync", len(scopes))
+	}
+}
+
+func TestSync_IncrementalParams(t *testing.T) {
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"incremental=false", http.StatusOK},
+		{"incremental=maybe", http.StatusBadRequest},
+		// The history below keeps no checkpoints to fetch since
+		{"incremental=true", http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		queue := syncqueue.New(syncqueue.Config{})
+		mux := http.NewServeMux()
+		h := NewHandler(memory.New(), stubProvider{papers: []model.Paper{validPaper("2401.00001v1")}}, queue)
+		h.History = &recordingHistory{}
+		h.RegisterRoutes(mux)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?query=llm&"+tc.query, nil))
+		queue.Shutdown(context.Background())
+		if rec.Code != tc.code {
+			t.Errorf("%s: POST /api/sync = %d, want %d: %s", tc.query, rec.Code, tc.code, rec.Body)
+		}
+	}
+}
+
+func TestSync_SourceErrors(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected int
+	}{
+		{http.StatusBadRequest, http.StatusBadRequest},
+		{http.StatusTooManyRequests, http.StatusTooManyRequests},
+		{http.StatusServiceUnavailable, http.StatusBadGateway},
+	}
+
+	for _, tc := range tests {
+		source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(tc.status), tc.status)
+		}))
+		client := arxiv.NewClientWithOptions(source.Client(), source.URL)
+		queue := syncqueue.New(syncqueue.Config{})
+		mux := http.NewServeMux()
+		NewHandler(memory.New(), client, queue).RegisterRoutes(mux)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?query=llm", nil))
+		queue.Shutdown(context.Background())
+		source.Close()
+		if rec.Code != tc.expected {
+			t.Errorf("arXiv %d: POST /api/sync = %d, want %d: %s", tc.status, rec.Code, tc.expected, rec.Body)
+		}
+	}
+}
+
+func TestSync_ProviderCalls(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected providertest.Call
+		fetched  int
+	}{
+		{"/api/sync", providertest.Call{Query: "machine learning", Limit: 20}, 20},
+		{"/api/sync?query=llm&limit=5", providertest.Call{Query: "llm", Limit: 5}, 5},
+		{"/api/sync?query=llm&limit=500", providertest.Call{Query: "llm", Limit: 20}, 20},
+	}
+
+	for _, tc := range tests {
+		provider := &providertest.Mock{Papers: providertest.SamplePapers(30)}
+		queue := syncqueue.New(syncqueue.Config{})
+		mux := http.NewServeMux()
+		NewHandler(memory.New(), provider, queue).RegisterRoutes(mux)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tc.url, nil))
+		queue.Shutdown(context.Background())
+		var body struct{ Fetched, Saved int }
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("%s: POST = %d, %v", tc.url, rec.Code, err)
+		}
+		if calls := provider.Calls(); len(calls) != 1 || calls[0] != tc.expected {
+			t.Errorf("%s: provider calls = %v, want [%v]", tc.url, calls, tc.expected)
+		}
+		if body.Fetched != tc.fetched || body.Saved != tc.fetched {
+			t.Errorf("%s: fetched %d, saved %d, want %d", tc.url, body.Fetched, body.Saved, tc.fetched)
+		}
+	}
+
+	// Rejected parameters never reach the provider
+	provider := &providertest.Mock{}
+	mux := http.NewServeMux()
+	NewHandler(memory.New(), provider, syncqueue.New(syncqueue.Config{})).RegisterRoutes(mux)
+	for _, url := range []string{"/api/sync?categories=cs.CL)", "/api/sync?min_score=high", "/api/sync?incremental=maybe"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, url, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: POST = %d, want 400", url, rec.Code)
+		}
+	}
+	if n := provider.CallCount(); n != 0 {
+		t.Errorf("provider called %d times for rejected requests", n)
+	}
+}
+
+func TestSync_ProviderErrors(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{fmt.Errorf("search: %w", parser.ErrBadQuery), http.StatusBadRequest},
+		{fmt.Errorf("search: %w", parser.ErrRateLimited), http.StatusTooManyRequests},
+		{fmt.Errorf("search: %w", parser.ErrUnavailable), http.StatusBadGateway},
+		{fmt.Errorf("search: %w", parser.ErrDecode), http.StatusBadGateway},
+		{errors.New("unexpected"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		store := memory.New()
+		queue := syncqueue.New(syncqueue.Config{})
+		mux := http.NewServeMux()
+		NewHandler(store, &providertest.Mock{Err: tc.err}, queue).RegisterRoutes(mux)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?query=llm", nil))
+		queue.Shutdown(context.Background())
+		if rec.Code != tc.expected {
+			t.Errorf("%v: POST /api/sync = %d, want %d: %s", tc.err, rec.Code, tc.expected, rec.Body)
+		}
+	}
+}
+
+func TestSync_AsyncWhileFetching(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	provider := &providertest.Mock{Papers: providertest.SamplePapers(3), Latency: 30 * time.Second, Clock: clk}
+	queue := syncqueue.New(syncqueue.Config{})
+	mux := http.NewServeMux()
+	NewHandler(memory.New(), provider, queue).RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sync?query=llm&async=true", nil))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /api/sync?async=true = %d, want 202", rec.Code)
+	}
+	var accepted syncqueue.Status
+	if err := json.NewDecoder(rec.Body).Decode(&accepted); err != nil {
+		t.Fatal(err)
+	}
+
+	// The job runs while the provider is still fetching
+	clk.BlockUntil(1)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/sync/jobs/%d", accepted.ID), nil))
+	var status syncqueue.Status
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.State != syncqueue.StateRunning {
+		t.Errorf("job state while fetching = %s, want running", status.State)
+	}
+
+	clk.Advance(30 * time.Second)
+	if err := queue.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := queue.Status(accepted.ID); status.State != syncqueue.StateCompleted {
+		t.Errorf("job state after the fetch = %s, want completed", status.State)
+	}
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/audit"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/syncqueue"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// uiPageSize is the number of papers per page in the web UI.
+const uiPageSize = 20
+
+var uiFuncs = template.FuncMap{
+	"explain":    func(p model.Paper) string { return filter.Explain(p.Score, p.ScoreDetails, filter.LangEN) },
+	"join":       strings.Join,
+	"plainTitle": func(title string) string { return textutil.RenderTitle(title, textutil.TitlePlain) },
+	"scoreClass": func(score int) string {
+		switch {
+		case score >= 70:
+			return "high"
+		case score >= 50:
+			return "mid"
+		default:
+			return "low"
+		}
+	},
+}
+
+// Each page is parsed with the shared layout into its own set, so pages can
+// define the same block names.
+var uiTemplates = map[string]*template.Template{
+	"list":  template.Must(template.New("").Funcs(uiFuncs).ParseFS(templateFS, "templates/layout.html", "templates/list.html")),
+	"paper": template.Must(template.New("").Funcs(uiFuncs).ParseFS(templateFS, "templates/layout.html", "templates/paper.html")),
+}
+
+// uiPage is the data passed to every UI template.
+type uiPage struct {
+	Query    string
+	Notice   string
+	SyncForm bool
+
+	// List page
+	Papers   []model.Paper
+	Page     int
+	PrevPage int
+	NextPage int
+	HasNext  bool
+
+	// Paper page
+	Paper model.Paper
+}
+
+// registerUIRoutes registers the HTML interface. It reads through the same
+// PaperStore and sync queue as the JSON API.
+func (h *Handler) registerUIRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/", h.handleUIList)
+	mux.HandleFunc("/papers/", h.handleUIPaper)
+	mux.HandleFunc("/sync", h.audited(audit.ActionSync, formValue("query"), h.handleUISync))
+}
+
+// GET /?page=&q= - Paper list and search results
+func (h *Handler) handleUIList(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	data := uiPage{
+		Query:    strings.TrimSpace(r.URL.Query().Get("q")),
+		SyncForm: h.SyncForm,
+		Page:     page,
+		PrevPage: page - 1,
+		NextPage: page + 1,
+	}
+	if id, err := strconv.Atoi(r.URL.Query().Get("job")); err == nil {
+		data.Notice = h.jobNotice(id)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var err error
+	if data.Query != "" {
+		data.Papers, err = h.repo.Search(ctx, data.Query, uiPageSize)
+	} else {
+		data.Papers, err = h.repo.List(ctx, uiPageSize, (page-1)*uiPageSize)
+		data.HasNext = len(data.Papers) == uiPageSize
+	}
+	if err != nil {
+		log.Printf("Error listing papers: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	renderPage(w, "list", data)
+}
+
+// GET /papers/:id - Paper detail with score breakdown
+func (h *Handler) handleUIPaper(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := paperid.Normalize(strings.TrimPrefix(r.URL.Path, "/papers/"))
+	if id == "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	paper, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		log.Printf("Error getting paper: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	renderPage(w, "paper", uiPage{Paper: paper, SyncForm: h.SyncForm})
+}
+
+// POST /sync - Queue a sync from the UI form and return to the list
+func (h *Handler) handleUISync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.SyncForm {
+		http.Error(w, "Sync from the web UI is disabled", http.StatusForbidden)
+		return
+	}
+
+	query := strings.TrimSpace(r.FormValue("query"))
+	if query == "" {
+		query = "machine learning"
+	}
+
+	job, _ := h.newSyncJob(query, 20, syncOptions{})
+	if err := h.queue.Submit(job); err != nil {
+		if errors.Is(err, syncqueue.ErrQueueFull) {
+			http.Error(w, "Sync queue full, try again later", http.StatusTooManyRequests)
+			return
+		}
+		http.Error(w, "Sync unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/?job=%d", job.ID()), http.StatusSeeOther)
+}
+
+// jobNotice describes a sync job for the banner shown after submitting.
+func (h *Handler) jobNotice(id int) string {
+	if h.queue == nil {
+		return ""
+	}
+	status, ok := h.queue.Status(id)
+	if !ok {
+		return ""
+	}
+	notice := fmt.Sprintf("Sync #%d for %q is %s", status.ID, status.Query, status.State)
+	if status.Position > 0 {
+		notice += fmt.Sprintf(" (position %d)", status.Position)
+	}
+	return notice + "."
+}
+
+// renderPage renders into a buffer first so a template error yields a clean 500.
+func renderPage(w http.ResponseWriter, name string, data uiPage) {
+	var buf bytes.Buffer
+	if err := uiTemplates[name].ExecuteTemplate(&buf, "layout", data); err != nil {
+		log.Printf("Error rendering %s page: %v", name, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
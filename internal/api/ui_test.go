@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+func newUITestServer(t *testing.T, papers []model.Paper) *http.ServeMux {
+	t.Helper()
+
+	store := memory.New()
+	if err := store.SaveBatch(context.Background(), papers); err != nil {
+		t.Fatalf("SaveBatch: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	NewHandler(store, nil, nil).RegisterRoutes(mux)
+	return mux
+}
+
+func get(mux *http.ServeMux, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestUI_List(t *testing.T) {
+	mux := newUITestServer(t, []model.Paper{
+		{
+			ID:         "2401.00001v1",
+			Title:      `$\alpha$-Sparse Attention <script>`,
+			Authors:    []string{"Ada Lovelace", "Alan Turing"},
+			Categories: []string{"cs.LG", "cs.AI"},
+			Score:      82,
+			UpdatedAt:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:        "2401.00002v1",
+			Title:     "Graph Transformers",
+			Score:     40,
+			UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	})
+
+	rec := get(mux, "/")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET / = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`<a href="/papers/2401.00001v1">α-Sparse Attention &lt;script&gt;</a>`,
+		`<span class="badge high" title="Quality score">82</span>`,
+		`<span class="badge low" title="Quality score">40</span>`,
+		`<span class="chip">cs.LG</span>`,
+		"Ada Lovelace, Alan Turing",
+		`name="q"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("list page missing %q", want)
+		}
+	}
+	if strings.Contains(body, `action="/sync"`) {
+		t.Error("sync form shown while disabled")
+	}
+	if strings.Index(body, "2401.00001v1") > strings.Index(body, "2401.00002v1") {
+		t.Error("papers not ordered newest first")
+	}
+}
+
+func TestUI_ListSearchAndPaging(t *testing.T) {
+	var papers []model.Paper
+	for i := 0; i < uiPageSize+5; i++ {
+		papers = append(papers, model.Paper{
+			ID:        fmt.Sprintf("2401.%05dv1", i),
+			Title:     fmt.Sprintf("Paper %d", i),
+			UpdatedAt: time.Date(2024, 1, 1, 0, i, 0, 0, time.UTC),
+		})
+	}
+	papers[3].Title = "Diffusion Policies"
+	mux := newUITestServer(t, papers)
+
+	first := get(mux, "/").Body.String()
+	if !strings.Contains(first, `href="/?page=2"`) {
+		t.Error("first page should link to page 2")
+	}
+	second := get(mux, "/?page=2").Body.String()
+	if strings.Count(second, `class="paper"`) != 5 {
+		t.Errorf("page 2 shows %d papers, want 5", strings.Count(second, `class="paper"`))
+	}
+	if strings.Contains(second, `href="/?page=3"`) {
+		t.Error("last page should not link further")
+	}
+
+	search := get(mux, "/?q=diffusion").Body.String()
+	if strings.Count(search, `class="paper"`) != 1 || !strings.Contains(search, "Diffusion Policies") {
+		t.Errorf("search page did not show the single match")
+	}
+}
+
+func TestUI_Paper(t *testing.T) {
+	mux := newUITestServer(t, []model.Paper{{
+		ID:           "2401.00001v2",
+		Title:        "Sparse Attention",
+		Abstract:     "We benchmark sparse attention.",
+		Comments:     "Accepted at ICML",
+		DOI:          "10.1000/xyz",
+		Pages:        12,
+		Figures:      4,
+		Score:        65,
+		ScoreDetails: []string{"+30 接收信号", "+20 DOI/期刊引用"},
+		Links:        []model.Link{{URL: "https://github.com/example/sparse", Type: "code"}},
+		UpdatedAt:    time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}})
+
+	rec := get(mux, "/papers/2401.00001v2")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET paper = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"<title>Sparse Attention - Genesis Pipeline</title>",
+		`<span class="badge mid" title="Quality score">65</span>`,
+		"30 接收信号</li>",
+		"<p>Scored 65: accepted at a peer-reviewed venue (&#43;30), has a DOI or journal reference (&#43;20).</p>",
+		`href="https://arxiv.org/abs/2401.00001v2"`,
+		`href="https://arxiv.org/pdf/2401.00001v2"`,
+		`href="https://doi.org/10.1000/xyz"`,
+		`href="https://github.com/example/sparse"`,
+		"Comments: Accepted at ICML",
+		"Length: 12 pages, 4 figures",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("paper page missing %q", want)
+		}
+	}
+
+	if rec := get(mux, "/papers/9999.99999v1"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing paper = %d, want 404", rec.Code)
+	}
+	if rec := get(mux, "/nope"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown path = %d, want 404", rec.Code)
+	}
+}
+
+func TestUI_PaperOldStyleID(t *testing.T) {
+	mux := newUITestServer(t, []model.Paper{
+		{ID: "math.GT/0309136v3", Title: "Knots"},
+		{ID: "file-7", Title: "Local Notes", Source: model.SourceFile + "notes.jsonl"},
+	})
+
+	rec := get(mux, "/papers/math.GT/0309136v3")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET old-style paper = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`href="https://arxiv.org/abs/math/0309136v3"`,
+		`href="https://arxiv.org/pdf/math/0309136v3"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("paper page missing %q", want)
+		}
+	}
+
+	// A paper from another source has no arXiv links to show
+	rec = get(mux, "/papers/file-7")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "arxiv.org") {
+		t.Errorf("GET file paper = %d, arXiv links shown: %t", rec.Code, strings.Contains(rec.Body.String(), "arxiv.org"))
+	}
+}
+
+func TestUI_SyncDisabled(t *testing.T) {
+	mux := newUITestServer(t, nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/sync", strings.NewReader("query=llm")))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("POST /sync = %d, want 403", rec.Code)
+	}
+}
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/audit"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/feedtoken"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+const (
+	maxWatchlistBody = 16 << 10 // Caps the JSON body of POST /api/watchlists
+	maxWatchlistName = 200      // Matches the name column
+)
+
+// watchlistView is a watchlist as the admin endpoints show it.
+type watchlistView struct {
+	storage.Watchlist
+	FeedToken string `json:"feed_token,omitempty"` // Empty when the feed is revoked
+}
+
+func (h *Handler) watchlistView(wl storage.Watchlist) watchlistView {
+	v := watchlistView{Watchlist: wl}
+	if wl.FeedKey != "" {
+		v.FeedToken = feedtoken.New(h.FeedSecret).Token(wl.ID, wl.FeedKey)
+	}
+	return v
+}
+
+// watchlistTarget names the watchlist a request acts on, for the audit
+// log ("" when creating one).
+func watchlistTarget(r *http.Request) string {
+	rest := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/watchlists"), "/")
+	id, _, _ := strings.Cut(rest, "/")
+	return id
+}
+
+// GET /api/watchlists - Watchlists with their feed tokens (admin token required)
+// POST /api/watchlists - Create a watchlist from a JSON object {"name", "authors", "title_terms"} (admin token required)
+func (h *Handler) handleWatchlists(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	switch r.Method {
+	case http.MethodGet:
+		watchlists, err := h.Watchlists.ListWatchlists(ctx)
+		if err != nil {
+			log.Printf("Error listing watchlists: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		views := make([]watchlistView, len(watchlists))
+		for i, wl := range watchlists {
+			views[i] = h.watchlistView(wl)
+		}
+		respondJSON(w, http.StatusOK, map[string]any{
+			"watchlists": views,
+			"count":      len(views),
+		})
+	case http.MethodPost:
+		h.createWatchlist(ctx, w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) createWatchlist(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name       string   `json:"name"`
+		Authors    []string `json:"authors"`
+		TitleTerms []string `json:"title_terms"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWatchlistBody))
+	if err := dec.Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON body: expected an object with name, authors and title_terms", http.StatusBadRequest)
+		return
+	}
+
+	wl := storage.Watchlist{
+		Name:       strings.TrimSpace(body.Name),
+		Authors:    nonBlank(body.Authors),
+		TitleTerms: nonBlank(body.TitleTerms),
+	}
+	switch {
+	case wl.Name == "" || len(wl.Name) > maxWatchlistName:
+		http.Error(w, "name must be 1-200 characters", http.StatusBadRequest)
+		return
+	case len(wl.Authors) == 0 && len(wl.TitleTerms) == 0:
+		http.Error(w, "A watchlist needs at least one of authors and title_terms", http.StatusBadRequest)
+		return
+	}
+
+	key, err := feedtoken.NewKey()
+	if err != nil {
+		log.Printf("Error generating feed key: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	wl.FeedKey = key
+
+	wl, err = h.Watchlists.CreateWatchlist(ctx, wl)
+	if err != nil {
+		log.Printf("Error creating watchlist: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusCreated, h.watchlistView(wl))
+}
+
+// nonBlank returns the trimmed entries of s that are not empty.
+func nonBlank(s []string) []string {
+	kept := []string{}
+	for _, v := range s {
+		if v = strings.TrimSpace(v); v != "" {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// GET, HEAD /api/watchlists/:id/feed.atom?token=&limit= - A watchlist's hits as an Atom feed (feed token required)
+// GET, HEAD /api/watchlists/:id/feed.json?token=&limit= - The same as a JSON Feed
+// GET, DELETE /api/watchlists/:id - Show or delete a watchlist (admin token required)
+// POST, DELETE /api/watchlists/:id/token - Issue a new feed token, revoking the old one, or revoke the feed (admin token required)
+func (h *Handler) handleWatchlist(w http.ResponseWriter, r *http.Request) {
+	rawID, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/watchlists/"), "/")
+	id, err := strconv.Atoi(rawID)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid watchlist ID", http.StatusBadRequest)
+		return
+	}
+
+	switch sub {
+	case "feed.atom", "feed.json":
+		h.serveWatchlistFeed(w, r, id, sub == "feed.json")
+	case "", "token":
+		if h.AdminToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+		next := h.watchlistByID
+		if sub == "token" {
+			next = h.watchlistToken
+		}
+		h.requireAdmin(h.audited(audit.ActionWatchlist, watchlistTarget, func(w http.ResponseWriter, r *http.Request) {
+			next(w, r, id)
+		}))(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) watchlistByID(w http.ResponseWriter, r *http.Request, id int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		var wl storage.Watchlist
+		if wl, err = h.Watchlists.GetWatchlist(ctx, id); err == nil {
+			respondJSON(w, http.StatusOK, h.watchlistView(wl))
+			return
+		}
+	case http.MethodDelete:
+		if err = h.Watchlists.DeleteWatchlist(ctx, id); err == nil {
+			respondJSON(w, http.StatusOK, map[string]any{"id": id, "deleted": true})
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	respondWatchlistError(w, err)
+}
+
+func (h *Handler) watchlistToken(w http.ResponseWriter, r *http.Request, id int) {
+	var key string
+	switch r.Method {
+	case http.MethodPost:
+		var err error
+		if key, err = feedtoken.NewKey(); err != nil {
+			log.Printf("Error generating feed key: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodDelete:
+		// An empty key matches no token
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Watchlists.SetWatchlistFeedKey(ctx, id, key); err != nil {
+		respondWatchlistError(w, err)
+		return
+	}
+	wl, err := h.Watchlists.GetWatchlist(ctx, id)
+	if err != nil {
+		respondWatchlistError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, h.watchlistView(wl))
+}
+
+func respondWatchlistError(w http.ResponseWriter, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Watchlist not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("Error accessing watchlist: %v", err)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+// serveWatchlistFeed answers a feed request bearing a token for the
+// watchlist's current feed key. Tokens are checked before the watchlist is
+// looked up, so watchlist IDs cannot be probed without one.
+func (h *Handler) serveWatchlistFeed(w http.ResponseWriter, r *http.Request, id int, asJSON bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	matcher, ok := h.repo.(storage.WatchlistMatcher)
+	if !ok {
+		http.Error(w, "Watchlist feeds unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	tokenID, key, err := feedtoken.New(h.FeedSecret).Parse(r.URL.Query().Get("token"))
+	if err != nil || tokenID != id {
+		http.Error(w, "Invalid feed token", http.StatusForbidden)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	wl, err := h.Watchlists.GetWatchlist(ctx, id)
+	if err != nil {
+		respondWatchlistError(w, err)
+		return
+	}
+	if wl.FeedKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(wl.FeedKey)) != 1 {
+		http.Error(w, "Feed token revoked", http.StatusForbidden)
+		return
+	}
+
+	contentType := feedContentType
+	if asJSON {
+		contentType = jsonFeedContentType
+	}
+	if h.checkNotModified(w, r, contentType) {
+		return
+	}
+
+	papers, err := matcher.ListWatchlistHits(ctx, wl, feedLimit(r))
+	if err != nil {
+		log.Printf("Error listing watchlist hits: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	src := feedSource{
+		ID:     "urn:genesis-pipeline:watchlist:" + strconv.Itoa(id),
+		Title:  "Genesis Pipeline watchlist: " + wl.Name,
+		Self:   r.URL.RequestURI(),
+		Papers: papers,
+	}
+	if asJSON {
+		writeJSONFeed(w, src)
+		return
+	}
+	h.writeAtom(w, src)
+}
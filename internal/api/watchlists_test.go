@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+// newWatchlistServer serves a store holding a paper by José García, one
+// titled about diffusion and one matching neither.
+func newWatchlistServer(t *testing.T) *http.ServeMux {
+	t.Helper()
+	store := memory.New()
+	store.SaveBatch(context.Background(), []model.Paper{
+		{
+			ID:        "2401.00001v1",
+			Title:     "Sparse Attention",
+			Authors:   []string{"José García"},
+			Published: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Links: []model.Link{
+				{URL: "https://arxiv.org/abs/2401.00001v1", Type: "abstract"},
+				{URL: "https://arxiv.org/pdf/2401.00001v1", Type: "pdf"},
+			},
+		},
+		{
+			ID:        "2401.00002v1",
+			Title:     "Latent Diffusion Revisited",
+			Authors:   []string{"Alice Smith"},
+			UpdatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:        "2401.00003v1",
+			Title:     "Unrelated",
+			Authors:   []string{"Bob Jones"},
+			UpdatedAt: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+		},
+	})
+	h := NewHandler(store, nil, nil)
+	h.AdminToken = "admin-secret"
+	h.Watchlists = store
+	h.FeedSecret = "feed-secret"
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	return mux
+}
+
+func adminDo(mux *http.ServeMux, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// createWatchlist creates a watchlist and returns its feed token.
+func createWatchlist(t *testing.T, mux *http.ServeMux, body string) (id int, token string) {
+	t.Helper()
+	rec := adminDo(mux, http.MethodPost, "/api/watchlists", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /api/watchlists = %d: %s", rec.Code, rec.Body)
+	}
+	var created struct {
+		ID        int    `json:"id"`
+		FeedToken string `json:"feed_token"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.FeedToken == "" {
+		t.Fatal("created watchlist has no feed token")
+	}
+	return created.ID, created.FeedToken
+}
+
+func TestWatchlistFeed_JSON(t *testing.T) {
+	mux := newWatchlistServer(t)
+	id, token := createWatchlist(t, mux, `{"name": "Garcia & diffusion", "authors": ["garcia"], "title_terms": ["DIFFUSION"]}`)
+
+	rec := get(mux, "/api/watchlists/"+strconv.Itoa(id)+"/feed.json?token="+token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET feed.json = %d: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != jsonFeedContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var feed map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("feed is not valid JSON: %v", err)
+	}
+	if feed["version"] != "https://jsonfeed.org/version/1.1" || feed["title"] != "Genesis Pipeline watchlist: Garcia & diffusion" {
+		t.Errorf("feed = %v", feed)
+	}
+	if url, _ := feed["feed_url"].(string); !strings.HasPrefix(url, "/api/watchlists/"+strconv.Itoa(id)+"/feed.json?") {
+		t.Errorf("feed_url = %v", feed["feed_url"])
+	}
+
+	items, _ := feed["items"].([]any)
+	if len(items) != 2 {
+		t.Fatalf("items = %v, want the two hits, newest first", items)
+	}
+	noLinks := items[0].(map[string]any)
+	if noLinks["id"] != "urn:genesis-pipeline:paper:2401.00002v1" {
+		t.Errorf("items[0].id = %v", noLinks["id"])
+	}
+	if _, ok := noLinks["url"]; ok {
+		t.Errorf("item without links has url %v", noLinks["url"])
+	}
+	linked := items[1].(map[string]any)
+	if linked["id"] != "urn:genesis-pipeline:paper:2401.00001v1" || linked["url"] != "https://arxiv.org/abs/2401.00001v1" {
+		t.Errorf("items[1] = %v", linked)
+	}
+	if linked["date_published"] != "2024-01-01T00:00:00Z" || linked["date_modified"] != "2024-01-01T00:00:00Z" {
+		t.Errorf("items[1] dates = %v, %v", linked["date_published"], linked["date_modified"])
+	}
+	authors, _ := linked["authors"].([]any)
+	if len(authors) != 1 || authors[0].(map[string]any)["name"] != "José García" {
+		t.Errorf("items[1].authors = %v", linked["authors"])
+	}
+	attachments, _ := linked["attachments"].([]any)
+	if len(attachments) != 1 || attachments[0].(map[string]any)["mime_type"] != "application/pdf" {
+		t.Errorf("items[1].attachments = %v", linked["attachments"])
+	}
+}
+
+func TestWatchlistFeed_Atom(t *testing.T) {
+	mux := newWatchlistServer(t)
+	id, token := createWatchlist(t, mux, `{"name": "Smith", "authors": ["smith"]}`)
+
+	rec := get(mux, "/api/watchlists/"+strconv.Itoa(id)+"/feed.atom?token="+token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET feed.atom = %d: %s", rec.Code, rec.Body)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("feed is not valid XML: %v", err)
+	}
+	if feed.Title != "Genesis Pipeline watchlist: Smith" || feed.ID != "urn:genesis-pipeline:watchlist:"+strconv.Itoa(id) {
+		t.Errorf("feed = %q %q", feed.Title, feed.ID)
+	}
+	if len(feed.Entries) != 1 || feed.Entries[0].ID != "urn:genesis-pipeline:paper:2401.00002v1" {
+		t.Fatalf("entries = %+v, want the Smith paper", feed.Entries)
+	}
+	if links := feed.Entries[0].Links; len(links) != 0 {
+		t.Errorf("entry without links has links %+v", links)
+	}
+}
+
+func TestWatchlistFeed_Token(t *testing.T) {
+	mux := newWatchlistServer(t)
+	id, token := createWatchlist(t, mux, `{"name": "Smith", "authors": ["smith"]}`)
+	otherID, otherToken := createWatchlist(t, mux, `{"name": "Jones", "authors": ["jones"]}`)
+	feed := "/api/watchlists/" + strconv.Itoa(id) + "/feed.atom"
+
+	tests := map[string]string{
+		"missing":         feed,
+		"garbage":         feed + "?token=not-a-token",
+		"tampered":        feed + "?token=" + flip(token[0]) + token[1:],
+		"other watchlist": feed + "?token=" + otherToken,
+	}
+	for name, path := range tests {
+		if rec := get(mux, path); rec.Code != http.StatusForbidden {
+			t.Errorf("%s token: GET = %d, want 403", name, rec.Code)
+		}
+	}
+	if rec := get(mux, "/api/watchlists/"+strconv.Itoa(otherID)+"/feed.json?token="+otherToken); rec.Code != http.StatusOK {
+		t.Errorf("other watchlist's own feed = %d, want 200", rec.Code)
+	}
+
+	// Without the admin token the management routes are closed
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/watchlists/"+strconv.Itoa(id)+"/token", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST token without admin token = %d, want 401", rec.Code)
+	}
+}
+
+func TestWatchlistFeed_Revoke(t *testing.T) {
+	mux := newWatchlistServer(t)
+	id, token := createWatchlist(t, mux, `{"name": "Smith", "authors": ["smith"]}`)
+	feed := "/api/watchlists/" + strconv.Itoa(id) + "/feed.json?token="
+
+	if rec := adminDo(mux, http.MethodDelete, "/api/watchlists/"+strconv.Itoa(id)+"/token", ""); rec.Code != http.StatusOK {
+		t.Fatalf("DELETE token = %d: %s", rec.Code, rec.Body)
+	} else if strings.Contains(rec.Body.String(), "feed_token") {
+		t.Errorf("revoked watchlist still shows a token: %s", rec.Body)
+	}
+	if rec := get(mux, feed+token); rec.Code != http.StatusForbidden {
+		t.Errorf("GET with revoked token = %d, want 403", rec.Code)
+	}
+
+	rec := adminDo(mux, http.MethodPost, "/api/watchlists/"+strconv.Itoa(id)+"/token", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST token = %d: %s", rec.Code, rec.Body)
+	}
+	var rotated struct {
+		FeedToken string `json:"feed_token"`
+	}
+	json.NewDecoder(rec.Body).Decode(&rotated)
+	if rotated.FeedToken == "" || rotated.FeedToken == token {
+		t.Fatalf("rotated token = %q, want a new one", rotated.FeedToken)
+	}
+	if rec := get(mux, feed+token); rec.Code != http.StatusForbidden {
+		t.Errorf("GET with the old token after rotation = %d, want 403", rec.Code)
+	}
+	if rec := get(mux, feed+rotated.FeedToken); rec.Code != http.StatusOK {
+		t.Errorf("GET with the new token = %d, want 200", rec.Code)
+	}
+
+	if rec := adminDo(mux, http.MethodDelete, "/api/watchlists/"+strconv.Itoa(id), ""); rec.Code != http.StatusOK {
+		t.Fatalf("DELETE watchlist = %d", rec.Code)
+	}
+	if rec := get(mux, feed+rotated.FeedToken); rec.Code != http.StatusNotFound {
+		t.Errorf("GET feed of a deleted watchlist = %d, want 404", rec.Code)
+	}
+}
+
+func TestWatchlists_Create(t *testing.T) {
+	mux := newWatchlistServer(t)
+	for _, body := range []string{
+		`not json`,
+		`{"name": " ", "authors": ["smith"]}`,
+		`{"name": "Empty", "authors": [" "], "title_terms": []}`,
+	} {
+		if rec := adminDo(mux, http.MethodPost, "/api/watchlists", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST %s = %d, want 400", body, rec.Code)
+		}
+	}
+
+	createWatchlist(t, mux, `{"name": "Smith", "authors": ["smith"]}`)
+	rec := adminDo(mux, http.MethodGet, "/api/watchlists", "")
+	var list struct {
+		Count      int `json:"count"`
+		Watchlists []struct {
+			Name    string   `json:"name"`
+			Authors []string `json:"authors"`
+		} `json:"watchlists"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Count != 1 || list.Watchlists[0].Name != "Smith" || len(list.Watchlists[0].Authors) != 1 {
+		t.Errorf("list = %+v", list)
+	}
+}
+
+// flip returns a different base64url character than c.
+func flip(c byte) string {
+	if c == 'A' {
+		return "B"
+	}
+	return "A"
+}
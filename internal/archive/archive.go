@@ -0,0 +1,171 @@
+// Package archive downloads paper PDFs into a local directory.
+package archive
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+const (
+	defaultTimeout     = 2 * time.Minute
+	defaultConcurrency = 2
+	defaultInterval    = time.Second // arXiv asks bulk clients to pace requests
+)
+
+// ErrNotPDF is returned when the server answers with something other than a PDF.
+var ErrNotPDF = arxiv.ErrNotPDF
+
+// Result is the outcome of one paper's download.
+type Result struct {
+	ID      string
+	Path    string // Local file, set on success and skip
+	Size    int64
+	Skipped bool // The file was already in the archive
+	Err     error
+}
+
+// Downloader fetches PDFs with bounded concurrency and a minimum interval
+// between requests, which every Download call of a downloader shares.
+type Downloader struct {
+	httpClient *http.Client
+	baseURL    string
+	dir        string
+
+	Concurrency int           // Parallel downloads (default: 2)
+	Interval    time.Duration // Minimum spacing between requests (default: 1s)
+	Clock       clock.Clock   // Time source for pacing (default: system clock)
+
+	limiter httpclient.Limiter
+}
+
+// NewDownloader creates a downloader that stores PDFs in dir.
+func NewDownloader(dir string) *Downloader {
+	return NewDownloaderWithOptions(nil, "", dir)
+}
+
+// NewDownloaderWithOptions creates a downloader with a custom HTTP client and
+// PDF base URL for papers without a PDF link (see arxiv.PDFURL; "" keeps
+// arXiv's).
+func NewDownloaderWithOptions(httpClient *http.Client, baseURL, dir string) *Downloader {
+	if httpClient == nil {
+		httpClient = httpclient.New(defaultTimeout)
+	}
+	return &Downloader{
+		httpClient:  httpClient,
+		baseURL:     baseURL,
+		dir:         dir,
+		Concurrency: defaultConcurrency,
+		Interval:    defaultInterval,
+		Clock:       clock.Real{},
+	}
+}
+
+// Download fetches the PDF of every paper. Papers whose file already exists
+// are skipped, and one failure does not stop the others. Results are in
+// input order.
+func (d *Downloader) Download(ctx context.Context, papers []model.Paper) []Result {
+	results := make([]Result, len(papers))
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		for i, p := range papers {
+			results[i] = Result{ID: p.ID, Err: fmt.Errorf("create archive dir: %w", err)}
+		}
+		return results
+	}
+
+	workers := max(d.Concurrency, 1)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = d.downloadOne(ctx, papers[i])
+			}
+		}()
+	}
+
+	for i := range papers {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+func (d *Downloader) downloadOne(ctx context.Context, p model.Paper) Result {
+	result := Result{ID: p.ID, Path: filepath.Join(d.dir, arxiv.PDFFileName(p))}
+
+	if info, err := os.Stat(result.Path); err == nil {
+		result.Size = info.Size()
+		result.Skipped = true
+		return result
+	}
+
+	if err := d.limiter.Wait(ctx, d.Clock, d.Interval); err != nil {
+		result.Path = ""
+		result.Err = err
+		return result
+	}
+
+	size, err := d.fetch(ctx, p, result.Path)
+	if err != nil {
+		result.Path = ""
+		result.Err = fmt.Errorf("download %s: %w", p.ID, err)
+		return result
+	}
+	result.Size = size
+	return result
+}
+
+// fetch downloads the PDF to dest (see arxiv.SavePDF).
+func (d *Downloader) fetch(ctx context.Context, p model.Paper, dest string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, arxiv.PDFURL(p, d.baseURL), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return arxiv.SavePDF(resp, dest)
+}
+
+// Archive downloads PDFs for up to limit stored papers scoring at least
+// minScore that have none yet, and records each local file in store.
+// Papers already on disk are recorded without downloading again.
+func Archive(ctx context.Context, store storage.PDFArchive, d *Downloader, minScore, limit int) ([]Result, error) {
+	papers, err := store.ListUnarchived(ctx, minScore, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := d.Download(ctx, papers)
+	for i, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		if err := store.SetPDF(ctx, r.ID, r.Path, r.Size); err != nil {
+			results[i].Err = fmt.Errorf("record pdf for %s: %w", r.ID, err)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,163 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+const fakePDF = "%PDF-1.4 fake"
+
+// newPDFServer serves a PDF for every ID except "bad" (HTML) and "missing" (404).
+func newPDFServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch {
+		case strings.Contains(r.URL.Path, "bad"):
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html>captcha</html>"))
+		case strings.Contains(r.URL.Path, "missing"):
+			http.NotFound(w, r)
+		default:
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte(fakePDF))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestDownloader(server *httptest.Server, dir string) *Downloader {
+	d := NewDownloaderWithOptions(server.Client(), server.URL+"/pdf/", dir)
+	d.Interval = 0
+	return d
+}
+
+func TestDownload_FailureIsolation(t *testing.T) {
+	var requests atomic.Int32
+	server := newPDFServer(t, &requests)
+	dir := t.TempDir()
+
+	papers := []model.Paper{
+		{ID: "2401.00001v1"},
+		{ID: "2401.bad01v1"},
+		{ID: "2401.missingv1"},
+		{ID: "2401.00002v2"},
+	}
+	results := newTestDownloader(server, dir).Download(context.Background(), papers)
+
+	if results[0].Err != nil || results[3].Err != nil {
+		t.Fatalf("good papers failed: %v, %v", results[0].Err, results[3].Err)
+	}
+	if !errors.Is(results[1].Err, ErrNotPDF) {
+		t.Errorf("HTML response error = %v, want ErrNotPDF", results[1].Err)
+	}
+	if results[2].Err == nil {
+		t.Error("404 should fail")
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "2401.00002v2.pdf"))
+	if err != nil || string(content) != fakePDF {
+		t.Errorf("downloaded file = %q, %v", content, err)
+	}
+	if results[3].Size != int64(len(fakePDF)) {
+		t.Errorf("size = %d, want %d", results[3].Size, len(fakePDF))
+	}
+
+	// Failed downloads leave nothing behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("archive has %d files, want 2", len(entries))
+	}
+}
+
+func TestArchive_SkipsExisting(t *testing.T) {
+	var requests atomic.Int32
+	server := newPDFServer(t, &requests)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	store := memory.New()
+	store.SaveBatch(ctx, []model.Paper{
+		{ID: "2401.00001v1", Score: 90},
+		{ID: "2401.00002v1", Score: 85},
+		{ID: "2401.00003v1", Score: 40}, // below threshold
+	})
+
+	// A previous run already saved one file to disk
+	if err := os.WriteFile(filepath.Join(dir, "2401.00002v1.pdf"), []byte(fakePDF), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Archive(ctx, store, newTestDownloader(server, dir), 80, 10)
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if requests.Load() != 1 {
+		t.Errorf("made %d requests, want 1", requests.Load())
+	}
+
+	for _, id := range []string{"2401.00001v1", "2401.00002v1"} {
+		path, size, err := store.GetPDF(ctx, id)
+		if err != nil || size != int64(len(fakePDF)) || filepath.Base(path) != id+".pdf" {
+			t.Errorf("GetPDF(%s) = %q, %d, %v", id, path, size, err)
+		}
+	}
+
+	// Recorded papers are not listed again
+	results, err = Archive(ctx, store, newTestDownloader(server, dir), 80, 10)
+	if err != nil || len(results) != 0 || requests.Load() != 1 {
+		t.Errorf("second run: %d results, %d requests, err %v; want nothing to do", len(results), requests.Load(), err)
+	}
+}
+
+func TestDownload_SpacesRequests(t *testing.T) {
+	var requests atomic.Int32
+	server := newPDFServer(t, &requests)
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	d := newTestDownloader(server, t.TempDir())
+	d.Interval = time.Second
+	d.Clock = clk
+	d.Concurrency = 2
+
+	done := make(chan []Result, 1)
+	go func() {
+		done <- d.Download(context.Background(), []model.Paper{{ID: "2401.00001v1"}, {ID: "2401.00002v1"}})
+	}()
+
+	// The first request goes out at once, the second waits its turn
+	clk.BlockUntil(1)
+	clk.Advance(999 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("second request went out before the interval elapsed")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%d requests before the interval elapsed, want 1", n)
+	}
+
+	clk.Advance(time.Millisecond)
+	for _, r := range <-done {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.ID, r.Err)
+		}
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+}
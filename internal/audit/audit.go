@@ -0,0 +1,113 @@
+// Package audit keeps the local activity trail: who triggered which
+// mutating operation, and when. Entries are written to the database only;
+// nothing leaves the deployment.
+package audit
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// Actors for operations not made over the API.
+const (
+	ActorCLI    = "cli"
+	ActorSignal = "signal" // SIGHUP to the API server
+	ActorSystem = "system" // Scheduled jobs such as retention pruning
+)
+
+// Actions recorded by this repository.
+const (
+	ActionSync          = "sync"
+	ActionDownload      = "download"
+	ActionHarvest       = "harvest"
+	ActionPresetsReload = "presets.reload"
+	ActionSettings      = "settings.update"
+	ActionPrune         = "audit.prune"
+	ActionWatchlist     = "watchlist.update"
+)
+
+// maxParams caps the stored parameter summary.
+const maxParams = 500
+
+// Recorder writes audit entries on behalf of the operations it audits.
+// Recording is best-effort: a failed write is logged and counted, never
+// returned, so it cannot fail the operation itself.
+type Recorder struct {
+	Log     storage.AuditLog
+	Clock   clock.Clock   // Entry timestamps (default: system clock)
+	Timeout time.Duration // Per-write limit (default: 5s)
+
+	failed atomic.Int64
+}
+
+// NewRecorder creates a recorder writing to log.
+func NewRecorder(log storage.AuditLog) *Recorder {
+	return &Recorder{Log: log, Clock: clock.Real{}, Timeout: 5 * time.Second}
+}
+
+// Record stores e, stamped with the current time. The write outlives a
+// cancelled ctx, so an entry is not lost because the client went away.
+func (r *Recorder) Record(ctx context.Context, e storage.AuditEntry) {
+	if r == nil || r.Log == nil {
+		return
+	}
+	e.At = clock.Or(r.Clock).Now()
+	if len(e.Params) > maxParams {
+		e.Params = e.Params[:maxParams]
+	}
+
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	if err := r.Log.RecordAudit(ctx, e); err != nil {
+		r.failed.Add(1)
+		log.Printf("Failed to record audit entry %s %s by %s: %v", e.Action, e.Target, e.Actor, err)
+	}
+}
+
+// Failed returns how many entries could not be written.
+func (r *Recorder) Failed() int64 {
+	return r.failed.Load()
+}
+
+// Prune deletes entries older than retention and records that it did.
+func (r *Recorder) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	n, err := r.Log.PruneAudit(ctx, clock.Or(r.Clock).Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		r.Record(ctx, storage.AuditEntry{Actor: ActorSystem, Action: ActionPrune, Target: "audit_log", Params: "older_than=" + retention.String()})
+	}
+	return n, nil
+}
+
+// PruneEvery prunes entries older than retention now and then once per
+// interval until ctx is done.
+func (r *Recorder) PruneEvery(ctx context.Context, retention, interval time.Duration) {
+	clk := clock.Or(r.Clock)
+	for {
+		if n, err := r.Prune(ctx, retention); err != nil {
+			log.Printf("Failed to prune audit log: %v", err)
+		} else if n > 0 {
+			log.Printf("Pruned %d audit entries older than %s", n, retention)
+		}
+
+		t := clk.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C():
+		}
+	}
+}
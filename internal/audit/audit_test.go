@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+var start = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+func TestPrune_TrimsToRetention(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	clk := clock.NewFake(start)
+	r := NewRecorder(store)
+	r.Clock = clk
+
+	// One entry per day for ten days
+	for day := 0; day < 10; day++ {
+		r.Record(ctx, storage.AuditEntry{Actor: ActorCLI, Action: ActionSync, Target: start.AddDate(0, 0, day).Format("Jan 2")})
+		clk.Advance(24 * time.Hour)
+	}
+
+	// Now May 11: a 3-day retention keeps May 8 onwards
+	n, err := r.Prune(ctx, 3*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if n != 7 {
+		t.Errorf("pruned %d entries, want 7", n)
+	}
+
+	kept, _ := store.ListAudit(ctx, ActionSync, 100)
+	if len(kept) != 3 || kept[2].Target != "May 8" {
+		t.Errorf("kept %+v, want May 8-10", kept)
+	}
+	pruned, _ := store.ListAudit(ctx, ActionPrune, 100)
+	if len(pruned) != 1 || pruned[0].Actor != ActorSystem {
+		t.Errorf("prune itself recorded as %+v", pruned)
+	}
+
+	// Nothing left to trim: no second prune entry
+	if n, _ := r.Prune(ctx, 3*24*time.Hour); n != 0 {
+		t.Errorf("second prune removed %d entries", n)
+	}
+	if pruned, _ := store.ListAudit(ctx, ActionPrune, 100); len(pruned) != 1 {
+		t.Errorf("empty prune was recorded: %+v", pruned)
+	}
+}
+
+func TestPruneEvery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := memory.New()
+	clk := clock.NewFake(start)
+	r := NewRecorder(store)
+	r.Clock = clk
+
+	r.Record(ctx, storage.AuditEntry{Actor: ActorCLI, Action: ActionSync})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.PruneEvery(ctx, 48*time.Hour, 24*time.Hour)
+	}()
+
+	// The first pass finds nothing old enough
+	clk.BlockUntil(1)
+	if entries, _ := store.ListAudit(ctx, ActionSync, 10); len(entries) != 1 {
+		t.Fatalf("entry pruned too early")
+	}
+
+	// Two days on, the next pass trims it
+	clk.Advance(24 * time.Hour)
+	clk.BlockUntil(1)
+	clk.Advance(24*time.Hour + time.Second)
+	clk.BlockUntil(1)
+	if entries, _ := store.ListAudit(ctx, ActionSync, 10); len(entries) != 0 {
+		t.Errorf("entry kept past retention: %+v", entries)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestRecord_NilRecorder(t *testing.T) {
+	var r *Recorder
+	r.Record(context.Background(), storage.AuditEntry{Action: ActionSync})
+}
@@ -7,7 +7,10 @@ import (
 
 	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/timing"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/validation"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
 )
 
 // Result holds benchmark results.
@@ -17,18 +20,29 @@ type Result struct {
 	ItemCount     int
 	ItemsPerSec   float64
 	ValidationRes *validation.ValidationResult
+	Requests      int   // HTTP attempts made, retries included (0 when not counted)
+	Bytes         int64 // Response bytes transferred
 }
 
 func (r Result) String() string {
-	return fmt.Sprintf(
+	s := fmt.Sprintf(
 		"%s: %v (%d items, %.2f items/sec)",
 		r.Operation, r.Duration, r.ItemCount, r.ItemsPerSec,
 	)
+	if r.Requests > 0 {
+		s += fmt.Sprintf(", %s in %d requests", formatBytes(r.Bytes), r.Requests)
+	}
+	return s
 }
 
 // Runner executes benchmarks on the pipeline.
 type Runner struct {
 	provider parser.Provider
+
+	// Metrics counts the provider's HTTP traffic, when the provider is an
+	// arxiv.Client with them as its Hooks; the fetch result then reports
+	// the bytes transferred (default: none)
+	Metrics *arxiv.Metrics
 }
 
 // NewRunner creates a new benchmark runner.
@@ -38,35 +52,51 @@ func NewRunner(provider parser.Provider) *Runner {
 
 // BenchmarkFetch measures paper fetching performance.
 func (r *Runner) BenchmarkFetch(ctx context.Context, query string, limit int) (Result, []model.Paper, error) {
-	start := time.Now()
-
-	papers, err := r.provider.FetchPapers(query, limit)
+	var timings timing.Timings
+	var papers []model.Paper
+	var before arxiv.MetricsSnapshot
+	if r.Metrics != nil {
+		before = r.Metrics.Snapshot()
+	}
+	err := timings.Measure(timing.StageFetch, func() error {
+		var err error
+		papers, err = r.provider.FetchPapers(ctx, query, limit)
+		return err
+	})
 	if err != nil {
 		return Result{}, nil, err
 	}
 
-	duration := time.Since(start)
+	duration, _ := timings.Get(timing.StageFetch)
 	itemsPerSec := float64(len(papers)) / duration.Seconds()
 
 	// Validate fetched papers
 	valResult := validation.ValidatePapers(papers)
 
-	return Result{
+	result := Result{
 		Operation:     "Fetch",
 		Duration:      duration,
 		ItemCount:     len(papers),
 		ItemsPerSec:   itemsPerSec,
 		ValidationRes: &valResult,
-	}, papers, nil
+	}
+	if r.Metrics != nil {
+		traffic := r.Metrics.Snapshot().Sub(before)
+		result.Requests, result.Bytes = traffic.Started, traffic.Bytes
+	}
+	return result, papers, nil
 }
 
 // BenchmarkValidation measures validation performance.
 func (r *Runner) BenchmarkValidation(papers []model.Paper) Result {
-	start := time.Now()
-
-	valResult := validation.ValidatePapers(papers)
-
-	duration := time.Since(start)
+	var timings timing.Timings
+	var valResult validation.ValidationResult
+	timings.Measure(timing.StageValidation, func() error {
+		valResult = validation.ValidatePapers(papers)
+		return nil
+	})
+
+	duration, _ := timings.Get(timing.StageValidation)
 	itemsPerSec := float64(len(papers)) / duration.Seconds()
 
 	return Result{
@@ -81,6 +111,7 @@ func (r *Runner) BenchmarkValidation(papers []model.Paper) Result {
 // Report holds a complete benchmark report.
 type Report struct {
 	Timestamp time.Time
+	Version   version.Info
 	Results   []Result
 	Summary   Summary
 }
@@ -97,6 +128,7 @@ type Summary struct {
 func (r *Runner) GenerateReport(ctx context.Context, query string, limit int) (*Report, error) {
 	report := &Report{
 		Timestamp: time.Now(),
+		Version:   version.Get(),
 	}
 
 	// Benchmark fetch
@@ -131,7 +163,9 @@ func PrintReport(report *Report) {
 	fmt.Println("═══════════════════════════════════════════")
 	fmt.Println("         BENCHMARK REPORT")
 	fmt.Println("═══════════════════════════════════════════")
-	fmt.Printf("Timestamp: %s\n\n", report.Timestamp.Format(time.RFC3339))
+	fmt.Printf("Timestamp: %s\n", report.Timestamp.Format(time.RFC3339))
+	fmt.Printf("Version:   %s %s, built %s, %s %s\n\n", report.Version.Version, report.Version.Commit,
+		report.Version.Date, report.Version.GoVersion, report.Version.Platform)
 
 	fmt.Println("Results:")
 	fmt.Println("───────────────────────────────────────────")
@@ -151,3 +185,17 @@ func PrintReport(report *Report) {
 	fmt.Printf("  Total Duration: %v\n", report.Summary.TotalDuration)
 	fmt.Println("═══════════════════════════════════════════")
 }
+
+// formatBytes renders n bytes with a binary unit, e.g. "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
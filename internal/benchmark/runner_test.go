@@ -0,0 +1,57 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/providertest"
+)
+
+func TestGenerateReport_Offline(t *testing.T) {
+	provider := &providertest.Mock{Papers: providertest.SamplePapers(25)}
+	report, err := NewRunner(provider).GenerateReport(context.Background(), "llm", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Summary.TotalPapers != 10 || report.Summary.ValidPapers != 10 || report.Summary.InvalidPapers != 0 {
+		t.Errorf("summary = %+v, want 10 valid papers", report.Summary)
+	}
+	if len(report.Results) != 2 || provider.CallCount() != 1 {
+		t.Errorf("%d results after %d fetches, want 2 after 1", len(report.Results), provider.CallCount())
+	}
+}
+
+func TestBenchmarkFetch_Traffic(t *testing.T) {
+	const feed = `<?xml version="1.0" encoding="UTF-8"?><feed xmlns="http://www.w3.org/2005/Atom">` +
+		`<entry><id>http://arxiv.org/abs/2401.00001v1</id><title>T</title><summary>A</summary>` +
+		`<published>2024-01-15T10:00:00Z</published><updated>2024-01-15T10:00:00Z</updated>` +
+		`<author><name>A. Author</name></author></entry></feed>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	metrics := &arxiv.Metrics{}
+	client := arxiv.New(arxiv.WithHTTPClient(server.Client()), arxiv.WithBaseURL(server.URL), arxiv.WithHooks(metrics))
+	runner := NewRunner(client)
+	runner.Metrics = metrics
+	// Traffic from before the benchmark is not counted
+	metrics.OnRequestStart(server.URL)
+	metrics.OnRequestDone(server.URL, http.StatusOK, 0, 1000, nil)
+
+	result, _, err := runner.BenchmarkFetch(context.Background(), "llm", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Requests != 1 || result.Bytes != int64(len(feed)) {
+		t.Errorf("traffic = %d requests, %d bytes; want 1, %d", result.Requests, result.Bytes, len(feed))
+	}
+	if want := fmt.Sprintf("%d B in 1 requests", len(feed)); !strings.Contains(result.String(), want) {
+		t.Errorf("String() = %q, want it to contain %q", result.String(), want)
+	}
+}
@@ -0,0 +1,240 @@
+// Package calibrate compares the scores the filter gave stored papers with
+// signals that appeared after they were saved: newer arXiv versions, a DOI
+// or journal reference, and citation counts.
+package calibrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+const (
+	defaultBatchSize = 50
+	defaultInterval  = 3 * time.Second // arXiv API asks for 3s between calls
+)
+
+// Lookup fetches current paper metadata by base arXiv ID.
+// *arxiv.Client satisfies it.
+type Lookup interface {
+	FetchByIDs(ctx context.Context, ids []string) ([]model.Paper, error)
+}
+
+// CitationCounter reports citation counts keyed by base arXiv ID. IDs it
+// has no data for are left out of the map.
+type CitationCounter interface {
+	CitationCounts(ctx context.Context, ids []string) (map[string]int, error)
+}
+
+// Outcome holds the later signals observed for one stored paper.
+type Outcome struct {
+	ID               string    `json:"id"`
+	Score            int       `json:"score"`
+	Missing          bool      `json:"missing,omitempty"` // No longer returned upstream
+	NewVersions      int       `json:"new_versions"`
+	GainedDOI        bool      `json:"gained_doi"`
+	GainedJournalRef bool      `json:"gained_journal_ref"`
+	HasCitations     bool      `json:"has_citations"`
+	Citations        int       `json:"citations,omitempty"`
+	CheckedAt        time.Time `json:"checked_at"`
+}
+
+// Positive reports whether the paper showed a publication signal: a DOI or
+// journal reference it lacked when stored, or at least minCitations citations.
+func (o Outcome) Positive(minCitations int) bool {
+	if o.GainedDOI || o.GainedJournalRef {
+		return true
+	}
+	return o.HasCitations && minCitations > 0 && o.Citations >= minCitations
+}
+
+// compare derives an outcome from the stored and the current record.
+func compare(stored, current model.Paper) Outcome {
+	o := Outcome{
+		ID:               stored.ID,
+		Score:            stored.Score,
+		GainedDOI:        stored.DOI == "" && current.DOI != "",
+		GainedJournalRef: stored.JournalRef == "" && current.JournalRef != "",
+	}
+	if n := current.Version() - stored.Version(); n > 0 {
+		o.NewVersions = n
+	}
+	return o
+}
+
+// State is the set of outcomes collected so far. It is saved after every
+// batch so an interrupted run resumes where it stopped.
+type State struct {
+	path string
+
+	Outcomes map[string]Outcome `json:"outcomes"`
+}
+
+// LoadState reads the state file at path. A missing file yields an empty
+// state, and an empty path keeps the state in memory only.
+func LoadState(path string) (*State, error) {
+	s := &State{path: path, Outcomes: make(map[string]Outcome)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read state: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("decode state %s: %w", path, err)
+	}
+	if s.Outcomes == nil {
+		s.Outcomes = make(map[string]Outcome)
+	}
+	return s, nil
+}
+
+// Save writes the state file atomically.
+func (s *State) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".calibrate-*")
+	if err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	return nil
+}
+
+// Collector fetches outcomes in batches, pausing between requests.
+type Collector struct {
+	lookup    Lookup
+	citations CitationCounter
+
+	BatchSize int           // IDs per lookup request (default: 50)
+	Interval  time.Duration // Delay between lookup requests (default: 3s)
+	Clock     clock.Clock   // Time source for pacing and CheckedAt (default: system clock)
+}
+
+// NewCollector creates a collector. citations may be nil, in which case
+// outcomes carry no citation data.
+func NewCollector(lookup Lookup, citations CitationCounter) *Collector {
+	return &Collector{
+		lookup:    lookup,
+		citations: citations,
+		BatchSize: defaultBatchSize,
+		Interval:  defaultInterval,
+		Clock:     clock.Real{},
+	}
+}
+
+// Collect fetches outcomes for the papers not yet in state, saving state
+// after each batch. On error the batches already fetched are kept.
+func (c *Collector) Collect(ctx context.Context, papers []model.Paper, state *State) error {
+	var pending []model.Paper
+	for _, p := range papers {
+		if _, done := state.Outcomes[p.ID]; !done {
+			pending = append(pending, p)
+		}
+	}
+
+	size := c.BatchSize
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	for start := 0; start < len(pending); start += size {
+		if start > 0 {
+			if err := sleep(ctx, clock.Or(c.Clock), c.Interval); err != nil {
+				return err
+			}
+		}
+
+		end := min(start+size, len(pending))
+		if err := c.collectBatch(ctx, pending[start:end], state); err != nil {
+			return err
+		}
+		if err := state.Save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Collector) collectBatch(ctx context.Context, batch []model.Paper, state *State) error {
+	ids := make([]string, len(batch))
+	for i, p := range batch {
+		ids[i] = p.BaseID()
+	}
+
+	fetched, err := c.lookup.FetchByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("fetch outcomes: %w", err)
+	}
+	current := make(map[string]model.Paper, len(fetched))
+	for _, p := range fetched {
+		current[p.BaseID()] = p
+	}
+
+	var citations map[string]int
+	if c.citations != nil {
+		citations, err = c.citations.CitationCounts(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("fetch citations: %w", err)
+		}
+	}
+
+	now := clock.Or(c.Clock).Now()
+	for _, p := range batch {
+		cur, ok := current[p.BaseID()]
+		o := Outcome{ID: p.ID, Score: p.Score, Missing: true}
+		if ok {
+			o = compare(p, cur)
+		}
+		if n, ok := citations[p.BaseID()]; ok {
+			o.HasCitations = true
+			o.Citations = n
+		}
+		o.CheckedAt = now
+		state.Outcomes[p.ID] = o
+	}
+	return nil
+}
+
+func sleep(ctx context.Context, clk clock.Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := clk.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
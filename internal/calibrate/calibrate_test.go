@@ -0,0 +1,297 @@
+package calibrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// stubLookup serves current metadata from a map keyed by base ID.
+type stubLookup struct {
+	current map[string]model.Paper
+	calls   [][]string
+	failAt  int // Fail the nth call (1-based); 0 never fails
+}
+
+func (s *stubLookup) FetchByIDs(ctx context.Context, ids []string) ([]model.Paper, error) {
+	s.calls = append(s.calls, ids)
+	if s.failAt == len(s.calls) {
+		return nil, errors.New("rate limited")
+	}
+	var papers []model.Paper
+	for _, id := range ids {
+		if p, ok := s.current[id]; ok {
+			papers = append(papers, p)
+		}
+	}
+	return papers, nil
+}
+
+type stubCitations map[string]int
+
+func (s stubCitations) CitationCounts(ctx context.Context, ids []string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, id := range ids {
+		if n, ok := s[id]; ok {
+			counts[id] = n
+		}
+	}
+	return counts, nil
+}
+
+func TestCollect_Signals(t *testing.T) {
+	stored := []model.Paper{
+		{ID: "2401.00001v1", Score: 85},
+		{ID: "2401.00002v2", Score: 60, DOI: "10.1/old"},
+		{ID: "2401.00003v1", Score: 40},
+	}
+	lookup := &stubLookup{current: map[string]model.Paper{
+		"2401.00001": {ID: "2401.00001v3", JournalRef: "NeurIPS 2024"},
+		"2401.00002": {ID: "2401.00002v2", DOI: "10.1/new"},
+	}}
+
+	c := NewCollector(lookup, stubCitations{"2401.00002": 12})
+	c.Interval = 0
+	state, _ := LoadState("")
+	if err := c.Collect(context.Background(), stored, state); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	first := state.Outcomes["2401.00001v1"]
+	if first.NewVersions != 2 || !first.GainedJournalRef || first.GainedDOI || first.HasCitations {
+		t.Errorf("2401.00001v1 = %+v, want 2 new versions and gained journal ref only", first)
+	}
+	second := state.Outcomes["2401.00002v2"]
+	if second.NewVersions != 0 || second.GainedDOI || !second.HasCitations || second.Citations != 12 {
+		t.Errorf("2401.00002v2 = %+v, want no gained DOI and 12 citations", second)
+	}
+	if third := state.Outcomes["2401.00003v1"]; !third.Missing {
+		t.Errorf("2401.00003v1 = %+v, want missing", third)
+	}
+}
+
+func TestCollect_ResumesFromState(t *testing.T) {
+	var stored []model.Paper
+	current := make(map[string]model.Paper)
+	for _, id := range []string{"2401.00001", "2401.00002", "2401.00003", "2401.00004", "2401.00005"} {
+		stored = append(stored, model.Paper{ID: id + "v1"})
+		current[id] = model.Paper{ID: id + "v1"}
+	}
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	lookup := &stubLookup{current: current, failAt: 2}
+	c := NewCollector(lookup, nil)
+	c.BatchSize = 2
+	c.Interval = 0
+
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Collect(context.Background(), stored, state); err == nil {
+		t.Fatal("Collect succeeded, want error from second batch")
+	}
+
+	// A fresh run picks up the saved first batch and fetches only the rest.
+	state, err = LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Outcomes) != 2 {
+		t.Fatalf("saved %d outcomes, want 2", len(state.Outcomes))
+	}
+
+	lookup = &stubLookup{current: current}
+	c = NewCollector(lookup, nil)
+	c.BatchSize = 2
+	c.Interval = 0
+	if err := c.Collect(context.Background(), stored, state); err != nil {
+		t.Fatalf("resumed Collect: %v", err)
+	}
+	if len(lookup.calls) != 2 || lookup.calls[0][0] != "2401.00003" {
+		t.Errorf("resumed calls = %v, want two batches starting at 2401.00003", lookup.calls)
+	}
+	if len(state.Outcomes) != 5 {
+		t.Errorf("collected %d outcomes, want 5", len(state.Outcomes))
+	}
+}
+
+func TestCollect_StopsWhenCanceled(t *testing.T) {
+	stored := []model.Paper{{ID: "2401.00001v1"}, {ID: "2401.00002v1"}}
+	lookup := &stubLookup{}
+	c := NewCollector(lookup, nil)
+	c.BatchSize = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	state, _ := LoadState("")
+	if err := c.Collect(ctx, stored, state); !errors.Is(err, context.Canceled) {
+		t.Errorf("Collect = %v, want context.Canceled", err)
+	}
+	if len(lookup.calls) != 1 {
+		t.Errorf("lookup called %d times, want 1 before the paced wait", len(lookup.calls))
+	}
+}
+
+func TestCollect_WaitsBetweenBatches(t *testing.T) {
+	stored := []model.Paper{{ID: "2401.00001v1"}, {ID: "2401.00002v1"}}
+	lookup := &stubLookup{}
+	clk := clock.NewFake(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	c := NewCollector(lookup, nil)
+	c.BatchSize = 1
+	c.Clock = clk
+
+	state, _ := LoadState("")
+	done := make(chan error, 1)
+	go func() { done <- c.Collect(context.Background(), stored, state) }()
+
+	clk.BlockUntil(1)
+	if len(lookup.calls) != 1 {
+		t.Fatalf("lookup called %d times before the interval, want 1", len(lookup.calls))
+	}
+	clk.Advance(3 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	if len(lookup.calls) != 2 {
+		t.Errorf("lookup called %d times, want 2", len(lookup.calls))
+	}
+	if got := state.Outcomes["2401.00002v1"].CheckedAt; !got.Equal(clk.Now()) {
+		t.Errorf("CheckedAt = %v, want %v", got, clk.Now())
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	outcomes := []Outcome{
+		// 80-100: 3 of 4 positive
+		{Score: 95, GainedDOI: true},
+		{Score: 88, GainedJournalRef: true, NewVersions: 1},
+		{Score: 80, HasCitations: true, Citations: 10},
+		{Score: 81, HasCitations: true, Citations: 9},
+		// 70-79: 1 of 2 positive
+		{Score: 79, GainedDOI: true},
+		{Score: 70, NewVersions: 2},
+		// 50-69: none
+		{Score: 50},
+		// 0-49: 1 of 1 positive
+		{Score: 0, GainedJournalRef: true},
+		// Excluded from buckets
+		{Score: 90, Missing: true},
+	}
+
+	report := BuildReport(outcomes, nil, 10, time.Now())
+
+	if report.Papers != 9 || report.Missing != 1 {
+		t.Errorf("Papers = %d, Missing = %d, want 9 and 1", report.Papers, report.Missing)
+	}
+
+	tests := []struct {
+		label     string
+		papers    int
+		positive  int
+		revised   int
+		cited     int
+		precision float64
+	}{
+		{"0-49", 1, 1, 0, 0, 1},
+		{"50-69", 1, 0, 0, 0, 0},
+		{"70-79", 2, 1, 1, 0, 0.5},
+		{"80-100", 4, 3, 1, 2, 0.75},
+	}
+	if len(report.Buckets) != len(tests) {
+		t.Fatalf("got %d buckets, want %d", len(report.Buckets), len(tests))
+	}
+	for i, tc := range tests {
+		b := report.Buckets[i]
+		if b.Label != tc.label || b.Papers != tc.papers || b.Positive != tc.positive ||
+			b.Revised != tc.revised || b.Cited != tc.cited || b.Precision != tc.precision {
+			t.Errorf("bucket %d = %+v, want %+v", i, b, tc)
+		}
+	}
+}
+
+func TestBuildReport_CitationsIgnoredWithoutThreshold(t *testing.T) {
+	report := BuildReport([]Outcome{{Score: 90, HasCitations: true, Citations: 500}}, []int{0, 80}, 0, time.Now())
+
+	if b := report.Buckets[1]; b.Label != "80-100" || b.Papers != 1 || b.Positive != 0 {
+		t.Errorf("bucket = %+v, want 1 paper and 0 positive", b)
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	scored := func(positive bool, scores ...int) []Outcome {
+		out := make([]Outcome, len(scores))
+		for i, s := range scores {
+			out[i] = Outcome{Score: s, GainedDOI: positive}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		outcomes []Outcome
+		keep     float64
+		expected Recommendation
+		ok       bool
+	}{
+		{
+			name: "ignored band below the positives",
+			outcomes: append(scored(true, 72, 75, 78, 80, 85, 88, 90, 92, 95, 99),
+				scored(false, 60, 61, 62, 63, 65, 66, 68, 69, 70, 71)...),
+			keep:     0.9,
+			expected: Recommendation{MinScore: 72, Keep: 0.9, KeptPositive: 1, DroppedNegative: 1},
+			ok:       true,
+		},
+		{
+			name: "trade-off stops at the keep target",
+			outcomes: append(scored(true, 50, 60, 70, 80, 90, 95, 96, 97, 98, 99),
+				scored(false, 55, 65, 75, 85)...),
+			keep:     0.8,
+			expected: Recommendation{MinScore: 66, Keep: 0.8, KeptPositive: 0.8, DroppedNegative: 0.5},
+			ok:       true,
+		},
+		{
+			name:     "nothing can go",
+			outcomes: append(scored(true, 50, 90), scored(false, 70)...),
+			keep:     1,
+			expected: Recommendation{MinScore: 0, Keep: 1, KeptPositive: 1, DroppedNegative: 0},
+			ok:       true,
+		},
+		{
+			name:     "missing outcomes are left out",
+			outcomes: append(scored(true, 80), Outcome{Score: 40, Missing: true}),
+			keep:     0.9,
+		},
+		{
+			name:     "no positives",
+			outcomes: scored(false, 40, 50),
+			keep:     0.9,
+		},
+	}
+
+	for _, tc := range tests {
+		rec, ok := Recommend(tc.outcomes, 10, tc.keep)
+		if ok != tc.ok || rec != tc.expected {
+			t.Errorf("%s: Recommend = %+v, %v; want %+v, %v", tc.name, rec, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+func TestReport_WriteTextRecommendation(t *testing.T) {
+	report := BuildReport(nil, nil, 10, time.Now())
+	report.Recommendation = &Recommendation{MinScore: 72, Keep: 0.9, KeptPositive: 0.92, DroppedNegative: 0.6}
+
+	var buf bytes.Buffer
+	report.WriteText(&buf)
+	if want := "min_score 72 would keep 92% of positive papers (target 90%) and drop 60% of the others"; !strings.Contains(buf.String(), want) {
+		t.Errorf("report text missing %q:\n%s", want, buf.String())
+	}
+}
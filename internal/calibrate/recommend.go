@@ -0,0 +1,59 @@
+package calibrate
+
+// DefaultKeep is the share of positive papers a recommended threshold
+// must keep.
+const DefaultKeep = 0.9
+
+// Recommendation is a min score suggested by past outcomes. It is only a
+// report; nothing applies it.
+type Recommendation struct {
+	MinScore        int     `json:"min_score"`
+	Keep            float64 `json:"keep"`             // Share of positive papers the threshold had to keep
+	KeptPositive    float64 `json:"kept_positive"`    // Share of positive papers scoring at least MinScore
+	DroppedNegative float64 `json:"dropped_negative"` // Share of the other papers scoring below it
+}
+
+// Recommend returns the min score that drops the most papers without a
+// positive outcome while keeping at least keep (0-1) of the positive
+// ones; of the scores that drop as many, the lowest. Missing outcomes are
+// left out. ok is false when there are no positive or no other papers to
+// learn from.
+func Recommend(outcomes []Outcome, minCitations int, keep float64) (rec Recommendation, ok bool) {
+	// Papers per score, so each threshold is a running sum
+	var positive, negative [101]int
+	var positives, negatives int
+	for _, o := range outcomes {
+		if o.Missing {
+			continue
+		}
+		score := min(max(o.Score, 0), 100)
+		if o.Positive(minCitations) {
+			positive[score]++
+			positives++
+		} else {
+			negative[score]++
+			negatives++
+		}
+	}
+	if positives == 0 || negatives == 0 {
+		return Recommendation{}, false
+	}
+
+	// Raising the threshold one point at a time drops the papers at the
+	// old one; stop once too many positive papers would go
+	rec = Recommendation{Keep: keep, KeptPositive: 1}
+	kept, dropped := positives, 0
+	for t := 1; t <= 100; t++ {
+		kept -= positive[t-1]
+		dropped += negative[t-1]
+		if float64(kept) < keep*float64(positives) {
+			break
+		}
+		if float64(dropped)/float64(negatives) > rec.DroppedNegative {
+			rec.MinScore = t
+			rec.KeptPositive = float64(kept) / float64(positives)
+			rec.DroppedNegative = float64(dropped) / float64(negatives)
+		}
+	}
+	return rec, true
+}
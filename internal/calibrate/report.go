@@ -0,0 +1,126 @@
+package calibrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DefaultBounds are the lower score bounds of the report buckets:
+// 0-49, 50-69, 70-79 and 80-100.
+var DefaultBounds = []int{0, 50, 70, 80}
+
+// Bucket aggregates outcomes for one score range.
+type Bucket struct {
+	Label     string  `json:"label"`
+	Min       int     `json:"min"`
+	Max       int     `json:"max"`
+	Papers    int     `json:"papers"`
+	Positive  int     `json:"positive"`  // DOI, journal ref or enough citations appeared
+	Revised   int     `json:"revised"`   // A newer arXiv version appeared
+	Cited     int     `json:"cited"`     // Papers with citation data
+	Precision float64 `json:"precision"` // Positive / Papers
+}
+
+// Report buckets original scores against later outcomes.
+type Report struct {
+	GeneratedAt  time.Time `json:"generated_at"`
+	Papers       int       `json:"papers"`
+	Missing      int       `json:"missing"` // Not returned upstream, left out of buckets
+	MinCitations int       `json:"min_citations"`
+	Buckets      []Bucket  `json:"buckets"`
+
+	// Recommendation is set by the caller from Recommend, when it has one
+	Recommendation *Recommendation `json:"recommendation,omitempty"`
+}
+
+// BuildReport groups outcomes into buckets starting at the ascending lower
+// bounds (DefaultBounds if nil). The last bucket ends at 100. The report is
+// stamped with now.
+func BuildReport(outcomes []Outcome, bounds []int, minCitations int, now time.Time) Report {
+	if len(bounds) == 0 {
+		bounds = DefaultBounds
+	}
+
+	report := Report{
+		GeneratedAt:  now,
+		MinCitations: minCitations,
+		Buckets:      make([]Bucket, len(bounds)),
+	}
+	for i, lo := range bounds {
+		hi := 100
+		if i+1 < len(bounds) {
+			hi = bounds[i+1] - 1
+		}
+		report.Buckets[i] = Bucket{Label: fmt.Sprintf("%d-%d", lo, hi), Min: lo, Max: hi}
+	}
+
+	for _, o := range outcomes {
+		report.Papers++
+		if o.Missing {
+			report.Missing++
+			continue
+		}
+		b := bucketFor(report.Buckets, o.Score)
+		if b == nil {
+			continue
+		}
+		b.Papers++
+		if o.Positive(minCitations) {
+			b.Positive++
+		}
+		if o.NewVersions > 0 {
+			b.Revised++
+		}
+		if o.HasCitations {
+			b.Cited++
+		}
+	}
+
+	for i := range report.Buckets {
+		b := &report.Buckets[i]
+		if b.Papers > 0 {
+			b.Precision = float64(b.Positive) / float64(b.Papers)
+		}
+	}
+	return report
+}
+
+// bucketFor returns the last bucket whose lower bound is at most score.
+func bucketFor(buckets []Bucket, score int) *Bucket {
+	for i := len(buckets) - 1; i >= 0; i-- {
+		if score >= buckets[i].Min {
+			return &buckets[i]
+		}
+	}
+	return nil
+}
+
+// WriteText prints the report as a table.
+func (r Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Calibration over %d papers (%d no longer found)\n", r.Papers, r.Missing)
+	if r.MinCitations > 0 {
+		fmt.Fprintf(w, "Positive = gained DOI/journal ref or >= %d citations\n\n", r.MinCitations)
+	} else {
+		fmt.Fprintf(w, "Positive = gained DOI/journal ref\n\n")
+	}
+
+	fmt.Fprintf(w, "%-8s %7s %9s %10s %8s %6s\n", "Score", "Papers", "Positive", "Precision", "Revised", "Cited")
+	for _, b := range r.Buckets {
+		fmt.Fprintf(w, "%-8s %7d %9d %9.1f%% %8d %6d\n",
+			b.Label, b.Papers, b.Positive, b.Precision*100, b.Revised, b.Cited)
+	}
+
+	if rec := r.Recommendation; rec != nil {
+		fmt.Fprintf(w, "\nmin_score %d would keep %.0f%% of positive papers (target %.0f%%) and drop %.0f%% of the others\n",
+			rec.MinScore, rec.KeptPositive*100, rec.Keep*100, rec.DroppedNegative*100)
+	}
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
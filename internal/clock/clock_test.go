@@ -0,0 +1,131 @@
+package clock
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFake_AdvanceFiresDueTimers(t *testing.T) {
+	c := NewFake(epoch)
+	early := c.NewTimer(time.Second)
+	late := c.NewTimer(time.Minute)
+
+	c.Advance(30 * time.Second)
+
+	select {
+	case at := <-early.C():
+		if !at.Equal(epoch.Add(time.Second)) {
+			t.Errorf("early fired at %v, want deadline %v", at, epoch.Add(time.Second))
+		}
+	default:
+		t.Fatal("early timer did not fire")
+	}
+	select {
+	case <-late.C():
+		t.Fatal("late timer fired before its deadline")
+	default:
+	}
+
+	if got := c.Now(); !got.Equal(epoch.Add(30 * time.Second)) {
+		t.Errorf("Now() = %v, want %v", got, epoch.Add(30*time.Second))
+	}
+
+	c.Advance(30 * time.Second)
+	select {
+	case <-late.C():
+	default:
+		t.Fatal("late timer did not fire at its deadline")
+	}
+}
+
+func TestFake_Stop(t *testing.T) {
+	c := NewFake(epoch)
+	timer := c.NewTimer(time.Second)
+
+	if !timer.Stop() {
+		t.Error("Stop() = false for a pending timer")
+	}
+	c.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Error("stopped timer fired")
+	default:
+	}
+	if timer.Stop() {
+		t.Error("Stop() = true for a stopped timer")
+	}
+}
+
+func TestFake_ZeroDurationFiresImmediately(t *testing.T) {
+	c := NewFake(epoch)
+	select {
+	case <-c.After(0):
+	default:
+		t.Error("After(0) did not fire without advancing")
+	}
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	c := NewFake(epoch)
+	done := make(chan struct{})
+	go func() {
+		<-c.After(time.Minute)
+		close(done)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+	<-done
+}
+
+// clockedPackages must take the time from an injected Clock.
+var clockedPackages = []string{
+	"../api",
+	"../audit",
+	"../archive",
+	"../calibrate",
+	"../filter",
+	"../httpclient",
+	"../ingest",
+	"../pipeline",
+	"../retry",
+	"../settings",
+	"../../pkg/genesis",
+	"../storage/memory",
+	"../syncqueue",
+}
+
+var directTime = regexp.MustCompile(`\btime\.(Now|Since|Until|Sleep|After|AfterFunc|NewTimer|NewTicker|Tick)\(`)
+
+func TestNoDirectTimeCalls(t *testing.T) {
+	for _, dir := range clockedPackages {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) == 0 {
+			t.Errorf("%s: no Go files; update clockedPackages", dir)
+		}
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, line := range strings.Split(string(data), "\n") {
+				if directTime.MatchString(line) {
+					t.Errorf("%s:%d calls the system clock directly; use clock.Clock: %s",
+						file, i+1, strings.TrimSpace(line))
+				}
+			}
+		}
+	}
+}
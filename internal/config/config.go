@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
@@ -17,6 +18,27 @@ type Config struct {
 
 	// Pipeline defaults
 	Pipeline PipelineConfig
+
+	// Sync job queue limits
+	Sync SyncConfig
+
+	// Web UI settings
+	UI UIConfig
+
+	// Quality filter rule sets
+	Filter FilterConfig
+
+	// Local activity trail
+	Audit AuditConfig
+
+	// Generated files
+	Output OutputConfig
+
+	// API server access
+	API APIConfig
+
+	// New paper announcements
+	Notify NotifyConfig
 }
 
 // DatabaseConfig holds database connection settings.
@@ -26,6 +48,13 @@ type DatabaseConfig struct {
 	User     string `envconfig:"DB_USER" default:"genesis"`
 	Password string `envconfig:"DB_PASSWORD" default:"genesis123"`
 	Name     string `envconfig:"DB_NAME" default:"genesis"`
+
+	// Papers written per round trip by SaveBatch
+	SaveChunkSize int `envconfig:"DB_SAVE_CHUNK_SIZE" default:"500"`
+
+	// Schema handling on startup: auto (apply pending migrations), check
+	// (refuse to start unless the schema matches the build) or skip
+	Migrate string `envconfig:"DB_MIGRATE" default:"auto"`
 }
 
 // ConnString returns the PostgreSQL connection string.
@@ -53,6 +82,106 @@ type PipelineConfig struct {
 	DefaultLimit    int    `envconfig:"DEFAULT_LIMIT" default:"10"`
 	DefaultMinScore int    `envconfig:"DEFAULT_MIN_SCORE" default:"60"`
 	DefaultMaxAge   int    `envconfig:"DEFAULT_MAX_AGE" default:"365"`
+
+	// Categories read by the arxiv-rss announcement provider
+	AnnounceCategories []string `envconfig:"ANNOUNCE_CATEGORIES" default:"cs.AI,cs.LG,cs.CL"`
+
+	// JSON file of extra presets, which may extend the built-in ones
+	PresetsFile string `envconfig:"PRESETS_FILE"`
+
+	// Abstracts longer than this many characters are cut at a word boundary before saving
+	MaxAbstractLength int `envconfig:"MAX_ABSTRACT_LENGTH" default:"10000"`
+
+	// Directory the download command archives PDFs into
+	PDFDir string `envconfig:"PDF_DIR" default:"./pdfs"`
+
+	// User-Agent of arXiv API requests; arXiv asks for contact details (default: genesis-pipeline/<version>)
+	UserAgent string `envconfig:"PROVIDER_USER_AGENT"`
+
+	// Minutes the API server reuses an arXiv response for an identical request (0 = no caching)
+	CacheTTLMinutes int `envconfig:"PROVIDER_CACHE_TTL_MINUTES" default:"10"`
+
+	// API keys of providers that need one, as provider:key pairs
+	ProviderKeys map[string]string `envconfig:"PROVIDER_KEYS"`
+
+	// Minimum delay between requests per provider, e.g. arxiv:3s (default: each provider's own)
+	ProviderIntervals map[string]time.Duration `envconfig:"PROVIDER_INTERVALS"`
+
+	// Store arXiv papers under their versionless ID, so a revision updates the stored paper instead of adding one
+	StripVersions bool `envconfig:"STRIP_VERSIONS" default:"true"`
+
+	// Papers whose abstract fingerprints differ from a stored paper's in at most this many of 64 bits are merged into it (0 = off)
+	NearDuplicateDistance int `envconfig:"NEAR_DUPLICATE_DISTANCE" default:"10"`
+}
+
+// SyncConfig holds sync job queue limits.
+type SyncConfig struct {
+	MaxConcurrent int `envconfig:"SYNC_MAX_CONCURRENT" default:"1"`
+	QueueDepth    int `envconfig:"SYNC_QUEUE_DEPTH" default:"10"`
+
+	// Finished jobs the API keeps in memory for status requests, and for
+	// how many minutes (0 = until evicted by count)
+	JobHistory    int `envconfig:"SYNC_JOB_HISTORY" default:"100"`
+	JobTTLMinutes int `envconfig:"SYNC_JOB_TTL_MINUTES" default:"60"`
+
+	// Days to keep the HTTP requests each sync made (0 = not recorded)
+	RequestRetentionDays int `envconfig:"SYNC_REQUEST_RETENTION_DAYS" default:"0"`
+}
+
+// FilterConfig holds quality filter settings.
+type FilterConfig struct {
+	// JSON rule set scored in shadow mode next to the active rules (empty = off)
+	ShadowRules string `envconfig:"FILTER_SHADOW_RULES"`
+	// Score change, in points, beyond which a shadow result is recorded
+	ShadowMaxDelta int `envconfig:"FILTER_SHADOW_MAX_DELTA" default:"10"`
+	// Points by minimum page count, e.g. "20:5,40:10"; the highest tier reached counts (empty = off)
+	PageTiers map[int]int `envconfig:"FILTER_PAGE_TIERS"`
+	// Characters of each title, abstract and comment the scorers read
+	MaxText int `envconfig:"FILTER_MAX_TEXT" default:"20000"`
+}
+
+// AuditConfig holds audit log settings.
+type AuditConfig struct {
+	// Days to keep audit entries; the API server prunes older ones daily (0 = keep forever)
+	RetentionDays int `envconfig:"AUDIT_RETENTION_DAYS" default:"90"`
+}
+
+// OutputConfig holds where generated files (exports) are written.
+type OutputConfig struct {
+	// Directory for generated files; export without -o writes here instead of stdout (empty = off)
+	Dir string `envconfig:"OUTPUT_DIR"`
+	// Export file name under Dir; fields: {{.Date}}, {{.Time}}, {{.Format}};
+	// helpers: lower, upper, printf, eq, ne, and, or, not
+	ExportName string `envconfig:"OUTPUT_EXPORT_NAME" default:"exports/{{.Date}}-{{.Time}}.{{.Format}}"`
+	// Newest exports to keep under Dir; older ones are pruned after each export (0 = keep all)
+	Retention int `envconfig:"OUTPUT_RETENTION" default:"0"`
+}
+
+// APIConfig holds API server access settings.
+type APIConfig struct {
+	// Bearer token for the admin endpoints, which are not served without one
+	AdminToken string `envconfig:"ADMIN_TOKEN"`
+	// Caller keys as label:key pairs; requests bearing a key are audited under its label
+	Keys map[string]string `envconfig:"API_KEYS"`
+	// Secret signing watchlist feed tokens; watchlist feeds are not served without one
+	FeedSecret string `envconfig:"FEED_TOKEN_SECRET"`
+}
+
+// UIConfig holds web UI settings.
+type UIConfig struct {
+	// Show the sync form; the UI has no login, so anyone who can reach it can sync
+	SyncForm bool `envconfig:"UI_SYNC_FORM" default:"false"`
+}
+
+// NotifyConfig holds new paper announcement settings.
+type NotifyConfig struct {
+	// Webhook that receives new papers as JSON with a Slack-compatible "text" field (empty = off)
+	WebhookURL string `envconfig:"NOTIFY_WEBHOOK_URL"`
+	BatchSize  int    `envconfig:"NOTIFY_BATCH_SIZE" default:"10"`
+	// Papers announced per sync; the rest are summarised in one message
+	MaxPerSync int `envconfig:"NOTIFY_MAX_PER_SYNC" default:"50"`
+	// Messages waiting for delivery before new ones are dropped
+	QueueSize int `envconfig:"NOTIFY_QUEUE_SIZE" default:"100"`
 }
 
 // Load loads configuration from environment variables.
@@ -78,9 +207,101 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("load pipeline config: %w", err)
 	}
 
+	// Load sync queue config
+	if err := envconfig.Process("", &cfg.Sync); err != nil {
+		return nil, fmt.Errorf("load sync config: %w", err)
+	}
+
+	// Load filter config
+	if err := envconfig.Process("", &cfg.Filter); err != nil {
+		return nil, fmt.Errorf("load filter config: %w", err)
+	}
+
+	// Load audit config
+	if err := envconfig.Process("", &cfg.Audit); err != nil {
+		return nil, fmt.Errorf("load audit config: %w", err)
+	}
+
+	// Load output config
+	if err := envconfig.Process("", &cfg.Output); err != nil {
+		return nil, fmt.Errorf("load output config: %w", err)
+	}
+
+	// Load API access config
+	if err := envconfig.Process("", &cfg.API); err != nil {
+		return nil, fmt.Errorf("load api config: %w", err)
+	}
+
+	// Load web UI config
+	if err := envconfig.Process("", &cfg.UI); err != nil {
+		return nil, fmt.Errorf("load ui config: %w", err)
+	}
+
+	// Load notification config
+	if err := envconfig.Process("", &cfg.Notify); err != nil {
+		return nil, fmt.Errorf("load notify config: %w", err)
+	}
+
 	return &cfg, nil
 }
 
+// Validate checks that configuration values are within sensible ranges.
+func (c *Config) Validate() error {
+	if c.DB.Port <= 0 || c.DB.Port > 65535 {
+		return fmt.Errorf("DB_PORT %d out of range", c.DB.Port)
+	}
+	if c.DB.Host == "" || c.DB.Name == "" {
+		return fmt.Errorf("DB_HOST and DB_NAME are required")
+	}
+	if c.Pipeline.DefaultLimit <= 0 {
+		return fmt.Errorf("DEFAULT_LIMIT must be positive, got %d", c.Pipeline.DefaultLimit)
+	}
+	if c.Filter.ShadowMaxDelta < 0 || c.Filter.ShadowMaxDelta > 100 {
+		return fmt.Errorf("FILTER_SHADOW_MAX_DELTA must be 0-100, got %d", c.Filter.ShadowMaxDelta)
+	}
+	for pages, points := range c.Filter.PageTiers {
+		if pages <= 0 || points < 0 || points > 100 {
+			return fmt.Errorf("FILTER_PAGE_TIERS entry %d:%d must have positive pages and 0-100 points", pages, points)
+		}
+	}
+	if c.Pipeline.DefaultMinScore < 0 || c.Pipeline.DefaultMinScore > 100 {
+		return fmt.Errorf("DEFAULT_MIN_SCORE must be 0-100, got %d", c.Pipeline.DefaultMinScore)
+	}
+	if c.Sync.JobHistory < 1 || c.Sync.JobTTLMinutes < 0 {
+		return fmt.Errorf("SYNC_JOB_HISTORY must be at least 1 and SYNC_JOB_TTL_MINUTES not negative, got %d and %d",
+			c.Sync.JobHistory, c.Sync.JobTTLMinutes)
+	}
+	if c.Sync.RequestRetentionDays < 0 {
+		return fmt.Errorf("SYNC_REQUEST_RETENTION_DAYS must not be negative, got %d", c.Sync.RequestRetentionDays)
+	}
+	if c.Pipeline.MaxAbstractLength < 100 {
+		return fmt.Errorf("MAX_ABSTRACT_LENGTH must be at least 100, got %d", c.Pipeline.MaxAbstractLength)
+	}
+	if c.Pipeline.CacheTTLMinutes < 0 {
+		return fmt.Errorf("PROVIDER_CACHE_TTL_MINUTES must not be negative, got %d", c.Pipeline.CacheTTLMinutes)
+	}
+	if c.Pipeline.NearDuplicateDistance < 0 || c.Pipeline.NearDuplicateDistance > 24 {
+		return fmt.Errorf("NEAR_DUPLICATE_DISTANCE must be between 0 and 24, got %d", c.Pipeline.NearDuplicateDistance)
+	}
+	if c.Filter.MaxText < 1000 {
+		return fmt.Errorf("FILTER_MAX_TEXT must be at least 1000, got %d", c.Filter.MaxText)
+	}
+	if c.Audit.RetentionDays < 0 {
+		return fmt.Errorf("AUDIT_RETENTION_DAYS must not be negative, got %d", c.Audit.RetentionDays)
+	}
+	if c.Output.Retention < 0 {
+		return fmt.Errorf("OUTPUT_RETENTION must not be negative, got %d", c.Output.Retention)
+	}
+	if c.Pipeline.DefaultMaxAge < 0 {
+		return fmt.Errorf("DEFAULT_MAX_AGE must not be negative, got %d", c.Pipeline.DefaultMaxAge)
+	}
+	if c.Notify.BatchSize < 1 || c.Notify.MaxPerSync < 1 || c.Notify.QueueSize < 1 {
+		return fmt.Errorf("NOTIFY_BATCH_SIZE, NOTIFY_MAX_PER_SYNC and NOTIFY_QUEUE_SIZE must be positive, got %d, %d and %d",
+			c.Notify.BatchSize, c.Notify.MaxPerSync, c.Notify.QueueSize)
+	}
+	return nil
+}
+
 // MustLoad loads configuration and panics on error.
 func MustLoad() *Config {
 	cfg, err := Load()
@@ -0,0 +1,48 @@
+package config
+
+import "testing"
+
+func TestLoad_ReadsEverySection(t *testing.T) {
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("FILTER_SHADOW_RULES", "rules/candidate.json")
+	t.Setenv("FILTER_SHADOW_MAX_DELTA", "15")
+	t.Setenv("FILTER_PAGE_TIERS", "20:5,40:10")
+	t.Setenv("AUDIT_RETENTION_DAYS", "30")
+	t.Setenv("OUTPUT_DIR", "out")
+	t.Setenv("UI_SYNC_FORM", "true")
+	t.Setenv("NOTIFY_WEBHOOK_URL", "https://hooks.example.com/x")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.DB.Host != "db.internal" {
+		t.Errorf("DB.Host = %q", cfg.DB.Host)
+	}
+	if cfg.Filter.ShadowRules != "rules/candidate.json" || cfg.Filter.ShadowMaxDelta != 15 {
+		t.Errorf("Filter = %+v, want shadow settings loaded", cfg.Filter)
+	}
+	if len(cfg.Filter.PageTiers) != 2 || cfg.Filter.PageTiers[40] != 10 {
+		t.Errorf("Filter.PageTiers = %v", cfg.Filter.PageTiers)
+	}
+	if cfg.Audit.RetentionDays != 30 || cfg.Output.Dir != "out" || !cfg.UI.SyncForm {
+		t.Errorf("Audit, Output or UI not loaded: %+v %+v %+v", cfg.Audit, cfg.Output, cfg.UI)
+	}
+	if cfg.Notify.WebhookURL != "https://hooks.example.com/x" || cfg.Notify.BatchSize != 10 || cfg.Notify.MaxPerSync != 50 {
+		t.Errorf("Notify = %+v", cfg.Notify)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestLoad_FilterDefaults(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Filter.ShadowMaxDelta != 10 || cfg.Filter.ShadowRules != "" || len(cfg.Filter.PageTiers) != 0 || cfg.Filter.MaxText != 20000 {
+		t.Errorf("Filter = %+v, want defaults", cfg.Filter)
+	}
+}
@@ -0,0 +1,212 @@
+// Package console renders pipeline results for a terminal. Each run writes
+// into its own Block, and blocks reach the shared writer whole, so
+// concurrent runs never interleave their output.
+package console
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/diff"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/ingest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
+	"golang.org/x/term"
+)
+
+// DefaultMaxBuffer is the default per-block buffer limit.
+const DefaultMaxBuffer = 1 << 20
+
+// DefaultWidth is the layout width when the output is not a terminal.
+const DefaultWidth = 80
+
+// minWidth keeps wrapped text readable on absurdly narrow terminals.
+const minWidth = 40
+
+// Renderer serialises blocks onto one writer.
+type Renderer struct {
+	w  io.Writer
+	mu sync.Mutex // Held while a block writes to w
+
+	MaxBuffer int  // Bytes a block buffers before it takes w and streams (default: 1 MiB)
+	Color     bool // Color abstract diffs (default: on unless NO_COLOR is set)
+	Width     int  // Columns to lay out for (default: 80; see TerminalWidth)
+}
+
+// TerminalWidth returns the width of the terminal f is attached to, or
+// DefaultWidth when f is not a terminal.
+func TerminalWidth(f *os.File) int {
+	if w, _, err := term.GetSize(int(f.Fd())); err == nil && w > 0 {
+		return w
+	}
+	return DefaultWidth
+}
+
+// NewRenderer creates a renderer writing to w.
+func NewRenderer(w io.Writer) *Renderer {
+	return &Renderer{
+		w:         w,
+		MaxBuffer: DefaultMaxBuffer,
+		Color:     os.Getenv("NO_COLOR") == "",
+		Width:     DefaultWidth,
+	}
+}
+
+// Begin starts a block of output for one run. The block must be flushed.
+func (r *Renderer) Begin() *Block {
+	return &Block{r: r}
+}
+
+// Block buffers one run's output. A block is not safe for concurrent use.
+//
+// Output stays in memory until Flush. If it grows past MaxBuffer, the block
+// takes the renderer's writer early and streams the rest, so memory stays
+// bounded and the block is still written without interruption; other blocks
+// wait for Flush.
+type Block struct {
+	r      *Renderer
+	buf    bytes.Buffer
+	locked bool
+	err    error
+}
+
+// Write appends p to the block. It implements io.Writer.
+func (b *Block) Write(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	b.buf.Write(p)
+	if limit := b.r.MaxBuffer; limit > 0 && b.buf.Len() > limit {
+		b.spill()
+	}
+	return len(p), b.err
+}
+
+// spill writes the buffer out, taking the writer for the rest of the block.
+func (b *Block) spill() {
+	if !b.locked {
+		b.r.mu.Lock()
+		b.locked = true
+	}
+	if _, err := b.r.w.Write(b.buf.Bytes()); err != nil && b.err == nil {
+		b.err = fmt.Errorf("write output: %w", err)
+	}
+	b.buf.Reset()
+}
+
+// Flush writes the block and releases the writer. It returns the first
+// write error.
+func (b *Block) Flush() error {
+	if b.buf.Len() > 0 || b.locked {
+		b.spill()
+		b.locked = false
+		b.r.mu.Unlock()
+	}
+	return b.err
+}
+
+func (b *Block) printf(format string, args ...any) {
+	fmt.Fprintf(b, format, args...)
+}
+
+func (b *Block) width() int {
+	if b.r.Width <= 0 {
+		return DefaultWidth
+	}
+	return max(b.r.Width, minWidth)
+}
+
+// rule draws a separator line across the layout width.
+func (b *Block) rule() {
+	b.printf("%s\n", strings.Repeat("═", b.width()))
+}
+
+// links writes the paper's arXiv abstract and PDF links, when its ID is
+// an arXiv ID.
+func (b *Block) links(p model.Paper) {
+	if abs := p.AbsURL(); abs != "" {
+		b.printf("    📄 Abstract: %s\n", abs)
+		b.printf("    📥 PDF:      %s\n", p.PDFURL())
+	}
+}
+
+// wrapped writes text after prefix, wrapped to the layout width with
+// continuation lines indented to where the text starts.
+func (b *Block) wrapped(prefix, text string) {
+	indent := textutil.DisplayWidth(prefix)
+	lines := textutil.Wrap(text, b.width()-indent)
+	b.printf("%s%s\n", prefix, lines[0])
+	for _, line := range lines[1:] {
+		b.printf("%s%s\n", strings.Repeat(" ", indent), line)
+	}
+}
+
+// FilterResults lists the papers that passed the quality filter, or every
+// fetched paper when the filter was skipped.
+func (b *Block) FilterResults(results []filter.FilterResult, passed []model.Paper, skipFilter bool) {
+	b.printf("\n")
+	b.rule()
+
+	if skipFilter {
+		// No filter applied, just print papers
+		b.printf("  📚 Fetched %d papers (filter skipped):\n", len(passed))
+		b.rule()
+		for i, p := range passed {
+			b.printf("\n")
+			b.wrapped(fmt.Sprintf("[%d] ", i+1), textutil.RenderTitle(p.Title, textutil.TitlePlain))
+			b.wrapped("    Authors: ", strings.Join(p.Authors, ", "))
+			b.links(p)
+		}
+	} else {
+		// Only show papers that passed the filter
+		b.printf("  📚 Filter Results: %d/%d papers passed\n", len(passed), len(results))
+		b.rule()
+
+		for i, p := range passed {
+			b.printf("\n")
+			b.wrapped(fmt.Sprintf("[%d] ✅ ", i+1), textutil.RenderTitle(p.Title, textutil.TitlePlain))
+			b.printf("    Score: %d/100 | Updated: %s\n", p.Score, p.UpdatedAt.Format("2006-01-02"))
+			if len(p.ScoreDetails) > 0 {
+				b.wrapped("    Details: ", strings.Join(p.ScoreDetails, ", "))
+			}
+			b.links(p)
+		}
+	}
+
+	b.printf("\n")
+	b.rule()
+}
+
+// Updates lists papers that replaced an older stored arXiv version.
+func (b *Block) Updates(updates []ingest.Update) {
+	if len(updates) == 0 {
+		return
+	}
+
+	b.printf("  🔄 Updated papers: %d new arXiv versions\n", len(updates))
+	b.rule()
+	for i, u := range updates {
+		change := "metadata unchanged"
+		if u.Version.ContentChanged {
+			change = "content changed"
+		}
+		b.printf("\n")
+		b.wrapped(fmt.Sprintf("[%d] ", i+1), textutil.RenderTitle(u.Paper.Title, textutil.TitlePlain))
+		b.printf("    v%d → v%d (%s) | Score: %d/100\n", u.Version.OldVersion, u.Version.NewVersion, change, u.Paper.Score)
+		segs := diff.Words(u.Version.OldAbstract, u.Version.NewAbstract)
+		if stats := diff.Summarize(segs); stats.Changed() {
+			b.printf("    ✏️  Abstract changed since last seen (%s):\n", stats)
+			b.wrapped("    ", diff.Text(segs, b.r.Color))
+		}
+		if abs := u.Paper.AbsURL(); abs != "" {
+			b.printf("    📄 Abstract: %s\n", abs)
+		}
+	}
+	b.printf("\n")
+	b.rule()
+}
@@ -0,0 +1,249 @@
+package console
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/ingest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
+)
+
+func papers(prefix string, n int) []model.Paper {
+	var ps []model.Paper
+	for i := 0; i < n; i++ {
+		ps = append(ps, model.Paper{
+			ID:        fmt.Sprintf("%s.%05dv1", prefix, i),
+			Title:     fmt.Sprintf("%s paper %d", prefix, i),
+			Score:     70,
+			UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+	return ps
+}
+
+// syncWriter is a bytes.Buffer safe for concurrent writes that records how
+// large each write was.
+type syncWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes []int
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, len(p))
+	return w.buf.Write(p)
+}
+
+// assertContiguous checks that each run's papers appear as one unbroken
+// block in out.
+func assertContiguous(t *testing.T, out string, runs []string, perRun int) {
+	t.Helper()
+	lines := strings.Split(out, "\n")
+	for _, run := range runs {
+		first, last, count := -1, -1, 0
+		for i, line := range lines {
+			if strings.Contains(line, run+" paper") {
+				if first < 0 {
+					first = i
+				}
+				last = i
+				count++
+			}
+		}
+		if count != perRun {
+			t.Errorf("run %s printed %d papers, want %d", run, count, perRun)
+			continue
+		}
+		for _, line := range lines[first : last+1] {
+			for _, other := range runs {
+				if other != run && strings.Contains(line, other+" paper") {
+					t.Fatalf("run %s output interleaved with run %s", run, other)
+				}
+			}
+		}
+	}
+}
+
+func renderConcurrently(r *Renderer, runs []string, perRun int) {
+	var wg sync.WaitGroup
+	for _, run := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out := r.Begin()
+			for _, p := range papers(run, perRun) {
+				// Render paper by paper to give the scheduler room to interleave
+				out.FilterResults(nil, []model.Paper{p}, false)
+			}
+			out.Flush()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRenderer_ConcurrentBlocksDoNotInterleave(t *testing.T) {
+	var w syncWriter
+	r := NewRenderer(&w)
+	runs := []string{"2401", "2402"}
+
+	renderConcurrently(r, runs, 50)
+
+	if len(w.writes) != len(runs) {
+		t.Errorf("got %d writes, want one per run", len(w.writes))
+	}
+	assertContiguous(t, w.buf.String(), runs, 50)
+}
+
+func TestRenderer_BoundedBufferStillDoesNotInterleave(t *testing.T) {
+	var w syncWriter
+	r := NewRenderer(&w)
+	r.MaxBuffer = 1024
+	runs := []string{"2401", "2402", "2403"}
+
+	renderConcurrently(r, runs, 100)
+
+	for _, n := range w.writes {
+		// One paper block is well under 1 KiB, so a spill never exceeds twice the limit
+		if n > 2*r.MaxBuffer {
+			t.Errorf("wrote %d bytes at once, want at most %d", n, 2*r.MaxBuffer)
+		}
+	}
+	if len(w.writes) <= len(runs) {
+		t.Errorf("got %d writes, want blocks to stream in several writes", len(w.writes))
+	}
+	assertContiguous(t, w.buf.String(), runs, 100)
+}
+
+func TestBlock_FilterResults(t *testing.T) {
+	var buf bytes.Buffer
+	out := NewRenderer(&buf).Begin()
+
+	p := papers("2401", 1)[0]
+	p.ScoreDetails = []string{"+30 接收信号"}
+	out.FilterResults(nil, []model.Paper{p}, false)
+	if buf.Len() != 0 {
+		t.Fatal("output written before Flush")
+	}
+	if err := out.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		"Filter Results: 1/0 papers passed",
+		"[1] ✅ 2401 paper 0",
+		"Score: 70/100 | Updated: 2024-01-01",
+		"Details: +30 接收信号",
+		"https://arxiv.org/pdf/2401.00000v1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestBlock_UpdatesShowsAbstractDiff(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRenderer(&buf)
+	r.Color = false
+	out := r.Begin()
+
+	out.Updates([]ingest.Update{{
+		Paper: model.Paper{ID: "2401.00001v2", Title: "Sparse Routing", Score: 81},
+		Version: model.VersionUpdate{
+			OldVersion: 1, NewVersion: 2, ContentChanged: true,
+			OldAbstract: "We reach 81.2% accuracy.",
+			NewAbstract: "We reach 84.7% accuracy.",
+		},
+	}})
+	out.Flush()
+
+	got := buf.String()
+	for _, want := range []string{
+		"v1 → v2 (content changed) | Score: 81/100",
+		"Abstract changed since last seen (+1 -1 words)",
+		"We reach [-81.2-]{+84.7+}% accuracy.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// goldenBlock renders a fixed set of results: a long English title, a
+// Chinese title, a long breakdown and an abstract diff.
+func goldenBlock(width int) string {
+	var buf bytes.Buffer
+	r := NewRenderer(&buf)
+	r.Color = false
+	r.Width = width
+	out := r.Begin()
+
+	updated := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	passed := []model.Paper{
+		{
+			ID:           "2403.01234v2",
+			Title:        `Scaling Sparse Mixture-of-Experts Transformers to $10^{6}$ Tokens with Learned Routing and Memory-Efficient Attention`,
+			Score:        85,
+			ScoreDetails: []string{"+30 接收信号", "+15 强评估证据", "+10 消融/基线实验", "+10 数据集/基准", "+10 代码链接", "+5 多版本迭代"},
+			UpdatedAt:    updated,
+		},
+		{
+			ID:           "2403.05678v1",
+			Title:        "面向长上下文大型语言模型的检索增强生成：基准、评测方法与系统性综述",
+			Score:        65,
+			ScoreDetails: []string{"+30 接收信号", "+10 数据集/基准"},
+			UpdatedAt:    updated,
+		},
+	}
+	out.FilterResults(nil, passed, false)
+	out.Updates([]ingest.Update{{
+		Paper: model.Paper{ID: "2403.01234v2", Title: passed[0].Title, Score: 85},
+		Version: model.VersionUpdate{
+			OldVersion: 1, NewVersion: 2, ContentChanged: true,
+			OldAbstract: "We scale sparse routing to long contexts and reach 81.2% accuracy on the retrieval benchmark while halving memory use.",
+			NewAbstract: "We scale sparse routing to long contexts and reach 84.7% accuracy on the retrieval benchmark while halving memory use; code is at https://github.com/example/sparse-routing-long-context.",
+		},
+	}})
+	out.Flush()
+	return buf.String()
+}
+
+func TestBlock_GoldenWidths(t *testing.T) {
+	for _, width := range []int{60, 80, 120} {
+		got := goldenBlock(width)
+
+		path := filepath.Join("testdata", fmt.Sprintf("results_%d.golden", width))
+		if *update {
+			if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read golden (run with -update to create): %v", err)
+		}
+		if got != string(want) {
+			t.Errorf("width %d:\n%s\nwant:\n%s", width, got, want)
+		}
+
+		// Only lines holding a single unbreakable word may overflow
+		for _, line := range strings.Split(got, "\n") {
+			if textutil.DisplayWidth(line) > width && strings.Contains(strings.TrimSpace(line), " ") && !strings.Contains(line, "https://") {
+				t.Errorf("width %d: line overflows (%d columns): %q", width, textutil.DisplayWidth(line), line)
+			}
+		}
+	}
+}
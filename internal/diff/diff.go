@@ -0,0 +1,242 @@
+// Package diff computes word-level differences between two texts and
+// renders them for terminals and HTML.
+package diff
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Op says how a segment relates the old text to the new one.
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// maxCells bounds the LCS table. Texts with more token pairs are reported
+// as one deletion followed by one insertion.
+const maxCells = 4_000_000
+
+// Segment is a run of text with one Op.
+type Segment struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Words diffs a and b on word, number, whitespace and punctuation tokens
+// using a longest common subsequence. Adjacent segments with the same Op
+// are merged.
+func Words(a, b string) []Segment {
+	return lcs(Tokenize(a), Tokenize(b))
+}
+
+// Tokenize splits s into words, numbers, whitespace runs and single
+// punctuation marks. Numbers keep inner separators ("0.85", "1,024"), and
+// each Han, Hiragana or Katakana character is its own token because those
+// scripts do not separate words with spaces. Concatenating the tokens
+// yields s.
+func Tokenize(s string) []string {
+	runes := []rune(s)
+	var tokens []string
+	for i := 0; i < len(runes); {
+		j := i + 1
+		r := runes[i]
+		switch {
+		case isIdeograph(r):
+			// single-rune token
+		case unicode.IsSpace(r):
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+		case isWordRune(r):
+			for j < len(runes) {
+				if isWordRune(runes[j]) && !isIdeograph(runes[j]) {
+					j++
+					continue
+				}
+				// Decimal point or thousands separator between digits
+				if (runes[j] == '.' || runes[j] == ',') && unicode.IsDigit(runes[j-1]) &&
+					j+1 < len(runes) && unicode.IsDigit(runes[j+1]) {
+					j += 2
+					continue
+				}
+				break
+			}
+		}
+		tokens = append(tokens, string(runes[i:j]))
+		i = j
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+func lcs(a, b []string) []Segment {
+	// Trim the common prefix and suffix; revisions usually touch a few words.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var segs []Segment
+	segs = appendSeg(segs, Equal, a[:prefix]...)
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midA)*len(midB) > maxCells {
+		segs = appendSeg(segs, Delete, midA...)
+		segs = appendSeg(segs, Insert, midB...)
+	} else {
+		segs = appendMiddle(segs, midA, midB)
+	}
+
+	return appendSeg(segs, Equal, a[len(a)-suffix:]...)
+}
+
+// appendMiddle fills an LCS length table and walks it forwards, preferring
+// deletions before insertions at each change.
+func appendMiddle(segs []Segment, a, b []string) []Segment {
+	n, m := len(a), len(b)
+	// table[i][j] is the LCS length of a[i:] and b[j:]
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			segs = appendSeg(segs, Equal, a[i])
+			i++
+			j++
+		case j == m || (i < n && table[i+1][j] >= table[i][j+1]):
+			segs = appendSeg(segs, Delete, a[i])
+			i++
+		default:
+			segs = appendSeg(segs, Insert, b[j])
+			j++
+		}
+	}
+	return segs
+}
+
+// appendSeg adds tokens to segs, extending the last segment if it has the
+// same op.
+func appendSeg(segs []Segment, op Op, tokens ...string) []Segment {
+	if len(tokens) == 0 {
+		return segs
+	}
+	text := strings.Join(tokens, "")
+	if n := len(segs); n > 0 && segs[n-1].Op == op {
+		segs[n-1].Text += text
+		return segs
+	}
+	return append(segs, Segment{Op: op, Text: text})
+}
+
+// Stats counts inserted and deleted words, ignoring whitespace and punctuation.
+type Stats struct {
+	Inserted int `json:"inserted"`
+	Deleted  int `json:"deleted"`
+}
+
+// Changed reports whether any word was inserted or deleted.
+func (s Stats) Changed() bool {
+	return s.Inserted > 0 || s.Deleted > 0
+}
+
+// String formats the stats as "+3 -1 words".
+func (s Stats) String() string {
+	return fmt.Sprintf("+%d -%d words", s.Inserted, s.Deleted)
+}
+
+// Summarize counts the word and number tokens inserted and deleted.
+func Summarize(segs []Segment) Stats {
+	var st Stats
+	for _, seg := range segs {
+		n := 0
+		for _, tok := range Tokenize(seg.Text) {
+			if isWordRune([]rune(tok)[0]) {
+				n++
+			}
+		}
+		switch seg.Op {
+		case Insert:
+			st.Inserted += n
+		case Delete:
+			st.Deleted += n
+		}
+	}
+	return st
+}
+
+// ANSI escape sequences for Text.
+const (
+	ansiRed   = "\x1b[31;9m" // red, struck through
+	ansiGreen = "\x1b[32m"
+	ansiReset = "\x1b[0m"
+)
+
+// Text renders segments for a terminal. With color, deletions are red and
+// struck through and insertions green; without it they are marked
+// [-deleted-] and {+inserted+}.
+func Text(segs []Segment, color bool) string {
+	var b strings.Builder
+	for _, seg := range segs {
+		switch {
+		case seg.Op == Equal:
+			b.WriteString(seg.Text)
+		case color && seg.Op == Delete:
+			b.WriteString(ansiRed + seg.Text + ansiReset)
+		case color:
+			b.WriteString(ansiGreen + seg.Text + ansiReset)
+		case seg.Op == Delete:
+			b.WriteString("[-" + seg.Text + "-]")
+		default:
+			b.WriteString("{+" + seg.Text + "+}")
+		}
+	}
+	return b.String()
+}
+
+// HTML renders segments as escaped HTML with <del> and <ins> spans.
+func HTML(segs []Segment) string {
+	var b strings.Builder
+	for _, seg := range segs {
+		text := html.EscapeString(seg.Text)
+		switch seg.Op {
+		case Delete:
+			b.WriteString("<del>" + text + "</del>")
+		case Insert:
+			b.WriteString("<ins>" + text + "</ins>")
+		default:
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
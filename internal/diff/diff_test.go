@@ -0,0 +1,156 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"hello world", []string{"hello", " ", "world"}},
+		{"accuracy of 0.85, up from 1,024.", []string{"accuracy", " ", "of", " ", "0.85", ",", " ", "up", " ", "from", " ", "1,024", "."}},
+		{"state-of-the-art (SOTA)", []string{"state", "-", "of", "-", "the", "-", "art", " ", "(", "SOTA", ")"}},
+		{"naïve  Schrödinger\n", []string{"naïve", "  ", "Schrödinger", "\n"}},
+		{"大语言模型", []string{"大", "语", "言", "模", "型"}},
+		{"GPT-4在MMLU上", []string{"GPT", "-", "4", "在", "MMLU", "上"}},
+		{"3.5x faster", []string{"3.5x", " ", "faster"}},
+		{"end.", []string{"end", "."}},
+	}
+
+	for _, tc := range tests {
+		got := Tokenize(tc.input)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+		if joined := strings.Join(got, ""); joined != tc.input {
+			t.Errorf("Tokenize(%q) rejoins to %q", tc.input, joined)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Segment
+	}{
+		{
+			name: "identical",
+			a:    "same text",
+			b:    "same text",
+			want: []Segment{{Equal, "same text"}},
+		},
+		{
+			name: "both empty",
+			a:    "",
+			b:    "",
+			want: nil,
+		},
+		{
+			name: "number change",
+			a:    "We reach 81.2% accuracy.",
+			b:    "We reach 84.7% accuracy.",
+			want: []Segment{{Equal, "We reach "}, {Delete, "81.2"}, {Insert, "84.7"}, {Equal, "% accuracy."}},
+		},
+		{
+			name: "insertion",
+			a:    "a fast model",
+			b:    "a fast and small model",
+			want: []Segment{{Equal, "a fast "}, {Insert, "and small "}, {Equal, "model"}},
+		},
+		{
+			name: "deletion with punctuation",
+			a:    "results, however, improve.",
+			b:    "results improve.",
+			want: []Segment{{Equal, "results"}, {Delete, ", however,"}, {Equal, " improve."}},
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "new abstract",
+			want: []Segment{{Insert, "new abstract"}},
+		},
+		{
+			name: "accented word",
+			a:    "a naive approach",
+			b:    "a naïve approach",
+			want: []Segment{{Equal, "a "}, {Delete, "naive"}, {Insert, "naïve"}, {Equal, " approach"}},
+		},
+		{
+			name: "han characters",
+			a:    "提出了新方法",
+			b:    "提出了新模型",
+			want: []Segment{{Equal, "提出了新"}, {Delete, "方法"}, {Insert, "模型"}},
+		},
+	}
+
+	for _, tc := range tests {
+		got := Words(tc.a, tc.b)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: Words(%q, %q) = %v, want %v", tc.name, tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestWords_Reconstructs(t *testing.T) {
+	a := "We propose X. It beats Y by 3 points on GLUE and 2 on SQuAD."
+	b := "We propose X, a simple method. It beats Y by 4.5 points on GLUE."
+
+	var oldText, newText strings.Builder
+	for _, seg := range Words(a, b) {
+		if seg.Op != Insert {
+			oldText.WriteString(seg.Text)
+		}
+		if seg.Op != Delete {
+			newText.WriteString(seg.Text)
+		}
+	}
+	if oldText.String() != a {
+		t.Errorf("old side = %q, want %q", oldText.String(), a)
+	}
+	if newText.String() != b {
+		t.Errorf("new side = %q, want %q", newText.String(), b)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	segs := Words("accuracy of 81.2% on ImageNet", "accuracy of 84.7% on ImageNet and COCO")
+	got := Summarize(segs)
+	want := Stats{Inserted: 3, Deleted: 1} // "84.7", "and", "COCO" / "81.2"
+	if got != want {
+		t.Errorf("Summarize = %+v, want %+v", got, want)
+	}
+	if got.String() != "+3 -1 words" {
+		t.Errorf("String() = %q", got.String())
+	}
+	if got := Summarize(Words("results.", "results (see appendix).")); got != (Stats{Inserted: 2}) {
+		t.Errorf("Summarize punctuation = %+v, want 2 inserted words", got)
+	}
+	if Summarize(Words("same", "same")).Changed() {
+		t.Error("identical texts reported as changed")
+	}
+}
+
+func TestText(t *testing.T) {
+	segs := []Segment{{Equal, "a "}, {Delete, "old"}, {Insert, "new"}}
+
+	if got, want := Text(segs, false), "a [-old-]{+new+}"; got != want {
+		t.Errorf("Text(plain) = %q, want %q", got, want)
+	}
+	if got, want := Text(segs, true), "a \x1b[31;9mold\x1b[0m\x1b[32mnew\x1b[0m"; got != want {
+		t.Errorf("Text(color) = %q, want %q", got, want)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	segs := Words("x < y & z", "x <= y & z")
+	want := "x &lt;<ins>=</ins> y &amp; z"
+	if got := HTML(segs); got != want {
+		t.Errorf("HTML = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+// Package digest picks the papers a periodic digest lists: the best of
+// each section up to a cap, up to a cap overall, with the rest that made
+// the score cut kept as one-line honourable mentions.
+package digest
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// OtherSection names the section of papers the Section function puts in
+// none.
+const OtherSection = "other"
+
+// Options control the selection. The zero value lists every paper, by
+// primary category.
+type Options struct {
+	MinScore    int            // Papers scoring lower are left out entirely (default: 0)
+	SectionCap  int            // Most papers listed per section (default: 0, no cap)
+	SectionCaps map[string]int // SectionCap for particular sections, e.g. {"cs.CL": 8}
+	MaxPapers   int            // Most papers listed across sections (default: 0, no cap)
+
+	// Section returns the section a paper is listed in, e.g. the preset
+	// that matched it (default: PrimaryCategory)
+	Section func(model.Paper) string
+}
+
+// Section is one heading of a digest.
+type Section struct {
+	Name   string
+	Papers []model.Paper // Best first
+}
+
+// Digest is the outcome of Select.
+type Digest struct {
+	Sections []Section     // In the order of their best paper
+	Mentions []model.Paper // Papers that made MinScore but no section, best first
+}
+
+// PrimaryCategory returns the paper's first category.
+func PrimaryCategory(p model.Paper) string {
+	if len(p.Categories) == 0 {
+		return ""
+	}
+	return p.Categories[0]
+}
+
+// Select ranks papers and fills the sections from the top, so a capped
+// section or a full digest sends the paper to Mentions instead. Only the
+// latest version of each paper counts. The outcome depends only on the
+// set of papers, not their order (see Compare).
+func Select(papers []model.Paper, opts Options) Digest {
+	section := opts.Section
+	if section == nil {
+		section = PrimaryCategory
+	}
+
+	ranked := rank(papers, opts.MinScore)
+	d := Digest{Sections: []Section{}, Mentions: []model.Paper{}}
+	index := make(map[string]int) // Section name -> position in d.Sections
+	listed := 0
+	for _, p := range ranked {
+		name := section(p)
+		if name == "" {
+			name = OtherSection
+		}
+		limit, ok := opts.SectionCaps[name]
+		if !ok {
+			limit = opts.SectionCap
+		}
+		i, seen := index[name]
+		full := (opts.MaxPapers > 0 && listed >= opts.MaxPapers) ||
+			(limit > 0 && seen && len(d.Sections[i].Papers) >= limit)
+		if full {
+			d.Mentions = append(d.Mentions, p)
+			continue
+		}
+		if !seen {
+			i = len(d.Sections)
+			index[name] = i
+			d.Sections = append(d.Sections, Section{Name: name})
+		}
+		d.Sections[i].Papers = append(d.Sections[i].Papers, p)
+		listed++
+	}
+	return d
+}
+
+// rank returns the latest version of each paper scoring at least
+// minScore, best first.
+func rank(papers []model.Paper, minScore int) []model.Paper {
+	latest := make(map[string]model.Paper, len(papers))
+	for _, p := range papers {
+		prev, ok := latest[p.BaseID()]
+		if !ok || p.Version() > prev.Version() || (p.Version() == prev.Version() && Compare(p, prev) < 0) {
+			latest[p.BaseID()] = p
+		}
+	}
+	ranked := make([]model.Paper, 0, len(latest))
+	for _, p := range latest {
+		if p.Score >= minScore {
+			ranked = append(ranked, p)
+		}
+	}
+	slices.SortFunc(ranked, Compare)
+	return ranked
+}
+
+// Compare orders papers best first: higher score, then the more recent
+// last update, then the lower ID. Distinct IDs never compare equal, so
+// the order is total.
+func Compare(a, b model.Paper) int {
+	if c := cmp.Compare(b.Score, a.Score); c != 0 {
+		return c
+	}
+	if c := b.UpdatedAt.Compare(a.UpdatedAt); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
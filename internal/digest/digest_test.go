@@ -0,0 +1,180 @@
+package digest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+var day = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func paper(id, category string, score int) model.Paper {
+	return model.Paper{ID: id, Categories: []string{category}, Score: score, UpdatedAt: day}
+}
+
+// names returns the IDs listed under each section, in order.
+func names(d Digest) map[string][]string {
+	out := make(map[string][]string, len(d.Sections))
+	for _, s := range d.Sections {
+		for _, p := range s.Papers {
+			out[s.Name] = append(out[s.Name], p.ID)
+		}
+	}
+	return out
+}
+
+func ids(papers []model.Paper) []string {
+	out := []string{}
+	for _, p := range papers {
+		out = append(out, p.ID)
+	}
+	return out
+}
+
+func TestSelect_Caps(t *testing.T) {
+	papers := []model.Paper{
+		paper("2403.00001v1", "cs.CL", 90),
+		paper("2403.00002v1", "cs.CL", 80),
+		paper("2403.00003v1", "cs.CL", 70),
+		paper("2403.00004v1", "cs.IR", 85),
+		paper("2403.00005v1", "cs.IR", 60),
+		paper("2403.00006v1", "cs.LG", 40),
+	}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		sections map[string][]string
+		order    []string
+		mentions []string
+	}{
+		{
+			name:     "no caps",
+			sections: map[string][]string{"cs.CL": {"2403.00001v1", "2403.00002v1", "2403.00003v1"}, "cs.IR": {"2403.00004v1", "2403.00005v1"}, "cs.LG": {"2403.00006v1"}},
+			order:    []string{"cs.CL", "cs.IR", "cs.LG"},
+			mentions: []string{},
+		},
+		{
+			name:     "section cap",
+			opts:     Options{SectionCap: 1},
+			sections: map[string][]string{"cs.CL": {"2403.00001v1"}, "cs.IR": {"2403.00004v1"}, "cs.LG": {"2403.00006v1"}},
+			order:    []string{"cs.CL", "cs.IR", "cs.LG"},
+			mentions: []string{"2403.00002v1", "2403.00003v1", "2403.00005v1"},
+		},
+		{
+			name:     "per-section cap",
+			opts:     Options{SectionCap: 1, SectionCaps: map[string]int{"cs.CL": 2}},
+			sections: map[string][]string{"cs.CL": {"2403.00001v1", "2403.00002v1"}, "cs.IR": {"2403.00004v1"}, "cs.LG": {"2403.00006v1"}},
+			order:    []string{"cs.CL", "cs.IR", "cs.LG"},
+			mentions: []string{"2403.00003v1", "2403.00005v1"},
+		},
+		{
+			name:     "overall cap",
+			opts:     Options{MaxPapers: 3},
+			sections: map[string][]string{"cs.CL": {"2403.00001v1", "2403.00002v1"}, "cs.IR": {"2403.00004v1"}},
+			order:    []string{"cs.CL", "cs.IR"},
+			mentions: []string{"2403.00003v1", "2403.00005v1", "2403.00006v1"},
+		},
+		{
+			name:     "both caps",
+			opts:     Options{SectionCap: 1, MaxPapers: 2},
+			sections: map[string][]string{"cs.CL": {"2403.00001v1"}, "cs.IR": {"2403.00004v1"}},
+			order:    []string{"cs.CL", "cs.IR"},
+			mentions: []string{"2403.00002v1", "2403.00003v1", "2403.00005v1", "2403.00006v1"},
+		},
+		{
+			name:     "min score leaves papers out entirely",
+			opts:     Options{MinScore: 65, SectionCap: 2},
+			sections: map[string][]string{"cs.CL": {"2403.00001v1", "2403.00002v1"}, "cs.IR": {"2403.00004v1"}},
+			order:    []string{"cs.CL", "cs.IR"},
+			mentions: []string{"2403.00003v1"},
+		},
+	}
+
+	for _, tc := range tests {
+		d := Select(papers, tc.opts)
+		if got := names(d); !reflect.DeepEqual(got, tc.sections) {
+			t.Errorf("%s: sections = %v, want %v", tc.name, got, tc.sections)
+		}
+		var order []string
+		for _, s := range d.Sections {
+			order = append(order, s.Name)
+		}
+		if !reflect.DeepEqual(order, tc.order) {
+			t.Errorf("%s: section order = %v, want %v", tc.name, order, tc.order)
+		}
+		if got := ids(d.Mentions); !reflect.DeepEqual(got, tc.mentions) {
+			t.Errorf("%s: mentions = %v, want %v", tc.name, got, tc.mentions)
+		}
+	}
+}
+
+func TestSelect_CustomSection(t *testing.T) {
+	presets := map[string]string{"2403.00001v1": "rag", "2403.00002v1": "rag", "2403.00003v1": "agents"}
+	papers := []model.Paper{
+		paper("2403.00001v1", "cs.CL", 50),
+		paper("2403.00002v1", "cs.IR", 70),
+		paper("2403.00003v1", "cs.CL", 60),
+		paper("2403.00004v1", "cs.CL", 40),
+	}
+
+	d := Select(papers, Options{Section: func(p model.Paper) string { return presets[p.ID] }})
+	want := map[string][]string{"rag": {"2403.00002v1", "2403.00001v1"}, "agents": {"2403.00003v1"}, OtherSection: {"2403.00004v1"}}
+	if got := names(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %v, want %v", got, want)
+	}
+}
+
+func TestSelect_Ranking(t *testing.T) {
+	older := paper("2403.00002v1", "cs.CL", 80)
+	older.UpdatedAt = day.Add(-time.Hour)
+	papers := []model.Paper{
+		paper("2403.00003v1", "cs.CL", 80),
+		older,
+		paper("2403.00001v1", "cs.CL", 80),
+		paper("2403.00004v1", "cs.CL", 95),
+		// An older version is dropped even when it scored higher
+		paper("2403.00005v1", "cs.CL", 99),
+		paper("2403.00005v2", "cs.CL", 10),
+	}
+
+	d := Select(papers, Options{})
+	// Score first, then the more recent update, then the lower ID
+	want := []string{"2403.00004v1", "2403.00001v1", "2403.00003v1", "2403.00002v1", "2403.00005v2"}
+	if got := names(d)["cs.CL"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("ranked = %v, want %v", got, want)
+	}
+}
+
+func TestSelect_Deterministic(t *testing.T) {
+	var papers []model.Paper
+	for i := range 40 {
+		// Few distinct scores and dates, so most of the order comes from
+		// the tiebreakers
+		p := paper(fmt.Sprintf("2403.%05dv1", i), []string{"cs.CL", "cs.IR", "cs.LG"}[i%3], 50+10*(i%3))
+		p.UpdatedAt = day.Add(time.Duration(i%2) * time.Hour)
+		papers = append(papers, p)
+	}
+	opts := Options{SectionCap: 4, SectionCaps: map[string]int{"cs.LG": 2}, MaxPapers: 8}
+	want := Select(papers, opts)
+
+	rng := rand.New(rand.NewSource(1))
+	for range 20 {
+		shuffled := append([]model.Paper(nil), papers...)
+		rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		if got := Select(shuffled, opts); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Select depends on input order:\n got %v %v\nwant %v %v", names(got), ids(got.Mentions), names(want), ids(want.Mentions))
+		}
+	}
+}
+
+func TestSelect_Empty(t *testing.T) {
+	d := Select(nil, Options{SectionCap: 3})
+	if d.Sections == nil || d.Mentions == nil || len(d.Sections) != 0 || len(d.Mentions) != 0 {
+		t.Errorf("Select(nil) = %+v, want empty, non-nil lists", d)
+	}
+}
@@ -0,0 +1,174 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/llm"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// ConfigCheck loads and validates configuration.
+type ConfigCheck struct {
+	Load func() (*config.Config, error)
+}
+
+func (c ConfigCheck) Name() string   { return "config" }
+func (c ConfigCheck) Required() bool { return true }
+
+func (c ConfigCheck) Check(ctx context.Context) (string, error) {
+	cfg, err := c.Load()
+	if err != nil {
+		return "", err
+	}
+	if err := cfg.Validate(); err != nil {
+		return "", err
+	}
+	return "loaded and valid", nil
+}
+
+// DatabaseCheck connects to PostgreSQL and reports pending schema changes.
+type DatabaseCheck struct {
+	Config config.DatabaseConfig
+}
+
+func (c DatabaseCheck) Name() string   { return "database" }
+func (c DatabaseCheck) Required() bool { return true }
+
+func (c DatabaseCheck) Check(ctx context.Context) (string, error) {
+	pool, err := storage.NewPool(ctx, c.Config)
+	if err != nil {
+		return "", fmt.Errorf("%s:%d unreachable: %w", c.Config.Host, c.Config.Port, err)
+	}
+	defer pool.Close()
+
+	pending, err := storage.PendingMigrations(ctx, pool)
+	if err != nil {
+		return "", err
+	}
+	if len(pending) > 0 {
+		return "", fmt.Errorf("migrations pending: %s", strings.Join(pending, ", "))
+	}
+
+	status, err := storage.CheckMigrations(ctx, storage.NewMigrationTracker(pool), storage.Migrations)
+	if err != nil {
+		return "", err
+	}
+	if !status.UpToDate() {
+		report := strings.ReplaceAll(strings.TrimSpace(status.Report()), "\n", "; ")
+		// In auto mode pending migrations are applied on the next start
+		if c.Config.Migrate == storage.MigrateCheck || len(status.Drifted) > 0 || len(status.Unknown) > 0 {
+			return "", fmt.Errorf("schema does not match this build: %s", report)
+		}
+		return fmt.Sprintf("%s:%d reachable, %s", c.Config.Host, c.Config.Port, report), nil
+	}
+	return fmt.Sprintf("%s:%d reachable, schema up to date", c.Config.Host, c.Config.Port), nil
+}
+
+// ProviderCheck runs a one-result query against a paper provider.
+type ProviderCheck struct {
+	Label    string
+	Provider parser.Provider
+}
+
+func (c ProviderCheck) Name() string   { return c.Label }
+func (c ProviderCheck) Required() bool { return true }
+
+func (c ProviderCheck) Check(ctx context.Context) (string, error) {
+	papers, err := c.Provider.FetchPapers(ctx, "machine learning", 1)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("reachable (%d result)", len(papers)), nil
+}
+
+// LLMCheck sends a trivial prompt to the keyword extractor.
+// It is skipped unless Enabled is set.
+type LLMCheck struct {
+	Enabled bool
+	Config  config.GeminiConfig
+	// NewExtractor defaults to llm.NewKeywordExtractor.
+	NewExtractor func(cfg config.GeminiConfig) (llm.KeywordExtractor, error)
+}
+
+func (c LLMCheck) Name() string   { return "llm" }
+func (c LLMCheck) Required() bool { return c.Enabled }
+
+func (c LLMCheck) Check(ctx context.Context) (string, error) {
+	if !c.Enabled {
+		return "", fmt.Errorf("%w (use -with-llm)", ErrSkipped)
+	}
+	if !c.Config.IsConfigured() {
+		return "", fmt.Errorf("GEMINI_API_KEY not configured")
+	}
+
+	newExtractor := c.NewExtractor
+	if newExtractor == nil {
+		newExtractor = func(cfg config.GeminiConfig) (llm.KeywordExtractor, error) {
+			return llm.NewKeywordExtractor("gemini", cfg)
+		}
+	}
+	extractor, err := newExtractor(c.Config)
+	if err != nil {
+		return "", err
+	}
+	keywords, err := extractor.ExtractKeywords("What is machine learning?")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s responded: %q", c.Config.Model, keywords), nil
+}
+
+// WritableDirCheck verifies that files can be created in the given
+// directories. Empty entries (unset paths) are ignored.
+type WritableDirCheck struct {
+	Dirs []string
+}
+
+func (c WritableDirCheck) Name() string   { return "output" }
+func (c WritableDirCheck) Required() bool { return true }
+
+func (c WritableDirCheck) Check(ctx context.Context) (string, error) {
+	var dirs []string
+	for _, dir := range c.Dirs {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("%w (no output paths configured)", ErrSkipped)
+	}
+
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", fmt.Errorf("%s: %w", dir, err)
+		}
+		f, err := os.CreateTemp(dir, ".doctor-*")
+		if err != nil {
+			return "", fmt.Errorf("%s not writable: %w", dir, err)
+		}
+		f.Close()
+		os.Remove(f.Name())
+	}
+	return strings.Join(dirs, ", ") + " writable", nil
+}
+
+// VersionCheck reports build information. It never fails.
+type VersionCheck struct{}
+
+func (c VersionCheck) Name() string   { return "version" }
+func (c VersionCheck) Required() bool { return false }
+
+func (c VersionCheck) Check(ctx context.Context) (string, error) {
+	version := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
+	}
+	return fmt.Sprintf("%s (%s %s/%s)", version, runtime.Version(), runtime.GOOS, runtime.GOARCH), nil
+}
@@ -0,0 +1,111 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrSkipped is returned by a check that does not apply to this setup.
+var ErrSkipped = errors.New("skipped")
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Checker is a single diagnostic.
+type Checker interface {
+	Name() string
+	// Required reports whether a failure should fail the whole report.
+	Required() bool
+	// Check returns a short detail on success, or an error. Wrapping
+	// ErrSkipped marks the check as not applicable.
+	Check(ctx context.Context) (string, error)
+}
+
+// Result is the outcome of running one Checker.
+type Result struct {
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Required bool          `json:"required"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Report collects all results.
+type Report struct {
+	OK      bool     `json:"ok"`
+	Results []Result `json:"results"`
+}
+
+// Run executes checks in order. The report is OK unless a required check fails.
+func Run(ctx context.Context, checks []Checker) Report {
+	report := Report{OK: true}
+
+	for _, c := range checks {
+		start := time.Now()
+		detail, err := c.Check(ctx)
+
+		result := Result{
+			Name:     c.Name(),
+			Status:   StatusPass,
+			Required: c.Required(),
+			Detail:   detail,
+			Duration: time.Since(start),
+		}
+		switch {
+		case errors.Is(err, ErrSkipped):
+			result.Status = StatusSkip
+			if result.Detail == "" {
+				result.Detail = err.Error()
+			}
+		case err != nil:
+			result.Status = StatusFail
+			result.Detail = err.Error()
+			if result.Required {
+				report.OK = false
+			}
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	return report
+}
+
+// WriteText prints one line per check.
+func (r Report) WriteText(w io.Writer) {
+	for _, res := range r.Results {
+		icon := "✅"
+		switch {
+		case res.Status == StatusSkip:
+			icon = "⏭️ "
+		case res.Status == StatusFail && res.Required:
+			icon = "❌"
+		case res.Status == StatusFail:
+			icon = "⚠️ "
+		}
+		fmt.Fprintf(w, "%s %-12s %s\n", icon, res.Name, res.Detail)
+	}
+
+	if r.OK {
+		fmt.Fprintln(w, "\nAll required checks passed")
+	} else {
+		fmt.Fprintln(w, "\nSome required checks failed")
+	}
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,198 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/llm"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+type fakeCheck struct {
+	name     string
+	required bool
+	err      error
+}
+
+func (f fakeCheck) Name() string   { return f.name }
+func (f fakeCheck) Required() bool { return f.required }
+func (f fakeCheck) Check(ctx context.Context) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	return "fine", nil
+}
+
+type failingProvider struct{}
+
+func (failingProvider) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	return nil, errors.New("connection refused")
+}
+
+// ctxProvider fails once its context is cancelled.
+type ctxProvider struct{}
+
+func (ctxProvider) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return []model.Paper{{ID: "2401.00001v1"}}, nil
+}
+
+type stubExtractor struct{}
+
+func (stubExtractor) ExtractKeywords(question string) (string, error) {
+	return "machine learning", nil
+}
+
+func TestRun_RequiredFailure(t *testing.T) {
+	report := Run(context.Background(), []Checker{
+		fakeCheck{name: "config", required: true},
+		fakeCheck{name: "database", required: true, err: errors.New("unreachable")},
+		fakeCheck{name: "version"},
+	})
+
+	if report.OK {
+		t.Error("report should fail when a required check fails")
+	}
+	if len(report.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(report.Results))
+	}
+	if report.Results[1].Status != StatusFail || report.Results[1].Detail != "unreachable" {
+		t.Errorf("unexpected database result: %+v", report.Results[1])
+	}
+}
+
+func TestRun_OptionalFailureAndSkip(t *testing.T) {
+	report := Run(context.Background(), []Checker{
+		fakeCheck{name: "config", required: true},
+		fakeCheck{name: "extra", err: errors.New("flaky")},
+		fakeCheck{name: "llm", err: fmt.Errorf("%w (use -with-llm)", ErrSkipped)},
+	})
+
+	if !report.OK {
+		t.Error("optional failures and skips should not fail the report")
+	}
+	if report.Results[2].Status != StatusSkip {
+		t.Errorf("expected skip status, got %s", report.Results[2].Status)
+	}
+}
+
+func TestReport_Output(t *testing.T) {
+	report := Run(context.Background(), []Checker{
+		fakeCheck{name: "config", required: true},
+		fakeCheck{name: "arxiv", required: true, err: errors.New("timeout")},
+	})
+
+	var text bytes.Buffer
+	report.WriteText(&text)
+	if !strings.Contains(text.String(), "arxiv") || !strings.Contains(text.String(), "timeout") {
+		t.Errorf("text output missing failure detail:\n%s", text.String())
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteJSON(&out); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded struct {
+		OK      bool `json:"ok"`
+		Results []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded.OK || len(decoded.Results) != 2 || decoded.Results[1].Status != "fail" {
+		t.Errorf("unexpected JSON report: %s", out.String())
+	}
+}
+
+func TestConfigCheck_LoadError(t *testing.T) {
+	check := ConfigCheck{Load: func() (*config.Config, error) {
+		return nil, errors.New("bad DB_PORT")
+	}}
+
+	if _, err := check.Check(context.Background()); err == nil {
+		t.Error("expected load error to fail the check")
+	}
+}
+
+func TestConfigCheck_Invalid(t *testing.T) {
+	check := ConfigCheck{Load: func() (*config.Config, error) {
+		cfg := &config.Config{}
+		cfg.DB.Host, cfg.DB.Name, cfg.DB.Port = "localhost", "genesis", 5433
+		cfg.Pipeline.DefaultLimit = 10
+		cfg.Pipeline.DefaultMinScore = 150
+		return cfg, nil
+	}}
+
+	if _, err := check.Check(context.Background()); err == nil || !strings.Contains(err.Error(), "DEFAULT_MIN_SCORE") {
+		t.Errorf("expected min score validation error, got %v", err)
+	}
+}
+
+func TestProviderCheck_Failure(t *testing.T) {
+	check := ProviderCheck{Label: "arxiv", Provider: failingProvider{}}
+
+	if _, err := check.Check(context.Background()); err == nil {
+		t.Error("expected provider failure to fail the check")
+	}
+}
+
+func TestProviderCheck_UsesContext(t *testing.T) {
+	check := ProviderCheck{Label: "arxiv", Provider: ctxProvider{}}
+
+	if _, err := check.Check(context.Background()); err != nil {
+		t.Errorf("expected the context-aware fetch to be used, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := check.Check(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation to reach the provider, got %v", err)
+	}
+}
+
+func TestLLMCheck(t *testing.T) {
+	skipped := LLMCheck{Config: config.GeminiConfig{APIKey: "key"}}
+	if _, err := skipped.Check(context.Background()); !errors.Is(err, ErrSkipped) {
+		t.Errorf("expected skip without -with-llm, got %v", err)
+	}
+
+	unconfigured := LLMCheck{Enabled: true}
+	if _, err := unconfigured.Check(context.Background()); err == nil {
+		t.Error("expected failure without API key")
+	}
+
+	enabled := LLMCheck{
+		Enabled: true,
+		Config:  config.GeminiConfig{APIKey: "key", Model: "test-model"},
+		NewExtractor: func(cfg config.GeminiConfig) (llm.KeywordExtractor, error) {
+			return stubExtractor{}, nil
+		},
+	}
+	if _, err := enabled.Check(context.Background()); err != nil {
+		t.Errorf("expected success with stub extractor, got %v", err)
+	}
+}
+
+func TestWritableDirCheck(t *testing.T) {
+	for _, check := range []WritableDirCheck{{}, {Dirs: []string{""}}} {
+		if _, err := check.Check(context.Background()); !errors.Is(err, ErrSkipped) {
+			t.Errorf("expected skip with no paths %q, got %v", check.Dirs, err)
+		}
+	}
+
+	dir := t.TempDir()
+	if _, err := (WritableDirCheck{Dirs: []string{dir}}).Check(context.Background()); err != nil {
+		t.Errorf("expected temp dir to be writable, got %v", err)
+	}
+}
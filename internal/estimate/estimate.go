@@ -0,0 +1,96 @@
+// Package estimate gauges how many papers a query matches over time from
+// provider match counts, without fetching or saving any paper.
+package estimate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+)
+
+const week = 7 * 24 * time.Hour
+
+// MaxWindows caps the windows one estimate counts, one request each.
+const MaxWindows = 12
+
+// ErrInvalidWindow is returned for window lengths ParseWindow rejects.
+var ErrInvalidWindow = errors.New("invalid window")
+
+// Window is the match count of one submission date range.
+type Window struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"` // Exclusive
+	Matches int       `json:"matches"`
+}
+
+// Result is the match rate of a query.
+type Result struct {
+	Query   string   `json:"query"`
+	Windows []Window `json:"windows"`  // Newest first
+	PerWeek float64  `json:"per_week"` // Matches per week across all windows
+}
+
+// Run counts the matches of query in n consecutive windows of the given
+// length ending at now, newest first, and extrapolates a weekly rate.
+func Run(ctx context.Context, c parser.Counter, query string, window time.Duration, n int, now time.Time) (Result, error) {
+	if window <= 0 {
+		return Result{}, fmt.Errorf("%w: must be positive", ErrInvalidWindow)
+	}
+	if n <= 0 {
+		n = 1
+	}
+	if n > MaxWindows {
+		return Result{}, fmt.Errorf("at most %d windows", MaxWindows)
+	}
+
+	result := Result{Query: query}
+	total := 0
+	to := now
+	for i := 0; i < n; i++ {
+		from := to.Add(-window)
+		matches, err := c.CountPapers(ctx, query, from, to)
+		if err != nil {
+			return result, fmt.Errorf("count papers: %w", err)
+		}
+		result.Windows = append(result.Windows, Window{From: from, To: to, Matches: matches})
+		total += matches
+		to = from
+	}
+	result.PerWeek = float64(total) * float64(week) / float64(window*time.Duration(n))
+	return result, nil
+}
+
+// ParseWindow parses a window length in days ("30d"), weeks ("2w") or as
+// a Go duration ("36h").
+func ParseWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = week
+	}
+	var d time.Duration
+	if unit > 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("%w %q", ErrInvalidWindow, s)
+		}
+		d = time.Duration(n) * unit
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("%w %q", ErrInvalidWindow, s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%w %q: must be positive", ErrInvalidWindow, s)
+	}
+	return d, nil
+}
@@ -0,0 +1,94 @@
+package estimate
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// countsByWindow is a parser.Counter with fixed totals per window start.
+type countsByWindow struct {
+	counts map[time.Time]int
+	calls  int
+}
+
+func (c *countsByWindow) CountPapers(ctx context.Context, query string, from, to time.Time) (int, error) {
+	c.calls++
+	n, ok := c.counts[from]
+	if !ok {
+		return 0, errors.New("unexpected window")
+	}
+	return n, nil
+}
+
+func TestRun(t *testing.T) {
+	now := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	counter := &countsByWindow{counts: map[time.Time]int{
+		now.Add(-30 * day): 90,
+		now.Add(-60 * day): 30,
+	}}
+
+	tests := []struct {
+		name    string
+		windows int
+		perWeek float64
+	}{
+		{"one window", 1, 21},  // 90 in 30 days
+		{"two windows", 2, 14}, // 120 in 60 days
+		{"default is one window", 0, 21},
+	}
+
+	for _, tc := range tests {
+		counter.calls = 0
+		result, err := Run(context.Background(), counter, "llm", 30*day, tc.windows, now)
+		if err != nil {
+			t.Fatalf("%s: Run failed: %v", tc.name, err)
+		}
+		if math.Abs(result.PerWeek-tc.perWeek) > 1e-9 {
+			t.Errorf("%s: PerWeek = %v, want %v", tc.name, result.PerWeek, tc.perWeek)
+		}
+		want := max(tc.windows, 1)
+		if len(result.Windows) != want || counter.calls != want {
+			t.Errorf("%s: %d windows in %d requests, want %d", tc.name, len(result.Windows), counter.calls, want)
+		}
+		if w := result.Windows[0]; !w.To.Equal(now) || w.Matches != 90 {
+			t.Errorf("%s: newest window = %+v", tc.name, w)
+		}
+	}
+}
+
+func TestRun_Limits(t *testing.T) {
+	now := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	if _, err := Run(context.Background(), &countsByWindow{}, "llm", 0, 1, now); !errors.Is(err, ErrInvalidWindow) {
+		t.Errorf("zero window: expected ErrInvalidWindow, got %v", err)
+	}
+	if _, err := Run(context.Background(), &countsByWindow{}, "llm", time.Hour, MaxWindows+1, now); err == nil {
+		t.Error("expected an error beyond MaxWindows")
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"30d", 30 * 24 * time.Hour, true},
+		{"2w", 14 * 24 * time.Hour, true},
+		{"36h", 36 * time.Hour, true},
+		{"0d", 0, false},
+		{"-1w", 0, false},
+		{"d", 0, false},
+		{"month", 0, false},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseWindow(tc.input)
+		if (err == nil) != tc.ok || got != tc.expected {
+			t.Errorf("ParseWindow(%q) = %v, %v; want %v (ok %t)", tc.input, got, err, tc.expected, tc.ok)
+		}
+	}
+}
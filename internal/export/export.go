@@ -0,0 +1,242 @@
+// Package export writes papers as CSV or JSON Lines in export order
+// (oldest first, ties broken by ID). Every record carries its updated_at
+// and ID, so an interrupted export can be continued after its last
+// complete record without gaps or duplicates.
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
+)
+
+// Supported formats.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// ErrUnknownFormat is returned for formats other than FormatCSV and
+// FormatJSONL.
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// columns is the CSV header. id and updated_at come first so a resume
+// only needs the start of the last row.
+var columns = []string{"id", "updated_at", "title", "authors", "categories", "score", "doi", "journal_ref", "comments", "abstract"}
+
+// record is the JSON Lines form of a paper.
+type record struct {
+	ID           string    `json:"id"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Title        string    `json:"title"`
+	Authors      []string  `json:"authors"`
+	Categories   []string  `json:"categories"`
+	Score        int       `json:"score"`
+	ScoreDetails []string  `json:"score_details,omitempty"`
+	DOI          string    `json:"doi,omitempty"`
+	JournalRef   string    `json:"journal_ref,omitempty"`
+	Comments     string    `json:"comments,omitempty"`
+	Abstract     string    `json:"abstract"`
+	Code         []string  `json:"code,omitempty"` // Code repository URLs
+}
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Writer encodes papers in one format. Records are buffered; call Flush to
+// push them to the underlying writer.
+type Writer struct {
+	csv   *csv.Writer
+	jsonl *bufio.Writer
+	enc   *json.Encoder
+	title textutil.TitleMode // How TeX markup in titles is rendered for the format
+}
+
+// NewWriter returns a writer for format. header writes the CSV header row
+// first; leave it off when appending to a partial export.
+func NewWriter(w io.Writer, format string, header bool) (*Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if header {
+			if err := cw.Write(columns); err != nil {
+				return nil, fmt.Errorf("write header: %w", err)
+			}
+		}
+		return &Writer{csv: cw, title: textutil.TitleModeForFormat(format)}, nil
+	case FormatJSONL:
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		enc.SetEscapeHTML(false)
+		return &Writer{jsonl: bw, enc: enc, title: textutil.TitleModeForFormat(format)}, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
+
+// Write encodes one paper.
+func (w *Writer) Write(p model.Paper) error {
+	updated := p.UpdatedAt.UTC()
+	title := textutil.RenderTitle(p.Title, w.title)
+	if w.csv != nil {
+		return w.csv.Write([]string{
+			p.ID,
+			updated.Format(time.RFC3339Nano),
+			title,
+			strings.Join(p.Authors, "; "),
+			strings.Join(p.Categories, " "),
+			strconv.Itoa(p.Score),
+			p.DOI,
+			p.JournalRef,
+			p.Comments,
+			p.Abstract,
+		})
+	}
+	return w.enc.Encode(record{
+		ID:           p.ID,
+		UpdatedAt:    updated,
+		Title:        title,
+		Authors:      p.Authors,
+		Categories:   p.Categories,
+		Score:        p.Score,
+		ScoreDetails: p.ScoreDetails,
+		DOI:          p.DOI,
+		JournalRef:   p.JournalRef,
+		Comments:     p.Comments,
+		Abstract:     p.Abstract,
+		Code:         codeURLs(p),
+	})
+}
+
+// codeURLs returns the URLs of p's code links.
+func codeURLs(p model.Paper) []string {
+	var urls []string
+	for _, l := range p.CodeLinks() {
+		urls = append(urls, l.URL)
+	}
+	return urls
+}
+
+// Flush writes buffered records to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return w.jsonl.Flush()
+}
+
+// Position is where a partial export stopped.
+type Position struct {
+	After   storage.Cursor // Last complete record (zero if there is none)
+	Offset  int64          // Bytes up to the end of that record; the rest is a torn write
+	Records int            // Complete records, not counting the CSV header
+}
+
+// Resume scans a partial export and returns the position after its last
+// complete record. A record only counts as complete once its terminating
+// newline was written.
+func Resume(r io.Reader, format string) (Position, error) {
+	switch format {
+	case FormatCSV:
+		return resumeCSV(r)
+	case FormatJSONL:
+		return resumeJSONL(r)
+	}
+	return Position{}, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
+
+func resumeJSONL(r io.Reader) (Position, error) {
+	var pos Position
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			// A line without its newline was cut off mid-write
+			return pos, nil
+		}
+		if err != nil {
+			return Position{}, fmt.Errorf("read export: %w", err)
+		}
+
+		var rec record
+		if err := json.Unmarshal(bytes.TrimSpace(line), &rec); err != nil {
+			return Position{}, fmt.Errorf("record %d: %w", pos.Records+1, err)
+		}
+		pos.After = storage.Cursor{UpdatedAt: rec.UpdatedAt, ID: rec.ID}
+		pos.Offset += int64(len(line))
+		pos.Records++
+	}
+}
+
+func resumeCSV(r io.Reader) (Position, error) {
+	tail := &lastByteReader{r: r}
+	cr := csv.NewReader(tail)
+	cr.FieldsPerRecord = len(columns)
+
+	// pos is the last row known to be complete; last is the latest parsed
+	// row, which is complete once another row starts or a newline ends it
+	var pos, last Position
+	for first := true; ; first = false {
+		row, err := cr.Read()
+		if err == io.EOF {
+			if tail.last == '\n' {
+				pos = last
+			}
+			return pos, nil
+		}
+		pos = last
+		if err != nil {
+			// Only the final row can be torn; earlier damage is an error
+			if cr.InputOffset() >= tail.n {
+				return pos, nil
+			}
+			return Position{}, fmt.Errorf("record %d: %w", pos.Records+1, err)
+		}
+
+		if first && row[0] == columns[0] {
+			last = Position{Offset: cr.InputOffset()}
+			continue
+		}
+		updated, err := time.Parse(time.RFC3339Nano, row[1])
+		if err != nil {
+			return Position{}, fmt.Errorf("record %d: updated_at: %w", pos.Records+1, err)
+		}
+		last = Position{
+			After:   storage.Cursor{UpdatedAt: updated, ID: row[0]},
+			Offset:  cr.InputOffset(),
+			Records: pos.Records + 1,
+		}
+	}
+}
+
+// lastByteReader remembers the count and the last of the bytes read.
+type lastByteReader struct {
+	r    io.Reader
+	n    int64
+	last byte
+}
+
+func (l *lastByteReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	if n > 0 {
+		l.n += int64(n)
+		l.last = p[n-1]
+	}
+	return n, err
+}
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+)
+
+// LineError is a line of an input file that could not be read as a paper.
+type LineError struct {
+	Line int // 1-based
+	Err  error
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e LineError) Unwrap() error { return e.Err }
+
+// inputRecord accepts the JSON Lines export schema and, for papers from
+// other tools, a minimal one: id, title, abstract, authors (a list or a
+// ";"-separated string) and updated_at (RFC 3339 or a date).
+type inputRecord struct {
+	ID           string          `json:"id"`
+	UpdatedAt    string          `json:"updated_at"`
+	Title        string          `json:"title"`
+	Authors      json.RawMessage `json:"authors"`
+	Categories   []string        `json:"categories"`
+	Score        int             `json:"score"`
+	ScoreDetails []string        `json:"score_details"`
+	DOI          string          `json:"doi"`
+	JournalRef   string          `json:"journal_ref"`
+	Comments     string          `json:"comments"`
+	Abstract     string          `json:"abstract"`
+	Code         []string        `json:"code"`
+}
+
+// ReadJSONL reads papers in the JSON Lines export schema (or the minimal
+// schema of inputRecord). Malformed lines are skipped and returned as
+// LineErrors; the error is only set when r itself fails. Blank lines are
+// ignored.
+func ReadJSONL(r io.Reader) ([]model.Paper, []LineError, error) {
+	var papers []model.Paper
+	var bad []LineError
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return papers, bad, fmt.Errorf("read input: %w", err)
+		}
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			p, perr := parseRecord(data)
+			if perr != nil {
+				bad = append(bad, LineError{Line: line, Err: perr})
+			} else {
+				papers = append(papers, p)
+			}
+		}
+		if err == io.EOF {
+			return papers, bad, nil
+		}
+	}
+}
+
+func parseRecord(data []byte) (model.Paper, error) {
+	var rec inputRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return model.Paper{}, err
+	}
+	// Other tools often give the abstract URL or an "arXiv:" citation form
+	id := paperid.Normalize(rec.ID)
+	if id == "" {
+		return model.Paper{}, errors.New("missing id")
+	}
+	authors, err := parseAuthors(rec.Authors)
+	if err != nil {
+		return model.Paper{}, err
+	}
+	updated, err := parseTime(rec.UpdatedAt)
+	if err != nil {
+		return model.Paper{}, err
+	}
+	p := model.Paper{
+		ID:           id,
+		UpdatedAt:    updated,
+		Title:        rec.Title,
+		Authors:      authors,
+		Categories:   rec.Categories,
+		Score:        rec.Score,
+		ScoreDetails: rec.ScoreDetails,
+		DOI:          rec.DOI,
+		JournalRef:   rec.JournalRef,
+		Comments:     rec.Comments,
+		Abstract:     rec.Abstract,
+	}
+	p.Pages, p.Figures, p.Tables = model.ParseExtent(p.Comments)
+	for _, u := range rec.Code {
+		p.Links = append(p.Links, model.Link{URL: u, Type: model.LinkCode})
+	}
+	p.SetTextLinks()
+	return p, nil
+}
+
+func parseAuthors(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list, nil
+	}
+	var joined string
+	if err := json.Unmarshal(raw, &joined); err != nil {
+		return nil, errors.New("authors: want a list or a string")
+	}
+	for _, name := range strings.Split(joined, ";") {
+		if name = strings.TrimSpace(name); name != "" {
+			list = append(list, name)
+		}
+	}
+	return list, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("updated_at: cannot parse %q", s)
+}
@@ -0,0 +1,104 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+func TestReadJSONL_MinimalSchema(t *testing.T) {
+	updated := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		line     string
+		expected model.Paper
+	}{
+		{
+			name: "authors as a list",
+			line: `{"id":"2403.00001v1","title":"Sparse Routing","abstract":"We route.","authors":["A. Author","B. Author"],"updated_at":"2024-03-01T00:00:00Z"}`,
+			expected: model.Paper{ID: "2403.00001v1", Title: "Sparse Routing", Abstract: "We route.",
+				Authors: []string{"A. Author", "B. Author"}, UpdatedAt: updated},
+		},
+		{
+			name: "authors as a string and a date",
+			line: `{"id":"2403.00002v1","title":"T","abstract":"A","authors":"A. Author; B. Author","updated_at":"2024-03-01"}`,
+			expected: model.Paper{ID: "2403.00002v1", Title: "T", Abstract: "A",
+				Authors: []string{"A. Author", "B. Author"}, UpdatedAt: updated},
+		},
+		{
+			name:     "abstract URL as id",
+			line:     `{"id":"http://arxiv.org/abs/2403.00003v2","title":"T","authors":["A"],"updated_at":"2024-03-01"}`,
+			expected: model.Paper{ID: "2403.00003v2", Title: "T", Authors: []string{"A"}, UpdatedAt: updated},
+		},
+		{
+			name:     "arXiv prefix and offset time",
+			line:     `{"id":"arXiv:2403.00004v1","title":"T","authors":["A"],"updated_at":"2024-03-01T01:00:00+01:00"}`,
+			expected: model.Paper{ID: "2403.00004v1", Title: "T", Authors: []string{"A"}, UpdatedAt: updated},
+		},
+		{
+			name: "export schema",
+			line: `{"id":"2403.00005v1","updated_at":"2024-03-01T00:00:00Z","title":"T","authors":["A"],"categories":["cs.CL"],"score":70,"comments":"12 pages, 3 figures","abstract":"A"}`,
+			expected: model.Paper{ID: "2403.00005v1", Title: "T", Abstract: "A", Authors: []string{"A"},
+				Categories: []string{"cs.CL"}, Score: 70, Comments: "12 pages, 3 figures", Pages: 12, Figures: 3, UpdatedAt: updated},
+		},
+	}
+
+	for _, tc := range tests {
+		papers, bad, err := ReadJSONL(strings.NewReader(tc.line))
+		if err != nil || len(bad) > 0 {
+			t.Errorf("%s: ReadJSONL failed: %v %v", tc.name, err, bad)
+			continue
+		}
+		if len(papers) != 1 || !reflect.DeepEqual(papers[0], tc.expected) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, papers, tc.expected)
+		}
+	}
+}
+
+func TestReadJSONL_MixedFile(t *testing.T) {
+	input := strings.Join([]string{
+		`{"id":"2403.00001v1","title":"T","authors":["A"],"updated_at":"2024-03-01"}`,
+		`{"id":"2403.00002v1", "title":`,
+		``,
+		`{"title":"No ID","authors":["A"]}`,
+		`{"id":"2403.00003v1","authors":{"name":"A"}}`,
+		`{"id":"2403.00004v1","updated_at":"yesterday"}`,
+		`{"id":"2403.00005v1","title":"T","authors":["A"],"updated_at":"2024-03-01"}`,
+	}, "\n")
+
+	papers, bad, err := ReadJSONL(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(papers) != 2 || papers[0].ID != "2403.00001v1" || papers[1].ID != "2403.00005v1" {
+		t.Errorf("expected papers 1 and 5, got %+v", papers)
+	}
+	var lines []int
+	for _, e := range bad {
+		lines = append(lines, e.Line)
+	}
+	if want := []int{2, 4, 5, 6}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("expected bad lines %v, got %v (%v)", want, lines, bad)
+	}
+}
+
+func TestReadJSONL_ReadsExports(t *testing.T) {
+	store := fixtureStore(t)
+	data := export(t, store, FormatJSONL, storage.Cursor{}, true)
+
+	papers, bad, err := ReadJSONL(bytes.NewReader(data))
+	if err != nil || len(bad) > 0 {
+		t.Fatalf("ReadJSONL failed: %v %v", err, bad)
+	}
+	if len(papers) != 6 || papers[0].Score != 40 || len(papers[0].Authors) != 2 {
+		t.Errorf("export did not round-trip: %+v", papers)
+	}
+	if code := papers[0].CodeLinks(); len(code) != 1 || code[0].URL != "https://github.com/org/repo" {
+		t.Errorf("code links did not round-trip: %+v", papers[0].Links)
+	}
+}
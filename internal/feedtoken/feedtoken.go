@@ -0,0 +1,82 @@
+// Package feedtoken signs the opaque tokens that let a feed reader fetch
+// a watchlist's feed without an API key.
+//
+// A token names a watchlist and one of its feed keys, signed with a
+// server secret. The key is stored with the watchlist: replacing it
+// revokes every token issued before, and changing the secret revokes all
+// of them.
+package feedtoken
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid is returned for a token that is malformed or was not signed
+// with the secret.
+var ErrInvalid = errors.New("invalid feed token")
+
+// NewKey returns a random feed key.
+func NewKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Signer issues and checks tokens with one secret.
+type Signer struct {
+	secret []byte
+}
+
+// New returns a Signer using secret, which should be long and random.
+func New(secret string) Signer {
+	return Signer{secret: []byte(secret)}
+}
+
+// Token returns the token for feed key of watchlist id.
+func (s Signer) Token(id int, key string) string {
+	payload := strconv.Itoa(id) + "." + key
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+// Parse returns the watchlist and feed key token names, or ErrInvalid.
+// Whether the key is still the watchlist's is for the caller to check.
+func (s Signer) Parse(token string) (id int, key string, err error) {
+	encPayload, encMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", ErrInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return 0, "", ErrInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encMAC)
+	if err != nil || !hmac.Equal(mac, s.sign(string(payload))) {
+		return 0, "", ErrInvalid
+	}
+
+	rawID, key, ok := strings.Cut(string(payload), ".")
+	if !ok || key == "" {
+		return 0, "", ErrInvalid
+	}
+	id, err = strconv.Atoi(rawID)
+	if err != nil || id <= 0 {
+		return 0, "", ErrInvalid
+	}
+	return id, key, nil
+}
+
+func (s Signer) sign(payload string) []byte {
+	m := hmac.New(sha256.New, s.secret)
+	m.Write([]byte(payload))
+	return m.Sum(nil)
+}
@@ -0,0 +1,53 @@
+package feedtoken
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSigner_RoundTrip(t *testing.T) {
+	s := New("server-secret")
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := NewKey(); other == key {
+		t.Error("NewKey returned the same key twice")
+	}
+
+	id, got, err := s.Parse(s.Token(42, key))
+	if err != nil || id != 42 || got != key {
+		t.Errorf("Parse = %d, %q, %v; want 42, %q", id, got, err, key)
+	}
+}
+
+func TestSigner_Invalid(t *testing.T) {
+	s := New("server-secret")
+	token := s.Token(7, "0123456789abcdef")
+	payload, _, _ := strings.Cut(token, ".")
+
+	tests := map[string]string{
+		"empty":          "",
+		"no signature":   payload,
+		"other secret":   New("another-secret").Token(7, "0123456789abcdef"),
+		"bad encoding":   "!!!." + token,
+		"truncated":      token[:len(token)-2],
+		"other payload":  New("server-secret").Token(8, "0123456789abcdef")[:len(payload)] + token[len(payload):],
+		"signed garbage": signed(s, "seven.key"),
+		"no key":         signed(s, "7."),
+		"negative id":    signed(s, "-7.key"),
+	}
+	for name, token := range tests {
+		if _, _, err := s.Parse(token); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: err = %v, want ErrInvalid", name, err)
+		}
+	}
+}
+
+// signed returns a correctly signed token over an arbitrary payload.
+func signed(s Signer, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
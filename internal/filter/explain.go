@@ -0,0 +1,159 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rule IDs, matching the Weights JSON fields. RuleSubstantial is scored
+// by Filter.PageTiers instead of a weight.
+const (
+	RuleAccepted       = "accepted"
+	RulePublished      = "published"
+	RuleStrongEvidence = "strong_evidence"
+	RuleAblation       = "ablation"
+	RuleDataset        = "dataset"
+	RuleCode           = "code"
+	RuleLimitations    = "limitations"
+	RuleRevised        = "revised"
+	RuleSubstantial    = "substantial"
+	RuleHype           = "hype"
+	RuleFrameworkOnly  = "framework_only"
+)
+
+// Languages Explain can render.
+const (
+	LangEN = "en"
+	LangZH = "zh"
+)
+
+// rule describes a scoring rule. label is the tag stored in score details
+// and must not change, or stored breakdowns stop being recognised.
+type rule struct {
+	id    string
+	label string
+	en    string
+	zh    string
+}
+
+var rules = []rule{
+	{RuleAccepted, "接收信号", "accepted at a peer-reviewed venue", "已被同行评审会议或期刊接收"},
+	{RulePublished, "DOI/期刊引用", "has a DOI or journal reference", "有 DOI 或期刊引用"},
+	{RuleStrongEvidence, "强实证(评估词>=3)", "strong empirical evaluation", "实证评估充分"},
+	{RuleAblation, "消融/基线实验", "reports ablations and baselines", "包含消融与基线实验"},
+	{RuleDataset, "数据集/基准测试", "uses a dataset or benchmark", "使用数据集或基准测试"},
+	{RuleCode, "代码链接", "links to code", "附有代码链接"},
+	{RuleLimitations, "局限性讨论", "discusses its limitations", "讨论了局限性"},
+	{RuleRevised, "多版本迭代", "revised since first submission", "提交后有修订版本"},
+	{RuleSubstantial, "篇幅充实", "is a substantial write-up", "篇幅充实"},
+	{RuleHype, "夸大营销词", "uses hype language", "使用夸大营销措辞"},
+	{RuleFrameworkOnly, "纯框架无评估", "proposes a framework without evaluation", "仅提出框架而无评估"},
+}
+
+// ruleLabel returns the stored label of the rule with the given ID.
+func ruleLabel(id string) string {
+	for _, r := range rules {
+		if r.id == id {
+			return r.label
+		}
+	}
+	return id
+}
+
+// Hit is one scoring rule that fired, as recorded in a score detail.
+type Hit struct {
+	Rule   string // Rule ID, empty when the label is not a known rule
+	Label  string // Label as stored
+	Points int
+}
+
+// ParseDetail reads a score detail such as "+30 接收信号". The rule is
+// also recognised in its English form ("+30 accepted at a peer-reviewed
+// venue", see DetailIn). Details that do not start with a signed number
+// keep their text as the label.
+func ParseDetail(detail string) Hit {
+	detail = strings.TrimSpace(detail)
+	points, label, ok := strings.Cut(detail, " ")
+	n, err := strconv.Atoi(points)
+	if !ok || err != nil {
+		return Hit{Label: detail}
+	}
+	hit := Hit{Label: label, Points: n}
+	for _, r := range rules {
+		if r.label == label || r.en == label {
+			hit.Rule = r.id
+			break
+		}
+	}
+	return hit
+}
+
+// ruleText returns the description of a hit in lang, or its label if the
+// rule is unknown.
+func ruleText(hit Hit, lang string) string {
+	for _, r := range rules {
+		if r.id == hit.Rule {
+			if lang == LangZH {
+				return r.zh
+			}
+			return r.en
+		}
+	}
+	return hit.Label
+}
+
+// DetailIn renders one score detail in lang, e.g. "+30 接收信号" as
+// "+30 accepted at a peer-reviewed venue" in LangEN.
+func DetailIn(detail, lang string) string {
+	hit := ParseDetail(detail)
+	if hit.Rule == "" {
+		return detail
+	}
+	return fmt.Sprintf("%+d %s", hit.Points, ruleText(hit, lang))
+}
+
+// StoredDetail returns a detail rendered by DetailIn with its stored label.
+func StoredDetail(detail string) string {
+	hit := ParseDetail(detail)
+	if hit.Rule == "" {
+		return detail
+	}
+	return fmt.Sprintf("%+d %s", hit.Points, ruleLabel(hit.Rule))
+}
+
+// Explain renders a score and its details as one sentence in lang (LangEN
+// or LangZH; anything else is English), e.g. "Scored 85: accepted at a
+// peer-reviewed venue (+30), links to code (+10)." Rules unknown to this
+// version are shown by their stored label.
+func Explain(score int, details []string, lang string) string {
+	zh := lang == LangZH
+
+	parts := make([]string, 0, len(details))
+	for _, d := range details {
+		hit := ParseDetail(d)
+		if hit.Label == "" {
+			continue
+		}
+		text := ruleText(hit, lang)
+		switch {
+		case hit.Points == 0:
+			parts = append(parts, text)
+		case zh:
+			parts = append(parts, fmt.Sprintf("%s（%+d）", text, hit.Points))
+		default:
+			parts = append(parts, fmt.Sprintf("%s (%+d)", text, hit.Points))
+		}
+	}
+
+	if zh {
+		if len(parts) == 0 {
+			return fmt.Sprintf("得分 %d：未发现评分信号。", score)
+		}
+		return fmt.Sprintf("得分 %d：%s。", score, strings.Join(parts, "、"))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("Scored %d: no scoring signals found.", score)
+	}
+	return fmt.Sprintf("Scored %d: %s.", score, strings.Join(parts, ", "))
+}
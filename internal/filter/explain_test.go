@@ -0,0 +1,94 @@
+package filter
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// goldenDetails is a representative breakdown: known positive and negative
+// rules, a label from a rule this version does not know, and a malformed
+// entry.
+var goldenDetails = []string{
+	"+30 接收信号",
+	"+10 代码链接",
+	"+10 消融/基线实验",
+	"+5 多版本迭代",
+	"-10 夸大营销词",
+	"+8 社区热度",
+	"manual review",
+}
+
+func TestExplain_Golden(t *testing.T) {
+	for _, lang := range []string{LangEN, LangZH} {
+		got := Explain(53, goldenDetails, lang) + "\n" + Explain(0, nil, lang) + "\n"
+
+		path := filepath.Join("testdata", "explain_"+lang+".golden")
+		if *update {
+			if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read golden (run with -update to create): %v", err)
+		}
+		if got != string(want) {
+			t.Errorf("%s explanation:\n%s\nwant:\n%s", lang, got, want)
+		}
+	}
+}
+
+func TestParseDetail(t *testing.T) {
+	tests := []struct {
+		detail   string
+		expected Hit
+	}{
+		{"+30 接收信号", Hit{Rule: RuleAccepted, Label: "接收信号", Points: 30}},
+		{"-25 纯框架无评估", Hit{Rule: RuleFrameworkOnly, Label: "纯框架无评估", Points: -25}},
+		{"+8 社区热度", Hit{Label: "社区热度", Points: 8}},
+		{"manual review", Hit{Label: "manual review"}},
+	}
+
+	for _, tc := range tests {
+		if got := ParseDetail(tc.detail); got != tc.expected {
+			t.Errorf("ParseDetail(%q) = %+v, want %+v", tc.detail, got, tc.expected)
+		}
+	}
+}
+
+// Every label the scorer writes must parse back to its rule.
+func TestParseDetail_RoundTripsScorerOutput(t *testing.T) {
+	for _, r := range rules {
+		if hit := ParseDetail("+1 " + ruleLabel(r.id)); hit.Rule != r.id {
+			t.Errorf("label of %s parsed as %q", r.id, hit.Rule)
+		}
+	}
+}
+
+func TestDetailIn_RoundTrip(t *testing.T) {
+	tests := []struct {
+		stored string
+		en     string
+	}{
+		{"+30 接收信号", "+30 accepted at a peer-reviewed venue"},
+		{"-10 夸大营销词", "-10 uses hype language"},
+		{"+5 custom rule", "+5 custom rule"},
+		{"not a detail", "not a detail"},
+	}
+
+	for _, tc := range tests {
+		if got := DetailIn(tc.stored, LangEN); got != tc.en {
+			t.Errorf("DetailIn(%q) = %q, want %q", tc.stored, got, tc.en)
+		}
+		if got := StoredDetail(tc.en); got != tc.stored {
+			t.Errorf("StoredDetail(%q) = %q, want %q", tc.en, got, tc.stored)
+		}
+	}
+	if got := Explain(40, []string{"+30 accepted at a peer-reviewed venue"}, LangZH); got != "得分 40：已被同行评审会议或期刊接收（+30）。" {
+		t.Errorf("Explain of an English detail = %q", got)
+	}
+}
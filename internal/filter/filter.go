@@ -1,6 +1,8 @@
 package filter
 
 import (
+	"fmt"
+	"iter"
 	"regexp"
 	"strings"
 
@@ -45,12 +47,57 @@ var (
 
 // Filter applies quality filtering to papers.
 type Filter struct {
-	MinScore int // Minimum score to pass (default: 60)
+	MinScore         int            `json:"min_score"`          // Minimum score to pass (default: 60)
+	CategoryMinScore map[string]int `json:"category_min_score"` // Per-primary-category overrides of MinScore
+	Weights          Weights        `json:"weights"`            // Score of each signal (default: DefaultWeights())
+
+	// PageTiers awards points by page count, keyed by minimum pages; only
+	// the highest tier reached counts, e.g. {"20": 5, "40": 10} (default: none)
+	PageTiers map[int]int `json:"page_tiers"`
+
+	// MaxText caps the characters of each text field (title, abstract,
+	// comments, link URL) the scorers read, so an oversized record cannot
+	// slow a sync down (default: DefaultMaxText)
+	MaxText int `json:"max_text"`
+}
+
+// DefaultMaxText is the default Filter.MaxText, well above any genuine
+// arXiv abstract.
+const DefaultMaxText = 20000
+
+// Weights is the score each signal adds; negative weights are penalties.
+type Weights struct {
+	Accepted       int `json:"accepted"`        // Acceptance note in comments
+	Published      int `json:"published"`       // DOI or journal reference
+	StrongEvidence int `json:"strong_evidence"` // Three or more evaluation keywords
+	Ablation       int `json:"ablation"`        // Ablation or baseline comparison
+	Dataset        int `json:"dataset"`         // Dataset or benchmark
+	Code           int `json:"code"`            // Code repository link
+	Limitations    int `json:"limitations"`     // Discusses limitations
+	Revised        int `json:"revised"`         // Version 2 or later
+	Hype           int `json:"hype"`            // Marketing language
+	FrameworkOnly  int `json:"framework_only"`  // Framework or perspective without evaluation
+}
+
+// DefaultWeights returns the production scoring weights.
+func DefaultWeights() Weights {
+	return Weights{
+		Accepted:       30,
+		Published:      20,
+		StrongEvidence: 15,
+		Ablation:       10,
+		Dataset:        10,
+		Code:           10,
+		Limitations:    5,
+		Revised:        5,
+		Hype:           -10,
+		FrameworkOnly:  -25,
+	}
 }
 
 // NewFilter creates a new filter with default settings.
 func NewFilter() *Filter {
-	return &Filter{MinScore: 60}
+	return &Filter{MinScore: 60, Weights: DefaultWeights(), MaxText: DefaultMaxText}
 }
 
 // FilterResult contains the filtering outcome for a paper.
@@ -73,13 +120,31 @@ func (f *Filter) Apply(papers []model.Paper) []FilterResult {
 	return results
 }
 
+// ApplyIter filters papers as they arrive, yielding one result per paper
+// in order. Errors from papers are passed through with a zero result.
+func (f *Filter) ApplyIter(papers iter.Seq2[model.Paper, error]) iter.Seq2[FilterResult, error] {
+	return func(yield func(FilterResult, error) bool) {
+		for paper, err := range papers {
+			if err != nil {
+				if !yield(FilterResult{}, err) {
+					return
+				}
+				continue
+			}
+			if !yield(f.evaluate(paper), nil) {
+				return
+			}
+		}
+	}
+}
+
 // FilterPassed returns only papers that passed both levels.
 func (f *Filter) FilterPassed(papers []model.Paper) []model.Paper {
 	passed := make([]model.Paper, 0)
 
 	for _, paper := range papers {
 		result := f.evaluate(paper)
-		if result.PassedLevel1 && result.Score >= f.MinScore {
+		if f.Passed(result) {
 			paper.Score = result.Score
 			paper.ScoreDetails = result.Details
 			passed = append(passed, paper)
@@ -89,82 +154,144 @@ func (f *Filter) FilterPassed(papers []model.Paper) []model.Paper {
 	return passed
 }
 
-func (f *Filter) evaluate(paper model.Paper) FilterResult {
-	result := FilterResult{Paper: paper}
-
-	// Count evaluation keywords in abstract
-	evalCount := countKeywords(paper.Abstract, evaluationKeywords)
-
-	// Level 1: Hard gate
-	hasAcceptedSignal := acceptedPattern.MatchString(paper.Comments)
-	hasDOI := paper.DOI != ""
-	hasJournalRef := paper.JournalRef != ""
-	hasStrongEvidence := evalCount >= 3
+// Passed reports whether a result clears both levels.
+func (f *Filter) Passed(r FilterResult) bool {
+	return r.PassedLevel1 && r.Score >= f.Threshold(r.Paper)
+}
 
-	// Must satisfy at least one strong signal
-	hasStrongSignal := hasAcceptedSignal || hasDOI || hasJournalRef || hasStrongEvidence
+// Threshold returns the minimum score for paper, which depends on its
+// primary (first listed) category.
+func (f *Filter) Threshold(paper model.Paper) int {
+	if len(paper.Categories) > 0 {
+		if min, ok := f.CategoryMinScore[paper.Categories[0]]; ok {
+			return min
+		}
+	}
+	return f.MinScore
+}
 
-	// AND must have at least 2 evaluation keywords
-	hasMinEvaluation := evalCount >= 2
+// Score returns paper with Score and ScoreDetails set, whether or not it
+// passes the filter.
+func (f *Filter) Score(paper model.Paper) model.Paper {
+	result := f.evaluate(paper)
+	paper.Score = result.Score
+	paper.ScoreDetails = result.Details
+	return paper
+}
 
-	result.PassedLevel1 = hasStrongSignal && hasMinEvaluation
+func (f *Filter) evaluate(paper model.Paper) FilterResult {
+	return f.judge(paper, f.extract(paper))
+}
 
-	// Level 2: Scoring
-	score := 0
-	details := make([]string, 0)
+// signals are the rule inputs found in a paper. Finding them is the costly
+// part of evaluation, so shadow scoring extracts them once for both rule
+// sets.
+type signals struct {
+	accepted    bool // Acceptance note in comments
+	published   bool // DOI or journal reference
+	evalCount   int  // Evaluation keywords in the abstract
+	ablation    bool
+	dataset     bool
+	code        bool
+	limitations bool
+	revised     bool
+	hype        bool
+	framework   bool
+	announced   bool // Brand-new submission from an announcement feed
+	pages       int  // Page count stated in comments
+}
 
-	// Positive signals
-	if hasAcceptedSignal {
-		score += 30
-		details = append(details, "+30 接收信号")
+// extract finds the signals in the first MaxText characters of each text
+// field. The abstract and title are lowercased once for every keyword scan.
+func (f *Filter) extract(paper model.Paper) signals {
+	limit := f.MaxText
+	if limit <= 0 {
+		limit = DefaultMaxText
 	}
-
-	if hasDOI || hasJournalRef {
-		score += 20
-		details = append(details, "+20 DOI/期刊引用")
+	rawAbstract := truncate(paper.Abstract, limit)
+	abstract := strings.ToLower(rawAbstract)
+	title := strings.ToLower(truncate(paper.Title, limit))
+	comments := truncate(paper.Comments, limit)
+
+	return signals{
+		accepted:    acceptedPattern.MatchString(comments),
+		published:   paper.DOI != "" || paper.JournalRef != "",
+		evalCount:   countKeywords(abstract, evaluationKeywords),
+		ablation:    containsAny(abstract, []string{"ablation", "baseline"}),
+		dataset:     containsAny(abstract, []string{"dataset", "benchmark"}),
+		code:        hasCodeLink(rawAbstract, comments, paper.Links, limit),
+		limitations: containsAny(abstract, limitationKeywords),
+		revised:     paper.Version() >= 2,
+		hype:        containsAny(abstract, hypeKeywords) || containsAny(title, hypeKeywords),
+		framework:   containsAny(abstract, frameworkKeywords),
+		announced:   paper.Source == model.SourceArxivRSS && paper.Announce == model.AnnounceNew,
+		pages:       paper.Pages,
 	}
+}
 
-	if evalCount >= 3 {
-		score += 15
-		details = append(details, "+15 强实证(评估词>=3)")
+// truncate returns the first n characters of s.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
 	}
-
-	if containsAny(paper.Abstract, []string{"ablation", "baseline"}) {
-		score += 10
-		details = append(details, "+10 消融/基线实验")
+	i := 0
+	for j := range s {
+		if i == n {
+			return s[:j]
+		}
+		i++
 	}
+	return s
+}
 
-	if containsAny(paper.Abstract, []string{"dataset", "benchmark"}) {
-		score += 10
-		details = append(details, "+10 数据集/基准测试")
-	}
+// judge applies the filter's gate and weights to extracted signals.
+func (f *Filter) judge(paper model.Paper, s signals) FilterResult {
+	result := FilterResult{Paper: paper}
+	w := f.Weights
 
-	if hasCodeLink(paper) {
-		score += 10
-		details = append(details, "+10 代码链接")
-	}
+	// Level 1: Hard gate
+	hasStrongEvidence := s.evalCount >= 3
 
-	if containsAny(paper.Abstract, limitationKeywords) {
-		score += 5
-		details = append(details, "+5 局限性讨论")
-	}
+	// Must satisfy at least one strong signal
+	hasStrongSignal := s.accepted || s.published || hasStrongEvidence
 
-	if paper.Version() >= 2 {
-		score += 5
-		details = append(details, "+5 多版本迭代")
+	// Brand-new submissions cannot have acceptance notes or DOIs yet; only
+	// the evaluation requirement applies. Replaced versions and cross-lists
+	// in the same feeds get the normal gate
+	if s.announced {
+		hasStrongSignal = true
 	}
 
-	// Negative signals
-	if containsAny(paper.Abstract, hypeKeywords) || containsAny(paper.Title, hypeKeywords) {
-		score -= 10
-		details = append(details, "-10 夸大营销词")
-	}
+	// AND must have at least 2 evaluation keywords
+	hasMinEvaluation := s.evalCount >= 2
 
-	if containsAny(paper.Abstract, frameworkKeywords) && evalCount == 0 {
-		score -= 25
-		details = append(details, "-25 纯框架无评估")
+	result.PassedLevel1 = hasStrongSignal && hasMinEvaluation
+
+	// Level 2: Scoring
+	score := 0
+	details := make([]string, 0)
+	add := func(ok bool, weight int, rule string) {
+		if ok && weight != 0 {
+			score += weight
+			details = append(details, fmt.Sprintf("%+d %s", weight, ruleLabel(rule)))
+		}
 	}
 
+	// Positive signals
+	add(s.accepted, w.Accepted, RuleAccepted)
+	add(s.published, w.Published, RulePublished)
+	add(hasStrongEvidence, w.StrongEvidence, RuleStrongEvidence)
+	add(s.ablation, w.Ablation, RuleAblation)
+	add(s.dataset, w.Dataset, RuleDataset)
+	add(s.code, w.Code, RuleCode)
+	add(s.limitations, w.Limitations, RuleLimitations)
+	add(s.revised, w.Revised, RuleRevised)
+	add(true, f.pagePoints(s.pages), RuleSubstantial)
+
+	// Negative signals
+	add(s.hype, w.Hype, RuleHype)
+	add(s.framework && s.evalCount == 0, w.FrameworkOnly, RuleFrameworkOnly)
+
 	// Ensure score is in valid range
 	if score < 0 {
 		score = 0
@@ -179,42 +306,46 @@ func (f *Filter) evaluate(paper model.Paper) FilterResult {
 	return result
 }
 
+// pagePoints returns the points of the highest page tier pages reaches.
+func (f *Filter) pagePoints(pages int) int {
+	best, points := 0, 0
+	for min, p := range f.PageTiers {
+		if min > 0 && pages >= min && min > best {
+			best, points = min, p
+		}
+	}
+	return points
+}
+
+// countKeywords counts the keywords found in text. Both are lowercase.
 func countKeywords(text string, keywords []string) int {
-	text = strings.ToLower(text)
 	count := 0
 	for _, kw := range keywords {
-		if strings.Contains(text, strings.ToLower(kw)) {
+		if strings.Contains(text, kw) {
 			count++
 		}
 	}
 	return count
 }
 
+// containsAny reports whether text contains a keyword. Both are lowercase.
 func containsAny(text string, keywords []string) bool {
-	text = strings.ToLower(text)
 	for _, kw := range keywords {
-		if strings.Contains(text, strings.ToLower(kw)) {
+		if strings.Contains(text, kw) {
 			return true
 		}
 	}
 	return false
 }
 
-func hasCodeLink(paper model.Paper) bool {
-	// Check in abstract
-	if codeRepoPattern.MatchString(paper.Abstract) {
+// hasCodeLink looks for a code repository in the capped abstract and
+// comments and in the links.
+func hasCodeLink(abstract, comments string, links []model.Link, limit int) bool {
+	if codeRepoPattern.MatchString(abstract) || codeRepoPattern.MatchString(comments) {
 		return true
 	}
-	// Check in comments
-	if codeRepoPattern.MatchString(paper.Comments) {
-		return true
-	}
-	// Check in links
-	for _, link := range paper.Links {
-		if link.Type == "code" {
-			return true
-		}
-		if codeRepoPattern.MatchString(link.URL) {
+	for _, link := range links {
+		if link.Type == "code" || codeRepoPattern.MatchString(truncate(link.URL, limit)) {
 			return true
 		}
 	}
@@ -0,0 +1,39 @@
+package filter
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// pathologicalPaper has a multi-megabyte abstract and thousands of URLs in
+// its comments, none of which would ever be a genuine arXiv record.
+func pathologicalPaper() model.Paper {
+	return model.Paper{
+		ID:       "2401.00001v1",
+		Title:    "Padding",
+		Abstract: strings.Repeat("We evaluate a framework on a benchmark dataset. ", 100000),
+		Comments: strings.Repeat("https://example.org/mirror/of/the/paper ", 5000),
+	}
+}
+
+func BenchmarkFilter_Pathological(b *testing.B) {
+	papers := []model.Paper{pathologicalPaper()}
+	for _, bc := range []struct {
+		name    string
+		maxText int
+	}{
+		{"uncapped", math.MaxInt},
+		{"capped", DefaultMaxText},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			f := NewFilter()
+			f.MaxText = bc.maxText
+			for i := 0; i < b.N; i++ {
+				f.Apply(papers)
+			}
+		})
+	}
+}
@@ -1,12 +1,46 @@
 package filter
 
 import (
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
 )
 
+func TestFilter_ApplyIter(t *testing.T) {
+	f := NewFilter()
+	good := model.Paper{ID: "1", Abstract: "We run experiments on a benchmark dataset.", Comments: "Accepted at ICML 2024"}
+	bad := model.Paper{ID: "2", Abstract: "An essay."}
+	fetchErr := errors.New("page 3 failed")
+
+	papers := func(yield func(model.Paper, error) bool) {
+		_ = yield(good, nil) && yield(bad, nil) && yield(model.Paper{}, fetchErr)
+	}
+
+	var ids []string
+	var errs []error
+	for r, err := range f.ApplyIter(papers) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ids = append(ids, r.Paper.ID)
+		if want := f.Apply([]model.Paper{r.Paper})[0]; r.Score != want.Score || r.PassedLevel1 != want.PassedLevel1 {
+			t.Errorf("paper %s: %+v, want Apply's %+v", r.Paper.ID, r, want)
+		}
+	}
+	if strings.Join(ids, ",") != "1,2" || len(errs) != 1 || errs[0] != fetchErr {
+		t.Errorf("results %v, errors %v; want papers 1 and 2 then the fetch error", ids, errs)
+	}
+
+	// Breaking out must not resume the source; range panics if it does
+	for range f.ApplyIter(papers) {
+		break
+	}
+}
+
 func TestFilter_Level1_Accepted(t *testing.T) {
 	f := NewFilter()
 
@@ -139,6 +173,54 @@ func TestFilter_FilterPassed(t *testing.T) {
 	}
 }
 
+func TestFilter_CategoryThreshold(t *testing.T) {
+	f := NewFilter()
+	f.MinScore = 40
+	f.CategoryMinScore = map[string]int{"cs.CL": 70}
+
+	// Scores 55: accepted (+30), strong evidence (+15), dataset (+10)
+	abstract := "We run experiments and evaluation on a benchmark dataset."
+	papers := []model.Paper{
+		{ID: "cl", Categories: []string{"cs.CL"}, Abstract: abstract, Comments: "Accepted at ACL"},
+		{ID: "na", Categories: []string{"math.NA", "cs.CL"}, Abstract: abstract, Comments: "Accepted at ACL"},
+		{ID: "none", Abstract: abstract, Comments: "Accepted at ACL"},
+	}
+
+	passed := f.FilterPassed(papers)
+	if len(passed) != 2 || passed[0].ID != "na" || passed[1].ID != "none" {
+		t.Errorf("passed = %+v, want na and none (cs.CL needs 70)", passed)
+	}
+}
+
+func TestFilter_PageTiers(t *testing.T) {
+	f := NewFilter()
+	f.PageTiers = map[int]int{20: 5, 40: 10}
+
+	tests := []struct {
+		pages    int
+		expected int
+	}{
+		{0, 0},
+		{19, 0},
+		{20, 5},
+		{39, 5},
+		{40, 10},
+		{120, 10},
+	}
+
+	for _, tc := range tests {
+		result := f.evaluate(model.Paper{ID: "2301.00001v1", Pages: tc.pages})
+		if result.Score != tc.expected {
+			t.Errorf("%d pages: score = %d, want %d (details %v)", tc.pages, result.Score, tc.expected, result.Details)
+		}
+	}
+
+	// Off by default
+	if got := NewFilter().evaluate(model.Paper{ID: "2301.00001v1", Pages: 80}).Score; got != 0 {
+		t.Errorf("default filter scored page count: %d", got)
+	}
+}
+
 func TestPaperVersion(t *testing.T) {
 	tests := []struct {
 		id      string
@@ -158,3 +240,86 @@ func TestPaperVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestFilter_Level1_AnnouncementLenient(t *testing.T) {
+	f := NewFilter()
+
+	paper := model.Paper{
+		ID:       "2401.00001v1",
+		Title:    "Brand New Submission",
+		Abstract: "We report experiments and evaluation of a new method.",
+		Source:   model.SourceArxivRSS,
+		Announce: model.AnnounceNew,
+	}
+
+	if result := f.evaluate(paper); !result.PassedLevel1 {
+		t.Error("Announced paper with evaluation keywords should pass Level 1 without DOI or acceptance")
+	}
+
+	for _, announce := range []string{"replace", "cross", ""} {
+		paper.Announce = announce
+		if result := f.evaluate(paper); result.PassedLevel1 {
+			t.Errorf("Announcement type %q without a strong signal should fail Level 1", announce)
+		}
+	}
+	paper.Announce = model.AnnounceNew
+
+	paper.Source = model.SourceArxiv
+	if result := f.evaluate(paper); result.PassedLevel1 {
+		t.Error("Search API paper without a strong signal should still fail Level 1")
+	}
+}
+
+func TestFilter_MaxText(t *testing.T) {
+	padding := strings.Repeat("x ", 50)
+	tests := []struct {
+		name     string
+		maxText  int
+		paper    model.Paper
+		ablation bool
+		code     bool
+		accepted bool
+	}{
+		{
+			name:     "keywords within the cap",
+			maxText:  200,
+			paper:    model.Paper{Abstract: padding + "ablation", Comments: padding + "accepted"},
+			ablation: true,
+			accepted: true,
+		},
+		{
+			name:    "keywords past the cap",
+			maxText: 100,
+			paper:   model.Paper{Abstract: padding + "ablation", Comments: padding + "accepted"},
+		},
+		{
+			name:    "code link past the cap",
+			maxText: 100,
+			paper:   model.Paper{Links: []model.Link{{URL: "https://example.org/" + padding + "https://github.com/a/b"}}},
+		},
+		{
+			name:    "cap counts characters, not bytes",
+			maxText: 60,
+			paper:   model.Paper{Abstract: strings.Repeat("稀", 50) + " ablation"},
+			// 50 wide characters plus " ablation" fit in 60 characters but not 60 bytes
+			ablation: true,
+		},
+		{
+			name:     "zero uses the default",
+			maxText:  0,
+			paper:    model.Paper{Abstract: strings.Repeat(padding, 100) + "ablation https://github.com/a/b"},
+			ablation: true,
+			code:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		f := NewFilter()
+		f.MaxText = tc.maxText
+		s := f.extract(tc.paper)
+		if s.ablation != tc.ablation || s.code != tc.code || s.accepted != tc.accepted {
+			t.Errorf("%s: ablation=%t code=%t accepted=%t, want %t %t %t",
+				tc.name, s.ablation, s.code, s.accepted, tc.ablation, tc.code, tc.accepted)
+		}
+	}
+}
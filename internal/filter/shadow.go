@@ -0,0 +1,82 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// LoadRules reads a rule set from a JSON file with the same fields as
+// Filter. Fields the file leaves out keep their NewFilter defaults, so a
+// candidate only needs to list what it changes.
+func LoadRules(path string) (*Filter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rules: %w", err)
+	}
+
+	f := NewFilter()
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("parse rules %s: %w", path, err)
+	}
+	if f.MinScore < 0 || f.MinScore > 100 {
+		return nil, fmt.Errorf("parse rules %s: min_score must be 0-100, got %d", path, f.MinScore)
+	}
+	if f.MaxText < 0 {
+		return nil, fmt.Errorf("parse rules %s: max_text must not be negative, got %d", path, f.MaxText)
+	}
+	return f, nil
+}
+
+// Divergence is a paper a candidate rule set judges differently from the
+// active one.
+type Divergence struct {
+	PaperID         string `json:"paper_id"`
+	ActiveScore     int    `json:"active_score"`
+	CandidateScore  int    `json:"candidate_score"`
+	ActivePassed    bool   `json:"active_passed"`
+	CandidatePassed bool   `json:"candidate_passed"`
+}
+
+// Flipped reports whether the candidate changes the pass/fail outcome.
+func (d Divergence) Flipped() bool {
+	return d.ActivePassed != d.CandidatePassed
+}
+
+// Shadow evaluates papers with the active filter and a candidate, finding
+// each paper's signals once. It returns the active results, which alone
+// should drive what is saved, and the papers that flip between pass and
+// fail or whose score moves by more than maxDelta points.
+func Shadow(active, candidate *Filter, papers []model.Paper, maxDelta int) ([]FilterResult, []Divergence) {
+	results := make([]FilterResult, 0, len(papers))
+	var divergences []Divergence
+
+	for _, paper := range papers {
+		s := active.extract(paper)
+		a := active.judge(paper, s)
+		c := candidate.judge(paper, s)
+		results = append(results, a)
+
+		d := Divergence{
+			PaperID:         paper.ID,
+			ActiveScore:     a.Score,
+			CandidateScore:  c.Score,
+			ActivePassed:    active.Passed(a),
+			CandidatePassed: candidate.Passed(c),
+		}
+		if d.Flipped() || abs(d.CandidateScore-d.ActiveScore) > maxDelta {
+			divergences = append(divergences, d)
+		}
+	}
+
+	return results, divergences
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+func TestShadow_RecordsDivergences(t *testing.T) {
+	active := NewFilter()
+
+	// The candidate stops rewarding acceptance notes and rewards code links
+	candidate := NewFilter()
+	candidate.Weights.Accepted = 10
+	candidate.Weights.Code = 40
+
+	evidence := "We run experiments and evaluation on a benchmark dataset."
+	papers := []model.Paper{
+		// 30+15+10 = 55 active, 35 candidate: fails both, moves 20
+		{ID: "accepted", Abstract: evidence, Comments: "Accepted at ACL"},
+		// 30+15+10+10 = 65 active, 75 candidate: passes both, moves 10
+		{ID: "both", Abstract: evidence + " Code: https://github.com/x/y", Comments: "Accepted at ACL"},
+		// 15+10+10 = 35 active, 65 candidate: flips to pass
+		{ID: "code", Abstract: evidence + " Code: https://github.com/x/y"},
+		// 30+20+15+10 = 75 active, 55 candidate: flips to fail
+		{ID: "published", Abstract: evidence, Comments: "Accepted at ICML", DOI: "10.1/x"},
+		// No evaluation at all: gate fails under both, score unchanged
+		{ID: "none", Abstract: "A position paper."},
+	}
+
+	results, divergences := Shadow(active, candidate, papers, 10)
+
+	if len(results) != len(papers) {
+		t.Fatalf("got %d results, want %d", len(results), len(papers))
+	}
+	for i, r := range results {
+		if want := active.evaluate(papers[i]); r.Score != want.Score || r.PassedLevel1 != want.PassedLevel1 {
+			t.Errorf("%s: shadow result %+v differs from active evaluation %+v", papers[i].ID, r, want)
+		}
+	}
+
+	want := map[string]struct{ active, candidate bool }{
+		"accepted":  {false, false},
+		"code":      {false, true},
+		"published": {true, false},
+	}
+	if len(divergences) != len(want) {
+		t.Fatalf("divergences = %+v, want %d", divergences, len(want))
+	}
+	for _, d := range divergences {
+		w, ok := want[d.PaperID]
+		if !ok {
+			t.Errorf("unexpected divergence %+v", d)
+			continue
+		}
+		if d.ActivePassed != w.active || d.CandidatePassed != w.candidate {
+			t.Errorf("%s: passed %v → %v, want %v → %v", d.PaperID, d.ActivePassed, d.CandidatePassed, w.active, w.candidate)
+		}
+		if d.Flipped() != (w.active != w.candidate) {
+			t.Errorf("%s: Flipped() = %v", d.PaperID, d.Flipped())
+		}
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "candidate.json")
+	rules := `{"min_score": 55, "category_min_score": {"cs.CL": 70}, "weights": {"code": 25}}`
+	if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := LoadRules(path)
+	if err != nil {
+		t.Fatalf("LoadRules: %v", err)
+	}
+	if f.MinScore != 55 || f.CategoryMinScore["cs.CL"] != 70 || f.Weights.Code != 25 {
+		t.Errorf("rules not applied: %+v", f)
+	}
+	if f.Weights.Accepted != DefaultWeights().Accepted {
+		t.Errorf("unlisted weight = %d, want default %d", f.Weights.Accepted, DefaultWeights().Accepted)
+	}
+
+	for _, bad := range []string{`{"min_score": 120}`, `{"weights": []}`} {
+		os.WriteFile(path, []byte(bad), 0o644)
+		if _, err := LoadRules(path); err == nil {
+			t.Errorf("LoadRules(%s) succeeded, want error", bad)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Package httpclient provides the HTTP client shared by paper providers.
+// Its transport retries transient failures with jittered exponential
+// backoff (see package retry) and reports every request to
+// the Recorder carried by the request context, so a sync can keep an
+// audit trail of exactly what it asked for.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/retry"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
+)
+
+const defaultMaxRetries = 3
+
+// errRetryStatus marks a response whose status is worth another try.
+var errRetryStatus = errors.New("retryable status")
+
+// New returns a client with the given timeout that uses Transport.
+func New(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &Transport{}}
+}
+
+// Request describes one HTTP exchange, retries included.
+type Request struct {
+	URL       string        // Request URL with credentials redacted
+	Status    int           // Final status code, 0 if no response arrived
+	Bytes     int64         // Response body bytes read
+	Duration  time.Duration // From the first attempt until the body was closed
+	Retries   int
+	Err       string // Transport error of the final attempt
+	StartedAt time.Time
+}
+
+// Recorder collects the requests made with its context. It is safe for
+// concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	requests []Request
+}
+
+// Requests returns the recorded requests in completion order.
+func (r *Recorder) Requests() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Request(nil), r.requests...)
+}
+
+func (r *Recorder) add(req Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context whose requests are reported to r.
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+func recorderFrom(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+type maxRetriesKey struct{}
+
+// WithMaxRetries returns a context whose requests are retried at most n
+// times, whatever the Transport's MaxRetries (0 or negative: none).
+func WithMaxRetries(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxRetriesKey{}, max(n, 0))
+}
+
+// DefaultBase carries the requests of a Transport without a Base, with
+// http.DefaultTransport's settings. It reads the proxy from the
+// environment even if the program changes http.DefaultTransport's.
+var DefaultBase http.RoundTripper = func() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	return t
+}()
+
+// UnderRetries returns current with rt as its base when current is a
+// retrying Transport, and rt in its place otherwise, so a caller's
+// transport (a proxy, or a recorder in tests) sees every attempt while
+// retries are kept.
+func UnderRetries(current, rt http.RoundTripper) http.RoundTripper {
+	t, ok := current.(*Transport)
+	if !ok {
+		return rt
+	}
+	retrying := *t
+	retrying.Base = rt
+	return &retrying
+}
+
+// Transport is an http.RoundTripper that retries rate limiting and
+// transient failures and records requests made under WithRecorder.
+// Client errors other than 429 are never retried.
+//
+// A response's Retry-After replaces the backoff before the next try. When
+// the wait would outlast the request context's deadline, the response or
+// error at hand is returned at once instead.
+type Transport struct {
+	Base          http.RoundTripper // Underlying transport (default: http.DefaultTransport's settings, proxied per HTTP_PROXY, HTTPS_PROXY and NO_PROXY)
+	MaxRetries    int               // Retries after 429, a 5xx other than 501 or a network error (default: 3, negative: none)
+	Backoff       time.Duration     // Wait before the first retry, doubled for each next one (default: 3s)
+	Jitter        float64           // Fraction of each wait taken off at random, so clients retry out of step (default: 0.5, negative: none)
+	MaxRetryAfter time.Duration     // Longest wait a Retry-After header gets; longer ones are cut to it (default: 60s)
+	Clock         clock.Clock       // Time source for durations and backoff (default: system clock)
+	UserAgent     string            // Sent when the request sets none (default: version.UserAgent())
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	clk := clock.Or(t.Clock)
+	base := t.Base
+	if base == nil {
+		base = DefaultBase
+	}
+	maxRetries := t.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+	if n, ok := req.Context().Value(maxRetriesKey{}).(int); ok {
+		maxRetries = n
+	}
+
+	if req.Header.Get("User-Agent") == "" {
+		ua := t.UserAgent
+		if ua == "" {
+			ua = version.UserAgent()
+		}
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", ua)
+	}
+
+	record := Request{URL: Redact(req.URL), StartedAt: clk.Now()}
+	var resp *http.Response
+	var bodyErr error
+	policy := retry.Policy{
+		MaxAttempts:   max(maxRetries, 0) + 1,
+		Backoff:       t.Backoff,
+		Jitter:        t.Jitter,
+		MaxRetryAfter: t.MaxRetryAfter,
+		Clock:         clk,
+		Retryable:     func(error) bool { return bodyErr == nil && canRetry(req) },
+	}
+	tries := 0
+	err := retry.Do(req.Context(), policy, func(ctx context.Context) error {
+		attempt := req
+		if tries > 0 {
+			if resp != nil {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				resp = nil
+			}
+
+			// A RoundTripper must not modify the caller's request, so each
+			// retry sends a clone with a fresh body
+			attempt = req.Clone(ctx)
+			if req.GetBody != nil {
+				attempt.Body, bodyErr = req.GetBody()
+				if bodyErr != nil {
+					return bodyErr
+				}
+			}
+		}
+		tries++
+
+		var err error
+		resp, err = base.RoundTrip(attempt)
+		if err != nil || !retryable(resp, nil) {
+			return err
+		}
+		if d, ok := RetryAfter(resp, clk.Now()); ok {
+			return retry.After(errRetryStatus, d)
+		}
+		return errRetryStatus
+	})
+	record.Retries = max(tries-1, 0)
+	if errors.Is(err, errRetryStatus) {
+		// Out of retries: the last response is the answer
+		err = nil
+	} else if err != nil && resp != nil {
+		// The context ended while waiting to retry
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, err
+	}
+
+	rec := recorderFrom(req.Context())
+	if rec == nil {
+		return resp, err
+	}
+	if err != nil {
+		record.Err = err.Error()
+		record.Duration = clk.Now().Sub(record.StartedAt)
+		rec.add(record)
+		return resp, err
+	}
+
+	record.Status = resp.StatusCode
+	resp.Body = &countingBody{ReadCloser: resp.Body, record: record, recorder: rec, clock: clk}
+	return resp, nil
+}
+
+// RetryAfter returns how long resp asks the client to wait before trying
+// again, from a Retry-After header given in seconds or as an HTTP date
+// (which now is measured against). ok is false when there is no valid
+// header.
+func RetryAfter(resp *http.Response, now time.Time) (d time.Duration, ok bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		if s < 0 {
+			return 0, false
+		}
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
+
+// canRetry reports whether the request can be sent again.
+func canRetry(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// countingBody records the request once its body is closed, when the
+// byte count and duration are known.
+type countingBody struct {
+	io.ReadCloser
+	record   Request
+	recorder *Recorder
+	clock    clock.Clock
+	once     sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.record.Bytes += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.record.Duration = b.clock.Now().Sub(b.record.StartedAt)
+		b.recorder.add(b.record)
+	})
+	return err
+}
+
+// sensitiveParams are query parameters that carry credentials.
+var sensitiveParams = []string{"key", "api_key", "apikey", "token", "access_token"}
+
+// Redact returns u as a string with user info and credential query
+// parameters replaced.
+func Redact(u *url.URL) string {
+	c := *u
+	c.User = nil
+	q := c.Query()
+	changed := false
+	for name := range q {
+		for _, s := range sensitiveParams {
+			if strings.EqualFold(name, s) {
+				q.Set(name, "REDACTED")
+				changed = true
+			}
+		}
+	}
+	if changed {
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
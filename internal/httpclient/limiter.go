@@ -0,0 +1,54 @@
+package httpclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+)
+
+// Limiter spaces the starts of a client's requests, handing out slots in
+// call order. Providers keep one per client, so every method of the
+// client shares its rate limit. The zero value is ready to use; a
+// Limiter must not be copied after first use.
+type Limiter struct {
+	mu   sync.Mutex
+	next time.Time // Earliest start of the next request
+}
+
+// Wait blocks until a request may start, interval after the start of the
+// previous one by clk (nil: the system clock), or ctx is done.
+func (l *Limiter) Wait(ctx context.Context, clk clock.Clock, interval time.Duration) error {
+	clk = clock.Or(clk)
+
+	l.mu.Lock()
+	now := clk.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(interval)
+	l.mu.Unlock()
+
+	d := start.Sub(now)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := clk.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Next returns the earliest start of the next request, zero before the
+// first.
+func (l *Limiter) Next() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.next
+}
@@ -0,0 +1,55 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+)
+
+func TestLimiter_SpacesStarts(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	var l Limiter
+
+	// The first request starts at once
+	if err := l.Wait(context.Background(), clk, 3*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if want := start.Add(3 * time.Second); !l.Next().Equal(want) {
+		t.Errorf("next = %v, want %v", l.Next(), want)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(context.Background(), clk, 3*time.Second) }()
+	clk.BlockUntil(1)
+	clk.Advance(2 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("second request started before the interval passed")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clk.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimiter_Canceled(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var l Limiter
+	if err := l.Wait(context.Background(), clk, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(ctx, clk, time.Minute) }()
+	clk.BlockUntil(1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
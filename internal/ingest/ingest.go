@@ -0,0 +1,120 @@
+// Package ingest saves fetched papers and detects new arXiv versions of
+// papers that are already stored.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// Result summarises one ingest.
+type Result struct {
+	Saved   int           // Papers committed to the store
+	New     []model.Paper // Saved papers whose base ID was not stored before
+	Updated []Update      // Saved papers that supersede a stored version
+}
+
+// Update pairs a newer paper version with its recorded history entry.
+type Update struct {
+	Paper   model.Paper         `json:"paper"`
+	Version model.VersionUpdate `json:"version"`
+}
+
+// Save stores papers, rescoring any that are a newer version of a stored
+// paper with f (skipped when f is nil) and recording the version change as
+// detected at now. On a partial save, only committed papers are reported
+// and recorded.
+//
+// With a store that implements storage.InsertReporter, a paper only counts
+// as new if this save inserted its row, and as updated if it inserted the
+// row or raised its revision, so concurrent syncs of the same paper
+// report it once between them. A raised revision never counts as new.
+func Save(ctx context.Context, store storage.PaperStore, f *filter.Filter, papers []model.Paper, now time.Time) (Result, error) {
+	var result Result
+	if len(papers) == 0 {
+		return result, nil
+	}
+
+	baseIDs := make([]string, 0, len(papers))
+	for _, p := range papers {
+		baseIDs = append(baseIDs, p.BaseID())
+	}
+
+	latest, err := store.LatestVersions(ctx, baseIDs)
+	if err != nil {
+		return result, fmt.Errorf("load stored versions: %w", err)
+	}
+
+	updates := model.DetectVersionUpdates(latest, papers, now)
+	byVersion := make(map[string]model.VersionUpdate, len(updates))
+	for _, u := range updates {
+		byVersion[versionKey(u.BaseID, u.NewVersion)] = u
+	}
+
+	// Revisions often add camera-ready notes or a journal reference
+	if f != nil && len(updates) > 0 {
+		papers = append([]model.Paper(nil), papers...)
+		for i, p := range papers {
+			if _, ok := byVersion[versionKey(p.BaseID(), p.Version())]; ok {
+				papers[i] = f.Score(p)
+			}
+		}
+	}
+
+	committed := len(papers)
+	var inserted, revised map[string]bool // nil when the store cannot report inserts
+	var saveErr error
+	if r, ok := store.(storage.InsertReporter); ok {
+		inserted, revised, saveErr = r.SaveBatchInserted(ctx, papers)
+	} else {
+		saveErr = store.SaveBatch(ctx, papers)
+	}
+	if saveErr != nil {
+		var partial *storage.PartialSaveError
+		if !errors.As(saveErr, &partial) {
+			return result, saveErr
+		}
+		committed = partial.Committed
+	}
+
+	var recorded []model.VersionUpdate
+	for _, p := range papers[:committed] {
+		u, ok := byVersion[versionKey(p.BaseID(), p.Version())]
+		switch {
+		case inserted != nil && !inserted[p.ID] && !revised[p.ID]:
+			// Already stored, possibly by a concurrent sync since LatestVersions
+		case ok:
+			result.Updated = append(result.Updated, Update{Paper: p, Version: u})
+			recorded = append(recorded, u)
+		case revised[p.ID]:
+			// A revision of a paper a concurrent sync stored, and counted as
+			// new, since LatestVersions: never new, and its previous version
+			// is unknown
+		case !hasBase(latest, p):
+			result.New = append(result.New, p)
+		}
+	}
+	result.Saved = committed
+
+	if err := store.RecordVersionUpdates(ctx, recorded); err != nil && saveErr == nil {
+		return result, fmt.Errorf("record version updates: %w", err)
+	}
+
+	return result, saveErr
+}
+
+func hasBase(latest map[string]model.Paper, p model.Paper) bool {
+	_, ok := latest[p.BaseID()]
+	return ok
+}
+
+func versionKey(baseID string, version int) string {
+	return paperid.ID{Base: baseID, Version: version}.String()
+}
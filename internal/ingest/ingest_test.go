@@ -0,0 +1,188 @@
+package ingest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+const abstract = "We report experiments on a new benchmark dataset with an ablation against a strong baseline."
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestSave_DetectsNewVersionAcrossSyncs(t *testing.T) {
+	store := memory.New()
+	f := filter.NewFilter()
+	ctx := context.Background()
+
+	v1 := f.Score(model.Paper{
+		ID:        "2401.00001v1",
+		Title:     "Sparse Mixture Routing",
+		Abstract:  abstract,
+		UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	first, err := Save(ctx, store, f, []model.Paper{v1}, now)
+	if err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	if len(first.New) != 1 || len(first.Updated) != 0 {
+		t.Fatalf("first sync: %d new, %d updated; want 1 new", len(first.New), len(first.Updated))
+	}
+
+	// The revision arrives unscored with a camera-ready note
+	v2 := model.Paper{
+		ID:        "2401.00001v2",
+		Title:     "Sparse Mixture Routing",
+		Abstract:  abstract,
+		Comments:  "Accepted at ICLR 2024, camera-ready version",
+		UpdatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	second, err := Save(ctx, store, f, []model.Paper{v2}, now)
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if len(second.New) != 0 || len(second.Updated) != 1 {
+		t.Fatalf("second sync: %d new, %d updated; want 1 updated", len(second.New), len(second.Updated))
+	}
+
+	update := second.Updated[0]
+	if update.Version.OldVersion != 1 || update.Version.NewVersion != 2 || !update.Version.ContentChanged {
+		t.Errorf("version update = %+v, want v1 -> v2 with content changed", update.Version)
+	}
+	if !update.Version.DetectedAt.Equal(now) {
+		t.Errorf("DetectedAt = %v, want %v", update.Version.DetectedAt, now)
+	}
+
+	stored, err := store.GetByID(ctx, "2401.00001v2")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if stored.Score <= v1.Score {
+		t.Errorf("rescored v2 score = %d, want above v1 score %d", stored.Score, v1.Score)
+	}
+
+	history, err := store.ListVersions(ctx, "2401.00001")
+	if err != nil {
+		t.Fatalf("ListVersions: %v", err)
+	}
+	if len(history) != 1 || history[0].NewVersion != 2 {
+		t.Errorf("history = %+v, want one v2 entry", history)
+	}
+
+	// Syncing the same version again records nothing new
+	third, err := Save(ctx, store, f, []model.Paper{v2}, now)
+	if err != nil {
+		t.Fatalf("third sync: %v", err)
+	}
+	if len(third.New) != 0 || len(third.Updated) != 0 {
+		t.Errorf("repeat sync: %d new, %d updated; want none", len(third.New), len(third.Updated))
+	}
+}
+
+func TestSave_WithoutFilterKeepsScores(t *testing.T) {
+	store := memory.New()
+	ctx := context.Background()
+
+	if _, err := Save(ctx, store, nil, []model.Paper{{ID: "2401.00002v1", Score: 40}}, now); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	result, err := Save(ctx, store, nil, []model.Paper{{ID: "2401.00002v2", Score: 55}}, now)
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if len(result.Updated) != 1 || result.Updated[0].Paper.Score != 55 {
+		t.Errorf("updated = %+v, want v2 with its original score", result.Updated)
+	}
+}
+
+// barrierStore holds every LatestVersions call until all syncs have made
+// one, so each sees the papers as not yet stored.
+type barrierStore struct {
+	*memory.Store
+	wg *sync.WaitGroup
+}
+
+func (s barrierStore) LatestVersions(ctx context.Context, baseIDs []string) (map[string]model.Paper, error) {
+	latest, err := s.Store.LatestVersions(ctx, baseIDs)
+	s.wg.Done()
+	s.wg.Wait()
+	return latest, err
+}
+
+func TestSave_ConcurrentSyncsCountNewOnce(t *testing.T) {
+	const syncs = 4
+	var barrier sync.WaitGroup
+	barrier.Add(syncs)
+	store := barrierStore{Store: memory.New(), wg: &barrier}
+
+	papers := []model.Paper{
+		{ID: "2401.00001v1", Title: "A", Abstract: abstract, UpdatedAt: now},
+		{ID: "2401.00002v1", Title: "B", Abstract: abstract, UpdatedAt: now},
+		{ID: "2401.00003v1", Title: "C", Abstract: abstract, UpdatedAt: now},
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		totalNew int
+	)
+	for i := 0; i < syncs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := Save(context.Background(), store, nil, papers, now)
+			if err != nil {
+				t.Errorf("Save: %v", err)
+			}
+			mu.Lock()
+			totalNew += len(res.New)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if totalNew != len(papers) {
+		t.Errorf("syncs reported %d new papers between them, want %d", totalNew, len(papers))
+	}
+}
+
+// staleStore answers LatestVersions as if nothing were stored, like a
+// sync that read it before a concurrent one saved the paper.
+type staleStore struct {
+	*memory.Store
+}
+
+func (s staleStore) LatestVersions(ctx context.Context, baseIDs []string) (map[string]model.Paper, error) {
+	return map[string]model.Paper{}, nil
+}
+
+func TestSave_RaisedRevisionNotNew(t *testing.T) {
+	store := memory.New()
+	ctx := context.Background()
+
+	// Papers stored under their base ID carry their version in Revision
+	if _, err := Save(ctx, store, nil, []model.Paper{{ID: "2401.00004", Revision: 1, UpdatedAt: now}}, now); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	result, err := Save(ctx, staleStore{store}, nil, []model.Paper{{ID: "2401.00004", Revision: 2, UpdatedAt: now}}, now)
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if len(result.New) != 0 {
+		t.Errorf("new = %+v, want none for a raised revision", result.New)
+	}
+
+	// Without the race the revision is an update
+	result, err = Save(ctx, store, nil, []model.Paper{{ID: "2401.00004", Revision: 3, UpdatedAt: now}}, now)
+	if err != nil {
+		t.Fatalf("third sync: %v", err)
+	}
+	if len(result.New) != 0 || len(result.Updated) != 1 || result.Updated[0].Version.NewVersion != 3 {
+		t.Errorf("third sync: new %+v, updated %+v; want v3 updated", result.New, result.Updated)
+	}
+}
@@ -10,11 +10,11 @@ type KeywordExtractor interface {
 
 // NewKeywordExtractor creates a keyword extractor based on the provider.
 // Supported providers: "gemini" (default)
-func NewKeywordExtractor(provider string, cfg config.GeminiConfig) (KeywordExtractor, error) {
+func NewKeywordExtractor(provider string, cfg config.GeminiConfig, opts ...Option) (KeywordExtractor, error) {
 	switch provider {
 	case "gemini", "":
-		return NewGeminiClient(cfg)
+		return NewGeminiClient(cfg, opts...)
 	default:
-		return NewGeminiClient(cfg)
+		return NewGeminiClient(cfg, opts...)
 	}
 }
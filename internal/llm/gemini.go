@@ -11,27 +11,42 @@ import (
 	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
 )
 
-const geminiAPIBaseURL = "https://generativelanguage.googleapis.com/v1beta/models"
+const (
+	geminiAPIBaseURL = "https://generativelanguage.googleapis.com/v1beta/models"
+	defaultTimeout   = 30 * time.Second
+)
 
 // GeminiClient handles Gemini API calls and implements KeywordExtractor.
 type GeminiClient struct {
 	apiKey     string
 	model      string
+	baseURL    string
 	httpClient *http.Client
 }
 
-// NewGeminiClient creates a new Gemini client from config.
-func NewGeminiClient(cfg config.GeminiConfig) (*GeminiClient, error) {
+// NewGeminiClient creates a new Gemini client from config, configured by
+// opts.
+func NewGeminiClient(cfg config.GeminiConfig, opts ...Option) (*GeminiClient, error) {
 	if cfg.APIKey == "" {
 		return nil, fmt.Errorf("GEMINI_API_KEY not configured")
 	}
 
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	baseURL := o.baseURL
+	if baseURL == "" {
+		baseURL = geminiAPIBaseURL
+	}
+
+	// The shared client retries rate limits and server errors, which
+	// the free tier hands out often
 	return &GeminiClient{
-		apiKey: cfg.APIKey,
-		model:  cfg.Model,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		apiKey:     cfg.APIKey,
+		model:      cfg.Model,
+		baseURL:    baseURL,
+		httpClient: o.client(),
 	}, nil
 }
 
@@ -93,7 +108,7 @@ Keywords:`, question)
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/%s:generateContent?key=%s", geminiAPIBaseURL, c.model, c.apiKey)
+	url := fmt.Sprintf("%s/%s:generateContent?key=%s", c.baseURL, c.model, c.apiKey)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
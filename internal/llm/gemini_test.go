@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+)
+
+// fakeTransport answers every request with a canned Gemini response.
+type fakeTransport struct {
+	calls atomic.Int32
+	host  atomic.Value
+}
+
+func (t *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls.Add(1)
+	t.host.Store(req.URL.Host)
+	body := `{"candidates":[{"content":{"parts":[{"text":" retrieval augmented generation "}]}}]}`
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestGeminiClient_WithTransport(t *testing.T) {
+	rt := &fakeTransport{}
+	c, err := NewGeminiClient(config.GeminiConfig{APIKey: "key", Model: "gemini-test"}, WithTransport(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keywords, err := c.ExtractKeywords("How do models use retrieved documents?")
+	if err != nil {
+		t.Fatalf("ExtractKeywords: %v", err)
+	}
+	if keywords != "retrieval augmented generation" || rt.calls.Load() != 1 {
+		t.Errorf("keywords %q after %d requests through the transport", keywords, rt.calls.Load())
+	}
+	if rt.host.Load() != "generativelanguage.googleapis.com" {
+		t.Errorf("request went to %v", rt.host.Load())
+	}
+	// Retries are kept, with the transport under them
+	if tr, ok := c.httpClient.Transport.(*httpclient.Transport); !ok || tr.Base != rt {
+		t.Errorf("transport = %#v, want httpclient.Transport over the given one", c.httpClient.Transport)
+	}
+}
+
+func TestGeminiClient_Options(t *testing.T) {
+	hc := &http.Client{}
+	c, err := NewGeminiClient(config.GeminiConfig{APIKey: "key"},
+		WithHTTPClient(hc), WithTimeout(time.Second), WithBaseURL("http://gemini.test/models"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.httpClient == hc || c.httpClient.Timeout != time.Second || hc.Timeout != 0 {
+		t.Errorf("timeout %v on a copy, given client %v", c.httpClient.Timeout, hc.Timeout)
+	}
+	if c.baseURL != "http://gemini.test/models" {
+		t.Errorf("base URL = %q", c.baseURL)
+	}
+
+	if _, err := NewGeminiClient(config.GeminiConfig{}); err == nil {
+		t.Error("client without an API key created")
+	}
+}
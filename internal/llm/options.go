@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+)
+
+// Option configures a GeminiClient, as arxiv.Option does the arXiv
+// client.
+type Option func(*options)
+
+type options struct {
+	httpClient *http.Client
+	timeout    time.Duration
+	transport  http.RoundTripper
+	baseURL    string
+}
+
+// WithHTTPClient makes the client send its requests with hc. WithTimeout
+// and WithTransport still apply, to a copy of hc (default: a client with
+// httpclient.Transport, which retries 429s, 5xx responses and network
+// errors).
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *options) { o.httpClient = hc }
+}
+
+// WithTimeout limits each request, retries included, to d (default: 30s).
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithTransport sends requests over rt, under the retries when the HTTP
+// client has them (see httpclient.UnderRetries). Without it, requests go
+// through the proxy HTTP_PROXY, HTTPS_PROXY and NO_PROXY name, if any.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) { o.transport = rt }
+}
+
+// WithBaseURL sends requests to url instead of the Gemini API, e.g. to a
+// test server.
+func WithBaseURL(url string) Option {
+	return func(o *options) { o.baseURL = url }
+}
+
+// client returns the HTTP client o describes.
+func (o options) client() *http.Client {
+	hc := o.httpClient
+	if hc == nil {
+		hc = httpclient.New(defaultTimeout)
+	}
+	if o.timeout > 0 || o.transport != nil {
+		// The caller's client may be shared, so it is left as it is
+		copied := *hc
+		hc = &copied
+		if o.timeout > 0 {
+			hc.Timeout = o.timeout
+		}
+		if o.transport != nil {
+			hc.Transport = httpclient.UnderRetries(hc.Transport, o.transport)
+		}
+	}
+	return hc
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// countWords are the spelled-out numbers seen in arXiv comments.
+var countWords = map[string]int{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7,
+	"eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12, "thirteen": 13,
+	"fourteen": 14, "fifteen": 15, "sixteen": 16, "seventeen": 17, "eighteen": 18,
+	"nineteen": 19, "twenty": 20,
+}
+
+const countNumber = `(?:\d+|one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve|thirteen|fourteen|fifteen|sixteen|seventeen|eighteen|nineteen|twenty)`
+
+// extentPattern matches a count followed by its unit, e.g. "38 pages",
+// "38pages", "9+3 pages", "12 color figures" or "5-page". The count must
+// come first: "Figure 3" and "pp. 12-20" refer to a single item.
+var extentPattern = regexp.MustCompile(`(?i)\b(` + countNumber + `(?:\s*\+\s*` + countNumber + `)*)` +
+	`\s*-?\s*(?:(?:supplementary|supplemental|additional|extra|main|colou?r(?:ed)?|black[- ]and[- ]white)\s+)?` +
+	`(pages?|pp\b|figures?|figs?\b|tables?)`)
+
+// ParseExtent reads the page, figure and table counts from author comments
+// such as "38 pages, 12 figures, 5 tables". Counts that are absent are
+// zero. When a unit appears more than once ("9 pages main text, 25 pages
+// total"), the largest count wins; sums written as "9+3 pages" are added.
+func ParseExtent(comments string) (pages, figures, tables int) {
+	for _, m := range extentPattern.FindAllStringSubmatch(comments, -1) {
+		n := 0
+		for _, term := range strings.Split(m[1], "+") {
+			n += countValue(strings.TrimSpace(term))
+		}
+
+		unit := strings.ToLower(m[2])
+		switch {
+		case strings.HasPrefix(unit, "p"):
+			pages = max(pages, n)
+		case strings.HasPrefix(unit, "f"):
+			figures = max(figures, n)
+		default:
+			tables = max(tables, n)
+		}
+	}
+	return pages, figures, tables
+}
+
+func countValue(s string) int {
+	if n, ok := countWords[strings.ToLower(s)]; ok {
+		return n
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// SetExtent fills Pages, Figures and Tables from the paper's comments.
+func (p *Paper) SetExtent() {
+	p.Pages, p.Figures, p.Tables = ParseExtent(p.Comments)
+}
+
+// Extent summarises the parsed counts, e.g. "38 pages, 12 figures,
+// 1 table". It is empty when no count was found.
+func (p Paper) Extent() string {
+	var parts []string
+	for _, c := range []struct {
+		n    int
+		unit string
+	}{{p.Pages, "page"}, {p.Figures, "figure"}, {p.Tables, "table"}} {
+		switch {
+		case c.n == 1:
+			parts = append(parts, "1 "+c.unit)
+		case c.n > 1:
+			parts = append(parts, strconv.Itoa(c.n)+" "+c.unit+"s")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,63 @@
+package model
+
+import "testing"
+
+func TestParseExtent(t *testing.T) {
+	// Comment strings as they appear on arXiv listings
+	tests := []struct {
+		comments               string
+		pages, figures, tables int
+	}{
+		{"38 pages, 12 figures, 5 tables", 38, 12, 5},
+		{"5 tables, 38 pages", 38, 0, 5},
+		{"38pages", 38, 0, 0},
+		{"12 Figures", 0, 12, 0},
+		{"10 pages, 4 figures. Accepted at ICLR 2024", 10, 4, 0},
+		{"Accepted to NeurIPS 2023. 9 pages main text, 25 pages total", 25, 0, 0},
+		{"9+3 pages, 7 figures", 12, 7, 0},
+		{"8 + 2 pages", 10, 0, 0},
+		{"Camera-ready version; 15 pages, 6 figures, 3 tables; code at https://github.com/x/y", 15, 6, 3},
+		{"18 pages, 10 figures, 2 supplementary tables", 18, 10, 2},
+		{"4-page extended abstract", 4, 0, 0},
+		{"IEEE conference, 6 pages; 5 color figures", 6, 5, 0},
+		{"two figures, one table", 0, 2, 1},
+		{"Twelve pages, Three Figures", 12, 3, 0},
+		{"1 page", 1, 0, 0},
+		{"20 pp, 4 figs", 20, 4, 0},
+		{"25 pp., 8 figs.", 25, 8, 0},
+		{"PAGES: 14; FIGURES 3", 0, 0, 0},
+		{"14 PAGES, 3 FIGURES, 1 TABLE", 14, 3, 1},
+		{"Figure 3 corrected", 0, 0, 0},
+		{"Published in Proc. ACL, pp. 123-130", 0, 0, 0},
+		{"In: Journal of ML, 2023, 45(2)", 0, 0, 0},
+		{"v2: added 3 figures and 2 tables, now 20 pages", 20, 3, 2},
+		{"12 pages\n 4 figures", 12, 4, 0},
+		{"", 0, 0, 0},
+		{"To appear in CVPR 2024", 0, 0, 0},
+	}
+
+	for _, tc := range tests {
+		pages, figures, tables := ParseExtent(tc.comments)
+		if pages != tc.pages || figures != tc.figures || tables != tc.tables {
+			t.Errorf("ParseExtent(%q) = %d pages, %d figures, %d tables; want %d, %d, %d",
+				tc.comments, pages, figures, tables, tc.pages, tc.figures, tc.tables)
+		}
+	}
+}
+
+func TestPaperExtent(t *testing.T) {
+	tests := []struct {
+		paper    Paper
+		expected string
+	}{
+		{Paper{Pages: 38, Figures: 12, Tables: 5}, "38 pages, 12 figures, 5 tables"},
+		{Paper{Pages: 1, Tables: 1}, "1 page, 1 table"},
+		{Paper{}, ""},
+	}
+
+	for _, tc := range tests {
+		if got := tc.paper.Extent(); got != tc.expected {
+			t.Errorf("Extent() = %q, want %q", got, tc.expected)
+		}
+	}
+}
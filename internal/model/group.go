@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// PaperGroup collects records that share a base ID.
+type PaperGroup struct {
+	BaseID   string    `json:"base_id"`
+	Paper    Paper     `json:"paper"`    // Representative record (highest version, latest update)
+	Variants []Variant `json:"variants"` // Every record in the group, in input order
+}
+
+// Variant is the per-record metadata kept for each member of a group.
+type Variant struct {
+	ID         string    `json:"id"`
+	Version    int       `json:"version"`
+	Categories []string  `json:"categories"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Score      int       `json:"score"`
+	Source     string    `json:"source"` // Provider that produced the record
+}
+
+// GroupByBaseID folds papers sharing a base ID into one group each.
+// Groups keep the order in which their base ID first appears.
+func GroupByBaseID(papers []Paper) []PaperGroup {
+	groups := make([]PaperGroup, 0, len(papers))
+	index := make(map[string]int, len(papers))
+
+	for _, p := range papers {
+		base := p.BaseID()
+		variant := Variant{
+			ID:         p.ID,
+			Version:    p.Version(),
+			Categories: p.Categories,
+			UpdatedAt:  p.UpdatedAt,
+			Score:      p.Score,
+			Source:     p.Source,
+		}
+
+		i, ok := index[base]
+		if !ok {
+			index[base] = len(groups)
+			groups = append(groups, PaperGroup{
+				BaseID:   base,
+				Paper:    p,
+				Variants: []Variant{variant},
+			})
+			continue
+		}
+
+		g := &groups[i]
+		g.Variants = append(g.Variants, variant)
+		if newer(p, g.Paper) {
+			g.Paper = p
+		}
+	}
+
+	return groups
+}
+
+// newer reports whether a should represent its group instead of b.
+func newer(a, b Paper) bool {
+	if a.Version() != b.Version() {
+		return a.Version() > b.Version()
+	}
+	return a.UpdatedAt.After(b.UpdatedAt)
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBaseID(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected string
+	}{
+		{"2301.00001v1", "2301.00001"},
+		{"2301.00001v12", "2301.00001"},
+		{"2301.00001", "2301.00001"},
+		{"cs/0001001v3", "cs/0001001"},
+		{"cs/0001001", "cs/0001001"},
+		{"v2", "v2"},
+		{"", ""},
+	}
+
+	for _, tc := range tests {
+		if got := (Paper{ID: tc.id}).BaseID(); got != tc.expected {
+			t.Errorf("Paper{ID: %q}.BaseID() = %q, want %q", tc.id, got, tc.expected)
+		}
+	}
+}
+
+func TestGroupByBaseID(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	// The same paper as stored from a search sync (v1), a later search
+	// sync (v2) and an announcement feed (versionless), plus an unrelated paper.
+	papers := []Paper{
+		{ID: "2401.00001v1", Title: "Old", Categories: []string{"cs.LG"}, UpdatedAt: day(1), Score: 60},
+		{ID: "2401.00002v1", Title: "Other", Categories: []string{"cs.CL"}, UpdatedAt: day(2)},
+		{ID: "2401.00001v2", Title: "New", Categories: []string{"cs.LG", "stat.ML"}, UpdatedAt: day(5), Score: 80},
+		{ID: "2401.00001", Title: "Feed", Categories: []string{"stat.ML"}, UpdatedAt: day(6)},
+	}
+
+	groups := GroupByBaseID(papers)
+
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+
+	g := groups[0]
+	if g.BaseID != "2401.00001" {
+		t.Errorf("expected first group 2401.00001, got %q", g.BaseID)
+	}
+	if len(g.Variants) != 3 {
+		t.Fatalf("expected 3 variants, got %d", len(g.Variants))
+	}
+	if g.Paper.ID != "2401.00001v2" {
+		t.Errorf("expected representative 2401.00001v2, got %q", g.Paper.ID)
+	}
+	if g.Variants[1].Version != 2 || g.Variants[1].Score != 80 {
+		t.Errorf("unexpected variant metadata: %+v", g.Variants[1])
+	}
+	if g.Variants[2].Version != 1 || len(g.Variants[2].Categories) != 1 {
+		t.Errorf("unexpected feed variant metadata: %+v", g.Variants[2])
+	}
+
+	if groups[1].BaseID != "2401.00002" || len(groups[1].Variants) != 1 {
+		t.Errorf("unexpected second group: %+v", groups[1])
+	}
+}
+
+func TestGroupByBaseID_SameVersionPrefersLatest(t *testing.T) {
+	papers := []Paper{
+		{ID: "2401.00001v1", Title: "Earlier", UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "2401.00001v1", Title: "Later", UpdatedAt: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	groups := GroupByBaseID(papers)
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %d", len(groups))
+	}
+	if groups[0].Paper.Title != "Later" {
+		t.Errorf("expected latest record as representative, got %q", groups[0].Paper.Title)
+	}
+}
@@ -0,0 +1,190 @@
+package model
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+)
+
+// Known values for Link.Type.
+const (
+	LinkAbstract = "abstract"
+	LinkPDF      = "pdf"
+	LinkCode     = "code"
+	LinkData     = "data"
+	LinkOther    = "other"
+)
+
+// arxivURL is where AbsURL and PDFURL point.
+const arxivURL = "https://arxiv.org"
+
+// AbsURL returns the arXiv abstract page of the paper ID id, in either ID
+// style, with or without a version; "" when id is not an arXiv ID.
+// e.g., "cs/0001001v1" -> "https://arxiv.org/abs/cs/0001001v1"
+func AbsURL(id string) string {
+	return arxivLink("/abs/", id)
+}
+
+// PDFURL returns the arXiv PDF of the paper ID id like AbsURL, without the
+// ".pdf" suffix arXiv does not need for either ID style.
+// e.g., "2301.00001v2" -> "https://arxiv.org/pdf/2301.00001v2"
+func PDFURL(id string) string {
+	return arxivLink("/pdf/", id)
+}
+
+func arxivLink(dir, id string) string {
+	parsed, err := paperid.Parse(id)
+	if err != nil {
+		return ""
+	}
+	return arxivURL + dir + parsed.URLPath()
+}
+
+// AbsURL returns the paper's arXiv abstract page (see the AbsURL function).
+func (p Paper) AbsURL() string { return AbsURL(p.ID) }
+
+// PDFURL returns the paper's arXiv PDF (see the PDFURL function).
+func (p Paper) PDFURL() string { return PDFURL(p.ID) }
+
+// Known values for Link.FoundIn, for links the source did not list
+// itself but the authors wrote into the text.
+const (
+	FoundInAbstract = "abstract"
+	FoundInComments = "comments"
+)
+
+// textURLPattern matches URLs written into an abstract or comments: with a
+// scheme, or bare on a code host ("github.com/org/repo").
+var textURLPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+|\b(?:www\.)?(?:github\.com|gitlab\.com|bitbucket\.org)/[^\s<>"]+`)
+
+// codeHosts and dataHosts classify links by host.
+var (
+	codeHosts = []string{"github.com", "gitlab.com", "bitbucket.org", "codeberg.org", "sourceforge.net"}
+	dataHosts = []string{"zenodo.org", "figshare.com", "kaggle.com", "data.mendeley.com", "dataverse.harvard.edu"}
+)
+
+// ClassifyLink returns the Link.Type of a URL judged by host and path:
+// LinkCode for code hosts, LinkData for dataset repositories (Hugging Face
+// datasets included), LinkPDF for PDF files and LinkOther for the rest.
+func ClassifyLink(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return LinkOther
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path := strings.ToLower(u.Path)
+	switch {
+	case hostIn(host, codeHosts):
+		return LinkCode
+	case hostIn(host, dataHosts), host == "huggingface.co" && strings.HasPrefix(path, "/datasets/"):
+		return LinkData
+	case strings.HasSuffix(path, ".pdf"):
+		return LinkPDF
+	}
+	return LinkOther
+}
+
+func hostIn(host string, hosts []string) bool {
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// LinksInText returns the URLs written in text, in order and without
+// duplicates. Punctuation that ends the sentence around a URL, as in
+// "(see github.com/org/repo)." is not part of it; bare code host URLs get
+// an https scheme.
+func LinksInText(text string) []string {
+	var urls []string
+	seen := map[string]bool{}
+	for _, m := range textURLPattern.FindAllString(text, -1) {
+		u := trimURL(m)
+		if !strings.Contains(strings.ToLower(u), "://") {
+			u = "https://" + u
+		}
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Host == "" || !strings.Contains(parsed.Host, ".") {
+			continue
+		}
+		if key := LinkKey(u); !seen[key] {
+			seen[key] = true
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// trimURL drops trailing punctuation and closing brackets that have no
+// opening one inside the URL.
+func trimURL(u string) string {
+	for u != "" {
+		last := u[len(u)-1]
+		switch {
+		case strings.IndexByte(".,;:!?'\"", last) >= 0:
+		case last == ')' && strings.Count(u, "(") < strings.Count(u, ")"):
+		case last == ']' && strings.Count(u, "[") < strings.Count(u, "]"):
+		case last == '}' && strings.Count(u, "{") < strings.Count(u, "}"):
+		default:
+			return u
+		}
+		u = u[:len(u)-1]
+	}
+	return u
+}
+
+// LinkKey returns the form of a URL used to tell duplicates apart: scheme,
+// "www." and a trailing slash or ".git" make no difference, nor does the
+// case of the host, or of the path on code hosts, which ignore it.
+func LinkKey(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	path := strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), ".git")
+	if hostIn(host, codeHosts) {
+		path = strings.ToLower(path)
+	}
+	key := host + path
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
+
+// SetTextLinks adds the URLs written in the abstract and comments to
+// Links, classified with ClassifyLink and marked with where they were
+// found. URLs already listed are not added again.
+func (p *Paper) SetTextLinks() {
+	seen := make(map[string]bool, len(p.Links))
+	for _, l := range p.Links {
+		seen[LinkKey(l.URL)] = true
+	}
+	for _, src := range []struct{ text, foundIn string }{
+		{p.Abstract, FoundInAbstract},
+		{p.Comments, FoundInComments},
+	} {
+		for _, u := range LinksInText(src.text) {
+			if key := LinkKey(u); !seen[key] {
+				seen[key] = true
+				p.Links = append(p.Links, Link{URL: u, Type: ClassifyLink(u), FoundIn: src.foundIn})
+			}
+		}
+	}
+}
+
+// CodeLinks returns the paper's links of type LinkCode.
+func (p Paper) CodeLinks() []Link {
+	var code []Link
+	for _, l := range p.Links {
+		if l.Type == LinkCode {
+			code = append(code, l)
+		}
+	}
+	return code
+}
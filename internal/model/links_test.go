@@ -0,0 +1,128 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLinksInText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{
+			name:     "code sentence",
+			text:     "Code is available at https://github.com/org/repo.",
+			expected: []string{"https://github.com/org/repo"},
+		},
+		{
+			name:     "closing bracket and period",
+			text:     "We release our models (see https://github.com/org/repo.) and data [https://zenodo.org/record/123].",
+			expected: []string{"https://github.com/org/repo", "https://zenodo.org/record/123"},
+		},
+		{
+			name:     "brackets that belong to the URL",
+			text:     "Details: https://en.wikipedia.org/wiki/Transformer_(machine_learning), more soon",
+			expected: []string{"https://en.wikipedia.org/wiki/Transformer_(machine_learning)"},
+		},
+		{
+			name:     "several, bare and repeated",
+			text:     "Code: github.com/org/repo; demo at https://org.github.io/demo! Mirror: https://www.github.com/org/repo/",
+			expected: []string{"https://github.com/org/repo", "https://org.github.io/demo"},
+		},
+		{
+			name:     "quoted",
+			text:     `Project page "https://example.org/project", code coming soon`,
+			expected: []string{"https://example.org/project"},
+		},
+		{name: "none", text: "We study https as a protocol and http://localhost:8080.", expected: nil},
+	}
+
+	for _, tc := range tests {
+		if got := LinksInText(tc.text); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s: LinksInText = %q, want %q", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestClassifyLink(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/org/repo":                LinkCode,
+		"https://gitlab.com/group/sub/repo":          LinkCode,
+		"https://www.bitbucket.org/org/repo":         LinkCode,
+		"https://huggingface.co/datasets/org/corpus": LinkData,
+		"https://huggingface.co/org/model":           LinkOther,
+		"https://zenodo.org/record/123":              LinkData,
+		"https://example.org/paper.pdf":              LinkPDF,
+		"https://org.github.io/demo":                 LinkOther,
+		"::not a url":                                LinkOther,
+	}
+	for url, expected := range tests {
+		if got := ClassifyLink(url); got != expected {
+			t.Errorf("ClassifyLink(%s) = %s, want %s", url, got, expected)
+		}
+	}
+}
+
+func TestPaper_SetTextLinks(t *testing.T) {
+	p := Paper{
+		Abstract: "Code is available at https://github.com/org/repo. Data: https://huggingface.co/datasets/org/corpus.",
+		Comments: "12 pages. Project page: https://org.github.io/demo; code mirror http://github.com/org/tool.git",
+		Links: []Link{
+			{URL: "http://arxiv.org/abs/2401.00001v1", Type: LinkAbstract},
+			{URL: "https://github.com/Org/tool", Type: LinkCode}, // Listed by the source already
+		},
+	}
+	p.SetTextLinks()
+
+	expected := []Link{
+		{URL: "http://arxiv.org/abs/2401.00001v1", Type: LinkAbstract},
+		{URL: "https://github.com/Org/tool", Type: LinkCode},
+		{URL: "https://github.com/org/repo", Type: LinkCode, FoundIn: FoundInAbstract},
+		{URL: "https://huggingface.co/datasets/org/corpus", Type: LinkData, FoundIn: FoundInAbstract},
+		{URL: "https://org.github.io/demo", Type: LinkOther, FoundIn: FoundInComments},
+	}
+	if !reflect.DeepEqual(p.Links, expected) {
+		t.Errorf("Links = %+v\nwant %+v", p.Links, expected)
+	}
+
+	// Running it again adds nothing
+	p.SetTextLinks()
+	if len(p.Links) != len(expected) {
+		t.Errorf("second SetTextLinks left %d links, want %d", len(p.Links), len(expected))
+	}
+	if code := p.CodeLinks(); len(code) != 2 || code[1].URL != "https://github.com/org/repo" {
+		t.Errorf("CodeLinks = %+v", code)
+	}
+}
+
+func TestArxivURLs(t *testing.T) {
+	tests := []struct {
+		id  string
+		abs string
+		pdf string
+	}{
+		{"2301.00001v2", "https://arxiv.org/abs/2301.00001v2", "https://arxiv.org/pdf/2301.00001v2"},
+		{"2301.00001", "https://arxiv.org/abs/2301.00001", "https://arxiv.org/pdf/2301.00001"},
+		{"2301.12345v10", "https://arxiv.org/abs/2301.12345v10", "https://arxiv.org/pdf/2301.12345v10"},
+		{"cs/0001001v1", "https://arxiv.org/abs/cs/0001001v1", "https://arxiv.org/pdf/cs/0001001v1"},
+		{"cs/0001001", "https://arxiv.org/abs/cs/0001001", "https://arxiv.org/pdf/cs/0001001"},
+		{"hep-th/9901001v3", "https://arxiv.org/abs/hep-th/9901001v3", "https://arxiv.org/pdf/hep-th/9901001v3"},
+		// arXiv paths carry no subject class
+		{"math.GT/0309136v3", "https://arxiv.org/abs/math/0309136v3", "https://arxiv.org/pdf/math/0309136v3"},
+		{"arXiv:cs/0001001v1", "https://arxiv.org/abs/cs/0001001v1", "https://arxiv.org/pdf/cs/0001001v1"},
+		// IDs of other sources have no arXiv page
+		{"file-7", "", ""},
+		{"", "", ""},
+	}
+	for _, tc := range tests {
+		p := Paper{ID: tc.id}
+		if got := p.AbsURL(); got != tc.abs {
+			t.Errorf("AbsURL(%q) = %q, want %q", tc.id, got, tc.abs)
+		}
+		if got := p.PDFURL(); got != tc.pdf {
+			t.Errorf("PDFURL(%q) = %q, want %q", tc.id, got, tc.pdf)
+		}
+	}
+}
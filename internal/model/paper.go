@@ -1,15 +1,37 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+)
+
+// Known values for Paper.Source.
+const (
+	SourceArxiv    = "arxiv"     // ArXiv search API
+	SourceArxivRSS = "arxiv-rss" // ArXiv announcement RSS feeds
+	SourceOpenAlex = "openalex"  // OpenAlex works search
+	SourceBioRxiv  = "biorxiv"   // bioRxiv preprints by posting date
+	SourceMedRxiv  = "medrxiv"   // medRxiv preprints by posting date
+	SourceFile     = "file:"     // Prefix of a local file import, e.g. "file:papers.jsonl"
+)
+
+// AnnounceNew is the Paper.Announce value of a brand-new submission.
+// Announcement feeds also carry cross-lists ("cross") and replaced
+// versions ("replace", "replace-cross").
+const AnnounceNew = "new"
 
 // Paper represents a scientific paper from ArXiv.
 type Paper struct {
-	ID         string    // ArXiv unique identifier (e.g., "2301.00001v1")
-	Title      string    // Paper title
-	Abstract   string    // Full abstract text
-	Authors    []string  // List of author names
-	Categories []string  // Academic category tags (e.g., cs.AI, cond-mat)
-	UpdatedAt  time.Time // Last update timestamp
+	ID              string    // ArXiv unique identifier (e.g., "2301.00001v1", or "2301.00001" with Revision set)
+	Revision        int       // Version of a paper whose ID has none (see StripVersion); 0 when the ID carries it
+	Title           string    // Paper title
+	Abstract        string    // Full abstract text
+	Authors         []string  // List of author names
+	Categories      []string  // Academic category tags (e.g., cs.AI, cond-mat), primary first
+	PrimaryCategory string    // The submission's primary category (arxiv:primary_category); empty when unknown
+	UpdatedAt       time.Time // Last update timestamp
+	Published       time.Time // First version's submission time (zero when the provider does not report it)
 
 	// Extended fields for quality filtering
 	Comments   string // Author comments (may contain "accepted", "to appear", etc.)
@@ -17,6 +39,18 @@ type Paper struct {
 	JournalRef string // Journal reference
 	Links      []Link // Related links (PDF, code repos, etc.)
 
+	// Extent parsed from Comments (see ParseExtent); zero when not stated
+	Pages   int
+	Figures int
+	Tables  int
+
+	// AbstractTruncated is set when Abstract was cut to fit storage limits
+	AbstractTruncated bool
+
+	// Provenance
+	Source   string // Provider that produced the record (SourceArxiv, SourceArxivRSS, SourceOpenAlex, ...)
+	Announce string // Announcement type from SourceArxivRSS feeds (e.g. AnnounceNew); not stored
+
 	// Computed fields (populated by filter)
 	Score        int      // Quality score (0-100)
 	ScoreDetails []string // Breakdown of score components
@@ -25,27 +59,35 @@ type Paper struct {
 // Link represents a related link for a paper.
 type Link struct {
 	URL   string // Full URL
-	Type  string // "abstract", "pdf", "code", "data", etc.
+	Type  string // LinkAbstract, LinkPDF, LinkCode, LinkData or LinkOther
 	Title string // Optional title/description
+
+	// FoundIn is where a link written by the authors was found
+	// (FoundInAbstract, FoundInComments); empty for the source's own links
+	FoundIn string
+}
+
+// BaseID returns the paper ID without its version suffix (see paperid.Split).
+// e.g., "2301.00001v2" -> "2301.00001", "cs/0001001v3" -> "cs/0001001"
+func (p Paper) BaseID() string {
+	return paperid.Base(p.ID)
 }
 
-// Version extracts the version number from the paper ID.
-// e.g., "2301.00001v2" -> 2, "2301.00001" -> 1
+// Version returns the version number from the paper ID, or Revision for
+// an ID without one.
+// e.g., "2301.00001v2" -> 2, "2301.00001" with Revision 3 -> 3, "2301.00001" -> 1
 func (p Paper) Version() int {
-	for i := len(p.ID) - 1; i >= 0; i-- {
-		if p.ID[i] == 'v' {
-			if i+1 < len(p.ID) {
-				v := 0
-				for j := i + 1; j < len(p.ID); j++ {
-					if p.ID[j] >= '0' && p.ID[j] <= '9' {
-						v = v*10 + int(p.ID[j]-'0')
-					}
-				}
-				if v > 0 {
-					return v
-				}
-			}
-		}
+	_, v := paperid.Split(p.ID)
+	if v == 0 {
+		v = p.Revision
 	}
-	return 1
+	return max(v, 1)
+}
+
+// StripVersion moves the version out of the ID into Revision, so that
+// every version of a paper is stored under its base ID:
+// "cs/0001001v2" becomes "cs/0001001" with Revision 2.
+func (p *Paper) StripVersion() {
+	p.Revision = p.Version()
+	p.ID = p.BaseID()
 }
@@ -0,0 +1,40 @@
+package model
+
+import "testing"
+
+func TestPaper_StripVersion(t *testing.T) {
+	tests := []struct {
+		id       string
+		revision int
+		base     string
+		version  int
+	}{
+		{"2301.00001v1", 0, "2301.00001", 1},
+		{"2301.00001v3", 0, "2301.00001", 3},
+		{"2301.00001v12", 0, "2301.00001", 12},
+		{"2301.00001", 0, "2301.00001", 1},
+		{"2301.00001", 4, "2301.00001", 4},
+		{"cs/0001001v2", 0, "cs/0001001", 2},
+		{"math.AG/0601001v1", 0, "math.AG/0601001", 1},
+		{"hep-th/9901001", 0, "hep-th/9901001", 1},
+		// A version in the ID wins over a stale Revision
+		{"2301.00001v5", 2, "2301.00001", 5},
+	}
+
+	for _, tc := range tests {
+		p := Paper{ID: tc.id, Revision: tc.revision}
+		if p.BaseID() != tc.base || p.Version() != tc.version {
+			t.Errorf("%s (revision %d): BaseID %q, Version %d, want %q, %d", tc.id, tc.revision, p.BaseID(), p.Version(), tc.base, tc.version)
+		}
+
+		p.StripVersion()
+		if p.ID != tc.base || p.Revision != tc.version || p.Version() != tc.version || p.BaseID() != tc.base {
+			t.Errorf("StripVersion(%s) = ID %q, Revision %d, want %q, %d", tc.id, p.ID, p.Revision, tc.base, tc.version)
+		}
+		// Stripping twice changes nothing
+		again := p
+		if again.StripVersion(); again.ID != p.ID || again.Revision != p.Revision {
+			t.Errorf("StripVersion twice on %s = %+v", tc.id, again)
+		}
+	}
+}
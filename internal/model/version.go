@@ -0,0 +1,103 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VersionUpdate records that a newer arXiv version of a stored paper was seen.
+type VersionUpdate struct {
+	BaseID         string    `json:"base_id"`
+	OldVersion     int       `json:"old_version"`
+	NewVersion     int       `json:"new_version"`
+	DetectedAt     time.Time `json:"detected_at"`
+	ContentChanged bool      `json:"content_changed"` // ContentHash differs between the two versions
+
+	// Abstracts on either side of the update, kept for diffing
+	OldAbstract string `json:"-"`
+	NewAbstract string `json:"-"`
+}
+
+// ContentHash fingerprints the text a revision can change: title, abstract,
+// authors, comments and publication references. Whitespace differences are
+// ignored.
+func (p Paper) ContentHash() string {
+	h := sha256.New()
+	for _, field := range []string{
+		p.Title,
+		p.Abstract,
+		strings.Join(p.Authors, ", "),
+		p.Comments,
+		p.DOI,
+		p.JournalRef,
+	} {
+		h.Write([]byte(strings.Join(strings.Fields(field), " ")))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DetectVersionUpdates compares incoming papers with the latest stored
+// version of each base ID and returns one update per incoming paper whose
+// version is higher. latest is keyed by base ID; several incoming versions
+// of one paper are chained in version order (v1 -> v2 -> v3).
+func DetectVersionUpdates(latest map[string]Paper, incoming []Paper, now time.Time) []VersionUpdate {
+	ordered := make([]Paper, len(incoming))
+	copy(ordered, incoming)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Version() < ordered[j].Version()
+	})
+
+	current := make(map[string]Paper, len(latest))
+	for base, p := range latest {
+		current[base] = p
+	}
+
+	var updates []VersionUpdate
+	for _, p := range ordered {
+		base := p.BaseID()
+		prev, ok := current[base]
+		if !ok {
+			current[base] = p
+			continue
+		}
+		if p.Version() <= prev.Version() {
+			continue
+		}
+
+		updates = append(updates, VersionUpdate{
+			BaseID:         base,
+			OldVersion:     prev.Version(),
+			NewVersion:     p.Version(),
+			DetectedAt:     now,
+			ContentChanged: p.ContentHash() != prev.ContentHash(),
+			OldAbstract:    prev.Abstract,
+			NewAbstract:    p.Abstract,
+		})
+		current[base] = p
+	}
+
+	return updates
+}
+
+// AbstractsByVersion collects the known abstract of each version of one
+// paper from its version history and its latest stored record. Updates
+// recorded before abstracts were kept contribute nothing.
+func AbstractsByVersion(history []VersionUpdate, latest Paper) map[int]string {
+	abstracts := make(map[int]string)
+	for _, u := range history {
+		if u.OldAbstract != "" {
+			abstracts[u.OldVersion] = u.OldAbstract
+		}
+		if u.NewAbstract != "" {
+			abstracts[u.NewVersion] = u.NewAbstract
+		}
+	}
+	if latest.ID != "" && latest.Abstract != "" {
+		abstracts[latest.Version()] = latest.Abstract
+	}
+	return abstracts
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestContentHash(t *testing.T) {
+	p := Paper{ID: "2301.00001v1", Title: "Sparse Attention", Abstract: "We study sparse attention."}
+
+	spaced := p
+	spaced.ID = "2301.00001v2"
+	spaced.Abstract = "We study   sparse\nattention."
+	if p.ContentHash() != spaced.ContentHash() {
+		t.Error("whitespace or ID changes should not change the content hash")
+	}
+
+	revised := p
+	revised.Comments = "Accepted at ICML 2024"
+	if p.ContentHash() == revised.ContentHash() {
+		t.Error("new comments should change the content hash")
+	}
+}
+
+func TestDetectVersionUpdates(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	latest := map[string]Paper{
+		"2301.00001": {ID: "2301.00001v1", Title: "A"},
+		"2301.00002": {ID: "2301.00002v2", Title: "B"},
+	}
+	incoming := []Paper{
+		{ID: "2301.00001v3", Title: "A", Comments: "camera-ready"},
+		{ID: "2301.00001v2", Title: "A"},
+		{ID: "2301.00002v2", Title: "B"}, // same version: not an update
+		{ID: "2301.00003v1", Title: "C"}, // brand new
+	}
+
+	updates := DetectVersionUpdates(latest, incoming, now)
+
+	want := []VersionUpdate{
+		{BaseID: "2301.00001", OldVersion: 1, NewVersion: 2, DetectedAt: now, ContentChanged: false},
+		{BaseID: "2301.00001", OldVersion: 2, NewVersion: 3, DetectedAt: now, ContentChanged: true},
+	}
+	if len(updates) != len(want) {
+		t.Fatalf("got %d updates, want %d: %+v", len(updates), len(want), updates)
+	}
+	for i := range want {
+		if updates[i] != want[i] {
+			t.Errorf("updates[%d] = %+v, want %+v", i, updates[i], want[i])
+		}
+	}
+}
+
+func TestAbstractsByVersion(t *testing.T) {
+	now := time.Now()
+	latest := map[string]Paper{"2301.00001": {ID: "2301.00001v1", Abstract: "first"}}
+	incoming := []Paper{
+		{ID: "2301.00001v2", Abstract: "second"},
+		{ID: "2301.00001v3", Abstract: "third"},
+	}
+	history := DetectVersionUpdates(latest, incoming, now)
+	history = append(history, VersionUpdate{BaseID: "2301.00001", OldVersion: 3, NewVersion: 4}) // recorded without abstracts
+
+	got := AbstractsByVersion(history, Paper{ID: "2301.00001v4", Abstract: "fourth"})
+
+	want := map[int]string{1: "first", 2: "second", 3: "third", 4: "fourth"}
+	if len(got) != len(want) {
+		t.Fatalf("AbstractsByVersion = %v, want %v", got, want)
+	}
+	for v, abstract := range want {
+		if got[v] != abstract {
+			t.Errorf("version %d = %q, want %q", v, got[v], abstract)
+		}
+	}
+	if got := AbstractsByVersion(nil, Paper{ID: "2301.00001v1"}); len(got) != 0 {
+		t.Errorf("AbstractsByVersion without abstracts = %v, want none", got)
+	}
+}
@@ -0,0 +1,212 @@
+// Package notify announces newly saved papers. A Queue batches the papers
+// of a sync into a few messages and delivers them from a worker, so a
+// large backfill neither floods the channel nor waits on it.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+const (
+	defaultBatchSize  = 10
+	defaultMaxPerSync = 50
+	defaultQueueSize  = 100
+)
+
+// Notifier delivers one message, e.g. to a chat webhook.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Message announces a batch of papers, or summarises those left out by
+// Config.MaxPerSync.
+type Message struct {
+	Query  string
+	Papers []model.Paper // Empty in an overflow summary
+	More   int           // Papers left out, set only in an overflow summary
+}
+
+// Text renders the message as plain text.
+func (m Message) Text() string {
+	if len(m.Papers) == 0 {
+		return fmt.Sprintf("…and %d more new papers for %q, see the stored papers", m.More, m.Query)
+	}
+	var b strings.Builder
+	noun := "papers"
+	if len(m.Papers) == 1 {
+		noun = "paper"
+	}
+	fmt.Fprintf(&b, "%d new %s for %q:", len(m.Papers), noun, m.Query)
+	for _, p := range m.Papers {
+		fmt.Fprintf(&b, "\n• %s (score %d) %s", p.Title, p.Score, p.AbsURL())
+	}
+	return b.String()
+}
+
+// Stats counts what Enqueue did with the papers of one sync.
+type Stats struct {
+	Sent     int `json:"sent"`     // Messages handed to the delivery worker
+	Batched  int `json:"batched"`  // Papers listed in those messages
+	Overflow int `json:"overflow"` // Papers over the per-sync cap, only counted in a summary
+	Dropped  int `json:"dropped"`  // Messages discarded because the queue was full
+}
+
+// Config controls batching and queue limits.
+type Config struct {
+	BatchSize  int // Papers per message (default: 10)
+	MaxPerSync int // Papers listed per sync; the rest are summarised in one message (default: 50)
+	QueueSize  int // Messages allowed to wait for delivery (default: 100)
+
+	// Limits, when set, is asked for BatchSize and MaxPerSync at every
+	// Enqueue, so they can change while the queue runs. A non-positive
+	// value keeps the one above
+	Limits func() (batchSize, maxPerSync int)
+}
+
+// Queue delivers messages to a Notifier from a single worker.
+type Queue struct {
+	cfg      Config
+	notifier Notifier
+
+	mu     sync.RWMutex // Guards closed against concurrent sends
+	closed bool
+	msgs   chan Message
+
+	delivered atomic.Int64
+	failed    atomic.Int64
+
+	ctx    context.Context // Cancelled to abandon delivery at shutdown
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a queue and starts its worker.
+func New(n Notifier, cfg Config) *Queue {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.MaxPerSync <= 0 {
+		cfg.MaxPerSync = defaultMaxPerSync
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		cfg:      cfg,
+		notifier: n,
+		msgs:     make(chan Message, cfg.QueueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go q.work()
+	return q
+}
+
+// Enqueue batches papers into messages for query and queues them without
+// blocking: messages that do not fit are dropped and logged. Papers over
+// MaxPerSync are announced by one summary message.
+func (q *Queue) Enqueue(query string, papers []model.Paper) Stats {
+	var stats Stats
+	if len(papers) == 0 {
+		return stats
+	}
+	batchSize, maxPerSync := q.cfg.BatchSize, q.cfg.MaxPerSync
+	if q.cfg.Limits != nil {
+		b, m := q.cfg.Limits()
+		if b > 0 {
+			batchSize = b
+		}
+		if m > 0 {
+			maxPerSync = m
+		}
+	}
+	listed := papers[:min(len(papers), maxPerSync)]
+	for start := 0; start < len(listed); start += batchSize {
+		batch := listed[start:min(start+batchSize, len(listed))]
+		if q.offer(Message{Query: query, Papers: batch}) {
+			stats.Sent++
+			stats.Batched += len(batch)
+		} else {
+			stats.Dropped++
+		}
+	}
+	if more := len(papers) - len(listed); more > 0 {
+		stats.Overflow = more
+		if q.offer(Message{Query: query, More: more}) {
+			stats.Sent++
+		} else {
+			stats.Dropped++
+		}
+	}
+	if stats.Dropped > 0 {
+		log.Printf("Notification queue full: dropped %d of %d messages for %q", stats.Dropped, stats.Sent+stats.Dropped, query)
+	}
+	return stats
+}
+
+// offer queues msg unless the queue is full or shut down.
+func (q *Queue) offer(msg Message) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return false
+	}
+	select {
+	case q.msgs <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// Delivered returns the number of messages the Notifier accepted and the
+// number it failed, since the queue started.
+func (q *Queue) Delivered() (ok, failed int) {
+	return int(q.delivered.Load()), int(q.failed.Load())
+}
+
+// Shutdown stops accepting messages and waits for the queued ones to be
+// delivered, or abandons them when ctx is done.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.msgs)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-q.done
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) work() {
+	defer close(q.done)
+	for msg := range q.msgs {
+		if q.ctx.Err() != nil {
+			q.failed.Add(1)
+			continue
+		}
+		if err := q.notifier.Notify(q.ctx, msg); err != nil {
+			q.failed.Add(1)
+			log.Printf("Failed to send notification for %q: %v", msg.Query, err)
+			continue
+		}
+		q.delivered.Add(1)
+	}
+}
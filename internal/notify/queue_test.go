@@ -0,0 +1,187 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// fakeNotifier records messages; with release set, each delivery waits
+// for it to be closed or for the context to end.
+type fakeNotifier struct {
+	mu      sync.Mutex
+	msgs    []Message
+	release chan struct{}
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, msg Message) error {
+	if n.release != nil {
+		select {
+		case <-n.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.msgs = append(n.msgs, msg)
+	return nil
+}
+
+func (n *fakeNotifier) received() []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Message(nil), n.msgs...)
+}
+
+func papers(n int) []model.Paper {
+	out := make([]model.Paper, n)
+	for i := range out {
+		out[i] = model.Paper{ID: fmt.Sprintf("2403.%05dv1", i), Title: fmt.Sprintf("Paper %d", i), Score: 70}
+	}
+	return out
+}
+
+func TestQueue_BatchesAndCaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		papers   int
+		cfg      Config
+		expected Stats
+		sizes    []int // Papers per delivered message, 0 for the summary
+	}{
+		{"one batch", 3, Config{}, Stats{Sent: 1, Batched: 3}, []int{3}},
+		{"uneven batches", 25, Config{BatchSize: 10}, Stats{Sent: 3, Batched: 25}, []int{10, 10, 5}},
+		{
+			"backfill over the cap",
+			500, Config{BatchSize: 10, MaxPerSync: 50},
+			Stats{Sent: 6, Batched: 50, Overflow: 450},
+			[]int{10, 10, 10, 10, 10, 0},
+		},
+		{
+			"limits override the config",
+			30, Config{BatchSize: 10, MaxPerSync: 50, Limits: func() (int, int) { return 4, 8 }},
+			Stats{Sent: 3, Batched: 8, Overflow: 22},
+			[]int{4, 4, 0},
+		},
+		{
+			"limits unset keep the config",
+			12, Config{BatchSize: 5, Limits: func() (int, int) { return 0, 0 }},
+			Stats{Sent: 3, Batched: 12},
+			[]int{5, 5, 2},
+		},
+		{"nothing new", 0, Config{}, Stats{}, nil},
+	}
+
+	for _, tc := range tests {
+		n := &fakeNotifier{}
+		q := New(n, tc.cfg)
+		stats := q.Enqueue("llm", papers(tc.papers))
+		if err := q.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if stats != tc.expected {
+			t.Errorf("%s: stats = %+v, want %+v", tc.name, stats, tc.expected)
+		}
+		var sizes []int
+		for _, msg := range n.received() {
+			sizes = append(sizes, len(msg.Papers))
+		}
+		if fmt.Sprint(sizes) != fmt.Sprint(tc.sizes) {
+			t.Errorf("%s: delivered batches %v, want %v", tc.name, sizes, tc.sizes)
+		}
+		if ok, failed := q.Delivered(); ok != len(tc.sizes) || failed != 0 {
+			t.Errorf("%s: delivered %d, failed %d", tc.name, ok, failed)
+		}
+		if tc.expected.Overflow > 0 {
+			last := n.received()[len(tc.sizes)-1]
+			if last.More != tc.expected.Overflow || !strings.Contains(last.Text(), fmt.Sprintf("and %d more", tc.expected.Overflow)) {
+				t.Errorf("%s: summary = %+v %q", tc.name, last, last.Text())
+			}
+		}
+	}
+}
+
+func TestQueue_DropsInsteadOfBlocking(t *testing.T) {
+	n := &fakeNotifier{release: make(chan struct{})}
+	q := New(n, Config{BatchSize: 10, MaxPerSync: 500, QueueSize: 2})
+
+	done := make(chan Stats)
+	go func() { done <- q.Enqueue("llm", papers(500)) }()
+	var stats Stats
+	select {
+	case stats = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Enqueue blocked on a stalled notifier")
+	}
+	// The worker holds at most one message and the queue two more
+	if stats.Sent+stats.Dropped != 50 || stats.Sent > 3 || stats.Batched != stats.Sent*10 {
+		t.Errorf("stats = %+v, want at most 3 of 50 messages queued", stats)
+	}
+
+	close(n.release)
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(n.received()); got != stats.Sent {
+		t.Errorf("delivered %d messages, want %d", got, stats.Sent)
+	}
+	if s := q.Enqueue("llm", papers(1)); s.Sent != 0 || s.Dropped != 1 {
+		t.Errorf("after shutdown: %+v, want the message dropped", s)
+	}
+}
+
+func TestQueue_ShutdownDeadline(t *testing.T) {
+	n := &fakeNotifier{release: make(chan struct{})}
+	q := New(n, Config{})
+	q.Enqueue("llm", papers(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want the deadline error", err)
+	}
+	if ok, failed := q.Delivered(); ok != 0 || failed != 1 {
+		t.Errorf("delivered %d, failed %d; want the stalled message failed", ok, failed)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	w := &Webhook{URL: server.URL, Client: server.Client()}
+	if err := w.Notify(context.Background(), Message{Query: "llm", Papers: papers(2)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Papers) != 2 || got.Papers[1].ID != "2403.00001v1" || got.Query != "llm" {
+		t.Errorf("payload = %+v", got)
+	}
+	if !strings.HasPrefix(got.Text, `2 new papers for "llm":`) || !strings.Contains(got.Text, "https://arxiv.org/abs/2403.00000v1") {
+		t.Errorf("text = %q", got.Text)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no", http.StatusForbidden)
+	}))
+	defer failing.Close()
+	w.URL = failing.URL
+	if err := w.Notify(context.Background(), Message{Query: "llm", More: 3}); err == nil {
+		t.Error("a 403 should be an error")
+	}
+}
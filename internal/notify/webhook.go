@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+)
+
+// Webhook posts messages as JSON. The "text" field is what Slack's
+// incoming webhooks display; "papers" is for other receivers.
+type Webhook struct {
+	URL    string
+	Client *http.Client // default: http.DefaultClient
+}
+
+// NewFromConfig starts a queue that posts to the configured webhook, or
+// returns nil when none is set. limits may be nil (see Config.Limits).
+func NewFromConfig(cfg config.NotifyConfig, limits func() (batchSize, maxPerSync int)) *Queue {
+	if cfg.WebhookURL == "" {
+		return nil
+	}
+	w := &Webhook{URL: cfg.WebhookURL, Client: httpclient.New(10 * time.Second)}
+	return New(w, Config{BatchSize: cfg.BatchSize, MaxPerSync: cfg.MaxPerSync, QueueSize: cfg.QueueSize, Limits: limits})
+}
+
+type webhookPaper struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Score int    `json:"score"`
+}
+
+type webhookPayload struct {
+	Text   string         `json:"text"`
+	Query  string         `json:"query"`
+	Papers []webhookPaper `json:"papers"`
+	More   int            `json:"more,omitempty"`
+}
+
+// Notify implements Notifier.
+func (w *Webhook) Notify(ctx context.Context, msg Message) error {
+	payload := webhookPayload{Text: msg.Text(), Query: msg.Query, Papers: []webhookPaper{}, More: msg.More}
+	for _, p := range msg.Papers {
+		payload.Papers = append(payload.Papers, webhookPaper{ID: p.ID, Title: p.Title, Score: p.Score})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post notification: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("post notification: status %d", resp.StatusCode)
+	}
+	return nil
+}
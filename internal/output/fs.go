@@ -0,0 +1,78 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// FSTarget stores output files under a local directory.
+type FSTarget struct {
+	Root string
+}
+
+// NewFSTarget creates a filesystem target rooted at dir.
+func NewFSTarget(dir string) *FSTarget {
+	return &FSTarget{Root: dir}
+}
+
+func (t *FSTarget) path(name string) string {
+	return filepath.Join(t.Root, filepath.FromSlash(name))
+}
+
+// Create writes data to a new file, failing if it already exists.
+func (t *FSTarget) Create(ctx context.Context, name string, data []byte) error {
+	p := t.path(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(p)
+		return fmt.Errorf("write file: %w", err)
+	}
+	return f.Close()
+}
+
+// List returns the regular files directly inside dir. A missing directory
+// has no files.
+func (t *FSTarget) List(ctx context.Context, dir string) ([]Object, error) {
+	entries, err := os.ReadDir(t.path(dir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var objects []Object
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, Object{
+			Name:    path.Join(dir, entry.Name()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	return objects, nil
+}
+
+// Remove deletes a file.
+func (t *FSTarget) Remove(ctx context.Context, name string) error {
+	return os.Remove(t.path(name))
+}
@@ -0,0 +1,311 @@
+// Package output writes generated files (digests, exports) to a storage
+// target using templated names, without clobbering earlier runs.
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+const (
+	maxCollisions = 100  // Bounds the -2, -3, ... suffixes tried for a taken name
+	maxNameLength = 1024 // Longest rendered name, in bytes
+	maxElemLength = 255  // Longest directory or file name within it, as most filesystems allow
+)
+
+// nameFuncs are the helpers name templates may call, besides the
+// comparisons and printf (see allowedFuncs).
+var nameFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// allowedFuncs are the functions name templates may call. Other
+// text/template built-ins, such as call and index, are rejected.
+var allowedFuncs = map[string]bool{
+	"lower": true, "upper": true, "printf": true,
+	"eq": true, "ne": true, "and": true, "or": true, "not": true,
+}
+
+// unknownField matches text/template's error for a field PathData lacks.
+var unknownField = regexp.MustCompile(`can't evaluate field (\w+)`)
+
+// Object describes a stored file.
+type Object struct {
+	Name    string // Slash-separated path relative to the target root
+	Size    int64
+	ModTime time.Time
+}
+
+// Target stores output files. Names are slash-separated and relative to the
+// target root. The filesystem is implemented by FSTarget; an S3-compatible
+// target can implement Create with a conditional put.
+type Target interface {
+	// Create stores data under name, creating parent directories as needed.
+	// It fails with an error matching fs.ErrExist if name is taken.
+	Create(ctx context.Context, name string, data []byte) error
+	// List returns the objects directly inside dir.
+	List(ctx context.Context, dir string) ([]Object, error)
+	// Remove deletes name.
+	Remove(ctx context.Context, name string) error
+}
+
+// PathData holds the fields available to name templates.
+type PathData struct {
+	Date   string // 2006-01-02
+	Time   string // 150405
+	Preset string
+	Job    string
+	Format string // File extension without the dot, e.g. "md"
+}
+
+// NewPathData fills Date and Time from t.
+func NewPathData(t time.Time) PathData {
+	return PathData{Date: t.Format("2006-01-02"), Time: t.Format("150405")}
+}
+
+// Writer writes files named by a template, e.g.
+// "digests/{{.Date}}-{{.Preset}}.md".
+type Writer struct {
+	target Target
+	name   *template.Template
+
+	// Retention keeps only the newest N files produced by this template,
+	// counted across runs with the same fixed fields (0 = keep all). Date
+	// and Time must only appear in the file name, not the directory.
+	Retention int
+}
+
+// NewWriter parses the name template and renders it once with sample
+// fields, so that unknown fields, functions other than the allowed ones
+// and names that escape the root or are too long fail here rather than
+// after the output has been produced.
+func NewWriter(target Target, nameTemplate string) (*Writer, error) {
+	tmpl, err := template.New("name").Option("missingkey=error").Funcs(nameFuncs).Parse(nameTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse name template: %w", err)
+	}
+	for _, t := range tmpl.Templates() {
+		if err := checkFuncs(t.Root); err != nil {
+			return nil, fmt.Errorf("parse name template: %w", err)
+		}
+	}
+
+	w := &Writer{target: target, name: tmpl}
+	sample := PathData{Date: "2006-01-02", Time: "150405", Preset: "preset", Job: "job", Format: "ext"}
+	if _, err := w.Render(sample); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// checkFuncs returns an error for the first function node calls that is
+// not in allowedFuncs.
+func checkFuncs(node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, c := range n.Nodes {
+			if err := checkFuncs(c); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return checkFuncs(n.Pipe)
+	case *parse.IfNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		return checkFuncs(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			if err := checkFuncs(cmd); err != nil {
+				return err
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if err := checkFuncs(arg); err != nil {
+				return err
+			}
+		}
+	case *parse.ChainNode:
+		return checkFuncs(n.Node)
+	case *parse.IdentifierNode:
+		if !allowedFuncs[n.Ident] {
+			return fmt.Errorf("function %q is not allowed in name templates (allowed: %s)", n.Ident, strings.Join(sortedKeys(allowedFuncs), ", "))
+		}
+	}
+	return nil
+}
+
+func checkBranch(b *parse.BranchNode) error {
+	for _, n := range []parse.Node{b.Pipe, b.List, b.ElseList} {
+		if err := checkFuncs(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Render returns the file name for data.
+func (w *Writer) Render(data PathData) (string, error) {
+	var buf bytes.Buffer
+	if err := w.name.Execute(&buf, data); err != nil {
+		if m := unknownField.FindStringSubmatch(err.Error()); m != nil {
+			return "", fmt.Errorf("render name: unknown field .%s (fields: %s)", m[1], pathFields())
+		}
+		return "", fmt.Errorf("render name: %w", err)
+	}
+
+	if buf.Len() > maxNameLength {
+		return "", fmt.Errorf("render name: %d bytes, over the limit of %d", buf.Len(), maxNameLength)
+	}
+	name := path.Clean(strings.TrimPrefix(buf.String(), "/"))
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("render name: %q escapes the output root", buf.String())
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if len(elem) > maxElemLength {
+			return "", fmt.Errorf("render name: %q is %d bytes, over the limit of %d", elem[:32]+"...", len(elem), maxElemLength)
+		}
+	}
+	return name, nil
+}
+
+// pathFields lists the fields of PathData as template references.
+func pathFields() string {
+	t := reflect.TypeFor[PathData]()
+	fields := make([]string, t.NumField())
+	for i := range fields {
+		fields[i] = "." + t.Field(i).Name
+	}
+	return strings.Join(fields, ", ")
+}
+
+// Write stores data under the rendered name, appending -2, -3, ... before
+// the extension if the name is taken, then applies Retention. It returns
+// the name actually written.
+func (w *Writer) Write(ctx context.Context, fields PathData, data []byte) (string, error) {
+	base, err := w.Render(fields)
+	if err != nil {
+		return "", err
+	}
+
+	name := base
+	for n := 2; ; n++ {
+		err := w.target.Create(ctx, name, data)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", fmt.Errorf("write %s: %w", name, err)
+		}
+		if n > maxCollisions {
+			return "", fmt.Errorf("write %s: too many existing versions", base)
+		}
+		name = withSuffix(base, n)
+	}
+
+	if w.Retention > 0 {
+		if err := w.prune(ctx, fields); err != nil {
+			return name, err
+		}
+	}
+	return name, nil
+}
+
+// prune removes the oldest files matching this template beyond Retention.
+// Files match when they render from the same fixed fields with any date
+// and time, with or without a collision suffix.
+func (w *Writer) prune(ctx context.Context, fields PathData) error {
+	fields.Date, fields.Time = "*", "*"
+	pattern, err := w.Render(fields)
+	if err != nil {
+		return err
+	}
+
+	objects, err := w.target.List(ctx, path.Dir(pattern))
+	if err != nil {
+		return fmt.Errorf("list outputs: %w", err)
+	}
+
+	var runs []Object
+	for _, obj := range objects {
+		if matchesRun(pattern, obj.Name) {
+			runs = append(runs, obj)
+		}
+	}
+	if len(runs) <= w.Retention {
+		return nil
+	}
+
+	// Newest first; names break ties so the order is deterministic
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].ModTime.Equal(runs[j].ModTime) {
+			return runs[i].ModTime.After(runs[j].ModTime)
+		}
+		return runs[i].Name > runs[j].Name
+	})
+
+	for _, obj := range runs[w.Retention:] {
+		if err := w.target.Remove(ctx, obj.Name); err != nil {
+			return fmt.Errorf("prune %s: %w", obj.Name, err)
+		}
+	}
+	return nil
+}
+
+// withSuffix inserts -n before the extension: "a/b.md" -> "a/b-2.md".
+func withSuffix(name string, n int) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-" + strconv.Itoa(n) + ext
+}
+
+// matchesRun reports whether name matches pattern, ignoring a collision suffix.
+func matchesRun(pattern, name string) bool {
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	i := strings.LastIndexByte(stem, '-')
+	if i < 0 {
+		return false
+	}
+	if n, err := strconv.Atoi(stem[i+1:]); err != nil || n < 2 {
+		return false
+	}
+	ok, _ := path.Match(pattern, stem[:i]+ext)
+	return ok
+}
@@ -0,0 +1,163 @@
+package output
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter_Render(t *testing.T) {
+	w, err := NewWriter(nil, "digests/{{.Date}}-{{.Preset}}.{{.Format}}")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	data := NewPathData(time.Date(2024, 3, 5, 7, 30, 0, 0, time.UTC))
+	data.Preset = "llm"
+	data.Format = "md"
+
+	name, err := w.Render(data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if name != "digests/2024-03-05-llm.md" {
+		t.Errorf("Render = %q, want %q", name, "digests/2024-03-05-llm.md")
+	}
+}
+
+func TestNewWriter_ValidatesTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		expected string // Error substring, empty when valid
+	}{
+		{"fields and helpers", `{{.Date}}-{{lower .Preset}}{{if ne .Job ""}}-{{printf "%s" .Job}}{{end}}.{{.Format}}`, ""},
+		{"unknown field", "{{.Date}}-{{.Topic}}.md", "unknown field .Topic (fields: .Date, .Time, .Preset, .Job, .Format)"},
+		{"undefined function", "{{exec .Date}}.md", `function "exec" not defined`},
+		{"forbidden built-in", "{{index .Date 0}}.md", `function "index" is not allowed`},
+		{"forbidden in a branch", `{{if .Job}}{{call .Job}}{{end}}.md`, `function "call" is not allowed`},
+		{"forbidden in a defined template", `{{define "x"}}{{len .}}{{end}}{{template "x" .Date}}.md`, `function "len" is not allowed`},
+		{"escapes the root", "../{{.Date}}.md", "escapes the output root"},
+		{"element too long", strings.Repeat("a", 256) + ".md", "over the limit of 255"},
+		{"name too long", strings.Repeat("{{.Date}}/", 110) + "x.md", "over the limit of 1024"},
+	}
+
+	for _, tc := range tests {
+		_, err := NewWriter(nil, tc.template)
+		switch {
+		case tc.expected == "" && err != nil:
+			t.Errorf("%s: NewWriter failed: %v", tc.name, err)
+		case tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)):
+			t.Errorf("%s: NewWriter error %v, want one containing %q", tc.name, err, tc.expected)
+		}
+	}
+}
+
+func TestWriter_Collision(t *testing.T) {
+	root := t.TempDir()
+	w, err := NewWriter(NewFSTarget(root), "exports/{{.Date}}-{{.Preset}}.csv")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	data := NewPathData(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+	data.Preset = "vision"
+
+	want := []string{
+		"exports/2024-03-05-vision.csv",
+		"exports/2024-03-05-vision-2.csv",
+		"exports/2024-03-05-vision-3.csv",
+	}
+	for i, expected := range want {
+		name, err := w.Write(context.Background(), data, []byte{byte('a' + i)})
+		if err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+		if name != expected {
+			t.Errorf("Write %d = %q, want %q", i, name, expected)
+		}
+	}
+
+	// The first run's file is untouched
+	content, err := os.ReadFile(filepath.Join(root, "exports", "2024-03-05-vision.csv"))
+	if err != nil || string(content) != "a" {
+		t.Errorf("first file = %q, %v; want %q", content, err, "a")
+	}
+}
+
+func TestWriter_Retention(t *testing.T) {
+	root := t.TempDir()
+	target := NewFSTarget(root)
+	w, err := NewWriter(target, "digests/{{.Date}}-{{.Preset}}.md")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	w.Retention = 2
+
+	// Another preset shares the directory and must not be pruned
+	if err := target.Create(context.Background(), "digests/2024-01-01-other.md", nil); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for day := 0; day < 4; day++ {
+		data := NewPathData(start.AddDate(0, 0, day))
+		data.Preset = "llm"
+		name, err := w.Write(context.Background(), data, []byte("digest"))
+		if err != nil {
+			t.Fatalf("Write day %d: %v", day, err)
+		}
+		// Distinct mtimes so "oldest" is well defined
+		mtime := start.Add(time.Duration(day) * time.Hour)
+		if err := os.Chtimes(filepath.Join(root, filepath.FromSlash(name)), mtime, mtime); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+	}
+
+	objects, err := target.List(context.Background(), "digests")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var names []string
+	for _, obj := range objects {
+		names = append(names, obj.Name)
+	}
+	sort.Strings(names)
+
+	want := []string{
+		"digests/2024-01-01-other.md",
+		"digests/2024-01-03-llm.md",
+		"digests/2024-01-04-llm.md",
+	}
+	if len(names) != len(want) {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("files[%d] = %q, want %q", i, names[i], want[i])
+		}
+	}
+}
+
+func TestMatchesRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"digests/2024-01-01-llm.md", true},
+		{"digests/2024-01-01-llm-2.md", true},
+		{"digests/2024-01-01-llm-1.md", false},
+		{"digests/2024-01-01-other.md", false},
+		{"digests/2024-01-01-llm.csv", false},
+	}
+
+	for _, tc := range tests {
+		if result := matchesRun("digests/*-llm.md", tc.name); result != tc.expected {
+			t.Errorf("matchesRun(%q) = %v, want %v", tc.name, result, tc.expected)
+		}
+	}
+}
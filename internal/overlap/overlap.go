@@ -0,0 +1,125 @@
+// Package overlap measures how much the papers two presets match
+// coincide, to help decide whether one of them can be retired.
+package overlap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+)
+
+// Set is the papers one preset matched.
+type Set struct {
+	Preset string
+	Query  string
+	Scores map[string]int // Quality score by base ID
+}
+
+// Side summarises one preset of a comparison.
+type Side struct {
+	Preset   string   `json:"preset"`
+	Query    string   `json:"query"`
+	Matched  int      `json:"matched"`
+	AvgScore float64  `json:"avg_score"` // 0 when nothing matched
+	Unique   []string `json:"unique"`    // Base IDs only this preset matched, sorted
+}
+
+// Report compares the matches of two presets.
+type Report struct {
+	A       Side     `json:"a"`
+	B       Side     `json:"b"`
+	Shared  []string `json:"shared"`  // Base IDs both matched, sorted
+	Jaccard float64  `json:"jaccard"` // Shared over matched by either; 0 when neither matched anything
+}
+
+// Compare reports the overlap of a and b.
+func Compare(a, b Set) Report {
+	report := Report{A: side(a, b), B: side(b, a), Shared: []string{}}
+	for id := range a.Scores {
+		if _, ok := b.Scores[id]; ok {
+			report.Shared = append(report.Shared, id)
+		}
+	}
+	sort.Strings(report.Shared)
+	report.Jaccard = Jaccard(a, b)
+	return report
+}
+
+// Jaccard returns |A ∩ B| / |A ∪ B| over the base IDs of a and b, or 0
+// when both are empty.
+func Jaccard(a, b Set) float64 {
+	shared := 0
+	for id := range a.Scores {
+		if _, ok := b.Scores[id]; ok {
+			shared++
+		}
+	}
+	union := len(a.Scores) + len(b.Scores) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// side summarises s against other.
+func side(s, other Set) Side {
+	sd := Side{Preset: s.Preset, Query: s.Query, Matched: len(s.Scores), Unique: []string{}}
+	total := 0
+	for id, score := range s.Scores {
+		total += score
+		if _, ok := other.Scores[id]; !ok {
+			sd.Unique = append(sd.Unique, id)
+		}
+	}
+	sort.Strings(sd.Unique)
+	if len(s.Scores) > 0 {
+		sd.AvgScore = float64(total) / float64(len(s.Scores))
+	}
+	return sd
+}
+
+// Match runs p through svc as a dry run: at most limit papers updated
+// within window are fetched from provider, validated and filtered with
+// the preset's thresholds, and nothing is saved or logged. The papers
+// that pass make up the set.
+func Match(ctx context.Context, svc *pipeline.Service, provider string, p preset.SearchPreset, window time.Duration, limit int) (Set, error) {
+	result, err := svc.Run(ctx, pipeline.RunParams{
+		Provider:         provider,
+		Query:            p.Query,
+		Limit:            limit,
+		MaxAge:           window,
+		MinScore:         p.MinScore,
+		CategoryMinScore: p.CategoryMinScore,
+		SkipSave:         true,
+	})
+	if err != nil {
+		return Set{}, fmt.Errorf("match preset %s: %w", p.Name, err)
+	}
+	set := Set{Preset: p.Name, Query: p.Query, Scores: make(map[string]int, len(result.Passed))}
+	for _, paper := range result.Passed {
+		// Keep the best score when several versions match
+		if score, ok := set.Scores[paper.BaseID()]; !ok || paper.Score > score {
+			set.Scores[paper.BaseID()] = paper.Score
+		}
+	}
+	return set, nil
+}
+
+// Run matches a and then b and compares them. The presets are fetched
+// one after the other, never concurrently, so the provider's own rate
+// limit (one request every three seconds for arXiv) spaces the calls.
+func Run(ctx context.Context, svc *pipeline.Service, provider string, a, b preset.SearchPreset, window time.Duration, limit int) (Report, error) {
+	setA, err := Match(ctx, svc, provider, a, window, limit)
+	if err != nil {
+		return Report{}, err
+	}
+	setB, err := Match(ctx, svc, provider, b, window, limit)
+	if err != nil {
+		return Report{}, err
+	}
+	return Compare(setA, setB), nil
+}
@@ -0,0 +1,115 @@
+package overlap
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/providertest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+)
+
+func set(name string, scores map[string]int) Set {
+	return Set{Preset: name, Query: name, Scores: scores}
+}
+
+func TestJaccard(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     map[string]int
+		expected float64
+	}{
+		{"both empty", nil, nil, 0},
+		{"one empty", map[string]int{"1": 50}, nil, 0},
+		{"identical", map[string]int{"1": 50, "2": 60}, map[string]int{"1": 70, "2": 80}, 1},
+		{"disjoint", map[string]int{"1": 50}, map[string]int{"2": 50}, 0},
+		{"one of three", map[string]int{"1": 50, "2": 50}, map[string]int{"2": 50, "3": 50}, 1.0 / 3},
+		{"subset", map[string]int{"1": 50, "2": 50, "3": 50, "4": 50}, map[string]int{"2": 50}, 0.25},
+	}
+
+	for _, tc := range tests {
+		if got := Jaccard(set("a", tc.a), set("b", tc.b)); got != tc.expected {
+			t.Errorf("%s: Jaccard = %v, want %v", tc.name, got, tc.expected)
+		}
+		// Overlap is symmetric
+		if got := Jaccard(set("b", tc.b), set("a", tc.a)); got != tc.expected {
+			t.Errorf("%s: reversed Jaccard = %v, want %v", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	report := Compare(
+		set("rag", map[string]int{"2401.00001": 60, "2401.00002": 70, "2401.00003": 80}),
+		set("llm", map[string]int{"2401.00002": 50, "2401.00004": 90}),
+	)
+
+	expected := Report{
+		A:       Side{Preset: "rag", Query: "rag", Matched: 3, AvgScore: 70, Unique: []string{"2401.00001", "2401.00003"}},
+		B:       Side{Preset: "llm", Query: "llm", Matched: 2, AvgScore: 70, Unique: []string{"2401.00004"}},
+		Shared:  []string{"2401.00002"},
+		Jaccard: 0.25,
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Compare = %+v, want %+v", report, expected)
+	}
+
+	empty := Compare(set("a", nil), set("b", nil))
+	if empty.A.AvgScore != 0 || empty.Jaccard != 0 || empty.Shared == nil || empty.A.Unique == nil {
+		t.Errorf("empty Compare = %+v, want zeros and empty lists", empty)
+	}
+}
+
+func TestRun_FixtureOverlap(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	paper := func(id, title string, age time.Duration) model.Paper {
+		return model.Paper{
+			ID:        id,
+			Title:     title,
+			Authors:   []string{"A. Author"},
+			Abstract:  "We run experiments on a benchmark dataset and compare against a strong baseline.",
+			Comments:  "Accepted at ACL 2024",
+			UpdatedAt: now.Add(-age),
+		}
+	}
+	day := 24 * time.Hour
+	provider := providertest.Fixture{
+		paper("2402.00001v1", "Retrieval for reasoning", day),
+		paper("2402.00002v1", "Retrieval at scale", day),
+		paper("2402.00003v1", "Reasoning in context", day),
+		paper("2402.00004v2", "Retrieval and reasoning, revisited", 2*day),
+		paper("2402.00004v1", "Retrieval and reasoning", 3*day),
+		paper("2311.00005v1", "Retrieval before the window", 120*day),
+	}
+	svc := pipeline.NewService(map[string]parser.Provider{model.SourceArxiv: provider}, nil)
+	svc.Clock = clock.NewFake(now)
+
+	a := preset.SearchPreset{Name: "retrieval", Query: "retrieval", MinScore: 10}
+	b := preset.SearchPreset{Name: "reasoning", Query: "reasoning", MinScore: 10}
+	report, err := Run(context.Background(), svc, model.SourceArxiv, a, b, 90*day, 50)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// Versions of one paper count once; the old paper is outside the window
+	if want := []string{"2402.00001", "2402.00004"}; !reflect.DeepEqual(report.Shared, want) {
+		t.Errorf("shared = %v, want %v", report.Shared, want)
+	}
+	if want := []string{"2402.00002"}; !reflect.DeepEqual(report.A.Unique, want) {
+		t.Errorf("unique to %s = %v, want %v", a.Name, report.A.Unique, want)
+	}
+	if want := []string{"2402.00003"}; !reflect.DeepEqual(report.B.Unique, want) {
+		t.Errorf("unique to %s = %v, want %v", b.Name, report.B.Unique, want)
+	}
+	if report.A.Matched != 3 || report.B.Matched != 3 || report.Jaccard != 0.5 {
+		t.Errorf("report = %+v, want 3 matches each and Jaccard 0.5", report)
+	}
+	if report.A.AvgScore <= 0 || report.B.AvgScore <= 0 {
+		t.Errorf("average scores %v and %v, want the filter's scores", report.A.AvgScore, report.B.AvgScore)
+	}
+}
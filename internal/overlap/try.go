@@ -0,0 +1,117 @@
+package overlap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// DefaultSample is how many base IDs of each bucket a Change lists.
+const DefaultSample = 10
+
+// Bucket counts papers in one part of a Change.
+type Bucket struct {
+	Count  int      `json:"count"`
+	Sample []string `json:"sample"` // The first base IDs, sorted
+}
+
+// Change compares what a candidate preset matches with what the preset of
+// the same name surfaced in its last stored sync.
+type Change struct {
+	Preset       string `json:"preset"`
+	Query        string `json:"query"`         // The candidate's
+	StoredQuery  string `json:"stored_query"`  // The current preset's, "" when there is none
+	BaselineSync int    `json:"baseline_sync"` // Sync whose results are the baseline, 0 when there is none
+
+	Added     Bucket `json:"added"`     // Matched by the candidate, not surfaced before
+	Removed   Bucket `json:"removed"`   // Surfaced before, not matched by the candidate
+	Unchanged Bucket `json:"unchanged"` // Both
+}
+
+// Diff sorts the base IDs of candidate and baseline into the buckets of a
+// Change, listing up to sample of each.
+func Diff(candidate, baseline Set, sample int) Change {
+	var added, removed, unchanged []string
+	for id := range candidate.Scores {
+		if _, ok := baseline.Scores[id]; ok {
+			unchanged = append(unchanged, id)
+		} else {
+			added = append(added, id)
+		}
+	}
+	for id := range baseline.Scores {
+		if _, ok := candidate.Scores[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	return Change{
+		Preset:      candidate.Preset,
+		Query:       candidate.Query,
+		StoredQuery: baseline.Query,
+		Added:       bucket(added, sample),
+		Removed:     bucket(removed, sample),
+		Unchanged:   bucket(unchanged, sample),
+	}
+}
+
+func bucket(ids []string, sample int) Bucket {
+	sort.Strings(ids)
+	return Bucket{Count: len(ids), Sample: append([]string{}, ids[:min(len(ids), max(sample, 0))]...)}
+}
+
+// Baseline returns what the stored preset p surfaced: the papers of the
+// last completed sync of its query from provider, across all categories
+// as preset syncs run, whose result set history keeps, less those scored
+// below p's minimum. Papers the sync did not score are kept. A query
+// never synced has an empty baseline and sync ID 0.
+func Baseline(ctx context.Context, history storage.SyncResults, provider string, p preset.SearchPreset) (Set, int, error) {
+	set := Set{Preset: p.Name, Query: p.Query, Scores: make(map[string]int)}
+	sync, results, err := history.PreviousSync(ctx, p.Query, storage.SyncScope{Provider: provider}, 0)
+	if errors.Is(err, storage.ErrNotFound) {
+		return set, 0, nil
+	}
+	if err != nil {
+		return Set{}, 0, fmt.Errorf("baseline of preset %s: %w", p.Name, err)
+	}
+	for id, score := range results {
+		if score == unscored || score >= p.MinScore {
+			set.Scores[id] = score
+		}
+	}
+	return set, sync.ID, nil
+}
+
+// unscored is the score sync result sets give papers the filter skipped.
+const unscored = -1
+
+// Try runs candidate through svc as a dry run, like Match, and compares
+// its matches with the baseline of the registered preset of the same
+// name. Without such a preset, or without a history, every match is
+// added.
+func Try(ctx context.Context, svc *pipeline.Service, provider string, candidate preset.SearchPreset, history storage.SyncResults, window time.Duration, limit, sample int) (Change, error) {
+	matched, err := Match(ctx, svc, provider, candidate, window, limit)
+	if err != nil {
+		return Change{}, err
+	}
+
+	var baseline Set
+	var syncID int
+	if current, ok := preset.Get(candidate.Name); ok {
+		baseline = Set{Preset: current.Name, Query: current.Query}
+		if history != nil {
+			baseline, syncID, err = Baseline(ctx, history, provider, current)
+			if err != nil {
+				return Change{}, err
+			}
+		}
+	}
+	change := Diff(matched, baseline, sample)
+	change.BaselineSync = syncID
+	return change, nil
+}
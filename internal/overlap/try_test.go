@@ -0,0 +1,105 @@
+package overlap
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/providertest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/preset"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// storedResults is a sync log holding one result set per query.
+type storedResults map[string]map[string]int
+
+func (s storedResults) RecordSyncResults(ctx context.Context, id int, results map[string]int) error {
+	return nil
+}
+
+func (s storedResults) PreviousSync(ctx context.Context, query string, scope storage.SyncScope, id int) (storage.SyncLog, map[string]int, error) {
+	results, ok := s[query]
+	if !ok {
+		return storage.SyncLog{}, nil, storage.ErrNotFound
+	}
+	return storage.SyncLog{ID: 7, Query: query}, results, nil
+}
+
+func TestDiff(t *testing.T) {
+	change := Diff(
+		set("rag", map[string]int{"1": 60, "2": 70, "3": 80, "4": 50}),
+		set("rag", map[string]int{"3": 60, "4": 40, "5": 90}),
+		1,
+	)
+	want := Change{
+		Preset: "rag", Query: "rag", StoredQuery: "rag",
+		Added:     Bucket{Count: 2, Sample: []string{"1"}},
+		Removed:   Bucket{Count: 1, Sample: []string{"5"}},
+		Unchanged: Bucket{Count: 2, Sample: []string{"3"}},
+	}
+	if !reflect.DeepEqual(change, want) {
+		t.Errorf("Diff = %+v, want %+v", change, want)
+	}
+
+	empty := Diff(set("a", nil), Set{}, DefaultSample)
+	if empty.Added.Sample == nil || empty.Removed.Count != 0 || empty.StoredQuery != "" {
+		t.Errorf("empty Diff = %+v, want zero counts and empty samples", empty)
+	}
+}
+
+func TestTry_FixtureBuckets(t *testing.T) {
+	t.Cleanup(preset.Reset)
+	current := preset.SearchPreset{Name: "retrieval", Query: "retrieval", MinScore: 10}
+	preset.Default.Register(current)
+
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	paper := func(id, title string) model.Paper {
+		return model.Paper{
+			ID: id, Title: title, Authors: []string{"A. Author"}, Comments: "Accepted at ACL 2024",
+			Abstract:  "We run experiments on a benchmark dataset and compare against a strong baseline.",
+			UpdatedAt: now.Add(-24 * time.Hour),
+		}
+	}
+	provider := providertest.Fixture{
+		paper("2402.00001v2", "Dense retrieval for reasoning"),
+		paper("2402.00002v1", "Dense retrieval at scale"),
+		paper("2402.00003v1", "Sparse retrieval at scale"),
+		paper("2402.00004v1", "Reasoning in context"),
+	}
+	svc := pipeline.NewService(map[string]parser.Provider{model.SourceArxiv: provider}, nil)
+	svc.Clock = clock.NewFake(now)
+
+	// The stored preset surfaced 00001 (as v1) and 00003; 00005 no longer
+	// matches, and 00006 was scored below the preset's minimum
+	history := storedResults{"retrieval": {
+		"2402.00001": 40, "2402.00003": unscored, "2402.00005": 55, "2402.00006": 5,
+	}}
+	candidate := preset.SearchPreset{Name: "Retrieval", Query: "dense retrieval", MinScore: 10}
+
+	change, err := Try(context.Background(), svc, model.SourceArxiv, candidate, history, 30*24*time.Hour, 50, DefaultSample)
+	if err != nil {
+		t.Fatalf("Try failed: %v", err)
+	}
+	want := Change{
+		Preset: "Retrieval", Query: "dense retrieval", StoredQuery: "retrieval", BaselineSync: 7,
+		Added:     Bucket{Count: 1, Sample: []string{"2402.00002"}},
+		Removed:   Bucket{Count: 2, Sample: []string{"2402.00003", "2402.00005"}},
+		Unchanged: Bucket{Count: 1, Sample: []string{"2402.00001"}},
+	}
+	if !reflect.DeepEqual(change, want) {
+		t.Errorf("Try = %+v, want %+v", change, want)
+	}
+
+	// A new preset, or one never synced, has everything added
+	for _, c := range []preset.SearchPreset{{Name: "fresh", Query: "dense retrieval"}, candidate} {
+		change, err := Try(context.Background(), svc, model.SourceArxiv, c, storedResults{}, 30*24*time.Hour, 50, DefaultSample)
+		if err != nil || change.Added.Count != 2 || change.Removed.Count != 0 || change.BaselineSync != 0 {
+			t.Errorf("%s: Try = %+v, %v, want both matches added", c.Name, change, err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package paperid parses and renders paper identifiers. Every other
+// package goes through it rather than cutting ID strings itself, so
+// version suffixes, URL forms and source prefixes are handled one way.
+//
+// arXiv IDs come in two styles, either with an optional version suffix:
+//
+//	2301.00001, 2301.00001v2        new style (2007 on), YYMM.NNNN(N)
+//	cs/0001001, math.GT/0309136v3   old style, archive[.CLASS]/YYMMNNN
+//
+// and may arrive as "arXiv:2301.00001", "oai:arXiv.org:2301.00001" or an
+// abs or pdf URL on arxiv.org.
+package paperid
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SourceArxiv is the Source of IDs given with an arXiv prefix or URL.
+const SourceArxiv = "arxiv"
+
+// ErrInvalid is wrapped by the errors of Parse.
+var ErrInvalid = errors.New("invalid paper ID")
+
+var (
+	newStyle = regexp.MustCompile(`^(\d{4}\.\d{4,5})(?:v(\d+))?$`)
+	oldStyle = regexp.MustCompile(`^([a-z]+(?:-[a-z]+)?(?:\.[A-Z]{2})?/\d{7})(?:v(\d+))?$`)
+)
+
+// prefixes are the citation forms Parse strips, matched ignoring case.
+var prefixes = []string{"oai:arxiv.org:", "arxiv:"}
+
+// ID is a parsed paper identifier.
+type ID struct {
+	Source  string // SourceArxiv when the ID came with a prefix or URL, empty otherwise
+	Base    string // The ID without its version, e.g. 2301.00001 or cs/0001001
+	Version int    // 0 when the ID names no version
+}
+
+// Parse reads an arXiv ID in any of the forms in the package comment.
+// Surrounding space is ignored; anything else that does not fit is an
+// error wrapping ErrInvalid.
+func Parse(raw string) (ID, error) {
+	s := strings.TrimSpace(raw)
+	var id ID
+	if strings.Contains(s, "://") {
+		path, err := urlPath(s)
+		if err != nil {
+			return ID{}, fmt.Errorf("%w %q: %v", ErrInvalid, raw, err)
+		}
+		s, id.Source = path, SourceArxiv
+	} else {
+		for _, prefix := range prefixes {
+			if len(s) > len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+				s, id.Source = s[len(prefix):], SourceArxiv
+				break
+			}
+		}
+	}
+
+	m := newStyle.FindStringSubmatch(s)
+	if m == nil {
+		m = oldStyle.FindStringSubmatch(s)
+	}
+	if m == nil {
+		return ID{}, fmt.Errorf("%w %q", ErrInvalid, raw)
+	}
+	id.Base = m[1]
+	if m[2] != "" {
+		v, err := strconv.Atoi(m[2])
+		if err != nil || v == 0 {
+			return ID{}, fmt.Errorf("%w %q: bad version", ErrInvalid, raw)
+		}
+		id.Version = v
+	}
+	return id, nil
+}
+
+// urlPath returns the ID part of an arXiv abs or pdf URL.
+func urlPath(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	host := strings.ToLower(u.Hostname())
+	if host != "arxiv.org" && !strings.HasSuffix(host, ".arxiv.org") {
+		return "", fmt.Errorf("not an arXiv URL")
+	}
+	for _, dir := range []string{"/abs/", "/pdf/"} {
+		if rest, ok := strings.CutPrefix(u.Path, dir); ok {
+			return strings.TrimSuffix(rest, ".pdf"), nil
+		}
+	}
+	return "", fmt.Errorf("not an abs or pdf URL")
+}
+
+// Valid reports whether raw parses.
+func Valid(raw string) bool {
+	_, err := Parse(raw)
+	return err == nil
+}
+
+// String returns the ID as papers store it: the base with its version
+// suffix, if any, and no prefix ("2301.00001v2").
+func (id ID) String() string {
+	if id.Version == 0 {
+		return id.Base
+	}
+	return id.Base + "v" + strconv.Itoa(id.Version)
+}
+
+// Canonical returns the ID in arXiv's citation form, with the version if
+// any ("arXiv:2301.00001v2"). Parse reads it back.
+func (id ID) Canonical() string {
+	return "arXiv:" + id.String()
+}
+
+// URLPath returns the ID as arXiv's abs and pdf URLs spell it, with the
+// version if any. Old-style IDs lose their subject class, which arXiv
+// paths do not carry: "math.GT/0309136v3" -> "math/0309136v3".
+func (id ID) URLPath() string {
+	s := id.String()
+	if archive, rest, ok := strings.Cut(s, "/"); ok {
+		archive, _, _ = strings.Cut(archive, ".")
+		s = archive + "/" + rest
+	}
+	return s
+}
+
+// WithVersion returns id naming version v, or no version when v is 0.
+func (id ID) WithVersion(v int) ID {
+	id.Version = v
+	return id
+}
+
+// Normalize returns raw in the stored form (see ID.String) when it
+// parses, and raw without surrounding space when it does not, so IDs of
+// other sources pass through unchanged.
+func Normalize(raw string) string {
+	if id, err := Parse(raw); err == nil {
+		return id.String()
+	}
+	return strings.TrimSpace(raw)
+}
+
+// URLPath returns raw as arXiv URLs spell it (see ID.URLPath) when it
+// parses, and raw without surrounding space when it does not.
+func URLPath(raw string) string {
+	if id, err := Parse(raw); err == nil {
+		return id.URLPath()
+	}
+	return strings.TrimSpace(raw)
+}
+
+// Split returns the base and version (0 for none) of raw. arXiv IDs are
+// split as Parse reads them; for any other ID a trailing "v<digits>" after
+// at least one character is taken as the version.
+func Split(raw string) (base string, version int) {
+	if id, err := Parse(raw); err == nil {
+		return id.Base, id.Version
+	}
+	s := strings.TrimSpace(raw)
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	if i > 1 && i < len(s) && s[i-1] == 'v' {
+		if v, err := strconv.Atoi(s[i:]); err == nil && v > 0 {
+			return s[:i-1], v
+		}
+	}
+	return s, 0
+}
+
+// Base returns raw without its version (see Split).
+func Base(raw string) string {
+	base, _ := Split(raw)
+	return base
+}
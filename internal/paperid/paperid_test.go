@@ -0,0 +1,190 @@
+package paperid
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected ID
+	}{
+		// New style
+		{"2301.00001", ID{Base: "2301.00001"}},
+		{"2301.00001v2", ID{Base: "2301.00001", Version: 2}},
+		{"0704.0001v12", ID{Base: "0704.0001", Version: 12}},
+		{" 2301.00001v1\n", ID{Base: "2301.00001", Version: 1}},
+
+		// Old style
+		{"cs/0001001", ID{Base: "cs/0001001"}},
+		{"cs/0001001v3", ID{Base: "cs/0001001", Version: 3}},
+		{"hep-th/9901001v1", ID{Base: "hep-th/9901001", Version: 1}},
+		{"math.GT/0309136", ID{Base: "math.GT/0309136"}},
+
+		// Prefixed
+		{"arXiv:2301.00001v2", ID{Source: SourceArxiv, Base: "2301.00001", Version: 2}},
+		{"arxiv:cs/0001001", ID{Source: SourceArxiv, Base: "cs/0001001"}},
+		{"oai:arXiv.org:2401.00001v1", ID{Source: SourceArxiv, Base: "2401.00001", Version: 1}},
+
+		// URLs
+		{"http://arxiv.org/abs/2301.00001v1", ID{Source: SourceArxiv, Base: "2301.00001", Version: 1}},
+		{"https://arxiv.org/abs/cs/0001001", ID{Source: SourceArxiv, Base: "cs/0001001"}},
+		{"https://arxiv.org/pdf/2301.00001v3.pdf", ID{Source: SourceArxiv, Base: "2301.00001", Version: 3}},
+		{"https://arxiv.org/pdf/2301.00001", ID{Source: SourceArxiv, Base: "2301.00001"}},
+		{"https://export.arxiv.org/abs/math.GT/0309136v2", ID{Source: SourceArxiv, Base: "math.GT/0309136", Version: 2}},
+	}
+
+	for _, tc := range tests {
+		got, err := Parse(tc.raw)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tc.raw, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("Parse(%q) = %+v, want %+v", tc.raw, got, tc.expected)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"arXiv:",
+		"2301.001",
+		"2301.000001",
+		"2301.00001v0",
+		"2301.00001v",
+		"2301.00001v2v3",
+		"CS/0001001",
+		"cs/001001",
+		"cs.AI",
+		"v2",
+		"../2301.00001",
+		"https://example.org/abs/2301.00001",
+		"https://arxiv.org/list/cs.CL/recent",
+		"doi:10.1000/xyz",
+	} {
+		if _, err := Parse(raw); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalid", raw, err)
+		}
+		if Valid(raw) {
+			t.Errorf("Valid(%q) = true", raw)
+		}
+	}
+}
+
+func TestID_Render(t *testing.T) {
+	tests := []struct {
+		raw       string
+		str       string
+		canonical string
+	}{
+		{"2301.00001v2", "2301.00001v2", "arXiv:2301.00001v2"},
+		{"https://arxiv.org/abs/2301.00001", "2301.00001", "arXiv:2301.00001"},
+		{"oai:arXiv.org:cs/0001001v3", "cs/0001001v3", "arXiv:cs/0001001v3"},
+	}
+
+	for _, tc := range tests {
+		id, err := Parse(tc.raw)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tc.raw, err)
+		}
+		if got := id.String(); got != tc.str {
+			t.Errorf("%q: String() = %q, want %q", tc.raw, got, tc.str)
+		}
+		if got := id.Canonical(); got != tc.canonical {
+			t.Errorf("%q: Canonical() = %q, want %q", tc.raw, got, tc.canonical)
+		}
+		// Both forms read back to the same ID
+		for _, s := range []string{id.String(), id.Canonical()} {
+			back, err := Parse(s)
+			if err != nil || back.Base != id.Base || back.Version != id.Version {
+				t.Errorf("%q: Parse(%q) = %+v, %v; want %+v", tc.raw, s, back, err, id)
+			}
+		}
+	}
+
+	id := ID{Base: "2301.00001", Version: 1}
+	if got := id.WithVersion(4).String(); got != "2301.00001v4" {
+		t.Errorf("WithVersion(4) = %q", got)
+	}
+	if got := id.WithVersion(0).String(); got != "2301.00001" {
+		t.Errorf("WithVersion(0) = %q", got)
+	}
+	if id.Version != 1 {
+		t.Error("WithVersion changed the receiver")
+	}
+}
+
+func TestURLPath(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"2301.00001v2", "2301.00001v2"},
+		{"2301.00001", "2301.00001"},
+		{"cs/0001001v1", "cs/0001001v1"},
+		{"cs/0001001", "cs/0001001"},
+		{"math.GT/0309136v3", "math/0309136v3"},
+		{"hep-th/9901001", "hep-th/9901001"},
+		{"arXiv:math.GT/0309136", "math/0309136"},
+		{"https://arxiv.org/pdf/cs/0001001v1.pdf", "cs/0001001v1"},
+		{" file-7 ", "file-7"},
+	}
+	for _, tc := range tests {
+		if got := URLPath(tc.raw); got != tc.want {
+			t.Errorf("URLPath(%q) = %q, want %q", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"arXiv:2301.00001v2", "2301.00001v2"},
+		{"http://arxiv.org/abs/cs/0001001", "cs/0001001"},
+		{" 2301.00001 ", "2301.00001"},
+		// Other sources' IDs pass through
+		{"invalid-id", "invalid-id"},
+		{" p1 ", "p1"},
+	}
+
+	for _, tc := range tests {
+		if got := Normalize(tc.raw); got != tc.expected {
+			t.Errorf("Normalize(%q) = %q, want %q", tc.raw, got, tc.expected)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		raw     string
+		base    string
+		version int
+	}{
+		{"2301.00001v1", "2301.00001", 1},
+		{"2301.00001v12", "2301.00001", 12},
+		{"2301.00001", "2301.00001", 0},
+		{"cs/0001001v3", "cs/0001001", 3},
+		{"arXiv:2301.00001v2", "2301.00001", 2},
+		{"https://arxiv.org/abs/2301.00001v5", "2301.00001", 5},
+		// Not arXiv IDs: only a trailing v<digits> is split off
+		{"paper-7v2", "paper-7", 2},
+		{"p1", "p1", 0},
+		{"v2", "v2", 0},
+		{"", "", 0},
+	}
+
+	for _, tc := range tests {
+		base, version := Split(tc.raw)
+		if base != tc.base || version != tc.version {
+			t.Errorf("Split(%q) = %q, %d; want %q, %d", tc.raw, base, version, tc.base, tc.version)
+		}
+		if got := Base(tc.raw); got != tc.base {
+			t.Errorf("Base(%q) = %q, want %q", tc.raw, got, tc.base)
+		}
+	}
+}
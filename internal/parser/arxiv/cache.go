@@ -0,0 +1,63 @@
+package arxiv
+
+import (
+	"sync"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+)
+
+// responseCache holds decoded feeds by request URL. It is safe for
+// concurrent use.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	hits    int
+	misses  int
+}
+
+type cacheEntry struct {
+	feed    atomFeed
+	expires time.Time
+}
+
+// get returns the feed cached for reqURL if it has not expired at now.
+func (rc *responseCache) get(reqURL string, now time.Time) (atomFeed, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	e, ok := rc.entries[reqURL]
+	if !ok || !now.Before(e.expires) {
+		rc.misses++
+		return atomFeed{}, false
+	}
+	rc.hits++
+	return e.feed, true
+}
+
+// put caches feed for reqURL until expires, dropping the entries that
+// have expired by now so the cache holds at most one TTL of requests.
+func (rc *responseCache) put(reqURL string, feed atomFeed, now, expires time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.entries == nil {
+		rc.entries = make(map[string]cacheEntry)
+	}
+	for u, e := range rc.entries {
+		if !now.Before(e.expires) {
+			delete(rc.entries, u)
+		}
+	}
+	rc.entries[reqURL] = cacheEntry{feed: feed, expires: expires}
+}
+
+func (rc *responseCache) stats() parser.CacheStats {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return parser.CacheStats{Hits: rc.hits, Misses: rc.misses, Entries: len(rc.entries)}
+}
+
+// CacheStats reports the lookups of the response cache. Everything is 0
+// while CacheTTL is not set.
+func (c *Client) CacheStats() parser.CacheStats {
+	return c.cache.stats()
+}
@@ -1,90 +1,716 @@
 package arxiv
 
 import (
-	"encoding/xml"
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"iter"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
 	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
 )
 
+// DefaultPageSize is the results requested per page when Client.PageSize
+// is not set.
+const DefaultPageSize = 100
+
 const (
-	defaultBaseURL = "http://export.arxiv.org/api/query"
-	defaultTimeout = 30 * time.Second
+	defaultBaseURL  = "https://export.arxiv.org/api/query"
+	defaultTimeout  = 30 * time.Second
+	defaultInterval = 3 * time.Second // arXiv asks for one request every three seconds
+	defaultWorkers  = 2
+
+	dateLayout = "200601021504" // submittedDate:[YYYYMMDDHHMM TO YYYYMMDDHHMM], likewise lastUpdatedDate
+	dateMin    = "000101010000" // Open lower bound of a date range
+	dateMax    = "999912312359" // Open upper bound
+)
+
+// Errors of the client's requests, matched with errors.Is. They are the
+// parser package's, so callers need not know which provider failed.
+var (
+	// ErrBadQuery is returned when arXiv answers 400 or reports that it
+	// could not run a query, e.g. for a malformed field prefix or ID.
+	// The error text carries arXiv's explanation when it gave one
+	ErrBadQuery = parser.ErrBadQuery
+	// ErrRateLimited is returned for a 429 response
+	ErrRateLimited = parser.ErrRateLimited
+	// ErrUnavailable is returned for 5xx responses and requests that got
+	// no response at all
+	ErrUnavailable = parser.ErrUnavailable
+	// ErrDecode is returned, as a *DecodeError, for a response that is
+	// not a well-formed Atom feed, and with ErrNotXML or
+	// ErrResponseTooLarge for one not decoded at all
+	ErrDecode = parser.ErrDecode
 )
 
+// StatusError is a response with a status other than 200. errors.Is
+// matches it against ErrBadQuery (400), ErrRateLimited (429) or
+// ErrUnavailable (5xx).
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // The wait the response's Retry-After asked for, 0 when absent
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Is implements the matching described on StatusError.
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrBadQuery:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// RetryWait returns RetryAfter, for parser.RetryAfter.
+func (e *StatusError) RetryWait() time.Duration { return e.RetryAfter }
+
+// statusError returns the StatusError of resp, received at now.
+func statusError(resp *http.Response, now time.Time) *StatusError {
+	e := &StatusError{StatusCode: resp.StatusCode}
+	e.RetryAfter, _ = httpclient.RetryAfter(resp, now)
+	return e
+}
+
+// requestError wraps the error of a request that got no response as
+// ErrUnavailable, unless ctx ended it.
+func requestError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("HTTP request: %w", err)
+	}
+	return fmt.Errorf("%w: HTTP request: %w", ErrUnavailable, err)
+}
+
+// DecodeError is a response that could not be decoded as a feed. It
+// matches ErrDecode with errors.Is and unwraps to the XML error.
+type DecodeError struct {
+	Err     error
+	Offset  int64  // Bytes of the response read when decoding failed
+	Snippet string // The response up to the failure, at most snippetLen bytes of it
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode XML at byte %d: %v (near %q)", e.Offset, e.Err, e.Snippet)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// Is reports whether target is ErrDecode.
+func (e *DecodeError) Is(target error) bool { return target == ErrDecode }
+
+// snippetLen is how much of an undecodable response a DecodeError keeps.
+const snippetLen = 120
+
+// tailReader reads one byte at a time from a buffered reader and keeps
+// the last snippetLen bytes read, so a decode error can show what it
+// stopped at rather than where the buffer happened to end.
+type tailReader struct {
+	r    *bufio.Reader
+	tail []byte
+}
+
+func newTailReader(r io.Reader) *tailReader {
+	return &tailReader{r: bufio.NewReader(r), tail: make([]byte, 0, 2*snippetLen)}
+}
+
+// Read implements io.Reader; xml.Decoder uses ReadByte instead.
+func (t *tailReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.keep(p[:n]...)
+	return n, err
+}
+
+// ReadByte implements io.ByteReader.
+func (t *tailReader) ReadByte() (byte, error) {
+	b, err := t.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	t.keep(b)
+	return b, nil
+}
+
+// keep appends p to the tail, dropping all but the last snippetLen bytes
+// once the tail's buffer is full.
+func (t *tailReader) keep(p ...byte) {
+	if len(p) > snippetLen {
+		p = p[len(p)-snippetLen:]
+	}
+	if len(t.tail)+len(p) > cap(t.tail) {
+		t.tail = append(t.tail[:0], t.tail[len(t.tail)-snippetLen+len(p):]...)
+	}
+	t.tail = append(t.tail, p...)
+}
+
+// snippet returns the last snippetLen bytes read.
+func (t *tailReader) snippet() string {
+	return string(t.tail[max(len(t.tail)-snippetLen, 0):])
+}
+
+// fieldQuery matches queries that start with an arXiv field prefix such as
+// all:, ti: or cat:, optionally inside groups.
+var fieldQuery = regexp.MustCompile(`^\(*(all|ti|au|abs|co|jr|cat|rn|id):`)
+
+func init() {
+	parser.Default.Register(model.SourceArxiv, func(opts parser.Options) parser.Provider {
+		c := New(WithUserAgent(opts.UserAgent), WithRateLimit(opts.Intervals[model.SourceArxiv]))
+		c.StripVersions = opts.StripVersions
+		return c
+	})
+}
+
 // Client is an ArXiv API client that implements the parser.Provider interface.
 type Client struct {
 	httpClient *http.Client
 	baseURL    string
+
+	PageSize    int           // Results requested per page (default: 100)
+	Interval    time.Duration // Minimum time between the starts of this client's requests (default: 3s)
+	MaxAttempts int           // Tries per request on a 5xx or network error, when the HTTP client retries (default: its own setting)
+	Search      SearchOptions // Result order of FetchPapers (default: arXiv's relevance order)
+	Categories  []string      // Restrict FetchPapers and CountPapers to papers in any of these, e.g. cs.CL (default: any)
+	From        time.Time     // Restrict FetchPapers to papers first submitted at or after this time (default: no bound)
+	To          time.Time     // Restrict FetchPapers to papers first submitted before this time (default: no bound)
+	Clock       clock.Clock   // Time source for the rate limit (default: system clock)
+	PDFBaseURL  string        // DownloadPDF's source for papers without a PDF link, followed by the ID (default: https://arxiv.org/pdf/)
+
+	// Concurrent lets the pages after the first overlap: up to Workers
+	// requests are in flight at once, each still starting Interval after
+	// the previous one. arXiv asks for one connection at a time, so this
+	// is for mirrors and tests (default: false, one page at a time)
+	Concurrent bool
+	Workers    int // Page requests in flight when Concurrent (default: 2)
+
+	// UserAgent is sent with every request. arXiv asks API clients to
+	// include contact details, e.g. "genesis-pipeline/1.0 (+mailto:you@example.org)"
+	// (default: version.UserAgent())
+	UserAgent string
+
+	// CacheTTL is how long a response is reused for requests to the same
+	// URL, without a network call or a rate-limit wait. Only successful
+	// responses are cached (default: 0, no caching)
+	CacheTTL time.Duration
+
+	// StripVersions returns papers under their base ID, "2301.00001"
+	// rather than "2301.00001v3", with the version in Paper.Revision, so
+	// a revision overwrites the stored paper instead of adding a row
+	// (default: false, versioned IDs)
+	StripVersions bool
+
+	// Hooks is told of every HTTP request attempt, retries and pages
+	// included, e.g. a *Metrics counting bytes transferred (default: none)
+	Hooks Hooks
+
+	// MaxResponseBytes is the longest response body read, after which a
+	// request fails with ErrResponseTooLarge rather than decode a runaway
+	// or hostile response into memory (default: 50 MB)
+	MaxResponseBytes int64
+
+	limiter httpclient.Limiter
+	cache   responseCache
 }
 
-// NewClient creates a new ArXiv API client.
+// NewClient creates a new ArXiv API client. It is New without options.
 func NewClient() *Client {
-	return &Client{
-		httpClient: &http.Client{
-			Timeout: defaultTimeout,
-		},
-		baseURL: defaultBaseURL,
-	}
+	return New()
 }
 
-// NewClientWithOptions creates a new ArXiv API client with custom options.
+// NewClientWithOptions creates a new ArXiv API client with the given HTTP
+// client and base URL; nil and "" keep the defaults. It is New with
+// WithHTTPClient and WithBaseURL.
 func NewClientWithOptions(httpClient *http.Client, baseURL string) *Client {
-	if baseURL == "" {
-		baseURL = defaultBaseURL
-	}
-	if httpClient == nil {
-		httpClient = &http.Client{Timeout: defaultTimeout}
-	}
-	return &Client{
-		httpClient: httpClient,
-		baseURL:    baseURL,
+	return New(WithHTTPClient(httpClient), WithBaseURL(baseURL))
+}
+
+// FetchPapers retrieves papers from ArXiv matching the query, requesting
+// them in pages of PageSize until limit is reached or a page comes back
+// short. Papers keep the API's order; a paper listed twice, on the next
+// page when the results shifted between requests or in another version,
+// is returned once, as its highest version or latest update.
+func (c *Client) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	result, err := c.FetchResult(ctx, query, limit)
+	if err != nil {
+		return nil, err
 	}
+	return result.Papers, nil
+}
+
+// FetchResult fetches like FetchPapers and adds the feed's opensearch
+// totals: the matches in all as of the last page, and the start index and
+// page size of the first, and how many repeat listings were dropped.
+func (c *Client) FetchResult(ctx context.Context, query string, limit int) (parser.FetchResult, error) {
+	return c.fetchResult(ctx, c.searchQuery(query, c.Categories), limit, nil)
+}
+
+// FetchAllPages fetches like FetchResult, up to total papers, and calls
+// onPage after each page with its 0-based index, the papers fetched so
+// far and the papers the harvest is expected to reach: total, or fewer
+// when arXiv reports fewer matches. A page that comes back short ends the
+// harvest. A panic in onPage ends it too, with an error rather than a
+// crash. onPage may be nil.
+func (c *Client) FetchAllPages(ctx context.Context, query string, total int, onPage func(pageIdx, fetched, total int)) (parser.FetchResult, error) {
+	return c.fetchResult(ctx, c.searchQuery(query, c.Categories), total, onPage)
+}
+
+// reportPage calls onPage, turning a panic into an error.
+func reportPage(onPage func(pageIdx, fetched, total int), page, fetched, total int) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("progress callback panicked on page %d: %v", page, r)
+		}
+	}()
+	onPage(page, fetched, total)
+	return nil
+}
+
+// FetchSince fetches like FetchResult, restricted to papers whose latest
+// version was submitted at or after since (arXiv's lastUpdatedDate, to
+// the minute).
+func (c *Client) FetchSince(ctx context.Context, query string, since time.Time, limit int) (parser.FetchResult, error) {
+	return c.fetchResult(ctx, sinceQuery(c.searchQuery(query, c.Categories), since), limit, nil)
 }
 
-// FetchPapers retrieves papers from ArXiv matching the query.
-func (c *Client) FetchPapers(query string, limit int) ([]model.Paper, error) {
+// fetchResult implements FetchResult for a query in arXiv search syntax,
+// calling onPage, when set, after each page.
+func (c *Client) fetchResult(ctx context.Context, search string, limit int, onPage func(pageIdx, fetched, total int)) (parser.FetchResult, error) {
 	if limit <= 0 {
 		limit = 10
 	}
+	if err := c.Search.Validate(); err != nil {
+		return parser.FetchResult{}, err
+	}
+	pageSize := c.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	// A range or category with no matches is an empty result, not an error
+	result := parser.FetchResult{Papers: []model.Paper{}}
+	papers := result.Papers
+	index := make(map[string]int) // Position in papers by base ID
+	pageIdx := 0
+	for start := 0; len(papers) < limit; {
+		// The first page says how many match, so later ones can be
+		// requested together without asking past the end
+		want := limit - len(papers)
+		if start > 0 && result.Total > 0 {
+			want = min(want, result.Total-start)
+		}
+		if want <= 0 {
+			break
+		}
+		sizes := []int{min(pageSize, want)}
+		if c.Concurrent && start > 0 {
+			sizes = pageSizes(want, pageSize)
+		}
+
+		feeds, err := c.fetchPages(ctx, search, start, sizes)
+		if err != nil {
+			return parser.FetchResult{}, err
+		}
+		short := false
+		for i, feed := range feeds {
+			if start == 0 {
+				result.Start, result.PerPage = feed.StartIndex, feed.ItemsPerPage
+			}
+			result.Total = feed.TotalResults
+			page := c.convertEntries(feed.Entries)
+			for _, p := range page {
+				base := paperid.Base(p.ID)
+				if i, ok := index[base]; ok {
+					result.Duplicates++
+					if newerListing(p, papers[i]) {
+						papers[i] = p
+					}
+					continue
+				}
+				index[base] = len(papers)
+				papers = append(papers, p)
+			}
+			start += len(page)
+			if onPage != nil {
+				expected := limit
+				if result.Total > 0 {
+					expected = min(limit, result.Total)
+				}
+				if err := reportPage(onPage, pageIdx, min(len(papers), limit), expected); err != nil {
+					return parser.FetchResult{}, err
+				}
+			}
+			pageIdx++
+			if len(page) < sizes[i] {
+				short = true
+				break
+			}
+		}
+		if short {
+			break
+		}
+	}
+
+	// The API may send more entries than asked for
+	if len(papers) > limit {
+		papers = papers[:limit]
+	}
+	result.Papers = papers
+	return result, nil
+}
+
+// FetchPapersIter yields the papers FetchPapers would return, page by
+// page as each arrives, so a caller can process a large harvest without
+// holding it. Pages are requested one at a time, only as the caller asks
+// for more. A failed request yields its error once and ends the sequence.
+// Once ctx is done, the next request or rate-limit wait ends the sequence
+// with ctx's error. A paper already yielded is skipped when listed again,
+// so its first listing stands even when a later one is newer.
+func (c *Client) FetchPapersIter(ctx context.Context, query string, limit int) iter.Seq2[model.Paper, error] {
+	return c.fetchIter(ctx, c.searchQuery(query, c.Categories), limit)
+}
+
+// fetchIter implements FetchPapersIter for a query in arXiv search syntax.
+func (c *Client) fetchIter(ctx context.Context, search string, limit int) iter.Seq2[model.Paper, error] {
+	return func(yield func(model.Paper, error) bool) {
+		if limit <= 0 {
+			limit = 10
+		}
+		if err := c.Search.Validate(); err != nil {
+			yield(model.Paper{}, err)
+			return
+		}
+		pageSize := c.PageSize
+		if pageSize <= 0 {
+			pageSize = DefaultPageSize
+		}
+
+		seen := make(map[string]bool)
+		for start, n := 0, 0; n < limit; {
+			size := min(pageSize, limit-n)
+			reqURL, err := c.buildURL(search, start, size)
+			if err != nil {
+				yield(model.Paper{}, fmt.Errorf("build URL: %w", err))
+				return
+			}
+			feed, err := c.fetchFeed(ctx, reqURL)
+			if err != nil {
+				yield(model.Paper{}, err)
+				return
+			}
+			page := c.convertEntries(feed.Entries)
+			for _, p := range page {
+				base := paperid.Base(p.ID)
+				if seen[base] || n == limit {
+					continue
+				}
+				seen[base] = true
+				n++
+				if !yield(p, nil) {
+					return
+				}
+			}
+			start += len(page)
+			if len(page) < size {
+				return
+			}
+		}
+	}
+}
+
+// newerListing reports whether p lists a later state of the paper q
+// lists: a higher version, or the same one updated later.
+func newerListing(p, q model.Paper) bool {
+	if pv, qv := p.Version(), q.Version(); pv != qv {
+		return pv > qv
+	}
+	return p.UpdatedAt.After(q.UpdatedAt)
+}
+
+// pageSizes splits n results into pages of at most size.
+func pageSizes(n, size int) []int {
+	var sizes []int
+	for ; n > 0; n -= size {
+		sizes = append(sizes, min(n, size))
+	}
+	return sizes
+}
+
+// fetchPages requests consecutive pages of search from start, one page per
+// entry of sizes, and returns their feeds in order. With more than one
+// page and Concurrent set, up to Workers requests run at once; the first
+// failure cancels the rest and is returned with its 0-based page index.
+func (c *Client) fetchPages(ctx context.Context, search string, start int, sizes []int) ([]atomFeed, error) {
+	urls := make([]string, len(sizes))
+	offsets := make([]int, len(sizes))
+	for i, size := range sizes {
+		reqURL, err := c.buildURL(search, start, size)
+		if err != nil {
+			return nil, fmt.Errorf("build URL: %w", err)
+		}
+		urls[i], offsets[i] = reqURL, start
+		start += size
+	}
+	if len(urls) == 1 {
+		feed, err := c.fetchFeed(ctx, urls[0])
+		if err != nil {
+			return nil, err
+		}
+		return []atomFeed{feed}, nil
+	}
+
+	workers := c.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	feeds := make([]atomFeed, len(urls))
+	pages := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for range min(workers, len(urls)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range pages {
+				feed, err := c.fetchFeed(ctx, urls[i])
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = fmt.Errorf("page %d (start %d): %w", offsets[i]/sizes[0], offsets[i], err)
+						cancel()
+					})
+					continue
+				}
+				feeds[i] = feed
+			}
+		}()
+	}
+	for i := range urls {
+		pages <- i
+	}
+	close(pages)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return feeds, nil
+}
 
-	reqURL, err := c.buildURL(query, limit)
+// FetchByIDs retrieves the current metadata of papers by arXiv ID. Base IDs
+// without a version suffix resolve to the latest version. The request is
+// abandoned once ctx is done.
+func (c *Client) FetchByIDs(ctx context.Context, ids []string) ([]model.Paper, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	u, err := url.Parse(c.baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("build URL: %w", err)
 	}
+	q := u.Query()
+	q.Set("id_list", strings.Join(ids, ","))
+	q.Set("max_results", fmt.Sprintf("%d", len(ids)))
+	u.RawQuery = q.Encode()
+
+	return c.fetch(ctx, u.String())
+}
+
+// wait blocks until the rate limit lets the next request start, or ctx
+// is done. Slots are handed out in call order across every method of the
+// client.
+func (c *Client) wait(ctx context.Context) error {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return c.limiter.Wait(ctx, c.Clock, interval)
+}
+
+// CountPapers returns how many papers matching query were submitted in
+// [from, to), from the total of a one-entry request.
+func (c *Client) CountPapers(ctx context.Context, query string, from, to time.Time) (int, error) {
+	query = fmt.Sprintf("(%s) AND %s", c.searchQuery(query, c.Categories), submittedRange(from, to))
+	reqURL, err := c.buildURL(query, 0, 1)
+	if err != nil {
+		return 0, fmt.Errorf("build URL: %w", err)
+	}
+	feed, err := c.fetchFeed(ctx, reqURL)
+	if err != nil {
+		return 0, err
+	}
+	return feed.TotalResults, nil
+}
+
+func (c *Client) fetch(ctx context.Context, reqURL string) ([]model.Paper, error) {
+	feed, err := c.fetchFeed(ctx, reqURL)
+	if err != nil {
+		return nil, err
+	}
+	return c.convertEntries(feed.Entries), nil
+}
 
-	resp, err := c.httpClient.Get(reqURL)
+// fetchFeed returns the decoded feed at reqURL, from the cache when
+// CacheTTL allows.
+func (c *Client) fetchFeed(ctx context.Context, reqURL string) (atomFeed, error) {
+	if c.CacheTTL <= 0 {
+		return c.requestFeed(ctx, reqURL)
+	}
+	clk := clock.Or(c.Clock)
+	if feed, ok := c.cache.get(reqURL, clk.Now()); ok {
+		return feed, nil
+	}
+	feed, err := c.requestFeed(ctx, reqURL)
+	if err != nil {
+		return atomFeed{}, err
+	}
+	now := clk.Now()
+	c.cache.put(reqURL, feed, now, now.Add(c.CacheTTL))
+	return feed, nil
+}
+
+func (c *Client) requestFeed(ctx context.Context, reqURL string) (atomFeed, error) {
+	if err := c.wait(ctx); err != nil {
+		return atomFeed{}, err
+	}
+	if c.MaxAttempts > 0 {
+		ctx = httpclient.WithMaxRetries(ctx, c.MaxAttempts-1)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request: %w", err)
+		return atomFeed{}, fmt.Errorf("build request: %w", err)
+	}
+	ua := c.UserAgent
+	if ua == "" {
+		ua = version.UserAgent()
+	}
+	req.Header.Set("User-Agent", ua)
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return atomFeed{}, requestError(ctx, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return atomFeed{}, statusError(resp, clock.Or(c.Clock).Now())
 	}
 
 	var feed atomFeed
-	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
-		return nil, fmt.Errorf("decode XML: %w", err)
+	if err := c.decodeXML(ctx, resp, &feed); err != nil {
+		return atomFeed{}, err
+	}
+	if msg, ok := feedError(feed); ok {
+		return atomFeed{}, fmt.Errorf("%w: %s", ErrBadQuery, msg)
+	}
+	return feed, nil
+}
+
+// feedError reports whether feed is arXiv's answer to a query it could not
+// run: a 200 response whose only entry is titled "Error", with an ID under
+// /api/errors and the explanation as its summary.
+func feedError(feed atomFeed) (string, bool) {
+	if len(feed.Entries) != 1 {
+		return "", false
+	}
+	e := feed.Entries[0]
+	if strings.TrimSpace(e.Title) != "Error" || !strings.Contains(e.ID, "/api/errors") {
+		return "", false
 	}
+	return cleanText(e.Summary), true
+}
 
-	return c.convertEntries(feed.Entries), nil
+// searchQuery returns query in arXiv search syntax, restricted to
+// categories and the client's submission range.
+func (c *Client) searchQuery(query string, categories []string) string {
+	// Queries already written in arXiv field syntax (e.g. from
+	// preset.BuildQuery) are sent as they are
+	if !fieldQuery.MatchString(query) {
+		query = "all:" + query
+	}
+	var restrict []string
+	if len(categories) > 0 {
+		cats := make([]string, len(categories))
+		for i, cat := range categories {
+			cats[i] = "cat:" + cat
+		}
+		if len(cats) == 1 {
+			restrict = append(restrict, cats[0])
+		} else {
+			restrict = append(restrict, "("+strings.Join(cats, " OR ")+")")
+		}
+	}
+	if !c.From.IsZero() || !c.To.IsZero() {
+		restrict = append(restrict, submittedRange(c.From, c.To))
+	}
+	if len(restrict) == 0 {
+		return query
+	}
+	return "(" + query + ") AND " + strings.Join(restrict, " AND ")
 }
 
-func (c *Client) buildURL(query string, limit int) (string, error) {
+// sinceQuery restricts search to papers last updated at or after since.
+func sinceQuery(search string, since time.Time) string {
+	return fmt.Sprintf("(%s) AND %s", search, dateRange("lastUpdatedDate", since, time.Time{}))
+}
+
+// submittedRange returns the submittedDate clause for [from, to) (see
+// dateRange).
+func submittedRange(from, to time.Time) string {
+	return dateRange("submittedDate", from, to)
+}
+
+// dateRange returns the clause restricting a date field to [from, to),
+// either of which may be zero for no bound. arXiv's bounds are inclusive
+// and minute-precise.
+func dateRange(field string, from, to time.Time) string {
+	lo, hi := dateMin, dateMax
+	if !from.IsZero() {
+		lo = from.UTC().Format(dateLayout)
+	}
+	if !to.IsZero() {
+		hi = to.Add(-time.Minute).UTC().Format(dateLayout)
+	}
+	return fmt.Sprintf("%s:[%s TO %s]", field, lo, hi)
+}
+
+// buildURL returns the URL of one page of results for a query in arXiv
+// search syntax (see searchQuery).
+func (c *Client) buildURL(query string, start, limit int) (string, error) {
 	u, err := url.Parse(c.baseURL)
 	if err != nil {
 		return "", err
 	}
 
 	q := u.Query()
-	q.Set("search_query", fmt.Sprintf("all:%s", query))
-	q.Set("start", "0")
+	q.Set("search_query", query)
+	q.Set("start", fmt.Sprintf("%d", start))
 	q.Set("max_results", fmt.Sprintf("%d", limit))
+	if c.Search.SortBy != "" {
+		q.Set("sortBy", c.Search.SortBy)
+	}
+	if c.Search.SortOrder != "" {
+		q.Set("sortOrder", c.Search.SortOrder)
+	}
 	u.RawQuery = q.Encode()
 
 	return u.String(), nil
@@ -95,16 +721,24 @@ func (c *Client) convertEntries(entries []atomEntry) []model.Paper {
 
 	for _, entry := range entries {
 		paper := model.Paper{
-			ID:         extractID(entry.ID),
-			Title:      cleanText(entry.Title),
-			Abstract:   cleanText(entry.Summary),
-			Authors:    extractAuthors(entry.Authors),
-			Categories: extractCategories(entry.Categories),
-			UpdatedAt:  entry.Updated,
-			Comments:   cleanText(entry.Comment),
-			DOI:        strings.TrimSpace(entry.DOI),
-			JournalRef: strings.TrimSpace(entry.JournalRef),
-			Links:      extractLinks(entry.Links),
+			ID:              extractID(entry.ID),
+			Title:           cleanText(entry.Title),
+			Abstract:        cleanText(entry.Summary),
+			Authors:         extractAuthors(entry.Authors),
+			Categories:      extractCategories(entry.Categories, entry.PrimaryCategory.Term),
+			PrimaryCategory: strings.TrimSpace(entry.PrimaryCategory.Term),
+			UpdatedAt:       entry.Updated,
+			Published:       entry.Published,
+			Comments:        cleanText(entry.Comment),
+			DOI:             strings.TrimSpace(entry.DOI),
+			JournalRef:      strings.TrimSpace(entry.JournalRef),
+			Links:           extractLinks(entry.Links),
+			Source:          model.SourceArxiv,
+		}
+		paper.SetExtent()
+		paper.SetTextLinks()
+		if c.StripVersions {
+			paper.StripVersion()
 		}
 		papers = append(papers, paper)
 	}
@@ -112,22 +746,32 @@ func (c *Client) convertEntries(entries []atomEntry) []model.Paper {
 	return papers
 }
 
+// extractLinks classifies the entry's links. Its abstract and PDF links
+// are rewritten to the form model.AbsURL and model.PDFURL give, so they
+// read the same whichever ID style or scheme the feed used.
 func extractLinks(links []atomLink) []model.Link {
 	result := make([]model.Link, 0, len(links))
 	for _, l := range links {
 		if l.Href == "" {
 			continue
 		}
-		linkType := "other"
-		if strings.Contains(l.Type, "pdf") || strings.HasSuffix(l.Href, ".pdf") {
-			linkType = "pdf"
-		} else if l.Rel == "alternate" {
-			linkType = "abstract"
-		} else if strings.Contains(l.Href, "github.com") || strings.Contains(l.Href, "gitlab.com") {
-			linkType = "code"
+		linkType := model.ClassifyLink(l.Href)
+		if strings.Contains(l.Type, "pdf") {
+			linkType = model.LinkPDF
+		} else if l.Rel == "alternate" && linkType != model.LinkPDF {
+			linkType = model.LinkAbstract
+		}
+		href := l.Href
+		if id, err := paperid.Parse(href); err == nil {
+			switch linkType {
+			case model.LinkAbstract:
+				href = model.AbsURL(id.String())
+			case model.LinkPDF:
+				href = model.PDFURL(id.String())
+			}
 		}
 		result = append(result, model.Link{
-			URL:   l.Href,
+			URL:   href,
 			Type:  linkType,
 			Title: l.Title,
 		})
@@ -138,11 +782,7 @@ func extractLinks(links []atomLink) []model.Link {
 // extractID extracts the paper ID from the ArXiv URL.
 // Example: "http://arxiv.org/abs/2301.00001v1" -> "2301.00001v1"
 func extractID(rawID string) string {
-	parts := strings.Split(rawID, "/abs/")
-	if len(parts) == 2 {
-		return parts[1]
-	}
-	return rawID
+	return paperid.Normalize(rawID)
 }
 
 func extractAuthors(authors []atomAuthor) []string {
@@ -155,22 +795,23 @@ func extractAuthors(authors []atomAuthor) []string {
 	return names
 }
 
-func extractCategories(categories []atomCategory) []string {
-	terms := make([]string, 0, len(categories))
+// extractCategories returns the category terms with primary, when set,
+// first. The feed does not always list the primary category first.
+func extractCategories(categories []atomCategory, primary string) []string {
+	terms := make([]string, 0, len(categories)+1)
+	if primary = strings.TrimSpace(primary); primary != "" {
+		terms = append(terms, primary)
+	}
 	for _, c := range categories {
-		if term := strings.TrimSpace(c.Term); term != "" {
+		if term := strings.TrimSpace(c.Term); term != "" && term != primary {
 			terms = append(terms, term)
 		}
 	}
 	return terms
 }
 
+// cleanText normalises feed text: the API serves titles and abstracts
+// with their line breaks, HTML entities and text-mode TeX.
 func cleanText(s string) string {
-	s = strings.TrimSpace(s)
-	s = strings.ReplaceAll(s, "\n", " ")
-	// Collapse multiple spaces into one
-	for strings.Contains(s, "  ") {
-		s = strings.ReplaceAll(s, "  ", " ")
-	}
-	return s
+	return textutil.CleanText(s)
 }
@@ -1,11 +1,35 @@
 package arxiv
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
+// pagedServer serves total numbered papers in pages, reporting the total
+// like arXiv, after latency.
+func pagedServer(total int, latency time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		start, _ := strconv.Atoi(q.Get("start"))
+		size, _ := strconv.Atoi(q.Get("max_results"))
+		time.Sleep(latency)
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><feed xmlns="http://www.w3.org/2005/Atom" xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/">`)
+		fmt.Fprintf(&b, `<opensearch:totalResults>%d</opensearch:totalResults>`, total)
+		for i := start; i < min(start+size, total); i++ {
+			fmt.Fprintf(&b, `<entry><id>http://arxiv.org/abs/2401.%05dv1</id><title>Paper %d</title><updated>2024-01-15T10:00:00Z</updated></entry>`, i, i)
+		}
+		b.WriteString(`</feed>`)
+		w.Write([]byte(b.String()))
+	}))
+}
+
 func BenchmarkFetchPapers(b *testing.B) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/xml")
@@ -17,13 +41,44 @@ func BenchmarkFetchPapers(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, err := client.FetchPapers("test", 10)
+		_, err := client.FetchPapers(context.Background(), "test", 10)
 		if err != nil {
 			b.Fatal(err)
 		}
 	}
 }
 
+// BenchmarkFetchPapersPaged fetches 1000 papers in ten pages from a server
+// that takes 20ms per page, one page at a time and through the worker
+// pool. The rate limit is lowered to 1ms so latency dominates.
+func BenchmarkFetchPapersPaged(b *testing.B) {
+	server := pagedServer(1000, 20*time.Millisecond)
+	defer server.Close()
+
+	for _, bc := range []struct {
+		name       string
+		concurrent bool
+		workers    int
+	}{
+		{"sequential", false, 0},
+		{"workers=2", true, 2},
+		{"workers=4", true, 4},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			client := NewClientWithOptions(server.Client(), server.URL)
+			client.Interval = time.Millisecond
+			client.Concurrent = bc.concurrent
+			client.Workers = bc.workers
+			for i := 0; i < b.N; i++ {
+				papers, err := client.FetchPapers(context.Background(), "test", 1000)
+				if err != nil || len(papers) != 1000 {
+					b.Fatalf("FetchPapers = %d papers, %v", len(papers), err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkExtractID(b *testing.B) {
 	id := "http://arxiv.org/abs/2301.00001v1"
 	for i := 0; i < b.N; i++ {
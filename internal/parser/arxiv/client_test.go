@@ -1,13 +1,38 @@
 package arxiv
 
 import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/providertest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/validation"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
 )
 
 const mockResponse = `<?xml version="1.0" encoding="UTF-8"?>
-<feed xmlns="http://www.w3.org/2005/Atom">
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
   <entry>
     <id>http://arxiv.org/abs/2301.00001v1</id>
     <title>Test Paper Title</title>
@@ -23,9 +48,38 @@ const mockResponse = `<?xml version="1.0" encoding="UTF-8"?>
     </author>
     <category term="cs.AI" />
     <category term="cs.LG" />
+    <arxiv:comment>Accepted at ICML 2024. 12 pages, 4 figures</arxiv:comment>
+    <arxiv:doi>10.1000/test.2023.001</arxiv:doi>
+    <arxiv:journal_ref>Proc. ICML 2024, pp. 1-12</arxiv:journal_ref>
   </entry>
 </feed>`
 
+func TestClient_FetchPapersCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang like a stalled arXiv until the test ends
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClientWithOptions(server.Client(), server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.FetchPapers(ctx, "machine learning", 10)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("FetchPapers returned %v after cancel", elapsed)
+	}
+}
+
 func TestClient_FetchPapers(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify query parameters
@@ -40,7 +94,7 @@ func TestClient_FetchPapers(t *testing.T) {
 
 	client := NewClientWithOptions(server.Client(), server.URL)
 
-	papers, err := client.FetchPapers("machine learning", 10)
+	papers, err := client.FetchPapers(context.Background(), "machine learning", 10)
 	if err != nil {
 		t.Fatalf("FetchPapers failed: %v", err)
 	}
@@ -62,6 +116,81 @@ func TestClient_FetchPapers(t *testing.T) {
 	if len(paper.Categories) != 2 {
 		t.Errorf("expected 2 categories, got %d", len(paper.Categories))
 	}
+	if paper.Comments != "Accepted at ICML 2024. 12 pages, 4 figures" {
+		t.Errorf("unexpected comments %q", paper.Comments)
+	}
+	if paper.DOI != "10.1000/test.2023.001" {
+		t.Errorf("unexpected DOI %q", paper.DOI)
+	}
+	if paper.JournalRef != "Proc. ICML 2024, pp. 1-12" {
+		t.Errorf("unexpected journal ref %q", paper.JournalRef)
+	}
+	if want := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC); !paper.UpdatedAt.Equal(want) {
+		t.Errorf("expected updated %v, got %v", want, paper.UpdatedAt)
+	}
+	if want := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC); !paper.Published.Equal(want) {
+		t.Errorf("expected published %v, got %v", want, paper.Published)
+	}
+}
+
+func TestClient_SearchQueryFieldQueries(t *testing.T) {
+	client := NewClient()
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"machine learning", "all:machine learning"},
+		{"cat:cs.IR", "cat:cs.IR"},
+		{`(all:RAG OR all:"retrieval augmented generation") AND cat:cs.IR`, `(all:RAG OR all:"retrieval augmented generation") AND cat:cs.IR`},
+		{"ratio: a study", "all:ratio: a study"},
+	}
+
+	for _, tc := range tests {
+		if got := client.searchQuery(tc.query, nil); got != tc.expected {
+			t.Errorf("searchQuery(%q) = %q, want %q", tc.query, got, tc.expected)
+		}
+	}
+}
+
+func TestClient_FetchByIDs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if got := query.Get("id_list"); got != "2301.00001,2301.00002" {
+			t.Errorf("id_list = %q, want %q", got, "2301.00001,2301.00002")
+		}
+		if query.Get("search_query") != "" {
+			t.Error("unexpected search_query parameter")
+		}
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL)
+
+	papers, err := client.FetchByIDs(context.Background(), []string{"2301.00001", "2301.00002"})
+	if err != nil {
+		t.Fatalf("FetchByIDs failed: %v", err)
+	}
+	if len(papers) != 1 || papers[0].ID != "2301.00001v1" {
+		t.Errorf("FetchByIDs = %+v, want one paper 2301.00001v1", papers)
+	}
+}
+
+func TestClient_FetchByIDsCanceled(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+	client := NewClientWithOptions(server.Client(), server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.FetchByIDs(ctx, []string{"2301.00001"}); !errors.Is(err, context.Canceled) || calls.Load() != 0 {
+		t.Errorf("err = %v after %d requests, want context.Canceled before any", err, calls.Load())
+	}
 }
 
 func TestExtractID(t *testing.T) {
@@ -82,6 +211,38 @@ func TestExtractID(t *testing.T) {
 	}
 }
 
+func TestExtractLinks_IDStyles(t *testing.T) {
+	tests := []struct {
+		rawID string
+		id    string
+		abs   string
+		pdf   string
+	}{
+		{"http://arxiv.org/abs/2301.00001v2", "2301.00001v2", "https://arxiv.org/abs/2301.00001v2", "https://arxiv.org/pdf/2301.00001v2"},
+		{"http://arxiv.org/abs/cs/0001001v1", "cs/0001001v1", "https://arxiv.org/abs/cs/0001001v1", "https://arxiv.org/pdf/cs/0001001v1"},
+		{"http://arxiv.org/abs/math.GT/0309136v3", "math.GT/0309136v3", "https://arxiv.org/abs/math/0309136v3", "https://arxiv.org/pdf/math/0309136v3"},
+		{"http://arxiv.org/abs/hep-th/9901001", "hep-th/9901001", "https://arxiv.org/abs/hep-th/9901001", "https://arxiv.org/pdf/hep-th/9901001"},
+	}
+	for _, tc := range tests {
+		if got := extractID(tc.rawID); got != tc.id {
+			t.Errorf("extractID(%q) = %q, want %q", tc.rawID, got, tc.id)
+		}
+		pdfHref := strings.Replace(tc.rawID, "/abs/", "/pdf/", 1)
+		links := extractLinks([]atomLink{
+			{Href: tc.rawID, Rel: "alternate", Type: "text/html"},
+			{Href: pdfHref, Rel: "related", Type: "application/pdf", Title: "pdf"},
+		})
+		if len(links) != 2 || links[0].URL != tc.abs || links[1].URL != tc.pdf {
+			t.Errorf("%s: links = %+v, want %s and %s", tc.id, links, tc.abs, tc.pdf)
+		}
+		// Every consumer renders the same links from the ID alone
+		p := model.Paper{ID: tc.id}
+		if p.AbsURL() != tc.abs || p.PDFURL() != tc.pdf {
+			t.Errorf("%s: AbsURL %s, PDFURL %s", tc.id, p.AbsURL(), p.PDFURL())
+		}
+	}
+}
+
 func TestExtractAuthors(t *testing.T) {
 	authors := []atomAuthor{
 		{Name: "John Doe"},
@@ -102,6 +263,31 @@ func TestExtractAuthors(t *testing.T) {
 	}
 }
 
+func TestClient_ArxivFieldsScore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+
+	papers, err := NewClientWithOptions(server.Client(), server.URL).FetchPapers(context.Background(), "test", 10)
+	if err != nil || len(papers) != 1 {
+		t.Fatalf("FetchPapers = %v, %v", papers, err)
+	}
+
+	// The acceptance note and DOI only score if the arxiv: elements decode
+	hits := map[string]int{}
+	for _, d := range filter.NewFilter().Apply(papers)[0].Details {
+		h := filter.ParseDetail(d)
+		hits[h.Rule] = h.Points
+	}
+	if hits[filter.RuleAccepted] != 30 {
+		t.Errorf("expected +30 for the acceptance note, got details %v", hits)
+	}
+	if hits[filter.RulePublished] == 0 {
+		t.Errorf("expected points for the DOI, got details %v", hits)
+	}
+}
+
 func TestExtractCategories(t *testing.T) {
 	categories := []atomCategory{
 		{Term: "cs.AI"},
@@ -109,10 +295,132 @@ func TestExtractCategories(t *testing.T) {
 		{Term: ""},
 	}
 
-	result := extractCategories(categories)
+	tests := []struct {
+		primary  string
+		expected []string
+	}{
+		{"", []string{"cs.AI", "cs.LG"}},
+		{"cs.LG", []string{"cs.LG", "cs.AI"}},
+		{"stat.ML", []string{"stat.ML", "cs.AI", "cs.LG"}},
+	}
 
-	if len(result) != 2 {
-		t.Errorf("expected 2 categories, got %d", len(result))
+	for _, tc := range tests {
+		if result := extractCategories(categories, tc.primary); !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("primary %q: got %q, want %q", tc.primary, result, tc.expected)
+		}
+	}
+}
+
+func TestClient_PrimaryCategory(t *testing.T) {
+	// The primary category is not the first <category> element
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <entry>
+    <id>http://arxiv.org/abs/2301.00002v1</id>
+    <updated>2023-01-15T10:00:00Z</updated>
+    <title>Cross-listed Paper</title>
+    <summary>An abstract.</summary>
+    <author><name>John Doe</name></author>
+    <arxiv:primary_category term="cs.CL" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="cs.AI" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="cs.CL" scheme="http://arxiv.org/schemas/atom"/>
+  </entry>
+</feed>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL)
+	papers, err := client.FetchPapers(context.Background(), "parsing", 10)
+	if err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+	if len(papers) != 1 {
+		t.Fatalf("expected 1 paper, got %d", len(papers))
+	}
+	if papers[0].PrimaryCategory != "cs.CL" {
+		t.Errorf("expected primary category cs.CL, got %q", papers[0].PrimaryCategory)
+	}
+	if want := []string{"cs.CL", "cs.AI"}; !reflect.DeepEqual(papers[0].Categories, want) {
+		t.Errorf("expected categories %q, got %q", want, papers[0].Categories)
+	}
+}
+
+func TestClient_TextLinks(t *testing.T) {
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <entry>
+    <id>http://arxiv.org/abs/2301.00003v1</id>
+    <updated>2023-01-15T10:00:00Z</updated>
+    <title>Paper With Code</title>
+    <summary>We propose a method. Code is available at
+    https://github.com/org/repo. Models: https://github.com/org/models.)</summary>
+    <author><name>John Doe</name></author>
+    <link href="http://arxiv.org/abs/2301.00003v1" rel="alternate" type="text/html"/>
+    <link title="pdf" href="http://arxiv.org/pdf/2301.00003v1" rel="related" type="application/pdf"/>
+    <link href="https://github.com/org/models" rel="related"/>
+    <arxiv:comment>Data at https://zenodo.org/record/42</arxiv:comment>
+  </entry>
+</feed>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	papers, err := NewClientWithOptions(server.Client(), server.URL).FetchPapers(context.Background(), "code", 10)
+	if err != nil || len(papers) != 1 {
+		t.Fatalf("FetchPapers = %d papers, %v", len(papers), err)
+	}
+	// arXiv links are rewritten to https; the feed's own repository link is
+	// kept as it is, not repeated
+	expected := []model.Link{
+		{URL: "https://arxiv.org/abs/2301.00003v1", Type: model.LinkAbstract},
+		{URL: "https://arxiv.org/pdf/2301.00003v1", Type: model.LinkPDF, Title: "pdf"},
+		{URL: "https://github.com/org/models", Type: model.LinkCode},
+		{URL: "https://github.com/org/repo", Type: model.LinkCode, FoundIn: model.FoundInAbstract},
+		{URL: "https://zenodo.org/record/42", Type: model.LinkData, FoundIn: model.FoundInComments},
+	}
+	if !reflect.DeepEqual(papers[0].Links, expected) {
+		t.Errorf("links = %+v\nwant %+v", papers[0].Links, expected)
+	}
+}
+
+func TestClient_StripVersions(t *testing.T) {
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><id>http://arxiv.org/abs/2301.00001v3</id><title>New Style</title><summary>A.</summary></entry>
+  <entry><id>http://arxiv.org/abs/cs/0001001v2</id><title>Old Style</title><summary>B.</summary></entry>
+  <entry><id>http://arxiv.org/abs/hep-th/9901001</id><title>No Version</title><summary>C.</summary></entry>
+</feed>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		strip bool
+		ids   []string
+	}{
+		{false, []string{"2301.00001v3", "cs/0001001v2", "hep-th/9901001"}},
+		{true, []string{"2301.00001", "cs/0001001", "hep-th/9901001"}},
+	}
+	for _, tc := range tests {
+		client := NewClientWithOptions(server.Client(), server.URL)
+		client.StripVersions = tc.strip
+		papers, err := client.FetchPapers(context.Background(), "versions", 10)
+		if err != nil || len(papers) != 3 {
+			t.Fatalf("FetchPapers = %d papers, %v", len(papers), err)
+		}
+		for i, p := range papers {
+			if p.ID != tc.ids[i] {
+				t.Errorf("StripVersions %v: ID %q, want %q", tc.strip, p.ID, tc.ids[i])
+			}
+			// The version is kept either way
+			if want := []int{3, 2, 1}[i]; p.Version() != want {
+				t.Errorf("StripVersions %v: %s version %d, want %d", tc.strip, p.ID, p.Version(), want)
+			}
+		}
 	}
 }
 
@@ -125,6 +433,7 @@ func TestCleanText(t *testing.T) {
 		{"hello\nworld", "hello world"},
 		{"hello  world", "hello world"},
 		{"  multi\n  line\n  text  ", "multi line text"},
+		{"\\textit{Chain-of-Thought} Prompting for Q&amp;A in $\\mathbb{R}^d$", "Chain-of-Thought Prompting for Q&A in $\\mathbb{R}^d$"},
 	}
 
 	for _, tc := range tests {
@@ -134,3 +443,1182 @@ func TestCleanText(t *testing.T) {
 		}
 	}
 }
+
+// conformanceServer serves numbered entries honouring start and
+// max_results, and none for queries mentioning "nomatch".
+func conformanceServer(t *testing.T, total int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		start, _ := strconv.Atoi(q.Get("start"))
+		size, _ := strconv.Atoi(q.Get("max_results"))
+		n := total
+		if strings.Contains(q.Get("search_query"), "nomatch") {
+			n = 0
+		}
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><feed xmlns="http://www.w3.org/2005/Atom">`)
+		for i := start; i < min(start+size, n); i++ {
+			fmt.Fprintf(&b, `<entry><id>http://arxiv.org/abs/2401.%05dv1</id><title>Paper %d</title>
+<summary>Abstract.</summary><updated>2024-01-15T10:00:00Z</updated>
+<author><name>A. Author</name></author><category term="cs.CL" /></entry>`, i, i)
+		}
+		b.WriteString(`</feed>`)
+		w.Write([]byte(b.String()))
+	}))
+}
+
+func TestClient_Conformance(t *testing.T) {
+	server := conformanceServer(t, 5)
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.Interval = time.Nanosecond
+	providertest.Run(t, client, providertest.Config{
+		Query:        "machine learning",
+		NoMatchQuery: "nomatch",
+		Name:         model.SourceArxiv,
+	})
+}
+
+func TestClient_DuplicateListings(t *testing.T) {
+	// A cross-listing repeats 00001 at an older version, and 00002 appears
+	// twice at one version, the second listing updated later
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><id>http://arxiv.org/abs/2401.00001v2</id><title>Primary</title><updated>2024-01-10T00:00:00Z</updated></entry>
+  <entry><id>http://arxiv.org/abs/2401.00002v1</id><title>First listing</title><updated>2024-01-05T00:00:00Z</updated></entry>
+  <entry><id>http://arxiv.org/abs/2401.00001v1</id><title>Cross-list</title><updated>2024-01-12T00:00:00Z</updated></entry>
+  <entry><id>http://arxiv.org/abs/2401.00002v1</id><title>Second listing</title><updated>2024-01-06T00:00:00Z</updated></entry>
+  <entry><id>http://arxiv.org/abs/2401.00003v1</id><title>Single</title><updated>2024-01-07T00:00:00Z</updated></entry>
+</feed>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	for _, strip := range []bool{false, true} {
+		client := NewClientWithOptions(server.Client(), server.URL)
+		client.StripVersions = strip
+		result, err := client.FetchResult(context.Background(), "dups", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, p := range result.Papers {
+			titles = append(titles, p.Title)
+		}
+		if want := []string{"Primary", "Second listing", "Single"}; !reflect.DeepEqual(titles, want) {
+			t.Errorf("StripVersions %v: kept %v, want %v", strip, titles, want)
+		}
+		if result.Duplicates != 2 {
+			t.Errorf("StripVersions %v: Duplicates = %d, want 2", strip, result.Duplicates)
+		}
+	}
+}
+
+func TestClient_FetchPapersPages(t *testing.T) {
+	var mu sync.Mutex
+	var starts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		mu.Lock()
+		starts = append(starts, q.Get("start"))
+		shift := len(starts) > 1
+		mu.Unlock()
+
+		// A paper announced after the first page pushes the results down
+		// by one, so the next page repeats the last entry
+		ids := []string{"2401.00001v1", "2401.00002v1", "2401.00003v1", "2401.00004v1", "2401.00005v1", "2401.00006v1"}
+		if shift {
+			ids = append([]string{"2401.09999v1"}, ids...)
+		}
+		start, _ := strconv.Atoi(q.Get("start"))
+		size, _ := strconv.Atoi(q.Get("max_results"))
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><feed xmlns="http://www.w3.org/2005/Atom">`)
+		for _, id := range ids[min(start, len(ids)):min(start+size, len(ids))] {
+			fmt.Fprintf(&b, `<entry><id>http://arxiv.org/abs/%s</id><title>T</title><updated>2024-01-15T10:00:00Z</updated></entry>`, id)
+		}
+		b.WriteString(`</feed>`)
+		w.Write([]byte(b.String()))
+	}))
+	defer server.Close()
+
+	fake := clock.NewFake(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.PageSize = 2
+	client.Clock = fake
+
+	done := make(chan []model.Paper)
+	go func() {
+		papers, err := client.FetchPapers(context.Background(), "llm", 5)
+		if err != nil {
+			t.Errorf("FetchPapers failed: %v", err)
+		}
+		done <- papers
+	}()
+	// Each later page waits out the default delay
+	for page := 2; page <= 3; page++ {
+		fake.BlockUntil(1)
+		fake.Advance(3 * time.Second)
+	}
+	papers := <-done
+
+	var ids []string
+	for _, p := range papers {
+		ids = append(ids, p.ID)
+	}
+	want := []string{"2401.00001v1", "2401.00002v1", "2401.00003v1", "2401.00004v1", "2401.00005v1"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("expected %v, got %v", want, ids)
+	}
+	if wantStarts := []string{"0", "2", "4"}; !reflect.DeepEqual(starts, wantStarts) {
+		t.Errorf("expected page starts %v, got %v", wantStarts, starts)
+	}
+}
+
+func TestClient_FetchPapersIter(t *testing.T) {
+	var requests atomic.Int32
+	inner := pagedServer(9, 0)
+	defer inner.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Redirect(w, r, inner.URL+"?"+r.URL.RawQuery, http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.PageSize = 2
+	client.Interval = time.Millisecond
+
+	want, err := client.FetchPapers(context.Background(), "llm", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests.Store(0)
+	var got []model.Paper
+	for p, err := range client.FetchPapersIter(context.Background(), "llm", 7) {
+		if err != nil {
+			t.Fatalf("FetchPapersIter failed: %v", err)
+		}
+		got = append(got, p)
+	}
+	if !reflect.DeepEqual(got, want) || requests.Load() != 4 {
+		t.Errorf("iterated %d papers in %d requests, want FetchPapers' %d in 4", len(got), requests.Load(), len(want))
+	}
+
+	// Pages are only requested as the caller asks for more
+	requests.Store(0)
+	for range client.FetchPapersIter(context.Background(), "llm", 7) {
+		break
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%d requests for one paper, want 1", n)
+	}
+}
+
+func TestClient_FetchAllPages(t *testing.T) {
+	type call struct{ page, fetched, total int }
+	tests := []struct {
+		name    string
+		matches int
+		total   int
+		calls   []call
+	}{
+		{"limit reached", 9, 5, []call{{0, 2, 5}, {1, 4, 5}, {2, 5, 5}}},
+		// The feed reports 3 matches, and the short second page ends it
+		{"fewer matches", 3, 10, []call{{0, 2, 3}, {1, 3, 3}}},
+	}
+
+	for _, tc := range tests {
+		server := pagedServer(tc.matches, 0)
+		client := NewClientWithOptions(server.Client(), server.URL)
+		client.PageSize = 2
+		client.Interval = time.Nanosecond
+
+		var calls []call
+		result, err := client.FetchAllPages(context.Background(), "llm", tc.total, func(page, fetched, total int) {
+			calls = append(calls, call{page, fetched, total})
+		})
+		papers := result.Papers
+		server.Close()
+		if err != nil || len(papers) != min(tc.matches, tc.total) {
+			t.Errorf("%s: FetchAllPages = %d papers, %v", tc.name, len(papers), err)
+		}
+		if !reflect.DeepEqual(calls, tc.calls) {
+			t.Errorf("%s: callbacks %v, want %v", tc.name, calls, tc.calls)
+		}
+	}
+}
+
+func TestClient_FetchAllPagesCallbackPanic(t *testing.T) {
+	var requests atomic.Int32
+	inner := pagedServer(9, 0)
+	defer inner.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Redirect(w, r, inner.URL+"?"+r.URL.RawQuery, http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.PageSize = 2
+	client.Interval = time.Nanosecond
+	result, err := client.FetchAllPages(context.Background(), "llm", 8, func(page, fetched, total int) {
+		if page == 1 {
+			panic("progress bar broke")
+		}
+	})
+	papers := result.Papers
+	if err == nil || !strings.Contains(err.Error(), "progress bar broke") || papers != nil {
+		t.Errorf("FetchAllPages = %d papers, %v, want the panic as an error", len(papers), err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("%d requests, want the harvest to stop after the page that panicked", n)
+	}
+}
+
+func TestClient_FetchPapersIterCancelled(t *testing.T) {
+	server := pagedServer(1000, 0)
+	defer server.Close()
+
+	// The default 3s rate limit holds every page after the first
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.PageSize = 10
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	started := time.Now()
+	var last error
+	for _, err := range client.FetchPapersIter(ctx, "llm", 1000) {
+		if err != nil {
+			last = err
+			continue
+		}
+		if n++; n == 10 {
+			// The next page is waiting for its slot when the caller gives up
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				cancel()
+			}()
+		}
+	}
+	if !errors.Is(last, context.Canceled) || n != 10 {
+		t.Errorf("iteration ended after %d papers with %v, want 10 and context.Canceled", n, last)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("cancelled iteration took %v, want it to stop promptly", elapsed)
+	}
+}
+
+func TestClient_ConcurrentPages(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	var mu sync.Mutex
+	var starts []int
+	inner := pagedServer(9, 0)
+	defer inner.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		mu.Lock()
+		starts = append(starts, start)
+		mu.Unlock()
+		// Earlier pages answer last, so arrival order is not result order
+		time.Sleep(time.Duration(10-start) * 3 * time.Millisecond)
+		resp, err := inner.Client().Get(inner.URL + "?" + r.URL.RawQuery)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(w, resp.Body)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.PageSize = 2
+	client.Interval = time.Millisecond
+	client.Concurrent = true
+	client.Workers = 3
+
+	// The total of 9 keeps the pool from asking past the end
+	papers, err := client.FetchPapers(context.Background(), "llm", 20)
+	if err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+	var ids []string
+	for _, p := range papers {
+		ids = append(ids, p.ID)
+	}
+	want := []string{"2401.00000v1", "2401.00001v1", "2401.00002v1", "2401.00003v1", "2401.00004v1", "2401.00005v1", "2401.00006v1", "2401.00007v1", "2401.00008v1"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("papers %v, want %v", ids, want)
+	}
+	sort.Ints(starts)
+	if wantStarts := []int{0, 2, 4, 6, 8}; !reflect.DeepEqual(starts, wantStarts) {
+		t.Errorf("page starts %v, want %v", starts, wantStarts)
+	}
+	if p := peak.Load(); p < 2 || p > 3 {
+		t.Errorf("peak of %d requests in flight, want 2 to 3 workers", p)
+	}
+}
+
+func TestClient_ConcurrentPageError(t *testing.T) {
+	inner := pagedServer(100, 0)
+	defer inner.Close()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Query().Get("start") == "20" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		http.Redirect(w, r, inner.URL+"?"+r.URL.RawQuery, http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.PageSize = 10
+	client.Interval = 5 * time.Millisecond
+	client.Concurrent = true
+
+	_, err := client.FetchPapers(context.Background(), "llm", 100)
+	if err == nil || !strings.Contains(err.Error(), "page 2 (start 20)") || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("FetchPapers error %v, want page 2's 503", err)
+	}
+	// The failure cancels the pages not yet started
+	if n := requests.Load(); n >= 10 {
+		t.Errorf("%d requests, want the remaining pages cancelled", n)
+	}
+}
+
+func TestClient_FetchResult(t *testing.T) {
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/">
+  <opensearch:totalResults>12431</opensearch:totalResults>
+  <opensearch:startIndex>0</opensearch:startIndex>
+  <opensearch:itemsPerPage>2</opensearch:itemsPerPage>
+  <entry>
+    <id>http://arxiv.org/abs/2401.00001v1</id>
+    <updated>2024-01-15T10:00:00Z</updated>
+    <title>First</title>
+    <author><name>John Doe</name></author>
+  </entry>
+  <entry>
+    <id>http://arxiv.org/abs/2401.00002v1</id>
+    <updated>2024-01-15T10:00:00Z</updated>
+    <title>Second</title>
+    <author><name>John Doe</name></author>
+  </entry>
+</feed>`
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.Interval = time.Millisecond
+	result, err := client.FetchResult(context.Background(), "transformers", 2)
+	if err != nil {
+		t.Fatalf("FetchResult failed: %v", err)
+	}
+	if len(result.Papers) != 2 || result.Total != 12431 || result.Start != 0 || result.PerPage != 2 {
+		t.Errorf("result = %d papers, total %d, start %d, per page %d; want 2 of 12431 from 0, 2 per page",
+			len(result.Papers), result.Total, result.Start, result.PerPage)
+	}
+	if requests != 1 {
+		t.Errorf("made %d requests, want 1", requests)
+	}
+
+	// FetchPapers keeps returning only the papers
+	papers, err := client.FetchPapers(context.Background(), "transformers", 2)
+	if err != nil || !reflect.DeepEqual(papers, result.Papers) {
+		t.Errorf("FetchPapers = %v, %v; want the same papers", papers, err)
+	}
+}
+
+// errorFeed is arXiv's reply to a query it cannot parse: HTTP 200 with a
+// single entry describing the problem.
+const errorFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="html">ArXiv Query: search_query=&amp;id_list=1234.12345&amp;start=0&amp;max_results=10</title>
+  <id>http://arxiv.org/api/kvDd7Kd7Kf1rSP6tKbfnzKqSLPI</id>
+  <opensearch:totalResults xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/">1</opensearch:totalResults>
+  <opensearch:startIndex xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/">0</opensearch:startIndex>
+  <opensearch:itemsPerPage xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/">1</opensearch:itemsPerPage>
+  <entry>
+    <id>http://arxiv.org/api/errors#incorrect_id_format_for_1234.12345</id>
+    <title>Error</title>
+    <summary>incorrect id format for 1234.12345</summary>
+    <updated>2007-10-12T00:00:00-04:00</updated>
+    <link href="http://arxiv.org/api/errors#incorrect_id_format_for_1234.12345" rel="alternate" type="text/html"/>
+    <author>
+      <name>arXiv api core</name>
+    </author>
+  </entry>
+</feed>`
+
+const emptyFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="html">ArXiv Query: search_query=all:zzzz&amp;id_list=&amp;start=0&amp;max_results=10</title>
+  <opensearch:totalResults xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/">0</opensearch:totalResults>
+</feed>`
+
+func TestClient_ErrorFeed(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(c *Client) (int, error)
+	}{
+		{"FetchPapers", func(c *Client) (int, error) {
+			papers, err := c.FetchPapers(context.Background(), "id:1234.12345", 10)
+			return len(papers), err
+		}},
+		{"FetchByIDs", func(c *Client) (int, error) {
+			papers, err := c.FetchByIDs(context.Background(), []string{"1234.12345"})
+			return len(papers), err
+		}},
+		{"CountPapers", func(c *Client) (int, error) {
+			return c.CountPapers(context.Background(), "id:1234.12345", time.Time{}, time.Time{})
+		}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(errorFeed))
+	}))
+	defer server.Close()
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.Interval = time.Millisecond
+
+	for _, tc := range tests {
+		n, err := tc.call(client)
+		if !errors.Is(err, ErrBadQuery) || !strings.Contains(err.Error(), "incorrect id format for 1234.12345") {
+			t.Errorf("%s: error %v, want ErrBadQuery with arXiv's message", tc.name, err)
+		}
+		if n != 0 {
+			t.Errorf("%s: got %d, want nothing from an error feed", tc.name, n)
+		}
+	}
+}
+
+func TestClient_ReencodedFeeds(t *testing.T) {
+	tests := []struct {
+		fixture string
+		title   string
+		author  string
+	}{
+		{"bom.xml", "Café retrieval", "Jürgen Müller"},
+		{"latin1.xml", "Café retrieval", "Jürgen Müller"},
+		{"invalid-utf8.xml", "Broken \ufffd\ufffd title", "A. Author"},
+	}
+	for _, tc := range tests {
+		feed, err := os.ReadFile(filepath.Join("testdata", tc.fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Sniffing takes a BOM for plain text
+			w.Header().Set("Content-Type", "application/atom+xml")
+			w.Write(feed)
+		}))
+		client := NewClientWithOptions(server.Client(), server.URL)
+		papers, err := client.FetchPapers(context.Background(), "encoding", 10)
+		server.Close()
+		if err != nil || len(papers) != 1 {
+			t.Errorf("%s: %d papers, %v", tc.fixture, len(papers), err)
+			continue
+		}
+		p := papers[0]
+		if p.Title != tc.title || p.Authors[0] != tc.author {
+			t.Errorf("%s: title %q by %q, want %q by %q", tc.fixture, p.Title, p.Authors[0], tc.title, tc.author)
+		}
+		for _, s := range []string{p.ID, p.Title, p.Abstract, p.Authors[0]} {
+			if !utf8.ValidString(s) {
+				t.Errorf("%s: invalid UTF-8 in %q", tc.fixture, s)
+			}
+		}
+		if err := validation.ValidatePaper(p); err != nil {
+			t.Errorf("%s: %v", tc.fixture, err)
+		}
+	}
+}
+
+func TestClient_DecodeError(t *testing.T) {
+	// The second entry's title is never closed
+	payload := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><id>http://arxiv.org/abs/2301.00001v1</id><title>Fine</title></entry>
+  <entry><id>http://arxiv.org/abs/2301.00002v1</id><title>Broken</entry>
+</feed>`
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+	client := NewClientWithOptions(&http.Client{Transport: &httpclient.Transport{Base: server.Client().Transport}}, server.URL)
+
+	_, err := client.FetchPapers(context.Background(), "llm", 10)
+	var de *DecodeError
+	if !errors.As(err, &de) || !errors.Is(err, ErrDecode) {
+		t.Fatalf("err = %v, want a DecodeError", err)
+	}
+	var syntax *xml.SyntaxError
+	if !errors.As(err, &syntax) {
+		t.Errorf("err = %v, want it to wrap the *xml.SyntaxError", err)
+	}
+	if !strings.HasSuffix(de.Snippet, "<title>Broken</entry>") || len(de.Snippet) > snippetLen {
+		t.Errorf("snippet = %q, want the payload up to the unmatched tag", de.Snippet)
+	}
+	if de.Offset != int64(strings.Index(payload, "Broken</entry>")+len("Broken</entry>")) {
+		t.Errorf("offset = %d", de.Offset)
+	}
+	if parser.Temporary(err) || calls.Load() != 1 {
+		t.Errorf("decode error: temporary %v after %d calls, want not retried", parser.Temporary(err), calls.Load())
+	}
+}
+
+func TestClient_ResponseTooLarge(t *testing.T) {
+	// A feed padded past the limit, whose entry would decode fine
+	padding := strings.Repeat("<!-- padding -->", 1000)
+	tests := []struct {
+		name    string
+		chunked bool // Without a Content-Length, so the limit is hit while decoding
+	}{{"content length", false}, {"chunked", true}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/atom+xml")
+				if tc.chunked {
+					w.Write([]byte(padding[:100]))
+					w.(http.Flusher).Flush()
+					w.Write([]byte(padding[100:]))
+				} else {
+					w.Header().Set("Content-Length", strconv.Itoa(len(padding)+len(mockResponse)))
+					w.Write([]byte(padding))
+				}
+				w.Write([]byte(mockResponse))
+			}))
+			defer server.Close()
+			client := New(WithHTTPClient(server.Client()), WithBaseURL(server.URL), WithMaxResponseSize(4096))
+
+			_, err := client.FetchPapers(context.Background(), "llm", 10)
+			if !errors.Is(err, ErrResponseTooLarge) || !errors.Is(err, ErrDecode) {
+				t.Errorf("err = %v, want ErrResponseTooLarge", err)
+			}
+		})
+	}
+
+	t.Run("within the limit", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(mockResponse))
+		}))
+		defer server.Close()
+		client := New(WithHTTPClient(server.Client()), WithBaseURL(server.URL), WithMaxResponseSize(int64(len(mockResponse))))
+		if _, err := client.FetchPapers(context.Background(), "llm", 10); err != nil {
+			t.Errorf("a body of exactly the limit failed: %v", err)
+		}
+	})
+}
+
+func TestClient_NotXML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><body>Service temporarily unavailable</body></html>"))
+	}))
+	defer server.Close()
+	client := NewClientWithOptions(server.Client(), server.URL)
+
+	_, err := client.FetchPapers(context.Background(), "llm", 10)
+	if !errors.Is(err, ErrNotXML) || !errors.Is(err, ErrDecode) {
+		t.Errorf("err = %v, want ErrNotXML", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "text/html") {
+		t.Errorf("err = %v, want it to name the Content-Type", err)
+	}
+}
+
+func TestIsXMLType(t *testing.T) {
+	tests := map[string]bool{
+		"":                                    true,
+		"application/atom+xml; charset=UTF-8": true,
+		"application/xml":                     true,
+		"text/xml; charset=utf-8":             true,
+		"text/html; charset=utf-8":            false,
+		"application/json":                    false,
+		"text/plain":                          false,
+		"not a type/":                         false,
+	}
+	for ct, want := range tests {
+		if got := isXMLType(ct); got != want {
+			t.Errorf("isXMLType(%q) = %v, want %v", ct, got, want)
+		}
+	}
+}
+
+func TestClient_BadQueryNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "bad query", http.StatusBadRequest)
+	}))
+	defer server.Close()
+	// The transport retries 429s and 5xx responses, with no wait here
+	transport := &httpclient.Transport{Base: server.Client().Transport, Backoff: time.Nanosecond, Jitter: -1}
+	client := NewClientWithOptions(&http.Client{Transport: transport}, server.URL)
+
+	_, err := client.FetchPapers(context.Background(), "ti:(", 10)
+	if !errors.Is(err, ErrBadQuery) || calls.Load() != 1 {
+		t.Errorf("err = %v after %d calls, want ErrBadQuery after 1", err, calls.Load())
+	}
+}
+
+func TestClient_StatusErrors(t *testing.T) {
+	tests := []struct {
+		status     int
+		retryAfter string
+		expected   error
+		wantAfter  time.Duration
+	}{
+		{http.StatusBadRequest, "", ErrBadQuery, 0},
+		{http.StatusTooManyRequests, "30", ErrRateLimited, 30 * time.Second},
+		{http.StatusServiceUnavailable, "", ErrUnavailable, 0},
+		{http.StatusInternalServerError, "", ErrUnavailable, 0},
+	}
+	sentinels := []error{ErrBadQuery, ErrRateLimited, ErrUnavailable, ErrDecode}
+
+	for _, tc := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tc.retryAfter != "" {
+				w.Header().Set("Retry-After", tc.retryAfter)
+			}
+			http.Error(w, http.StatusText(tc.status), tc.status)
+		}))
+		client := NewClientWithOptions(server.Client(), server.URL)
+
+		_, err := client.FetchPapers(context.Background(), "llm", 10)
+		server.Close()
+		for _, sentinel := range sentinels {
+			if got := errors.Is(err, sentinel); got != (sentinel == tc.expected) {
+				t.Errorf("%d: errors.Is(%v, %v) = %v", tc.status, err, sentinel, got)
+			}
+		}
+		var se *StatusError
+		if !errors.As(err, &se) || se.StatusCode != tc.status || se.RetryAfter != tc.wantAfter {
+			t.Errorf("%d: errors.As = %+v, want the status and Retry-After %v", tc.status, se, tc.wantAfter)
+		}
+		if d, _ := parser.RetryAfter(err); d != tc.wantAfter {
+			t.Errorf("%d: parser.RetryAfter = %v, want %v", tc.status, d, tc.wantAfter)
+		}
+		if got := parser.Temporary(err); got != (tc.expected != ErrBadQuery) {
+			t.Errorf("%d: parser.Temporary = %v", tc.status, got)
+		}
+	}
+
+	// No response at all is the source's trouble too, unless the caller
+	// gave up
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	client := NewClientWithOptions(server.Client(), server.URL)
+	server.Close()
+	if _, err := client.FetchPapers(context.Background(), "llm", 10); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("closed server: %v, want ErrUnavailable", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.FetchPapers(ctx, "llm", 10); errors.Is(err, ErrUnavailable) || !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled fetch: %v, want context.Canceled only", err)
+	}
+}
+
+func TestClient_EmptyFeed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(emptyFeed))
+	}))
+	defer server.Close()
+	client := NewClientWithOptions(server.Client(), server.URL)
+
+	papers, err := client.FetchPapers(context.Background(), "zzzz", 10)
+	if err != nil || papers == nil || len(papers) != 0 {
+		t.Errorf("FetchPapers = %v, %v; want an empty result and no error", papers, err)
+	}
+}
+
+func TestClient_UserAgent(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.UserAgent())
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+
+	// A plain http.Client adds no User-Agent of its own, so the client must
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.Interval = time.Millisecond
+	client.FetchPapers(context.Background(), "test", 1)
+	client.UserAgent = "genesis-pipeline/1.0 (+mailto:ops@example.org)"
+	client.FetchPapers(context.Background(), "test", 1)
+
+	want := []string{version.UserAgent(), "genesis-pipeline/1.0 (+mailto:ops@example.org)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("User-Agents = %q, want %q", got, want)
+	}
+
+	built, err := parser.Default.New(model.SourceArxiv, parser.Options{UserAgent: "custom/1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := built.(*Client); c.UserAgent != "custom/1.0" || !strings.HasPrefix(c.baseURL, "https://") {
+		t.Errorf("registry client = %q at %q, want the option's User-Agent over HTTPS", c.UserAgent, c.baseURL)
+	}
+}
+
+func TestClient_CacheTTL(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+
+	fake := clock.NewFake(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.Clock = fake
+	client.CacheTTL = 10 * time.Minute
+
+	first, err := client.FetchPapers(context.Background(), "test", 1)
+	if err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+
+	// Within the TTL the same query is served from memory, concurrently
+	// and without waiting out the rate limit
+	fake.Advance(5 * time.Minute)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			papers, err := client.FetchPapers(context.Background(), "test", 1)
+			if err != nil || !reflect.DeepEqual(papers, first) {
+				t.Errorf("cached FetchPapers = %v, %v; want the first response", papers, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%d requests within the TTL, want 1", n)
+	}
+	if got, want := client.CacheStats(), (parser.CacheStats{Hits: 8, Misses: 1, Entries: 1}); got != want {
+		t.Errorf("CacheStats = %+v, want %+v", got, want)
+	}
+
+	// A different URL and an expired entry both go to the server
+	if _, err := client.FetchPapers(context.Background(), "other", 1); err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+	fake.Advance(10 * time.Minute)
+	if _, err := client.FetchPapers(context.Background(), "test", 1); err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+	// Storing the refreshed entry dropped the expired "other"
+	if got := client.CacheStats(); got.Misses != 3 || got.Entries != 1 {
+		t.Errorf("CacheStats = %+v, want 3 misses and 1 entry", got)
+	}
+}
+
+func TestClient_RateLimitsAcrossCalls(t *testing.T) {
+	server := conformanceServer(t, 1)
+	defer server.Close()
+
+	fake := clock.NewFake(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.Clock = fake
+
+	// The first request goes out at once
+	if _, err := client.FetchPapers(context.Background(), "first", 1); err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.FetchPapers(context.Background(), "second", 1)
+		done <- err
+	}()
+	fake.BlockUntil(1)
+	fake.Advance(2999 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("second call did not wait for the 3s interval")
+	case <-time.After(20 * time.Millisecond):
+	}
+	fake.Advance(time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+}
+
+func TestClient_RateLimitHonoursCancel(t *testing.T) {
+	server := conformanceServer(t, 1)
+	defer server.Close()
+
+	fake := clock.NewFake(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.Clock = fake
+	if _, err := client.FetchPapers(context.Background(), "first", 1); err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.FetchPapers(ctx, "second", 1)
+		done <- err
+	}()
+	fake.BlockUntil(1)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancelled call kept waiting for a slot")
+	}
+}
+
+func TestClient_CountPapers(t *testing.T) {
+	var got url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/">
+  <opensearch:totalResults>1234</opensearch:totalResults>
+  <entry><id>http://arxiv.org/abs/2401.00001v1</id><title>T</title></entry>
+</feed>`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	n, err := client.CountPapers(context.Background(), "reasoning", from, from.AddDate(0, 0, 30))
+	if err != nil {
+		t.Fatalf("CountPapers failed: %v", err)
+	}
+	if n != 1234 {
+		t.Errorf("expected 1234 matches, got %d", n)
+	}
+	if q := got.Get("search_query"); q != "(all:reasoning) AND submittedDate:[202403010000 TO 202403302359]" {
+		t.Errorf("unexpected search_query %q", q)
+	}
+	if got.Get("max_results") != "1" {
+		t.Errorf("expected max_results=1, got %q", got.Get("max_results"))
+	}
+}
+
+// flakyServer fails with the given statuses in turn, 0 dropping the
+// connection, and then serves mockResponse.
+func flakyServer(t *testing.T, failures ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n > len(failures) {
+			w.Write([]byte(mockResponse))
+			return
+		}
+		if failures[n-1] != 0 {
+			w.WriteHeader(failures[n-1])
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		conn.Close()
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func retryingClient(server *httptest.Server, backoff time.Duration) *Client {
+	transport := &httpclient.Transport{Base: server.Client().Transport, Backoff: backoff}
+	client := NewClientWithOptions(&http.Client{Transport: transport}, server.URL)
+	client.Interval = time.Nanosecond
+	return client
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		timeout    time.Duration // Of the request context (default: none)
+		waits      []time.Duration
+		wantErr    bool
+	}{
+		{name: "seconds", retryAfter: "2", waits: []time.Duration{2 * time.Second}},
+		{name: "long wait capped", retryAfter: "3600", waits: []time.Duration{time.Minute}},
+		{name: "past the deadline", retryAfter: "30", timeout: 10 * time.Second, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", tc.retryAfter)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(mockResponse))
+		}))
+		// The backoff alone would wait an hour, so only Retry-After lets
+		// the retry through
+		clk := clock.NewFake(time.Now())
+		transport := &httpclient.Transport{Base: server.Client().Transport, Backoff: time.Hour, Jitter: -1, Clock: clk}
+		client := NewClientWithOptions(&http.Client{Transport: transport}, server.URL)
+		client.Interval = time.Nanosecond
+
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if tc.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+		}
+		go func() {
+			for _, d := range tc.waits {
+				clk.BlockUntil(1)
+				clk.Advance(d)
+			}
+		}()
+
+		_, err := client.FetchPapers(ctx, "machine learning", 10)
+		cancel()
+		server.Close()
+		if tc.wantErr {
+			if !errors.Is(err, ErrUnavailable) || calls.Load() != 1 {
+				t.Errorf("%s: err = %v after %d calls, want ErrUnavailable after 1", tc.name, err, calls.Load())
+			}
+			continue
+		}
+		if err != nil || calls.Load() != 2 {
+			t.Errorf("%s: err = %v after %d calls, want success after 2", tc.name, err, calls.Load())
+		}
+	}
+}
+
+func TestClient_RetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    []int
+		maxAttempts int
+		wantErr     bool
+		wantCalls   int32
+	}{
+		{name: "fails twice then succeeds", failures: []int{http.StatusServiceUnavailable, 0}, wantCalls: 3},
+		{name: "5xx until out of attempts", failures: []int{500, 502, 503}, maxAttempts: 2, wantErr: true, wantCalls: 2},
+		{name: "4xx is not retried", failures: []int{http.StatusBadRequest}, wantErr: true, wantCalls: 1},
+	}
+
+	for _, tc := range tests {
+		server, calls := flakyServer(t, tc.failures...)
+		client := retryingClient(server, time.Millisecond)
+		client.MaxAttempts = tc.maxAttempts
+
+		papers, err := client.FetchPapers(context.Background(), "machine learning", 10)
+		if tc.wantErr != (err != nil) {
+			t.Errorf("%s: err = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+		if !tc.wantErr && len(papers) != 1 {
+			t.Errorf("%s: got %d papers, want 1", tc.name, len(papers))
+		}
+		if calls.Load() != tc.wantCalls {
+			t.Errorf("%s: %d calls, want %d", tc.name, calls.Load(), tc.wantCalls)
+		}
+	}
+}
+
+func TestClient_RetryHonoursDeadline(t *testing.T) {
+	server, calls := flakyServer(t, http.StatusServiceUnavailable)
+	client := retryingClient(server, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// A backoff past the deadline is not started: the 503 is returned
+	start := time.Now()
+	_, err := client.FetchPapers(ctx, "machine learning", 10)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second || calls.Load() != 1 {
+		t.Errorf("returned after %v and %d calls, want the backoff cut short", elapsed, calls.Load())
+	}
+}
+
+func TestClient_Categories(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		categories []string
+		expected   string
+	}{
+		{"one category", "diffusion model", []string{"cs.CV"}, "(all:diffusion model) AND cat:cs.CV"},
+		{
+			"several categories",
+			"diffusion model",
+			[]string{"cs.AI", "cs.LG", "cs.CL"},
+			"(all:diffusion model) AND (cat:cs.AI OR cat:cs.LG OR cat:cs.CL)",
+		},
+		{"field query", "ti:diffusion", []string{"cs.LG"}, "(ti:diffusion) AND cat:cs.LG"},
+	}
+
+	for _, tc := range tests {
+		var got string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.URL.Query().Get("search_query")
+			w.Write([]byte(mockResponse))
+		}))
+		client := NewClientWithOptions(server.Client(), server.URL)
+		client.Categories = tc.categories
+
+		papers, err := client.FetchPapers(context.Background(), tc.query, 10)
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: FetchPapers failed: %v", tc.name, err)
+		}
+		if got != tc.expected {
+			t.Errorf("%s: search_query = %q, want %q", tc.name, got, tc.expected)
+		}
+		if len(papers) != 1 || !reflect.DeepEqual(papers[0].Categories, []string{"cs.AI", "cs.LG"}) {
+			t.Errorf("%s: papers should keep the feed's categories, got %+v", tc.name, papers)
+		}
+	}
+}
+
+func TestClient_Scoped(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.Query().Get("search_query"))
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.Categories = []string{"cs.CV"}
+
+	scoped := client.Scoped([]string{"cs.CL", "cs.IR"})
+	if _, err := scoped.FetchPapers(context.Background(), "retrieval", 10); err != nil {
+		t.Fatalf("scoped FetchPapers failed: %v", err)
+	}
+	for _, err := range scoped.(parser.Streamer).FetchPapersIter(context.Background(), "retrieval", 10) {
+		if err != nil {
+			t.Fatalf("scoped FetchPapersIter failed: %v", err)
+		}
+	}
+	// The client keeps its own categories
+	if _, err := client.FetchPapers(context.Background(), "retrieval", 10); err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+
+	want := []string{
+		"(all:retrieval) AND (cat:cs.CL OR cat:cs.IR)",
+		"(all:retrieval) AND (cat:cs.CL OR cat:cs.IR)",
+		"(all:retrieval) AND cat:cs.CV",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("search queries = %q, want %q", got, want)
+	}
+}
+
+func TestClient_SubmittedRange(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		from, to   time.Time
+		categories []string
+		expected   string
+	}{
+		{"from and to", from, to, nil, "(all:llm) AND submittedDate:[202403010000 TO 202403072359]"},
+		{"from only", from, time.Time{}, nil, "(all:llm) AND submittedDate:[202403010000 TO 999912312359]"},
+		{"to only", time.Time{}, to, nil, "(all:llm) AND submittedDate:[000101010000 TO 202403072359]"},
+		{
+			"with a category",
+			from, to, []string{"cs.CL"},
+			"(all:llm) AND cat:cs.CL AND submittedDate:[202403010000 TO 202403072359]",
+		},
+	}
+
+	for _, tc := range tests {
+		var got string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.URL.Query().Get("search_query")
+			w.Write([]byte(mockResponse))
+		}))
+		client := NewClientWithOptions(server.Client(), server.URL)
+		client.From, client.To, client.Categories = tc.from, tc.to, tc.categories
+
+		_, err := client.FetchPapers(context.Background(), "llm", 10)
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: FetchPapers failed: %v", tc.name, err)
+		}
+		if got != tc.expected {
+			t.Errorf("%s: search_query = %q, want %q", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestClient_FetchSince(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.Query().Get("search_query"))
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.Interval = time.Millisecond
+	client.Categories = []string{"cs.CL"}
+	since := time.Date(2024, 3, 1, 9, 30, 45, 0, time.FixedZone("CET", 3600))
+
+	result, err := client.FetchSince(context.Background(), "llm", since, 10)
+	if err != nil {
+		t.Fatalf("FetchSince failed: %v", err)
+	}
+	if len(result.Papers) != 1 {
+		t.Errorf("got %d papers, want the feed's one", len(result.Papers))
+	}
+	if _, err := client.Scoped([]string{"cs.IR"}).(parser.Incremental).FetchSince(context.Background(), "llm", since, 10); err != nil {
+		t.Fatalf("scoped FetchSince failed: %v", err)
+	}
+
+	want := []string{
+		"((all:llm) AND cat:cs.CL) AND lastUpdatedDate:[202403010830 TO 999912312359]",
+		"((all:llm) AND cat:cs.IR) AND lastUpdatedDate:[202403010830 TO 999912312359]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("search queries = %q, want %q", got, want)
+	}
+}
+
+func TestClient_EmptyRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><feed xmlns="http://www.w3.org/2005/Atom"></feed>`))
+	}))
+	defer server.Close()
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.From = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	papers, err := client.FetchPapers(context.Background(), "llm", 10)
+	if err != nil {
+		t.Fatalf("an empty range should not be an error, got %v", err)
+	}
+	if papers == nil || len(papers) != 0 {
+		t.Errorf("expected an empty slice, got %#v", papers)
+	}
+}
@@ -0,0 +1,295 @@
+package arxiv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrQuerySyntax is wrapped by the errors of ParseExpr.
+var ErrQuerySyntax = errors.New("query syntax error")
+
+// Boolean operators of arXiv's search syntax.
+const (
+	OpAnd    = "AND"
+	OpOr     = "OR"
+	OpAndNot = "ANDNOT"
+)
+
+// fieldPrefixes are the arXiv field prefixes a term may use.
+var fieldPrefixes = map[string]bool{
+	"all": true, "ti": true, "au": true, "abs": true, "co": true,
+	"jr": true, "cat": true, "rn": true, "id": true,
+}
+
+// Expr is a boolean search: a term, or terms combined with AND, OR and
+// ANDNOT. Build one with Term, And, Or and AndNot, or parse it with
+// ParseExpr. The zero Expr is empty and is left out of combinations.
+type Expr struct {
+	op    string // Empty for a term
+	field string // Field prefix of a term, e.g. ti
+	text  string // Text of a term
+	args  []Expr // Operands of op
+}
+
+// Term returns a search for text in field (all, ti, au, abs, cat, ...).
+// Text with spaces is searched as a phrase; a blank text gives the empty
+// Expr.
+func Term(field, text string) Expr {
+	text = strings.Join(strings.Fields(strings.ReplaceAll(text, `"`, "")), " ")
+	if text == "" {
+		return Expr{}
+	}
+	return Expr{field: field, text: text}
+}
+
+// Any returns a search for text in any field, like Term("all", text).
+func Any(text string) Expr { return Term("all", text) }
+
+// And returns a search matching every one of args.
+func And(args ...Expr) Expr { return combine(OpAnd, args) }
+
+// Or returns a search matching any of args.
+func Or(args ...Expr) Expr { return combine(OpOr, args) }
+
+// AndNot returns a search matching include but not exclude.
+func AndNot(include, exclude Expr) Expr {
+	switch {
+	case include.IsZero():
+		return Expr{}
+	case exclude.IsZero():
+		return include
+	}
+	return Expr{op: OpAndNot, args: []Expr{include, exclude}}
+}
+
+// combine joins args with op, an associative operator: empty operands
+// are dropped and nested ones of the same op are flattened.
+func combine(op string, args []Expr) Expr {
+	var flat []Expr
+	for _, a := range args {
+		switch {
+		case a.IsZero():
+		case a.op == op:
+			flat = append(flat, a.args...)
+		default:
+			flat = append(flat, a)
+		}
+	}
+	switch len(flat) {
+	case 0:
+		return Expr{}
+	case 1:
+		return flat[0]
+	}
+	return Expr{op: op, args: flat}
+}
+
+// IsZero reports whether e is empty.
+func (e Expr) IsZero() bool { return e.op == "" && e.text == "" }
+
+// String returns e in arXiv search syntax, e.g.
+// `(all:"chain of thought" OR all:CoT) AND all:"large language model"`.
+// Every combination inside another is parenthesised, since arXiv does not
+// document operator precedence. URL encoding is left to the request.
+func (e Expr) String() string {
+	if e.op == "" {
+		if e.text == "" {
+			return ""
+		}
+		return e.field + ":" + phrase(e.text)
+	}
+	parts := make([]string, len(e.args))
+	for i, a := range e.args {
+		parts[i] = a.String()
+		if a.op != "" {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " "+e.op+" ")
+}
+
+// ParseExpr parses a boolean search such as
+//
+//	("chain of thought" OR CoT) AND "large language model" ANDNOT ti:survey
+//
+// Terms are words or quoted phrases, optionally prefixed by an arXiv field
+// (ti:, au:, abs:, cat:, ...); unprefixed terms search all fields.
+// Operators are upper case. AND and ANDNOT bind tighter than OR and
+// group from the left; terms next to each other without an operator are
+// ANDed.
+func ParseExpr(s string) (Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return Expr{}, err
+	}
+	p := &exprParser{tokens: tokens}
+	e, err := p.or()
+	if err != nil {
+		return Expr{}, err
+	}
+	if p.pos < len(p.tokens) {
+		return Expr{}, fmt.Errorf("%w: unexpected %s", ErrQuerySyntax, p.tokens[p.pos])
+	}
+	if e.IsZero() {
+		return Expr{}, fmt.Errorf("%w: no terms", ErrQuerySyntax)
+	}
+	return e, nil
+}
+
+// token is one lexical unit of a boolean search.
+type token struct {
+	kind  byte   // '(', ')', 'o' operator or 't' term
+	value string // Operator, or term text
+	field string // Field prefix of a term
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case 't':
+		return fmt.Sprintf("term %q", t.value)
+	case 'o':
+		return t.value
+	}
+	return fmt.Sprintf("%q", string(t.kind))
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{kind: c})
+			i++
+		default:
+			// A word, a quoted phrase, or either after a field prefix
+			field := ""
+			j := i
+			for j < len(s) && isWordByte(s[j]) && s[j] != ':' {
+				j++
+			}
+			if j < len(s) && s[j] == ':' && fieldPrefixes[s[i:j]] {
+				field, i = s[i:j], j+1
+			}
+			var text string
+			if i < len(s) && s[i] == '"' {
+				end := strings.IndexByte(s[i+1:], '"')
+				if end < 0 {
+					return nil, fmt.Errorf("%w: unterminated phrase %s", ErrQuerySyntax, s[i:])
+				}
+				text, i = s[i+1:i+1+end], i+end+2
+			} else {
+				j = i
+				for j < len(s) && isWordByte(s[j]) {
+					j++
+				}
+				text, i = s[i:j], j
+			}
+			if field == "" {
+				switch text {
+				case OpAnd, OpOr, OpAndNot:
+					tokens = append(tokens, token{kind: 'o', value: text})
+					continue
+				}
+				field = "all"
+			}
+			if strings.TrimSpace(text) == "" {
+				return nil, fmt.Errorf("%w: empty term at %q", ErrQuerySyntax, s[:i])
+			}
+			tokens = append(tokens, token{kind: 't', value: text, field: field})
+		}
+	}
+	return tokens, nil
+}
+
+func isWordByte(c byte) bool {
+	return c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != '(' && c != ')' && c != '"'
+}
+
+// exprParser is a recursive descent parser over tokens.
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// or parses and-expressions separated by OR.
+func (p *exprParser) or() (Expr, error) {
+	e, err := p.and()
+	if err != nil {
+		return Expr{}, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind != 'o' || t.value != OpOr {
+			return e, nil
+		}
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return Expr{}, err
+		}
+		e = Or(e, right)
+	}
+}
+
+// and parses operands joined by AND, ANDNOT or nothing.
+func (p *exprParser) and() (Expr, error) {
+	e, err := p.operand()
+	if err != nil {
+		return Expr{}, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == ')' || (t.kind == 'o' && t.value == OpOr) {
+			return e, nil
+		}
+		op := OpAnd
+		if t.kind == 'o' {
+			op = t.value
+			p.pos++
+		}
+		right, err := p.operand()
+		if err != nil {
+			return Expr{}, err
+		}
+		if op == OpAndNot {
+			e = AndNot(e, right)
+		} else {
+			e = And(e, right)
+		}
+	}
+}
+
+// operand parses a term or a parenthesised group.
+func (p *exprParser) operand() (Expr, error) {
+	t, ok := p.peek()
+	if !ok {
+		return Expr{}, fmt.Errorf("%w: unexpected end of query", ErrQuerySyntax)
+	}
+	p.pos++
+	switch t.kind {
+	case 't':
+		return Term(t.field, t.value), nil
+	case '(':
+		e, err := p.or()
+		if err != nil {
+			return Expr{}, err
+		}
+		if t, ok := p.peek(); !ok || t.kind != ')' {
+			return Expr{}, fmt.Errorf("%w: missing )", ErrQuerySyntax)
+		}
+		p.pos++
+		return e, nil
+	}
+	return Expr{}, fmt.Errorf("%w: unexpected %s", ErrQuerySyntax, t)
+}
@@ -0,0 +1,122 @@
+package arxiv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExpr_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     Expr
+		expected string
+		encoded  string // search_query as sent in the URL
+	}{
+		{
+			name:     "term",
+			expr:     Any("CoT"),
+			expected: "all:CoT",
+			encoded:  "all%3ACoT",
+		},
+		{
+			name:     "phrase",
+			expr:     Term("ti", `  "chain   of thought" `),
+			expected: `ti:"chain of thought"`,
+			encoded:  "ti%3A%22chain+of+thought%22",
+		},
+		{
+			name:     "or group in and",
+			expr:     And(Or(Any("chain of thought"), Any("CoT")), Any("large language model")),
+			expected: `(all:"chain of thought" OR all:CoT) AND all:"large language model"`,
+			encoded:  "%28all%3A%22chain+of+thought%22+OR+all%3ACoT%29+AND+all%3A%22large+language+model%22",
+		},
+		{
+			name:     "andnot",
+			expr:     AndNot(Term("au", "del maestro"), Or(Term("ti", "checkerboard"), Term("ti", "Pyrochlore"))),
+			expected: `au:"del maestro" ANDNOT (ti:checkerboard OR ti:Pyrochlore)`,
+			encoded:  "au%3A%22del+maestro%22+ANDNOT+%28ti%3Acheckerboard+OR+ti%3APyrochlore%29",
+		},
+		{
+			name:     "nested same operator is flattened",
+			expr:     And(Term("cat", "cs.CL"), And(Any("rag"), Any("retrieval"))),
+			expected: "cat:cs.CL AND all:rag AND all:retrieval",
+			encoded:  "cat%3Acs.CL+AND+all%3Arag+AND+all%3Aretrieval",
+		},
+		{
+			name:     "andnot inside and starts with a group",
+			expr:     And(AndNot(Any("agent"), Any("survey")), Term("cat", "cs.AI")),
+			expected: "(all:agent ANDNOT all:survey) AND cat:cs.AI",
+			encoded:  "%28all%3Aagent+ANDNOT+all%3Asurvey%29+AND+cat%3Acs.AI",
+		},
+		{
+			name:     "empty operands are dropped",
+			expr:     And(Any(" "), Or(Any("rag")), AndNot(Any("llm"), Expr{})),
+			expected: "all:rag AND all:llm",
+			encoded:  "all%3Arag+AND+all%3Allm",
+		},
+		{name: "empty", expr: Or(And(), Any("")), expected: ""},
+	}
+
+	for _, tc := range tests {
+		if got := tc.expr.String(); got != tc.expected {
+			t.Errorf("%s: String() = %q, want %q", tc.name, got, tc.expected)
+		}
+		if tc.expected == "" {
+			continue
+		}
+
+		// Round trip through a request: sent as built, not wrapped in all:
+		var raw string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw = r.URL.RawQuery
+			w.Write([]byte(mockResponse))
+		}))
+		client := NewClientWithOptions(server.Client(), server.URL)
+		if _, err := client.FetchPapers(context.Background(), tc.expr.String(), 10); err != nil {
+			t.Fatalf("%s: FetchPapers: %v", tc.name, err)
+		}
+		server.Close()
+		if !strings.Contains(raw, "search_query="+tc.encoded+"&") {
+			t.Errorf("%s: request query = %s, want search_query=%s", tc.name, raw, tc.encoded)
+		}
+	}
+}
+
+func TestParseExpr(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`("chain of thought" OR CoT) AND "large language model"`, `(all:"chain of thought" OR all:CoT) AND all:"large language model"`},
+		{`ti:transformer au:"Ashish Vaswani"`, `ti:transformer AND au:"Ashish Vaswani"`},
+		{`rag OR retrieval AND cat:cs.IR`, `all:rag OR (all:retrieval AND cat:cs.IR)`},
+		{`agent ANDNOT survey ANDNOT review`, `(all:agent ANDNOT all:survey) ANDNOT all:review`},
+		{`((a OR b))`, `all:a OR all:b`},
+		{`in-context learning`, `all:"in-context" AND all:learning`},
+		{`foo:bar`, `all:"foo:bar"`},
+	}
+	for _, tc := range tests {
+		e, err := ParseExpr(tc.input)
+		if err != nil {
+			t.Errorf("ParseExpr(%s): %v", tc.input, err)
+			continue
+		}
+		if got := e.String(); got != tc.expected {
+			t.Errorf("ParseExpr(%s) = %s, want %s", tc.input, got, tc.expected)
+		}
+		// What String returns parses back to itself
+		if again, err := ParseExpr(e.String()); err != nil || again.String() != e.String() {
+			t.Errorf("reparsing %s = %s, %v", e, again, err)
+		}
+	}
+
+	for _, input := range []string{``, `  `, `(rag`, `rag)`, `rag AND`, `OR rag`, `()`, `"unterminated`, `ti:`, `""`, `rag AND OR llm`} {
+		if _, err := ParseExpr(input); !errors.Is(err, ErrQuerySyntax) {
+			t.Errorf("ParseExpr(%q) = %v, want ErrQuerySyntax", input, err)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package arxiv
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
+)
+
+const (
+	defaultOAIURL       = "http://export.arxiv.org/oai2"
+	defaultMaxFlowWaits = 10
+
+	oaiDateLayout = "2006-01-02" // arXiv's datestamp granularity
+)
+
+// Harvester lists whole arXiv categories through OAI-PMH, the interface
+// arXiv provides for bulk metadata, where the search API is meant for
+// queries. It sends its requests like its Client: through the same HTTP
+// client and rate limit, with the same User-Agent and Hooks.
+type Harvester struct {
+	client *Client
+
+	BaseURL string // OAI-PMH endpoint (default: http://export.arxiv.org/oai2)
+
+	// MaxFlowWaits is how many times one request may be answered 503
+	// with a Retry-After, arXiv's flow control, and waited on before the
+	// harvest fails (default: 10)
+	MaxFlowWaits int
+}
+
+// NewHarvester creates a harvester sending its requests with c; nil
+// means NewClient().
+func NewHarvester(c *Client) *Harvester {
+	if c == nil {
+		c = NewClient()
+	}
+	return &Harvester{client: c}
+}
+
+// HarvestOptions selects the records ListRecords returns.
+type HarvestOptions struct {
+	// Category is an archive, e.g. "cs" or "hep-th", or a category within
+	// one, e.g. "cs.CL". OAI-PMH sets are whole archives, so records of a
+	// category are listed for its archive and the others dropped here
+	// (default: every record)
+	Category string
+	From     time.Time // Records whose datestamp (last metadata change) is on or after this day (default: no bound)
+	Until    time.Time // Records whose datestamp is on or before this day (default: no bound)
+}
+
+// ListRecords returns the papers of the records matching opts, in the
+// order arXiv lists them, following resumption tokens until the list is
+// complete. Deleted records are skipped. Papers come under their
+// versionless ID: the arXiv metadata format does not say which version
+// it describes. A failed request yields its error once and ends the
+// sequence.
+func (h *Harvester) ListRecords(ctx context.Context, opts HarvestOptions) iter.Seq2[model.Paper, error] {
+	return func(yield func(model.Paper, error) bool) {
+		reqURL, err := h.listURL(opts)
+		if err != nil {
+			yield(model.Paper{}, fmt.Errorf("build URL: %w", err))
+			return
+		}
+		for reqURL != "" {
+			resp, err := h.requestPage(ctx, reqURL)
+			if err != nil {
+				yield(model.Paper{}, err)
+				return
+			}
+			if resp.Error.Code != "" {
+				if resp.Error.Code == "noRecordsMatch" {
+					return
+				}
+				yield(model.Paper{}, fmt.Errorf("%w: OAI-PMH %s: %s", ErrBadQuery, resp.Error.Code, strings.TrimSpace(resp.Error.Message)))
+				return
+			}
+			for _, rec := range resp.ListRecords.Records {
+				if rec.Header.Status == "deleted" {
+					continue
+				}
+				paper := convertRecord(rec.Metadata.ArXiv)
+				if !inCategory(paper, opts.Category) {
+					continue
+				}
+				if !yield(paper, nil) {
+					return
+				}
+			}
+			reqURL = ""
+			if token := strings.TrimSpace(resp.ListRecords.ResumptionToken.Token); token != "" {
+				reqURL = h.resumeURL(token)
+			}
+		}
+	}
+}
+
+// listURL returns the URL of the first ListRecords request for opts.
+func (h *Harvester) listURL(opts HarvestOptions) (string, error) {
+	u, err := url.Parse(h.baseURL())
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("verb", "ListRecords")
+	q.Set("metadataPrefix", "arXiv")
+	if opts.Category != "" {
+		q.Set("set", oaiSet(opts.Category))
+	}
+	if !opts.From.IsZero() {
+		q.Set("from", opts.From.UTC().Format(oaiDateLayout))
+	}
+	if !opts.Until.IsZero() {
+		q.Set("until", opts.Until.UTC().Format(oaiDateLayout))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// resumeURL returns the URL continuing a list at token. OAI-PMH allows no
+// other argument beside it.
+func (h *Harvester) resumeURL(token string) string {
+	q := url.Values{}
+	q.Set("verb", "ListRecords")
+	q.Set("resumptionToken", token)
+	return h.baseURL() + "?" + q.Encode()
+}
+
+func (h *Harvester) baseURL() string {
+	if h.BaseURL != "" {
+		return h.BaseURL
+	}
+	return defaultOAIURL
+}
+
+// physicsArchives are the archives whose OAI-PMH set sits under physics.
+var physicsArchives = map[string]bool{
+	"astro-ph": true, "cond-mat": true, "gr-qc": true, "hep-ex": true, "hep-lat": true,
+	"hep-ph": true, "hep-th": true, "math-ph": true, "nlin": true, "nucl-ex": true,
+	"nucl-th": true, "physics": true, "quant-ph": true,
+}
+
+// oaiSet returns the OAI-PMH set listing category: its archive, e.g.
+// "cs" for "cs.CL" and "physics:hep-th" for "hep-th".
+func oaiSet(category string) string {
+	archive, _, _ := strings.Cut(category, ".")
+	if physicsArchives[archive] {
+		return "physics:" + archive
+	}
+	return archive
+}
+
+// inCategory reports whether p is listed in category. Sets already hold
+// only the archive's records, so a bare archive matches everything.
+func inCategory(p model.Paper, category string) bool {
+	if !strings.Contains(category, ".") {
+		return true
+	}
+	for _, c := range p.Categories {
+		if strings.EqualFold(c, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestPage fetches and decodes one ListRecords response, waiting out
+// flow-control 503s.
+func (h *Harvester) requestPage(ctx context.Context, reqURL string) (oaiResponse, error) {
+	c := h.client
+	maxWaits := h.MaxFlowWaits
+	if maxWaits <= 0 {
+		maxWaits = defaultMaxFlowWaits
+	}
+	for waits := 0; ; waits++ {
+		resp, err := h.request(ctx, reqURL)
+		se, ok := err.(*StatusError)
+		if !ok || se.StatusCode != http.StatusServiceUnavailable || se.RetryAfter <= 0 || waits == maxWaits {
+			return resp, err
+		}
+		timer := clock.Or(c.Clock).NewTimer(se.RetryAfter)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return oaiResponse{}, ctx.Err()
+		}
+	}
+}
+
+func (h *Harvester) request(ctx context.Context, reqURL string) (oaiResponse, error) {
+	c := h.client
+	if err := c.wait(ctx); err != nil {
+		return oaiResponse{}, err
+	}
+	if c.MaxAttempts > 0 {
+		ctx = httpclient.WithMaxRetries(ctx, c.MaxAttempts-1)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return oaiResponse{}, fmt.Errorf("build request: %w", err)
+	}
+	ua := c.UserAgent
+	if ua == "" {
+		ua = version.UserAgent()
+	}
+	req.Header.Set("User-Agent", ua)
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return oaiResponse{}, requestError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return oaiResponse{}, statusError(resp, clock.Or(c.Clock).Now())
+	}
+
+	var out oaiResponse
+	if err := c.decodeXML(ctx, resp, &out); err != nil {
+		return oaiResponse{}, err
+	}
+	return out, nil
+}
+
+// convertRecord converts the arXiv metadata of an OAI-PMH record.
+func convertRecord(m oaiArXiv) model.Paper {
+	id := paperid.Base(strings.TrimSpace(m.ID))
+	categories := strings.Fields(m.Categories)
+	var primary string
+	if len(categories) > 0 {
+		// The metadata format lists the primary category first
+		primary = categories[0]
+	}
+	published := parseOAIDate(m.Created)
+	updated := parseOAIDate(m.Updated)
+	if updated.IsZero() {
+		updated = published
+	}
+
+	paper := model.Paper{
+		ID:              id,
+		Title:           cleanText(m.Title),
+		Abstract:        cleanText(m.Abstract),
+		Authors:         oaiAuthors(m.Authors),
+		Categories:      categories,
+		PrimaryCategory: primary,
+		UpdatedAt:       updated,
+		Published:       published,
+		Comments:        cleanText(m.Comments),
+		DOI:             strings.TrimSpace(m.DOI),
+		JournalRef:      cleanText(m.JournalRef),
+		Links: []model.Link{
+			{URL: model.AbsURL(id), Type: model.LinkAbstract},
+			{URL: model.PDFURL(id), Type: model.LinkPDF},
+		},
+		Source: model.SourceArxiv,
+	}
+	paper.SetExtent()
+	paper.SetTextLinks()
+	return paper
+}
+
+// oaiAuthors returns the authors' names as "Forenames Keyname Suffix".
+func oaiAuthors(authors []oaiAuthor) []string {
+	names := make([]string, 0, len(authors))
+	for _, a := range authors {
+		name := strings.Join(strings.Fields(a.Forenames+" "+a.Keyname+" "+a.Suffix), " ")
+		if name != "" {
+			names = append(names, cleanText(name))
+		}
+	}
+	return names
+}
+
+func parseOAIDate(s string) time.Time {
+	t, err := time.Parse(oaiDateLayout, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// OAI-PMH XML structures for ListRecords responses in the arXiv metadata
+// format (http://arxiv.org/OAI/arXiv/).
+
+type oaiResponse struct {
+	Error       oaiError       `xml:"error"`
+	ListRecords oaiListRecords `xml:"ListRecords"`
+}
+
+type oaiError struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+type oaiListRecords struct {
+	Records         []oaiRecord        `xml:"record"`
+	ResumptionToken oaiResumptionToken `xml:"resumptionToken"`
+}
+
+type oaiResumptionToken struct {
+	Token            string `xml:",chardata"`
+	Cursor           int    `xml:"cursor,attr"`
+	CompleteListSize int    `xml:"completeListSize,attr"`
+}
+
+type oaiRecord struct {
+	Header struct {
+		Identifier string `xml:"identifier"`
+		Status     string `xml:"status,attr"`
+	} `xml:"header"`
+	Metadata struct {
+		ArXiv oaiArXiv `xml:"http://arxiv.org/OAI/arXiv/ arXiv"`
+	} `xml:"metadata"`
+}
+
+type oaiArXiv struct {
+	ID         string      `xml:"id"`
+	Created    string      `xml:"created"`
+	Updated    string      `xml:"updated"`
+	Authors    []oaiAuthor `xml:"authors>author"`
+	Title      string      `xml:"title"`
+	Categories string      `xml:"categories"`
+	Comments   string      `xml:"comments"`
+	JournalRef string      `xml:"journal-ref"`
+	DOI        string      `xml:"doi"`
+	Abstract   string      `xml:"abstract"`
+}
+
+type oaiAuthor struct {
+	Keyname   string `xml:"keyname"`
+	Forenames string `xml:"forenames"`
+	Suffix    string `xml:"suffix"`
+}
@@ -0,0 +1,265 @@
+package arxiv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// oaiServer serves the ListRecords fixture named by each request's
+// resumption token, or by "" for the first request, and records the
+// queries it gets.
+func oaiServer(t *testing.T, pages map[string]string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		fixture, ok := pages[r.URL.Query().Get("resumptionToken")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Errorf("read fixture: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server, &queries
+}
+
+func newTestHarvester(server *httptest.Server) *Harvester {
+	client := NewClientWithOptions(server.Client(), "")
+	client.Interval = time.Nanosecond
+	h := NewHarvester(client)
+	h.BaseURL = server.URL + "/oai2"
+	return h
+}
+
+func harvest(h *Harvester, opts HarvestOptions) ([]model.Paper, error) {
+	var papers []model.Paper
+	for p, err := range h.ListRecords(context.Background(), opts) {
+		if err != nil {
+			return papers, err
+		}
+		papers = append(papers, p)
+	}
+	return papers, nil
+}
+
+func TestHarvester_ListRecords(t *testing.T) {
+	server, queries := oaiServer(t, map[string]string{
+		"":             "oai-page1.xml",
+		"6913532|1001": "oai-page2.xml",
+	})
+	h := newTestHarvester(server)
+
+	papers, err := harvest(h, HarvestOptions{
+		Category: "cs.CL",
+		From:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Until:    time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("ListRecords failed: %v", err)
+	}
+
+	wantQueries := []string{
+		"from=2024-01-01&metadataPrefix=arXiv&set=cs&until=2024-02-29&verb=ListRecords",
+		"resumptionToken=6913532%7C1001&verb=ListRecords",
+	}
+	if !reflect.DeepEqual(*queries, wantQueries) {
+		t.Errorf("queries = %q, want %q", *queries, wantQueries)
+	}
+
+	var ids []string
+	for _, p := range papers {
+		ids = append(ids, p.ID)
+	}
+	// 2401.00002 is deleted and 2401.00003 is in cs.RO only
+	if want := []string{"2401.00001", "cs/0112017"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("IDs = %v, want %v", ids, want)
+	}
+
+	p := papers[0]
+	if p.Title != "Parsing Sentences with Transformers" {
+		t.Errorf("title = %q", p.Title)
+	}
+	if p.Abstract != "We parse sentences with transformers and report state-of-the-art results." {
+		t.Errorf("abstract = %q", p.Abstract)
+	}
+	if want := []string{"Ada Lovelace", "B. C. Jones Jr"}; !reflect.DeepEqual(p.Authors, want) {
+		t.Errorf("authors = %q, want %q", p.Authors, want)
+	}
+	if want := []string{"cs.CL", "cs.LG"}; !reflect.DeepEqual(p.Categories, want) || p.PrimaryCategory != "cs.CL" {
+		t.Errorf("categories = %v (primary %q), want %v with cs.CL first", p.Categories, p.PrimaryCategory, want)
+	}
+	if !p.Published.Equal(time.Date(2023, 12, 29, 0, 0, 0, 0, time.UTC)) || !p.UpdatedAt.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("published %v, updated %v", p.Published, p.UpdatedAt)
+	}
+	if p.DOI != "10.1000/example.1" || p.JournalRef != "ACL 2024" || p.Pages != 12 || p.Figures != 3 {
+		t.Errorf("DOI %q, journal %q, %d pages, %d figures", p.DOI, p.JournalRef, p.Pages, p.Figures)
+	}
+	if p.Source != model.SourceArxiv || p.Revision != 0 {
+		t.Errorf("source %q, revision %d", p.Source, p.Revision)
+	}
+	var types []string
+	for _, l := range p.Links {
+		types = append(types, l.Type)
+	}
+	if want := []string{model.LinkAbstract, model.LinkPDF, model.LinkCode}; !reflect.DeepEqual(types, want) {
+		t.Errorf("link types = %v, want %v", types, want)
+	}
+
+	// Without an update date, the record was last changed when created
+	if old := papers[1]; !old.UpdatedAt.Equal(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("cs/0112017 updated %v", old.UpdatedAt)
+	}
+}
+
+func TestHarvester_WholeArchive(t *testing.T) {
+	server, queries := oaiServer(t, map[string]string{
+		"":             "oai-page1.xml",
+		"6913532|1001": "oai-page2.xml",
+	})
+	papers, err := harvest(newTestHarvester(server), HarvestOptions{Category: "cs"})
+	if err != nil {
+		t.Fatalf("ListRecords failed: %v", err)
+	}
+	if len(papers) != 3 {
+		t.Errorf("got %d papers, want the 3 not deleted", len(papers))
+	}
+	if want := "metadataPrefix=arXiv&set=cs&verb=ListRecords"; (*queries)[0] != want {
+		t.Errorf("first query = %q, want %q", (*queries)[0], want)
+	}
+	if papers[1].UpdatedAt != papers[1].Published || papers[1].Published.IsZero() {
+		t.Errorf("2401.00003 published %v, updated %v; want both its creation date", papers[1].Published, papers[1].UpdatedAt)
+	}
+}
+
+func TestHarvester_OAIErrors(t *testing.T) {
+	t.Run("no records match", func(t *testing.T) {
+		server, _ := oaiServer(t, map[string]string{"": "oai-norecords.xml"})
+		papers, err := harvest(newTestHarvester(server), HarvestOptions{Category: "cs"})
+		if err != nil || len(papers) != 0 {
+			t.Errorf("got %d papers, err %v; want none and no error", len(papers), err)
+		}
+	})
+	t.Run("bad resumption token", func(t *testing.T) {
+		server, _ := oaiServer(t, map[string]string{"": "oai-page1.xml", "6913532|1001": "oai-badtoken.xml"})
+		papers, err := harvest(newTestHarvester(server), HarvestOptions{})
+		if !errors.Is(err, ErrBadQuery) {
+			t.Errorf("err = %v, want ErrBadQuery", err)
+		}
+		if len(papers) != 2 {
+			t.Errorf("got %d papers before the error, want the first page's 2", len(papers))
+		}
+	})
+}
+
+func TestHarvester_BadResponses(t *testing.T) {
+	t.Run("too large", func(t *testing.T) {
+		server, _ := oaiServer(t, map[string]string{"": "oai-page1.xml"})
+		h := newTestHarvester(server)
+		h.client.MaxResponseBytes = 512
+		if _, err := harvest(h, HarvestOptions{}); !errors.Is(err, ErrResponseTooLarge) {
+			t.Errorf("err = %v, want ErrResponseTooLarge", err)
+		}
+	})
+	t.Run("HTML", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>Maintenance</body></html>"))
+		}))
+		defer server.Close()
+		if _, err := harvest(newTestHarvester(server), HarvestOptions{}); !errors.Is(err, ErrNotXML) {
+			t.Errorf("err = %v, want ErrNotXML", err)
+		}
+	})
+}
+
+func TestHarvester_FlowControl(t *testing.T) {
+	var calls atomic.Int32
+	page, err := os.ReadFile(filepath.Join("testdata", "oai-page2.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	fake := clock.NewFake(time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC))
+	h := newTestHarvester(server)
+	h.client.Clock = fake
+
+	done := make(chan error)
+	var papers []model.Paper
+	go func() {
+		var err error
+		papers, err = harvest(h, HarvestOptions{})
+		done <- err
+	}()
+	fake.BlockUntil(1)
+	fake.Advance(5 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("ListRecords failed: %v", err)
+	}
+	if calls.Load() != 2 || len(papers) != 1 {
+		t.Errorf("%d requests, %d papers; want 2 and 1", calls.Load(), len(papers))
+	}
+
+	t.Run("gives up", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		h := newTestHarvester(server)
+		h.MaxFlowWaits = 1
+		h.client.Clock = fake
+
+		go func() {
+			_, err := harvest(h, HarvestOptions{})
+			done <- err
+		}()
+		fake.BlockUntil(1)
+		fake.Advance(time.Second)
+		if err := <-done; !errors.Is(err, ErrUnavailable) {
+			t.Errorf("err = %v, want ErrUnavailable after one wait", err)
+		}
+	})
+}
+
+func TestOAISet(t *testing.T) {
+	tests := map[string]string{
+		"cs":          "cs",
+		"cs.CL":       "cs",
+		"math.AG":     "math",
+		"hep-th":      "physics:hep-th",
+		"astro-ph.CO": "physics:astro-ph",
+		"q-bio.NC":    "q-bio",
+		"stat.ML":     "stat",
+	}
+	for category, want := range tests {
+		if got := oaiSet(category); got != want {
+			t.Errorf("oaiSet(%q) = %q, want %q", category, got, want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package arxiv
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+)
+
+// Hooks observes the client's HTTP traffic. Every attempt is reported,
+// retries and each page included, so a request retried twice starts and
+// finishes three times. Hooks may be called from several goroutines at
+// once when the client fetches pages concurrently.
+type Hooks interface {
+	// OnRequestStart is called as an attempt is sent.
+	OnRequestStart(url string)
+	// OnRequestDone is called once the attempt's response body is closed,
+	// with the bytes read from it, or with status 0 and err when no
+	// response came back. err is also set when reading the body failed.
+	OnRequestDone(url string, status int, d time.Duration, bytes int64, err error)
+}
+
+// NopHooks ignores every call; it is what a Client without Hooks behaves
+// like.
+type NopHooks struct{}
+
+func (NopHooks) OnRequestStart(string)                                  {}
+func (NopHooks) OnRequestDone(string, int, time.Duration, int64, error) {}
+
+// Metrics is a Hooks that counts requests, for tests and benchmarks. The
+// zero value is ready to use and it is safe for concurrent use.
+type Metrics struct {
+	mu   sync.Mutex
+	snap MetricsSnapshot
+}
+
+// MetricsSnapshot is what a Metrics counted up to some point.
+type MetricsSnapshot struct {
+	Started  int           // Attempts sent
+	Done     int           // Attempts finished
+	Errors   int           // Attempts without a response, or whose body failed to read
+	Bytes    int64         // Response body bytes read
+	Duration time.Duration // Total time from sending each attempt to closing its body
+	Statuses map[int]int   // Finished attempts by status code
+}
+
+// OnRequestStart implements Hooks.
+func (m *Metrics) OnRequestStart(string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snap.Started++
+}
+
+// OnRequestDone implements Hooks.
+func (m *Metrics) OnRequestDone(_ string, status int, d time.Duration, bytes int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snap.Done++
+	if err != nil {
+		m.snap.Errors++
+	}
+	m.snap.Bytes += bytes
+	m.snap.Duration += d
+	if status != 0 {
+		if m.snap.Statuses == nil {
+			m.snap.Statuses = make(map[int]int)
+		}
+		m.snap.Statuses[status]++
+	}
+}
+
+// Snapshot returns the counts so far.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.snap
+	if s.Statuses != nil {
+		s.Statuses = make(map[int]int, len(m.snap.Statuses))
+		for status, n := range m.snap.Statuses {
+			s.Statuses[status] = n
+		}
+	}
+	return s
+}
+
+// Sub returns the counts s gained since earlier, a snapshot of the same
+// Metrics taken before it.
+func (s MetricsSnapshot) Sub(earlier MetricsSnapshot) MetricsSnapshot {
+	d := MetricsSnapshot{
+		Started:  s.Started - earlier.Started,
+		Done:     s.Done - earlier.Done,
+		Errors:   s.Errors - earlier.Errors,
+		Bytes:    s.Bytes - earlier.Bytes,
+		Duration: s.Duration - earlier.Duration,
+	}
+	for status, n := range s.Statuses {
+		if n -= earlier.Statuses[status]; n != 0 {
+			if d.Statuses == nil {
+				d.Statuses = make(map[int]int)
+			}
+			d.Statuses[status] = n
+		}
+	}
+	return d
+}
+
+// client returns the HTTP client to send a request with: c's own, or,
+// with Hooks set, a copy reporting every attempt under its retries.
+func (c *Client) client() *http.Client {
+	if c.Hooks == nil {
+		return c.httpClient
+	}
+	hc := *c.httpClient
+	base := hc.Transport
+	if t, ok := base.(*httpclient.Transport); ok {
+		base = t.Base
+		if base == nil {
+			base = httpclient.DefaultBase
+		}
+	} else if base == nil {
+		base = http.DefaultTransport
+	}
+	hc.Transport = httpclient.UnderRetries(hc.Transport, &hookTransport{
+		base:  base,
+		hooks: c.Hooks,
+		clock: clock.Or(c.Clock),
+	})
+	return &hc
+}
+
+// hookTransport reports each round trip through it to hooks.
+type hookTransport struct {
+	base  http.RoundTripper
+	hooks Hooks
+	clock clock.Clock
+}
+
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
+	start := t.clock.Now()
+	t.hooks.OnRequestStart(url)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.hooks.OnRequestDone(url, 0, t.clock.Now().Sub(start), 0, err)
+		return nil, err
+	}
+	resp.Body = &hookedBody{
+		ReadCloser: resp.Body,
+		done: func(n int64, err error) {
+			t.hooks.OnRequestDone(url, resp.StatusCode, t.clock.Now().Sub(start), n, err)
+		},
+	}
+	return resp, nil
+}
+
+// hookedBody counts the bytes read from a response body and reports them
+// once, when it is closed.
+type hookedBody struct {
+	io.ReadCloser
+	n    int64
+	err  error // First read error other than io.EOF
+	once sync.Once
+	done func(n int64, err error)
+}
+
+func (b *hookedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *hookedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.n, b.err) })
+	return err
+}
@@ -0,0 +1,174 @@
+package arxiv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// recordingHooks remembers the calls it gets, in order.
+type recordingHooks struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (h *recordingHooks) OnRequestStart(url string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls = append(h.calls, "start "+url)
+}
+
+func (h *recordingHooks) OnRequestDone(url string, status int, _ time.Duration, bytes int64, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls = append(h.calls, fmt.Sprintf("done %s %d %d %t", url, status, bytes, err != nil))
+}
+
+func TestClient_HooksSeeRetries(t *testing.T) {
+	server, calls := flakyServer(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+	client := retryingClient(server, time.Millisecond)
+	var metrics Metrics
+	client.Hooks = &metrics
+
+	if _, err := client.FetchPapers(context.Background(), "machine learning", 10); err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+
+	got := metrics.Snapshot()
+	if got.Started != int(calls.Load()) || got.Done != got.Started {
+		t.Errorf("started %d, done %d; want both %d, one per attempt", got.Started, got.Done, calls.Load())
+	}
+	if got.Errors != 0 {
+		t.Errorf("errors = %d, want none: every attempt got a response", got.Errors)
+	}
+	if want := map[int]int{http.StatusServiceUnavailable: 1, http.StatusBadGateway: 1, http.StatusOK: 1}; !reflect.DeepEqual(got.Statuses, want) {
+		t.Errorf("statuses = %v, want %v", got.Statuses, want)
+	}
+	if got.Bytes != int64(len(mockResponse)) {
+		t.Errorf("bytes = %d, want %d", got.Bytes, len(mockResponse))
+	}
+}
+
+func TestClient_HooksSeeNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	client := retryingClient(server, time.Millisecond)
+	client.MaxAttempts = 2
+	server.Close()
+	var metrics Metrics
+	client.Hooks = &metrics
+
+	if _, err := client.FetchPapers(context.Background(), "llm", 10); err == nil {
+		t.Fatal("FetchPapers from a closed server succeeded")
+	}
+	if got := metrics.Snapshot(); got.Started != 2 || got.Done != 2 || got.Errors != 2 || got.Statuses != nil {
+		t.Errorf("metrics = %+v, want two attempts failing without a response", got)
+	}
+}
+
+func TestClient_HooksSeePages(t *testing.T) {
+	var mu sync.Mutex
+	var written int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><feed xmlns="http://www.w3.org/2005/Atom">`)
+		fmt.Fprintf(&b, `<entry><id>http://arxiv.org/abs/2401.0000%dv1</id><title>T</title><updated>2024-01-15T10:00:00Z</updated></entry>`, start+1)
+		b.WriteString(`</feed>`)
+		mu.Lock()
+		written += int64(b.Len())
+		mu.Unlock()
+		w.Write([]byte(b.String()))
+	}))
+	defer server.Close()
+
+	hooks := &recordingHooks{}
+	client := New(WithHTTPClient(server.Client()), WithBaseURL(server.URL), WithRateLimit(time.Nanosecond), WithHooks(hooks))
+	client.PageSize = 1
+
+	papers, err := client.FetchPapers(context.Background(), "llm", 3)
+	if err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+	if len(papers) != 3 {
+		t.Fatalf("got %d papers, want 3", len(papers))
+	}
+
+	if len(hooks.calls) != 6 {
+		t.Fatalf("calls = %q, want a start and a done per page", hooks.calls)
+	}
+	var bytes int64
+	for i := 0; i < len(hooks.calls); i += 2 {
+		start, done := hooks.calls[i], hooks.calls[i+1]
+		url, ok := strings.CutPrefix(start, "start ")
+		if !ok || !strings.Contains(url, fmt.Sprintf("start=%d", i/2)) {
+			t.Errorf("call %d = %q, want the start of page %d", i, start, i/2)
+		}
+		var status int
+		var n int64
+		var failed bool
+		if _, err := fmt.Sscanf(strings.TrimPrefix(done, "done "+url), " %d %d %t", &status, &n, &failed); err != nil || status != http.StatusOK || failed {
+			t.Errorf("call %d = %q, want page %d done with 200", i+1, done, i/2)
+		}
+		bytes += n
+	}
+	if bytes != written {
+		t.Errorf("hooks saw %d bytes, server wrote %d", bytes, written)
+	}
+}
+
+func TestClient_HooksSeeDownloads(t *testing.T) {
+	const pdf = "%PDF-1.5 body"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte(pdf))
+	}))
+	defer server.Close()
+	var metrics Metrics
+	client := New(WithHTTPClient(server.Client()), WithRateLimit(time.Nanosecond), WithHooks(&metrics))
+	client.PDFBaseURL = server.URL + "/pdf/"
+
+	path, err := client.DownloadPDF(context.Background(), model.Paper{ID: "2401.00001v1"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadPDF failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != pdf || filepath.Base(path) != "2401.00001v1.pdf" {
+		t.Errorf("downloaded %q to %s", data, path)
+	}
+	if got := metrics.Snapshot(); got.Started != 1 || got.Done != 1 || got.Bytes != int64(len(pdf)) {
+		t.Errorf("metrics = %+v, want one request of %d bytes", got, len(pdf))
+	}
+}
+
+func TestMetricsSnapshot_Sub(t *testing.T) {
+	var m Metrics
+	m.OnRequestStart("a")
+	m.OnRequestDone("a", http.StatusOK, time.Second, 100, nil)
+	before := m.Snapshot()
+	m.OnRequestStart("b")
+	m.OnRequestDone("b", http.StatusServiceUnavailable, 2*time.Second, 10, nil)
+	m.OnRequestStart("c")
+	m.OnRequestDone("c", 0, time.Second, 0, context.DeadlineExceeded)
+
+	got := m.Snapshot().Sub(before)
+	want := MetricsSnapshot{
+		Started: 2, Done: 2, Errors: 1, Bytes: 10, Duration: 3 * time.Second,
+		Statuses: map[int]int{http.StatusServiceUnavailable: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sub = %+v, want %+v", got, want)
+	}
+	if before.Statuses[http.StatusServiceUnavailable] != 0 {
+		t.Errorf("earlier snapshot changed: %v", before.Statuses)
+	}
+}
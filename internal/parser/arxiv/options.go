@@ -0,0 +1,111 @@
+package arxiv
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+)
+
+// Option configures a Client built by New.
+type Option func(*options)
+
+// options collects what the Options set; New turns them into a Client.
+type options struct {
+	httpClient *http.Client
+	timeout    time.Duration
+	transport  http.RoundTripper
+	baseURL    string
+	userAgent  string
+	interval   time.Duration
+	hooks      Hooks
+	maxBytes   int64
+}
+
+// WithHTTPClient makes the client send its requests with hc. WithTimeout
+// and WithTransport still apply, to a copy of hc (default: a client with
+// httpclient.Transport, which retries 429s, 5xx responses and network
+// errors).
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *options) { o.httpClient = hc }
+}
+
+// WithTimeout limits each request, retries and reading the response
+// included, to d (default: 30s).
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithTransport sends requests over rt, e.g. a proxying or recording
+// transport. When the HTTP client retries through httpclient.Transport,
+// rt goes under it, so every attempt passes through rt and retries are
+// kept. Without it, requests go through the proxy HTTP_PROXY, HTTPS_PROXY
+// and NO_PROXY name, if any.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) { o.transport = rt }
+}
+
+// WithBaseURL sends queries to url instead of arXiv's API, e.g. to a
+// mirror or a test server (default: https://export.arxiv.org/api/query).
+func WithBaseURL(url string) Option {
+	return func(o *options) { o.baseURL = url }
+}
+
+// WithUserAgent sets Client.UserAgent.
+func WithUserAgent(ua string) Option {
+	return func(o *options) { o.userAgent = ua }
+}
+
+// WithRateLimit sets Client.Interval, the minimum time between the starts
+// of two requests (default: 3s, as arXiv asks).
+func WithRateLimit(interval time.Duration) Option {
+	return func(o *options) { o.interval = interval }
+}
+
+// WithHooks sets Client.Hooks, which observe every request attempt.
+func WithHooks(h Hooks) Option {
+	return func(o *options) { o.hooks = h }
+}
+
+// WithMaxResponseSize sets Client.MaxResponseBytes, the longest response
+// body read before a request fails with ErrResponseTooLarge (default: 50 MB).
+func WithMaxResponseSize(n int64) Option {
+	return func(o *options) { o.maxBytes = n }
+}
+
+// New creates an ArXiv API client configured by opts.
+func New(opts ...Option) *Client {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	hc := o.httpClient
+	if hc == nil {
+		hc = httpclient.New(defaultTimeout)
+	}
+	if o.timeout > 0 || o.transport != nil {
+		// The caller's client may be shared, so it is left as it is
+		copied := *hc
+		hc = &copied
+		if o.timeout > 0 {
+			hc.Timeout = o.timeout
+		}
+		if o.transport != nil {
+			hc.Transport = httpclient.UnderRetries(hc.Transport, o.transport)
+		}
+	}
+	baseURL := o.baseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	return &Client{
+		httpClient:       hc,
+		baseURL:          baseURL,
+		UserAgent:        o.userAgent,
+		Interval:         o.interval,
+		Hooks:            o.hooks,
+		MaxResponseBytes: o.maxBytes,
+	}
+}
@@ -0,0 +1,130 @@
+package arxiv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+)
+
+// countingTransport counts the requests it passes on.
+type countingTransport struct {
+	base  http.RoundTripper
+	calls atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls.Add(1)
+	return t.base.RoundTrip(req)
+}
+
+func TestNew_Defaults(t *testing.T) {
+	c := New()
+	if c.baseURL != defaultBaseURL || c.httpClient.Timeout != defaultTimeout || c.UserAgent != "" || c.Interval != 0 {
+		t.Errorf("New() = base %q, timeout %v, UA %q, interval %v", c.baseURL, c.httpClient.Timeout, c.UserAgent, c.Interval)
+	}
+	if _, ok := c.httpClient.Transport.(*httpclient.Transport); !ok {
+		t.Errorf("default transport = %T, want the retrying httpclient.Transport", c.httpClient.Transport)
+	}
+}
+
+func TestNew_Options(t *testing.T) {
+	var gotUA atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA.Store(r.UserAgent())
+		if r.URL.Query().Get("search_query") == "all:slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+	fetch := func(c *Client, query string) error {
+		c.Interval = time.Nanosecond
+		_, err := c.FetchPapers(context.Background(), query, 1)
+		return err
+	}
+
+	t.Run("base URL", func(t *testing.T) {
+		if err := fetch(New(WithBaseURL(server.URL)), "llm"); err != nil {
+			t.Errorf("fetch from the test server: %v", err)
+		}
+	})
+
+	t.Run("user agent", func(t *testing.T) {
+		if err := fetch(New(WithBaseURL(server.URL), WithUserAgent("tester/1.0")), "llm"); err != nil {
+			t.Fatal(err)
+		}
+		if gotUA.Load() != "tester/1.0" {
+			t.Errorf("User-Agent = %v", gotUA.Load())
+		}
+	})
+
+	t.Run("HTTP client", func(t *testing.T) {
+		counter := &countingTransport{base: server.Client().Transport}
+		hc := &http.Client{Transport: counter}
+		c := New(WithHTTPClient(hc), WithBaseURL(server.URL))
+		if err := fetch(c, "llm"); err != nil || counter.calls.Load() != 1 {
+			t.Errorf("fetch = %v with %d requests through the given client", err, counter.calls.Load())
+		}
+		if c.httpClient != hc {
+			t.Error("the given client was copied though no option changes it")
+		}
+	})
+
+	t.Run("transport", func(t *testing.T) {
+		counter := &countingTransport{base: http.DefaultTransport}
+		c := New(WithTransport(counter), WithBaseURL(server.URL))
+		if err := fetch(c, "llm"); err != nil || counter.calls.Load() != 1 {
+			t.Errorf("fetch = %v with %d requests through the transport", err, counter.calls.Load())
+		}
+		// Retries are kept, with the transport under them
+		if rt, ok := c.httpClient.Transport.(*httpclient.Transport); !ok || rt.Base != counter {
+			t.Errorf("transport = %#v, want httpclient.Transport over the given one", c.httpClient.Transport)
+		}
+		if c.httpClient.Timeout != defaultTimeout {
+			t.Errorf("timeout = %v, want the default kept", c.httpClient.Timeout)
+		}
+
+		// A client that does not retry gets the transport in its place
+		c = New(WithHTTPClient(&http.Client{}), WithTransport(counter))
+		if c.httpClient.Transport != counter {
+			t.Errorf("transport = %T, want the given one", c.httpClient.Transport)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		hc := &http.Client{Transport: server.Client().Transport}
+		c := New(WithHTTPClient(hc), WithBaseURL(server.URL), WithTimeout(50*time.Millisecond))
+		if err := fetch(c, "slow"); err == nil {
+			t.Error("fetch outlasting the timeout succeeded")
+		}
+		if err := fetch(c, "llm"); err != nil {
+			t.Errorf("fast fetch: %v", err)
+		}
+		if hc.Timeout != 0 || c.httpClient.Transport != hc.Transport {
+			t.Errorf("given client changed to timeout %v, or transport not kept", hc.Timeout)
+		}
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		if c := New(WithRateLimit(time.Second)); c.Interval != time.Second || c.baseURL != defaultBaseURL {
+			t.Errorf("interval = %v, base %q", c.Interval, c.baseURL)
+		}
+	})
+}
+
+func TestNewClientWithOptions_Wraps(t *testing.T) {
+	hc := &http.Client{}
+	c := NewClientWithOptions(hc, "http://mirror.example/api")
+	if c.httpClient != hc || c.baseURL != "http://mirror.example/api" {
+		t.Errorf("NewClientWithOptions = %p %q", c.httpClient, c.baseURL)
+	}
+	c = NewClientWithOptions(nil, "")
+	if c.httpClient == nil || c.baseURL != defaultBaseURL {
+		t.Errorf("NewClientWithOptions(nil, \"\") = %v %q, want the defaults", c.httpClient, c.baseURL)
+	}
+}
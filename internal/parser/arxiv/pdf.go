@@ -0,0 +1,132 @@
+package arxiv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
+)
+
+const defaultPDFBaseURL = "https://arxiv.org/pdf/"
+
+// ErrNotPDF is returned by DownloadPDF when the server answers with
+// something other than a PDF, e.g. a "PDF unavailable" HTML page.
+var ErrNotPDF = errors.New("response is not a PDF")
+
+// DownloadPDF saves the PDF of paper into dir and returns the file's path.
+// The link comes from the paper's "pdf" link, or else is PDFBaseURL + ID.
+// Files are named after the versioned ID (see PDFFileName), so a file
+// already in dir is the same version and is returned without a request.
+// The request waits for the client's rate limit like a search does, and
+// the file only appears once it is complete.
+func (c *Client) DownloadPDF(ctx context.Context, paper model.Paper, dir string) (string, error) {
+	dest := filepath.Join(dir, PDFFileName(paper))
+	if info, err := os.Stat(dest); err == nil && info.Size() > 0 {
+		return dest, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create PDF dir: %w", err)
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return "", err
+	}
+	if c.MaxAttempts > 0 {
+		ctx = httpclient.WithMaxRetries(ctx, c.MaxAttempts-1)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.pdfURL(paper), nil)
+	if err != nil {
+		return "", fmt.Errorf("build request: %w", err)
+	}
+	ua := c.UserAgent
+	if ua == "" {
+		ua = version.UserAgent()
+	}
+	req.Header.Set("User-Agent", ua)
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download %s: %w", paper.ID, requestError(ctx, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download %s: %w", paper.ID, statusError(resp, clock.Or(c.Clock).Now()))
+	}
+	if _, err := SavePDF(resp, dest); err != nil {
+		return "", fmt.Errorf("download %s: %w", paper.ID, err)
+	}
+	return dest, nil
+}
+
+// SavePDF writes the body of resp, a 200 response, to dest and returns
+// its size, or fails with ErrNotPDF when resp is not a PDF. The body goes
+// to a temporary file in dest's directory first, renamed into place once
+// complete, so an interrupted download is never taken for a whole one.
+func SavePDF(resp *http.Response, dest string) (int64, error) {
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/pdf" {
+		return 0, fmt.Errorf("%w: %q", ErrNotPDF, resp.Header.Get("Content-Type"))
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".download-*")
+	if err != nil {
+		return 0, fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	size, err := io.Copy(tmp, resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func (c *Client) pdfURL(paper model.Paper) string {
+	return PDFURL(paper, c.PDFBaseURL)
+}
+
+// PDFURL returns the paper's "pdf" link, or else base + ID as arXiv URLs
+// spell it (see paperid.URLPath); an empty base is https://arxiv.org/pdf/.
+func PDFURL(paper model.Paper, base string) string {
+	for _, link := range paper.Links {
+		if link.Type == "pdf" && link.URL != "" {
+			return link.URL
+		}
+	}
+	if base == "" {
+		base = defaultPDFBaseURL
+	}
+	return base + paperid.URLPath(paper.ID)
+}
+
+// PDFFileName returns the file name DownloadPDF uses for paper:
+// "<base_id>v<version>.pdf", with every character other than letters,
+// digits, dots and hyphens replaced by an underscore, so old-style IDs
+// and malformed ones cannot leave the directory
+// ("cs/0001001v2" -> "cs_0001001v2.pdf").
+func PDFFileName(paper model.Paper) string {
+	base := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, paper.BaseID())
+	return base + "v" + strconv.Itoa(paper.Version()) + ".pdf"
+}
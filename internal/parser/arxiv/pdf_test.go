@@ -0,0 +1,91 @@
+package arxiv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+func TestPDFFileName(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected string
+	}{
+		{"2401.00001v2", "2401.00001v2.pdf"},
+		{"2401.00001", "2401.00001v1.pdf"},
+		{"cs/0001001v3", "cs_0001001v3.pdf"},
+		{"../../etc/passwd", ".._.._etc_passwdv1.pdf"},
+	}
+
+	for _, tc := range tests {
+		if got := PDFFileName(model.Paper{ID: tc.id}); got != tc.expected {
+			t.Errorf("PDFFileName(%q) = %q, want %q", tc.id, got, tc.expected)
+		}
+	}
+}
+
+func TestClient_DownloadPDF(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/html/2401.00003v1" {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html>PDF unavailable</html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.5 " + r.URL.Path))
+	}))
+	defer server.Close()
+	client := NewClientWithOptions(server.Client(), server.URL)
+	client.Interval = time.Millisecond
+	client.PDFBaseURL = server.URL + "/pdf/"
+	dir := filepath.Join(t.TempDir(), "pdfs")
+
+	linked := model.Paper{ID: "2401.00001v2", Links: []model.Link{
+		{Type: "abstract", URL: server.URL + "/abs/2401.00001v2"},
+		{Type: "pdf", URL: server.URL + "/links/2401.00001v2"},
+	}}
+	path, err := client.DownloadPDF(context.Background(), linked, dir)
+	if err != nil {
+		t.Fatalf("DownloadPDF failed: %v", err)
+	}
+	if want := filepath.Join(dir, "2401.00001v2.pdf"); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "%PDF-1.5 /links/2401.00001v2" {
+		t.Errorf("file holds %q, want the linked PDF", data)
+	}
+	if client.limiter.Next().IsZero() {
+		t.Error("download did not take a rate-limit slot")
+	}
+
+	// Without a link the PDF comes from PDFBaseURL
+	if _, err := client.DownloadPDF(context.Background(), model.Paper{ID: "2401.00002v1"}, dir); err != nil {
+		t.Fatalf("DownloadPDF failed: %v", err)
+	}
+
+	// A file already there is the same version and is not fetched again
+	if _, err := client.DownloadPDF(context.Background(), linked, dir); err != nil {
+		t.Fatalf("second DownloadPDF failed: %v", err)
+	}
+	if want := []string{"/links/2401.00001v2", "/pdf/2401.00002v1"}; len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("requests = %v, want %v", paths, want)
+	}
+
+	client.PDFBaseURL = server.URL + "/html/"
+	_, err = client.DownloadPDF(context.Background(), model.Paper{ID: "2401.00003v1"}, dir)
+	if !errors.Is(err, ErrNotPDF) {
+		t.Errorf("DownloadPDF of an HTML page = %v, want ErrNotPDF", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "2401.00003v1.pdf")); !os.IsNotExist(statErr) {
+		t.Error("failed download left a file behind")
+	}
+}
@@ -0,0 +1,67 @@
+package arxiv
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// ErrEmptyQuery is returned by FetchWithQuery for a Query without terms.
+var ErrEmptyQuery = errors.New("empty query")
+
+// Query is a search scoped to arXiv fields. Every term must match: terms
+// are combined with AND, within and across fields. Multi-word terms are
+// searched as phrases.
+type Query struct {
+	Category []string // cat:, e.g. cs.CL
+	Title    []string // ti:
+	Author   []string // au:, e.g. "Yoshua Bengio"
+	Abstract []string // abs:
+	All      []string // all: (any field)
+}
+
+// String returns the query in arXiv search syntax, e.g.
+// `cat:cs.CL AND abs:"retrieval augmented"`. URL encoding is left to
+// the request.
+func (q Query) String() string {
+	var terms []string
+	for _, f := range []struct {
+		prefix string
+		terms  []string
+	}{
+		{"cat:", q.Category},
+		{"ti:", q.Title},
+		{"au:", q.Author},
+		{"abs:", q.Abstract},
+		{"all:", q.All},
+	} {
+		for _, t := range f.terms {
+			if t = phrase(t); t != "" {
+				terms = append(terms, f.prefix+t)
+			}
+		}
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// phrase quotes a term that arXiv would otherwise split. Quotes inside
+// the term are dropped, since the API has no way to escape them.
+func phrase(term string) string {
+	term = strings.Join(strings.Fields(strings.ReplaceAll(term, `"`, "")), " ")
+	if strings.ContainsAny(term, " -():") {
+		return `"` + term + `"`
+	}
+	return term
+}
+
+// FetchWithQuery retrieves papers matching a field-scoped query, like
+// FetchPapers.
+func (c *Client) FetchWithQuery(ctx context.Context, q Query, limit int) ([]model.Paper, error) {
+	s := q.String()
+	if s == "" {
+		return nil, ErrEmptyQuery
+	}
+	return c.FetchPapers(ctx, s, limit)
+}
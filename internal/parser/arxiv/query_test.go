@@ -0,0 +1,72 @@
+package arxiv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuery_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    Query
+		expected string
+	}{
+		{
+			name:     "category and abstract phrase",
+			query:    Query{Category: []string{"cs.CL"}, Abstract: []string{"retrieval augmented"}},
+			expected: `cat:cs.CL AND abs:"retrieval augmented"`,
+		},
+		{
+			name:     "author and title",
+			query:    Query{Title: []string{"transformer"}, Author: []string{"Ashish Vaswani"}},
+			expected: `ti:transformer AND au:"Ashish Vaswani"`,
+		},
+		{
+			name:     "quotes and spacing are normalised",
+			query:    Query{All: []string{`  "chain   of thought" `, "in-context"}},
+			expected: `all:"chain of thought" AND all:"in-context"`,
+		},
+		{
+			name:     "blank terms are skipped",
+			query:    Query{Title: []string{" "}, Category: []string{"cs.IR"}},
+			expected: "cat:cs.IR",
+		},
+		{name: "empty", query: Query{}, expected: ""},
+	}
+
+	for _, tc := range tests {
+		if got := tc.query.String(); got != tc.expected {
+			t.Errorf("%s: String() = %q, want %q", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestClient_FetchWithQuery(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query().Get("search_query")
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL)
+	q := Query{Category: []string{"cs.CL"}, Abstract: []string{"retrieval augmented"}}
+	papers, err := client.FetchWithQuery(context.Background(), q, 10)
+	if err != nil {
+		t.Fatalf("FetchWithQuery failed: %v", err)
+	}
+	if len(papers) != 1 {
+		t.Errorf("expected 1 paper, got %d", len(papers))
+	}
+	// Sent as built, not wrapped in all:
+	if want := `cat:cs.CL AND abs:"retrieval augmented"`; got != want {
+		t.Errorf("search_query = %q, want %q", got, want)
+	}
+
+	if _, err := client.FetchWithQuery(context.Background(), Query{}, 10); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("expected ErrEmptyQuery, got %v", err)
+	}
+}
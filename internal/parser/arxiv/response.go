@@ -0,0 +1,107 @@
+package arxiv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/feedxml"
+)
+
+// defaultMaxResponseBytes bounds a response body when the client sets no
+// limit; a page of 2000 entries, the API's largest, is a few MB.
+const defaultMaxResponseBytes = 50 << 20
+
+var (
+	// ErrResponseTooLarge is returned for a response body longer than the
+	// client's MaxResponseBytes. It also matches ErrDecode
+	ErrResponseTooLarge = errors.New("response too large")
+	// ErrNotXML is returned for a response whose Content-Type is not an
+	// XML one, e.g. an HTML error page from a proxy. It also matches
+	// ErrDecode
+	ErrNotXML = errors.New("response is not XML")
+)
+
+// maxResponseBytes returns MaxResponseBytes or its default.
+func (c *Client) maxResponseBytes() int64 {
+	if c.MaxResponseBytes > 0 {
+		return c.MaxResponseBytes
+	}
+	return defaultMaxResponseBytes
+}
+
+// decodeXML decodes the XML body of resp, a 200 response, into v. The
+// body must declare an XML Content-Type, or none, and be no longer than
+// the client's MaxResponseBytes; both are checked before decoding where
+// the headers allow.
+func (c *Client) decodeXML(ctx context.Context, resp *http.Response, v any) error {
+	if ct := resp.Header.Get("Content-Type"); !isXMLType(ct) {
+		return fmt.Errorf("%w: %w: Content-Type %q", ErrDecode, ErrNotXML, ct)
+	}
+	limit := c.maxResponseBytes()
+	if resp.ContentLength > limit {
+		return tooLarge(limit)
+	}
+
+	limited := &limitedReader{r: resp.Body, left: limit}
+	body := newTailReader(feedxml.Reader(limited))
+	dec := feedxml.NewDecoder(body)
+	if err := dec.Decode(v); err != nil {
+		if limited.exceeded {
+			return tooLarge(limit)
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+		return &DecodeError{Err: err, Offset: dec.InputOffset(), Snippet: body.snippet()}
+	}
+	return nil
+}
+
+func tooLarge(limit int64) error {
+	return fmt.Errorf("%w: %w: over %d bytes", ErrDecode, ErrResponseTooLarge, limit)
+}
+
+// isXMLType reports whether the Content-Type ct is an XML one, such as
+// application/atom+xml or text/xml. A response without one is decoded
+// and left to fail on its content.
+func isXMLType(ct string) bool {
+	if ct == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// limitedReader reads up to left bytes from r, then fails with
+// ErrResponseTooLarge if r has any more.
+type limitedReader struct {
+	r        io.Reader
+	left     int64
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.left <= 0 {
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			l.exceeded = true
+			return 0, ErrResponseTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.left {
+		p = p[:l.left]
+	}
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	return n, err
+}
@@ -0,0 +1,53 @@
+package arxiv
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+)
+
+// scoped is a Client searching other categories than its own. Requests
+// still go through the client, so one rate limit covers both.
+type scoped struct {
+	c          *Client
+	categories []string
+}
+
+// Scoped implements parser.Scoper: the returned provider searches like c
+// but only in categories, whatever c.Categories says.
+func (c *Client) Scoped(categories []string) parser.Provider {
+	return &scoped{c: c, categories: slices.Clone(categories)}
+}
+
+// FetchPapers implements parser.Provider.
+func (s *scoped) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	result, err := s.FetchResult(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return result.Papers, nil
+}
+
+// FetchResult implements parser.ResultFetcher.
+func (s *scoped) FetchResult(ctx context.Context, query string, limit int) (parser.FetchResult, error) {
+	return s.c.fetchResult(ctx, s.c.searchQuery(query, s.categories), limit, nil)
+}
+
+// FetchSince implements parser.Incremental.
+func (s *scoped) FetchSince(ctx context.Context, query string, since time.Time, limit int) (parser.FetchResult, error) {
+	return s.c.fetchResult(ctx, sinceQuery(s.c.searchQuery(query, s.categories), since), limit, nil)
+}
+
+// FetchAllPages implements parser.PageFetcher.
+func (s *scoped) FetchAllPages(ctx context.Context, query string, total int, onPage func(pageIdx, fetched, total int)) (parser.FetchResult, error) {
+	return s.c.fetchResult(ctx, s.c.searchQuery(query, s.categories), total, onPage)
+}
+
+// FetchPapersIter implements parser.Streamer.
+func (s *scoped) FetchPapersIter(ctx context.Context, query string, limit int) iter.Seq2[model.Paper, error] {
+	return s.c.fetchIter(ctx, s.c.searchQuery(query, s.categories), limit)
+}
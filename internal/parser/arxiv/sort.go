@@ -0,0 +1,61 @@
+package arxiv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sort fields accepted by the arXiv API.
+const (
+	SortRelevance   = "relevance"
+	SortLastUpdated = "lastUpdatedDate"
+	SortSubmitted   = "submittedDate"
+)
+
+// Sort orders accepted by the arXiv API.
+const (
+	OrderAscending  = "ascending"
+	OrderDescending = "descending"
+)
+
+// SearchOptions orders the results of FetchPapers. Empty fields leave
+// arXiv's defaults: relevance, descending.
+type SearchOptions struct {
+	SortBy    string // SortRelevance, SortLastUpdated or SortSubmitted
+	SortOrder string // OrderAscending or OrderDescending
+}
+
+// Validate reports a field arXiv would not accept.
+func (o SearchOptions) Validate() error {
+	switch o.SortBy {
+	case "", SortRelevance, SortLastUpdated, SortSubmitted:
+	default:
+		return fmt.Errorf("unknown sort field %q (expected %s, %s or %s)", o.SortBy, SortRelevance, SortLastUpdated, SortSubmitted)
+	}
+	switch o.SortOrder {
+	case "", OrderAscending, OrderDescending:
+	default:
+		return fmt.Errorf("unknown sort order %q (expected %s or %s)", o.SortOrder, OrderAscending, OrderDescending)
+	}
+	return nil
+}
+
+// ParseSort reads a sort given as "field" or "field:order", e.g.
+// "submittedDate:desc". Orders may be abbreviated to asc and desc.
+func ParseSort(s string) (SearchOptions, error) {
+	field, order, _ := strings.Cut(s, ":")
+	switch order {
+	case "asc":
+		order = OrderAscending
+	case "desc":
+		order = OrderDescending
+	}
+	opts := SearchOptions{SortBy: field, SortOrder: order}
+	if field == "" {
+		return SearchOptions{}, fmt.Errorf("missing sort field in %q", s)
+	}
+	if err := opts.Validate(); err != nil {
+		return SearchOptions{}, err
+	}
+	return opts, nil
+}
@@ -0,0 +1,64 @@
+package arxiv
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected SearchOptions
+		wantErr  bool
+	}{
+		{input: "relevance", expected: SearchOptions{SortBy: SortRelevance}},
+		{input: "submittedDate:desc", expected: SearchOptions{SortBy: SortSubmitted, SortOrder: OrderDescending}},
+		{input: "lastUpdatedDate:ascending", expected: SearchOptions{SortBy: SortLastUpdated, SortOrder: OrderAscending}},
+		{input: "date", wantErr: true},
+		{input: "submittedDate:newest", wantErr: true},
+		{input: ":asc", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseSort(tc.input)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseSort(%q): expected error, got %+v", tc.input, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.expected {
+			t.Errorf("ParseSort(%q) = %+v, %v, want %+v", tc.input, got, err, tc.expected)
+		}
+	}
+}
+
+func TestClient_BuildURLSort(t *testing.T) {
+	client := NewClient()
+	client.Search = SearchOptions{SortBy: SortSubmitted, SortOrder: OrderDescending}
+
+	raw, err := client.buildURL("machine learning", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, _ := url.Parse(raw)
+	if got := q.Query(); got.Get("sortBy") != "submittedDate" || got.Get("sortOrder") != "descending" {
+		t.Errorf("unexpected sort parameters in %s", raw)
+	}
+
+	client.Search = SearchOptions{}
+	raw, _ = client.buildURL("machine learning", 0, 10)
+	if q, _ := url.Parse(raw); q.Query().Has("sortBy") || q.Query().Has("sortOrder") {
+		t.Errorf("default order should not be sent, got %s", raw)
+	}
+}
+
+func TestClient_FetchPapersRejectsInvalidSort(t *testing.T) {
+	client := NewClientWithOptions(nil, "http://127.0.0.1:0")
+	client.Search = SearchOptions{SortBy: "citations"}
+
+	if _, err := client.FetchPapers(context.Background(), "machine learning", 10); err == nil {
+		t.Error("expected an invalid sort field to be rejected before any request")
+	}
+}
@@ -5,7 +5,10 @@ import "time"
 // Atom feed XML structures for ArXiv API responses.
 
 type atomFeed struct {
-	Entries []atomEntry `xml:"entry"`
+	TotalResults int         `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
+	StartIndex   int         `xml:"http://a9.com/-/spec/opensearch/1.1/ startIndex"`
+	ItemsPerPage int         `xml:"http://a9.com/-/spec/opensearch/1.1/ itemsPerPage"`
+	Entries      []atomEntry `xml:"entry"`
 }
 
 type atomEntry struct {
@@ -18,10 +21,11 @@ type atomEntry struct {
 	Categories []atomCategory `xml:"category"`
 	Links      []atomLink     `xml:"link"`
 
-	// ArXiv-specific fields (arxiv: namespace)
-	Comment    string `xml:"comment"`
-	DOI        string `xml:"doi"`
-	JournalRef string `xml:"journal_ref"`
+	// ArXiv-specific fields (arxiv: namespace, http://arxiv.org/schemas/atom)
+	Comment         string       `xml:"http://arxiv.org/schemas/atom comment"`
+	DOI             string       `xml:"http://arxiv.org/schemas/atom doi"`
+	JournalRef      string       `xml:"http://arxiv.org/schemas/atom journal_ref"`
+	PrimaryCategory atomCategory `xml:"http://arxiv.org/schemas/atom primary_category"`
 }
 
 type atomAuthor struct {
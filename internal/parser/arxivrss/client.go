@@ -0,0 +1,204 @@
+package arxivrss
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/feedxml"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
+)
+
+const (
+	defaultBaseURL = "https://rss.arxiv.org/rss"
+	defaultTimeout = 30 * time.Second
+)
+
+func init() {
+	parser.Default.Register(model.SourceArxivRSS, func(opts parser.Options) parser.Provider {
+		c := NewClient(opts.AnnounceCategories)
+		c.StripVersions = opts.StripVersions
+		return c
+	})
+}
+
+// Client reads arXiv's per-category announcement feeds. It implements
+// parser.Provider and tags papers with model.SourceArxivRSS.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	categories []string
+
+	// StripVersions returns papers under their base ID with the version in
+	// Paper.Revision, as arxiv.Client.StripVersions (default: false)
+	StripVersions bool
+}
+
+// NewClient creates a client for the given categories (e.g., "cs.CL").
+func NewClient(categories []string) *Client {
+	return NewClientWithOptions(nil, "", categories)
+}
+
+// NewClientWithOptions creates a client with a custom HTTP client and base URL.
+func NewClientWithOptions(httpClient *http.Client, baseURL string, categories []string) *Client {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = httpclient.New(defaultTimeout)
+	}
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		categories: categories,
+	}
+}
+
+// FetchPapers returns today's announcements across the configured categories.
+// A non-empty query keeps only papers whose title or abstract contains it.
+// Papers cross-listed in several categories are returned once.
+func (c *Client) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	needle := strings.ToLower(strings.TrimSpace(query))
+	seen := make(map[string]bool)
+	var papers []model.Paper
+
+	for _, category := range c.categories {
+		items, err := c.fetchCategory(ctx, category)
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", category, err)
+		}
+
+		for _, p := range convertItems(items) {
+			if c.StripVersions {
+				p.StripVersion()
+			}
+			if seen[p.ID] || !matches(p, needle) {
+				continue
+			}
+			seen[p.ID] = true
+			papers = append(papers, p)
+			if len(papers) >= limit {
+				return papers, nil
+			}
+		}
+	}
+
+	return papers, nil
+}
+
+func (c *Client) fetchCategory(ctx context.Context, category string) ([]rssItem, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/"+category, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var feed rssFeed
+	if err := feedxml.NewDecoder(feedxml.Reader(resp.Body)).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("%w: decode XML: %w", parser.ErrDecode, err)
+	}
+
+	return feed.Channel.Items, nil
+}
+
+func matches(p model.Paper, needle string) bool {
+	if needle == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(p.Title), needle) ||
+		strings.Contains(strings.ToLower(p.Abstract), needle)
+}
+
+func convertItems(items []rssItem) []model.Paper {
+	papers := make([]model.Paper, 0, len(items))
+
+	for _, item := range items {
+		id := extractID(item)
+		if id == "" {
+			continue
+		}
+
+		updated, _ := time.Parse(time.RFC1123Z, strings.TrimSpace(item.PubDate))
+
+		paper := model.Paper{
+			ID:         id,
+			Title:      textutil.CleanText(item.Title),
+			Abstract:   extractAbstract(item.Description),
+			Authors:    extractAuthors(item.Creator),
+			Categories: extractCategories(item.Categories),
+			UpdatedAt:  updated,
+			Links: []model.Link{
+				{URL: model.AbsURL(id), Type: model.LinkAbstract},
+				{URL: model.PDFURL(id), Type: model.LinkPDF},
+			},
+			Source:   model.SourceArxivRSS,
+			Announce: strings.TrimSpace(item.AnnounceType),
+		}
+		paper.SetTextLinks()
+		papers = append(papers, paper)
+	}
+
+	return papers
+}
+
+// extractID reads the versioned ID from the guid, falling back to the link.
+// Example: "oai:arXiv.org:2401.00001v1" -> "2401.00001v1"
+func extractID(item rssItem) string {
+	for _, raw := range []string{item.GUID, item.Link} {
+		if id, err := paperid.Parse(raw); err == nil {
+			return id.String()
+		}
+	}
+	return ""
+}
+
+// extractAbstract drops the "arXiv:... Announce Type: new" preamble.
+func extractAbstract(description string) string {
+	if i := strings.Index(description, "Abstract:"); i >= 0 {
+		description = description[i+len("Abstract:"):]
+	}
+	return textutil.CleanText(description)
+}
+
+// extractAuthors splits the dc:creator list ("A, B, and C").
+func extractAuthors(creator string) []string {
+	creator = strings.ReplaceAll(creator, " and ", ", ")
+	parts := strings.Split(creator, ",")
+
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		name := textutil.CollapseSpace(strings.TrimPrefix(strings.TrimSpace(part), "and "))
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func extractCategories(categories []string) []string {
+	terms := make([]string, 0, len(categories))
+	for _, c := range categories {
+		if term := strings.TrimSpace(c); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,231 @@
+package arxivrss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/providertest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+const mockCLFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss xmlns:arxiv="http://arxiv.org/schemas/atom" xmlns:dc="http://purl.org/dc/elements/1.1/" version="2.0">
+  <channel>
+    <title>cs.CL updates on arXiv.org</title>
+    <item>
+      <title>Retrieval Augmented Generation at Scale</title>
+      <link>https://arxiv.org/abs/2401.00001</link>
+      <description>arXiv:2401.00001v1 Announce Type: new
+Abstract: We evaluate retrieval augmented generation on three benchmark
+    datasets.</description>
+      <guid isPermaLink="false">oai:arXiv.org:2401.00001v1</guid>
+      <category>cs.CL</category>
+      <category>cs.IR</category>
+      <pubDate>Mon, 15 Jan 2024 00:00:00 -0500</pubDate>
+      <arxiv:announce_type>new</arxiv:announce_type>
+      <dc:creator>Alice Smith, Bob Jones, and Carol White</dc:creator>
+    </item>
+    <item>
+      <title>Tokenizers Revisited</title>
+      <link>https://arxiv.org/abs/2401.00002</link>
+      <description>arXiv:2401.00002v1 Announce Type: cross
+Abstract: A study of tokenizers.</description>
+      <guid isPermaLink="false">oai:arXiv.org:2401.00002v1</guid>
+      <category>cs.LG</category>
+      <category>cs.CL</category>
+      <pubDate>Mon, 15 Jan 2024 00:00:00 -0500</pubDate>
+      <arxiv:announce_type>cross</arxiv:announce_type>
+      <dc:creator>Dan Brown</dc:creator>
+    </item>
+  </channel>
+</rss>`
+
+const mockLGFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss xmlns:arxiv="http://arxiv.org/schemas/atom" xmlns:dc="http://purl.org/dc/elements/1.1/" version="2.0">
+  <channel>
+    <title>cs.LG updates on arXiv.org</title>
+    <item>
+      <title>Tokenizers Revisited</title>
+      <link>https://arxiv.org/abs/2401.00002</link>
+      <description>arXiv:2401.00002v1 Announce Type: new
+Abstract: A study of tokenizers.</description>
+      <guid isPermaLink="false">oai:arXiv.org:2401.00002v1</guid>
+      <category>cs.LG</category>
+      <category>cs.CL</category>
+      <pubDate>Mon, 15 Jan 2024 00:00:00 -0500</pubDate>
+      <arxiv:announce_type>new</arxiv:announce_type>
+      <dc:creator>Dan Brown</dc:creator>
+    </item>
+  </channel>
+</rss>`
+
+// Search API response for a paper first seen in the announcement feed.
+const mockSearchResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <entry>
+    <id>http://arxiv.org/abs/2401.00001v1</id>
+    <title>Retrieval Augmented Generation at Scale</title>
+    <summary>We evaluate retrieval augmented generation on three benchmark datasets.</summary>
+    <updated>2024-01-16T10:00:00Z</updated>
+    <author><name>Alice Smith</name></author>
+    <category term="cs.CL" />
+    <arxiv:comment>Accepted at ACL 2024</arxiv:comment>
+  </entry>
+</feed>`
+
+func newFeedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		switch r.URL.Path {
+		case "/cs.CL":
+			w.Write([]byte(mockCLFeed))
+		case "/cs.LG":
+			w.Write([]byte(mockLGFeed))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestClient_FetchPapers(t *testing.T) {
+	server := newFeedServer(t)
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL, []string{"cs.CL", "cs.LG"})
+
+	papers, err := client.FetchPapers(context.Background(), "", 10)
+	if err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+
+	// The cross-listed paper appears in both feeds but is returned once
+	if len(papers) != 2 {
+		t.Fatalf("expected 2 papers, got %d", len(papers))
+	}
+
+	paper := papers[0]
+	if paper.ID != "2401.00001v1" {
+		t.Errorf("expected ID '2401.00001v1', got %q", paper.ID)
+	}
+	if paper.Abstract != "We evaluate retrieval augmented generation on three benchmark datasets." {
+		t.Errorf("unexpected abstract %q", paper.Abstract)
+	}
+	if len(paper.Authors) != 3 || paper.Authors[2] != "Carol White" {
+		t.Errorf("unexpected authors %q", paper.Authors)
+	}
+	if len(paper.Categories) != 2 || paper.Categories[0] != "cs.CL" {
+		t.Errorf("unexpected categories %q", paper.Categories)
+	}
+	if paper.UpdatedAt.IsZero() {
+		t.Error("expected pubDate to be parsed")
+	}
+	if paper.Source != model.SourceArxivRSS {
+		t.Errorf("expected source %q, got %q", model.SourceArxivRSS, paper.Source)
+	}
+	if paper.Announce != model.AnnounceNew || papers[1].Announce != "cross" {
+		t.Errorf("unexpected announce types %q, %q", paper.Announce, papers[1].Announce)
+	}
+}
+
+func TestClient_FetchPapers_QueryAndLimit(t *testing.T) {
+	server := newFeedServer(t)
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL, []string{"cs.CL", "cs.LG"})
+
+	papers, err := client.FetchPapers(context.Background(), "tokenizers", 10)
+	if err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+	if len(papers) != 1 || papers[0].ID != "2401.00002v1" {
+		t.Errorf("expected only the tokenizer paper, got %+v", papers)
+	}
+
+	papers, err = client.FetchPapers(context.Background(), "", 1)
+	if err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+	if len(papers) != 1 {
+		t.Errorf("expected limit of 1, got %d", len(papers))
+	}
+}
+
+func TestExtractAuthors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"Alice Smith", []string{"Alice Smith"}},
+		{"Alice Smith, Bob Jones", []string{"Alice Smith", "Bob Jones"}},
+		{"Alice Smith, Bob Jones, and Carol White", []string{"Alice Smith", "Bob Jones", "Carol White"}},
+		{"Alice Smith and Bob Jones", []string{"Alice Smith", "Bob Jones"}},
+		{"", []string{}},
+	}
+
+	for _, tc := range tests {
+		result := extractAuthors(tc.input)
+		if strings.Join(result, "|") != strings.Join(tc.expected, "|") {
+			t.Errorf("extractAuthors(%q) = %q, want %q", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestDedupeAgainstSearchIngestion(t *testing.T) {
+	feeds := newFeedServer(t)
+	defer feeds.Close()
+	search := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(mockSearchResponse))
+	}))
+	defer search.Close()
+
+	ctx := context.Background()
+	store := memory.New()
+
+	announced, err := NewClientWithOptions(feeds.Client(), feeds.URL, []string{"cs.CL"}).FetchPapers(context.Background(), "", 10)
+	if err != nil {
+		t.Fatalf("announcement fetch failed: %v", err)
+	}
+	if err := store.SaveBatch(ctx, announced); err != nil {
+		t.Fatalf("save announcements: %v", err)
+	}
+
+	searched, err := arxiv.NewClientWithOptions(search.Client(), search.URL).FetchPapers(context.Background(), "retrieval", 10)
+	if err != nil {
+		t.Fatalf("search fetch failed: %v", err)
+	}
+	if err := store.SaveBatch(ctx, searched); err != nil {
+		t.Fatalf("save search results: %v", err)
+	}
+
+	count, _ := store.Count(ctx, true)
+	if count != int64(len(announced)) {
+		t.Errorf("expected %d stored papers after re-ingestion, got %d", len(announced), count)
+	}
+
+	paper, err := store.GetByID(ctx, "2401.00001v1")
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if paper.Source != model.SourceArxiv || paper.Comments != "Accepted at ACL 2024" {
+		t.Errorf("expected search record to replace announcement, got source %q comments %q", paper.Source, paper.Comments)
+	}
+}
+
+func TestClient_Conformance(t *testing.T) {
+	server := newFeedServer(t)
+	defer server.Close()
+
+	client := NewClientWithOptions(server.Client(), server.URL, []string{"cs.CL", "cs.LG"})
+	providertest.Run(t, client, providertest.Config{
+		Query:        "",
+		NoMatchQuery: "protein folding",
+		Name:         model.SourceArxivRSS,
+	})
+}
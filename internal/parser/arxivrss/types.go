@@ -0,0 +1,23 @@
+package arxivrss
+
+// RSS 2.0 structures for arXiv announcement feeds.
+
+type rssFeed struct {
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title string    `xml:"title"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title        string   `xml:"title"`
+	Link         string   `xml:"link"`
+	Description  string   `xml:"description"`
+	GUID         string   `xml:"guid"`
+	Categories   []string `xml:"category"`
+	PubDate      string   `xml:"pubDate"`
+	Creator      string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	AnnounceType string   `xml:"http://arxiv.org/schemas/atom announce_type"`
+}
@@ -0,0 +1,336 @@
+package biorxiv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
+)
+
+const (
+	defaultBaseURL  = "https://api.biorxiv.org/details"
+	defaultTimeout  = 30 * time.Second
+	defaultInterval = time.Second
+	defaultWindow   = 7 * 24 * time.Hour
+	defaultMaxAge   = 30 * 24 * time.Hour
+
+	dateLayout = "2006-01-02"
+	day        = 24 * time.Hour
+)
+
+// Servers the details API serves, by the Source of their papers.
+var servers = map[string]struct{ name, site string }{
+	model.SourceBioRxiv: {"biorxiv", "https://www.biorxiv.org"},
+	model.SourceMedRxiv: {"medrxiv", "https://www.medrxiv.org"},
+}
+
+func init() {
+	for source := range servers {
+		parser.Default.Register(source, func(opts parser.Options) parser.Provider {
+			c := NewClient(source)
+			c.UserAgent = opts.UserAgent
+			c.Interval = opts.Intervals[source]
+			c.MaxAge = opts.MaxAge
+			return c
+		})
+	}
+}
+
+// Client lists the preprints of bioRxiv or medRxiv. It implements
+// parser.Provider and tags papers with the server's Source.
+//
+// The API has no search: it lists the preprints posted within a date
+// range. FetchPapers walks back from today in windows of Window, keeping
+// the preprints that match the query, until it has enough or reaches
+// MaxAge. Papers come under their DOI, e.g. "10.1101/2024.01.05.574321",
+// with the version in Paper.Revision; Paper.DOI is the DOI of the
+// journal version once there is one.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	source     string
+
+	Window   time.Duration // Days listed per range of requests (default: 7 days)
+	MaxAge   time.Duration // How far back FetchPapers looks (default: 30 days)
+	Interval time.Duration // Minimum time between the starts of this client's requests (default: 1s)
+	Clock    clock.Clock   // Time source for today and the rate limit (default: system clock)
+
+	// UserAgent is sent with every request (default: version.UserAgent())
+	UserAgent string
+
+	limiter httpclient.Limiter
+}
+
+// NewClient creates a client for source, model.SourceBioRxiv or
+// model.SourceMedRxiv.
+func NewClient(source string) *Client {
+	return NewClientWithOptions(nil, "", source)
+}
+
+// NewClientWithOptions creates a client for source with the given HTTP
+// client and details endpoint; nil and "" keep the defaults.
+func NewClientWithOptions(httpClient *http.Client, baseURL, source string) *Client {
+	if httpClient == nil {
+		httpClient = httpclient.New(defaultTimeout)
+	}
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if _, ok := servers[source]; !ok {
+		source = model.SourceBioRxiv
+	}
+	return &Client{httpClient: httpClient, baseURL: strings.TrimSuffix(baseURL, "/"), source: source}
+}
+
+// StatusError is a response with a status other than 200. errors.Is
+// matches it against parser.ErrBadQuery (400), parser.ErrRateLimited
+// (429) or parser.ErrUnavailable (5xx).
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // The wait the response's Retry-After asked for, 0 when absent
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Is implements the matching described on StatusError.
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case parser.ErrBadQuery:
+		return e.StatusCode == http.StatusBadRequest
+	case parser.ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case parser.ErrUnavailable:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// RetryWait returns RetryAfter, for parser.RetryAfter.
+func (e *StatusError) RetryWait() time.Duration { return e.RetryAfter }
+
+// FetchPapers returns up to limit of the latest preprints, newest first,
+// whose title, abstract or category contains query; an empty query
+// matches every preprint. Each paper appears once, as its latest version
+// within MaxAge.
+func (c *Client) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	window := max(c.Window.Truncate(day), day)
+	if c.Window <= 0 {
+		window = defaultWindow
+	}
+	maxAge := c.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultMaxAge
+	}
+	today := clock.Or(c.Clock).Now().UTC().Truncate(day)
+	oldest := today.Add(-maxAge)
+
+	needle := strings.ToLower(strings.TrimSpace(query))
+	seen := make(map[string]bool)
+	var papers []model.Paper
+	for end := today; !end.Before(oldest) && len(papers) < limit; {
+		start := end.Add(-window + day)
+		if start.Before(oldest) {
+			start = oldest
+		}
+		records, err := c.fetchRange(ctx, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s to %s: %w", start.Format(dateLayout), end.Format(dateLayout), err)
+		}
+		for _, p := range c.latest(records) {
+			// A later version was already listed in a newer window
+			if seen[p.ID] {
+				continue
+			}
+			seen[p.ID] = true
+			if matches(p, needle) {
+				papers = append(papers, p)
+			}
+		}
+		end = start.Add(-day)
+	}
+	if len(papers) > limit {
+		papers = papers[:limit]
+	}
+	return papers, nil
+}
+
+// fetchRange returns the records posted from start to end, both
+// included, following the cursor through every page.
+func (c *Client) fetchRange(ctx context.Context, start, end time.Time) ([]record, error) {
+	var records []record
+	for cursor := 0; ; {
+		resp, err := c.requestPage(ctx, start, end, cursor)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Messages) == 0 {
+			return nil, fmt.Errorf("%w: response without messages", parser.ErrDecode)
+		}
+		msg := resp.Messages[0]
+		if msg.Status != "ok" {
+			if strings.HasPrefix(msg.Status, "no posts found") {
+				return records, nil
+			}
+			return nil, fmt.Errorf("%w: %s", parser.ErrBadQuery, msg.Status)
+		}
+		records = append(records, resp.Collection...)
+		cursor += len(resp.Collection)
+		if len(resp.Collection) == 0 || cursor >= int(msg.Total) {
+			return records, nil
+		}
+	}
+}
+
+func (c *Client) requestPage(ctx context.Context, start, end time.Time, cursor int) (detailsResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return detailsResponse{}, err
+	}
+	reqURL := fmt.Sprintf("%s/%s/%s/%s/%d/json", c.baseURL, servers[c.source].name,
+		start.Format(dateLayout), end.Format(dateLayout), cursor)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return detailsResponse{}, fmt.Errorf("build request: %w", err)
+	}
+	ua := c.UserAgent
+	if ua == "" {
+		ua = version.UserAgent()
+	}
+	req.Header.Set("User-Agent", ua)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return detailsResponse{}, fmt.Errorf("HTTP request: %w", err)
+		}
+		return detailsResponse{}, fmt.Errorf("%w: HTTP request: %w", parser.ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		e := &StatusError{StatusCode: resp.StatusCode}
+		e.RetryAfter, _ = httpclient.RetryAfter(resp, clock.Or(c.Clock).Now())
+		return detailsResponse{}, e
+	}
+
+	var out detailsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		if ctx.Err() != nil {
+			return detailsResponse{}, fmt.Errorf("read response: %w", err)
+		}
+		return detailsResponse{}, fmt.Errorf("%w: decode JSON: %w", parser.ErrDecode, err)
+	}
+	return out, nil
+}
+
+// wait blocks until the client's rate limit allows another request.
+func (c *Client) wait(ctx context.Context) error {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return c.limiter.Wait(ctx, c.Clock, interval)
+}
+
+// latest converts records, one paper per DOI at its highest version,
+// newest first.
+func (c *Client) latest(records []record) []model.Paper {
+	byID := make(map[string]model.Paper)
+	for _, r := range records {
+		p := c.convertRecord(r)
+		if p.ID == "" {
+			continue
+		}
+		if prev, ok := byID[p.ID]; ok && prev.Revision >= p.Revision {
+			continue
+		}
+		byID[p.ID] = p
+	}
+	papers := make([]model.Paper, 0, len(byID))
+	for _, p := range byID {
+		papers = append(papers, p)
+	}
+	sort.Slice(papers, func(i, j int) bool {
+		if !papers[i].UpdatedAt.Equal(papers[j].UpdatedAt) {
+			return papers[i].UpdatedAt.After(papers[j].UpdatedAt)
+		}
+		return papers[i].ID < papers[j].ID
+	})
+	return papers
+}
+
+func (c *Client) convertRecord(r record) model.Paper {
+	doi := strings.TrimSpace(r.DOI)
+	revision, _ := strconv.Atoi(strings.TrimSpace(r.Version))
+	revision = max(revision, 1)
+	posted, _ := time.Parse(dateLayout, strings.TrimSpace(r.Date))
+
+	paper := model.Paper{
+		ID:        doi,
+		Revision:  revision,
+		Title:     textutil.CleanText(r.Title),
+		Abstract:  textutil.CleanText(r.Abstract),
+		Authors:   authors(r.Authors),
+		UpdatedAt: posted,
+		Source:    c.source,
+	}
+	if category := strings.TrimSpace(r.Category); category != "" {
+		paper.Categories = []string{category}
+		paper.PrimaryCategory = category
+	}
+	if revision == 1 {
+		paper.Published = posted
+	}
+	if published := strings.TrimSpace(r.Published); published != "" && published != "NA" {
+		paper.DOI = published
+	}
+	if doi != "" {
+		content := fmt.Sprintf("%s/content/%sv%d", servers[c.source].site, doi, revision)
+		paper.Links = []model.Link{
+			{URL: content, Type: model.LinkAbstract},
+			{URL: content + ".full.pdf", Type: model.LinkPDF},
+		}
+	}
+	paper.SetTextLinks()
+	return paper
+}
+
+// authors splits the API's list, "Smith, J.; Doe, A. B.", into names
+// written forenames first, "J. Smith" and "A. B. Doe".
+func authors(list string) []string {
+	var names []string
+	for _, part := range strings.Split(list, ";") {
+		last, first, ok := strings.Cut(part, ",")
+		name := textutil.CollapseSpace(last)
+		if ok && strings.TrimSpace(first) != "" {
+			name = textutil.CollapseSpace(first + " " + last)
+		}
+		if name != "" {
+			names = append(names, textutil.CleanText(name))
+		}
+	}
+	return names
+}
+
+func matches(p model.Paper, needle string) bool {
+	if needle == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(p.Title), needle) ||
+		strings.Contains(strings.ToLower(p.Abstract), needle) ||
+		strings.Contains(strings.ToLower(p.PrimaryCategory), needle)
+}
@@ -0,0 +1,261 @@
+package biorxiv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+)
+
+// detailsServer serves the fixture named by each request's path, and
+// no posts for any other, and records the paths it gets.
+func detailsServer(t *testing.T, pages map[string]string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		fixture, ok := pages[r.URL.Path]
+		if !ok {
+			fixture = "details-noposts.json"
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Errorf("read fixture: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server, &paths
+}
+
+var twoWeeks = map[string]string{
+	"/details/biorxiv/2024-01-04/2024-01-10/0/json": "details-week1-page1.json",
+	"/details/biorxiv/2024-01-04/2024-01-10/2/json": "details-week1-page2.json",
+	"/details/biorxiv/2023-12-28/2024-01-03/0/json": "details-week2.json",
+}
+
+// fixedNow is a clock stopped at now whose timers still fire, so the
+// rate limit's short waits pass.
+type fixedNow struct {
+	clock.Real
+	now time.Time
+}
+
+func (c fixedNow) Now() time.Time { return c.now }
+
+// newTestClient returns a bioRxiv client whose today is 2024-01-10 and
+// that looks two weeks back, a week at a time.
+func newTestClient(server *httptest.Server) *Client {
+	c := NewClientWithOptions(server.Client(), server.URL+"/details", model.SourceBioRxiv)
+	c.Clock = fixedNow{now: time.Date(2024, 1, 10, 15, 30, 0, 0, time.UTC)}
+	c.Interval = time.Nanosecond
+	c.Window = 7 * 24 * time.Hour
+	c.MaxAge = 14 * 24 * time.Hour
+	return c
+}
+
+func ids(papers []model.Paper) []string {
+	var out []string
+	for _, p := range papers {
+		out = append(out, p.ID)
+	}
+	return out
+}
+
+func TestClient_FetchPapers(t *testing.T) {
+	server, paths := detailsServer(t, twoWeeks)
+	papers, err := newTestClient(server).FetchPapers(context.Background(), "", 10)
+	if err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+
+	wantPaths := []string{
+		"/details/biorxiv/2024-01-04/2024-01-10/0/json",
+		"/details/biorxiv/2024-01-04/2024-01-10/2/json",
+		"/details/biorxiv/2023-12-28/2024-01-03/0/json",
+		"/details/biorxiv/2023-12-27/2023-12-27/0/json",
+	}
+	if !reflect.DeepEqual(*paths, wantPaths) {
+		t.Errorf("paths = %q, want %q", *paths, wantPaths)
+	}
+	want := []string{"10.1101/2024.01.04.574100", "10.1101/2024.01.05.574321", "10.1101/2023.12.30.573050", "10.1101/2023.12.29.573001"}
+	if got := ids(papers); !reflect.DeepEqual(got, want) {
+		t.Fatalf("IDs = %v, want %v, newest first", got, want)
+	}
+
+	// The second version, posted on the 9th, replaces the first
+	p := papers[0]
+	if p.Revision != 2 || p.Version() != 2 || p.Title != "Single-cell atlas of the developing mouse cortex" {
+		t.Errorf("revision %d, title %q", p.Revision, p.Title)
+	}
+	if !p.UpdatedAt.Equal(time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC)) || !p.Published.IsZero() {
+		t.Errorf("updated %v, published %v; want the 9th and unknown", p.UpdatedAt, p.Published)
+	}
+	if want := []string{"J. Smith", "A. B. Doe", "M. García"}; !reflect.DeepEqual(p.Authors, want) {
+		t.Errorf("authors = %q, want %q", p.Authors, want)
+	}
+	if !reflect.DeepEqual(p.Categories, []string{"neuroscience"}) || p.PrimaryCategory != "neuroscience" || p.Source != model.SourceBioRxiv {
+		t.Errorf("categories %v (primary %q), source %q", p.Categories, p.PrimaryCategory, p.Source)
+	}
+	wantLinks := []model.Link{
+		{URL: "https://www.biorxiv.org/content/10.1101/2024.01.04.574100v2", Type: model.LinkAbstract},
+		{URL: "https://www.biorxiv.org/content/10.1101/2024.01.04.574100v2.full.pdf", Type: model.LinkPDF},
+		{URL: "https://github.com/example/atlas", Type: model.LinkCode, FoundIn: model.FoundInAbstract},
+	}
+	if !reflect.DeepEqual(p.Links, wantLinks) {
+		t.Errorf("links = %+v, want %+v", p.Links, wantLinks)
+	}
+	if p.DOI != "" {
+		t.Errorf("DOI = %q, want none before journal publication", p.DOI)
+	}
+
+	// A first version published in a journal since
+	aligner := papers[1]
+	if aligner.DOI != "10.1093/bioinformatics/btae001" || !aligner.Published.Equal(aligner.UpdatedAt) || aligner.Revision != 1 {
+		t.Errorf("DOI %q, published %v, updated %v, revision %d", aligner.DOI, aligner.Published, aligner.UpdatedAt, aligner.Revision)
+	}
+}
+
+func TestClient_FetchPapers_Query(t *testing.T) {
+	server, _ := detailsServer(t, twoWeeks)
+	// Matches a category as well as titles and abstracts
+	papers, err := newTestClient(server).FetchPapers(context.Background(), "Bioinformatics", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"10.1101/2024.01.05.574321", "10.1101/2023.12.29.573001"}; !reflect.DeepEqual(ids(papers), want) {
+		t.Errorf("IDs = %v, want %v", ids(papers), want)
+	}
+}
+
+func TestClient_FetchPapers_StopsAtLimit(t *testing.T) {
+	server, paths := detailsServer(t, twoWeeks)
+	papers, err := newTestClient(server).FetchPapers(context.Background(), "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(papers) != 1 || len(*paths) != 2 {
+		t.Errorf("%d papers after %d requests, want 1 from the first window's 2 pages", len(papers), len(*paths))
+	}
+}
+
+func TestClient_MedRxiv(t *testing.T) {
+	server, paths := detailsServer(t, map[string]string{
+		"/details/medrxiv/2024-01-04/2024-01-10/0/json": "details-week2.json",
+	})
+	c := newTestClient(server)
+	c.source = model.SourceMedRxiv
+	c.MaxAge = 3 * 24 * time.Hour
+
+	papers, err := c.FetchPapers(context.Background(), "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Three days back from the 10th is one window, shortened to the 7th
+	if want := []string{"/details/medrxiv/2024-01-07/2024-01-10/0/json"}; !reflect.DeepEqual(*paths, want) {
+		t.Errorf("paths = %q, want %q", *paths, want)
+	}
+	if len(papers) != 0 {
+		t.Errorf("got %d papers, want none", len(papers))
+	}
+
+	p := c.convertRecord(record{DOI: "10.1101/2024.01.08.24300001", Version: "1", Date: "2024-01-08"})
+	if p.Source != model.SourceMedRxiv || p.Links[0].URL != "https://www.medrxiv.org/content/10.1101/2024.01.08.24300001v1" {
+		t.Errorf("source %q, links %+v", p.Source, p.Links)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"bad interval", http.StatusOK, `{"messages":[{"status":"Error: interval must be valid dates (YYYY-MM-DD)"}],"collection":[]}`, parser.ErrBadQuery},
+		{"bad request", http.StatusBadRequest, "", parser.ErrBadQuery},
+		{"rate limited", http.StatusTooManyRequests, "", parser.ErrRateLimited},
+		{"unavailable", http.StatusServiceUnavailable, "", parser.ErrUnavailable},
+		{"not JSON", http.StatusOK, "<html>maintenance</html>", parser.ErrDecode},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(server).FetchPapers(context.Background(), "", 5)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestClient_StatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/details/biorxiv/2024-01-04/2024-01-10/0/json":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	c := newTestClient(server)
+
+	_, err := c.FetchPapers(context.Background(), "", 5)
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want a 429 StatusError", err)
+	}
+	if wait, ok := parser.RetryAfter(err); !ok || wait != 30*time.Second {
+		t.Errorf("RetryAfter = %v, %v; want 30s", wait, ok)
+	}
+
+	// Other statuses are not rate limiting
+	c.baseURL = server.URL + "/missing"
+	_, err = c.FetchPapers(context.Background(), "", 5)
+	if !errors.As(err, &se) || se.StatusCode != http.StatusNotFound || errors.Is(err, parser.ErrRateLimited) || errors.Is(err, parser.ErrUnavailable) {
+		t.Errorf("err = %v, want a 404 StatusError that is neither rate limiting nor unavailability", err)
+	}
+}
+
+func TestAuthors(t *testing.T) {
+	tests := map[string][]string{
+		"":                                nil,
+		"Smith, J.; Doe, A. B.":           {"J. Smith", "A. B. Doe"},
+		"Smith, J.; ; Consortium, The;":   {"J. Smith", "The Consortium"},
+		"COVID-19 Genomics UK Consortium": {"COVID-19 Genomics UK Consortium"},
+	}
+	for list, want := range tests {
+		if got := authors(list); !reflect.DeepEqual(got, want) {
+			t.Errorf("authors(%q) = %q, want %q", list, got, want)
+		}
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	for _, source := range []string{model.SourceBioRxiv, model.SourceMedRxiv} {
+		p, err := parser.Default.New(source, parser.Options{UserAgent: "tester/1.0", MaxAge: 90 * 24 * time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c, ok := p.(*Client); !ok || c.source != source || c.UserAgent != "tester/1.0" || c.MaxAge != 90*24*time.Hour {
+			t.Errorf("%s: registered provider = %#v", source, p)
+		}
+	}
+}
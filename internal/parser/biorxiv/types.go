@@ -0,0 +1,53 @@
+package biorxiv
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// JSON structures of the bioRxiv details API
+// (https://api.biorxiv.org/details/), shared by medRxiv.
+
+type detailsResponse struct {
+	Messages   []message `json:"messages"`
+	Collection []record  `json:"collection"`
+}
+
+type message struct {
+	Status string  `json:"status"` // "ok", or e.g. "no posts found"
+	Cursor flexInt `json:"cursor"`
+	Count  flexInt `json:"count"`
+	Total  flexInt `json:"total"`
+}
+
+type record struct {
+	DOI       string `json:"doi"`
+	Title     string `json:"title"`
+	Authors   string `json:"authors"` // "Smith, J.; Doe, A. B."
+	Date      string `json:"date"`    // Posting date of this version, "2024-01-05"
+	Version   string `json:"version"`
+	Category  string `json:"category"`
+	Abstract  string `json:"abstract"`
+	Published string `json:"published"` // DOI of the journal version, or "NA"
+}
+
+// flexInt is a count the API sends as a number or as a string.
+type flexInt int
+
+func (n *flexInt) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		data = []byte(strings.TrimSpace(s))
+	}
+	if string(data) == "null" || len(data) == 0 {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.Atoi(string(data))
+	if err != nil {
+		return err
+	}
+	*n = flexInt(v)
+	return nil
+}
@@ -0,0 +1,75 @@
+// Package feedxml decodes XML feeds the way proxies sometimes hand them
+// over: with a UTF-8 byte order mark, re-encoded as ISO-8859-1 or
+// windows-1252, or with bytes that are not valid UTF-8.
+package feedxml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// prologSize is how much of a feed Reader reads ahead for the XML
+// declaration.
+const prologSize = 512
+
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
+// declaredEncoding matches the encoding in an XML declaration.
+var declaredEncoding = regexp.MustCompile(`^<\?xml[^>]*?\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// charsets are the single-byte encodings Reader converts from, by
+// lowercased label.
+var charsets = map[string]encoding.Encoding{
+	"iso-8859-1":   charmap.ISO8859_1,
+	"iso8859-1":    charmap.ISO8859_1,
+	"iso_8859-1":   charmap.ISO8859_1,
+	"latin1":       charmap.ISO8859_1,
+	"latin-1":      charmap.ISO8859_1,
+	"l1":           charmap.ISO8859_1,
+	"windows-1252": charmap.Windows1252,
+	"cp1252":       charmap.Windows1252,
+	"x-cp1252":     charmap.Windows1252,
+}
+
+// Reader returns r as valid UTF-8: a leading byte order mark is dropped,
+// a feed declared as ISO-8859-1 or windows-1252 is converted, and any
+// other byte sequence that is not UTF-8 becomes U+FFFD. Decode what it
+// returns with a Decoder from NewDecoder, which accepts the declaration
+// the conversion leaves in place.
+func Reader(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(len(bom)); bytes.Equal(head, bom) {
+		br.Discard(len(bom))
+	}
+	head, _ := br.Peek(prologSize)
+	if m := declaredEncoding.FindSubmatch(head); m != nil {
+		if enc, ok := charsets[strings.ToLower(string(m[1]))]; ok {
+			return enc.NewDecoder().Reader(br)
+		}
+	}
+	return unicode.UTF8.NewDecoder().Reader(br)
+}
+
+// NewDecoder returns an xml.Decoder for a stream from Reader. A feed
+// declared in a charset Reader does not convert fails with an error
+// naming it.
+func NewDecoder(r io.Reader) *xml.Decoder {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if _, ok := charsets[strings.ToLower(label)]; ok {
+			// Reader has converted it already
+			return input, nil
+		}
+		return nil, fmt.Errorf("unsupported charset %q", label)
+	}
+	return dec
+}
@@ -0,0 +1,61 @@
+package feedxml
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+type doc struct {
+	Title string `xml:"title"`
+}
+
+func decode(t *testing.T, raw string) (string, error) {
+	t.Helper()
+	var d doc
+	err := NewDecoder(Reader(strings.NewReader(raw))).Decode(&d)
+	return d.Title, err
+}
+
+func TestDecoder(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"plain", `<?xml version="1.0" encoding="UTF-8"?><doc><title>Café</title></doc>`, "Café"},
+		{"BOM", "\xef\xbb\xbf" + `<?xml version="1.0" encoding="UTF-8"?><doc><title>Café</title></doc>`, "Café"},
+		{"BOM without declaration", "\xef\xbb\xbf<doc><title>Café</title></doc>", "Café"},
+		{"latin-1", `<?xml version="1.0" encoding="ISO-8859-1"?><doc><title>Caf` + "\xe9" + `</title></doc>`, "Café"},
+		{"windows-1252", `<?xml version='1.0' encoding='windows-1252'?><doc><title>` + "\x93Quoted\x94 \x96 dash" + `</title></doc>`, "“Quoted” – dash"},
+		{"invalid UTF-8", `<?xml version="1.0" encoding="UTF-8"?><doc><title>Bad ` + "\xff\xfe" + ` bytes</title></doc>`, "Bad �� bytes"},
+	}
+	for _, tc := range tests {
+		got, err := decode(t, tc.raw)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: title %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestDecoder_UnsupportedCharset(t *testing.T) {
+	_, err := decode(t, `<?xml version="1.0" encoding="Shift_JIS"?><doc><title>x</title></doc>`)
+	if err == nil || !strings.Contains(err.Error(), `"Shift_JIS"`) {
+		t.Errorf("err = %v, want one naming the charset", err)
+	}
+}
+
+func TestReader_LongProlog(t *testing.T) {
+	// A declaration is only looked for at the start; a feed without one
+	// is read as UTF-8 however long it is
+	raw := "<doc><title>" + strings.Repeat("é", 1000) + "</title></doc>"
+	b, err := io.ReadAll(Reader(strings.NewReader(raw)))
+	if err != nil || string(b) != raw {
+		t.Errorf("Reader changed a valid UTF-8 feed: %v", err)
+	}
+	var d doc
+	if err := xml.Unmarshal(b, &d); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package multi merges the papers of several providers into one result,
+// so that no single source crowds the others out.
+package multi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+)
+
+// Source is one provider of an aggregate.
+type Source struct {
+	Name     string          // Provider name, recorded on papers that carry no Source
+	Provider parser.Provider // Where the papers come from
+	Share    float64         // Relative share of the limit, e.g. 70 and 30 (default: equal shares)
+	Cap      int             // Most papers taken from this source (default: no cap beyond its share)
+}
+
+// Provider fetches from every source concurrently and merges the results.
+// Each source is asked for up to the whole limit, so that the quota of a
+// source that fails or runs short can go to the others; the limit is then
+// split by Share, no source gives more than its Cap, and each source that
+// succeeded gets at least Floor papers when it has them.
+//
+// Duplicates across sources are kept; the pipeline's dedup drops the later
+// copies, so with Interleave no source's papers all come after another's.
+type Provider struct {
+	Sources []Source
+
+	Floor      int  // Papers guaranteed to each source that succeeded, as far as it has them and the limit allows (default: 0)
+	Interleave bool // Alternate sources in the merged list instead of listing them one after the other (default: false)
+}
+
+// New returns a provider over sources with equal shares.
+func New(sources ...Source) *Provider {
+	return &Provider{Sources: sources}
+}
+
+// FetchPapers implements parser.Provider.
+func (p *Provider) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	result, err := p.FetchResult(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return result.Papers, nil
+}
+
+// FetchResult fetches like FetchPapers and reports the papers taken from
+// each source in Sources; a source that failed counts 0. Total sums the
+// totals of the sources that report one, and Duplicates their dropped
+// repeats. Only when every source fails is
+// the fetch an error.
+func (p *Provider) FetchResult(ctx context.Context, query string, limit int) (parser.FetchResult, error) {
+	if len(p.Sources) == 0 {
+		return parser.FetchResult{}, errors.New("multi: no sources")
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	fetched := make([]parser.FetchResult, len(p.Sources))
+	errs := make([]error, len(p.Sources))
+	var wg sync.WaitGroup
+	for i, s := range p.Sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetched[i], errs[i] = fetch(ctx, s, query, want(s, limit))
+		}()
+	}
+	wg.Wait()
+
+	available := make([]int, len(p.Sources))
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			log.Printf("Source %s failed, its quota goes to the others: %v", p.Sources[i].Name, err)
+			continue
+		}
+		available[i] = len(fetched[i].Papers)
+	}
+	if failed == len(p.Sources) {
+		return parser.FetchResult{}, fmt.Errorf("every source failed: %w", errors.Join(errs...))
+	}
+
+	quotas := Allocate(limit, p.shares(), available, p.Floor)
+	result := parser.FetchResult{Sources: make(map[string]int, len(p.Sources))}
+	lists := make([][]model.Paper, len(p.Sources))
+	for i, s := range p.Sources {
+		lists[i] = append([]model.Paper(nil), fetched[i].Papers[:quotas[i]]...)
+		for j := range lists[i] {
+			if lists[i][j].Source == "" {
+				lists[i][j].Source = s.Name
+			}
+		}
+		result.Sources[s.Name] += quotas[i]
+		result.Total += fetched[i].Total
+		result.Duplicates += fetched[i].Duplicates
+	}
+	result.Papers = merge(lists, p.Interleave)
+	return result, nil
+}
+
+// want returns how many papers to ask s for.
+func want(s Source, limit int) int {
+	if s.Cap > 0 {
+		return min(s.Cap, limit)
+	}
+	return limit
+}
+
+// fetch asks s for up to n papers, with the total of sources that report
+// one.
+func fetch(ctx context.Context, s Source, query string, n int) (parser.FetchResult, error) {
+	var result parser.FetchResult
+	var err error
+	if rf, ok := s.Provider.(parser.ResultFetcher); ok {
+		result, err = rf.FetchResult(ctx, query, n)
+	} else {
+		result.Papers, err = s.Provider.FetchPapers(ctx, query, n)
+	}
+	if err != nil {
+		return parser.FetchResult{}, err
+	}
+	if len(result.Papers) > n {
+		result.Papers = result.Papers[:n]
+	}
+	return result, nil
+}
+
+// shares returns the weight of each source, equal when none is set.
+func (p *Provider) shares() []float64 {
+	shares := make([]float64, len(p.Sources))
+	set := false
+	for i, s := range p.Sources {
+		shares[i] = max(s.Share, 0)
+		set = set || s.Share > 0
+	}
+	if !set {
+		for i := range shares {
+			shares[i] = 1
+		}
+	}
+	return shares
+}
+
+// Allocate splits limit among sources in proportion to shares, giving none
+// more than it has available. What a source cannot use goes to the others
+// in proportion to theirs. Then every source is raised to floor, or to all
+// it has if less, by taking from the sources furthest above the floor.
+// Sources with a zero share get only their floor and what nobody else can
+// use.
+func Allocate(limit int, shares []float64, available []int, floor int) []int {
+	quotas := make([]int, len(shares))
+	remaining := limit
+	for remaining > 0 {
+		var open []int
+		total := 0.0
+		for i := range shares {
+			if quotas[i] < available[i] && shares[i] > 0 {
+				open = append(open, i)
+				total += shares[i]
+			}
+		}
+		if len(open) == 0 {
+			break
+		}
+		given := 0
+		for i, n := range apportion(remaining, open, shares, total) {
+			n = min(n, available[i]-quotas[i])
+			quotas[i] += n
+			given += n
+		}
+		remaining -= given
+		if given == 0 {
+			break
+		}
+	}
+	// Leftovers go to zero-share sources, in order
+	for i := range quotas {
+		n := min(remaining, available[i]-quotas[i])
+		quotas[i] += n
+		remaining -= n
+	}
+
+	for i := range quotas {
+		need := min(floor, available[i])
+		for quotas[i] < need {
+			// Take one from the source with the most above its own floor
+			from := -1
+			for j := range quotas {
+				spare := quotas[j] - min(floor, available[j])
+				if j != i && spare > 0 && (from < 0 || spare > quotas[from]-min(floor, available[from])) {
+					from = j
+				}
+			}
+			if from < 0 {
+				break
+			}
+			quotas[from]--
+			quotas[i]++
+		}
+	}
+	return quotas
+}
+
+// apportion splits n among the sources in open by largest remainder, so
+// the parts add up to n.
+func apportion(n int, open []int, shares []float64, total float64) map[int]int {
+	parts := make(map[int]int, len(open))
+	type rest struct {
+		i    int
+		frac float64
+	}
+	rests := make([]rest, 0, len(open))
+	given := 0
+	for _, i := range open {
+		exact := float64(n) * shares[i] / total
+		parts[i] = int(exact)
+		given += parts[i]
+		rests = append(rests, rest{i, exact - float64(parts[i])})
+	}
+	sort.SliceStable(rests, func(a, b int) bool { return rests[a].frac > rests[b].frac })
+	for k := 0; given < n; k++ {
+		parts[rests[k%len(rests)].i]++
+		given++
+	}
+	return parts
+}
+
+// merge concatenates lists, or takes one paper from each in turn when
+// interleave is set.
+func merge(lists [][]model.Paper, interleave bool) []model.Paper {
+	papers := []model.Paper{}
+	if !interleave {
+		for _, l := range lists {
+			papers = append(papers, l...)
+		}
+		return papers
+	}
+	for k := 0; ; k++ {
+		added := false
+		for _, l := range lists {
+			if k < len(l) {
+				papers = append(papers, l[k])
+				added = true
+			}
+		}
+		if !added {
+			return papers
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/providertest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/pipeline"
+)
+
+// fixture returns n papers with IDs prefix.0001v1 onwards.
+func fixture(prefix string, n int) providertest.Fixture {
+	papers := make(providertest.Fixture, n)
+	for i := range papers {
+		papers[i] = model.Paper{
+			ID:        fmt.Sprintf("%s.%04dv1", prefix, i+1),
+			Title:     fmt.Sprintf("Retrieval paper %d", i+1),
+			Authors:   []string{"A. Author"},
+			UpdatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	return papers
+}
+
+type failing struct{}
+
+func (failing) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	return nil, errors.New("service unavailable")
+}
+
+func ids(papers []model.Paper) []string {
+	out := make([]string, len(papers))
+	for i, p := range papers {
+		out[i] = p.ID
+	}
+	return out
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		shares    []float64
+		available []int
+		floor     int
+		expected  []int
+	}{
+		{"proportional", 10, []float64{70, 30}, []int{10, 10}, 0, []int{7, 3}},
+		{"largest remainder", 10, []float64{1, 1, 1}, []int{10, 10, 10}, 0, []int{4, 3, 3}},
+		{"short source redistributes", 10, []float64{70, 30}, []int{10, 1}, 0, []int{9, 1}},
+		{"failed source redistributes", 10, []float64{1, 1, 1}, []int{10, 0, 10}, 0, []int{5, 0, 5}},
+		{"nothing to give", 10, []float64{1, 1}, []int{2, 3}, 0, []int{2, 3}},
+		{"floor", 10, []float64{95, 5}, []int{10, 10}, 3, []int{7, 3}},
+		{"floor above what is available", 10, []float64{95, 5}, []int{10, 2}, 3, []int{8, 2}},
+		{"floors beyond the limit", 2, []float64{1, 1, 1}, []int{5, 5, 5}, 1, []int{1, 1, 0}},
+		{"zero share gets leftovers", 10, []float64{1, 0}, []int{4, 10}, 0, []int{4, 6}},
+	}
+
+	for _, tc := range tests {
+		got := Allocate(tc.limit, tc.shares, tc.available, tc.floor)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s: Allocate = %v, want %v", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestProvider_Caps(t *testing.T) {
+	p := &Provider{Sources: []Source{
+		{Name: "big", Provider: fixture("2401", 50), Share: 70},
+		{Name: "small", Provider: fixture("2402", 50), Share: 30, Cap: 2},
+	}}
+
+	result, err := p.FetchResult(context.Background(), "retrieval", 10)
+	if err != nil {
+		t.Fatalf("FetchResult failed: %v", err)
+	}
+	if want := map[string]int{"big": 8, "small": 2}; !reflect.DeepEqual(result.Sources, want) {
+		t.Errorf("sources = %v, want %v", result.Sources, want)
+	}
+	if len(result.Papers) != 10 || result.Papers[0].Source != "big" || result.Papers[9].Source != "small" {
+		t.Errorf("papers = %v, want big's 8 then small's 2", ids(result.Papers))
+	}
+}
+
+func TestProvider_Interleave(t *testing.T) {
+	p := &Provider{
+		Sources: []Source{
+			{Name: "a", Provider: fixture("2401", 50)},
+			{Name: "b", Provider: fixture("2402", 2)},
+		},
+		Interleave: true,
+	}
+
+	papers, err := p.FetchPapers(context.Background(), "retrieval", 6)
+	if err != nil {
+		t.Fatalf("FetchPapers failed: %v", err)
+	}
+	// b runs out after two, so a fills the rest after the alternation
+	want := []string{"2401.0001v1", "2402.0001v1", "2401.0002v1", "2402.0002v1", "2401.0003v1", "2401.0004v1"}
+	if got := ids(papers); !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestProvider_FailureRedistributes(t *testing.T) {
+	p := &Provider{
+		Sources: []Source{
+			{Name: "a", Provider: fixture("2401", 50)},
+			{Name: "down", Provider: failing{}},
+			{Name: "b", Provider: fixture("2402", 50)},
+		},
+		Floor: 4,
+	}
+
+	result, err := p.FetchResult(context.Background(), "retrieval", 9)
+	if err != nil {
+		t.Fatalf("FetchResult failed: %v", err)
+	}
+	if want := map[string]int{"a": 5, "down": 0, "b": 4}; !reflect.DeepEqual(result.Sources, want) {
+		t.Errorf("sources = %v, want %v", result.Sources, want)
+	}
+
+	p.Sources = []Source{{Name: "down", Provider: failing{}}}
+	if _, err := p.FetchResult(context.Background(), "retrieval", 9); err == nil {
+		t.Error("FetchResult succeeded with every source down")
+	}
+}
+
+func TestProvider_Conformance(t *testing.T) {
+	providertest.Run(t, New(
+		Source{Name: "a", Provider: fixture("2401", 3)},
+		Source{Name: "b", Provider: fixture("2402", 3)},
+	), providertest.Config{Query: "retrieval", NoMatchQuery: "no such topic"})
+}
+
+func TestRun_ReportsSources(t *testing.T) {
+	p := New(
+		Source{Name: "a", Provider: fixture("2401", 50)},
+		Source{Name: "b", Provider: fixture("2402", 1)},
+	)
+	svc := pipeline.NewService(map[string]parser.Provider{"mixed": p}, nil)
+
+	result, err := svc.Run(context.Background(), pipeline.RunParams{Provider: "mixed", Query: "retrieval", Limit: 6, SkipFilter: true, SkipSave: true})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := map[string]int{"a": 5, "b": 1}
+	if !reflect.DeepEqual(result.Sources, want) {
+		t.Errorf("RunResult.Sources = %v, want %v", result.Sources, want)
+	}
+	if got := result.Summary(nil, 0).Sources; !reflect.DeepEqual(got, want) {
+		t.Errorf("Summary.Sources = %v, want %v", got, want)
+	}
+	if result.Passed[5].Source != "b" {
+		t.Errorf("last paper from %q, want provenance b", result.Passed[5].Source)
+	}
+}
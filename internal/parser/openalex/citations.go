@@ -0,0 +1,51 @@
+package openalex
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// arxivDOIPrefix starts the DOI arXiv registers for every paper,
+	// followed by its base ID, e.g. 10.48550/arXiv.2301.00001. OpenAlex
+	// stores DOIs in lower case.
+	arxivDOIPrefix = "10.48550/arxiv."
+
+	maxFilterValues = 50 // Values one OpenAlex filter may OR together
+)
+
+// CitationCounts returns the cited-by counts OpenAlex holds for the arXiv
+// papers with the given base IDs, keyed by ID, which makes the client a
+// calibrate.CitationCounter. Papers are found by their arXiv DOI, so
+// citations OpenAlex credits to a separate journal version are not
+// counted. IDs OpenAlex does not know are left out.
+func (c *Client) CitationCounts(ctx context.Context, ids []string) (map[string]int, error) {
+	counts := make(map[string]int, len(ids))
+	for batch := range slices.Chunk(ids, maxFilterValues) {
+		byDOI := make(map[string]string, len(batch))
+		dois := make([]string, 0, len(batch))
+		for _, id := range batch {
+			doi := arxivDOIPrefix + strings.ToLower(strings.TrimSpace(id))
+			byDOI[doi] = id
+			dois = append(dois, doi)
+		}
+
+		q := url.Values{}
+		q.Set("filter", "doi:"+strings.Join(dois, "|"))
+		q.Set("select", "id,doi,cited_by_count")
+		q.Set("per-page", strconv.Itoa(maxFilterValues))
+		resp, err := c.request(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range resp.Results {
+			if id, ok := byDOI[strings.ToLower(strings.TrimPrefix(w.DOI, doiPrefix))]; ok {
+				counts[id] = w.CitedByCount
+			}
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,55 @@
+package openalex
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/calibrate"
+)
+
+var _ calibrate.CitationCounter = (*Client)(nil)
+
+func TestClient_CitationCounts(t *testing.T) {
+	server, requests := worksServer(t, map[string]string{"": "works-citations.json"})
+	c := newTestClient(server)
+	c.APIKey = "secret"
+
+	ids := []string{"1706.03762", "hep-th/9711200", "2401.99999"}
+	counts, err := c.CitationCounts(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("CitationCounts failed: %v", err)
+	}
+	// The unknown paper is left out; a paper without citations is not
+	want := map[string]int{"1706.03762": 104523, "hep-th/9711200": 0}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+
+	if len(*requests) != 1 {
+		t.Fatalf("%d requests, want 1", len(*requests))
+	}
+	q := (*requests)[0].URL.Query()
+	if want := "doi:10.48550/arxiv.1706.03762|10.48550/arxiv.hep-th/9711200|10.48550/arxiv.2401.99999"; q.Get("filter") != want {
+		t.Errorf("filter = %q, want %q", q.Get("filter"), want)
+	}
+	if !strings.Contains(q.Get("select"), "cited_by_count") || q.Get("api_key") != "secret" {
+		t.Errorf("query = %s", (*requests)[0].URL.RawQuery)
+	}
+}
+
+func TestClient_CitationCounts_Batches(t *testing.T) {
+	server, requests := worksServer(t, map[string]string{"": "works-citations.json"})
+
+	ids := make([]string, maxFilterValues+1)
+	for i := range ids {
+		ids[i] = "2401.0000" + string(rune('0'+i%10))
+	}
+	if _, err := newTestClient(server).CitationCounts(context.Background(), ids); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 2 {
+		t.Errorf("%d requests for %d IDs, want 2", len(*requests), len(ids))
+	}
+}
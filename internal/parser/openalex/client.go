@@ -0,0 +1,434 @@
+package openalex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
+)
+
+const (
+	defaultBaseURL  = "https://api.openalex.org/works"
+	defaultTimeout  = 30 * time.Second
+	defaultInterval = 100 * time.Millisecond // OpenAlex allows ten requests a second
+	defaultPageSize = 100
+	maxPageSize     = 200 // The largest per-page OpenAlex accepts
+
+	// defaultMaxResponseBytes bounds a response body when the client sets
+	// no limit; a page of 200 works with abstracts is a few MB
+	defaultMaxResponseBytes = 20 << 20
+
+	idPrefix  = "https://openalex.org/"
+	doiPrefix = "https://doi.org/"
+)
+
+// ErrResponseTooLarge is returned for a response body longer than the
+// client's MaxResponseBytes. It also matches parser.ErrDecode.
+var ErrResponseTooLarge = errors.New("response too large")
+
+func init() {
+	parser.Default.Register(model.SourceOpenAlex, func(opts parser.Options) parser.Provider {
+		c := NewClient()
+		c.UserAgent = opts.UserAgent
+		c.Interval = opts.Intervals[model.SourceOpenAlex]
+		c.APIKey = opts.Keys[model.SourceOpenAlex]
+		return c
+	})
+}
+
+// Client searches the works of OpenAlex, an open catalogue of scholarly
+// works that needs no key. It implements parser.Provider and
+// parser.ResultFetcher and tags papers with model.SourceOpenAlex. Papers
+// come under their OpenAlex ID, e.g. "W2741809807".
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+
+	PageSize int           // Works requested per page, at most 200 (default: 100)
+	Interval time.Duration // Minimum time between the starts of this client's requests (default: 100ms)
+	Clock    clock.Clock   // Time source for the rate limit (default: system clock)
+
+	// UserAgent is sent with every request. OpenAlex serves requests
+	// whose User-Agent has a contact address, e.g.
+	// "genesis-pipeline/1.0 (+mailto:you@example.org)", from a faster
+	// pool (default: version.UserAgent())
+	UserAgent string
+
+	// APIKey is sent as api_key, for OpenAlex's premium rate limits
+	// (default: none; OpenAlex needs no key)
+	APIKey string
+
+	// MaxResponseBytes is the longest response body read, after which a
+	// request fails with ErrResponseTooLarge (default: 20 MB)
+	MaxResponseBytes int64
+
+	limiter httpclient.Limiter
+}
+
+// NewClient creates an OpenAlex client.
+func NewClient() *Client {
+	return NewClientWithOptions(nil, "")
+}
+
+// NewClientWithOptions creates an OpenAlex client with the given HTTP
+// client and works endpoint; nil and "" keep the defaults.
+func NewClientWithOptions(httpClient *http.Client, baseURL string) *Client {
+	if httpClient == nil {
+		httpClient = httpclient.New(defaultTimeout)
+	}
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return &Client{httpClient: httpClient, baseURL: baseURL}
+}
+
+// StatusError is a response with a status other than 200. errors.Is
+// matches it against parser.ErrBadQuery (400, 403), parser.ErrRateLimited
+// (429) or parser.ErrUnavailable (5xx).
+type StatusError struct {
+	StatusCode int
+	Message    string        // OpenAlex's explanation, when it gave one
+	RetryAfter time.Duration // The wait the response's Retry-After asked for, 0 when absent
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("unexpected status code: %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Is implements the matching described on StatusError.
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case parser.ErrBadQuery:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusForbidden
+	case parser.ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case parser.ErrUnavailable:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// RetryWait returns RetryAfter, for parser.RetryAfter.
+func (e *StatusError) RetryWait() time.Duration { return e.RetryAfter }
+
+// FetchPapers returns up to limit works matching query, a full-text
+// search of titles, abstracts and full texts, in OpenAlex's relevance
+// order. Limits beyond one page are fetched by following the cursor.
+func (c *Client) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	result, err := c.FetchResult(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return result.Papers, nil
+}
+
+// FetchResult fetches like FetchPapers and adds the count of matching
+// works OpenAlex reports.
+func (c *Client) FetchResult(ctx context.Context, query string, limit int) (parser.FetchResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	pageSize := c.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	// A search with no matches is an empty result, not an error
+	result := parser.FetchResult{Papers: []model.Paper{}}
+	seen := make(map[string]bool)
+	cursor := "*"
+	for cursor != "" && len(result.Papers) < limit {
+		resp, err := c.requestPage(ctx, query, cursor, min(pageSize, limit-len(result.Papers)))
+		if err != nil {
+			return parser.FetchResult{}, err
+		}
+		if result.PerPage == 0 {
+			result.PerPage = resp.Meta.PerPage
+		}
+		result.Total = resp.Meta.Count
+		for _, w := range resp.Results {
+			p := convertWork(w)
+			if p.ID == "" {
+				continue
+			}
+			if seen[p.ID] {
+				result.Duplicates++
+				continue
+			}
+			seen[p.ID] = true
+			result.Papers = append(result.Papers, p)
+		}
+		if len(resp.Results) == 0 {
+			break
+		}
+		cursor = resp.Meta.NextCursor
+	}
+	if len(result.Papers) > limit {
+		result.Papers = result.Papers[:limit]
+	}
+	return result, nil
+}
+
+// requestPage fetches the page of works at cursor.
+func (c *Client) requestPage(ctx context.Context, query, cursor string, perPage int) (worksResponse, error) {
+	q := url.Values{}
+	if query = strings.TrimSpace(query); query != "" {
+		q.Set("search", query)
+	}
+	q.Set("per-page", strconv.Itoa(perPage))
+	q.Set("cursor", cursor)
+	return c.request(ctx, q)
+}
+
+// request fetches the works selected by the query parameters q.
+func (c *Client) request(ctx context.Context, q url.Values) (worksResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return worksResponse{}, err
+	}
+	if c.APIKey != "" {
+		q.Set("api_key", c.APIKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return worksResponse{}, fmt.Errorf("build request: %w", err)
+	}
+	ua := c.UserAgent
+	if ua == "" {
+		ua = version.UserAgent()
+	}
+	req.Header.Set("User-Agent", ua)
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return worksResponse{}, fmt.Errorf("HTTP request: %w", err)
+		}
+		return worksResponse{}, fmt.Errorf("%w: HTTP request: %w", parser.ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return worksResponse{}, c.statusError(resp)
+	}
+	limit := c.MaxResponseBytes
+	if limit <= 0 {
+		limit = defaultMaxResponseBytes
+	}
+	if resp.ContentLength > limit {
+		return worksResponse{}, tooLarge(limit)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		if ctx.Err() != nil {
+			return worksResponse{}, fmt.Errorf("read response: %w", err)
+		}
+		return worksResponse{}, fmt.Errorf("%w: read response: %w", parser.ErrUnavailable, err)
+	}
+	if int64(len(data)) > limit {
+		return worksResponse{}, tooLarge(limit)
+	}
+	var out worksResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return worksResponse{}, fmt.Errorf("%w: decode JSON: %w", parser.ErrDecode, err)
+	}
+	return out, nil
+}
+
+func tooLarge(limit int64) error {
+	return fmt.Errorf("%w: %w: over %d bytes", parser.ErrDecode, ErrResponseTooLarge, limit)
+}
+
+// statusError returns the StatusError of resp, with the message of the
+// JSON error body OpenAlex sends along.
+func (c *Client) statusError(resp *http.Response) *StatusError {
+	e := &StatusError{StatusCode: resp.StatusCode}
+	e.RetryAfter, _ = httpclient.RetryAfter(resp, clock.Or(c.Clock).Now())
+	var body errorResponse
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if json.Unmarshal(data, &body) == nil {
+		e.Message = strings.TrimSpace(body.Message)
+		if e.Message == "" {
+			e.Message = strings.TrimSpace(body.Error)
+		}
+	}
+	return e
+}
+
+// wait blocks until the client's rate limit allows another request.
+func (c *Client) wait(ctx context.Context) error {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return c.limiter.Wait(ctx, c.Clock, interval)
+}
+
+// convertWork converts an OpenAlex work; the paper has no ID when the
+// work has none.
+func convertWork(w work) model.Paper {
+	published := parseDate(w.PublicationDate)
+	updated := parseDate(w.UpdatedDate)
+	if updated.IsZero() {
+		updated = published
+	}
+	var primary string
+	if w.PrimaryTopic != nil {
+		primary = strings.TrimSpace(w.PrimaryTopic.DisplayName)
+	}
+
+	paper := model.Paper{
+		ID:              strings.TrimPrefix(w.ID, idPrefix),
+		Title:           textutil.CleanText(w.DisplayName),
+		Abstract:        textutil.CleanText(ReconstructAbstract(w.AbstractIndex)),
+		Authors:         authors(w.Authorships),
+		Categories:      categories(primary, w.Topics),
+		PrimaryCategory: primary,
+		UpdatedAt:       updated,
+		Published:       published,
+		DOI:             strings.TrimPrefix(w.DOI, doiPrefix),
+		JournalRef:      journalRef(w),
+		Links:           links(w),
+		Source:          model.SourceOpenAlex,
+	}
+	paper.SetTextLinks()
+	return paper
+}
+
+// ReconstructAbstract returns the abstract an OpenAlex inverted index
+// stands for. OpenAlex does not serve abstracts as text but as a map from
+// each word to the positions it holds, e.g. {"Deep": [0], "learning":
+// [1, 3], "is": [2]} for "Deep learning is learning".
+func ReconstructAbstract(index map[string][]int) string {
+	type placed struct {
+		pos  int
+		word string
+	}
+	var words []placed
+	for word, positions := range index {
+		for _, pos := range positions {
+			words = append(words, placed{pos, word})
+		}
+	}
+	sort.Slice(words, func(i, j int) bool { return words[i].pos < words[j].pos })
+
+	var b strings.Builder
+	for i, w := range words {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(w.word)
+	}
+	return b.String()
+}
+
+func authors(authorships []authorship) []string {
+	names := make([]string, 0, len(authorships))
+	for _, a := range authorships {
+		if name := textutil.CollapseSpace(a.Author.DisplayName); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// categories returns the names of the work's topics, primary first.
+func categories(primary string, topics []topic) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	add(primary)
+	for _, t := range topics {
+		add(strings.TrimSpace(t.DisplayName))
+	}
+	return names
+}
+
+// journalRef returns a reference like "Nature 521(7553):436-444 (2015)"
+// for a work published in a journal or proceedings, and "" for one only
+// in a repository such as arXiv.
+func journalRef(w work) string {
+	loc := w.PrimaryLocation
+	if loc == nil || loc.Source == nil || loc.Source.Type == "repository" {
+		return ""
+	}
+	ref := textutil.CleanText(loc.Source.DisplayName)
+	if ref == "" {
+		return ""
+	}
+	b := w.Biblio
+	if b.Volume != "" {
+		ref += " " + b.Volume
+	}
+	if b.Issue != "" {
+		ref += "(" + b.Issue + ")"
+	}
+	if b.FirstPage != "" {
+		ref += ":" + b.FirstPage
+		if b.LastPage != "" && b.LastPage != b.FirstPage {
+			ref += "-" + b.LastPage
+		}
+	}
+	if len(w.PublicationDate) >= 4 {
+		ref += " (" + w.PublicationDate[:4] + ")"
+	}
+	return ref
+}
+
+// links returns the landing page as the abstract link and the best open
+// access PDF, if any.
+func links(w work) []model.Link {
+	var out []model.Link
+	if loc := w.PrimaryLocation; loc != nil && loc.LandingPageURL != "" {
+		out = append(out, model.Link{URL: loc.LandingPageURL, Type: model.LinkAbstract})
+	} else if w.DOI != "" {
+		out = append(out, model.Link{URL: w.DOI, Type: model.LinkAbstract})
+	}
+	pdf := ""
+	if loc := w.BestOALocation; loc != nil {
+		pdf = loc.PDFURL
+	}
+	if pdf == "" && w.PrimaryLocation != nil {
+		pdf = w.PrimaryLocation.PDFURL
+	}
+	if pdf != "" {
+		out = append(out, model.Link{URL: pdf, Type: model.LinkPDF})
+	} else if oa := w.OpenAccess.OAURL; oa != "" && (len(out) == 0 || oa != out[0].URL) {
+		out = append(out, model.Link{URL: oa, Type: model.LinkOther, Title: "Open access"})
+	}
+	return out
+}
+
+// parseDate parses OpenAlex's dates, "2024-01-15", and timestamps,
+// "2024-03-01T12:34:56.789012" in UTC.
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", time.RFC3339Nano, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,260 @@
+package openalex
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+)
+
+const page2Cursor = "IlsxMDAuMCwgJ2h0dHBzOi8vb3BlbmFsZXgub3JnL1cxMDAwMDAwMDInXSI="
+
+// worksServer serves the fixture named by each request's cursor and
+// records the queries it gets.
+func worksServer(t *testing.T, pages map[string]string) (*httptest.Server, *[]*http.Request) {
+	t.Helper()
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		fixture, ok := pages[r.URL.Query().Get("cursor")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Errorf("read fixture: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestClient(server *httptest.Server) *Client {
+	c := NewClientWithOptions(server.Client(), server.URL+"/works")
+	c.Interval = time.Nanosecond
+	return c
+}
+
+func TestClient_FetchResult(t *testing.T) {
+	server, requests := worksServer(t, map[string]string{
+		"*":         "works-page1.json",
+		page2Cursor: "works-page2.json",
+	})
+	c := newTestClient(server)
+	c.PageSize = 2
+	c.APIKey = "secret"
+	c.UserAgent = "tester/1.0 (+mailto:me@example.org)"
+
+	result, err := c.FetchResult(context.Background(), "deep learning", 10)
+	if err != nil {
+		t.Fatalf("FetchResult failed: %v", err)
+	}
+	if len(*requests) != 2 {
+		t.Fatalf("%d requests, want one per page", len(*requests))
+	}
+	first := (*requests)[0]
+	if q := first.URL.Query(); q.Get("search") != "deep learning" || q.Get("per-page") != "2" || q.Get("cursor") != "*" || q.Get("api_key") != "secret" {
+		t.Errorf("first query = %s", first.URL.RawQuery)
+	}
+	if first.UserAgent() != c.UserAgent {
+		t.Errorf("User-Agent = %q", first.UserAgent())
+	}
+	if result.Total != 3 || result.PerPage != 2 {
+		t.Errorf("total %d, per page %d; want 3 and 2", result.Total, result.PerPage)
+	}
+
+	var ids []string
+	for _, p := range result.Papers {
+		ids = append(ids, p.ID)
+	}
+	if want := []string{"W1000000001", "W1000000002", "W1000000003"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("IDs = %v, want %v", ids, want)
+	}
+
+	p := result.Papers[0]
+	if p.Title != "Deep learning" || p.Source != model.SourceOpenAlex {
+		t.Errorf("title %q, source %q", p.Title, p.Source)
+	}
+	if want := "Deep learning allows computational models composed of multiple processing layers. Code: https://github.com/example/deep. Deep learning works."; p.Abstract != want {
+		t.Errorf("abstract = %q, want %q", p.Abstract, want)
+	}
+	if want := []string{"Yann LeCun", "Yoshua Bengio", "Geoffrey E. Hinton"}; !reflect.DeepEqual(p.Authors, want) {
+		t.Errorf("authors = %q, want %q", p.Authors, want)
+	}
+	if want := []string{"Neural Networks and Applications", "Advanced Image and Video Retrieval Techniques"}; !reflect.DeepEqual(p.Categories, want) || p.PrimaryCategory != want[0] {
+		t.Errorf("categories = %q (primary %q), want %q", p.Categories, p.PrimaryCategory, want)
+	}
+	if p.DOI != "10.1038/nature14539" || p.JournalRef != "Nature 521(7553):436-444 (2015)" {
+		t.Errorf("DOI %q, journal ref %q", p.DOI, p.JournalRef)
+	}
+	if !p.Published.Equal(time.Date(2015, 5, 27, 0, 0, 0, 0, time.UTC)) || !p.UpdatedAt.Equal(time.Date(2024, 3, 1, 12, 34, 56, 789012000, time.UTC)) {
+		t.Errorf("published %v, updated %v", p.Published, p.UpdatedAt)
+	}
+	wantLinks := []model.Link{
+		{URL: "https://doi.org/10.1038/nature14539", Type: model.LinkAbstract},
+		{URL: "https://hal.science/hal-04206682/document", Type: model.LinkPDF},
+		{URL: "https://github.com/example/deep", Type: model.LinkCode, FoundIn: model.FoundInAbstract},
+	}
+	if !reflect.DeepEqual(p.Links, wantLinks) {
+		t.Errorf("links = %+v, want %+v", p.Links, wantLinks)
+	}
+
+	// A preprint: no journal reference, and its dates come from publication
+	arxiv := result.Papers[1]
+	if arxiv.JournalRef != "" || arxiv.DOI != "" || arxiv.Abstract != "" || len(arxiv.Categories) != 0 {
+		t.Errorf("preprint: journal %q, DOI %q, abstract %q, categories %v", arxiv.JournalRef, arxiv.DOI, arxiv.Abstract, arxiv.Categories)
+	}
+	if !arxiv.UpdatedAt.Equal(arxiv.Published) || arxiv.Published.IsZero() {
+		t.Errorf("preprint: published %v, updated %v; want both the publication date", arxiv.Published, arxiv.UpdatedAt)
+	}
+	if want := "https://arxiv.org/pdf/1706.03762"; len(arxiv.Links) != 2 || arxiv.Links[1].URL != want {
+		t.Errorf("preprint links = %+v, want the primary location's PDF", arxiv.Links)
+	}
+
+	if got := result.Papers[2].JournalRef; got != "Proceedings of NAACL-HLT 2019:4171-4186 (2019)" {
+		t.Errorf("conference journal ref = %q", got)
+	}
+}
+
+func TestClient_FetchPapers_Limit(t *testing.T) {
+	server, requests := worksServer(t, map[string]string{
+		"*":         "works-page1.json",
+		page2Cursor: "works-page2.json",
+	})
+	c := newTestClient(server)
+
+	papers, err := c.FetchPapers(context.Background(), "deep learning", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fixture holds two works whatever per-page asked for
+	if len(papers) != 1 || len(*requests) != 1 {
+		t.Errorf("%d papers after %d requests, want 1 after 1", len(papers), len(*requests))
+	}
+	if got := (*requests)[0].URL.Query().Get("per-page"); got != "1" {
+		t.Errorf("per-page = %s, want the limit", got)
+	}
+}
+
+func TestClient_FetchResult_NoMatches(t *testing.T) {
+	server, requests := worksServer(t, map[string]string{"*": "works-empty.json"})
+
+	result, err := newTestClient(server).FetchResult(context.Background(), "zzyzx quarkonium", 10)
+	if err != nil {
+		t.Fatalf("FetchResult failed: %v", err)
+	}
+	if result.Papers == nil || len(result.Papers) != 0 || result.Total != 0 {
+		t.Errorf("papers %#v, total %d; want an empty, non-nil list", result.Papers, result.Total)
+	}
+	if len(*requests) != 1 {
+		t.Errorf("%d requests, want 1", len(*requests))
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"bad query", http.StatusBadRequest, `{"error":"Invalid query parameters error.","message":"per-page must be between 1 and 200"}`, parser.ErrBadQuery},
+		{"rate limited", http.StatusTooManyRequests, `{"error":"Too many requests"}`, parser.ErrRateLimited},
+		{"unavailable", http.StatusBadGateway, "", parser.ErrUnavailable},
+		{"not JSON", http.StatusOK, "<html>maintenance</html>", parser.ErrDecode},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(server).FetchPapers(context.Background(), "llm", 5)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	t.Run("message", func(t *testing.T) {
+		e := &StatusError{StatusCode: http.StatusBadRequest, Message: "per-page must be between 1 and 200"}
+		if want := "unexpected status code: 400: per-page must be between 1 and 200"; e.Error() != want {
+			t.Errorf("Error() = %q, want %q", e.Error(), want)
+		}
+	})
+}
+
+func TestClient_ResponseTooLarge(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "works-page1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunked := range []bool{false, true} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if chunked {
+				// Without a Content-Length the limit is found while reading
+				w.(http.Flusher).Flush()
+			}
+			w.Write(data)
+		}))
+		c := newTestClient(server)
+		c.MaxResponseBytes = int64(len(data)) - 1
+
+		_, err := c.FetchPapers(context.Background(), "deep learning", 2)
+		if !errors.Is(err, ErrResponseTooLarge) || !errors.Is(err, parser.ErrDecode) {
+			t.Errorf("chunked %v: err = %v, want ErrResponseTooLarge", chunked, err)
+		}
+
+		// A body exactly at the limit is read
+		c.MaxResponseBytes = int64(len(data))
+		if _, err := c.FetchPapers(context.Background(), "deep learning", 2); err != nil {
+			t.Errorf("chunked %v: at the limit: %v", chunked, err)
+		}
+		server.Close()
+	}
+}
+
+func TestReconstructAbstract(t *testing.T) {
+	tests := []struct {
+		index map[string][]int
+		want  string
+	}{
+		{nil, ""},
+		{map[string][]int{"Deep": {0}, "learning": {1, 3}, "is": {2}}, "Deep learning is learning"},
+		// Positions OpenAlex skipped leave no gap
+		{map[string][]int{"b": {5}, "a": {2}}, "a b"},
+	}
+	for _, tc := range tests {
+		if got := ReconstructAbstract(tc.index); got != tc.want {
+			t.Errorf("ReconstructAbstract(%v) = %q, want %q", tc.index, got, tc.want)
+		}
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	p, err := parser.Default.New(model.SourceOpenAlex, parser.Options{
+		UserAgent: "tester/1.0",
+		Keys:      map[string]string{model.SourceOpenAlex: "secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := p.(*Client)
+	if !ok || c.UserAgent != "tester/1.0" || c.APIKey != "secret" {
+		t.Errorf("registered provider = %#v", p)
+	}
+}
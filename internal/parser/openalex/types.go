@@ -0,0 +1,69 @@
+package openalex
+
+// JSON structures of the OpenAlex works API (https://docs.openalex.org),
+// limited to the fields the client reads.
+
+type worksResponse struct {
+	Meta    worksMeta `json:"meta"`
+	Results []work    `json:"results"`
+}
+
+type worksMeta struct {
+	Count      int    `json:"count"`
+	PerPage    int    `json:"per_page"`
+	NextCursor string `json:"next_cursor"`
+}
+
+type work struct {
+	ID              string           `json:"id"` // e.g. "https://openalex.org/W2741809807"
+	DOI             string           `json:"doi"`
+	DisplayName     string           `json:"display_name"`
+	PublicationDate string           `json:"publication_date"`
+	UpdatedDate     string           `json:"updated_date"`
+	AbstractIndex   map[string][]int `json:"abstract_inverted_index"`
+	Authorships     []authorship     `json:"authorships"`
+	PrimaryLocation *location        `json:"primary_location"`
+	BestOALocation  *location        `json:"best_oa_location"`
+	OpenAccess      openAccess       `json:"open_access"`
+	Biblio          biblio           `json:"biblio"`
+	PrimaryTopic    *topic           `json:"primary_topic"`
+	Topics          []topic          `json:"topics"`
+	CitedByCount    int              `json:"cited_by_count"`
+}
+
+type authorship struct {
+	Author struct {
+		DisplayName string `json:"display_name"`
+	} `json:"author"`
+}
+
+type location struct {
+	LandingPageURL string  `json:"landing_page_url"`
+	PDFURL         string  `json:"pdf_url"`
+	Source         *source `json:"source"`
+}
+
+type source struct {
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"` // journal, conference, repository, ebook platform, ...
+}
+
+type openAccess struct {
+	OAURL string `json:"oa_url"`
+}
+
+type biblio struct {
+	Volume    string `json:"volume"`
+	Issue     string `json:"issue"`
+	FirstPage string `json:"first_page"`
+	LastPage  string `json:"last_page"`
+}
+
+type topic struct {
+	DisplayName string `json:"display_name"`
+}
+
+type errorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
@@ -1,9 +1,154 @@
 package parser
 
-import "github.com/1psychoQAQ/genesis-pipeline/internal/model"
+import (
+	"context"
+	"errors"
+	"iter"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// Errors a provider's fetch can be matched against with errors.Is, so
+// callers can tell their own mistakes from the source's trouble.
+var (
+	ErrBadQuery    = errors.New("query rejected by the source")   // Retrying the same query will not help
+	ErrRateLimited = errors.New("rate limited by the source")     // Retry later
+	ErrUnavailable = errors.New("source unavailable or failing")  // Network error or a 5xx response
+	ErrDecode      = errors.New("source response not understood") // Malformed payload; retrying rarely helps
+)
+
+// Kinds of fetch errors ErrorKind reports, e.g. in run summaries.
+const (
+	KindBadQuery    = "bad_query"
+	KindRateLimited = "rate_limited"
+	KindUnavailable = "unavailable"
+	KindDecode      = "decode"
+)
+
+// ErrorKind returns the Kind* of err, or "" for an error that is none of
+// the provider errors above.
+func ErrorKind(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrBadQuery):
+		return KindBadQuery
+	case errors.Is(err, ErrRateLimited):
+		return KindRateLimited
+	case errors.Is(err, ErrUnavailable):
+		return KindUnavailable
+	case errors.Is(err, ErrDecode):
+		return KindDecode
+	}
+	return ""
+}
+
+// Temporary reports whether the same fetch may succeed if tried again
+// later: the source limited the rate or was unavailable. A rejected query
+// or an undecodable response is not temporary.
+func Temporary(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable)
+}
+
+// RetryAfter returns the wait the source asked for before the next try,
+// e.g. with a Retry-After header. ok is false when it gave none.
+func RetryAfter(err error) (d time.Duration, ok bool) {
+	var w interface{ RetryWait() time.Duration }
+	if errors.As(err, &w) && w.RetryWait() > 0 {
+		return w.RetryWait(), true
+	}
+	return 0, false
+}
 
 // Provider defines the interface for fetching papers from external sources.
 type Provider interface {
-	// FetchPapers retrieves papers matching the query, up to the specified limit.
-	FetchPapers(query string, limit int) ([]model.Paper, error)
+	// FetchPapers retrieves papers matching the query, up to the specified
+	// limit. Requests are abandoned once ctx is done.
+	FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error)
+}
+
+// Counter is implemented by providers that can count matches without
+// fetching them.
+type Counter interface {
+	// CountPapers returns how many papers matching query were submitted
+	// in [from, to).
+	CountPapers(ctx context.Context, query string, from, to time.Time) (int, error)
+}
+
+// FetchResult is a page of matches with the provider's account of how
+// many there are in all.
+type FetchResult struct {
+	Papers  []model.Paper
+	Total   int // Papers matching the query, of which Papers is a part
+	Start   int // 0-based offset of the first of Papers among the matches
+	PerPage int // Entries per request the provider reported
+
+	// Sources counts the papers taken from each source, for providers
+	// that merge several (nil otherwise)
+	Sources map[string]int
+
+	// Duplicates counts entries dropped as repeat listings of a paper
+	// already in Papers, e.g. the same ID on two pages
+	Duplicates int
+}
+
+// ResultFetcher is implemented by providers that report the total number
+// of matches along with the papers fetched.
+type ResultFetcher interface {
+	// FetchResult is FetchPapers with the provider's match totals.
+	FetchResult(ctx context.Context, query string, limit int) (FetchResult, error)
+}
+
+// Streamer is implemented by providers that can yield papers as they
+// arrive instead of all at once.
+type Streamer interface {
+	// FetchPapersIter yields what FetchPapers would return. A fetch error
+	// is yielded once, with a zero paper, and ends the sequence.
+	FetchPapersIter(ctx context.Context, query string, limit int) iter.Seq2[model.Paper, error]
+}
+
+// PageFetcher is implemented by providers that fetch in pages and can
+// report each page as it arrives, e.g. for progress on long harvests.
+type PageFetcher interface {
+	// FetchAllPages fetches up to total papers like FetchResult and calls
+	// onPage after each page with its 0-based index, the papers fetched
+	// so far and the papers the harvest is expected to reach.
+	FetchAllPages(ctx context.Context, query string, total int, onPage func(pageIdx, fetched, total int)) (FetchResult, error)
+}
+
+// Incremental is implemented by providers that can restrict a fetch to
+// papers updated since a point in time.
+type Incremental interface {
+	// FetchSince is FetchResult for papers last updated at or after since.
+	FetchSince(ctx context.Context, query string, since time.Time, limit int) (FetchResult, error)
+}
+
+// Scoper is implemented by providers that can restrict a search to
+// categories at the source, e.g. with arXiv's cat: field.
+type Scoper interface {
+	// Scoped returns a provider over the same source whose searches only
+	// match papers in any of categories, in place of the receiver's own
+	// restriction. It shares the receiver's rate limit and cache.
+	Scoped(categories []string) Provider
+}
+
+// PDFDownloader is implemented by providers that can fetch the PDFs of
+// the papers they return.
+type PDFDownloader interface {
+	// DownloadPDF saves the PDF of paper into dir and returns the file's
+	// path, without a request when the file is already there.
+	DownloadPDF(ctx context.Context, paper model.Paper, dir string) (string, error)
+}
+
+// CacheStats counts the lookups of a provider's response cache.
+type CacheStats struct {
+	Hits    int `json:"hits"`
+	Misses  int `json:"misses"`
+	Entries int `json:"entries"` // Responses held, including expired ones not yet dropped
+}
+
+// CacheReporter is implemented by providers that cache responses.
+type CacheReporter interface {
+	CacheStats() CacheStats
 }
@@ -0,0 +1,31 @@
+package providertest
+
+import (
+	"context"
+	"strings"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// Fixture is a conforming provider over fixed papers. It returns those
+// whose title or abstract contains the query, ignoring case; an empty
+// query matches every paper.
+type Fixture []model.Paper
+
+// FetchPapers implements parser.Provider.
+func (f Fixture) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	needle := strings.ToLower(strings.TrimSpace(query))
+	papers := []model.Paper{}
+	for _, p := range f {
+		if limit > 0 && len(papers) >= limit {
+			break
+		}
+		if strings.Contains(strings.ToLower(p.Title), needle) || strings.Contains(strings.ToLower(p.Abstract), needle) {
+			papers = append(papers, p)
+		}
+	}
+	return papers, nil
+}
@@ -0,0 +1,129 @@
+// Package providertest checks that a parser.Provider keeps the contract
+// the pipeline relies on:
+//
+//   - at most limit papers are returned;
+//   - a query without matches returns no papers and no error;
+//   - a cancelled context fails the fetch promptly with context.Canceled;
+//   - every paper passes validation.ValidatePaper, so ID, Title, Authors
+//     and UpdatedAt are always filled in;
+//   - the provider is reachable through its parser.Registry name.
+//
+// Provider tests call Run against recorded fixtures.
+package providertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/validation"
+)
+
+// cancelTimeout is how long a provider may take to notice a cancelled
+// context.
+const cancelTimeout = 2 * time.Second
+
+// Config describes the fixtures a provider is checked against.
+type Config struct {
+	Query        string // Matches at least two papers
+	NoMatchQuery string // Matches no paper
+
+	// Name is the provider's parser.Registry name; the registry check is
+	// skipped when empty
+	Name     string
+	Registry *parser.Registry // Registry holding Name (default: parser.Default)
+}
+
+// TB is the part of testing.TB that Run reports through.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Run checks p and reports every violation as a test error.
+func Run(t TB, p parser.Provider, cfg Config) {
+	t.Helper()
+	for _, v := range Check(p, cfg) {
+		t.Errorf("provider contract: %s", v)
+	}
+}
+
+// Check runs the conformance checks against p and returns the violations.
+func Check(p parser.Provider, cfg Config) []string {
+	var violations []string
+	fail := func(format string, args ...any) {
+		violations = append(violations, fmt.Sprintf(format, args...))
+	}
+	ctx := context.Background()
+
+	// Limit, and the papers used for the validity checks
+	for _, limit := range []int{1, 2} {
+		papers, err := p.FetchPapers(ctx, cfg.Query, limit)
+		switch {
+		case err != nil:
+			fail("FetchPapers(%q, %d) failed: %v", cfg.Query, limit, err)
+		case len(papers) > limit:
+			fail("FetchPapers(%q, %d) returned %d papers, more than the limit", cfg.Query, limit, len(papers))
+		case len(papers) < limit:
+			fail("FetchPapers(%q, %d) returned %d papers; the fixture query must match at least 2", cfg.Query, limit, len(papers))
+		}
+		for _, paper := range papers {
+			checkPaper(paper, fail)
+		}
+	}
+
+	papers, err := p.FetchPapers(ctx, cfg.NoMatchQuery, 10)
+	if err != nil {
+		fail("FetchPapers(%q) without matches failed: %v; want no papers and a nil error", cfg.NoMatchQuery, err)
+	} else if len(papers) > 0 {
+		fail("FetchPapers(%q) without matches returned %d papers", cfg.NoMatchQuery, len(papers))
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.FetchPapers(cancelled, cfg.Query, 2)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			fail("FetchPapers with a cancelled context returned %v; want context.Canceled", err)
+		}
+	case <-time.After(cancelTimeout):
+		fail("FetchPapers with a cancelled context did not return within %v", cancelTimeout)
+	}
+
+	if cfg.Name != "" {
+		reg := cfg.Registry
+		if reg == nil {
+			reg = parser.Default
+		}
+		built, err := reg.New(cfg.Name, parser.Options{AnnounceCategories: []string{"cs.CL"}})
+		switch {
+		case err != nil:
+			fail("registry: %v", err)
+		case reflect.TypeOf(built) != reflect.TypeOf(p):
+			fail("registry: %q builds a %T, not a %T", cfg.Name, built, p)
+		}
+	}
+	return violations
+}
+
+func checkPaper(p model.Paper, fail func(string, ...any)) {
+	errs := validation.ValidatePaper(p)
+	if len(errs) == 0 {
+		return
+	}
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	fail("paper %q is invalid: %s", p.ID, strings.Join(msgs, "; "))
+}
@@ -0,0 +1,170 @@
+package providertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/validation"
+)
+
+var fixturePapers = Fixture{
+	{ID: "2401.00001v1", Title: "Sparse Routing", Abstract: "Routing for mixtures of experts.",
+		Authors: []string{"A. Author"}, UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	{ID: "2401.00002v1", Title: "Dense Routing", Abstract: "Routing without experts.",
+		Authors: []string{"B. Author"}, UpdatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+}
+
+var fixtureConfig = Config{Query: "routing", NoMatchQuery: "protein folding"}
+
+func TestRun_Fixture(t *testing.T) {
+	reg := parser.NewRegistry()
+	reg.Register("fixture", func(parser.Options) parser.Provider { return fixturePapers })
+	cfg := fixtureConfig
+	cfg.Name, cfg.Registry = "fixture", reg
+
+	Run(t, fixturePapers, cfg)
+}
+
+// broken is a provider double with one way of breaking the contract.
+type broken struct {
+	Fixture
+	ignoreLimit   bool
+	ignoreContext bool
+	errNoMatch    bool
+	untitled      bool
+}
+
+func (b broken) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	if b.ignoreLimit {
+		limit = 0
+	}
+	if b.ignoreContext {
+		ctx = context.Background()
+	}
+	papers, err := b.Fixture.FetchPapers(ctx, query, limit)
+	if b.errNoMatch && err == nil && len(papers) == 0 {
+		return nil, errors.New("no results")
+	}
+	if b.untitled {
+		for i := range papers {
+			papers[i].Title = ""
+		}
+	}
+	return papers, err
+}
+
+func TestCheck_BrokenProviders(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider parser.Provider
+		registry string // Registered to build a Fixture when set
+		expected string
+	}{
+		{"ignores limit", broken{Fixture: fixturePapers, ignoreLimit: true}, "", "returned 2 papers, more than the limit"},
+		{"ignores context", broken{Fixture: fixturePapers, ignoreContext: true}, "", "with a cancelled context returned <nil>; want context.Canceled"},
+		{"errors without matches", broken{Fixture: fixturePapers, errNoMatch: true}, "", `FetchPapers("protein folding") without matches failed: no results`},
+		{"invalid papers", broken{Fixture: fixturePapers, untitled: true}, "", `paper "2401.00001v1" is invalid: Title: cannot be empty`},
+		{"too few fixtures", fixturePapers[:1], "", "the fixture query must match at least 2"},
+		{"unregistered", fixturePapers, "missing", `registry: unknown provider "missing"`},
+		{"registry type", broken{Fixture: fixturePapers}, "fixture", "registry: \"fixture\" builds a providertest.Fixture, not a providertest.broken"},
+	}
+
+	for _, tc := range tests {
+		cfg := fixtureConfig
+		if tc.registry != "" {
+			cfg.Registry = parser.NewRegistry()
+			cfg.Registry.Register("fixture", func(parser.Options) parser.Provider { return fixturePapers })
+			cfg.Name = tc.registry
+		}
+		violations := Check(tc.provider, cfg)
+		if !strings.Contains(strings.Join(violations, "\n"), tc.expected) {
+			t.Errorf("%s: expected a violation containing %q, got %q", tc.name, tc.expected, violations)
+		}
+	}
+}
+
+// Run reports through t, so a broken provider fails the calling test.
+func TestRun_ReportsViolations(t *testing.T) {
+	rec := &recordingTB{}
+	Run(rec, broken{Fixture: fixturePapers, untitled: true}, fixtureConfig)
+	if len(rec.errors) == 0 || !strings.HasPrefix(rec.errors[0], "provider contract: ") {
+		t.Errorf("expected reported violations, got %q", rec.errors)
+	}
+}
+
+type recordingTB struct{ errors []string }
+
+func (r *recordingTB) Helper() {}
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMock(t *testing.T) {
+	errDown := errors.New("arxiv down")
+	m := &Mock{Papers: SamplePapers(3), Err: errDown, Errs: []error{nil, context.DeadlineExceeded}}
+	ctx := context.Background()
+
+	papers, err := m.FetchPapers(ctx, "llm", 2)
+	if err != nil || len(papers) != 2 {
+		t.Fatalf("first fetch = %d papers, %v; want 2, nil", len(papers), err)
+	}
+	if _, err := m.FetchPapers(ctx, "llm", 0); err != context.DeadlineExceeded {
+		t.Errorf("second fetch = %v, want the second of Errs", err)
+	}
+	if _, err := m.FetchPapers(ctx, "rag", 5); err != errDown {
+		t.Errorf("third fetch = %v, want Err", err)
+	}
+	want := []Call{{"llm", 2}, {"llm", 0}, {"rag", 5}}
+	if got := m.Calls(); fmt.Sprint(got) != fmt.Sprint(want) || m.CallCount() != 3 {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+	for _, err := range validation.ValidatePapers(SamplePapers(3)).Errors {
+		t.Errorf("sample paper invalid: %v", err)
+	}
+
+	// The conformance checks hold for it, the no-match query aside
+	violations := Check(&Mock{Papers: SamplePapers(3)}, Config{Query: "any", NoMatchQuery: "none"})
+	for _, v := range violations {
+		if !strings.Contains(v, "without matches") {
+			t.Errorf("unexpected violation: %s", v)
+		}
+	}
+}
+
+func TestMock_Latency(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	m := &Mock{Papers: SamplePapers(1), Latency: time.Second, Clock: clk}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := m.FetchPapers(context.Background(), "llm", 10)
+		done <- err
+	}()
+	clk.BlockUntil(1)
+	select {
+	case err := <-done:
+		t.Fatalf("fetch returned before its latency: %v", err)
+	default:
+	}
+	clk.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("fetch = %v", err)
+	}
+
+	// Cancelling the context ends the wait
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		clk.BlockUntil(1)
+		cancel()
+	}()
+	if _, err := m.FetchPapers(ctx, "llm", 10); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled fetch = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownProvider is returned for provider names nothing registered.
+var ErrUnknownProvider = errors.New("unknown provider")
+
+// ErrNotConfigured is matched by a ConfigError.
+var ErrNotConfigured = errors.New("provider not configured")
+
+// Options carries the settings providers are built with.
+type Options struct {
+	AnnounceCategories []string // Categories read by announcement feeds, e.g. "cs.CL"
+	UserAgent          string   // User-Agent of providers that let it be set (default: their own)
+	StripVersions      bool     // Return arXiv papers under their versionless ID (see model.Paper.StripVersion)
+
+	// MaxAge is the age beyond which papers are dropped, for providers
+	// that list by date rather than search, so they list as far back as
+	// that (default: each provider's own)
+	MaxAge time.Duration
+
+	Keys      map[string]string        // API key by provider name, for providers that need one
+	Intervals map[string]time.Duration // Minimum delay between requests by provider name (default: each provider's own)
+}
+
+// ConfigChecker is implemented by providers that need settings, such as
+// an API key, the deployment may not have.
+type ConfigChecker interface {
+	// MissingConfig names the settings the provider lacks, e.g.
+	// "PROVIDER_KEYS entry for openalex"; none when it can run.
+	MissingConfig() []string
+}
+
+// ConfigError reports the settings a provider lacks.
+type ConfigError struct {
+	Provider string
+	Missing  []string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("provider %s is not configured: missing %s", e.Provider, strings.Join(e.Missing, ", "))
+}
+
+func (e *ConfigError) Is(target error) bool { return target == ErrNotConfigured }
+
+// CheckConfig returns a ConfigError when p, registered as name, reports
+// missing settings.
+func CheckConfig(name string, p Provider) error {
+	cc, ok := p.(ConfigChecker)
+	if !ok {
+		return nil
+	}
+	if missing := cc.MissingConfig(); len(missing) > 0 {
+		return &ConfigError{Provider: name, Missing: missing}
+	}
+	return nil
+}
+
+// Factory builds a provider.
+type Factory func(opts Options) Provider
+
+// Registry maps provider names (the model.Source* values) to factories.
+// It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// Default is the registry provider packages add themselves to when
+// imported.
+var Default = NewRegistry()
+
+// Register adds a factory under name. Registering a name twice is an error.
+func (r *Registry) Register(name string, f Factory) error {
+	if name == "" || f == nil {
+		return errors.New("register provider: empty name or factory")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("register provider: %q already registered", name)
+	}
+	r.factories[name] = f
+	return nil
+}
+
+// New builds the provider registered under name.
+func (r *Registry) New(name string, opts Options) (Provider, error) {
+	r.mu.RLock()
+	f, ok := r.factories[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownProvider, name)
+	}
+	return f(opts), nil
+}
+
+// Names returns the registered names, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// All builds every registered provider, keyed by name.
+func (r *Registry) All(opts Options) map[string]Provider {
+	providers := make(map[string]Provider)
+	for _, name := range r.Names() {
+		p, _ := r.New(name, opts)
+		providers[name] = p
+	}
+	return providers
+}
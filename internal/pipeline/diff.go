@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// unscored marks a paper in a result set that was not scored.
+const unscored = -1
+
+// SyncDiff compares a sync with the previous completed sync of the same
+// query. Papers are identified by base ID, so a new version of a paper
+// the query already returned is not new.
+type SyncDiff struct {
+	PreviousID int       `json:"previous_id"`
+	PreviousAt time.Time `json:"previous_at"`
+
+	New          []string      `json:"new"`           // Returned now but not last time
+	Disappeared  []string      `json:"disappeared"`   // Returned last time but no longer
+	ScoreChanged []ScoreChange `json:"score_changed"` // Returned both times with a different score
+}
+
+// ScoreChange is a paper whose quality score moved between two syncs.
+type ScoreChange struct {
+	ID  string `json:"id"`
+	Old int    `json:"old"`
+	New int    `json:"new"`
+}
+
+// Empty reports whether nothing changed since the previous sync.
+func (d *SyncDiff) Empty() bool {
+	return len(d.New) == 0 && len(d.Disappeared) == 0 && len(d.ScoreChanged) == 0
+}
+
+// diffResults compares two result sets (base ID -> score). Scores are
+// only compared when both syncs scored the paper.
+func diffResults(prev, cur map[string]int) SyncDiff {
+	var d SyncDiff
+	for id, score := range cur {
+		old, ok := prev[id]
+		switch {
+		case !ok:
+			d.New = append(d.New, id)
+		case old != score && old != unscored && score != unscored:
+			d.ScoreChanged = append(d.ScoreChanged, ScoreChange{ID: id, Old: old, New: score})
+		}
+	}
+	for id := range prev {
+		if _, ok := cur[id]; !ok {
+			d.Disappeared = append(d.Disappeared, id)
+		}
+	}
+	sort.Strings(d.New)
+	sort.Strings(d.Disappeared)
+	sort.Slice(d.ScoreChanged, func(i, j int) bool { return d.ScoreChanged[i].ID < d.ScoreChanged[j].ID })
+	return d
+}
+
+// resultSet returns the base ID -> score of every paper the query
+// returned, scored where the filter ran.
+func resultSet(returned []string, result *RunResult) map[string]int {
+	set := make(map[string]int, len(returned))
+	for _, id := range returned {
+		set[id] = unscored
+	}
+	for _, r := range result.FilterResults {
+		set[r.Paper.BaseID()] = r.Score
+	}
+	return set
+}
+
+// compareResults stores the result set of sync id and, when diff is set,
+// compares it with the previous sync of the same query in scope.
+// Failures are logged and never fail the sync.
+func (s *Service) compareResults(ctx context.Context, id int, query string, scope storage.SyncScope, set map[string]int, diff bool) *SyncDiff {
+	results, ok := s.History.(storage.SyncResults)
+	if !ok || id == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	var d *SyncDiff
+	if diff {
+		prev, prevSet, err := results.PreviousSync(ctx, query, scope, id)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			log.Printf("No previous sync of %q to compare with", query)
+		case err != nil:
+			log.Printf("Failed to load previous sync: %v", err)
+		default:
+			cmp := diffResults(prevSet, set)
+			cmp.PreviousID = prev.ID
+			if prev.CompletedAt != nil {
+				cmp.PreviousAt = *prev.CompletedAt
+			}
+			d = &cmp
+		}
+	}
+	if err := results.RecordSyncResults(ctx, id, set); err != nil {
+		log.Printf("Failed to record sync results: %v", err)
+	}
+	return d
+}
@@ -0,0 +1,167 @@
+package pipeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+// resultHistory is a storage.SyncHistory, storage.SyncResults and
+// storage.SyncScopes that keeps every sync in memory.
+type resultHistory struct {
+	recordingHistory
+	logs    []storage.SyncLog
+	results map[int]map[string]int
+	scopes  map[int]storage.SyncScope
+}
+
+func (h *resultHistory) StartSync(ctx context.Context, query string) (int, error) {
+	h.logs = append(h.logs, storage.SyncLog{ID: len(h.logs) + 1, Query: query, Status: "running"})
+	return len(h.logs), nil
+}
+
+func (h *resultHistory) CompleteSync(ctx context.Context, id int, fetched, newCount, updated int, timings map[string]int64) error {
+	completed := now.Add(time.Duration(id) * time.Hour)
+	h.logs[id-1].Status, h.logs[id-1].CompletedAt = "completed", &completed
+	return nil
+}
+
+func (h *resultHistory) RecordSyncResults(ctx context.Context, id int, results map[string]int) error {
+	if h.results == nil {
+		h.results = make(map[int]map[string]int)
+	}
+	h.results[id] = results
+	return nil
+}
+
+func (h *resultHistory) RecordSyncScope(ctx context.Context, id int, scope storage.SyncScope) error {
+	if h.scopes == nil {
+		h.scopes = make(map[int]storage.SyncScope)
+	}
+	h.scopes[id] = scope
+	return nil
+}
+
+func (h *resultHistory) PreviousSync(ctx context.Context, query string, scope storage.SyncScope, id int) (storage.SyncLog, map[string]int, error) {
+	for i := len(h.logs) - 1; i >= 0; i-- {
+		l := h.logs[i]
+		if l.ID != id && l.Query == query && l.Status == "completed" && h.results[l.ID] != nil && h.scopes[l.ID].Equal(scope) {
+			return l, h.results[l.ID], nil
+		}
+	}
+	return storage.SyncLog{}, nil, storage.ErrNotFound
+}
+
+func TestRun_DiffLast(t *testing.T) {
+	recent := now.Add(-24 * time.Hour)
+	revised := paper("2402.00002v2", "Camera-ready", recent)
+	revised.DOI = "10.1000/xyz" // scores higher than v1
+
+	first := []model.Paper{
+		paper("2402.00001v1", "Accepted at ACL", recent), // kept
+		paper("2402.00002v1", "Camera-ready", recent),    // revised, score changes
+		paper("2402.00003v1", "", recent),                // disappears
+	}
+	second := []model.Paper{
+		paper("2402.00001v1", "Accepted at ACL", recent),
+		revised,
+		paper("2402.00004v1", "Accepted at ACL", recent), // new
+	}
+
+	history := &resultHistory{}
+	provider := &fixtureProvider{papers: first}
+	svc := NewService(map[string]parser.Provider{model.SourceArxiv: provider}, memory.New())
+	svc.History = history
+	ctx := context.Background()
+
+	res, err := svc.Run(ctx, RunParams{Query: "llm", Diff: true})
+	if err != nil {
+		t.Fatalf("first Run: %v", err)
+	}
+	if res.Diff != nil {
+		t.Errorf("first sync diff = %+v, want none", res.Diff)
+	}
+
+	// A different query must not be compared with
+	if _, err := svc.Run(ctx, RunParams{Query: "rag"}); err != nil {
+		t.Fatalf("other Run: %v", err)
+	}
+
+	provider.papers = second
+	res, err = svc.Run(ctx, RunParams{Query: "llm", Diff: true})
+	if err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	d := res.Diff
+	if d == nil {
+		t.Fatal("second sync has no diff")
+	}
+	if d.PreviousID != 1 {
+		t.Errorf("previous sync = %d, want 1", d.PreviousID)
+	}
+	if want := []string{"2402.00004"}; !reflect.DeepEqual(d.New, want) {
+		t.Errorf("new = %v, want %v", d.New, want)
+	}
+	if want := []string{"2402.00003"}; !reflect.DeepEqual(d.Disappeared, want) {
+		t.Errorf("disappeared = %v, want %v", d.Disappeared, want)
+	}
+	if len(d.ScoreChanged) != 1 || d.ScoreChanged[0].ID != "2402.00002" || d.ScoreChanged[0].New <= d.ScoreChanged[0].Old {
+		t.Errorf("score changed = %+v, want 2402.00002 scoring higher", d.ScoreChanged)
+	}
+}
+
+func TestRun_DiffLastScope(t *testing.T) {
+	recent := now.Add(-24 * time.Hour)
+	arxiv := &fixtureProvider{papers: []model.Paper{paper("2402.00001v1", "Accepted at ACL", recent)}}
+	openalex := &fixtureProvider{papers: []model.Paper{paper("2402.00002v1", "Accepted at ACL", recent)}}
+	history := &resultHistory{}
+	svc := NewService(map[string]parser.Provider{model.SourceArxiv: arxiv, model.SourceOpenAlex: openalex}, memory.New())
+	svc.History = history
+	ctx := context.Background()
+
+	if _, err := svc.Run(ctx, RunParams{Query: "llm", Diff: true}); err != nil {
+		t.Fatalf("arxiv Run: %v", err)
+	}
+
+	// The same query from another provider or in other categories has
+	// nothing to compare with
+	res, err := svc.Run(ctx, RunParams{Query: "llm", Provider: model.SourceOpenAlex, Diff: true})
+	if err != nil {
+		t.Fatalf("openalex Run: %v", err)
+	}
+	if res.Diff != nil {
+		t.Errorf("openalex diff = %+v, want none", res.Diff)
+	}
+	res, err = svc.Run(ctx, RunParams{Query: "llm", Categories: []string{"cs.CL"}, Diff: true})
+	if err != nil {
+		t.Fatalf("scoped Run: %v", err)
+	}
+	if res.Diff != nil {
+		t.Errorf("scoped diff = %+v, want none", res.Diff)
+	}
+
+	// Each provider is compared with its own previous sync
+	res, err = svc.Run(ctx, RunParams{Query: "llm", Provider: model.SourceOpenAlex, Diff: true})
+	if err != nil {
+		t.Fatalf("second openalex Run: %v", err)
+	}
+	if res.Diff == nil || res.Diff.PreviousID != 2 || !res.Diff.Empty() {
+		t.Errorf("second openalex diff = %+v, want an empty one against sync 2", res.Diff)
+	}
+}
+
+func TestDiffResults_UnscoredNotCompared(t *testing.T) {
+	prev := map[string]int{"a": unscored, "b": 40, "c": 50}
+	cur := map[string]int{"a": 70, "b": unscored, "c": 50}
+
+	d := diffResults(prev, cur)
+	if !d.Empty() {
+		t.Errorf("diff = %+v, want empty", d)
+	}
+}
@@ -0,0 +1,672 @@
+// Package pipeline runs one sync: fetch, dedup, recency and validation
+// checks, quality filtering and saving. The CLI and the API sync handler
+// are thin adapters over Service.Run.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/ingest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/notify"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/similarity"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/timing"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/validation"
+)
+
+// ErrUnknownProvider is returned when RunParams.Provider names no
+// configured provider.
+var ErrUnknownProvider = parser.ErrUnknownProvider
+
+// ErrInvalidParams is wrapped by the errors of Service.Validate for
+// RunParams the service cannot honour.
+var ErrInvalidParams = errors.New("invalid run parameters")
+
+// Reasons a fetched paper is not saved, as keys of RunResult.Rejected.
+const (
+	RejectDuplicate  = "duplicate"       // Same ID earlier in the batch
+	RejectNearDup    = "near_duplicate"  // Abstract nearly identical to a stored paper's or one earlier in the batch
+	RejectTooOld     = "too_old"         // Last updated (or published, see AgeBy) before MaxAge
+	RejectInvalid    = "invalid"         // Failed metadata validation
+	RejectGate       = "failed_gate"     // No acceptance signal, DOI or strong evidence
+	RejectLowScore   = "below_min_score" // Passed the gate but scored under its category threshold
+	RejectOutOfScope = "out_of_scope"    // Not in RunParams.Categories, or only cross-listed into them when StrictCategories
+)
+
+// Timestamps RunParams.MaxAge can apply to.
+const (
+	AgeUpdated   = "updated"   // Last revision
+	AgePublished = "published" // First version; papers without one fall back to their last revision
+)
+
+// RunParams describes one sync.
+type RunParams struct {
+	Provider string // Key in Service.Providers (default: model.SourceArxiv)
+	Query    string
+	Limit    int
+	MaxAge   time.Duration // Drop papers last updated longer ago (0 = no limit)
+	AgeBy    string        // Timestamp MaxAge applies to: AgeUpdated or AgePublished (default: AgeUpdated)
+	MinScore int           // Filter threshold (0 = filter default)
+	// CategoryMinScore overrides MinScore by primary category
+	CategoryMinScore map[string]int
+	// Categories scopes the run to papers in any of these arXiv
+	// categories, e.g. cs.CL. A provider that implements parser.Scoper
+	// searches only these; papers outside them are dropped before saving
+	// as RejectOutOfScope (default: any category)
+	Categories []string
+	// StrictCategories keeps only papers whose primary category is in
+	// Categories, dropping those merely cross-listed into one
+	StrictCategories bool
+	SkipFilter       bool // Save every valid paper; only new versions of stored papers are scored
+	SkipSave         bool // Stop after filtering; nothing is saved or logged
+	// Diff compares the result set with the previous completed sync of the
+	// same query, provider and categories (needs a History that implements
+	// storage.SyncResults)
+	Diff bool
+	// Incremental fetches only papers updated since the previous completed
+	// sync of the same query, provider and categories started (needs a
+	// History that implements storage.SyncCheckpoints). The fetch is full
+	// when there is no such sync or the provider is not a
+	// parser.Incremental
+	Incremental bool
+
+	// Timings receives stage durations as they are measured, so a caller
+	// can report progress. A new one is used when nil.
+	Timings *timing.Timings
+
+	// Progress, when set and the provider is a parser.Streamer, is called
+	// with the papers fetched so far as each arrives. The provider's match
+	// total is not reported for such runs.
+	Progress func(fetched int)
+
+	// OnPage, when set and the provider is a parser.PageFetcher, is
+	// called after each page of the fetch, in place of Progress, with the
+	// page's 0-based index, the papers fetched so far and the papers the
+	// fetch is expected to reach. The provider's match total is not
+	// reported for such runs.
+	OnPage func(pageIdx, fetched, total int)
+}
+
+// RunResult summarises one sync.
+type RunResult struct {
+	Provider string
+	Query    string
+	SyncID   int // sync_log row of the run, 0 when it was not logged
+
+	Since    time.Time      // Start of the last-updated window an incremental run fetched, zero for a full fetch
+	Fetched  int            // Papers returned by the provider
+	Total    int            // Papers matching the query in all, when the provider reports it (see parser.ResultFetcher)
+	Sources  map[string]int // Papers fetched from each source of a provider that merges several, nil otherwise
+	Repeats  int            // Repeat listings the provider dropped before returning (see parser.FetchResult.Duplicates)
+	Deduped  int            // Left after removing duplicate IDs
+	Passed   []model.Paper  // Papers that reached the save stage, scored unless SkipFilter
+	Rejected map[string]int // Papers dropped before saving, by Reject* reason
+
+	// FilterResults holds one entry per paper that reached the filter
+	FilterResults []filter.FilterResult
+
+	Saved     int             // Papers committed to the store
+	New       []model.Paper   // Saved papers whose base ID was not stored before
+	Updated   []ingest.Update // Saved papers that supersede a stored version
+	Unchanged int             // Saved papers that were already stored at this version
+	Truncated int             // Valid papers cut to fit storage limits (see validation.Limits)
+	Partial   bool            // The save stopped part-way; Saved says how far it got
+
+	Shadow *ShadowSummary // Set when a shadow rule set was evaluated
+	Diff   *SyncDiff      // Set when Diff was requested and an earlier sync was found
+
+	Notifications *notify.Stats // Set when new papers were handed to Service.Notify
+
+	Timings *timing.Timings
+
+	returned []string // Base IDs of the deduplicated papers the query returned
+}
+
+// ShadowRules is a candidate filter rule set scored alongside the active
+// one. Its results are recorded but never decide what is saved.
+type ShadowRules struct {
+	Name     string         // Identifies the rule set in recorded results
+	Filter   *filter.Filter // Candidate thresholds and weights
+	MaxDelta int            // Score change beyond which a paper counts as diverging
+}
+
+// ShadowSummary reports how a candidate rule set diverged in one run.
+type ShadowSummary struct {
+	RuleSet     string              `json:"rule_set"`
+	Evaluated   int                 `json:"evaluated"`   // Papers scored by both rule sets
+	ToPass      int                 `json:"to_pass"`     // Rejected by the active rules, passed by the candidate
+	ToFail      int                 `json:"to_fail"`     // Passed by the active rules, rejected by the candidate
+	ScoreMoved  int                 `json:"score_moved"` // Same outcome, score moved by more than MaxDelta
+	Divergences []filter.Divergence `json:"divergences"`
+}
+
+// Service wires providers and stores into a sync.
+type Service struct {
+	Providers map[string]parser.Provider
+	Store     storage.PaperStore  // Required unless every run sets SkipSave
+	History   storage.SyncHistory // Optional sync log
+	Clock     clock.Clock         // Time source for recency and version detection (default: system clock)
+
+	PageTiers map[int]int       // Points by minimum page count for the active filter (see filter.Filter)
+	MaxText   int               // Characters of each text field the active filter reads (default: filter.DefaultMaxText)
+	Limits    validation.Limits // Field size caps applied before filtering (default: validation.DefaultLimits())
+
+	// NearDuplicates merges papers into a stored one (or one earlier in the
+	// batch) with another ID whose abstract fingerprint is within this many
+	// bits (0 = off; see similarity.DefaultMaxDistance). Stored papers are
+	// checked when Store implements storage.NearDuplicateFinder.
+	NearDuplicates int
+
+	Shadow    *ShadowRules      // Optional candidate rule set evaluated in shadow mode
+	ShadowLog storage.ShadowLog // Optional store for shadow divergences
+
+	// Notify announces the new papers of each sync; nothing waits for
+	// delivery. Optional.
+	Notify *notify.Queue
+
+	// Requests stores the HTTP requests of logged syncs for
+	// RequestRetention; nothing is captured while either is unset.
+	Requests         storage.SyncRequestLog
+	RequestRetention time.Duration
+}
+
+// LoadShadowRules reads a candidate rule set (see filter.LoadRules), named
+// after its file.
+func LoadShadowRules(path string, maxDelta int) (*ShadowRules, error) {
+	f, err := filter.LoadRules(path)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return &ShadowRules{Name: name, Filter: f, MaxDelta: maxDelta}, nil
+}
+
+// NewService creates a service with the given providers and store.
+func NewService(providers map[string]parser.Provider, store storage.PaperStore) *Service {
+	return &Service{Providers: providers, Store: store, Clock: clock.Real{}}
+}
+
+// Validate reports every way p conflicts with the service's
+// dependencies, joined into one error, before anything is fetched.
+func (s *Service) Validate(p RunParams) error {
+	if p.Provider == "" {
+		p.Provider = model.SourceArxiv
+	}
+	var errs []error
+	if _, ok := s.Providers[p.Provider]; !ok {
+		errs = append(errs, fmt.Errorf("%w %q", ErrUnknownProvider, p.Provider))
+	}
+	if p.AgeBy != "" && p.AgeBy != AgeUpdated && p.AgeBy != AgePublished {
+		errs = append(errs, fmt.Errorf("%w: max age applies to %q or %q, not %q", ErrInvalidParams, AgeUpdated, AgePublished, p.AgeBy))
+	}
+	if slices.Contains(p.Categories, "") {
+		errs = append(errs, fmt.Errorf("%w: empty category", ErrInvalidParams))
+	}
+	if p.StrictCategories && len(p.Categories) == 0 {
+		errs = append(errs, fmt.Errorf("%w: strict categories needs categories", ErrInvalidParams))
+	}
+	if p.MinScore < 0 || p.MinScore > 100 {
+		errs = append(errs, fmt.Errorf("%w: min score %d is outside 0-100", ErrInvalidParams, p.MinScore))
+	}
+	if !p.SkipSave && s.Store == nil {
+		errs = append(errs, fmt.Errorf("%w: saving needs a paper store", ErrInvalidParams))
+	}
+	if p.Incremental {
+		if p.SkipSave {
+			errs = append(errs, fmt.Errorf("%w: incremental syncs need the sync log, which SkipSave turns off", ErrInvalidParams))
+		} else if _, ok := s.History.(storage.SyncCheckpoints); !ok {
+			errs = append(errs, fmt.Errorf("%w: incremental syncs need a sync log that keeps checkpoints", ErrInvalidParams))
+		}
+		if p.Diff {
+			errs = append(errs, fmt.Errorf("%w: diff compares whole result sets, which an incremental sync does not fetch", ErrInvalidParams))
+		}
+	}
+	if p.Diff {
+		if p.SkipSave {
+			errs = append(errs, fmt.Errorf("%w: diff needs the sync log, which SkipSave turns off", ErrInvalidParams))
+		} else if _, ok := s.History.(storage.SyncResults); !ok {
+			errs = append(errs, fmt.Errorf("%w: diff needs a sync log that keeps result sets", ErrInvalidParams))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run performs one sync after checking p with Validate. The result is
+// filled in as far as the run got, also when it fails.
+func (s *Service) Run(ctx context.Context, p RunParams) (RunResult, error) {
+	if p.Provider == "" {
+		p.Provider = model.SourceArxiv
+	}
+	if p.Timings == nil {
+		p.Timings = &timing.Timings{}
+	}
+	result := RunResult{
+		Provider: p.Provider,
+		Query:    p.Query,
+		Rejected: make(map[string]int),
+		Timings:  p.Timings,
+	}
+
+	if err := s.Validate(p); err != nil {
+		return result, err
+	}
+	provider := s.Providers[p.Provider]
+	if sc, ok := provider.(parser.Scoper); ok && len(p.Categories) > 0 {
+		provider = sc.Scoped(p.Categories)
+	}
+
+	scope := storage.SyncScope{Provider: p.Provider, Categories: p.Categories, Strict: p.StrictCategories}
+	if p.Incremental {
+		result.Since = s.checkpoint(ctx, provider, p.Query, scope)
+	}
+	logID := 0
+	if !p.SkipSave {
+		logID = s.startSyncLog(ctx, p.Query)
+		result.SyncID = logID
+	}
+	if ss, ok := s.History.(storage.SyncScopes); ok && logID != 0 {
+		if err := ss.RecordSyncScope(ctx, logID, scope); err != nil {
+			log.Printf("Failed to record sync scope: %v", err)
+		}
+	}
+	if logID != 0 && !result.Since.IsZero() {
+		if err := s.History.(storage.SyncCheckpoints).RecordSyncWindow(ctx, logID, result.Since, clock.Or(s.Clock).Now()); err != nil {
+			log.Printf("Failed to record sync window: %v", err)
+		}
+	}
+	var rec *httpclient.Recorder
+	if logID != 0 && s.Requests != nil && s.RequestRetention > 0 {
+		rec = &httpclient.Recorder{}
+		ctx = httpclient.WithRecorder(ctx, rec)
+	}
+	err := s.run(ctx, provider, p, logID, &result)
+	if err == nil && !p.SkipSave {
+		result.Diff = s.compareResults(ctx, logID, p.Query, scope, resultSet(result.returned, &result), p.Diff)
+	}
+	if !p.SkipSave {
+		s.finishSyncLog(ctx, logID, &result, err)
+	}
+	if rec != nil {
+		s.recordRequests(ctx, logID, rec.Requests())
+	}
+	return result, err
+}
+
+func (s *Service) run(ctx context.Context, provider parser.Provider, p RunParams, logID int, result *RunResult) error {
+	clk := clock.Or(s.Clock)
+
+	var papers []model.Paper
+	err := p.Timings.Measure(timing.StageFetch, func() error {
+		if !result.Since.IsZero() {
+			fetched, err := provider.(parser.Incremental).FetchSince(ctx, p.Query, result.Since, p.Limit)
+			papers, result.Total, result.Sources, result.Repeats = fetched.Papers, fetched.Total, fetched.Sources, fetched.Duplicates
+			return err
+		}
+		if pf, ok := provider.(parser.PageFetcher); ok && p.OnPage != nil {
+			fetched, err := pf.FetchAllPages(ctx, p.Query, p.Limit, p.OnPage)
+			papers, result.Total, result.Sources, result.Repeats = fetched.Papers, fetched.Total, fetched.Sources, fetched.Duplicates
+			return err
+		}
+		if st, ok := provider.(parser.Streamer); ok && p.Progress != nil {
+			for paper, err := range st.FetchPapersIter(ctx, p.Query, p.Limit) {
+				if err != nil {
+					return err
+				}
+				papers = append(papers, paper)
+				p.Progress(len(papers))
+			}
+			return nil
+		}
+		rf, ok := provider.(parser.ResultFetcher)
+		if !ok {
+			var err error
+			papers, err = provider.FetchPapers(ctx, p.Query, p.Limit)
+			return err
+		}
+		fetched, err := rf.FetchResult(ctx, p.Query, p.Limit)
+		papers, result.Total, result.Sources, result.Repeats = fetched.Papers, fetched.Total, fetched.Sources, fetched.Duplicates
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("fetch papers: %w", err)
+	}
+	result.Fetched = len(papers)
+
+	seen := make(map[string]bool, len(papers))
+	papers = keep(papers, result, RejectDuplicate, func(paper model.Paper) bool {
+		dup := seen[paper.ID]
+		seen[paper.ID] = true
+		return !dup
+	})
+	result.Deduped = len(papers)
+	for _, paper := range papers {
+		result.returned = append(result.returned, paper.BaseID())
+	}
+
+	// Record provenance for providers that leave it to the caller
+	for i := range papers {
+		if papers[i].Source == "" {
+			papers[i].Source = p.Provider
+		}
+	}
+
+	if len(p.Categories) > 0 {
+		papers = keep(papers, result, RejectOutOfScope, func(paper model.Paper) bool {
+			return inScope(paper, p.Categories, p.StrictCategories)
+		})
+	}
+
+	if p.MaxAge > 0 {
+		cutoff := clk.Now().Add(-p.MaxAge)
+		papers = keep(papers, result, RejectTooOld, func(paper model.Paper) bool {
+			if p.AgeBy == AgePublished && !paper.Published.IsZero() {
+				return paper.Published.After(cutoff)
+			}
+			return paper.UpdatedAt.After(cutoff)
+		})
+	}
+
+	p.Timings.Measure(timing.StageValidation, func() error {
+		papers = keep(papers, result, RejectInvalid, validation.IsValid)
+		// Oversized fields are cut here so one bad record cannot fail the
+		// whole save on a column limit
+		for i, paper := range papers {
+			var changed []string
+			papers[i], changed = s.Limits.Sanitize(paper)
+			if len(changed) > 0 {
+				result.Truncated++
+				log.Printf("Truncated %s of paper %s to fit storage limits", strings.Join(changed, ", "), paper.ID)
+			}
+		}
+		return nil
+	})
+
+	if s.NearDuplicates > 0 {
+		papers = s.mergeNearDuplicates(ctx, papers, result)
+	}
+
+	f := filter.NewFilter()
+	if p.MinScore > 0 {
+		f.MinScore = p.MinScore
+	}
+	f.CategoryMinScore = p.CategoryMinScore
+	f.PageTiers = s.PageTiers
+	if s.MaxText > 0 {
+		f.MaxText = s.MaxText
+	}
+	// Shadow rules are scored even when the active filter is skipped, so
+	// unfiltered syncs still show what a candidate would change
+	if !p.SkipFilter || s.Shadow != nil {
+		var filtered []model.Paper
+		var rejected map[string]int
+		p.Timings.Measure(timing.StageFilter, func() error {
+			filtered, rejected = s.applyFilter(f, papers, result)
+			return nil
+		})
+		if !p.SkipFilter {
+			papers = filtered
+			for reason, n := range rejected {
+				result.Rejected[reason] += n
+			}
+		}
+		if result.Shadow != nil && !p.SkipSave {
+			s.recordShadow(ctx, logID, result.Shadow, clk.Now())
+		}
+	}
+	result.Passed = papers
+
+	if p.SkipSave || len(papers) == 0 {
+		return nil
+	}
+
+	var saved ingest.Result
+	err = p.Timings.Measure(timing.StageSave, func() error {
+		var err error
+		saved, err = ingest.Save(ctx, s.Store, f, papers, clk.Now())
+		return err
+	})
+	result.Saved = saved.Saved
+	result.New = saved.New
+	result.Updated = saved.Updated
+	result.Unchanged = saved.Saved - len(saved.New) - len(saved.Updated)
+	result.Partial = errors.Is(err, storage.ErrPartialSave)
+	// Papers saved before a partial save failed are announced too
+	if s.Notify != nil && len(result.New) > 0 {
+		stats := s.Notify.Enqueue(p.Query, result.New)
+		result.Notifications = &stats
+	}
+	if err != nil {
+		return fmt.Errorf("save papers: %w", err)
+	}
+	return nil
+}
+
+// mergeNearDuplicates drops papers whose abstracts nearly match a stored
+// paper or a paper kept earlier in the batch under another base ID, so
+// the same work arriving from two sources is stored once. Lookup errors
+// keep the paper.
+func (s *Service) mergeNearDuplicates(ctx context.Context, papers []model.Paper, result *RunResult) []model.Paper {
+	finder, _ := s.Store.(storage.NearDuplicateFinder)
+	type kept struct {
+		baseID string
+		id     string
+		fp     uint64
+	}
+	var batch []kept
+
+	return keep(papers, result, RejectNearDup, func(paper model.Paper) bool {
+		fp := similarity.Fingerprint(paper.Abstract)
+		if fp == 0 {
+			return true
+		}
+		base := paper.BaseID()
+		for _, k := range batch {
+			if k.baseID != base && similarity.Near(fp, k.fp, s.NearDuplicates) {
+				log.Printf("Merged paper %s into near-duplicate %s from the same batch", paper.ID, k.id)
+				return false
+			}
+		}
+		if finder != nil {
+			near, err := finder.FindNearFingerprint(ctx, base, fp, s.NearDuplicates)
+			if err != nil {
+				log.Printf("Failed to look up near duplicates of %s: %v", paper.ID, err)
+			} else if len(near) > 0 {
+				log.Printf("Merged paper %s into stored near-duplicate %s", paper.ID, near[0].ID)
+				return false
+			}
+		}
+		batch = append(batch, kept{baseID: base, id: paper.ID, fp: fp})
+		return true
+	})
+}
+
+// applyFilter scores papers in a single pass and returns those that pass,
+// with the others counted by reason. With shadow rules configured the same
+// pass also scores the candidate.
+func (s *Service) applyFilter(f *filter.Filter, papers []model.Paper, result *RunResult) ([]model.Paper, map[string]int) {
+	if s.Shadow != nil {
+		var divergences []filter.Divergence
+		result.FilterResults, divergences = filter.Shadow(f, s.Shadow.Filter, papers, s.Shadow.MaxDelta)
+		result.Shadow = summarizeShadow(s.Shadow.Name, len(papers), divergences)
+	} else {
+		result.FilterResults = f.Apply(papers)
+	}
+
+	passed := make([]model.Paper, 0, len(papers))
+	rejected := make(map[string]int)
+	for _, r := range result.FilterResults {
+		switch {
+		case !r.PassedLevel1:
+			rejected[RejectGate]++
+		case !f.Passed(r):
+			rejected[RejectLowScore]++
+		default:
+			paper := r.Paper
+			paper.Score = r.Score
+			paper.ScoreDetails = r.Details
+			passed = append(passed, paper)
+		}
+	}
+	return passed, rejected
+}
+
+func summarizeShadow(ruleSet string, evaluated int, divergences []filter.Divergence) *ShadowSummary {
+	sum := &ShadowSummary{RuleSet: ruleSet, Evaluated: evaluated, Divergences: divergences}
+	for _, d := range divergences {
+		switch {
+		case d.CandidatePassed && !d.ActivePassed:
+			sum.ToPass++
+		case d.ActivePassed && !d.CandidatePassed:
+			sum.ToFail++
+		default:
+			sum.ScoreMoved++
+		}
+	}
+	return sum
+}
+
+// recordShadow stores shadow divergences. Failing to record them must not
+// fail the sync.
+func (s *Service) recordShadow(ctx context.Context, logID int, sum *ShadowSummary, now time.Time) {
+	if s.ShadowLog == nil || len(sum.Divergences) == 0 {
+		return
+	}
+	records := make([]storage.ShadowResult, 0, len(sum.Divergences))
+	for _, d := range sum.Divergences {
+		records = append(records, storage.ShadowResult{
+			SyncID:          logID,
+			RuleSet:         sum.RuleSet,
+			PaperID:         d.PaperID,
+			ActiveScore:     d.ActiveScore,
+			CandidateScore:  d.CandidateScore,
+			ActivePassed:    d.ActivePassed,
+			CandidatePassed: d.CandidatePassed,
+			RecordedAt:      now,
+		})
+	}
+	if err := s.ShadowLog.RecordShadowResults(ctx, records); err != nil {
+		log.Printf("Failed to record shadow results: %v", err)
+	}
+}
+
+// recordRequests stores the HTTP requests of sync id and prunes those
+// past the retention. Failing to record them must not fail the sync.
+func (s *Service) recordRequests(ctx context.Context, id int, requests []httpclient.Request) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	records := make([]storage.SyncRequest, 0, len(requests))
+	for _, r := range requests {
+		records = append(records, storage.SyncRequest{
+			SyncID:     id,
+			URL:        r.URL,
+			Status:     r.Status,
+			Bytes:      r.Bytes,
+			DurationMs: r.Duration.Milliseconds(),
+			Retries:    r.Retries,
+			Error:      r.Err,
+			StartedAt:  r.StartedAt,
+		})
+	}
+	if err := s.Requests.RecordSyncRequests(ctx, id, records); err != nil {
+		log.Printf("Failed to record sync requests: %v", err)
+	}
+	cutoff := clock.Or(s.Clock).Now().Add(-s.RequestRetention)
+	if _, err := s.Requests.PruneSyncRequests(ctx, cutoff); err != nil {
+		log.Printf("Failed to prune sync requests: %v", err)
+	}
+}
+
+// inScope reports whether paper is in any of categories or, when strict,
+// has its primary category among them. Providers that do not report a
+// primary category list it first, and a paper without categories is in
+// no scope.
+func inScope(paper model.Paper, categories []string, strict bool) bool {
+	if strict {
+		primary := paper.PrimaryCategory
+		if primary == "" && len(paper.Categories) > 0 {
+			primary = paper.Categories[0]
+		}
+		return primary != "" && slices.Contains(categories, primary)
+	}
+	return slices.ContainsFunc(paper.Categories, func(c string) bool { return slices.Contains(categories, c) })
+}
+
+// keep returns the papers ok accepts, counting the others under reason.
+func keep(papers []model.Paper, result *RunResult, reason string, ok func(model.Paper) bool) []model.Paper {
+	kept := make([]model.Paper, 0, len(papers))
+	for _, paper := range papers {
+		if !ok(paper) {
+			result.Rejected[reason]++
+			continue
+		}
+		kept = append(kept, paper)
+	}
+	return kept
+}
+
+// checkpoint returns when the previous completed sync of query in scope
+// started, or zero for a full fetch: when there is none, it cannot be
+// read or the provider cannot fetch incrementally.
+func (s *Service) checkpoint(ctx context.Context, provider parser.Provider, query string, scope storage.SyncScope) time.Time {
+	if _, ok := provider.(parser.Incremental); !ok {
+		log.Printf("Provider cannot fetch incrementally, fetching in full")
+		return time.Time{}
+	}
+	prev, err := s.History.(storage.SyncCheckpoints).LastCompletedSync(ctx, query, scope)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Failed to read the last sync, fetching in full: %v", err)
+		}
+		return time.Time{}
+	}
+	return prev.StartedAt
+}
+
+// startSyncLog records the start of a sync and returns its log ID, or 0
+// when there is no sync log.
+func (s *Service) startSyncLog(ctx context.Context, query string) int {
+	if s.History == nil {
+		return 0
+	}
+	id, err := s.History.StartSync(ctx, query)
+	if err != nil {
+		log.Printf("Failed to record sync start: %v", err)
+		return 0
+	}
+	return id
+}
+
+// finishSyncLog records the outcome and timings of a sync. Failed syncs
+// keep the timings of the stages that ran.
+func (s *Service) finishSyncLog(ctx context.Context, id int, result *RunResult, syncErr error) {
+	if s.History == nil || id == 0 {
+		return
+	}
+
+	// The sync context may have expired; the log write gets its own deadline
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	var err error
+	if syncErr != nil {
+		err = s.History.FailSync(ctx, id, syncErr.Error(), result.Timings.Millis())
+	} else {
+		err = s.History.CompleteSync(ctx, id, result.Fetched, len(result.New), len(result.Updated), result.Timings.Millis())
+	}
+	if err != nil {
+		log.Printf("Failed to record sync result: %v", err)
+	}
+}
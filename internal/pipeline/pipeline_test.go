@@ -0,0 +1,1059 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/httpclient"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/notify"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/similarity"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/timing"
+)
+
+const abstract = "We report experiments on a new benchmark dataset with an ablation against a strong baseline."
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fixtureProvider returns the same papers for every query.
+type fixtureProvider struct {
+	papers []model.Paper
+	err    error
+}
+
+func (p fixtureProvider) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	return p.papers, p.err
+}
+
+// recordingHistory is a storage.SyncHistory that keeps the last outcome.
+type recordingHistory struct {
+	status             string
+	fetched, new, upds int
+	errMsg             string
+	timings            map[string]int64
+}
+
+func (h *recordingHistory) StartSync(ctx context.Context, query string) (int, error) {
+	h.status = "running"
+	return 1, nil
+}
+
+func (h *recordingHistory) CompleteSync(ctx context.Context, id int, fetched, newCount, updated int, timings map[string]int64) error {
+	h.status, h.fetched, h.new, h.upds, h.timings = "completed", fetched, newCount, updated, timings
+	return nil
+}
+
+func (h *recordingHistory) FailSync(ctx context.Context, id int, errMsg string, timings map[string]int64) error {
+	h.status, h.errMsg, h.timings = "failed", errMsg, timings
+	return nil
+}
+
+func (h *recordingHistory) GetSyncHistory(ctx context.Context, limit int) ([]storage.SyncLog, error) {
+	return nil, nil
+}
+
+// partialStore commits the first paper of each batch, then fails.
+type partialStore struct {
+	*memory.Store
+}
+
+func (s partialStore) SaveBatch(ctx context.Context, papers []model.Paper) error {
+	if err := s.Store.SaveBatch(ctx, papers[:1]); err != nil {
+		return err
+	}
+	return &storage.PartialSaveError{Committed: 1, Total: len(papers), Err: errors.New("connection reset")}
+}
+
+func (s partialStore) SaveBatchInserted(ctx context.Context, papers []model.Paper) (map[string]bool, map[string]bool, error) {
+	inserted, revised, err := s.Store.SaveBatchInserted(ctx, papers[:1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return inserted, revised, &storage.PartialSaveError{Committed: 1, Total: len(papers), Err: errors.New("connection reset")}
+}
+
+func paper(id, comments string, updated time.Time) model.Paper {
+	return model.Paper{
+		ID:        id,
+		Title:     "Paper " + id,
+		Authors:   []string{"A. Author"},
+		Abstract:  abstract,
+		Comments:  comments,
+		UpdatedAt: updated,
+	}
+}
+
+// fixture covers every rejection reason once, plus two papers that pass.
+func fixture() []model.Paper {
+	recent := now.Add(-24 * time.Hour)
+	weak := paper("2402.00004v1", "", recent)
+	weak.Abstract = "A position paper."
+	invalid := paper("2402.00006v1", "Accepted at ACL", recent)
+	invalid.Authors = nil
+
+	return []model.Paper{
+		paper("2402.00001v1", "Accepted at ACL", recent),
+		paper("2402.00002v1", "Camera-ready", recent),
+		paper("2402.00001v1", "Accepted at ACL", recent),                 // duplicate
+		paper("2402.00003v1", "", recent),                                // gate passes on evidence, score 35
+		weak,                                                             // no strong signal
+		paper("2402.00005v1", "Accepted at ACL", now.AddDate(0, 0, -90)), // too old
+		invalid,
+	}
+}
+
+func newService(store storage.PaperStore, papers []model.Paper) (*Service, *recordingHistory) {
+	history := &recordingHistory{}
+	svc := NewService(map[string]parser.Provider{model.SourceArxiv: fixtureProvider{papers: papers}}, store)
+	svc.History = history
+	svc.Clock = clock.NewFake(now)
+	return svc, history
+}
+
+func TestRun_CountsEveryStage(t *testing.T) {
+	store := memory.New()
+	svc, history := newService(store, fixture())
+
+	res, err := svc.Run(context.Background(), RunParams{Query: "llm", Limit: 10, MaxAge: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if res.Fetched != 7 || res.Deduped != 6 {
+		t.Errorf("fetched %d, deduped %d; want 7, 6", res.Fetched, res.Deduped)
+	}
+	wantRejected := map[string]int{
+		RejectDuplicate: 1,
+		RejectTooOld:    1,
+		RejectInvalid:   1,
+		RejectGate:      1,
+		RejectLowScore:  1,
+	}
+	for reason, n := range wantRejected {
+		if res.Rejected[reason] != n {
+			t.Errorf("rejected[%s] = %d, want %d", reason, res.Rejected[reason], n)
+		}
+	}
+	if len(res.FilterResults) != 4 {
+		t.Errorf("filtered %d papers, want 4", len(res.FilterResults))
+	}
+	if len(res.Passed) != 2 || res.Saved != 2 || len(res.New) != 2 || res.Unchanged != 0 {
+		t.Errorf("passed %d, saved %d, new %d, unchanged %d; want 2, 2, 2, 0",
+			len(res.Passed), res.Saved, len(res.New), res.Unchanged)
+	}
+	for _, p := range res.Passed {
+		if p.Score < 60 || p.Source != model.SourceArxiv {
+			t.Errorf("%s: score %d, source %q; want scored arxiv paper", p.ID, p.Score, p.Source)
+		}
+	}
+	if res.Partial {
+		t.Error("complete save reported as partial")
+	}
+
+	for _, stage := range []string{timing.StageFetch, timing.StageValidation, timing.StageFilter, timing.StageSave} {
+		if _, ok := res.Timings.Millis()[stage]; !ok {
+			t.Errorf("stage %q not timed", stage)
+		}
+	}
+	if history.status != "completed" || history.fetched != 7 || history.new != 2 {
+		t.Errorf("sync log = %s, fetched %d, new %d; want completed, 7, 2", history.status, history.fetched, history.new)
+	}
+
+	n, _ := store.Count(context.Background(), true)
+	if n != 2 {
+		t.Errorf("store holds %d papers, want 2", n)
+	}
+}
+
+func TestRun_SecondRunReportsUpdatesAndUnchanged(t *testing.T) {
+	store := memory.New()
+	recent := now.Add(-24 * time.Hour)
+	svc, _ := newService(store, []model.Paper{
+		paper("2402.00001v1", "Accepted at ACL", recent),
+		paper("2402.00002v1", "Accepted at ACL", recent),
+	})
+	if _, err := svc.Run(context.Background(), RunParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.Providers[model.SourceArxiv] = fixtureProvider{papers: []model.Paper{
+		paper("2402.00001v2", "Accepted at ACL", recent),
+		paper("2402.00002v1", "Accepted at ACL", recent),
+	}}
+	res, err := svc.Run(context.Background(), RunParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.New) != 0 || len(res.Updated) != 1 || res.Unchanged != 1 {
+		t.Errorf("new %d, updated %d, unchanged %d; want 0, 1, 1", len(res.New), len(res.Updated), res.Unchanged)
+	}
+}
+
+// totalProvider reports a total along with the fixture papers.
+type totalProvider struct {
+	fixtureProvider
+	total int
+}
+
+func (p totalProvider) FetchResult(ctx context.Context, query string, limit int) (parser.FetchResult, error) {
+	return parser.FetchResult{Papers: p.papers, Total: p.total}, nil
+}
+
+func TestRun_ReportsTotalMatches(t *testing.T) {
+	svc, _ := newService(memory.New(), fixture())
+	res, err := svc.Run(context.Background(), RunParams{SkipSave: true})
+	if err != nil || res.Total != 0 {
+		t.Fatalf("Run = total %d, %v; want 0 from a provider without totals", res.Total, err)
+	}
+
+	svc.Providers[model.SourceArxiv] = totalProvider{fixtureProvider{papers: fixture()}, 12431}
+	res, err = svc.Run(context.Background(), RunParams{SkipSave: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Fetched != 7 || res.Total != 12431 || res.Summary(nil, 0).Counts.Total != 12431 {
+		t.Errorf("fetched %d of %d, want 7 of 12431 in the result and summary", res.Fetched, res.Total)
+	}
+}
+
+// streamingProvider yields the fixture papers one at a time.
+type streamingProvider struct {
+	totalProvider
+}
+
+func (p streamingProvider) FetchPapersIter(ctx context.Context, query string, limit int) iter.Seq2[model.Paper, error] {
+	return func(yield func(model.Paper, error) bool) {
+		for _, paper := range p.papers {
+			if !yield(paper, nil) {
+				return
+			}
+		}
+		if p.err != nil {
+			yield(model.Paper{}, p.err)
+		}
+	}
+}
+
+func TestRun_StreamsWithProgress(t *testing.T) {
+	svc, _ := newService(memory.New(), fixture())
+	provider := streamingProvider{totalProvider{fixtureProvider{papers: fixture()}, 12431}}
+	svc.Providers[model.SourceArxiv] = provider
+
+	// Without Progress the provider's totals are used
+	res, err := svc.Run(context.Background(), RunParams{SkipSave: true})
+	if err != nil || res.Total != 12431 {
+		t.Fatalf("Run = total %d, %v; want the batch fetch", res.Total, err)
+	}
+
+	var progress []int
+	res, err = svc.Run(context.Background(), RunParams{SkipSave: true, Progress: func(n int) { progress = append(progress, n) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 3, 4, 5, 6, 7}; !reflect.DeepEqual(progress, want) || res.Fetched != 7 {
+		t.Errorf("progress %v for %d fetched, want %v", progress, res.Fetched, want)
+	}
+
+	provider.err = errors.New("connection reset")
+	svc.Providers[model.SourceArxiv] = provider
+	if _, err := svc.Run(context.Background(), RunParams{SkipSave: true, Progress: func(int) {}}); err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("Run = %v, want the stream's error", err)
+	}
+}
+
+// pagingProvider returns the fixture papers in pages of three.
+type pagingProvider struct {
+	fixtureProvider
+}
+
+func (p pagingProvider) FetchAllPages(ctx context.Context, query string, total int, onPage func(pageIdx, fetched, total int)) (parser.FetchResult, error) {
+	papers := p.papers[:min(total, len(p.papers))]
+	for i := 0; i*3 < len(papers); i++ {
+		onPage(i, min((i+1)*3, len(papers)), len(papers))
+	}
+	return parser.FetchResult{Papers: papers, Total: len(papers)}, nil
+}
+
+func TestRun_ReportsPages(t *testing.T) {
+	svc, _ := newService(memory.New(), fixture())
+	svc.Providers[model.SourceArxiv] = pagingProvider{fixtureProvider{papers: fixture()}}
+
+	var pages [][3]int
+	res, err := svc.Run(context.Background(), RunParams{SkipSave: true, Limit: 50, OnPage: func(page, fetched, total int) {
+		pages = append(pages, [3]int{page, fetched, total})
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][3]int{{0, 3, 7}, {1, 6, 7}, {2, 7, 7}}; !reflect.DeepEqual(pages, want) || res.Fetched != 7 {
+		t.Errorf("pages %v for %d fetched, want %v", pages, res.Fetched, want)
+	}
+}
+
+func TestRun_ReportsPagesRepeats(t *testing.T) {
+	// Each page after the first lists the previous page's last entry
+	// again, as when new submissions shift the results between requests
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("max_results"))
+		var b strings.Builder
+		b.WriteString(`<feed xmlns="http://www.w3.org/2005/Atom">`)
+		for i := max(start-1, 0); i < min(start+size, 5); i++ {
+			fmt.Fprintf(&b, `<entry><id>http://arxiv.org/abs/2402.%05dv1</id><title>Paper %d</title><summary>%s</summary>`+
+				`<updated>2024-02-29T00:00:00Z</updated><author><name>A. Author</name></author></entry>`, i, i, abstract)
+		}
+		b.WriteString(`</feed>`)
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Write([]byte(b.String()))
+	}))
+	defer srv.Close()
+	client := arxiv.NewClientWithOptions(srv.Client(), srv.URL)
+	client.PageSize = 2
+	client.Interval = time.Nanosecond
+
+	svc, _ := newService(memory.New(), nil)
+	svc.Providers[model.SourceArxiv] = client
+	pages := 0
+	res, err := svc.Run(context.Background(), RunParams{Query: "llm", Limit: 5, SkipSave: true, SkipFilter: true, OnPage: func(page, fetched, total int) {
+		pages++
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second page repeats 2402.00001; the third, at start 5, brings
+	// the one paper still missing
+	if pages != 3 || res.Fetched != 5 || res.Repeats != 1 {
+		t.Errorf("%d pages, %d fetched, %d repeats; want 3, 5 and 1", pages, res.Fetched, res.Repeats)
+	}
+}
+
+// scopedProvider records the categories it was scoped to and returns
+// the same papers whatever they are.
+type scopedProvider struct {
+	fixtureProvider
+	scopes *[][]string
+}
+
+func (p scopedProvider) Scoped(categories []string) parser.Provider {
+	*p.scopes = append(*p.scopes, categories)
+	return p.fixtureProvider
+}
+
+func TestRun_Categories(t *testing.T) {
+	recent := now.Add(-24 * time.Hour)
+	withCategories := func(id string, categories ...string) model.Paper {
+		p := paper(id, "Accepted at ACL", recent)
+		p.Categories = categories
+		return p
+	}
+	primaryLast := withCategories("2402.00005v1", "cs.LG", "cs.IR")
+	primaryLast.PrimaryCategory = "cs.IR"
+	papers := []model.Paper{
+		withCategories("2402.00001v1", "cs.CL"),
+		withCategories("2402.00002v1", "cs.LG", "cs.IR"), // cross-listed
+		withCategories("2402.00003v1", "cs.CV"),
+		withCategories("2402.00004v1"),
+		primaryLast, // The reported primary category wins over the order
+	}
+
+	tests := []struct {
+		name     string
+		strict   bool
+		expected []string
+	}{
+		{"any category", false, []string{"2402.00001v1", "2402.00002v1", "2402.00005v1"}},
+		{"primary category", true, []string{"2402.00001v1", "2402.00005v1"}},
+	}
+
+	for _, tc := range tests {
+		var scopes [][]string
+		svc, _ := newService(memory.New(), nil)
+		svc.Providers[model.SourceArxiv] = scopedProvider{fixtureProvider{papers: papers}, &scopes}
+
+		res, err := svc.Run(context.Background(), RunParams{Categories: []string{"cs.CL", "cs.IR"}, StrictCategories: tc.strict, SkipFilter: true})
+		if err != nil {
+			t.Fatalf("%s: Run failed: %v", tc.name, err)
+		}
+		if want := [][]string{{"cs.CL", "cs.IR"}}; !reflect.DeepEqual(scopes, want) {
+			t.Errorf("%s: provider scoped to %v, want %v", tc.name, scopes, want)
+		}
+		var saved []string
+		for _, p := range res.Passed {
+			saved = append(saved, p.ID)
+		}
+		if !reflect.DeepEqual(saved, tc.expected) {
+			t.Errorf("%s: passed %v, want %v", tc.name, saved, tc.expected)
+		}
+		if got := res.Rejected[RejectOutOfScope]; got != len(papers)-len(tc.expected) {
+			t.Errorf("%s: %d out of scope, want %d", tc.name, got, len(papers)-len(tc.expected))
+		}
+	}
+}
+
+// checkpointHistory is a storage.SyncHistory, storage.SyncCheckpoints
+// and storage.SyncScopes that keeps every sync in memory. Syncs start at
+// the fake clock's time.
+type checkpointHistory struct {
+	recordingHistory
+	clock  *clock.Fake
+	logs   []storage.SyncLog
+	scopes map[int]storage.SyncScope
+}
+
+func (h *checkpointHistory) StartSync(ctx context.Context, query string) (int, error) {
+	h.logs = append(h.logs, storage.SyncLog{ID: len(h.logs) + 1, Query: query, Status: "running", StartedAt: h.clock.Now()})
+	return len(h.logs), nil
+}
+
+func (h *checkpointHistory) CompleteSync(ctx context.Context, id int, fetched, newCount, updated int, timings map[string]int64) error {
+	h.logs[id-1].Status = "completed"
+	return nil
+}
+
+func (h *checkpointHistory) LastCompletedSync(ctx context.Context, query string, scope storage.SyncScope) (storage.SyncLog, error) {
+	for i := len(h.logs) - 1; i >= 0; i-- {
+		if l := h.logs[i]; l.Query == query && l.Status == "completed" && h.scopes[l.ID].Equal(scope) {
+			return l, nil
+		}
+	}
+	return storage.SyncLog{}, storage.ErrNotFound
+}
+
+func (h *checkpointHistory) RecordSyncScope(ctx context.Context, id int, scope storage.SyncScope) error {
+	if h.scopes == nil {
+		h.scopes = make(map[int]storage.SyncScope)
+	}
+	h.scopes[id] = scope
+	return nil
+}
+
+func (h *checkpointHistory) RecordSyncWindow(ctx context.Context, id int, from, to time.Time) error {
+	h.logs[id-1].WindowFrom, h.logs[id-1].WindowTo = &from, &to
+	return nil
+}
+
+// sinceProvider is a parser.Incremental that serves the papers updated
+// since the time asked for, and records what it was asked.
+type sinceProvider struct {
+	fixtureProvider
+	full  int         // FetchPapers calls
+	since []time.Time // FetchSince calls
+}
+
+func (p *sinceProvider) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	p.full++
+	return p.papers, nil
+}
+
+func (p *sinceProvider) FetchSince(ctx context.Context, query string, since time.Time, limit int) (parser.FetchResult, error) {
+	p.since = append(p.since, since)
+	var papers []model.Paper
+	for _, paper := range p.papers {
+		if !paper.UpdatedAt.Before(since) {
+			papers = append(papers, paper)
+		}
+	}
+	return parser.FetchResult{Papers: papers}, nil
+}
+
+func TestRun_Incremental(t *testing.T) {
+	first := now.Add(-24 * time.Hour)
+	clk := clock.NewFake(first)
+	provider := &sinceProvider{fixtureProvider: fixtureProvider{papers: []model.Paper{
+		paper("2402.00001v1", "Accepted at ACL", first.Add(-time.Hour)),
+	}}}
+	history := &checkpointHistory{clock: clk}
+	store := memory.New()
+	svc := NewService(map[string]parser.Provider{model.SourceArxiv: provider}, store)
+	svc.History, svc.Clock = history, clk
+	ctx := context.Background()
+
+	// Without a previous sync the fetch is full
+	res, err := svc.Run(ctx, RunParams{Query: "llm", Incremental: true})
+	if err != nil {
+		t.Fatalf("first Run: %v", err)
+	}
+	if provider.full != 1 || len(provider.since) != 0 || !res.Since.IsZero() {
+		t.Errorf("first sync: %d full and %v incremental fetches, since %v; want one full fetch", provider.full, provider.since, res.Since)
+	}
+	if history.logs[0].WindowFrom != nil {
+		t.Errorf("full sync recorded window from %v", history.logs[0].WindowFrom)
+	}
+
+	// The next sync asks only for what changed since the first started
+	provider.papers = append(provider.papers, paper("2402.00002v1", "Accepted at ACL", now.Add(-time.Hour)))
+	clk.Advance(24 * time.Hour)
+	res, err = svc.Run(ctx, RunParams{Query: "llm", Incremental: true})
+	if err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if provider.full != 1 || !reflect.DeepEqual(provider.since, []time.Time{first}) {
+		t.Errorf("second sync: %d full fetches, incremental since %v; want one since %v", provider.full, provider.since, first)
+	}
+	if res.Fetched != 1 || len(res.New) != 1 || res.New[0].ID != "2402.00002v1" {
+		t.Errorf("fetched %d, new %v; want only 2402.00002v1", res.Fetched, res.New)
+	}
+	if !res.Since.Equal(first) {
+		t.Errorf("since = %v, want %v", res.Since, first)
+	}
+	if l := history.logs[1]; l.WindowFrom == nil || !l.WindowFrom.Equal(first) || l.WindowTo == nil || !l.WindowTo.Equal(now) {
+		t.Errorf("recorded window %v - %v, want %v - %v", l.WindowFrom, l.WindowTo, first, now)
+	}
+	if n, _ := store.Count(ctx, true); n != 2 {
+		t.Errorf("store holds %d papers, want 2", n)
+	}
+
+	// Another query has no checkpoint yet
+	if _, err := svc.Run(ctx, RunParams{Query: "rag", Incremental: true}); err != nil {
+		t.Fatalf("other Run: %v", err)
+	}
+	if provider.full != 2 {
+		t.Errorf("other query: %d full fetches, want 2", provider.full)
+	}
+}
+
+func TestRun_IncrementalScope(t *testing.T) {
+	first := now.Add(-24 * time.Hour)
+	clk := clock.NewFake(first)
+	arxiv := &sinceProvider{fixtureProvider: fixtureProvider{papers: []model.Paper{
+		paper("2402.00001v1", "Accepted at ACL", first.Add(-time.Hour)),
+	}}}
+	openalex := &sinceProvider{fixtureProvider: fixtureProvider{papers: []model.Paper{
+		paper("2402.00002v1", "Accepted at ACL", first.Add(-time.Hour)),
+	}}}
+	history := &checkpointHistory{clock: clk}
+	svc := NewService(map[string]parser.Provider{model.SourceArxiv: arxiv, model.SourceOpenAlex: openalex}, memory.New())
+	svc.History, svc.Clock = history, clk
+	ctx := context.Background()
+
+	if _, err := svc.Run(ctx, RunParams{Query: "llm", Incremental: true}); err != nil {
+		t.Fatalf("arxiv Run: %v", err)
+	}
+	clk.Advance(time.Hour)
+
+	// The arXiv sync is no checkpoint for another provider, nor for the
+	// same provider restricted to categories
+	res, err := svc.Run(ctx, RunParams{Query: "llm", Provider: model.SourceOpenAlex, Incremental: true})
+	if err != nil {
+		t.Fatalf("openalex Run: %v", err)
+	}
+	if openalex.full != 1 || len(openalex.since) != 0 || !res.Since.IsZero() {
+		t.Errorf("openalex: %d full and %v incremental fetches; want one full fetch", openalex.full, openalex.since)
+	}
+	if len(res.New) != 1 || res.New[0].ID != "2402.00002v1" {
+		t.Errorf("openalex: new %v, want 2402.00002v1", res.New)
+	}
+	if _, err := svc.Run(ctx, RunParams{Query: "llm", Categories: []string{"cs.CL"}, Incremental: true}); err != nil {
+		t.Fatalf("scoped Run: %v", err)
+	}
+	if arxiv.full != 2 || len(arxiv.since) != 0 {
+		t.Errorf("scoped arxiv: %d full and %v incremental fetches; want a second full fetch", arxiv.full, arxiv.since)
+	}
+
+	// Each scope then follows its own checkpoint
+	clk.Advance(time.Hour)
+	if _, err := svc.Run(ctx, RunParams{Query: "llm", Incremental: true}); err != nil {
+		t.Fatalf("second arxiv Run: %v", err)
+	}
+	if !reflect.DeepEqual(arxiv.since, []time.Time{first}) {
+		t.Errorf("second arxiv sync since %v, want %v", arxiv.since, first)
+	}
+	if _, err := svc.Run(ctx, RunParams{Query: "llm", Provider: model.SourceOpenAlex, Incremental: true}); err != nil {
+		t.Fatalf("second openalex Run: %v", err)
+	}
+	if want := first.Add(time.Hour); !reflect.DeepEqual(openalex.since, []time.Time{want}) {
+		t.Errorf("second openalex sync since %v, want %v", openalex.since, want)
+	}
+}
+
+func TestRun_SkipFilterAndSkipSave(t *testing.T) {
+	store := memory.New()
+	svc, history := newService(store, fixture())
+
+	res, err := svc.Run(context.Background(), RunParams{SkipFilter: true, SkipSave: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without the filter only duplicates and invalid papers are dropped
+	if len(res.Passed) != 5 || res.FilterResults != nil {
+		t.Errorf("passed %d papers, filter results %v; want 5 unfiltered", len(res.Passed), res.FilterResults)
+	}
+	if res.Saved != 0 || history.status != "" {
+		t.Errorf("saved %d, sync log %q; want nothing saved or logged", res.Saved, history.status)
+	}
+	if n, _ := store.Count(context.Background(), true); n != 0 {
+		t.Errorf("store holds %d papers, want 0", n)
+	}
+}
+
+func TestRun_Failures(t *testing.T) {
+	recent := now.Add(-24 * time.Hour)
+	papers := []model.Paper{
+		paper("2402.00001v1", "Accepted at ACL", recent),
+		paper("2402.00002v1", "Accepted at ACL", recent),
+	}
+
+	t.Run("fetch", func(t *testing.T) {
+		svc, history := newService(memory.New(), nil)
+		svc.Providers[model.SourceArxiv] = fixtureProvider{err: errors.New("arxiv down")}
+		_, err := svc.Run(context.Background(), RunParams{})
+		if err == nil || history.status != "failed" {
+			t.Errorf("err = %v, sync log %q; want logged failure", err, history.status)
+		}
+		if len(history.timings) != 1 {
+			t.Errorf("timings = %v, want only fetch", history.timings)
+		}
+	})
+
+	t.Run("partial save", func(t *testing.T) {
+		svc, history := newService(partialStore{memory.New()}, papers)
+		res, err := svc.Run(context.Background(), RunParams{})
+		if !errors.Is(err, storage.ErrPartialSave) {
+			t.Fatalf("err = %v, want partial save", err)
+		}
+		if !res.Partial || res.Saved != 1 || len(res.New) != 1 {
+			t.Errorf("partial %v, saved %d, new %d; want true, 1, 1", res.Partial, res.Saved, len(res.New))
+		}
+		if history.status != "failed" {
+			t.Errorf("sync log = %q, want failed", history.status)
+		}
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		svc, history := newService(memory.New(), papers)
+		_, err := svc.Run(context.Background(), RunParams{Provider: "openreview"})
+		if !errors.Is(err, ErrUnknownProvider) {
+			t.Errorf("err = %v, want ErrUnknownProvider", err)
+		}
+		if history.status != "" {
+			t.Errorf("sync log = %q, want nothing logged", history.status)
+		}
+	})
+}
+
+// recordingShadowLog keeps recorded shadow results.
+type recordingShadowLog struct {
+	results []storage.ShadowResult
+}
+
+func (l *recordingShadowLog) RecordShadowResults(ctx context.Context, results []storage.ShadowResult) error {
+	l.results = append(l.results, results...)
+	return nil
+}
+
+func (l *recordingShadowLog) ShadowReport(ctx context.Context, ruleSet string, limit int) (storage.ShadowReport, error) {
+	return storage.ShadowReport{}, storage.ErrNotFound
+}
+
+func TestRun_ShadowRulesOnlyRecord(t *testing.T) {
+	store := memory.New()
+	svc, _ := newService(store, fixture())
+	shadowLog := &recordingShadowLog{}
+	svc.ShadowLog = shadowLog
+
+	// Acceptance notes no longer count: the accepted papers flip to fail
+	candidate := filter.NewFilter()
+	candidate.Weights.Accepted = 0
+	svc.Shadow = &ShadowRules{Name: "no-acceptance", Filter: candidate, MaxDelta: 10}
+
+	res, err := svc.Run(context.Background(), RunParams{MaxAge: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The active rules still decide what is saved
+	if res.Saved != 2 {
+		t.Errorf("saved %d, want the 2 papers the active rules pass", res.Saved)
+	}
+
+	sum := res.Shadow
+	if sum == nil {
+		t.Fatal("no shadow summary")
+	}
+	if sum.RuleSet != "no-acceptance" || sum.Evaluated != 4 || sum.ToFail != 2 || sum.ToPass != 0 {
+		t.Errorf("summary = %+v, want 4 evaluated, 2 flipped to fail", sum)
+	}
+	if len(shadowLog.results) != 2 {
+		t.Fatalf("recorded %d shadow results, want 2", len(shadowLog.results))
+	}
+	for _, r := range shadowLog.results {
+		if r.SyncID != 1 || r.RuleSet != "no-acceptance" || !r.ActivePassed || r.CandidatePassed || !r.RecordedAt.Equal(now) {
+			t.Errorf("recorded %+v, want a flip to fail in sync 1", r)
+		}
+	}
+}
+
+func TestRun_ShadowRulesWithSkipFilter(t *testing.T) {
+	svc, _ := newService(memory.New(), fixture())
+	candidate := filter.NewFilter()
+	candidate.Weights.Accepted = 0
+	svc.Shadow = &ShadowRules{Name: "no-acceptance", Filter: candidate}
+
+	res, err := svc.Run(context.Background(), RunParams{SkipFilter: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No max age: the old accepted paper is scored too
+	if res.Shadow == nil || res.Shadow.ToFail != 3 {
+		t.Errorf("shadow = %+v, want 3 flips even without the active filter", res.Shadow)
+	}
+	if res.Saved != 5 || res.Rejected[RejectGate] != 0 {
+		t.Errorf("saved %d, gate rejections %d; want every valid paper saved", res.Saved, res.Rejected[RejectGate])
+	}
+}
+
+// recordingRequests is a storage.SyncRequestLog that keeps what it is given.
+type recordingRequests struct {
+	requests []storage.SyncRequest
+	cutoff   time.Time
+}
+
+func (l *recordingRequests) RecordSyncRequests(ctx context.Context, id int, requests []storage.SyncRequest) error {
+	l.requests = append(l.requests, requests...)
+	return nil
+}
+
+func (l *recordingRequests) ListSyncRequests(ctx context.Context, id int) ([]storage.SyncRequest, error) {
+	return l.requests, nil
+}
+
+func (l *recordingRequests) PruneSyncRequests(ctx context.Context, cutoff time.Time) (int64, error) {
+	l.cutoff = cutoff
+	return 0, nil
+}
+
+// pagedArxiv serves total entries in pages, answering failStart with a 503.
+func pagedArxiv(t *testing.T, total, failStart int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("max_results"))
+		if start == failStart {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		var b strings.Builder
+		b.WriteString(`<feed xmlns="http://www.w3.org/2005/Atom">`)
+		for i := start; i < min(start+size, total); i++ {
+			fmt.Fprintf(&b, `<entry><id>http://arxiv.org/abs/2402.%05dv1</id><title>Paper %d</title><summary>%s</summary>`+
+				`<updated>2024-02-29T00:00:00Z</updated><author><name>A. Author</name></author></entry>`, i, i, abstract)
+		}
+		b.WriteString(`</feed>`)
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Write([]byte(b.String()))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRun_RecordsRequestsPerPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		failStart int
+		statuses  []int
+		fails     bool
+	}{
+		{"all pages", -1, []int{200, 200, 200}, false},
+		{"failing page", 2, []int{200, 503}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := pagedArxiv(t, 5, tc.failStart)
+			// The failing page must fail on its first attempt
+			noRetry := &http.Client{Timeout: 5 * time.Second, Transport: &httpclient.Transport{MaxRetries: -1}}
+			client := arxiv.NewClientWithOptions(noRetry, srv.URL)
+			client.PageSize = 2
+			client.Interval = time.Nanosecond
+
+			svc, history := newService(memory.New(), nil)
+			svc.Providers[model.SourceArxiv] = client
+			requests := &recordingRequests{}
+			svc.Requests = requests
+			svc.RequestRetention = 7 * 24 * time.Hour
+
+			result, err := svc.Run(context.Background(), RunParams{Query: "llm", Limit: 5, SkipFilter: true})
+			if (err != nil) != tc.fails {
+				t.Fatalf("Run error = %v, want failure %v", err, tc.fails)
+			}
+			if !tc.fails && result.Fetched != 5 {
+				t.Errorf("fetched %d papers, want 5", result.Fetched)
+			}
+
+			if len(requests.requests) != len(tc.statuses) {
+				t.Fatalf("recorded %d requests, want one per page (%d): %+v", len(requests.requests), len(tc.statuses), requests.requests)
+			}
+			for i, r := range requests.requests {
+				if r.SyncID != 1 || r.Status != tc.statuses[i] || (r.Status == http.StatusOK && r.Bytes == 0) {
+					t.Errorf("request %d = %+v, want sync 1 and status %d", i, r, tc.statuses[i])
+				}
+				if want := fmt.Sprintf("start=%d", i*2); !strings.Contains(r.URL, want) {
+					t.Errorf("request %d URL %s lacks %s", i, r.URL, want)
+				}
+			}
+			if want := now.Add(-svc.RequestRetention); !requests.cutoff.Equal(want) {
+				t.Errorf("pruned before %v, want %v", requests.cutoff, want)
+			}
+			if tc.fails && history.status != "failed" {
+				t.Errorf("sync status = %s, want failed", history.status)
+			}
+		})
+	}
+}
+
+func TestRun_RequestCaptureOffByDefault(t *testing.T) {
+	srv := pagedArxiv(t, 2, -1)
+	svc, _ := newService(memory.New(), nil)
+	svc.Providers[model.SourceArxiv] = arxiv.NewClientWithOptions(httpclient.New(5*time.Second), srv.URL)
+	requests := &recordingRequests{}
+	svc.Requests = requests
+
+	if _, err := svc.Run(context.Background(), RunParams{Query: "llm", Limit: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests.requests) != 0 {
+		t.Errorf("recorded %d requests with no retention set", len(requests.requests))
+	}
+}
+
+func TestService_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		store    bool
+		history  storage.SyncHistory
+		params   RunParams
+		expected []string // Substrings of the joined error, one per conflict
+	}{
+		{name: "valid", store: true, history: &resultHistory{}, params: RunParams{Diff: true}},
+		{name: "filter only without a store", params: RunParams{SkipSave: true}},
+		{
+			name:     "diff with skip save",
+			store:    true,
+			history:  &resultHistory{},
+			params:   RunParams{Diff: true, SkipSave: true},
+			expected: []string{"diff needs the sync log"},
+		},
+		{
+			name:     "diff without result sets",
+			store:    true,
+			history:  &recordingHistory{},
+			params:   RunParams{Diff: true},
+			expected: []string{"diff needs a sync log that keeps result sets"},
+		},
+		{
+			name:     "unknown age timestamp",
+			store:    true,
+			params:   RunParams{AgeBy: "created"},
+			expected: []string{`not "created"`},
+		},
+		{
+			name:     "strict without categories",
+			store:    true,
+			params:   RunParams{StrictCategories: true},
+			expected: []string{"strict categories needs categories"},
+		},
+		{name: "incremental", store: true, history: &checkpointHistory{}, params: RunParams{Incremental: true}},
+		{
+			name:     "incremental without checkpoints",
+			store:    true,
+			history:  &recordingHistory{},
+			params:   RunParams{Incremental: true},
+			expected: []string{"keeps checkpoints"},
+		},
+		{
+			name:     "incremental diff",
+			store:    true,
+			history:  &resultHistory{},
+			params:   RunParams{Incremental: true, Diff: true},
+			expected: []string{"keeps checkpoints", "incremental sync does not fetch"},
+		},
+		{
+			name:     "every conflict at once",
+			params:   RunParams{Provider: "openreview", MinScore: 120, Diff: true},
+			expected: []string{`unknown provider "openreview"`, "min score 120", "needs a paper store", "keeps result sets"},
+		},
+	}
+
+	for _, tc := range tests {
+		var store storage.PaperStore
+		if tc.store {
+			store = memory.New()
+		}
+		svc := NewService(map[string]parser.Provider{model.SourceArxiv: fixtureProvider{}}, store)
+		svc.History = tc.history
+
+		err := svc.Validate(tc.params)
+		if len(tc.expected) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+			continue
+		}
+		for _, want := range tc.expected {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: error %q does not mention %q", tc.name, err, want)
+			}
+		}
+		if n := len(strings.Split(err.Error(), "\n")); n != len(tc.expected) {
+			t.Errorf("%s: %d conflicts reported, want %d", tc.name, n, len(tc.expected))
+		}
+	}
+}
+
+func TestRun_MergesNearDuplicates(t *testing.T) {
+	const routing = `We propose a sparse routing method for mixture-of-experts language models that assigns tokens to experts with a learned balanced assignment. ` +
+		`Compared with top-k gating, our router reduces expert overload by 40% and improves perplexity on C4 and The Pile at equal compute. ` +
+		`We analyse the learned assignments and find that experts specialise by syntax rather than topic.`
+	const retrieval = `We study retrieval augmented generation for open-domain question answering. Our retriever is trained jointly with the reader ` +
+		`using a contrastive objective over hard negatives mined from Wikipedia. Experiments on Natural Questions and TriviaQA show gains of 3 points ` +
+		`in exact match over strong dense retrieval baselines.`
+	recent := now.Add(-24 * time.Hour)
+	withAbstract := func(id, text string) model.Paper {
+		p := paper(id, "", recent)
+		p.Abstract = text
+		return p
+	}
+
+	store := memory.New()
+	store.SaveBatch(context.Background(), []model.Paper{withAbstract("2403.00001v1", routing)})
+	svc, _ := newService(store, []model.Paper{
+		withAbstract("2403.00009v1", routing[:len(routing)-20]),                               // stored paper, cut short by another source
+		withAbstract("2403.00002v1", retrieval),                                               // new
+		withAbstract("2403.00003v1", strings.Replace(retrieval, "3 points", "$3$ points", 1)), // same batch, LaTeX kept
+		withAbstract("2403.00001v2", routing),                                                 // new version of the stored paper
+	})
+	svc.NearDuplicates = similarity.DefaultMaxDistance
+
+	res, err := svc.Run(context.Background(), RunParams{SkipFilter: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rejected[RejectNearDup] != 2 {
+		t.Errorf("merged %d near duplicates, want 2", res.Rejected[RejectNearDup])
+	}
+	var ids []string
+	for _, p := range res.Passed {
+		ids = append(ids, p.ID)
+	}
+	if want := []string{"2403.00002v1", "2403.00001v2"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("kept %v, want %v", ids, want)
+	}
+
+	near, err := store.FindNearDuplicates(context.Background(), "2403.00002v1", similarity.DefaultMaxDistance)
+	if err != nil || len(near) != 0 {
+		t.Errorf("FindNearDuplicates = %v, %v; want none stored beside the kept paper", near, err)
+	}
+}
+
+// stalledNotifier accepts no message until release is closed.
+type stalledNotifier struct {
+	release  chan struct{}
+	received atomic.Int64
+}
+
+func (n *stalledNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	select {
+	case <-n.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	n.received.Add(1)
+	return nil
+}
+
+func TestRun_NotifiesWithoutBlocking(t *testing.T) {
+	recent := now.Add(-24 * time.Hour)
+	backfill := make([]model.Paper, 500)
+	for i := range backfill {
+		backfill[i] = paper(fmt.Sprintf("2402.%05dv1", i), "Accepted at ACL", recent)
+	}
+	svc, _ := newService(memory.New(), backfill)
+	n := &stalledNotifier{release: make(chan struct{})}
+	svc.Notify = notify.New(n, notify.Config{BatchSize: 10, MaxPerSync: 50, QueueSize: 3})
+
+	done := make(chan RunResult)
+	go func() {
+		res, err := svc.Run(context.Background(), RunParams{Query: "llm"})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- res
+	}()
+	var res RunResult
+	select {
+	case res = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sync waited for a stalled notifier")
+	}
+
+	if len(res.New) != 500 || res.Notifications == nil {
+		t.Fatalf("saved %d new papers, notifications %+v", len(res.New), res.Notifications)
+	}
+	// 5 batches of 10 and a summary of the other 450; the worker holds
+	// one message and the queue three, so at least two are dropped
+	stats := *res.Notifications
+	if stats.Sent+stats.Dropped != 6 || stats.Dropped < 2 || stats.Overflow != 450 {
+		t.Errorf("notification stats = %+v", stats)
+	}
+
+	close(n.release)
+	if err := svc.Notify.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := int(n.received.Load()); got != stats.Sent {
+		t.Errorf("delivered %d messages, want %d", got, stats.Sent)
+	}
+}
+
+func TestRun_MaxAgeBy(t *testing.T) {
+	// A revision of an old paper, a new paper, and a paper whose provider
+	// reports no publication time
+	revised := paper("2201.00001v5", "Accepted at ACL", now.Add(-24*time.Hour))
+	revised.Published = now.AddDate(-2, 0, 0)
+	fresh := paper("2402.00002v1", "Accepted at ACL", now.Add(-24*time.Hour))
+	fresh.Published = fresh.UpdatedAt
+	unknown := paper("2402.00003v1", "Accepted at ACL", now.Add(-24*time.Hour))
+
+	tests := []struct {
+		ageBy    string
+		expected []string
+	}{
+		{"", []string{"2201.00001v5", "2402.00002v1", "2402.00003v1"}},
+		{AgeUpdated, []string{"2201.00001v5", "2402.00002v1", "2402.00003v1"}},
+		{AgePublished, []string{"2402.00002v1", "2402.00003v1"}},
+	}
+
+	for _, tc := range tests {
+		svc, _ := newService(nil, []model.Paper{revised, fresh, unknown})
+		res, err := svc.Run(context.Background(), RunParams{MaxAge: 30 * 24 * time.Hour, AgeBy: tc.ageBy, SkipFilter: true, SkipSave: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, p := range res.Passed {
+			ids = append(ids, p.ID)
+		}
+		if !reflect.DeepEqual(ids, tc.expected) {
+			t.Errorf("age by %q: kept %v, want %v", tc.ageBy, ids, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"github.com/1psychoQAQ/genesis-pipeline/internal/notify"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+)
+
+// Summary is the stable JSON form of a run for scripts such as CI jobs.
+// Fields are only ever added, never renamed or removed.
+type Summary struct {
+	Provider string `json:"provider"`
+	Query    string `json:"query"`
+	SyncID   int    `json:"sync_id"` // sync_log row, 0 when the run was not logged
+
+	Counts   SummaryCounts  `json:"counts"`
+	Rejected map[string]int `json:"rejected"` // Papers dropped before saving, with every Reject* reason present
+	Partial  bool           `json:"partial"`  // The save stopped part-way
+
+	// Sources counts the papers fetched from each source of a provider
+	// that merges several; absent for single-source providers
+	Sources map[string]int `json:"sources,omitempty"`
+
+	TimingsMS     map[string]int64 `json:"timings_ms"` // Stage durations in milliseconds, measured stages only
+	Notifications *notify.Stats    `json:"notifications"`
+
+	Error     string `json:"error"`      // Empty on success
+	ErrorKind string `json:"error_kind"` // The parser.Kind* of Error, empty when it is none of them
+	ExitCode  int    `json:"exit_code"`  // Status the process exits with
+}
+
+// SummaryCounts counts the papers at each stage of a run.
+type SummaryCounts struct {
+	Fetched   int `json:"fetched"`
+	Total     int `json:"total_matches"` // 0 when the provider does not report it
+	Deduped   int `json:"deduped"`
+	Passed    int `json:"passed"`
+	Saved     int `json:"saved"`
+	New       int `json:"new"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Truncated int `json:"truncated"`
+}
+
+// rejectReasons lists every Reject* reason, so summaries carry the same
+// keys whatever was dropped.
+var rejectReasons = []string{RejectDuplicate, RejectNearDup, RejectOutOfScope, RejectTooOld, RejectInvalid, RejectGate, RejectLowScore}
+
+// Summary returns the run's Summary, given the error Run returned and the
+// process's exit code.
+func (r RunResult) Summary(err error, exitCode int) Summary {
+	s := Summary{
+		Provider: r.Provider,
+		Query:    r.Query,
+		SyncID:   r.SyncID,
+		Counts: SummaryCounts{
+			Fetched:   r.Fetched,
+			Total:     r.Total,
+			Deduped:   r.Deduped,
+			Passed:    len(r.Passed),
+			Saved:     r.Saved,
+			New:       len(r.New),
+			Updated:   len(r.Updated),
+			Unchanged: r.Unchanged,
+			Truncated: r.Truncated,
+		},
+		Sources:       r.Sources,
+		Rejected:      make(map[string]int, len(rejectReasons)),
+		Partial:       r.Partial,
+		TimingsMS:     map[string]int64{},
+		Notifications: r.Notifications,
+		ExitCode:      exitCode,
+	}
+	for _, reason := range rejectReasons {
+		s.Rejected[reason] = r.Rejected[reason]
+	}
+	if r.Timings != nil {
+		for name, ms := range r.Timings.Millis() {
+			s.TimingsMS[name] = ms
+		}
+	}
+	if err != nil {
+		s.Error = err.Error()
+		s.ErrorKind = parser.ErrorKind(err)
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/ingest"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/notify"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/timing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestSummary_Golden(t *testing.T) {
+	timings := &timing.Timings{}
+	timings.Add(timing.StageFetch, 1500*time.Millisecond)
+	timings.Add(timing.StageFilter, 20*time.Millisecond)
+	timings.Add(timing.StageSave, 300*time.Millisecond)
+
+	tests := []struct {
+		name     string
+		golden   string
+		result   RunResult
+		err      error
+		exitCode int
+	}{
+		{
+			name:   "saved",
+			golden: "summary_saved.golden",
+			result: RunResult{
+				Provider: model.SourceArxiv, Query: "llm", SyncID: 42,
+				Fetched: 7, Deduped: 6,
+				Passed:   make([]model.Paper, 3),
+				Rejected: map[string]int{RejectDuplicate: 1, RejectGate: 2, RejectTooOld: 1},
+				Saved:    3, New: make([]model.Paper, 2), Updated: make([]ingest.Update, 1),
+				Timings:       timings,
+				Notifications: &notify.Stats{Sent: 1, Batched: 2},
+			},
+		},
+		{
+			name:   "failed without a database",
+			golden: "summary_failed.golden",
+			result: RunResult{
+				Provider: model.SourceArxiv, Query: "llm", Rejected: map[string]int{},
+			},
+			err:      fmt.Errorf("fetch papers: %w", parser.ErrUnavailable),
+			exitCode: 3,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := json.MarshalIndent(tc.result.Summary(tc.err, tc.exitCode), "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, '\n')
+
+		path := filepath.Join("testdata", tc.golden)
+		if *update {
+			if err := os.WriteFile(path, got, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read golden (run with -update to create): %v", err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s:\n%s\nwant:\n%s", tc.name, got, want)
+		}
+	}
+}
+
+func TestRun_SummarySyncID(t *testing.T) {
+	for _, skipSave := range []bool{false, true} {
+		svc, _ := newService(memory.New(), fixture())
+		res, err := svc.Run(context.Background(), RunParams{Query: "llm", SkipSave: skipSave})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := 1 // recordingHistory's ID
+		if skipSave {
+			want = 0
+		}
+		if s := res.Summary(nil, 0); s.SyncID != want || s.Counts.Fetched != 7 {
+			t.Errorf("skip save %t: summary %+v, want sync ID %d", skipSave, s, want)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package preset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+)
+
+// ErrInvalidPreset is wrapped by errors about malformed preset definitions.
+var ErrInvalidPreset = errors.New("invalid preset")
+
+// Definition is a preset as written in a presets file. A preset that
+// extends another inherits every field it leaves unset. Keyword lists can
+// replace the parent's (keywords) or edit it (add_keywords,
+// remove_keywords). A query is either written in arXiv's syntax (query)
+// or as a boolean search (boolean_query, see arxiv.ParseExpr), e.g.
+// ("chain of thought" OR CoT) AND "large language model".
+type Definition struct {
+	Name             string         `json:"name"`
+	Extends          string         `json:"extends,omitempty"`
+	Description      string         `json:"description,omitempty"`
+	Keywords         []string       `json:"keywords,omitempty"`
+	AddKeywords      []string       `json:"add_keywords,omitempty"`
+	RemoveKeywords   []string       `json:"remove_keywords,omitempty"`
+	Categories       []string       `json:"categories,omitempty"`
+	Query            string         `json:"query,omitempty"`
+	BooleanQuery     string         `json:"boolean_query,omitempty"`
+	MinScore         *int           `json:"min_score,omitempty"`
+	MaxAgeDays       *int           `json:"max_age_days,omitempty"`
+	CategoryMinScore map[string]int `json:"category_min_score,omitempty"`
+}
+
+// ReadFile reads a JSON array of definitions and returns the built-in
+// presets plus the resolved ones. Definitions may extend built-in presets,
+// each other in any order, and override built-ins of the same name.
+func ReadFile(path string) (map[string]SearchPreset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read presets: %w", err)
+	}
+
+	var defs []Definition
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("parse presets %s: %w", path, err)
+	}
+
+	presets := Builtins()
+	resolved, err := Resolve(defs, presets)
+	if err != nil {
+		return nil, fmt.Errorf("load presets %s: %w", path, err)
+	}
+	for name, p := range resolved {
+		// An override may recase a built-in's name; keep only its casing
+		for builtin := range presets {
+			if Key(builtin) == Key(name) {
+				delete(presets, builtin)
+			}
+		}
+		presets[name] = p
+	}
+	return presets, nil
+}
+
+// ParseCandidate parses one definition, a JSON object, and resolves it
+// against base without registering it, e.g. to try an edited preset
+// before it goes into the presets file. Unlike in a file, a candidate may
+// extend the preset of its own name, to change only some of its fields.
+func ParseCandidate(data []byte, base []SearchPreset) (SearchPreset, error) {
+	var d Definition
+	if err := json.Unmarshal(data, &d); err != nil {
+		return SearchPreset{}, fmt.Errorf("parse candidate preset: %w", err)
+	}
+	bases := make(map[string]SearchPreset, len(base))
+	for _, p := range base {
+		bases[p.Name] = p
+	}
+
+	// Resolved under another name, the candidate's parent is the base
+	// preset rather than the candidate itself
+	name := d.Name
+	if Key(name) != "" && Key(d.Extends) == Key(name) {
+		d.Name = name + " (candidate)"
+	}
+	resolved, err := Resolve([]Definition{d}, bases)
+	if err != nil {
+		return SearchPreset{}, err
+	}
+	p := resolved[d.Name]
+	p.Name = name
+	return p, nil
+}
+
+// ReadCandidate reads a candidate definition from path, see ParseCandidate.
+func ReadCandidate(path string, base []SearchPreset) (SearchPreset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SearchPreset{}, fmt.Errorf("read candidate preset: %w", err)
+	}
+	return ParseCandidate(data, base)
+}
+
+// Resolve turns definitions into presets, following extends chains through
+// the definitions and then base. Names, including those in extends, match
+// ignoring case (see Key); two definitions whose names differ only in case
+// are an error. Unknown parents and cycles are errors.
+func Resolve(defs []Definition, base map[string]SearchPreset) (map[string]SearchPreset, error) {
+	byKey := make(map[string]Definition, len(defs))
+	for _, d := range defs {
+		if Key(d.Name) == "" {
+			return nil, fmt.Errorf("%w: definition without a name", ErrInvalidPreset)
+		}
+		if prev, dup := byKey[Key(d.Name)]; dup {
+			if prev.Name == d.Name {
+				return nil, fmt.Errorf("%w %q: defined twice", ErrInvalidPreset, d.Name)
+			}
+			return nil, fmt.Errorf("%w %q: same name as %q but for case", ErrInvalidPreset, d.Name, prev.Name)
+		}
+		byKey[Key(d.Name)] = d
+	}
+	baseByKey := make(map[string]SearchPreset, len(base))
+	for _, p := range base {
+		baseByKey[Key(p.Name)] = p
+	}
+
+	r := resolver{defs: byKey, base: baseByKey, done: make(map[string]SearchPreset)}
+	resolved := make(map[string]SearchPreset, len(defs))
+	for _, d := range defs {
+		p, err := r.resolve(d.Name, nil)
+		if err != nil {
+			return nil, err
+		}
+		resolved[p.Name] = p
+	}
+	return resolved, nil
+}
+
+// resolver maps are keyed by Key of the preset name.
+type resolver struct {
+	defs map[string]Definition
+	base map[string]SearchPreset
+	done map[string]SearchPreset
+}
+
+// resolve returns the preset called name. chain holds the names being
+// resolved below it, to detect cycles.
+func (r *resolver) resolve(name string, chain []string) (SearchPreset, error) {
+	if p, ok := r.done[Key(name)]; ok {
+		return p, nil
+	}
+	for i, n := range chain {
+		if Key(n) == Key(name) {
+			cycle := append(append([]string(nil), chain[i:]...), name)
+			return SearchPreset{}, fmt.Errorf("%w %q: extends cycle %s", ErrInvalidPreset, chain[0], strings.Join(cycle, " -> "))
+		}
+	}
+
+	d, ok := r.defs[Key(name)]
+	if !ok {
+		// Built-ins are complete; they cannot extend anything
+		if p, ok := r.base[Key(name)]; ok {
+			return p, nil
+		}
+		return SearchPreset{}, fmt.Errorf("%w %q: extends unknown preset %q", ErrInvalidPreset, chain[len(chain)-1], name)
+	}
+
+	if d.BooleanQuery != "" {
+		if d.Query != "" {
+			return SearchPreset{}, fmt.Errorf("%w %q: both query and boolean_query set", ErrInvalidPreset, d.Name)
+		}
+		e, err := arxiv.ParseExpr(d.BooleanQuery)
+		if err != nil {
+			return SearchPreset{}, fmt.Errorf("%w %q: boolean_query: %w", ErrInvalidPreset, d.Name, err)
+		}
+		d.Query = e.String()
+	}
+
+	var parent SearchPreset
+	if d.Extends != "" {
+		var err error
+		parent, err = r.resolve(d.Extends, append(chain, name))
+		if err != nil {
+			return SearchPreset{}, err
+		}
+	}
+
+	p := apply(parent, d)
+	r.done[Key(name)] = p
+	return p, nil
+}
+
+// apply overrides parent with the fields d sets.
+func apply(parent SearchPreset, d Definition) SearchPreset {
+	p := parent
+	p.Name = d.Name
+	p.Parents = nil
+	if d.Extends != "" {
+		p.Parents = append([]string{d.Extends}, parent.Parents...)
+	}
+	if d.Description != "" {
+		p.Description = d.Description
+	}
+
+	keywords := parent.Keywords
+	if d.Keywords != nil {
+		keywords = d.Keywords
+	}
+	keywords = removeKeywords(addKeywords(keywords, d.AddKeywords), d.RemoveKeywords)
+	keywordsChanged := d.Keywords != nil || len(d.AddKeywords) > 0 || len(d.RemoveKeywords) > 0
+	p.Keywords = keywords
+
+	if d.Categories != nil {
+		p.Categories = d.Categories
+	}
+	if d.MinScore != nil {
+		p.MinScore = *d.MinScore
+	}
+	if d.MaxAgeDays != nil {
+		p.MaxAgeDays = *d.MaxAgeDays
+	}
+	if d.CategoryMinScore != nil {
+		p.CategoryMinScore = d.CategoryMinScore
+	}
+
+	// A hand-written query wins; otherwise rebuild it when its inputs changed
+	switch {
+	case d.Query != "":
+		p.Query = d.Query
+	case keywordsChanged || d.Categories != nil:
+		p.Query = BuildQuery(p.Keywords, p.Categories)
+	}
+	return p
+}
+
+func addKeywords(keywords, add []string) []string {
+	out := append([]string(nil), keywords...)
+	for _, k := range add {
+		if indexFold(out, k) < 0 {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func removeKeywords(keywords, remove []string) []string {
+	out := make([]string, 0, len(keywords))
+	for _, k := range keywords {
+		if indexFold(remove, k) < 0 {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func indexFold(list []string, s string) int {
+	for i, v := range list {
+		if strings.EqualFold(v, s) {
+			return i
+		}
+	}
+	return -1
+}
+
+// BuildQuery expands keywords and categories into an arXiv search query:
+// any keyword may match, and the paper must be in one of the categories.
+func BuildQuery(keywords, categories []string) string {
+	terms := make([]string, 0, len(keywords))
+	for _, k := range keywords {
+		if strings.ContainsAny(k, " -") {
+			k = `"` + k + `"`
+		}
+		terms = append(terms, "all:"+k)
+	}
+	cats := make([]string, 0, len(categories))
+	for _, c := range categories {
+		cats = append(cats, "cat:"+c)
+	}
+
+	var parts []string
+	if len(terms) > 0 {
+		parts = append(parts, group(terms))
+	}
+	if len(cats) > 0 {
+		parts = append(parts, group(cats))
+	}
+	return strings.Join(parts, " AND ")
+}
+
+func group(terms []string) string {
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	return "(" + strings.Join(terms, " OR ") + ")"
+}
@@ -0,0 +1,246 @@
+package preset
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func intp(n int) *int { return &n }
+
+var testBase = map[string]SearchPreset{
+	"rag": {
+		Name:       "rag",
+		Keywords:   []string{"retrieval augmented generation", "RAG", "knowledge retrieval"},
+		Query:      "retrieval augmented generation RAG",
+		MinScore:   50,
+		MaxAgeDays: 180,
+	},
+}
+
+func TestResolve_TwoLevelInheritance(t *testing.T) {
+	defs := []Definition{
+		// Defined before its parent on purpose
+		{Name: "rag-ir-recent", Extends: "rag-ir", MaxAgeDays: intp(30), AddKeywords: []string{"reranking"}},
+		{Name: "rag-ir", Extends: "rag", Categories: []string{"cs.IR"}, MinScore: intp(60)},
+	}
+
+	got, err := Resolve(defs, testBase)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	ir := got["rag-ir"]
+	if ir.MinScore != 60 || ir.MaxAgeDays != 180 || strings.Join(ir.Parents, ",") != "rag" {
+		t.Errorf("rag-ir = %+v", ir)
+	}
+	if want := `(all:"retrieval augmented generation" OR all:RAG OR all:"knowledge retrieval") AND cat:cs.IR`; ir.Query != want {
+		t.Errorf("rag-ir query = %s, want %s", ir.Query, want)
+	}
+
+	recent := got["rag-ir-recent"]
+	if recent.MinScore != 60 || recent.MaxAgeDays != 30 {
+		t.Errorf("rag-ir-recent min score %d, max age %d; want 60 inherited, 30 overridden", recent.MinScore, recent.MaxAgeDays)
+	}
+	if strings.Join(recent.Parents, ",") != "rag-ir,rag" {
+		t.Errorf("parents = %v, want [rag-ir rag]", recent.Parents)
+	}
+	if len(recent.Categories) != 1 || !strings.Contains(recent.Query, "all:reranking") || !strings.HasSuffix(recent.Query, "AND cat:cs.IR") {
+		t.Errorf("rag-ir-recent categories %v, query %s", recent.Categories, recent.Query)
+	}
+
+	// The parent is untouched by its child's list operations
+	if len(got["rag-ir"].Keywords) != 3 || len(testBase["rag"].Keywords) != 3 {
+		t.Error("child keyword edits leaked into a parent")
+	}
+}
+
+func TestResolve_KeywordOperations(t *testing.T) {
+	tests := []struct {
+		name     string
+		def      Definition
+		expected []string
+	}{
+		{"add", Definition{AddKeywords: []string{"reranking"}}, []string{"retrieval augmented generation", "RAG", "knowledge retrieval", "reranking"}},
+		{"add existing", Definition{AddKeywords: []string{"rag"}}, []string{"retrieval augmented generation", "RAG", "knowledge retrieval"}},
+		{"remove", Definition{RemoveKeywords: []string{"rag", "knowledge retrieval"}}, []string{"retrieval augmented generation"}},
+		{"replace then edit", Definition{Keywords: []string{"dense retrieval", "ColBERT"}, AddKeywords: []string{"late interaction"}, RemoveKeywords: []string{"colbert"}}, []string{"dense retrieval", "late interaction"}},
+	}
+
+	for _, tc := range tests {
+		tc.def.Name, tc.def.Extends = "child", "rag"
+		got, err := Resolve([]Definition{tc.def}, testBase)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if kw := got["child"].Keywords; strings.Join(kw, "|") != strings.Join(tc.expected, "|") {
+			t.Errorf("%s: keywords = %v, want %v", tc.name, kw, tc.expected)
+		}
+	}
+}
+
+func TestResolve_OverridePrecedence(t *testing.T) {
+	defs := []Definition{
+		{Name: "parent", Extends: "rag", Query: "hand written", Description: "Parent"},
+		{Name: "child", Extends: "parent", MinScore: intp(0)},
+		{Name: "edited", Extends: "parent", AddKeywords: []string{"reranking"}, Query: "child query"},
+	}
+
+	got, err := Resolve(defs, testBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Explicit zero overrides; unset fields inherit, including a hand-written query
+	if c := got["child"]; c.MinScore != 0 || c.Query != "hand written" || c.Description != "Parent" {
+		t.Errorf("child = %+v", c)
+	}
+	// A child's own query beats the one rebuilt from edited keywords
+	if q := got["edited"].Query; q != "child query" {
+		t.Errorf("edited query = %q, want the child's", q)
+	}
+}
+
+func TestResolve_BooleanQuery(t *testing.T) {
+	defs := []Definition{
+		{Name: "cot", Extends: "rag", BooleanQuery: `("chain of thought" OR CoT) AND "large language model" ANDNOT ti:survey`},
+		{Name: "cot-recent", Extends: "cot", MaxAgeDays: intp(30)},
+	}
+
+	got, err := Resolve(defs, testBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `((all:"chain of thought" OR all:CoT) AND all:"large language model") ANDNOT ti:survey`
+	if q := got["cot"].Query; q != want {
+		t.Errorf("cot query = %s, want %s", q, want)
+	}
+	if q := got["cot-recent"].Query; q != want {
+		t.Errorf("cot-recent query = %s, want the parent's", q)
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		defs []Definition
+		want string
+	}{
+		{"unknown parent", []Definition{{Name: "a", Extends: "nope"}}, `"a": extends unknown preset "nope"`},
+		{"self cycle", []Definition{{Name: "a", Extends: "a"}}, "extends cycle a -> a"},
+		{"cycle", []Definition{{Name: "a", Extends: "b"}, {Name: "b", Extends: "c"}, {Name: "c", Extends: "a"}}, "extends cycle a -> b -> c -> a"},
+		{"duplicate", []Definition{{Name: "a"}, {Name: "a"}}, "defined twice"},
+		{"duplicate but for case", []Definition{{Name: "rag-ir"}, {Name: "RAG-IR"}}, `"RAG-IR": same name as "rag-ir" but for case`},
+		{"cycle across cases", []Definition{{Name: "a", Extends: "B"}, {Name: "b", Extends: "A"}}, "extends cycle a -> B -> A"},
+		{"unnamed", []Definition{{Extends: "rag"}}, "without a name"},
+		{"bad boolean query", []Definition{{Name: "a", BooleanQuery: "(cot OR"}}, `"a": boolean_query: query syntax error`},
+		{"both queries", []Definition{{Name: "a", Query: "all:cot", BooleanQuery: "cot"}}, "both query and boolean_query set"},
+	}
+
+	for _, tc := range tests {
+		_, err := Resolve(tc.defs, testBase)
+		if !errors.Is(err, ErrInvalidPreset) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestResolve_NamesIgnoreCase(t *testing.T) {
+	defs := []Definition{
+		{Name: "RAG-IR", Extends: "RAG", Categories: []string{"cs.IR"}},
+		{Name: "rag-ir-recent", Extends: "Rag-Ir", MaxAgeDays: intp(30)},
+	}
+	got, err := Resolve(defs, testBase)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	// Results keep the casing they were defined with
+	ir, ok := got["RAG-IR"]
+	if !ok || ir.MinScore != 50 || len(ir.Categories) != 1 {
+		t.Errorf("RAG-IR = %+v, %v", ir, ok)
+	}
+	if recent := got["rag-ir-recent"]; strings.Join(recent.Parents, ",") != "Rag-Ir,RAG" || len(recent.Categories) != 1 {
+		t.Errorf("rag-ir-recent = %+v", recent)
+	}
+}
+
+func TestLoadFile_RecasesBuiltin(t *testing.T) {
+	t.Cleanup(Reset)
+
+	path := filepath.Join(t.TempDir(), "presets.json")
+	os.WriteFile(path, []byte(`[{"name": "RAG", "keywords": ["retrieval augmented generation"], "min_score": 70}]`), 0o644)
+	if err := LoadFile(path); err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+
+	p, ok := Get("rag")
+	if !ok || p.Name != "RAG" || p.MinScore != 70 {
+		t.Errorf("Get(rag) = %+v, %v; want the file's RAG", p, ok)
+	}
+	if got := len(List()); got != len(builtins) {
+		t.Errorf("%d presets, want %d: the override must replace the built-in", got, len(builtins))
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Cleanup(Reset)
+
+	path := filepath.Join(t.TempDir(), "presets.json")
+	os.WriteFile(path, []byte(`[{"name": "rag-ir", "extends": "rag", "categories": ["cs.IR"], "max_age_days": 30}]`), 0o644)
+
+	if err := LoadFile(path); err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	p, ok := Get("rag-ir")
+	if !ok || p.MaxAgeDays != 30 || p.MinScore != 50 {
+		t.Errorf("rag-ir = %+v, %v", p, ok)
+	}
+
+	// A bad file leaves the loaded presets in place
+	os.WriteFile(path, []byte(`[{"name": "x", "extends": "y"}]`), 0o644)
+	if err := LoadFile(path); !errors.Is(err, ErrInvalidPreset) {
+		t.Errorf("LoadFile with unknown parent: %v", err)
+	}
+	if _, ok := Get("rag-ir"); !ok {
+		t.Error("failed load dropped rag-ir")
+	}
+
+	// Reloading drops presets removed from the file
+	os.WriteFile(path, []byte(`[]`), 0o644)
+	if err := LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Get("rag-ir"); ok {
+		t.Error("rag-ir survived a reload without it")
+	}
+	if _, ok := Get("rag"); !ok {
+		t.Error("reload dropped the built-in rag")
+	}
+}
+
+func TestParseCandidate(t *testing.T) {
+	rag := Builtins()["rag"]
+	base := []SearchPreset{rag}
+
+	// Extending its own name changes only the fields given
+	p, err := ParseCandidate([]byte(`{"name": "rag", "extends": "rag", "min_score": 70}`), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "rag" || p.MinScore != 70 || p.Query != rag.Query {
+		t.Errorf("candidate = %+v, want rag with min score 70", p)
+	}
+
+	// Without extends it stands alone
+	p, err = ParseCandidate([]byte(`{"name": "rag", "boolean_query": "retrieval AND generation"}`), base)
+	if err != nil || p.MinScore != 0 || p.Query == rag.Query || p.Query == "" {
+		t.Errorf("standalone candidate = %+v, %v", p, err)
+	}
+
+	for _, bad := range []string{`[]`, `{"name": ""}`, `{"name": "x", "extends": "nope"}`} {
+		if _, err := ParseCandidate([]byte(bad), base); err == nil {
+			t.Errorf("ParseCandidate(%s) succeeded", bad)
+		}
+	}
+}
@@ -8,18 +8,26 @@ type SearchPreset struct {
 	Query       string   // Final combined query
 	MinScore    int      // Recommended minimum score
 	MaxAgeDays  int      // Recommended max age in days
+	Categories  []string // Restrict the search to these arXiv categories (empty = any)
+	Parents     []string // Presets this one extends, nearest first (file presets only)
+
+	// CategoryMinScore overrides MinScore for papers whose primary category
+	// is more (or less) competitive than the preset's field as a whole
+	CategoryMinScore map[string]int
 }
 
-// Presets contains all available search presets.
-var Presets = map[string]SearchPreset{
+// builtins are the presets every registry reset starts from.
+var builtins = map[string]SearchPreset{
 	// LLM & NLP
 	"llm-reasoning": {
 		Name:        "llm-reasoning",
 		Description: "LLM reasoning and chain-of-thought",
 		Keywords:    []string{"large language model", "reasoning", "chain of thought", "CoT"},
-		Query:       "large language model reasoning chain of thought",
+		Query:       `all:"large language model" AND (all:reasoning OR all:"chain of thought" OR all:CoT)`,
 		MinScore:    50,
 		MaxAgeDays:  180,
+		// cs.CL carries most of the volume for this topic
+		CategoryMinScore: map[string]int{"cs.CL": 60},
 	},
 	"llm-agent": {
 		Name:        "llm-agent",
@@ -159,17 +167,11 @@ var Presets = map[string]SearchPreset{
 	},
 }
 
-// List returns all preset names and descriptions.
-func List() []SearchPreset {
-	result := make([]SearchPreset, 0, len(Presets))
-	for _, p := range Presets {
-		result = append(result, p)
+// Builtins returns a copy of the built-in presets.
+func Builtins() map[string]SearchPreset {
+	presets := make(map[string]SearchPreset, len(builtins))
+	for name, p := range builtins {
+		presets[name] = p
 	}
-	return result
-}
-
-// Get returns a preset by name.
-func Get(name string) (SearchPreset, bool) {
-	p, ok := Presets[name]
-	return p, ok
+	return presets
 }
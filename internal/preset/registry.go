@@ -0,0 +1,138 @@
+package preset
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Key returns the form preset names are matched by. Names that differ
+// only in case, such as "RAG" and "rag", name the same preset; the
+// preset keeps the casing it was defined with for display.
+func Key(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Registry is a set of presets that is safe for concurrent use. Presets
+// are values, but their slices and maps are shared; callers must not
+// modify them.
+type Registry struct {
+	mu      sync.RWMutex
+	presets map[string]SearchPreset // By Key of the name
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{presets: make(map[string]SearchPreset)}
+}
+
+// Default is the registry the package-level functions use. It holds the
+// built-in presets plus any loaded with LoadFile.
+var Default = NewRegistry()
+
+func init() {
+	Reset()
+}
+
+// Reset restores the default registry to the built-in presets.
+func Reset() {
+	Default.Replace(Builtins())
+}
+
+// Register adds p, replacing any preset whose name has the same Key, so
+// re-registering with different casing renames rather than duplicates.
+func (r *Registry) Register(p SearchPreset) error {
+	if Key(p.Name) == "" {
+		return fmt.Errorf("%w: preset without a name", ErrInvalidPreset)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.presets[Key(p.Name)] = p
+	return nil
+}
+
+// Get returns a preset by name, ignoring case.
+func (r *Registry) Get(name string) (SearchPreset, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.presets[Key(name)]
+	return p, ok
+}
+
+// List returns every preset, sorted by name.
+func (r *Registry) List() []SearchPreset {
+	return r.filter(func(SearchPreset) bool { return true })
+}
+
+// ListByCategory returns the presets restricted to the given arXiv
+// category, sorted by name.
+func (r *Registry) ListByCategory(category string) []SearchPreset {
+	return r.filter(func(p SearchPreset) bool { return slices.Contains(p.Categories, category) })
+}
+
+// Replace swaps the whole set of presets at once, so readers see either
+// the old set or the new one. The names must differ by Key, as ReadFile
+// ensures.
+func (r *Registry) Replace(presets map[string]SearchPreset) {
+	next := make(map[string]SearchPreset, len(presets))
+	for _, p := range presets {
+		next[Key(p.Name)] = p
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.presets = next
+}
+
+// LoadFile replaces the registry with the built-in presets plus those
+// defined in path. On error the registry is left unchanged.
+func (r *Registry) LoadFile(path string) error {
+	presets, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+	r.Replace(presets)
+	return nil
+}
+
+func (r *Registry) filter(keep func(SearchPreset) bool) []SearchPreset {
+	r.mu.RLock()
+	result := make([]SearchPreset, 0, len(r.presets))
+	for _, p := range r.presets {
+		if keep(p) {
+			result = append(result, p)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Register adds p to the default registry.
+func Register(p SearchPreset) error {
+	return Default.Register(p)
+}
+
+// Get returns a preset from the default registry.
+func Get(name string) (SearchPreset, bool) {
+	return Default.Get(name)
+}
+
+// List returns every preset in the default registry, sorted by name.
+func List() []SearchPreset {
+	return Default.List()
+}
+
+// ListByCategory returns the default registry's presets restricted to
+// category.
+func ListByCategory(category string) []SearchPreset {
+	return Default.ListByCategory(category)
+}
+
+// LoadFile replaces the default registry with the built-in presets plus
+// those defined in path.
+func LoadFile(path string) error {
+	return Default.LoadFile(path)
+}
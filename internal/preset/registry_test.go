@@ -0,0 +1,128 @@
+package preset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestRegistry_RegisterAndList(t *testing.T) {
+	r := NewRegistry()
+	for _, p := range []SearchPreset{
+		{Name: "rag-ir", Categories: []string{"cs.IR"}},
+		{Name: "nlp", Categories: []string{"cs.CL", "cs.IR"}},
+		{Name: "any"},
+	} {
+		if err := r.Register(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Register(SearchPreset{}); err == nil {
+		t.Error("registered a preset without a name")
+	}
+
+	tests := []struct {
+		category string
+		expected []string
+	}{
+		{"", []string{"any", "nlp", "rag-ir"}},
+		{"cs.IR", []string{"nlp", "rag-ir"}},
+		{"cs.CL", []string{"nlp"}},
+		{"cs.CV", nil},
+	}
+	for _, tc := range tests {
+		got := r.List()
+		if tc.category != "" {
+			got = r.ListByCategory(tc.category)
+		}
+		if names := presetNames(got); fmt.Sprint(names) != fmt.Sprint(tc.expected) {
+			t.Errorf("category %q: %v, want %v", tc.category, names, tc.expected)
+		}
+	}
+}
+
+func TestRegistry_NamesIgnoreCase(t *testing.T) {
+	r := NewRegistry()
+	r.Register(SearchPreset{Name: "rag", MinScore: 50})
+
+	for _, name := range []string{"rag", "RAG", " Rag "} {
+		if _, ok := r.Get(name); !ok {
+			t.Errorf("Get(%q) found nothing", name)
+		}
+	}
+
+	// Registering with new casing renames the preset in place
+	r.Register(SearchPreset{Name: "RAG", MinScore: 60})
+	list := r.List()
+	if len(list) != 1 || list[0].Name != "RAG" || list[0].MinScore != 60 {
+		t.Errorf("after recasing: %+v, want one preset named RAG", list)
+	}
+	if err := r.Register(SearchPreset{Name: "  "}); err == nil {
+		t.Error("registered a preset with a blank name")
+	}
+}
+
+func TestReset(t *testing.T) {
+	t.Cleanup(Reset)
+
+	Register(SearchPreset{Name: "extra"})
+	Reset()
+
+	if _, ok := Get("extra"); ok {
+		t.Error("extra survived Reset")
+	}
+	if got := len(List()); got != len(builtins) {
+		t.Errorf("%d presets after Reset, want %d built-ins", got, len(builtins))
+	}
+}
+
+// Run with -race: readers must never observe a half-replaced registry.
+func TestRegistry_ConcurrentReload(t *testing.T) {
+	t.Cleanup(Reset)
+	path := filepath.Join(t.TempDir(), "presets.json")
+	os.WriteFile(path, []byte(`[{"name": "rag-ir", "extends": "rag", "categories": ["cs.IR"]}]`), 0o644)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, ok := Get("rag"); !ok {
+					t.Error("built-in rag missing during reload")
+					return
+				}
+				if n := len(List()); n != len(builtins) && n != len(builtins)+1 {
+					t.Errorf("List returned %d presets mid-reload", n)
+					return
+				}
+				ListByCategory("cs.IR")
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		if err := LoadFile(path); err != nil {
+			t.Error(err)
+		}
+		Reset()
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func presetNames(presets []SearchPreset) []string {
+	var names []string
+	for _, p := range presets {
+		names = append(names, p.Name)
+	}
+	return names
+}
@@ -0,0 +1,123 @@
+// Package retry runs an operation again after transient failures, with
+// jittered exponential backoff. Every outbound call that retries goes
+// through Do, so attempts, waits and deadlines are handled one way.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+)
+
+const (
+	defaultMaxAttempts   = 4
+	defaultBackoff       = 3 * time.Second
+	defaultJitter        = 0.5
+	defaultMaxRetryAfter = 60 * time.Second
+)
+
+// Policy says how often and how long to retry. The zero value tries four
+// times in all, waiting about 3s, 6s and 12s in between.
+type Policy struct {
+	MaxAttempts   int           // Tries in all, the first included (default: 4, negative: one try)
+	Backoff       time.Duration // Wait before the second try, doubled for each next one (default: 3s)
+	MaxBackoff    time.Duration // Longest backoff wait (default: 0, no cap)
+	Jitter        float64       // Fraction of each backoff taken off at random, so clients retry out of step (default: 0.5, negative: none)
+	MaxElapsed    time.Duration // No try starts later than this after the first (default: 0, no limit)
+	MaxRetryAfter time.Duration // Longest wait an After error gets; longer ones are cut to it (default: 60s)
+	Clock         clock.Clock   // Time source for waits and elapsed time (default: system clock)
+
+	// Retryable reports whether a try that failed with err may be
+	// followed by another (default: every error is retried)
+	Retryable func(err error) bool
+}
+
+// afterError carries the wait the failed operation asked for.
+type afterError struct {
+	err error
+	d   time.Duration
+}
+
+func (e *afterError) Error() string { return e.err.Error() }
+func (e *afterError) Unwrap() error { return e.err }
+
+// After wraps err so Do waits d before the next try instead of the
+// backoff, e.g. for a response's Retry-After. errors.Is and errors.As see
+// through it, and Do returns err itself.
+func After(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &afterError{err: err, d: max(d, 0)}
+}
+
+// Do calls fn until it succeeds, the policy runs out of attempts or
+// elapsed time, or fn fails with an error the policy does not retry, and
+// returns fn's last error. Waits end early when ctx is done, and Do then
+// returns ctx's error. A wait that would outlast ctx's deadline is not
+// started: fn's last error is returned at once.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	clk := clock.Or(p.Clock)
+	attempts := p.MaxAttempts
+	if attempts == 0 {
+		attempts = defaultMaxAttempts
+	}
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+	jitter := p.Jitter
+	if jitter == 0 {
+		jitter = defaultJitter
+	}
+	maxRetryAfter := p.MaxRetryAfter
+	if maxRetryAfter <= 0 {
+		maxRetryAfter = defaultMaxRetryAfter
+	}
+
+	start := clk.Now()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		var after *afterError
+		asked := errors.As(err, &after)
+		if asked && after == err {
+			err = after.err
+		}
+		if attempt >= attempts || ctx.Err() != nil || (p.Retryable != nil && !p.Retryable(err)) {
+			return err
+		}
+
+		wait := backoff
+		if p.MaxBackoff > 0 {
+			wait = min(wait, p.MaxBackoff)
+		}
+		if jitter > 0 {
+			wait -= time.Duration(rand.Float64() * min(jitter, 1) * float64(wait))
+		}
+		if asked {
+			wait = min(after.d, maxRetryAfter)
+		}
+		now := clk.Now()
+		if p.MaxElapsed > 0 && now.Add(wait).Sub(start) > p.MaxElapsed {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+			return err
+		}
+
+		timer := clk.NewTimer(wait)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,167 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+)
+
+var errBoom = errors.New("boom")
+
+// sleepClock is a fake clock whose timers fire at once, moving the time
+// on by their duration. It records every wait.
+type sleepClock struct {
+	*clock.Fake
+	waits []time.Duration
+}
+
+func newSleepClock(start time.Time) *sleepClock {
+	return &sleepClock{Fake: clock.NewFake(start)}
+}
+
+func (c *sleepClock) NewTimer(d time.Duration) clock.Timer {
+	c.waits = append(c.waits, d)
+	c.Advance(d)
+	return c.Fake.NewTimer(0)
+}
+
+// failing returns an operation that fails with errs in turn, then
+// succeeds, and counts its calls.
+func failing(calls *int, errs ...error) func(context.Context) error {
+	return func(context.Context) error {
+		*calls++
+		if *calls <= len(errs) {
+			return errs[*calls-1]
+		}
+		return nil
+	}
+}
+
+func TestDo_BackoffSchedule(t *testing.T) {
+	clk := newSleepClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	calls := 0
+	p := Policy{MaxAttempts: 5, Backoff: time.Second, MaxBackoff: 3 * time.Second, Jitter: -1, Clock: clk}
+
+	err := Do(context.Background(), p, failing(&calls, errBoom, errBoom, errBoom, errBoom, errBoom))
+	if err != errBoom || calls != 5 {
+		t.Fatalf("Do = %v after %d calls, want errBoom after 5", err, calls)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	if !reflect.DeepEqual(clk.waits, want) {
+		t.Errorf("waits = %v, want %v", clk.waits, want)
+	}
+}
+
+func TestDo_Jitter(t *testing.T) {
+	clk := newSleepClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	calls := 0
+	p := Policy{MaxAttempts: 4, Backoff: 4 * time.Second, Clock: clk}
+
+	if err := Do(context.Background(), p, failing(&calls, errBoom, errBoom, errBoom)); err != nil {
+		t.Fatalf("Do = %v, want success on the last try", err)
+	}
+	// The default jitter takes up to half of each backoff off
+	for i, d := range clk.waits {
+		full := 4 * time.Second << i
+		if d < full/2 || d > full {
+			t.Errorf("wait %d = %v, want within [%v, %v]", i, d, full/2, full)
+		}
+	}
+	if len(clk.waits) != 3 {
+		t.Errorf("%d waits, want 3", len(clk.waits))
+	}
+}
+
+func TestDo_Retryable(t *testing.T) {
+	errPermanent := errors.New("permanent")
+	clk := newSleepClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	calls := 0
+	p := Policy{Clock: clk, Retryable: func(err error) bool { return !errors.Is(err, errPermanent) }}
+
+	err := Do(context.Background(), p, failing(&calls, errBoom, errPermanent, errBoom))
+	if err != errPermanent || calls != 2 {
+		t.Errorf("Do = %v after %d calls, want errPermanent after 2", err, calls)
+	}
+}
+
+func TestDo_After(t *testing.T) {
+	clk := newSleepClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	calls := 0
+	p := Policy{Backoff: time.Hour, MaxRetryAfter: time.Minute, Clock: clk}
+
+	// The wait asked for replaces the backoff, up to MaxRetryAfter
+	err := Do(context.Background(), p, failing(&calls, After(errBoom, 10*time.Second), After(errBoom, time.Hour)))
+	if err != nil || calls != 3 {
+		t.Fatalf("Do = %v after %d calls, want success after 3", err, calls)
+	}
+	if want := []time.Duration{10 * time.Second, time.Minute}; !reflect.DeepEqual(clk.waits, want) {
+		t.Errorf("waits = %v, want %v", clk.waits, want)
+	}
+
+	// The error handed back is the one After wrapped
+	calls = 0
+	err = Do(context.Background(), Policy{MaxAttempts: 1, Clock: clk}, failing(&calls, After(errBoom, time.Second)))
+	if err != errBoom {
+		t.Errorf("Do = %#v, want errBoom itself", err)
+	}
+	if After(nil, time.Second) != nil {
+		t.Error("After(nil) != nil")
+	}
+}
+
+func TestDo_Deadline(t *testing.T) {
+	// The deadline is on the system clock, so the fake one starts there
+	clk := newSleepClock(time.Now())
+	ctx, cancel := context.WithDeadline(context.Background(), clk.Now().Add(5*time.Second))
+	defer cancel()
+	calls := 0
+	p := Policy{Backoff: 2 * time.Second, Jitter: -1, Clock: clk}
+
+	// 2s fits before the deadline; the 4s after it would not, so Do
+	// returns rather than sleep past it
+	err := Do(ctx, p, failing(&calls, errBoom, errBoom, errBoom))
+	if err != errBoom || calls != 2 {
+		t.Errorf("Do = %v after %d calls, want errBoom after 2", err, calls)
+	}
+	if want := []time.Duration{2 * time.Second}; !reflect.DeepEqual(clk.waits, want) {
+		t.Errorf("waits = %v, want %v", clk.waits, want)
+	}
+}
+
+func TestDo_MaxElapsed(t *testing.T) {
+	clk := newSleepClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	calls := 0
+	p := Policy{MaxAttempts: 10, Backoff: 4 * time.Second, Jitter: -1, MaxElapsed: 10 * time.Second, Clock: clk}
+
+	err := Do(context.Background(), p, failing(&calls, errBoom, errBoom, errBoom))
+	if err != errBoom || calls != 2 {
+		t.Errorf("Do = %v after %d calls, want errBoom after 2", err, calls)
+	}
+}
+
+func TestDo_CancelledWhileWaiting(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		clk.BlockUntil(1)
+		cancel()
+	}()
+
+	calls := 0
+	err := Do(ctx, Policy{Clock: clk}, failing(&calls, errBoom, errBoom))
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("Do = %v after %d calls, want context.Canceled after 1", err, calls)
+	}
+}
+
+func TestDo_NegativeMaxAttempts(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), Policy{MaxAttempts: -1}, failing(&calls, errBoom))
+	if err != errBoom || calls != 1 {
+		t.Errorf("Do = %v after %d calls, want errBoom after 1", err, calls)
+	}
+}
@@ -0,0 +1,217 @@
+// Package settings holds the defaults operators can change while the API
+// server runs (GET/PUT /api/settings). A value comes from the first layer
+// that sets it:
+//
+//  1. a parameter of the request itself, applied by the caller
+//  2. the settings table, changed over the API
+//  3. the configuration (environment), and its defaults
+//
+// Changes apply to the runs that start after them; nothing restarts.
+package settings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/config"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// Keys of the runtime settings. All take integers.
+const (
+	KeyMinScore         = "sync.min_score"      // Filter threshold of API syncs, 0-100
+	KeyMaxAgeDays       = "sync.max_age_days"   // Drop papers last updated longer ago (0 = no limit)
+	KeyNotifyBatchSize  = "notify.batch_size"   // Papers per notification message, at least 1
+	KeyNotifyMaxPerSync = "notify.max_per_sync" // Papers announced per sync, at least 1
+)
+
+// ErrInvalid is wrapped by the errors of Update for unknown keys and
+// values out of range.
+var ErrInvalid = errors.New("invalid setting")
+
+// Values are the effective settings.
+type Values struct {
+	MinScore         int `json:"sync.min_score"`
+	MaxAgeDays       int `json:"sync.max_age_days"`
+	NotifyBatchSize  int `json:"notify.batch_size"`
+	NotifyMaxPerSync int `json:"notify.max_per_sync"`
+}
+
+// MaxAge returns MaxAgeDays as a duration.
+func (v Values) MaxAge() time.Duration {
+	return time.Duration(v.MaxAgeDays) * 24 * time.Hour
+}
+
+// FromConfig returns the configured values, the layer under the table.
+func FromConfig(cfg *config.Config) Values {
+	return Values{
+		MinScore:         cfg.Pipeline.DefaultMinScore,
+		MaxAgeDays:       cfg.Pipeline.DefaultMaxAge,
+		NotifyBatchSize:  cfg.Notify.BatchSize,
+		NotifyMaxPerSync: cfg.Notify.MaxPerSync,
+	}
+}
+
+// field describes one key: where it lives in Values and its bounds.
+type field struct {
+	min, max int // max < min: no upper bound
+	ptr      func(*Values) *int
+}
+
+var fields = map[string]field{
+	KeyMinScore:         {0, 100, func(v *Values) *int { return &v.MinScore }},
+	KeyMaxAgeDays:       {0, -1, func(v *Values) *int { return &v.MaxAgeDays }},
+	KeyNotifyBatchSize:  {1, 0, func(v *Values) *int { return &v.NotifyBatchSize }},
+	KeyNotifyMaxPerSync: {1, 0, func(v *Values) *int { return &v.NotifyMaxPerSync }},
+}
+
+// Keys returns the setting keys in lexical order.
+func Keys() []string {
+	return slices.Sorted(maps.Keys(fields))
+}
+
+// parse validates raw as the value of key.
+func parse(key string, raw json.RawMessage) (int, error) {
+	f, ok := fields[key]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown key %q", ErrInvalid, key)
+	}
+	var n int
+	if err := json.Unmarshal(raw, &n); err != nil || string(bytes.TrimSpace(raw)) == "null" {
+		return 0, fmt.Errorf("%w: %s must be an integer, got %s", ErrInvalid, key, raw)
+	}
+	if n < f.min || (f.max >= f.min && n > f.max) {
+		if f.max >= f.min {
+			return 0, fmt.Errorf("%w: %s must be %d-%d, got %d", ErrInvalid, key, f.min, f.max, n)
+		}
+		return 0, fmt.Errorf("%w: %s must be at least %d, got %d", ErrInvalid, key, f.min, n)
+	}
+	return n, nil
+}
+
+// Service serves the effective settings from memory and writes changes
+// through to the store. It is safe for concurrent use.
+type Service struct {
+	store    storage.SettingsStore
+	defaults Values
+
+	Clock clock.Clock // Time source for updated_at (default: system clock)
+
+	update    sync.Mutex // Serialises Update, so the store and memory agree on the last write
+	mu        sync.RWMutex
+	current   Values
+	overrides map[string]storage.Setting
+}
+
+// New returns a service over store, which may be nil to serve defaults
+// only. Call Load to read the stored settings.
+func New(store storage.SettingsStore, defaults Values) *Service {
+	return &Service{store: store, defaults: defaults, current: defaults, overrides: map[string]storage.Setting{}}
+}
+
+// Load reads the stored settings over the defaults. Rows that no longer
+// validate, e.g. keys a later version dropped, are logged and skipped.
+func (s *Service) Load(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	stored, err := s.store.ListSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("load settings: %w", err)
+	}
+
+	current, overrides := s.defaults, map[string]storage.Setting{}
+	for _, st := range stored {
+		n, err := parse(st.Key, st.Value)
+		if err != nil {
+			log.Printf("Ignoring stored setting: %v", err)
+			continue
+		}
+		*fields[st.Key].ptr(&current) = n
+		overrides[st.Key] = st
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current, s.overrides = current, overrides
+	return nil
+}
+
+// Values returns the effective settings.
+func (s *Service) Values() Values {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Overrides returns the stored settings in effect, by key.
+func (s *Service) Overrides() []storage.Setting {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]storage.Setting, 0, len(s.overrides))
+	for _, k := range Keys() {
+		if st, ok := s.overrides[k]; ok {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+// NotifyLimits returns the notification batch size and per-sync cap, for
+// notify.Config.Limits.
+func (s *Service) NotifyLimits() (batchSize, maxPerSync int) {
+	v := s.Values()
+	return v.NotifyBatchSize, v.NotifyMaxPerSync
+}
+
+// Update validates and stores changes made by actor, and returns the
+// settings now in effect. Nothing changes unless every value is valid;
+// the error then lists each bad one.
+func (s *Service) Update(ctx context.Context, changes map[string]json.RawMessage, actor string) (Values, error) {
+	if s.store == nil {
+		return Values{}, errors.New("settings are read-only without a settings store")
+	}
+	if len(changes) == 0 {
+		return Values{}, fmt.Errorf("%w: no settings given", ErrInvalid)
+	}
+
+	now := clock.Or(s.Clock).Now()
+	var errs []error
+	var updates []storage.Setting
+	values := make(map[string]int, len(changes))
+	for _, k := range slices.Sorted(maps.Keys(changes)) {
+		n, err := parse(k, changes[k])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		values[k] = n
+		raw, _ := json.Marshal(n)
+		updates = append(updates, storage.Setting{Key: k, Value: raw, UpdatedAt: now, UpdatedBy: actor})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Values{}, err
+	}
+
+	s.update.Lock()
+	defer s.update.Unlock()
+	if err := s.store.PutSettings(ctx, updates); err != nil {
+		return Values{}, fmt.Errorf("store settings: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range updates {
+		*fields[u.Key].ptr(&s.current) = values[u.Key]
+		s.overrides[u.Key] = u
+	}
+	return s.current, nil
+}
@@ -0,0 +1,108 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+)
+
+var defaults = Values{MinScore: 60, MaxAgeDays: 365, NotifyBatchSize: 10, NotifyMaxPerSync: 50}
+
+func raw(s string) json.RawMessage { return json.RawMessage(s) }
+
+func TestService_Load(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	s := New(store, defaults)
+	if err := s.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s.Values() != defaults || len(s.Overrides()) != 0 {
+		t.Fatalf("empty table: values %+v, overrides %v", s.Values(), s.Overrides())
+	}
+
+	// Rows that no longer validate are skipped; the rest apply
+	store.PutSettings(ctx, []storage.Setting{
+		{Key: KeyMinScore, Value: raw("75")},
+		{Key: KeyNotifyBatchSize, Value: raw("0")},
+		{Key: "digest.hour", Value: raw("8")},
+	})
+	if err := s.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := defaults
+	want.MinScore = 75
+	if s.Values() != want {
+		t.Errorf("values = %+v, want %+v", s.Values(), want)
+	}
+	if o := s.Overrides(); len(o) != 1 || o[0].Key != KeyMinScore {
+		t.Errorf("overrides = %+v, want sync.min_score only", o)
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	s := New(store, defaults)
+	s.Clock = clock.NewFake(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+
+	invalid := []map[string]json.RawMessage{
+		{},
+		{"digest.hour": raw("8")},
+		{KeyMinScore: raw("101")},
+		{KeyMinScore: raw("-1")},
+		{KeyMaxAgeDays: raw("-1")},
+		{KeyNotifyBatchSize: raw("0")},
+		{KeyMinScore: raw("70.5")},
+		{KeyMinScore: raw(`"70"`)},
+		{KeyMinScore: raw("null")},
+		{KeyMinScore: raw("70"), KeyNotifyMaxPerSync: raw("0")}, // One bad value rejects the rest
+	}
+	for _, changes := range invalid {
+		if _, err := s.Update(ctx, changes, "api:test"); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Update(%s) = %v, want ErrInvalid", changes, err)
+		}
+	}
+	if stored, _ := store.ListSettings(ctx); len(stored) != 0 || s.Values() != defaults {
+		t.Fatalf("rejected updates changed settings: stored %v, values %+v", stored, s.Values())
+	}
+
+	values, err := s.Update(ctx, map[string]json.RawMessage{KeyMinScore: raw("70"), KeyMaxAgeDays: raw("0")}, "api:test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Values{MinScore: 70, MaxAgeDays: 0, NotifyBatchSize: 10, NotifyMaxPerSync: 50}
+	if values != want || s.Values() != want {
+		t.Errorf("values = %+v, want %+v", values, want)
+	}
+	if b, m := s.NotifyLimits(); b != 10 || m != 50 {
+		t.Errorf("NotifyLimits = %d, %d, want the defaults", b, m)
+	}
+
+	// The store has them, so a restart keeps them
+	stored, _ := store.ListSettings(ctx)
+	if len(stored) != 2 || stored[0].Key != KeyMaxAgeDays || string(stored[1].Value) != "70" ||
+		stored[1].UpdatedBy != "api:test" || !stored[1].UpdatedAt.Equal(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("stored = %+v", stored)
+	}
+	restarted := New(store, defaults)
+	if err := restarted.Load(ctx); err != nil || restarted.Values() != want {
+		t.Errorf("after a restart: values %+v, err %v", restarted.Values(), err)
+	}
+}
+
+func TestService_NoStore(t *testing.T) {
+	s := New(nil, defaults)
+	if err := s.Load(context.Background()); err != nil || s.Values() != defaults {
+		t.Errorf("Load without a store: values %+v, err %v", s.Values(), err)
+	}
+	if _, err := s.Update(context.Background(), map[string]json.RawMessage{KeyMinScore: raw("70")}, "api:test"); err == nil {
+		t.Error("Update without a store succeeded")
+	}
+}
@@ -0,0 +1,124 @@
+// Package similarity fingerprints abstracts so near-identical copies of a
+// paper (cut short, or with LaTeX rendered differently by another source)
+// can be found without comparing full texts.
+package similarity
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ShingleSize is the number of tokens in each shingle. Tokens are words,
+// except in scripts written without spaces (Han, kana, Thai),
+// where each character is a token.
+const ShingleSize = 3
+
+// DefaultMaxDistance is the largest fingerprint distance, in bits, at
+// which two abstracts are treated as the same paper. Unrelated abstracts
+// differ in about 32 bits, and rarely in under 20 even when they share a
+// sentence; a copy cut short by a tenth, or with a few words changed,
+// typically differs in 4 to 8.
+const DefaultMaxDistance = 10
+
+// latexCommand matches commands such as \mathcal or \emph, whose names one
+// source keeps and another renders away.
+var latexCommand = regexp.MustCompile(`\\[a-zA-Z]+`)
+
+// Normalize reduces text to lowercase letters and digits separated by
+// single spaces, after Unicode compatibility folding and dropping LaTeX
+// commands and markup.
+func Normalize(text string) string {
+	text = latexCommand.ReplaceAllString(norm.NFKC.String(text), " ")
+
+	var b strings.Builder
+	b.Grow(len(text))
+	space := true // No leading space
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), " ")
+}
+
+// Fingerprint returns the 64-bit SimHash of the normalized text's
+// shingles. Similar texts get fingerprints that differ in few bits; text
+// too short to shingle gets 0.
+func Fingerprint(text string) uint64 {
+	tokens := tokenize(Normalize(text))
+	if len(tokens) < ShingleSize {
+		return 0
+	}
+
+	var weights [64]int
+	h := fnv.New64a()
+	for i := 0; i+ShingleSize <= len(tokens); i++ {
+		h.Reset()
+		for _, t := range tokens[i : i+ShingleSize] {
+			h.Write([]byte(t))
+			h.Write([]byte{0})
+		}
+		sum := h.Sum64()
+		for bit := range weights {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fp uint64
+	for bit, w := range weights {
+		if w > 0 {
+			fp |= 1 << bit
+		}
+	}
+	return fp
+}
+
+// tokenize splits normalized text into words, and words of unspaced
+// scripts into characters.
+func tokenize(text string) []string {
+	var tokens []string
+	for _, word := range strings.Fields(text) {
+		start := 0
+		for i, r := range word {
+			if unspaced(r) {
+				if start < i {
+					tokens = append(tokens, word[start:i])
+				}
+				tokens = append(tokens, string(r))
+				start = i + len(string(r))
+			}
+		}
+		if start < len(word) {
+			tokens = append(tokens, word[start:])
+		}
+	}
+	return tokens
+}
+
+func unspaced(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai)
+}
+
+// Distance returns the number of bits in which two fingerprints differ.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Near reports whether two fingerprints are within maxDistance bits of
+// each other. Zero fingerprints (no text) are near nothing.
+func Near(a, b uint64, maxDistance int) bool {
+	return a != 0 && b != 0 && Distance(a, b) <= maxDistance
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditEntry is one mutating operation in the local activity log.
+type AuditEntry struct {
+	ID     int64     `json:"id"`
+	Actor  string    `json:"actor"`            // "cli", "signal", "system" or "api:<remote address>"
+	Action string    `json:"action"`           // e.g. "sync", "presets.reload"
+	Target string    `json:"target,omitempty"` // What the action applied to, e.g. the sync query
+	Params string    `json:"params,omitempty"` // Summary of the request parameters
+	Status int       `json:"status,omitempty"` // HTTP status of API actions
+	At     time.Time `json:"at"`
+}
+
+// AuditRepository handles audit log persistence.
+type AuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAuditRepository creates a new audit log repository.
+func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{pool: pool}
+}
+
+// RecordAudit stores one entry.
+func (r *AuditRepository) RecordAudit(ctx context.Context, e AuditEntry) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO audit_log (actor, action, target, params, status, at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, e.Actor, e.Action, e.Target, e.Params, e.Status, e.At)
+	if err != nil {
+		return fmt.Errorf("record audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAudit returns the latest entries, newest first, optionally only
+// those with the given action.
+func (r *AuditRepository) ListAudit(ctx context.Context, action string, limit int) ([]AuditEntry, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, actor, action, target, params, status, at
+		FROM audit_log
+		WHERE $1 = '' OR action = $1
+		ORDER BY at DESC, id DESC
+		LIMIT $2
+	`, action, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Target, &e.Params, &e.Status, &e.At); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// PruneAudit deletes entries recorded before cutoff and returns how many
+// were removed.
+func (r *AuditRepository) PruneAudit(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM audit_log WHERE at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("prune audit log: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
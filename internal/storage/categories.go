@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// CategoryCount is a category and the number of stored papers in it.
+type CategoryCount struct {
+	Category string `json:"category"`
+	Count    int64  `json:"count"`
+}
+
+// DistinctCategories returns the categories of the stored papers, most
+// papers first and ties by name. With primary set only each paper's
+// primary (first) category counts; otherwise a paper counts once in
+// each of its categories.
+func (r *PaperRepository) DistinctCategories(ctx context.Context, primary bool) ([]CategoryCount, error) {
+	query := `
+		SELECT category, COUNT(*) FROM papers, unnest(categories) AS category
+		GROUP BY category
+		ORDER BY COUNT(*) DESC, category
+	`
+	if primary {
+		query = `
+			SELECT categories[1], COUNT(*) FROM papers
+			WHERE cardinality(categories) > 0
+			GROUP BY categories[1]
+			ORDER BY COUNT(*) DESC, categories[1]
+		`
+	}
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list categories: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []CategoryCount{}
+	for rows.Next() {
+		var c CategoryCount
+		if err := rows.Scan(&c.Category, &c.Count); err != nil {
+			return nil, fmt.Errorf("scan category: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// DefaultChunkSize is the number of papers written per round trip by SaveBatch.
+const DefaultChunkSize = 500
+
+// ErrPartialSave is matched (via errors.Is) by a *PartialSaveError.
+var ErrPartialSave = errors.New("partial save")
+
+// PartialSaveError reports how far a batch save got before it stopped.
+type PartialSaveError struct {
+	Committed int   // Papers durably saved before the failure
+	Total     int   // Papers in the batch
+	Err       error // Cause (often context.DeadlineExceeded)
+}
+
+func (e *PartialSaveError) Error() string {
+	return fmt.Sprintf("partial save: %d/%d papers committed: %v", e.Committed, e.Total, e.Err)
+}
+
+// Unwrap exposes both ErrPartialSave and the underlying cause.
+func (e *PartialSaveError) Unwrap() []error {
+	return []error{ErrPartialSave, e.Err}
+}
+
+// SaveChunked splits papers into chunks of size and saves them one by one,
+// checking ctx between chunks. If it stops early, the returned error is a
+// *PartialSaveError carrying the number of papers already committed.
+func SaveChunked(ctx context.Context, papers []model.Paper, size int, save func(context.Context, []model.Paper) error) error {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+
+	committed := 0
+	for committed < len(papers) {
+		if err := ctx.Err(); err != nil {
+			return &PartialSaveError{Committed: committed, Total: len(papers), Err: err}
+		}
+
+		end := min(committed+size, len(papers))
+		if err := save(ctx, papers[committed:end]); err != nil {
+			return &PartialSaveError{Committed: committed, Total: len(papers), Err: err}
+		}
+		committed = end
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// ErrInvalidCursor is returned by DecodeCursor for malformed cursors.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// newestFirst is the total order for paper listings. The id tiebreaker keeps
+// papers sharing a timestamp (arXiv updates in batches) in a stable order
+// across pages.
+const newestFirst = "ORDER BY updated_at DESC, id DESC"
+
+// Cursor marks a position in the newest-first paper order for keyset
+// pagination. A page continues with the papers ordered after it.
+type Cursor struct {
+	UpdatedAt time.Time
+	ID        string
+}
+
+// CursorAfter returns the cursor that continues a listing after p.
+func CursorAfter(p model.Paper) Cursor {
+	return Cursor{UpdatedAt: p.UpdatedAt, ID: p.ID}
+}
+
+// Encode returns the cursor as an opaque URL-safe token.
+func (c Cursor) Encode() string {
+	raw := strconv.FormatInt(c.UpdatedAt.UnixNano(), 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode.
+func DecodeCursor(token string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{UpdatedAt: time.Unix(0, n).UTC(), ID: id}, nil
+}
+
+// Precedes reports whether the cursor position comes before p, i.e. whether
+// p belongs to the page the cursor continues.
+func (c Cursor) Precedes(p model.Paper) bool {
+	if !p.UpdatedAt.Equal(c.UpdatedAt) {
+		return p.UpdatedAt.Before(c.UpdatedAt)
+	}
+	return p.ID < c.ID
+}
+
+// Before reports whether the cursor position comes before p in export
+// order: oldest first, ties broken by ID ascending. The zero cursor comes
+// before every paper.
+func (c Cursor) Before(p model.Paper) bool {
+	if c.IsZero() {
+		return true
+	}
+	if !p.UpdatedAt.Equal(c.UpdatedAt) {
+		return p.UpdatedAt.After(c.UpdatedAt)
+	}
+	return p.ID > c.ID
+}
+
+// IsZero reports whether c is the zero cursor.
+func (c Cursor) IsZero() bool {
+	return c.UpdatedAt.IsZero() && c.ID == ""
+}
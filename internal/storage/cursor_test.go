@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	c := Cursor{UpdatedAt: time.Date(2024, 1, 1, 20, 0, 0, 123000, time.UTC), ID: "cs/0001001v2"}
+
+	decoded, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !decoded.UpdatedAt.Equal(c.UpdatedAt) || decoded.ID != c.ID {
+		t.Errorf("DecodeCursor(Encode(%+v)) = %+v", c, decoded)
+	}
+
+	for _, token := range []string{"", "not base64!", "MTIz", "YWJjOmlk"} {
+		if _, err := DecodeCursor(token); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", token, err)
+		}
+	}
+}
+
+func TestCursor_Precedes(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := Cursor{UpdatedAt: at, ID: "2401.00005v1"}
+
+	tests := []struct {
+		paper    model.Paper
+		expected bool
+	}{
+		{model.Paper{ID: "2401.00009v1", UpdatedAt: at.Add(-time.Second)}, true},
+		{model.Paper{ID: "2401.00001v1", UpdatedAt: at.Add(time.Second)}, false},
+		{model.Paper{ID: "2401.00004v1", UpdatedAt: at}, true},
+		{model.Paper{ID: "2401.00005v1", UpdatedAt: at}, false},
+		{model.Paper{ID: "2401.00006v1", UpdatedAt: at}, false},
+	}
+
+	for _, tc := range tests {
+		if result := c.Precedes(tc.paper); result != tc.expected {
+			t.Errorf("Precedes(%s @ %s) = %v, want %v", tc.paper.ID, tc.paper.UpdatedAt, result, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Bucket widths for ingestion statistics. Buckets start at midnight UTC;
+// weeks start on Monday.
+const (
+	BucketDay  = "day"
+	BucketWeek = "week"
+)
+
+// IngestionBucket sums what was stored in one day or week.
+type IngestionBucket struct {
+	Start         time.Time `json:"start"`
+	Papers        int64     `json:"papers"`         // Papers first saved
+	AbstractBytes int64     `json:"abstract_bytes"` // Bytes of their abstracts
+	Syncs         int64     `json:"syncs"`          // Syncs started, when the sync log is known
+}
+
+// IngestionReporter is implemented by backends that record when each
+// paper was first saved.
+type IngestionReporter interface {
+	// IngestionStats returns a bucket for every day or week from the one
+	// holding from to the one holding to, oldest first, empty ones included.
+	IngestionStats(ctx context.Context, from, to time.Time, bucket string) ([]IngestionBucket, error)
+}
+
+// SyncCounter is implemented by sync logs that can count their runs by
+// day or week.
+type SyncCounter interface {
+	// CountSyncs returns the number of syncs started from the bucket
+	// holding from up to to, by bucket start; buckets without syncs are
+	// left out.
+	CountSyncs(ctx context.Context, from, to time.Time, bucket string) (map[time.Time]int64, error)
+}
+
+// BucketStart returns the start of the day or week (Monday) holding t, in UTC.
+func BucketStart(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if bucket == BucketWeek {
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return day
+}
+
+func nextBucket(start time.Time, bucket string) time.Time {
+	if bucket == BucketWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// ValidBucket reports whether bucket is BucketDay or BucketWeek.
+func ValidBucket(bucket string) bool {
+	return bucket == BucketDay || bucket == BucketWeek
+}
+
+// FillBuckets returns the buckets from the one holding from to the one
+// holding to, taking the sums found for each start, for IngestionStats.
+func FillBuckets(from, to time.Time, bucket string, found map[time.Time]IngestionBucket) []IngestionBucket {
+	var buckets []IngestionBucket
+	for start, end := BucketStart(from, bucket), BucketStart(to, bucket); !start.After(end); start = nextBucket(start, bucket) {
+		b := found[start]
+		b.Start = start
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+// AddSyncCounts sets the Syncs of each bucket from counts by bucket start.
+func AddSyncCounts(buckets []IngestionBucket, counts map[time.Time]int64) {
+	for i := range buckets {
+		buckets[i].Syncs = counts[buckets[i].Start]
+	}
+}
+
+// IngestionStats sums the papers first saved from the bucket holding from
+// up to to, and the bytes of their abstracts, by UTC day or week.
+func (r *PaperRepository) IngestionStats(ctx context.Context, from, to time.Time, bucket string) ([]IngestionBucket, error) {
+	if !ValidBucket(bucket) {
+		return nil, fmt.Errorf("unknown bucket %q", bucket)
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT date_trunc($3, created_at AT TIME ZONE 'UTC'), COUNT(*), COALESCE(SUM(octet_length(abstract)), 0)
+		FROM papers
+		WHERE created_at >= $1 AND created_at <= $2
+		GROUP BY 1
+	`, BucketStart(from, bucket), to, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("ingestion stats: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[time.Time]IngestionBucket)
+	for rows.Next() {
+		var b IngestionBucket
+		if err := rows.Scan(&b.Start, &b.Papers, &b.AbstractBytes); err != nil {
+			return nil, fmt.Errorf("scan ingestion stats: %w", err)
+		}
+		found[b.Start.UTC()] = b
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ingestion stats: %w", err)
+	}
+	return FillBuckets(from, to, bucket, found), nil
+}
+
+// CountSyncs counts the syncs started from the bucket holding from up to
+// to by UTC day or week.
+func (r *SyncRepository) CountSyncs(ctx context.Context, from, to time.Time, bucket string) (map[time.Time]int64, error) {
+	if !ValidBucket(bucket) {
+		return nil, fmt.Errorf("unknown bucket %q", bucket)
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT date_trunc($3, started_at AT TIME ZONE 'UTC'), COUNT(*)
+		FROM sync_log
+		WHERE started_at >= $1 AND started_at <= $2
+		GROUP BY 1
+	`, BucketStart(from, bucket), to, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("count syncs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[time.Time]int64)
+	for rows.Next() {
+		var (
+			start time.Time
+			n     int64
+		)
+		if err := rows.Scan(&start, &n); err != nil {
+			return nil, fmt.Errorf("scan sync count: %w", err)
+		}
+		counts[start.UTC()] = n
+	}
+	return counts, rows.Err()
+}
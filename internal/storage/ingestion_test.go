@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketStart(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*3600)
+	tests := []struct {
+		at     time.Time
+		bucket string
+		want   time.Time
+	}{
+		{time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC), BucketDay, time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC), BucketDay, time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC)},
+		// Already March 14 in Tokyo, still March 13 in UTC
+		{time.Date(2024, 3, 14, 8, 59, 59, 0, tokyo), BucketDay, time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC), BucketWeek, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), BucketWeek, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 10, 23, 59, 59, 0, time.UTC), BucketWeek, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		// Weeks run across month and year ends
+		{time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC), BucketWeek, time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		if got := BucketStart(tc.at, tc.bucket); !got.Equal(tc.want) || got.Location() != time.UTC {
+			t.Errorf("BucketStart(%v, %s) = %v, want %v", tc.at, tc.bucket, got, tc.want)
+		}
+	}
+}
+
+func TestFillBuckets(t *testing.T) {
+	from := time.Date(2024, 2, 28, 15, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC)
+	leap := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	buckets := FillBuckets(from, to, BucketDay, map[time.Time]IngestionBucket{leap: {Papers: 4, AbstractBytes: 900}})
+	if len(buckets) != 3 || buckets[1].Start != leap || buckets[1].Papers != 4 || buckets[0].Papers != 0 || buckets[2].Papers != 0 {
+		t.Fatalf("buckets = %+v, want Feb 28, 29 with 4 papers, and Mar 1", buckets)
+	}
+
+	AddSyncCounts(buckets, map[time.Time]int64{leap: 2})
+	if buckets[0].Syncs != 0 || buckets[1].Syncs != 2 {
+		t.Errorf("sync counts = %d, %d, want 0, 2", buckets[0].Syncs, buckets[1].Syncs)
+	}
+}
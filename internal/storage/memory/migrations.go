@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// Migrations is an in-process storage.MigrationTracker. It records the
+// migrations applied without running their SQL.
+type Migrations struct {
+	// Fail, when set, is returned by ApplyMigration for the migration
+	// with that version instead of recording it
+	Fail  map[int]error
+	Clock clock.Clock // Records application times (default: system clock)
+
+	applied map[int]storage.AppliedMigration
+	mu      sync.Mutex
+}
+
+// AppliedMigrations returns the recorded migrations, oldest first.
+func (t *Migrations) AppliedMigrations(ctx context.Context) ([]storage.AppliedMigration, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	applied := make([]storage.AppliedMigration, 0, len(t.applied))
+	for _, a := range t.applied {
+		applied = append(applied, a)
+	}
+	sort.Slice(applied, func(i, j int) bool { return applied[i].Version < applied[j].Version })
+	return applied, nil
+}
+
+// ApplyMigration records m as applied.
+func (t *Migrations) ApplyMigration(ctx context.Context, m storage.Migration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.Fail[m.Version]; err != nil {
+		return err
+	}
+	if t.applied == nil {
+		t.applied = make(map[int]storage.AppliedMigration)
+	}
+	if _, ok := t.applied[m.Version]; !ok {
+		t.applied[m.Version] = storage.AppliedMigration{
+			Version: m.Version, Name: m.Name, Checksum: m.Checksum(), AppliedAt: clock.Or(t.Clock).Now(),
+		}
+	}
+	return nil
+}
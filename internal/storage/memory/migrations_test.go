@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+var testMigrations = []storage.Migration{
+	{Version: 1, Name: "baseline", SQL: "CREATE TABLE papers (id TEXT)"},
+	{Version: 2, Name: "add_score", SQL: "ALTER TABLE papers ADD COLUMN score INT"},
+}
+
+func TestMigrations_PendingAndApply(t *testing.T) {
+	ctx := context.Background()
+	tracker := &Migrations{}
+
+	status, err := storage.CheckMigrations(ctx, tracker, testMigrations[:1])
+	if err != nil || len(status.Pending) != 1 || status.UpToDate() {
+		t.Fatalf("fresh database: status %+v, %v, want the baseline pending", status, err)
+	}
+	if _, err := storage.ApplyMigrations(ctx, tracker, testMigrations[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	// A newer build brings a second migration
+	status, err = storage.CheckMigrations(ctx, tracker, testMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Pending) != 1 || status.Pending[0].Version != 2 || len(status.Drifted) != 0 {
+		t.Errorf("status = %+v, want migration 2 pending", status)
+	}
+	if !strings.Contains(status.Report(), "pending: 2 add_score") {
+		t.Errorf("report = %q", status.Report())
+	}
+
+	var sql strings.Builder
+	if err := storage.WritePendingSQL(&sql, status.Pending); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sql.String(), "-- Migration 2: add_score") || !strings.Contains(sql.String(), testMigrations[1].SQL) {
+		t.Errorf("dry run SQL = %q", sql.String())
+	}
+
+	done, err := storage.ApplyMigrations(ctx, tracker, testMigrations)
+	if err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("apply = %v, %v, want migration 2 only", done, err)
+	}
+	if status, _ := storage.CheckMigrations(ctx, tracker, testMigrations); !status.UpToDate() || status.Report() != "" {
+		t.Errorf("after apply: %+v", status)
+	}
+	if done, _ := storage.ApplyMigrations(ctx, tracker, testMigrations); len(done) != 0 {
+		t.Errorf("second apply ran %v", done)
+	}
+}
+
+func TestMigrations_Drift(t *testing.T) {
+	ctx := context.Background()
+	tracker := &Migrations{}
+	if _, err := storage.ApplyMigrations(ctx, tracker, testMigrations); err != nil {
+		t.Fatal(err)
+	}
+
+	modified := append([]storage.Migration(nil), testMigrations...)
+	modified[0].SQL += " -- edited after release"
+	status, err := storage.CheckMigrations(ctx, tracker, modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []storage.Drift{{
+		Version: 1, Name: "baseline", Applied: testMigrations[0].Checksum(), Expected: modified[0].Checksum(),
+	}}
+	if !reflect.DeepEqual(status.Drifted, want) || len(status.Pending) != 0 || status.UpToDate() {
+		t.Errorf("status = %+v, want migration 1 drifted", status)
+	}
+	if !strings.Contains(status.Report(), "modified since applied: 1 baseline") {
+		t.Errorf("report = %q", status.Report())
+	}
+
+	// A drifted migration is reported, not rerun
+	if done, err := storage.ApplyMigrations(ctx, tracker, modified); err != nil || len(done) != 0 {
+		t.Errorf("apply over drift = %v, %v", done, err)
+	}
+
+	// An older build does not know migration 2
+	status, _ = storage.CheckMigrations(ctx, tracker, testMigrations[:1])
+	if !reflect.DeepEqual(status.Unknown, []int{2}) {
+		t.Errorf("unknown = %v, want [2]", status.Unknown)
+	}
+}
+
+func TestMigrations_ApplyStopsAtFailure(t *testing.T) {
+	ctx := context.Background()
+	boom := errors.New("syntax error")
+	tracker := &Migrations{Fail: map[int]error{2: boom}}
+	three := append(append([]storage.Migration(nil), testMigrations...), storage.Migration{Version: 3, Name: "later", SQL: "SELECT 1"})
+
+	done, err := storage.ApplyMigrations(ctx, tracker, three)
+	if !errors.Is(err, boom) || len(done) != 1 {
+		t.Fatalf("apply = %v, %v, want migration 1 applied, then the failure", done, err)
+	}
+	status, _ := storage.CheckMigrations(ctx, tracker, three)
+	if len(status.Pending) != 2 {
+		t.Errorf("pending = %v, want 2 and 3", status.Pending)
+	}
+}
@@ -0,0 +1,676 @@
+// Package memory provides an in-process storage.PaperStore for tests and
+// database-free runs.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/similarity"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
+)
+
+// Store keeps papers in a map guarded by a mutex.
+type Store struct {
+	ChunkSize int         // Papers per chunk in SaveBatch (default: 500)
+	Clock     clock.Clock // Records first-save times (default: system clock)
+
+	mu       sync.RWMutex
+	papers   map[string]model.Paper
+	versions []model.VersionUpdate
+	pdfs     map[string]pdfFile
+	stored   map[string]time.Time // First save time per paper ID
+	prints   map[string]uint64    // Abstract fingerprint per paper ID
+	index    map[string]indexed   // Searchable abstract text per paper ID
+	modified time.Time            // Last save time
+	audit    []storage.AuditEntry // In recording order
+	auditID  int64                // Last assigned audit entry ID
+	settings map[string]storage.Setting
+
+	watchlists  map[int]storage.Watchlist
+	watchlistID int // Last assigned watchlist ID
+}
+
+// RecordAudit appends an audit entry.
+func (s *Store) RecordAudit(ctx context.Context, e storage.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auditID++
+	e.ID = s.auditID
+	s.audit = append(s.audit, e)
+	return nil
+}
+
+// ListAudit returns the latest entries with action (any when empty),
+// most recently recorded first.
+func (s *Store) ListAudit(ctx context.Context, action string, limit int) ([]storage.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries := []storage.AuditEntry{}
+	for i := len(s.audit) - 1; i >= 0 && len(entries) < limit; i-- {
+		if action == "" || s.audit[i].Action == action {
+			entries = append(entries, s.audit[i])
+		}
+	}
+	return entries, nil
+}
+
+// PruneAudit drops entries recorded before cutoff.
+func (s *Store) PruneAudit(ctx context.Context, cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.audit[:0]
+	for _, e := range s.audit {
+		if !e.At.Before(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	pruned := int64(len(s.audit) - len(kept))
+	s.audit = kept
+	return pruned, nil
+}
+
+// ListSettings returns the stored settings by key.
+func (s *Store) ListSettings(ctx context.Context) ([]storage.Setting, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	settings := []storage.Setting{}
+	for _, st := range s.settings {
+		settings = append(settings, st)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings, nil
+}
+
+// PutSettings inserts or replaces settings.
+func (s *Store) PutSettings(ctx context.Context, settings []storage.Setting) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, st := range settings {
+		s.settings[st.Key] = st
+	}
+	return nil
+}
+
+// CreateWatchlist stores w with the next ID.
+func (s *Store) CreateWatchlist(ctx context.Context, w storage.Watchlist) (storage.Watchlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watchlistID++
+	w.ID = s.watchlistID
+	w.CreatedAt = clock.Or(s.Clock).Now()
+	w.Authors = slices.Clone(w.Authors)
+	w.TitleTerms = slices.Clone(w.TitleTerms)
+	s.watchlists[w.ID] = w
+	return w, nil
+}
+
+// GetWatchlist returns a watchlist, or storage.ErrNotFound.
+func (s *Store) GetWatchlist(ctx context.Context, id int) (storage.Watchlist, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, ok := s.watchlists[id]
+	if !ok {
+		return storage.Watchlist{}, storage.ErrNotFound
+	}
+	return w, nil
+}
+
+// ListWatchlists returns every watchlist by ID.
+func (s *Store) ListWatchlists(ctx context.Context) ([]storage.Watchlist, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	watchlists := []storage.Watchlist{}
+	for _, w := range s.watchlists {
+		watchlists = append(watchlists, w)
+	}
+	sort.Slice(watchlists, func(i, j int) bool { return watchlists[i].ID < watchlists[j].ID })
+	return watchlists, nil
+}
+
+// DeleteWatchlist removes a watchlist, or returns storage.ErrNotFound.
+func (s *Store) DeleteWatchlist(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.watchlists[id]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(s.watchlists, id)
+	return nil
+}
+
+// SetWatchlistFeedKey replaces a watchlist's feed key, or returns
+// storage.ErrNotFound.
+func (s *Store) SetWatchlistFeedKey(ctx context.Context, id int, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.watchlists[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	w.FeedKey = key
+	s.watchlists[id] = w
+	return nil
+}
+
+// ListWatchlistHits returns the newest papers with an author whose name
+// contains one of w's authors or a title containing one of its terms.
+func (s *Store) ListWatchlistHits(ctx context.Context, w storage.Watchlist, limit int) ([]model.Paper, error) {
+	authors := make([]string, len(w.Authors))
+	for i, a := range w.Authors {
+		authors[i] = textutil.FoldName(a)
+	}
+	terms := make([]string, len(w.TitleTerms))
+	for i, t := range w.TitleTerms {
+		terms[i] = strings.ToLower(t)
+	}
+
+	matches := s.sorted(func(p model.Paper) bool {
+		for _, author := range p.Authors {
+			folded := textutil.FoldName(author)
+			for _, a := range authors {
+				if strings.Contains(folded, a) {
+					return true
+				}
+			}
+		}
+		title := strings.ToLower(p.Title)
+		for _, t := range terms {
+			if strings.Contains(title, t) {
+				return true
+			}
+		}
+		return false
+	})
+	return page(matches, limit, 0), nil
+}
+
+// pdfFile is a locally archived PDF.
+type pdfFile struct {
+	path string
+	size int64
+}
+
+// New creates an empty in-memory store.
+func New() *Store {
+	return &Store{
+		ChunkSize: storage.DefaultChunkSize,
+		Clock:     clock.Real{},
+		papers:    make(map[string]model.Paper),
+		pdfs:      make(map[string]pdfFile),
+		stored:    make(map[string]time.Time),
+		prints:    make(map[string]uint64),
+		index:     make(map[string]indexed),
+		settings:  make(map[string]storage.Setting),
+
+		watchlists: make(map[int]storage.Watchlist),
+	}
+}
+
+// SaveBatch inserts or updates papers in chunks, stopping between chunks
+// if ctx is done.
+func (s *Store) SaveBatch(ctx context.Context, papers []model.Paper) error {
+	return storage.SaveChunked(ctx, papers, s.ChunkSize, s.saveChunk)
+}
+
+func (s *Store) saveChunk(ctx context.Context, papers []model.Paper) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range papers {
+		s.put(p)
+	}
+	return nil
+}
+
+// put stores p, noting when its ID was first seen. Caller must hold s.mu.
+func (s *Store) put(p model.Paper) {
+	now := clock.Or(s.Clock).Now()
+	if _, ok := s.stored[p.ID]; !ok {
+		s.stored[p.ID] = now
+	}
+	s.papers[p.ID] = p
+	s.prints[p.ID] = similarity.Fingerprint(p.Abstract)
+	lead, rest, cut := storage.SearchText(p.Abstract)
+	s.index[p.ID] = indexed{text: strings.ToLower(lead + " " + rest), truncated: cut}
+	s.modified = now
+}
+
+// indexed is the part of an abstract Search reads, as the Postgres index
+// holds it.
+type indexed struct {
+	text      string
+	truncated bool
+}
+
+// SaveBatchInserted saves papers and returns the IDs it inserted rather
+// than updated, and apart from them those it updated to a higher
+// Revision. Each chunk is checked and written under one lock, so
+// concurrent callers saving the same paper never both insert it.
+func (s *Store) SaveBatchInserted(ctx context.Context, papers []model.Paper) (inserted, revised map[string]bool, err error) {
+	inserted, revised = make(map[string]bool), make(map[string]bool)
+	err = storage.SaveChunked(ctx, papers, s.ChunkSize, func(ctx context.Context, chunk []model.Paper) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for _, p := range chunk {
+			switch old, ok := s.papers[p.ID]; {
+			case !ok:
+				inserted[p.ID] = true
+			case p.Revision > old.Revision:
+				revised[p.ID] = true
+			}
+			s.put(p)
+		}
+		return nil
+	})
+	return inserted, revised, err
+}
+
+// Delete removes a paper by ID.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.papers[id]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(s.papers, id)
+	delete(s.stored, id)
+	delete(s.prints, id)
+	delete(s.index, id)
+	delete(s.pdfs, id)
+	return nil
+}
+
+// GetByID retrieves a paper by ID, or by its base ID when a versioned ID
+// is not stored.
+func (s *Store) GetByID(ctx context.Context, id string) (model.Paper, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.papers[id]
+	if !ok {
+		p, ok = s.papers[paperid.Base(id)]
+	}
+	if !ok {
+		return model.Paper{}, storage.ErrNotFound
+	}
+	return p, nil
+}
+
+// List returns papers ordered by update time, newest first.
+func (s *Store) List(ctx context.Context, limit, offset int) ([]model.Paper, error) {
+	return page(s.sorted(nil), limit, offset), nil
+}
+
+// ListAfter returns up to limit papers ordered after the cursor.
+func (s *Store) ListAfter(ctx context.Context, after storage.Cursor, limit int) ([]model.Paper, error) {
+	return page(s.sorted(after.Precedes), limit, 0), nil
+}
+
+// StreamPapers calls fn for each paper after the cursor, oldest first with
+// ties broken by ID.
+func (s *Store) StreamPapers(ctx context.Context, after storage.Cursor, fn func(model.Paper) error) error {
+	papers := s.sorted(after.Before)
+	for i := len(papers) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(papers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search matches query case-insensitively against the title and the
+// indexed prefix of the abstract (see storage.SearchText).
+func (s *Store) Search(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	q := strings.ToLower(query)
+	matches := s.sorted(func(p model.Paper) bool {
+		return strings.Contains(strings.ToLower(p.Title), q) ||
+			strings.Contains(s.index[p.ID].text, q)
+	})
+	return page(matches, limit, 0), nil
+}
+
+// CountSearchTruncated returns the number of papers whose abstract was
+// cut for the search index.
+func (s *Store) CountSearchTruncated(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, e := range s.index {
+		if e.truncated {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ListByAuthor matches author names ignoring case and diacritics.
+func (s *Store) ListByAuthor(ctx context.Context, name string, limit, offset int) ([]model.Paper, error) {
+	needle := textutil.FoldName(name)
+	matches := s.sorted(func(p model.Paper) bool {
+		for _, author := range p.Authors {
+			if strings.Contains(textutil.FoldName(author), needle) {
+				return true
+			}
+		}
+		return false
+	})
+	return page(matches, limit, offset), nil
+}
+
+// ListMinScore returns papers scoring at least the threshold for their
+// primary category, newest first.
+func (s *Store) ListMinScore(ctx context.Context, min storage.MinScores, limit, offset int) ([]model.Paper, error) {
+	return page(s.sorted(min.Allows), limit, offset), nil
+}
+
+// ListStoredBefore returns up to limit papers first saved before cutoff,
+// oldest first.
+func (s *Store) ListStoredBefore(ctx context.Context, cutoff time.Time, limit int) ([]model.Paper, error) {
+	s.mu.RLock()
+	var matches []model.Paper
+	for id, p := range s.papers {
+		if s.stored[id].Before(cutoff) {
+			matches = append(matches, p)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		ti, tj := s.stored[matches[i].ID], s.stored[matches[j].ID]
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	s.mu.RUnlock()
+
+	return page(matches, limit, 0), nil
+}
+
+// IngestionStats sums the papers first saved from the bucket holding from
+// up to to, and the bytes of their abstracts, by UTC day or week.
+func (s *Store) IngestionStats(ctx context.Context, from, to time.Time, bucket string) ([]storage.IngestionBucket, error) {
+	if !storage.ValidBucket(bucket) {
+		return nil, fmt.Errorf("unknown bucket %q", bucket)
+	}
+	from = storage.BucketStart(from, bucket)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	found := make(map[time.Time]storage.IngestionBucket)
+	for id, p := range s.papers {
+		at := s.stored[id]
+		if at.Before(from) || at.After(to) {
+			continue
+		}
+		start := storage.BucketStart(at, bucket)
+		b := found[start]
+		b.Papers++
+		b.AbstractBytes += int64(len(p.Abstract))
+		found[start] = b
+	}
+	return storage.FillBuckets(from, to, bucket, found), nil
+}
+
+// Count returns the number of stored papers. The count is always exact.
+func (s *Store) Count(ctx context.Context, exact bool) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.papers)), nil
+}
+
+// FindNearDuplicates returns papers with another base ID whose abstracts
+// are within maxDistance bits of paper id's, nearest first.
+func (s *Store) FindNearDuplicates(ctx context.Context, id string, maxDistance int) ([]model.Paper, error) {
+	s.mu.RLock()
+	p, ok := s.papers[id]
+	fp := s.prints[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return s.FindNearFingerprint(ctx, p.BaseID(), fp, maxDistance)
+}
+
+// FindNearFingerprint returns papers other than versions of baseID whose
+// fingerprints are within maxDistance bits of fp, nearest first.
+func (s *Store) FindNearFingerprint(ctx context.Context, baseID string, fp uint64, maxDistance int) ([]model.Paper, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var near []model.Paper
+	for id, p := range s.papers {
+		if p.BaseID() != baseID && similarity.Near(fp, s.prints[id], maxDistance) {
+			near = append(near, p)
+		}
+	}
+	sort.Slice(near, func(i, j int) bool {
+		di := similarity.Distance(fp, s.prints[near[i].ID])
+		dj := similarity.Distance(fp, s.prints[near[j].ID])
+		if di != dj {
+			return di < dj
+		}
+		return near[i].ID < near[j].ID
+	})
+	return near, nil
+}
+
+// DistinctCategories counts papers by category, most papers first and
+// ties by name; with primary set only each paper's first category counts.
+func (s *Store) DistinctCategories(ctx context.Context, primary bool) ([]storage.CategoryCount, error) {
+	s.mu.RLock()
+	counts := make(map[string]int64)
+	for _, p := range s.papers {
+		cats := p.Categories
+		if primary && len(cats) > 1 {
+			cats = cats[:1]
+		}
+		for _, c := range cats {
+			counts[c]++
+		}
+	}
+	s.mu.RUnlock()
+
+	result := make([]storage.CategoryCount, 0, len(counts))
+	for c, n := range counts {
+		result = append(result, storage.CategoryCount{Category: c, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Category < result[j].Category
+	})
+	return result, nil
+}
+
+// DataVersion returns the time of the last save and the number of stored
+// papers.
+func (s *Store) DataVersion(ctx context.Context) (storage.DataVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return storage.DataVersion{Modified: s.modified, Count: int64(len(s.papers))}, nil
+}
+
+// GetLatestUpdateTime returns the most recent paper update time.
+func (s *Store) GetLatestUpdateTime(ctx context.Context) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.papers) == 0 {
+		return time.Time{}, storage.ErrNotFound
+	}
+
+	var latest time.Time
+	for _, p := range s.papers {
+		if p.UpdatedAt.After(latest) {
+			latest = p.UpdatedAt
+		}
+	}
+	return latest, nil
+}
+
+// LatestVersions returns the highest stored version of each base ID.
+func (s *Store) LatestVersions(ctx context.Context, baseIDs []string) (map[string]model.Paper, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(baseIDs))
+	for _, id := range baseIDs {
+		wanted[id] = true
+	}
+
+	latest := make(map[string]model.Paper)
+	for _, p := range s.papers {
+		base := p.BaseID()
+		if !wanted[base] {
+			continue
+		}
+		if prev, ok := latest[base]; !ok || p.Version() > prev.Version() {
+			latest[base] = p
+		}
+	}
+	return latest, nil
+}
+
+// ListByBaseIDs returns every stored version of the given base IDs,
+// newest first.
+func (s *Store) ListByBaseIDs(ctx context.Context, baseIDs []string) ([]model.Paper, error) {
+	wanted := make(map[string]bool, len(baseIDs))
+	for _, id := range baseIDs {
+		wanted[id] = true
+	}
+	return s.sorted(func(p model.Paper) bool { return wanted[p.BaseID()] }), nil
+}
+
+// RecordVersionUpdates appends to the version history, skipping updates
+// already recorded for the same base ID and new version.
+func (s *Store) RecordVersionUpdates(ctx context.Context, updates []model.VersionUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range updates {
+		if !s.hasVersion(u.BaseID, u.NewVersion) {
+			s.versions = append(s.versions, u)
+		}
+	}
+	return nil
+}
+
+// hasVersion reports whether an update to version is recorded. Caller must hold s.mu.
+func (s *Store) hasVersion(baseID string, version int) bool {
+	for _, v := range s.versions {
+		if v.BaseID == baseID && v.NewVersion == version {
+			return true
+		}
+	}
+	return false
+}
+
+// ListVersions returns the version history of a base ID, oldest first.
+func (s *Store) ListVersions(ctx context.Context, baseID string) ([]model.VersionUpdate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var history []model.VersionUpdate
+	for _, v := range s.versions {
+		if v.BaseID == baseID {
+			history = append(history, v)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].NewVersion < history[j].NewVersion
+	})
+	return history, nil
+}
+
+// ListUnarchived returns papers scoring at least minScore without a local
+// PDF, highest score first.
+func (s *Store) ListUnarchived(ctx context.Context, minScore, limit int) ([]model.Paper, error) {
+	s.mu.RLock()
+	var matches []model.Paper
+	for id, p := range s.papers {
+		if _, ok := s.pdfs[id]; !ok && p.Score >= minScore {
+			matches = append(matches, p)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID > matches[j].ID
+	})
+	return page(matches, limit, 0), nil
+}
+
+// SetPDF records the local PDF file of a paper.
+func (s *Store) SetPDF(ctx context.Context, id, path string, size int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.papers[id]; !ok {
+		return storage.ErrNotFound
+	}
+	s.pdfs[id] = pdfFile{path: path, size: size}
+	return nil
+}
+
+// GetPDF returns the local PDF of a paper, or storage.ErrNotFound.
+func (s *Store) GetPDF(ctx context.Context, id string) (string, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.pdfs[id]
+	if !ok {
+		return "", 0, storage.ErrNotFound
+	}
+	return f.path, f.size, nil
+}
+
+// sorted returns the papers accepted by keep (all if nil), newest first
+// with ties broken by ID descending.
+func (s *Store) sorted(keep func(model.Paper) bool) []model.Paper {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]model.Paper, 0, len(s.papers))
+	for _, p := range s.papers {
+		if keep == nil || keep(p) {
+			result = append(result, p)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].UpdatedAt.Equal(result[j].UpdatedAt) {
+			return result[i].UpdatedAt.After(result[j].UpdatedAt)
+		}
+		return result[i].ID > result[j].ID
+	})
+	return result
+}
+
+func page(papers []model.Paper, limit, offset int) []model.Paper {
+	if offset >= len(papers) {
+		return nil
+	}
+	papers = papers[offset:]
+	if limit > 0 && limit < len(papers) {
+		papers = papers[:limit]
+	}
+	return papers
+}
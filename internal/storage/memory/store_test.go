@@ -0,0 +1,464 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage"
+)
+
+// countdownContext reports cancellation after Err has been called n times.
+type countdownContext struct {
+	context.Context
+	remaining int
+}
+
+func (c *countdownContext) Err() error {
+	if c.remaining <= 0 {
+		return context.Canceled
+	}
+	c.remaining--
+	return nil
+}
+
+func makePapers(n int) []model.Paper {
+	papers := make([]model.Paper, n)
+	for i := range papers {
+		papers[i] = model.Paper{
+			ID:        fmt.Sprintf("2401.%05dv1", i),
+			Title:     fmt.Sprintf("Paper %d", i),
+			UpdatedAt: time.Date(2024, 1, 1, 0, i, 0, 0, time.UTC),
+		}
+	}
+	return papers
+}
+
+func TestSaveBatch_CancelledMidBatch(t *testing.T) {
+	store := New()
+	store.ChunkSize = 100
+
+	// Allow three chunks through, then report the context as cancelled.
+	ctx := &countdownContext{Context: context.Background(), remaining: 3}
+
+	err := store.SaveBatch(ctx, makePapers(1000))
+
+	if !errors.Is(err, storage.ErrPartialSave) {
+		t.Fatalf("expected ErrPartialSave, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected wrapped context.Canceled, got %v", err)
+	}
+
+	var partial *storage.PartialSaveError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected *PartialSaveError, got %T", err)
+	}
+	if partial.Committed != 300 || partial.Total != 1000 {
+		t.Errorf("expected 300/1000 committed, got %d/%d", partial.Committed, partial.Total)
+	}
+
+	count, _ := store.Count(context.Background(), true)
+	if count != int64(partial.Committed) {
+		t.Errorf("store holds %d papers, error reports %d committed", count, partial.Committed)
+	}
+}
+
+func TestSaveBatch_Complete(t *testing.T) {
+	store := New()
+	store.ChunkSize = 7
+
+	if err := store.SaveBatch(context.Background(), makePapers(50)); err != nil {
+		t.Fatalf("SaveBatch failed: %v", err)
+	}
+
+	count, _ := store.Count(context.Background(), true)
+	if count != 50 {
+		t.Errorf("expected 50 papers, got %d", count)
+	}
+}
+
+func TestStore_ListAndSearch(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+	if err := store.SaveBatch(ctx, makePapers(5)); err != nil {
+		t.Fatalf("SaveBatch failed: %v", err)
+	}
+
+	papers, err := store.List(ctx, 2, 1)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(papers) != 2 || papers[0].ID != "2401.00003v1" {
+		t.Errorf("unexpected page: %+v", papers)
+	}
+
+	found, err := store.Search(ctx, "paper 4", 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "2401.00004v1" {
+		t.Errorf("unexpected search result: %+v", found)
+	}
+
+	if _, err := store.GetByID(ctx, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStore_ListByAuthor(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+	papers := []model.Paper{
+		{ID: "1", Authors: []string{"José García", "Ana Pérez"}, UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "2", Authors: []string{"Yann LeCun"}, UpdatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: "3", Authors: []string{"Joseph Smith"}, UpdatedAt: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	if err := store.SaveBatch(ctx, papers); err != nil {
+		t.Fatalf("SaveBatch failed: %v", err)
+	}
+
+	tests := []struct {
+		author   string
+		expected []string
+	}{
+		{"Jose", []string{"3", "1"}},
+		{"josé garcía", []string{"1"}},
+		{"JOSE GARCIA", []string{"1"}},
+		{"lecun", []string{"2"}},
+		{"perez", []string{"1"}},
+		{"nobody", nil},
+	}
+
+	for _, tc := range tests {
+		found, err := store.ListByAuthor(ctx, tc.author, 10, 0)
+		if err != nil {
+			t.Fatalf("ListByAuthor(%q) failed: %v", tc.author, err)
+		}
+		var ids []string
+		for _, p := range found {
+			ids = append(ids, p.ID)
+		}
+		if strings.Join(ids, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("ListByAuthor(%q) = %v, want %v", tc.author, ids, tc.expected)
+		}
+	}
+}
+
+func TestStore_ListMinScore(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	papers := []model.Paper{
+		{ID: "1", Categories: []string{"cs.CL"}, Score: 65, UpdatedAt: day(1)},
+		{ID: "2", Categories: []string{"cs.CL"}, Score: 75, UpdatedAt: day(2)},
+		{ID: "3", Categories: []string{"math.NA", "cs.CL"}, Score: 56, UpdatedAt: day(3)},
+		{ID: "4", Categories: []string{"math.NA"}, Score: 40, UpdatedAt: day(4)},
+		{ID: "5", Score: 55, UpdatedAt: day(5)},
+	}
+	if err := store.SaveBatch(ctx, papers); err != nil {
+		t.Fatalf("SaveBatch failed: %v", err)
+	}
+
+	tests := []struct {
+		spec     string
+		expected []string
+	}{
+		{"cs.CL:70,default:55", []string{"5", "3", "2"}},
+		{"cs.CL:60,math.NA:40", []string{"5", "4", "3", "2", "1"}},
+		{"math.NA:60,default:60", []string{"2", "1"}},
+		{"70", []string{"2"}},
+	}
+
+	for _, tc := range tests {
+		min, err := storage.ParseMinScores(tc.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, err := store.ListMinScore(ctx, min, 10, 0)
+		if err != nil {
+			t.Fatalf("ListMinScore(%q) failed: %v", tc.spec, err)
+		}
+		var ids []string
+		for _, p := range found {
+			ids = append(ids, p.ID)
+		}
+		if strings.Join(ids, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("ListMinScore(%q) = %v, want %v", tc.spec, ids, tc.expected)
+		}
+	}
+}
+
+func TestStore_SaveBatchInserted_ConcurrentOverlap(t *testing.T) {
+	store := New()
+	store.ChunkSize = 3
+	ctx := context.Background()
+
+	const (
+		writers  = 10
+		distinct = 40
+		perBatch = 15
+	)
+
+	// Writer w saves papers w*3 .. w*3+14 (mod distinct), so neighbours overlap
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		totalNew int
+	)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			batch := make([]model.Paper, 0, perBatch)
+			for i := 0; i < perBatch; i++ {
+				batch = append(batch, model.Paper{ID: fmt.Sprintf("2401.%05dv1", (w*3+i)%distinct)})
+			}
+			inserted, _, err := store.SaveBatchInserted(ctx, batch)
+			if err != nil {
+				t.Errorf("writer %d: %v", w, err)
+			}
+			mu.Lock()
+			totalNew += len(inserted)
+			mu.Unlock()
+		}(w)
+	}
+	wg.Wait()
+
+	count, _ := store.Count(ctx, true)
+	if count != distinct {
+		t.Fatalf("stored %d papers, want %d", count, distinct)
+	}
+	if int64(totalNew) != count {
+		t.Errorf("aggregate new count = %d, want distinct paper count %d", totalNew, count)
+	}
+}
+
+func TestStore_StrippedVersions(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+
+	save := func(revision int) (inserted, revised bool) {
+		t.Helper()
+		ins, rev, err := store.SaveBatchInserted(ctx, []model.Paper{{ID: "cs/0001001", Revision: revision}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ins["cs/0001001"], rev["cs/0001001"]
+	}
+	if ins, rev := save(1); !ins || rev {
+		t.Errorf("first save: inserted %t, revised %t; want inserted", ins, rev)
+	}
+	if ins, rev := save(1); ins || rev {
+		t.Errorf("same revision: inserted %t, revised %t; want neither", ins, rev)
+	}
+	if ins, rev := save(2); ins || !rev {
+		t.Errorf("higher revision: inserted %t, revised %t; want revised", ins, rev)
+	}
+
+	if count, _ := store.Count(ctx, true); count != 1 {
+		t.Errorf("stored %d papers, want 1", count)
+	}
+	// A versioned ID finds the paper stored under its base ID
+	p, err := store.GetByID(ctx, "cs/0001001v2")
+	if err != nil || p.Version() != 2 {
+		t.Errorf("GetByID(cs/0001001v2) = %s v%d, %v", p.ID, p.Version(), err)
+	}
+}
+
+func TestStore_PaginationWithTiedTimestamps(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+
+	same := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	var papers []model.Paper
+	for i := 0; i < 10; i++ {
+		papers = append(papers, model.Paper{ID: fmt.Sprintf("2401.%05dv1", i), UpdatedAt: same})
+	}
+	if err := store.SaveBatch(ctx, papers); err != nil {
+		t.Fatalf("SaveBatch failed: %v", err)
+	}
+
+	collect := func(name string, pages ...[]model.Paper) {
+		t.Helper()
+		seen := make(map[string]bool)
+		var ids []string
+		for _, page := range pages {
+			for _, p := range page {
+				seen[p.ID] = true
+				ids = append(ids, p.ID)
+			}
+		}
+		if len(seen) != 10 {
+			t.Errorf("%s pagination returned %d distinct papers, want 10: %v", name, len(seen), ids)
+		}
+		for i := 1; i < len(ids); i++ {
+			if ids[i] >= ids[i-1] {
+				t.Errorf("%s pagination not ordered by ID descending: %v", name, ids)
+				break
+			}
+		}
+	}
+
+	// Offset pagination
+	page1, _ := store.List(ctx, 5, 0)
+	page2, _ := store.List(ctx, 5, 5)
+	collect("offset", page1, page2)
+
+	// Cursor pagination
+	first, _ := store.List(ctx, 5, 0)
+	cursor, err := storage.DecodeCursor(storage.CursorAfter(first[len(first)-1]).Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	second, _ := store.ListAfter(ctx, cursor, 5)
+	collect("cursor", first, second)
+
+	rest, _ := store.ListAfter(ctx, storage.CursorAfter(second[len(second)-1]), 5)
+	if len(rest) != 0 {
+		t.Errorf("cursor past the last paper returned %d papers", len(rest))
+	}
+}
+
+func TestStore_ListStoredBefore(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	s := New()
+	s.Clock = clk
+
+	s.SaveBatch(ctx, []model.Paper{{ID: "2401.00002v1"}, {ID: "2401.00001v1"}})
+	clk.Advance(90 * 24 * time.Hour)
+	s.SaveBatch(ctx, []model.Paper{{ID: "2404.00001v1"}})
+	// Re-saving keeps the original first-save time
+	s.SaveBatch(ctx, []model.Paper{{ID: "2401.00001v1", Title: "revised"}})
+
+	old, err := s.ListStoredBefore(ctx, start.Add(time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(old) != 2 || old[0].ID != "2401.00001v1" || old[1].ID != "2401.00002v1" {
+		t.Errorf("ListStoredBefore = %+v, want 2401.00001v1 then 2401.00002v1", old)
+	}
+
+	all, _ := s.ListStoredBefore(ctx, clk.Now().Add(time.Second), 10)
+	if len(all) != 3 || all[2].ID != "2404.00001v1" {
+		t.Errorf("ListStoredBefore(now) = %+v, want 3 papers ending with 2404.00001v1", all)
+	}
+}
+
+func TestStore_FindNearDuplicates(t *testing.T) {
+	const text = `We study retrieval augmented generation for open-domain question answering. Our retriever is trained jointly with the reader ` +
+		`using a contrastive objective over hard negatives mined from Wikipedia. Experiments on Natural Questions and TriviaQA show gains of 3 points ` +
+		`in exact match over strong dense retrieval baselines.`
+	s := New()
+	ctx := context.Background()
+	s.SaveBatch(ctx, []model.Paper{
+		{ID: "2401.00001v1", Abstract: text},
+		{ID: "2401.00001v2", Abstract: text},                  // another version
+		{ID: "2401.00002v1", Abstract: strings.ToUpper(text)}, // another source
+		{ID: "2401.00003v1", Abstract: "An unrelated abstract about protein folding with diffusion models."},
+	})
+
+	near, err := s.FindNearDuplicates(ctx, "2401.00001v1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(near) != 1 || near[0].ID != "2401.00002v1" {
+		t.Errorf("FindNearDuplicates = %v, want only 2401.00002v1", near)
+	}
+	if _, err := s.FindNearDuplicates(ctx, "2401.09999v1", 10); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown ID, got %v", err)
+	}
+}
+
+func TestStore_SearchLongAbstract(t *testing.T) {
+	ctx := context.Background()
+	// Far past Postgres's 1 MB tsvector limit
+	abstract := "We introduce needlework attention. " + strings.Repeat("filler tokens pad the abstract ", 80000) + "tailterm"
+	papers := []model.Paper{
+		{ID: "2403.00001v1", Title: "Long", Abstract: abstract},
+		{ID: "2403.00002v1", Title: "Short", Abstract: "Needlework for small models."},
+	}
+	s := New()
+	if err := s.SaveBatch(ctx, papers); err != nil {
+		t.Fatalf("SaveBatch failed: %v", err)
+	}
+
+	got, _ := s.GetByID(ctx, "2403.00001v1")
+	if got.Abstract != abstract {
+		t.Error("the stored abstract should be kept whole")
+	}
+	if found, _ := s.Search(ctx, "needlework", 10); len(found) != 2 {
+		t.Errorf("a term from the indexed prefix found %d papers, want 2", len(found))
+	}
+	if found, _ := s.Search(ctx, "tailterm", 10); len(found) != 0 {
+		t.Errorf("a term past the indexed prefix found %v", found)
+	}
+	if n, _ := s.CountSearchTruncated(ctx); n != 1 {
+		t.Errorf("CountSearchTruncated = %d, want 1", n)
+	}
+}
+
+func TestStore_IngestionStats(t *testing.T) {
+	ctx := context.Background()
+	// A second before Monday, March 11 starts in UTC
+	sunday := time.Date(2024, 3, 10, 23, 59, 59, 0, time.UTC)
+	clk := clock.NewFake(sunday)
+	s := New()
+	s.Clock = clk
+
+	s.SaveBatch(ctx, []model.Paper{{ID: "2403.00001v1", Abstract: "abcd"}})
+	clk.Advance(time.Second) // Monday 00:00:00
+	s.SaveBatch(ctx, []model.Paper{{ID: "2403.00002v1", Abstract: "héllo"}, {ID: "2403.00003v1", Abstract: "xy"}})
+	clk.Advance(2*24*time.Hour + 12*time.Hour) // Wednesday 12:00
+	s.SaveBatch(ctx, []model.Paper{{ID: "2403.00004v1", Abstract: "z"}})
+	// Re-saving counts the paper on its first day only
+	s.SaveBatch(ctx, []model.Paper{{ID: "2403.00001v1", Abstract: "a longer abstract"}})
+
+	// Buckets follow UTC days whatever the zone of the bounds
+	paris := time.FixedZone("CET", 3600)
+	days, err := s.IngestionStats(ctx, time.Date(2024, 3, 10, 12, 0, 0, 0, paris), clk.Now().In(paris), storage.BucketDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []storage.IngestionBucket{
+		{Start: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), Papers: 1, AbstractBytes: 17},
+		{Start: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), Papers: 2, AbstractBytes: 8},
+		{Start: time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC), Papers: 1, AbstractBytes: 1},
+	}
+	if len(days) != len(want) {
+		t.Fatalf("day buckets = %+v, want %+v", days, want)
+	}
+	for i := range want {
+		if !days[i].Start.Equal(want[i].Start) || days[i].Papers != want[i].Papers || days[i].AbstractBytes != want[i].AbstractBytes {
+			t.Errorf("day %d = %+v, want %+v", i, days[i], want[i])
+		}
+	}
+
+	weeks, err := s.IngestionStats(ctx, sunday, clk.Now(), storage.BucketWeek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(weeks) != 2 || !weeks[0].Start.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)) || weeks[0].Papers != 1 ||
+		!weeks[1].Start.Equal(time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)) || weeks[1].Papers != 3 || weeks[1].AbstractBytes != 9 {
+		t.Errorf("week buckets = %+v, want 1 paper in the week of March 4 and 3 in the week of March 11", weeks)
+	}
+
+	// A window starting on Tuesday leaves out Monday's papers
+	tuesday, _ := s.IngestionStats(ctx, time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), clk.Now(), storage.BucketDay)
+	if len(tuesday) != 2 || tuesday[0].Papers != 0 || tuesday[1].Papers != 1 {
+		t.Errorf("from Tuesday = %+v, want an empty Tuesday and one paper on Wednesday", tuesday)
+	}
+
+	if _, err := s.IngestionStats(ctx, sunday, clk.Now(), "month"); err == nil {
+		t.Error("IngestionStats accepted bucket month")
+	}
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// approxCountThreshold is the estimated row count below which an exact
+// COUNT(*) is cheap enough to always run.
+const approxCountThreshold = 100000
+
+// RelationSizes holds on-disk sizes (in bytes) for the papers table.
+type RelationSizes struct {
+	TableBytes int64        `json:"table_bytes"`
+	IndexBytes int64        `json:"index_bytes"`
+	TotalBytes int64        `json:"total_bytes"`
+	Indexes    []IndexStats `json:"indexes"`
+}
+
+// IndexStats describes a single index on the papers table.
+type IndexStats struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+	Scans int64  `json:"scans"`
+}
+
+// countSource provides the two ways of counting papers.
+type countSource interface {
+	// estimateCount returns the planner's row estimate and whether the
+	// statistics behind it are recent enough to trust.
+	estimateCount(ctx context.Context) (estimate int64, fresh bool, err error)
+	exactCount(ctx context.Context) (int64, error)
+}
+
+// resolveCount picks between the estimated and exact count. The estimate is
+// only used when it is fresh and large enough that COUNT(*) would be slow.
+func resolveCount(ctx context.Context, src countSource, exact bool) (int64, error) {
+	if exact {
+		return src.exactCount(ctx)
+	}
+
+	estimate, fresh, err := src.estimateCount(ctx)
+	if err != nil || !fresh || estimate < approxCountThreshold {
+		return src.exactCount(ctx)
+	}
+	return estimate, nil
+}
+
+func (r *PaperRepository) exactCount(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM papers").Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count papers: %w", err)
+	}
+	return count, nil
+}
+
+// estimateCount reads pg_class.reltuples. The estimate is considered stale if
+// the table was never analyzed or more than 10% of rows changed since then.
+func (r *PaperRepository) estimateCount(ctx context.Context) (int64, bool, error) {
+	var (
+		reltuples int64
+		modified  int64
+		analyzed  bool
+	)
+	err := r.pool.QueryRow(ctx, `
+		SELECT c.reltuples::bigint,
+		       COALESCE(s.n_mod_since_analyze, 0),
+		       (s.last_analyze IS NOT NULL OR s.last_autoanalyze IS NOT NULL)
+		FROM pg_class c
+		LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+		WHERE c.oid = 'papers'::regclass
+	`).Scan(&reltuples, &modified, &analyzed)
+	if err != nil {
+		return 0, false, fmt.Errorf("estimate count: %w", err)
+	}
+
+	fresh := analyzed && reltuples >= 0 && modified <= reltuples/10
+	return reltuples, fresh, nil
+}
+
+// RelationSizes returns the on-disk size of the papers table and its indexes.
+func (r *PaperRepository) RelationSizes(ctx context.Context) (RelationSizes, error) {
+	var sizes RelationSizes
+	err := r.pool.QueryRow(ctx, `
+		SELECT pg_relation_size('papers'),
+		       pg_indexes_size('papers'),
+		       pg_total_relation_size('papers')
+	`).Scan(&sizes.TableBytes, &sizes.IndexBytes, &sizes.TotalBytes)
+	if err != nil {
+		return RelationSizes{}, fmt.Errorf("relation sizes: %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT indexrelname, pg_relation_size(indexrelid), idx_scan
+		FROM pg_stat_user_indexes
+		WHERE relname = 'papers'
+		ORDER BY indexrelname
+	`)
+	if err != nil {
+		return RelationSizes{}, fmt.Errorf("index sizes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var idx IndexStats
+		if err := rows.Scan(&idx.Name, &idx.Bytes, &idx.Scans); err != nil {
+			return RelationSizes{}, fmt.Errorf("scan index stats: %w", err)
+		}
+		sizes.Indexes = append(sizes.Indexes, idx)
+	}
+
+	return sizes, rows.Err()
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeCountSource struct {
+	estimate    int64
+	fresh       bool
+	estimateErr error
+	exact       int64
+	exactCalls  int
+}
+
+func (f *fakeCountSource) estimateCount(ctx context.Context) (int64, bool, error) {
+	return f.estimate, f.fresh, f.estimateErr
+}
+
+func (f *fakeCountSource) exactCount(ctx context.Context) (int64, error) {
+	f.exactCalls++
+	return f.exact, nil
+}
+
+func TestResolveCount(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       fakeCountSource
+		exact     bool
+		want      int64
+		wantExact bool
+	}{
+		{
+			name:      "fresh large estimate is used",
+			src:       fakeCountSource{estimate: 500000, fresh: true, exact: 500123},
+			want:      500000,
+			wantExact: false,
+		},
+		{
+			name:      "stale reltuples falls back to exact",
+			src:       fakeCountSource{estimate: 500000, fresh: false, exact: 812345},
+			want:      812345,
+			wantExact: true,
+		},
+		{
+			name:      "small table uses exact",
+			src:       fakeCountSource{estimate: 1200, fresh: true, exact: 1234},
+			want:      1234,
+			wantExact: true,
+		},
+		{
+			name:      "exact requested",
+			src:       fakeCountSource{estimate: 500000, fresh: true, exact: 500123},
+			exact:     true,
+			want:      500123,
+			wantExact: true,
+		},
+		{
+			name:      "estimate error falls back to exact",
+			src:       fakeCountSource{estimateErr: errors.New("boom"), exact: 42},
+			want:      42,
+			wantExact: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			src := tc.src
+			got, err := resolveCount(context.Background(), &src, tc.exact)
+			if err != nil {
+				t.Fatalf("resolveCount failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("resolveCount() = %d, want %d", got, tc.want)
+			}
+			if (src.exactCalls > 0) != tc.wantExact {
+				t.Errorf("exact count called %d times, want exact=%v", src.exactCalls, tc.wantExact)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Migration is one versioned step of the schema. Once released, its SQL
+// must not change: schema changes go in a new Migration appended to
+// Migrations, so an applied step's checksum keeps matching the binary.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Checksum returns the hex SHA-256 of the migration's SQL.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.SQL))
+	return hex.EncodeToString(sum[:])
+}
+
+// Migrations lists the schema steps this binary expects, oldest first.
+// The baseline is idempotent, so databases created before migrations
+// were tracked take it as already applied.
+var Migrations = []Migration{
+	{Version: 1, Name: "baseline", SQL: createTableSQL},
+	{Version: 2, Name: "sync_log_provider", SQL: `
+-- Provider a sync fetched from (NULL = recorded before providers could be picked per sync)
+ALTER TABLE sync_log ADD COLUMN IF NOT EXISTS provider VARCHAR(50);
+`},
+	{Version: 3, Name: "sync_log_scope", SQL: `
+-- Categories a sync was restricted to, as SyncScope.Key renders them
+-- (NULL = recorded before scopes were, which no checkpoint or diff follows)
+ALTER TABLE sync_log ADD COLUMN IF NOT EXISTS scope TEXT;
+CREATE INDEX IF NOT EXISTS idx_sync_log_scope_completed ON sync_log(query, provider, scope, completed_at DESC);
+`},
+	{Version: 4, Name: "maintenance_tasks", SQL: `
+-- One-off data migrations that depend on configuration, e.g. folding
+-- versioned IDs once version stripping is on (see FoldVersions)
+CREATE TABLE IF NOT EXISTS maintenance_tasks (
+    name VARCHAR(100) PRIMARY KEY,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+`},
+	{Version: 5, Name: "watchlists", SQL: `
+-- Saved author and title searches with their own feeds. feed_key is the
+-- key feed tokens must name; empty when the feed is revoked
+CREATE TABLE IF NOT EXISTS watchlists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    authors TEXT[] NOT NULL DEFAULT '{}',
+    title_terms TEXT[] NOT NULL DEFAULT '{}',
+    feed_key VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+`},
+}
+
+// AppliedMigration is a migration recorded as applied to a database.
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	Checksum  string // Empty when not recorded
+	AppliedAt time.Time
+}
+
+// MigrationTracker records which migrations a database has applied.
+type MigrationTracker interface {
+	// AppliedMigrations returns the recorded migrations, oldest first.
+	AppliedMigrations(ctx context.Context) ([]AppliedMigration, error)
+	// ApplyMigration runs m and records it, both or neither.
+	ApplyMigration(ctx context.Context, m Migration) error
+}
+
+// Drift is an applied migration whose SQL differs from the binary's.
+type Drift struct {
+	Version  int
+	Name     string
+	Applied  string // Recorded checksum
+	Expected string // Checksum of the binary's SQL
+}
+
+// MigrationStatus compares a database's applied migrations with the
+// binary's.
+type MigrationStatus struct {
+	Applied []AppliedMigration
+	Pending []Migration // Not applied yet, oldest first
+	Drifted []Drift     // Applied with a different checksum
+	Unknown []int       // Applied versions the binary does not have (a newer build ran)
+}
+
+// UpToDate reports whether the database matches the binary exactly.
+func (s MigrationStatus) UpToDate() bool {
+	return len(s.Pending) == 0 && len(s.Drifted) == 0 && len(s.Unknown) == 0
+}
+
+// Report describes how the database differs from the binary, one line
+// per problem; it is empty when the schema is up to date.
+func (s MigrationStatus) Report() string {
+	var b strings.Builder
+	for _, m := range s.Pending {
+		fmt.Fprintf(&b, "pending: %d %s\n", m.Version, m.Name)
+	}
+	for _, d := range s.Drifted {
+		fmt.Fprintf(&b, "modified since applied: %d %s (applied %.12s, binary %.12s)\n", d.Version, d.Name, d.Applied, d.Expected)
+	}
+	for _, v := range s.Unknown {
+		fmt.Fprintf(&b, "unknown to this build: %d (applied by a newer version?)\n", v)
+	}
+	return b.String()
+}
+
+// CheckMigrations compares the migrations tracker recorded with
+// migrations.
+func CheckMigrations(ctx context.Context, tracker MigrationTracker, migrations []Migration) (MigrationStatus, error) {
+	applied, err := tracker.AppliedMigrations(ctx)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	status := MigrationStatus{Applied: applied}
+
+	byVersion := make(map[int]AppliedMigration, len(applied))
+	for _, a := range applied {
+		byVersion[a.Version] = a
+	}
+	known := make(map[int]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+		a, ok := byVersion[m.Version]
+		switch {
+		case !ok:
+			status.Pending = append(status.Pending, m)
+		case a.Checksum != "" && a.Checksum != m.Checksum():
+			status.Drifted = append(status.Drifted, Drift{
+				Version: m.Version, Name: m.Name, Applied: a.Checksum, Expected: m.Checksum(),
+			})
+		}
+	}
+	for _, a := range applied {
+		if !known[a.Version] {
+			status.Unknown = append(status.Unknown, a.Version)
+		}
+	}
+	return status, nil
+}
+
+// ApplyMigrations runs the migrations tracker has not recorded, oldest
+// first, and returns those it applied. Drifted migrations are not rerun.
+func ApplyMigrations(ctx context.Context, tracker MigrationTracker, migrations []Migration) ([]Migration, error) {
+	status, err := CheckMigrations(ctx, tracker, migrations)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, m := range status.Pending {
+		if err := tracker.ApplyMigration(ctx, m); err != nil {
+			return done, fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// WritePendingSQL writes the SQL of the pending migrations to w, each
+// under a comment naming it, without running anything.
+func WritePendingSQL(w io.Writer, pending []Migration) error {
+	for _, m := range pending {
+		if _, err := fmt.Fprintf(w, "-- Migration %d: %s (sha256 %s)\n%s\n", m.Version, m.Name, m.Checksum(), strings.TrimSpace(m.SQL)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Migrate modes, as DB_MIGRATE and -migrate take them.
+const (
+	MigrateAuto  = "auto"  // Apply pending migrations on startup
+	MigrateCheck = "check" // Refuse to start unless the schema matches the binary
+	MigrateSkip  = "skip"  // Trust the operator; touch nothing
+)
+
+// ErrSchemaMismatch is returned by PrepareSchema in check mode when the
+// database's migrations differ from the binary's.
+var ErrSchemaMismatch = errors.New("schema does not match this build")
+
+// ValidateMigrateMode reports an error for anything but the Migrate modes.
+func ValidateMigrateMode(mode string) error {
+	switch mode {
+	case MigrateAuto, MigrateCheck, MigrateSkip:
+		return nil
+	}
+	return fmt.Errorf("unknown migrate mode %q (want %s, %s or %s)", mode, MigrateAuto, MigrateCheck, MigrateSkip)
+}
+
+// PrepareSchema readies the database for use according to mode: auto
+// applies pending migrations, check returns an ErrSchemaMismatch error
+// listing the differences, and skip does nothing.
+func PrepareSchema(ctx context.Context, pool *pgxpool.Pool, mode string) error {
+	if err := ValidateMigrateMode(mode); err != nil {
+		return err
+	}
+	switch mode {
+	case MigrateAuto:
+		return Migrate(ctx, pool)
+	case MigrateCheck:
+		status, err := CheckMigrations(ctx, NewMigrationTracker(pool), Migrations)
+		if err != nil {
+			return err
+		}
+		if !status.UpToDate() {
+			return fmt.Errorf("%w (run `pipeline migrate`):\n%s", ErrSchemaMismatch, strings.TrimRight(status.Report(), "\n"))
+		}
+	}
+	return nil
+}
+
+const createMigrationsTableSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY,
+    name TEXT NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+)`
+
+// migrationLock serializes migrations across processes starting at once.
+const migrationLock = 0x67656e65 // "gene"
+
+// PostgresMigrations tracks migrations in the schema_migrations table.
+type PostgresMigrations struct {
+	pool *pgxpool.Pool
+}
+
+// NewMigrationTracker creates a tracker over pool.
+func NewMigrationTracker(pool *pgxpool.Pool) *PostgresMigrations {
+	return &PostgresMigrations{pool: pool}
+}
+
+// AppliedMigrations returns the recorded migrations; none when the table
+// does not exist yet, which it leaves that way.
+func (t *PostgresMigrations) AppliedMigrations(ctx context.Context) ([]AppliedMigration, error) {
+	var exists bool
+	if err := t.pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	rows, err := t.pool.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, fmt.Errorf("scan migration: %w", err)
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// ApplyMigration runs m and records it in one transaction. A migration
+// another process recorded meanwhile is left alone.
+func (t *PostgresMigrations) ApplyMigration(ctx context.Context, m Migration) error {
+	if _, err := t.pool.Exec(ctx, createMigrationsTableSQL); err != nil {
+		return fmt.Errorf("create migrations table: %w", err)
+	}
+
+	tx, err := t.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLock); err != nil {
+		return fmt.Errorf("lock migrations: %w", err)
+	}
+	var recorded bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.Version).Scan(&recorded); err != nil {
+		return fmt.Errorf("read migrations: %w", err)
+	}
+	if recorded {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, m.SQL); err != nil {
+		return fmt.Errorf("execute migration: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+		m.Version, m.Name, m.Checksum()); err != nil {
+		return fmt.Errorf("record migration: %w", err)
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// ErrInvalidMinScore is wrapped by the errors ParseMinScores returns.
+var ErrInvalidMinScore = errors.New("invalid min score")
+
+// categoryPattern matches arXiv category names such as cs.CL, math.NA,
+// hep-th and cond-mat.stat-mech.
+var categoryPattern = regexp.MustCompile(`^[a-z][a-z-]*(\.[A-Za-z][A-Za-z-]*)?$`)
+
+// MinScores is a score threshold that can differ by a paper's primary
+// (first listed) category.
+type MinScores struct {
+	Default    int
+	ByCategory map[string]int
+}
+
+// MinScoreError describes the segment of a min score spec that failed to parse.
+type MinScoreError struct {
+	Segment string
+	Reason  string
+}
+
+func (e *MinScoreError) Error() string {
+	return fmt.Sprintf("%v: segment %q: %s", ErrInvalidMinScore, e.Segment, e.Reason)
+}
+
+func (e *MinScoreError) Unwrap() error { return ErrInvalidMinScore }
+
+// ParseMinScores parses a comma-separated spec such as
+// "cs.CL:70,default:55". A bare number sets the default, so a plain
+// min_score=60 keeps working. Unlisted categories use the default, which
+// is 0 when the spec does not set one.
+func ParseMinScores(spec string) (MinScores, error) {
+	var m MinScores
+	hasDefault := false
+	for _, segment := range strings.Split(spec, ",") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			return MinScores{}, &MinScoreError{Segment: segment, Reason: "empty segment"}
+		}
+
+		category, value, ok := strings.Cut(segment, ":")
+		if !ok {
+			category, value = "default", segment
+		}
+		category = strings.TrimSpace(category)
+
+		score, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || score < 0 || score > 100 {
+			return MinScores{}, &MinScoreError{Segment: segment, Reason: "score must be an integer from 0 to 100"}
+		}
+
+		if category == "default" {
+			if hasDefault {
+				return MinScores{}, &MinScoreError{Segment: segment, Reason: "default given twice"}
+			}
+			hasDefault = true
+			m.Default = score
+			continue
+		}
+		if !categoryPattern.MatchString(category) {
+			return MinScores{}, &MinScoreError{Segment: segment, Reason: "not an arXiv category"}
+		}
+		if _, dup := m.ByCategory[category]; dup {
+			return MinScores{}, &MinScoreError{Segment: segment, Reason: "category given twice"}
+		}
+		if m.ByCategory == nil {
+			m.ByCategory = make(map[string]int)
+		}
+		m.ByCategory[category] = score
+	}
+	return m, nil
+}
+
+// For returns the threshold for a paper with the given categories.
+func (m MinScores) For(categories []string) int {
+	if len(categories) > 0 {
+		if score, ok := m.ByCategory[categories[0]]; ok {
+			return score
+		}
+	}
+	return m.Default
+}
+
+// Allows reports whether p scores at least its category's threshold.
+func (m MinScores) Allows(p model.Paper) bool {
+	return p.Score >= m.For(p.Categories)
+}
+
+// String returns the spec in canonical form, categories sorted.
+func (m MinScores) String() string {
+	categories := make([]string, 0, len(m.ByCategory))
+	for c := range m.ByCategory {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+
+	segments := make([]string, 0, len(categories)+1)
+	for _, c := range categories {
+		segments = append(segments, c+":"+strconv.Itoa(m.ByCategory[c]))
+	}
+	segments = append(segments, "default:"+strconv.Itoa(m.Default))
+	return strings.Join(segments, ",")
+}
+
+// condition returns a WHERE condition on the score column, with
+// placeholders numbered from $next, and its arguments. The primary
+// category is categories[1]; NULL for an empty array, which falls
+// through to the default.
+func (m MinScores) condition(next int) (string, []any) {
+	if len(m.ByCategory) == 0 {
+		return fmt.Sprintf("score >= $%d", next), []any{m.Default}
+	}
+
+	categories := make([]string, 0, len(m.ByCategory))
+	for c := range m.ByCategory {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+
+	var b strings.Builder
+	args := make([]any, 0, 2*len(categories)+1)
+	b.WriteString("score >= CASE categories[1]")
+	for _, c := range categories {
+		fmt.Fprintf(&b, " WHEN $%d THEN $%d::int", next, next+1)
+		args = append(args, c, m.ByCategory[c])
+		next += 2
+	}
+	fmt.Fprintf(&b, " ELSE $%d::int END", next)
+	args = append(args, m.Default)
+	return b.String(), args
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMinScores(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"60", "default:60"},
+		{"cs.CL:70,default:55", "cs.CL:70,default:55"},
+		{" default:55 , cs.CL:70, math.NA:40 ", "cs.CL:70,math.NA:40,default:55"},
+		{"hep-th:80", "hep-th:80,default:0"},
+		{"cond-mat.stat-mech:65,50", "cond-mat.stat-mech:65,default:50"},
+	}
+
+	for _, tc := range tests {
+		m, err := ParseMinScores(tc.spec)
+		if err != nil {
+			t.Errorf("ParseMinScores(%q): %v", tc.spec, err)
+			continue
+		}
+		if got := m.String(); got != tc.expected {
+			t.Errorf("ParseMinScores(%q) = %s, want %s", tc.spec, got, tc.expected)
+		}
+	}
+}
+
+func TestParseMinScores_Invalid(t *testing.T) {
+	tests := []struct {
+		spec    string
+		segment string
+	}{
+		{"", ""},
+		{"cs.CL:70,,default:55", ""},
+		{"cs.CL:high", "cs.CL:high"},
+		{"cs.CL:70,default:101", "default:101"},
+		{"cs.CL:-1", "cs.CL:-1"},
+		{"cs.CL:70,cs.CL:60", "cs.CL:60"},
+		{"55,default:60", "default:60"},
+		{"CS CL:70", "CS CL:70"},
+		{":70", ":70"},
+	}
+
+	for _, tc := range tests {
+		_, err := ParseMinScores(tc.spec)
+		var specErr *MinScoreError
+		if !errors.As(err, &specErr) || !errors.Is(err, ErrInvalidMinScore) {
+			t.Errorf("ParseMinScores(%q) error = %v, want MinScoreError", tc.spec, err)
+			continue
+		}
+		if specErr.Segment != tc.segment {
+			t.Errorf("ParseMinScores(%q) segment = %q, want %q", tc.spec, specErr.Segment, tc.segment)
+		}
+	}
+}
+
+func TestMinScores_For(t *testing.T) {
+	m := MinScores{Default: 55, ByCategory: map[string]int{"cs.CL": 70}}
+
+	tests := []struct {
+		categories []string
+		expected   int
+	}{
+		{[]string{"cs.CL"}, 70},
+		{[]string{"cs.CL", "cs.AI"}, 70},
+		{[]string{"cs.AI", "cs.CL"}, 55}, // only the primary category counts
+		{nil, 55},
+	}
+
+	for _, tc := range tests {
+		if got := m.For(tc.categories); got != tc.expected {
+			t.Errorf("For(%v) = %d, want %d", tc.categories, got, tc.expected)
+		}
+	}
+}
+
+func TestMinScores_Condition(t *testing.T) {
+	cond, args := MinScores{Default: 55}.condition(3)
+	if cond != "score >= $3" || len(args) != 1 || args[0] != 55 {
+		t.Errorf("default only: %q %v", cond, args)
+	}
+
+	m := MinScores{Default: 55, ByCategory: map[string]int{"math.NA": 40, "cs.CL": 70}}
+	cond, args = m.condition(3)
+	want := "score >= CASE categories[1] WHEN $3 THEN $4::int WHEN $5 THEN $6::int ELSE $7::int END"
+	if cond != want {
+		t.Errorf("condition = %q, want %q", cond, want)
+	}
+	if len(args) != 5 || args[0] != "cs.CL" || args[1] != 70 || args[4] != 55 {
+		t.Errorf("args = %v, want categories sorted with the default last", args)
+	}
+}
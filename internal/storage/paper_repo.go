@@ -2,14 +2,21 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/similarity"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
 )
 
 // ErrNotFound is returned when a paper is not found.
@@ -17,33 +24,52 @@ var ErrNotFound = errors.New("paper not found")
 
 // PaperRepository handles paper persistence.
 type PaperRepository struct {
-	pool *pgxpool.Pool
+	pool      *pgxpool.Pool
+	ChunkSize int // Papers per round trip in SaveBatch (default: 500)
+
+	unaccentOnce sync.Once
+	unaccent     bool
 }
 
 // NewPaperRepository creates a new paper repository.
 func NewPaperRepository(pool *pgxpool.Pool) *PaperRepository {
-	return &PaperRepository{pool: pool}
+	return &PaperRepository{pool: pool, ChunkSize: DefaultChunkSize}
 }
 
-// Save inserts or updates a paper.
-func (r *PaperRepository) Save(ctx context.Context, paper model.Paper) error {
-	query := `
-		INSERT INTO papers (id, title, abstract, authors, categories, updated_at, comments, doi, journal_ref, score, score_details)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		ON CONFLICT (id) DO UPDATE SET
-			title = EXCLUDED.title,
-			abstract = EXCLUDED.abstract,
-			authors = EXCLUDED.authors,
-			categories = EXCLUDED.categories,
-			updated_at = EXCLUDED.updated_at,
-			comments = EXCLUDED.comments,
-			doi = EXCLUDED.doi,
-			journal_ref = EXCLUDED.journal_ref,
-			score = EXCLUDED.score,
-			score_details = EXCLUDED.score_details
-	`
-
-	_, err := r.pool.Exec(ctx, query,
+// upsertPaperSQL inserts a paper or updates the existing row with the same ID.
+const upsertPaperSQL = `
+	INSERT INTO papers (id, title, abstract, authors, categories, updated_at, comments, doi, journal_ref, score, score_details, authors_normalized, pages, figures, tables, abstract_truncated, source, fingerprint, search_vector, search_truncated, published_at, links, revision)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, ` + searchVectorSQL + `, $21, $22, $23, $24)
+	ON CONFLICT (id) DO UPDATE SET
+		title = EXCLUDED.title,
+		abstract = EXCLUDED.abstract,
+		authors = EXCLUDED.authors,
+		categories = EXCLUDED.categories,
+		updated_at = EXCLUDED.updated_at,
+		comments = EXCLUDED.comments,
+		doi = EXCLUDED.doi,
+		journal_ref = EXCLUDED.journal_ref,
+		score = EXCLUDED.score,
+		score_details = EXCLUDED.score_details,
+		authors_normalized = EXCLUDED.authors_normalized,
+		pages = EXCLUDED.pages,
+		figures = EXCLUDED.figures,
+		tables = EXCLUDED.tables,
+		abstract_truncated = EXCLUDED.abstract_truncated,
+		source = EXCLUDED.source,
+		fingerprint = EXCLUDED.fingerprint,
+		search_vector = EXCLUDED.search_vector,
+		search_truncated = EXCLUDED.search_truncated,
+		published_at = COALESCE(EXCLUDED.published_at, papers.published_at),
+		links = EXCLUDED.links,
+		revision = EXCLUDED.revision,
+		saved_at = NOW()
+`
+
+// upsertArgs returns the upsertPaperSQL arguments for a paper.
+func upsertArgs(paper model.Paper) []any {
+	lead, rest, cut := SearchText(paper.Abstract)
+	return []any{
 		paper.ID,
 		paper.Title,
 		paper.Abstract,
@@ -55,7 +81,43 @@ func (r *PaperRepository) Save(ctx context.Context, paper model.Paper) error {
 		paper.JournalRef,
 		paper.Score,
 		paper.ScoreDetails,
-	)
+		textutil.FoldNames(paper.Authors),
+		paper.Pages,
+		paper.Figures,
+		paper.Tables,
+		paper.AbstractTruncated,
+		paper.Source,
+		// BIGINT is signed; the bits are what matter
+		int64(similarity.Fingerprint(paper.Abstract)),
+		lead,
+		rest,
+		cut,
+		nullTime(paper.Published),
+		linksJSON(paper.Links),
+		paper.Revision,
+	}
+}
+
+// linksJSON returns links as a JSON array, empty rather than null.
+func linksJSON(links []model.Link) []byte {
+	if links == nil {
+		links = []model.Link{}
+	}
+	data, _ := json.Marshal(links)
+	return data
+}
+
+// nullTime returns t, or nil for the zero time so it is stored as NULL.
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// Save inserts or updates a paper.
+func (r *PaperRepository) Save(ctx context.Context, paper model.Paper) error {
+	_, err := r.pool.Exec(ctx, upsertPaperSQL, upsertArgs(paper)...)
 	if err != nil {
 		return fmt.Errorf("save paper: %w", err)
 	}
@@ -63,40 +125,19 @@ func (r *PaperRepository) Save(ctx context.Context, paper model.Paper) error {
 	return nil
 }
 
-// SaveBatch inserts or updates multiple papers.
+// SaveBatch inserts or updates multiple papers in chunks of ChunkSize.
+// Each chunk writes its rows in ID order, so concurrent saves of
+// overlapping papers wait for each other instead of deadlocking. If ctx
+// expires between chunks, it returns a *PartialSaveError.
 func (r *PaperRepository) SaveBatch(ctx context.Context, papers []model.Paper) error {
-	batch := &pgx.Batch{}
-
-	query := `
-		INSERT INTO papers (id, title, abstract, authors, categories, updated_at, comments, doi, journal_ref, score, score_details)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		ON CONFLICT (id) DO UPDATE SET
-			title = EXCLUDED.title,
-			abstract = EXCLUDED.abstract,
-			authors = EXCLUDED.authors,
-			categories = EXCLUDED.categories,
-			updated_at = EXCLUDED.updated_at,
-			comments = EXCLUDED.comments,
-			doi = EXCLUDED.doi,
-			journal_ref = EXCLUDED.journal_ref,
-			score = EXCLUDED.score,
-			score_details = EXCLUDED.score_details
-	`
+	return SaveChunked(ctx, papers, r.ChunkSize, r.saveChunk)
+}
 
-	for _, paper := range papers {
-		batch.Queue(query,
-			paper.ID,
-			paper.Title,
-			paper.Abstract,
-			paper.Authors,
-			paper.Categories,
-			paper.UpdatedAt,
-			paper.Comments,
-			paper.DOI,
-			paper.JournalRef,
-			paper.Score,
-			paper.ScoreDetails,
-		)
+// saveChunk writes one chunk of papers in a single round trip.
+func (r *PaperRepository) saveChunk(ctx context.Context, papers []model.Paper) error {
+	batch := &pgx.Batch{}
+	for _, paper := range lockOrder(papers) {
+		batch.Queue(upsertPaperSQL, upsertArgs(paper)...)
 	}
 
 	results := r.pool.SendBatch(ctx, batch)
@@ -111,23 +152,18 @@ func (r *PaperRepository) SaveBatch(ctx context.Context, papers []model.Paper) e
 	return nil
 }
 
-// GetByID retrieves a paper by ID.
+// GetByID retrieves a paper by ID. A versioned ID that is not stored
+// finds the paper stored under its base ID (see model.Paper.StripVersion).
 func (r *PaperRepository) GetByID(ctx context.Context, id string) (model.Paper, error) {
 	query := `
-		SELECT id, title, abstract, authors, categories, updated_at
+		SELECT ` + paperColumns + `
 		FROM papers
-		WHERE id = $1
+		WHERE id = $1 OR id = $2
+		ORDER BY id = $1 DESC
+		LIMIT 1
 	`
 
-	var paper model.Paper
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&paper.ID,
-		&paper.Title,
-		&paper.Abstract,
-		&paper.Authors,
-		&paper.Categories,
-		&paper.UpdatedAt,
-	)
+	paper, err := scanPaper(r.pool.QueryRow(ctx, query, id, paperid.Base(id)))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return model.Paper{}, ErrNotFound
@@ -141,9 +177,9 @@ func (r *PaperRepository) GetByID(ctx context.Context, id string) (model.Paper,
 // List retrieves papers with pagination.
 func (r *PaperRepository) List(ctx context.Context, limit, offset int) ([]model.Paper, error) {
 	query := `
-		SELECT id, title, abstract, authors, categories, updated_at
+		SELECT ` + paperColumns + `
 		FROM papers
-		ORDER BY updated_at DESC
+		` + newestFirst + `
 		LIMIT $1 OFFSET $2
 	`
 
@@ -151,35 +187,146 @@ func (r *PaperRepository) List(ctx context.Context, limit, offset int) ([]model.
 	if err != nil {
 		return nil, fmt.Errorf("list papers: %w", err)
 	}
+
+	return scanPapers(rows)
+}
+
+// ListMinScore returns papers scoring at least the threshold for their
+// primary category, newest first.
+func (r *PaperRepository) ListMinScore(ctx context.Context, min MinScores, limit, offset int) ([]model.Paper, error) {
+	cond, args := min.condition(3)
+	query := `
+		SELECT ` + paperColumns + `
+		FROM papers
+		WHERE ` + cond + `
+		` + newestFirst + `
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, append([]any{limit, offset}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("list papers by min score: %w", err)
+	}
+
+	return scanPapers(rows)
+}
+
+// ListAfter returns up to limit papers ordered after the cursor.
+func (r *PaperRepository) ListAfter(ctx context.Context, after Cursor, limit int) ([]model.Paper, error) {
+	query := `
+		SELECT ` + paperColumns + `
+		FROM papers
+		WHERE (updated_at, id) < ($1, $2)
+		` + newestFirst + `
+		LIMIT $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, after.UpdatedAt, after.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list papers after cursor: %w", err)
+	}
+
+	return scanPapers(rows)
+}
+
+// StreamPapers calls fn for each paper after the cursor, oldest first with
+// ties broken by ID. Rows are read as fn consumes them.
+func (r *PaperRepository) StreamPapers(ctx context.Context, after Cursor, fn func(model.Paper) error) error {
+	where, args := "", []any{}
+	if !after.IsZero() {
+		where, args = "WHERE (updated_at, id) > ($1, $2)", []any{after.UpdatedAt, after.ID}
+	}
+	query := `
+		SELECT ` + paperColumns + `
+		FROM papers
+		` + where + `
+		ORDER BY updated_at, id
+	`
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("stream papers: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		paper, err := scanPaper(rows)
+		if err != nil {
+			return fmt.Errorf("scan paper: %w", err)
+		}
+		if err := fn(paper); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("stream papers: %w", err)
+	}
+	return nil
+}
+
+// paperColumns is the select list read by scanPaper.
+const paperColumns = `id, title, abstract, authors, categories, updated_at,
+		COALESCE(comments, ''), COALESCE(doi, ''), COALESCE(journal_ref, ''),
+		COALESCE(score, 0), COALESCE(score_details, '{}'),
+		COALESCE(pages, 0), COALESCE(figures, 0), COALESCE(tables, 0),
+		COALESCE(abstract_truncated, FALSE), COALESCE(source, ''), published_at,
+		COALESCE(links, '[]'), COALESCE(revision, 0)`
+
+// scanPaper reads one row selected with paperColumns.
+func scanPaper(row pgx.Row) (model.Paper, error) {
+	var paper model.Paper
+	var published *time.Time
+	err := row.Scan(
+		&paper.ID,
+		&paper.Title,
+		&paper.Abstract,
+		&paper.Authors,
+		&paper.Categories,
+		&paper.UpdatedAt,
+		&paper.Comments,
+		&paper.DOI,
+		&paper.JournalRef,
+		&paper.Score,
+		&paper.ScoreDetails,
+		&paper.Pages,
+		&paper.Figures,
+		&paper.Tables,
+		&paper.AbstractTruncated,
+		&paper.Source,
+		&published,
+		&paper.Links,
+		&paper.Revision,
+	)
+	if published != nil {
+		paper.Published = *published
+	}
+	// Categories are stored primary first
+	if len(paper.Categories) > 0 {
+		paper.PrimaryCategory = paper.Categories[0]
+	}
+	return paper, err
+}
+
+// scanPapers reads rows selected with paperColumns.
+func scanPapers(rows pgx.Rows) ([]model.Paper, error) {
 	defer rows.Close()
 
 	var papers []model.Paper
 	for rows.Next() {
-		var paper model.Paper
-		if err := rows.Scan(
-			&paper.ID,
-			&paper.Title,
-			&paper.Abstract,
-			&paper.Authors,
-			&paper.Categories,
-			&paper.UpdatedAt,
-		); err != nil {
+		paper, err := scanPaper(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan paper: %w", err)
 		}
 		papers = append(papers, paper)
 	}
 
-	return papers, nil
+	return papers, rows.Err()
 }
 
-// Count returns the total number of papers.
-func (r *PaperRepository) Count(ctx context.Context) (int64, error) {
-	var count int64
-	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM papers").Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("count papers: %w", err)
-	}
-	return count, nil
+// Count returns the total number of papers. With exact=false, large tables
+// are counted from planner statistics when those are fresh.
+func (r *PaperRepository) Count(ctx context.Context, exact bool) (int64, error) {
+	return resolveCount(ctx, r, exact)
 }
 
 // Delete removes a paper by ID.
@@ -196,40 +343,100 @@ func (r *PaperRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-// Search searches papers by title or abstract.
+// Search finds papers by full-text search over the title and the indexed
+// prefix of the abstract (see SearchText), or by a title containing query.
+// Title matches rank first, then matches in the abstract's lead.
 func (r *PaperRepository) Search(ctx context.Context, query string, limit int) ([]model.Paper, error) {
 	sqlQuery := `
-		SELECT id, title, abstract, authors, categories, updated_at
+		SELECT ` + paperColumns + `
+		FROM papers, websearch_to_tsquery('english', $1) q
+		WHERE search_vector @@ q OR title ILIKE $2
+		ORDER BY ts_rank(search_vector, q) DESC, updated_at DESC, id DESC
+		LIMIT $3
+	`
+
+	rows, err := r.pool.Query(ctx, sqlQuery, query, containsPattern(query), limit)
+	if err != nil {
+		return nil, fmt.Errorf("search papers: %w", err)
+	}
+
+	return scanPapers(rows)
+}
+
+// ListByAuthor returns papers with an author whose name contains name,
+// ignoring case and diacritics ("jose" finds "José", "lecun" finds "LeCun").
+func (r *PaperRepository) ListByAuthor(ctx context.Context, name string, limit, offset int) ([]model.Paper, error) {
+	sqlQuery := `
+		SELECT ` + paperColumns + `
+		FROM papers
+		WHERE ` + authorMatchSQL(r.hasUnaccent(ctx)) + `
+		` + newestFirst + `
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.pool.Query(ctx, sqlQuery, containsPattern(textutil.FoldName(name)), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list papers by author: %w", err)
+	}
+
+	return scanPapers(rows)
+}
+
+// ListStoredBefore returns up to limit papers first saved before cutoff,
+// oldest first.
+func (r *PaperRepository) ListStoredBefore(ctx context.Context, cutoff time.Time, limit int) ([]model.Paper, error) {
+	query := `
+		SELECT ` + paperColumns + `
 		FROM papers
-		WHERE title ILIKE $1 OR abstract ILIKE $1
-		ORDER BY updated_at DESC
+		WHERE created_at < $1
+		ORDER BY created_at, id
 		LIMIT $2
 	`
 
-	searchPattern := "%" + query + "%"
-	rows, err := r.pool.Query(ctx, sqlQuery, searchPattern, limit)
+	rows, err := r.pool.Query(ctx, query, cutoff, limit)
 	if err != nil {
-		return nil, fmt.Errorf("search papers: %w", err)
+		return nil, fmt.Errorf("list stored papers: %w", err)
 	}
-	defer rows.Close()
 
-	var papers []model.Paper
-	for rows.Next() {
-		var paper model.Paper
-		if err := rows.Scan(
-			&paper.ID,
-			&paper.Title,
-			&paper.Abstract,
-			&paper.Authors,
-			&paper.Categories,
-			&paper.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("scan paper: %w", err)
-		}
-		papers = append(papers, paper)
+	return scanPapers(rows)
+}
+
+// authorMatchSQL matches $1 against the folded authors column, and against
+// the raw authors for rows saved before that column existed. Without the
+// unaccent extension the raw fallback only folds case.
+func authorMatchSQL(unaccent bool) string {
+	return authorMatchOn("$1", unaccent)
+}
+
+// authorMatchOn is authorMatchSQL for the LIKE pattern pattern, e.g. a
+// column of an unnested pattern array.
+func authorMatchOn(pattern string, unaccent bool) string {
+	fold := "lower(a)"
+	if unaccent {
+		fold = "lower(unaccent(a))"
 	}
+	return `(EXISTS (SELECT 1 FROM unnest(authors_normalized) n WHERE n LIKE ` + pattern + `)
+		OR EXISTS (SELECT 1 FROM unnest(authors) a WHERE ` + fold + ` LIKE ` + pattern + `))`
+}
 
-	return papers, nil
+// containsPattern escapes LIKE wildcards in s and wraps it in %...%.
+func containsPattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+// hasUnaccent reports whether the unaccent extension is installed.
+// The lookup runs once per repository.
+func (r *PaperRepository) hasUnaccent(ctx context.Context) bool {
+	r.unaccentOnce.Do(func() {
+		err := r.pool.QueryRow(ctx,
+			"SELECT EXISTS(SELECT 1 FROM pg_extension WHERE extname = 'unaccent')",
+		).Scan(&r.unaccent)
+		if err != nil {
+			r.unaccent = false
+		}
+	})
+	return r.unaccent
 }
 
 // GetLatestUpdateTime returns the most recent paper update time.
@@ -245,6 +452,20 @@ func (r *PaperRepository) GetLatestUpdateTime(ctx context.Context) (time.Time, e
 	return latest, nil
 }
 
+// DataVersion returns when a paper was last saved and how many are stored.
+// Both are answered from indexes (idx_papers_saved_at and the primary key).
+func (r *PaperRepository) DataVersion(ctx context.Context) (DataVersion, error) {
+	var t *time.Time
+	var v DataVersion
+	if err := r.pool.QueryRow(ctx, "SELECT MAX(saved_at), COUNT(*) FROM papers").Scan(&t, &v.Count); err != nil {
+		return DataVersion{}, fmt.Errorf("data version: %w", err)
+	}
+	if t != nil {
+		v.Modified = *t
+	}
+	return v, nil
+}
+
 // Exists checks if a paper with the given ID exists.
 func (r *PaperRepository) Exists(ctx context.Context, id string) (bool, error) {
 	var exists bool
@@ -255,23 +476,88 @@ func (r *PaperRepository) Exists(ctx context.Context, id string) (bool, error) {
 	return exists, nil
 }
 
-// SaveBatchWithStats saves papers and returns new/updated counts.
-func (r *PaperRepository) SaveBatchWithStats(ctx context.Context, papers []model.Paper) (newCount, updatedCount int, err error) {
+// lockOrder returns papers sorted by ID, the order every chunk locks its
+// rows in: two transactions taking the same locks in the same order cannot
+// deadlock. The sort is stable, so of two papers with one ID the later is
+// still saved last.
+func lockOrder(papers []model.Paper) []model.Paper {
+	sorted := slices.Clone(papers)
+	slices.SortStableFunc(sorted, func(a, b model.Paper) int { return strings.Compare(a.ID, b.ID) })
+	return sorted
+}
+
+// upsertCountingSQL is upsertPaperSQL reporting whether the row was
+// inserted: xmax is zero only on a row version created by INSERT.
+const upsertCountingSQL = upsertPaperSQL + `	RETURNING (xmax = 0)`
+
+// storedRevisionSQL locks the row of a paper, if there is one, and reads
+// the revision upsertCountingSQL is about to replace.
+const storedRevisionSQL = `SELECT revision FROM papers WHERE id = $1 FOR UPDATE`
+
+// SaveBatchInserted saves papers like SaveBatch and returns the IDs whose
+// rows it inserted rather than updated, and apart from them those it
+// updated to a higher revision.
+//
+// The upsert itself reports the insert, and the stored revision is read
+// under the row lock, so the decision is made under the lock on the ID:
+// when several syncs save the same paper or revision concurrently,
+// exactly one of them reports it. Rows are locked in ID order within each
+// chunk, as in SaveBatch, so such syncs never deadlock. On a partial save
+// only committed chunks are reported.
+func (r *PaperRepository) SaveBatchInserted(ctx context.Context, papers []model.Paper) (inserted, revised map[string]bool, err error) {
+	inserted, revised = make(map[string]bool), make(map[string]bool)
+	err = SaveChunked(ctx, papers, r.ChunkSize, func(ctx context.Context, chunk []model.Paper) error {
+		return r.saveChunkCounting(ctx, chunk, inserted, revised)
+	})
+	return inserted, revised, err
+}
+
+// saveChunkCounting writes one chunk in a single round trip and, once it
+// has committed, adds the IDs it inserted rather than updated to inserted
+// and those whose revision it raised to revised.
+func (r *PaperRepository) saveChunkCounting(ctx context.Context, papers []model.Paper, inserted, revised map[string]bool) error {
+	papers = lockOrder(papers)
+	batch := &pgx.Batch{}
 	for _, paper := range papers {
-		exists, err := r.Exists(ctx, paper.ID)
-		if err != nil {
-			return 0, 0, err
-		}
+		batch.Queue(storedRevisionSQL, paper.ID)
+		batch.Queue(upsertCountingSQL, upsertArgs(paper)...)
+	}
 
-		if err := r.Save(ctx, paper); err != nil {
-			return 0, 0, err
+	results := r.pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	var added, raised []string
+	for _, paper := range papers {
+		var stored int
+		err := results.QueryRow().Scan(&stored)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("batch save: %w", err)
 		}
+		bumped := err == nil && paper.Revision > stored
 
-		if exists {
-			updatedCount++
-		} else {
-			newCount++
+		var isNew bool
+		if err := results.QueryRow().Scan(&isNew); err != nil {
+			return fmt.Errorf("batch save: %w", err)
+		}
+		switch {
+		case isNew:
+			added = append(added, paper.ID)
+		case bumped:
+			raised = append(raised, paper.ID)
 		}
 	}
-	return newCount, updatedCount, nil
+
+	// The batch runs as one implicit transaction, so the inserts only
+	// stand once it has committed
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("batch save: %w", err)
+	}
+
+	for _, id := range added {
+		inserted[id] = true
+	}
+	for _, id := range raised {
+		revised[id] = true
+	}
+	return nil
 }
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// ListUnarchived returns papers scoring at least minScore that have no local
+// PDF yet, highest score first.
+func (r *PaperRepository) ListUnarchived(ctx context.Context, minScore, limit int) ([]model.Paper, error) {
+	query := `
+		SELECT ` + paperColumns + `
+		FROM papers
+		WHERE score >= $1 AND COALESCE(pdf_path, '') = ''
+		ORDER BY score DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, minScore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list unarchived papers: %w", err)
+	}
+
+	return scanPapers(rows)
+}
+
+// SetPDF records the local PDF file of a paper.
+func (r *PaperRepository) SetPDF(ctx context.Context, id, path string, size int64) error {
+	result, err := r.pool.Exec(ctx,
+		"UPDATE papers SET pdf_path = $2, pdf_size = $3 WHERE id = $1",
+		id, path, size,
+	)
+	if err != nil {
+		return fmt.Errorf("set pdf: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetPDF returns the local PDF path and size of a paper. It returns
+// ErrNotFound if the paper does not exist or has no local PDF.
+func (r *PaperRepository) GetPDF(ctx context.Context, id string) (string, int64, error) {
+	var path string
+	var size int64
+	err := r.pool.QueryRow(ctx,
+		"SELECT COALESCE(pdf_path, ''), COALESCE(pdf_size, 0) FROM papers WHERE id = $1",
+		id,
+	).Scan(&path, &size)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", 0, ErrNotFound
+		}
+		return "", 0, fmt.Errorf("get pdf: %w", err)
+	}
+	if path == "" {
+		return "", 0, ErrNotFound
+	}
+	return path, size, nil
+}
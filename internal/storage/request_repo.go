@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SyncRequest is one HTTP request a sync made to a provider.
+type SyncRequest struct {
+	SyncID     int       `json:"sync_id"`
+	URL        string    `json:"url"`    // Credentials redacted
+	Status     int       `json:"status"` // 0 when no response arrived
+	Bytes      int64     `json:"bytes"`
+	DurationMs int64     `json:"duration_ms"`
+	Retries    int       `json:"retries"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// RecordSyncRequests stores the HTTP requests made by sync id.
+func (r *SyncRepository) RecordSyncRequests(ctx context.Context, id int, requests []SyncRequest) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, req := range requests {
+		batch.Queue(`
+			INSERT INTO sync_requests
+				(sync_id, url, status, bytes, duration_ms, retries, error, started_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, id, req.URL, req.Status, req.Bytes, req.DurationMs, req.Retries, req.Error, req.StartedAt)
+	}
+
+	br := r.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for range requests {
+		if _, err := br.Exec(); err != nil {
+			return fmt.Errorf("record sync request: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListSyncRequests returns the requests recorded for sync id in the order
+// they were made.
+func (r *SyncRepository) ListSyncRequests(ctx context.Context, id int) ([]SyncRequest, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT sync_id, url, status, bytes, duration_ms, retries, error, started_at
+		FROM sync_requests
+		WHERE sync_id = $1
+		ORDER BY started_at, id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("list sync requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []SyncRequest{}
+	for rows.Next() {
+		var req SyncRequest
+		if err := rows.Scan(
+			&req.SyncID, &req.URL, &req.Status, &req.Bytes, &req.DurationMs,
+			&req.Retries, &req.Error, &req.StartedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan sync request: %w", err)
+		}
+		requests = append(requests, req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list sync requests: %w", err)
+	}
+	return requests, nil
+}
+
+// PruneSyncRequests deletes requests started before cutoff and returns how
+// many were removed.
+func (r *SyncRepository) PruneSyncRequests(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM sync_requests WHERE started_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("prune sync requests: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
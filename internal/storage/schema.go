@@ -7,6 +7,8 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// createTableSQL is the baseline migration. It stays as released; later
+// schema changes are appended to Migrations.
 const createTableSQL = `
 CREATE TABLE IF NOT EXISTS papers (
     id VARCHAR(50) PRIMARY KEY,
@@ -40,13 +42,231 @@ ALTER TABLE papers ADD COLUMN IF NOT EXISTS score INT DEFAULT 0;
 ALTER TABLE papers ADD COLUMN IF NOT EXISTS score_details TEXT[] DEFAULT '{}';
 
 CREATE INDEX IF NOT EXISTS idx_papers_score ON papers(score DESC);
+
+-- Per-stage durations in milliseconds, e.g. {"fetch": 4200, "save": 310}
+ALTER TABLE sync_log ADD COLUMN IF NOT EXISTS timings JSONB;
+
+-- Keyset pagination in newest-first order
+CREATE INDEX IF NOT EXISTS idx_papers_updated_at_id ON papers(updated_at DESC, id DESC);
+
+-- Case- and accent-folded author names, maintained on save
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS authors_normalized TEXT[] DEFAULT '{}';
+
+-- Locally archived PDF (pipeline download)
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS pdf_path TEXT DEFAULT '';
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS pdf_size BIGINT DEFAULT 0;
+
+-- Last write per row; MAX(saved_at) and the row count version responses for ETags
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS saved_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS idx_papers_saved_at ON papers(saved_at);
+
+-- arXiv revisions seen during sync
+CREATE INDEX IF NOT EXISTS idx_papers_base_id ON papers ((regexp_replace(id, 'v[0-9]+$', '')));
+
+CREATE TABLE IF NOT EXISTS paper_versions (
+    id SERIAL PRIMARY KEY,
+    base_id VARCHAR(50) NOT NULL,
+    old_version INT NOT NULL,
+    new_version INT NOT NULL,
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    content_changed BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (base_id, new_version)
+);
+
+-- Abstracts on either side of a version update (GET /api/papers/:id/diff)
+ALTER TABLE paper_versions ADD COLUMN IF NOT EXISTS old_abstract TEXT DEFAULT '';
+ALTER TABLE paper_versions ADD COLUMN IF NOT EXISTS new_abstract TEXT DEFAULT '';
+
+-- Papers a candidate filter rule set judged differently (shadow mode)
+CREATE TABLE IF NOT EXISTS rule_shadow_results (
+    id SERIAL PRIMARY KEY,
+    sync_id INT REFERENCES sync_log(id) ON DELETE CASCADE,
+    rule_set TEXT NOT NULL,
+    paper_id VARCHAR(50) NOT NULL,
+    active_score INT NOT NULL,
+    candidate_score INT NOT NULL,
+    active_passed BOOLEAN NOT NULL,
+    candidate_passed BOOLEAN NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rule_shadow_results_rule_set ON rule_shadow_results(rule_set, id DESC);
+
+-- HTTP requests made by each sync, kept for SYNC_REQUEST_RETENTION_DAYS
+CREATE TABLE IF NOT EXISTS sync_requests (
+    id SERIAL PRIMARY KEY,
+    sync_id INT NOT NULL REFERENCES sync_log(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    status INT NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    retries INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_requests_sync_id ON sync_requests(sync_id);
+CREATE INDEX IF NOT EXISTS idx_sync_requests_started_at ON sync_requests(started_at);
+
+-- Extent parsed from comments, e.g. "38 pages, 12 figures, 5 tables"
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS pages INT DEFAULT 0;
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS figures INT DEFAULT 0;
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS tables INT DEFAULT 0;
+
+-- Abstract cut to the sync's length limit before saving
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS abstract_truncated BOOLEAN DEFAULT FALSE;
+
+-- Provider that produced the record (arxiv, arxiv-rss)
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS source VARCHAR(20) DEFAULT '';
+
+-- Local activity trail of mutating operations (never sent anywhere)
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    target TEXT NOT NULL DEFAULT '',
+    params TEXT NOT NULL DEFAULT '',
+    status INT NOT NULL DEFAULT 0,
+    at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_at ON audit_log(at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action_at ON audit_log(action, at DESC);
+
+-- Base ID -> score of every paper a sync's query returned, compared by -diff-last
+ALTER TABLE sync_log ADD COLUMN IF NOT EXISTS results JSONB;
+CREATE INDEX IF NOT EXISTS idx_sync_log_query_completed ON sync_log(query, completed_at DESC);
+
+-- Room for file imports, e.g. "file:reviewer-dump-2024-03.jsonl"
+ALTER TABLE papers ALTER COLUMN source TYPE VARCHAR(100);
+
+-- SimHash of the normalized abstract, set on save (0 = not computed yet)
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS fingerprint BIGINT NOT NULL DEFAULT 0;
+
+-- Build that ran the sync, e.g. "v1.4.0 (abc1234)"
+ALTER TABLE sync_log ADD COLUMN IF NOT EXISTS version VARCHAR(100);
+
+-- Full-text index over the title and a bounded prefix of the abstract
+-- (see SearchText); search_truncated marks papers whose abstract was cut
+-- for it. Rows saved before get a plain prefix until they are saved again.
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS search_vector tsvector;
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS search_truncated BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE papers
+SET search_vector = setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+                    setweight(to_tsvector('english', left(COALESCE(abstract, ''), 8192)), 'B'),
+    search_truncated = length(COALESCE(abstract, '')) > 8192
+WHERE search_vector IS NULL;
+CREATE INDEX IF NOT EXISTS idx_papers_search ON papers USING GIN(search_vector);
+
+-- First version's submission time, telling new papers from revisions (NULL = not reported)
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+
+-- Last-updated window an incremental sync fetched (NULL = full fetch)
+ALTER TABLE sync_log ADD COLUMN IF NOT EXISTS window_from TIMESTAMPTZ;
+ALTER TABLE sync_log ADD COLUMN IF NOT EXISTS window_to TIMESTAMPTZ;
+
+-- Defaults changed at runtime over PUT /api/settings, over the configuration
+CREATE TABLE IF NOT EXISTS settings (
+    key VARCHAR(100) PRIMARY KEY,
+    value JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    updated_by TEXT NOT NULL DEFAULT ''
+);
+
+-- Related links: the source's own and those written in the abstract or comments
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS links JSONB NOT NULL DEFAULT '[]';
+
+-- Version of a paper stored under its base ID (0 = the ID carries it)
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS revision INT NOT NULL DEFAULT 0;
 `
 
-// Migrate runs database migrations.
-func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
-	_, err := pool.Exec(ctx, createTableSQL)
+// createUnaccentSQL enables accent-insensitive matching for rows saved
+// before authors_normalized existed. It needs extension privileges, so
+// failure is tolerated.
+const createUnaccentSQL = `CREATE EXTENSION IF NOT EXISTS unaccent`
+
+// expectedColumns lists the columns Migrations leave in place.
+var expectedColumns = map[string][]string{
+	"papers": {
+		"id", "title", "abstract", "authors", "categories", "updated_at", "created_at",
+		"comments", "doi", "journal_ref", "score", "score_details", "authors_normalized", "pdf_path", "pdf_size",
+		"saved_at", "pages", "figures", "tables",
+		"abstract_truncated", "source", "fingerprint", "search_vector", "search_truncated",
+		"published_at", "links", "revision",
+	},
+	"sync_log": {
+		"id", "query", "papers_fetched", "papers_new", "papers_updated",
+		"started_at", "completed_at", "status", "timings", "results", "version",
+		"window_from", "window_to", "provider", "scope",
+	},
+	"paper_versions": {
+		"id", "base_id", "old_version", "new_version", "detected_at", "content_changed",
+		"old_abstract", "new_abstract",
+	},
+	"rule_shadow_results": {
+		"id", "sync_id", "rule_set", "paper_id", "active_score", "candidate_score",
+		"active_passed", "candidate_passed", "recorded_at",
+	},
+	"sync_requests": {
+		"id", "sync_id", "url", "status", "bytes", "duration_ms", "retries", "error", "started_at",
+	},
+	"audit_log": {
+		"id", "actor", "action", "target", "params", "status", "at",
+	},
+	"settings": {
+		"key", "value", "updated_at", "updated_by",
+	},
+	"maintenance_tasks": {
+		"name", "completed_at",
+	},
+	"watchlists": {
+		"id", "name", "authors", "title_terms", "feed_key", "created_at",
+	},
+}
+
+// PendingMigrations reports the table columns Migrate would still create.
+func PendingMigrations(ctx context.Context, pool *pgxpool.Pool) ([]string, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT table_name, column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()
+	`)
 	if err != nil {
-		return fmt.Errorf("execute migration: %w", err)
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+	defer rows.Close()
+
+	present := make(map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, fmt.Errorf("scan column: %w", err)
+		}
+		present[table+"."+column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+
+	var pending []string
+	for _, table := range []string{"papers", "sync_log", "paper_versions", "rule_shadow_results", "sync_requests", "audit_log", "settings", "maintenance_tasks", "watchlists"} {
+		for _, column := range expectedColumns[table] {
+			if !present[table+"."+column] {
+				pending = append(pending, table+"."+column)
+			}
+		}
 	}
+	return pending, nil
+}
+
+// Migrate applies the pending Migrations.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	if _, err := ApplyMigrations(ctx, NewMigrationTracker(pool), Migrations); err != nil {
+		return err
+	}
+
+	// Optional: author matching falls back to case folding without it
+	_, _ = pool.Exec(ctx, createUnaccentSQL)
+
 	return nil
 }
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Limits on the abstract text fed into the full-text index. Postgres caps
+// a tsvector at 1 MB and word positions at 16383, so indexing a
+// pathological abstract in full would fail the save. Only the index is
+// cut; the stored abstract is kept whole.
+const (
+	SearchLeadWords = 100      // Leading abstract words weighted just below the title
+	SearchMaxBytes  = 32 << 10 // Abstract bytes indexed at all, cut at a word boundary
+)
+
+// searchVectorSQL builds the index from the title ($2), the abstract's
+// lead ($19) and the rest of its indexed prefix ($20).
+const searchVectorSQL = `setweight(to_tsvector('english', $2), 'A') ||
+		setweight(to_tsvector('english', $19), 'B') ||
+		setweight(to_tsvector('english', $20), 'C')`
+
+// SearchText returns the part of abstract that is indexed for search: its
+// first SearchLeadWords words and the words after them, up to
+// SearchMaxBytes in all. truncated reports whether words were left out.
+func SearchText(abstract string) (lead, rest string, truncated bool) {
+	words := strings.Fields(abstract)
+	size, n := 0, 0
+	for _, w := range words {
+		if size+len(w)+1 > SearchMaxBytes {
+			break
+		}
+		size += len(w) + 1
+		n++
+	}
+	split := min(n, SearchLeadWords)
+	return strings.Join(words[:split], " "), strings.Join(words[split:n], " "), n < len(words)
+}
+
+// CountSearchTruncated returns the number of papers whose abstract was
+// cut to fit the search index.
+func (r *PaperRepository) CountSearchTruncated(ctx context.Context) (int, error) {
+	var n int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM papers WHERE search_truncated`).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count truncated search index entries: %w", err)
+	}
+	return n, nil
+}
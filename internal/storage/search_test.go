@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearchText(t *testing.T) {
+	words := func(n int) string { return strings.TrimSpace(strings.Repeat("word ", n)) }
+	tests := []struct {
+		name      string
+		abstract  string
+		leadWords int
+		restWords int
+		truncated bool
+	}{
+		{"short", "A short  abstract.", 3, 0, false},
+		{"past the lead", words(150), SearchLeadWords, 50, false},
+		{"past the budget", words(20000), SearchLeadWords, SearchMaxBytes/5 - SearchLeadWords, true},
+		{"one giant word", strings.Repeat("x", SearchMaxBytes+1), 0, 0, true},
+		{"empty", "", 0, 0, false},
+	}
+
+	for _, tc := range tests {
+		lead, rest, truncated := SearchText(tc.abstract)
+		if n := len(strings.Fields(lead)); n != tc.leadWords {
+			t.Errorf("%s: lead has %d words, want %d", tc.name, n, tc.leadWords)
+		}
+		if n := len(strings.Fields(rest)); n != tc.restWords {
+			t.Errorf("%s: rest has %d words, want %d", tc.name, n, tc.restWords)
+		}
+		if truncated != tc.truncated {
+			t.Errorf("%s: truncated = %t, want %t", tc.name, truncated, tc.truncated)
+		}
+		if len(lead)+len(rest) > SearchMaxBytes {
+			t.Errorf("%s: indexed %d bytes, over the budget", tc.name, len(lead)+len(rest))
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Setting is one runtime setting as stored (see package settings).
+type Setting struct {
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	UpdatedBy string          `json:"updated_by"`
+}
+
+// SettingsRepository handles runtime settings persistence.
+type SettingsRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSettingsRepository creates a new settings repository.
+func NewSettingsRepository(pool *pgxpool.Pool) *SettingsRepository {
+	return &SettingsRepository{pool: pool}
+}
+
+// ListSettings returns every stored setting, by key.
+func (r *SettingsRepository) ListSettings(ctx context.Context) ([]Setting, error) {
+	rows, err := r.pool.Query(ctx, `SELECT key, value, updated_at, updated_by FROM settings ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("list settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := []Setting{}
+	for rows.Next() {
+		var s Setting
+		if err := rows.Scan(&s.Key, &s.Value, &s.UpdatedAt, &s.UpdatedBy); err != nil {
+			return nil, fmt.Errorf("scan setting: %w", err)
+		}
+		settings = append(settings, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list settings: %w", err)
+	}
+	return settings, nil
+}
+
+// PutSettings inserts or replaces settings. The batch runs as one
+// implicit transaction, so either every setting is stored or none is.
+func (r *SettingsRepository) PutSettings(ctx context.Context, settings []Setting) error {
+	if len(settings) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, s := range settings {
+		batch.Queue(`
+			INSERT INTO settings (key, value, updated_at, updated_by)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (key) DO UPDATE
+			SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at, updated_by = EXCLUDED.updated_by
+		`, s.Key, []byte(s.Value), s.UpdatedAt, s.UpdatedBy)
+	}
+
+	br := r.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for _, s := range settings {
+		if _, err := br.Exec(); err != nil {
+			return fmt.Errorf("put setting %s: %w", s.Key, err)
+		}
+	}
+	return nil
+}
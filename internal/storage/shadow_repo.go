@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ShadowResult is a paper a candidate rule set judged differently from the
+// active rules during a sync.
+type ShadowResult struct {
+	SyncID          int       `json:"sync_id,omitempty"` // 0 when the sync was not logged
+	RuleSet         string    `json:"rule_set"`
+	PaperID         string    `json:"paper_id"`
+	ActiveScore     int       `json:"active_score"`
+	CandidateScore  int       `json:"candidate_score"`
+	ActivePassed    bool      `json:"active_passed"`
+	CandidatePassed bool      `json:"candidate_passed"`
+	RecordedAt      time.Time `json:"recorded_at"`
+}
+
+// ShadowReport summarises the divergences recorded for a rule set.
+type ShadowReport struct {
+	RuleSet     string         `json:"rule_set"`
+	Syncs       int            `json:"syncs"`       // Syncs with at least one divergence
+	Divergences int            `json:"divergences"` // All recorded divergences
+	ToPass      int            `json:"to_pass"`     // Rejected by the active rules, passed by the candidate
+	ToFail      int            `json:"to_fail"`     // Passed by the active rules, rejected by the candidate
+	ScoreMoved  int            `json:"score_moved"` // Same outcome, but the score moved beyond the allowed delta
+	Recent      []ShadowResult `json:"recent"`
+}
+
+// ShadowRepository handles shadow evaluation persistence.
+type ShadowRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewShadowRepository creates a new shadow result repository.
+func NewShadowRepository(pool *pgxpool.Pool) *ShadowRepository {
+	return &ShadowRepository{pool: pool}
+}
+
+// RecordShadowResults stores the divergences found in one sync.
+func (r *ShadowRepository) RecordShadowResults(ctx context.Context, results []ShadowResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, res := range results {
+		batch.Queue(`
+			INSERT INTO rule_shadow_results
+				(sync_id, rule_set, paper_id, active_score, candidate_score, active_passed, candidate_passed, recorded_at)
+			VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8)
+		`, res.SyncID, res.RuleSet, res.PaperID, res.ActiveScore, res.CandidateScore,
+			res.ActivePassed, res.CandidatePassed, res.RecordedAt)
+	}
+
+	br := r.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for range results {
+		if _, err := br.Exec(); err != nil {
+			return fmt.Errorf("record shadow result: %w", err)
+		}
+	}
+	return nil
+}
+
+// ShadowReport summarises the divergences of ruleSet, or of the most
+// recently recorded rule set when ruleSet is empty, with up to limit of
+// the latest ones.
+func (r *ShadowRepository) ShadowReport(ctx context.Context, ruleSet string, limit int) (ShadowReport, error) {
+	if ruleSet == "" {
+		err := r.pool.QueryRow(ctx, `
+			SELECT rule_set FROM rule_shadow_results ORDER BY id DESC LIMIT 1
+		`).Scan(&ruleSet)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ShadowReport{}, ErrNotFound
+		}
+		if err != nil {
+			return ShadowReport{}, fmt.Errorf("find latest rule set: %w", err)
+		}
+	}
+
+	report := ShadowReport{RuleSet: ruleSet, Recent: []ShadowResult{}}
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT sync_id),
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE candidate_passed AND NOT active_passed),
+		       COUNT(*) FILTER (WHERE active_passed AND NOT candidate_passed),
+		       COUNT(*) FILTER (WHERE active_passed = candidate_passed)
+		FROM rule_shadow_results
+		WHERE rule_set = $1
+	`, ruleSet).Scan(&report.Syncs, &report.Divergences, &report.ToPass, &report.ToFail, &report.ScoreMoved)
+	if err != nil {
+		return ShadowReport{}, fmt.Errorf("summarise shadow results: %w", err)
+	}
+	if report.Divergences == 0 {
+		return ShadowReport{}, ErrNotFound
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT COALESCE(sync_id, 0), rule_set, paper_id, active_score, candidate_score,
+		       active_passed, candidate_passed, recorded_at
+		FROM rule_shadow_results
+		WHERE rule_set = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, ruleSet, limit)
+	if err != nil {
+		return ShadowReport{}, fmt.Errorf("list shadow results: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res ShadowResult
+		if err := rows.Scan(
+			&res.SyncID, &res.RuleSet, &res.PaperID, &res.ActiveScore, &res.CandidateScore,
+			&res.ActivePassed, &res.CandidatePassed, &res.RecordedAt,
+		); err != nil {
+			return ShadowReport{}, fmt.Errorf("scan shadow result: %w", err)
+		}
+		report.Recent = append(report.Recent, res)
+	}
+	if err := rows.Err(); err != nil {
+		return ShadowReport{}, fmt.Errorf("list shadow results: %w", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/paperid"
+)
+
+// maxNearDuplicates caps the papers a near-duplicate query returns.
+const maxNearDuplicates = 20
+
+// fingerprintDistanceSQL counts the bits in which a row's fingerprint
+// differs from $1.
+const fingerprintDistanceSQL = `length(replace((fingerprint # $1)::bit(64)::text, '0', ''))`
+
+// FindNearDuplicates returns stored papers with another base ID whose
+// abstracts are within maxDistance bits of paper id's, nearest first, or
+// ErrNotFound if id is not stored.
+func (r *PaperRepository) FindNearDuplicates(ctx context.Context, id string, maxDistance int) ([]model.Paper, error) {
+	var fp int64
+	err := r.pool.QueryRow(ctx, "SELECT fingerprint FROM papers WHERE id = $1", id).Scan(&fp)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get fingerprint: %w", err)
+	}
+	return r.FindNearFingerprint(ctx, paperid.Base(id), uint64(fp), maxDistance)
+}
+
+// FindNearFingerprint returns stored papers other than versions of baseID
+// whose fingerprints are within maxDistance bits of fp, nearest first.
+// Rows saved before fingerprints existed are never returned.
+func (r *PaperRepository) FindNearFingerprint(ctx context.Context, baseID string, fp uint64, maxDistance int) ([]model.Paper, error) {
+	if fp == 0 {
+		return nil, nil
+	}
+	query := `
+		SELECT ` + paperColumns + `
+		FROM papers
+		WHERE fingerprint <> 0
+			AND ` + baseIDExpr + ` <> $2
+			AND ` + fingerprintDistanceSQL + ` <= $3
+		ORDER BY ` + fingerprintDistanceSQL + `, id
+		LIMIT $4
+	`
+	rows, err := r.pool.Query(ctx, query, int64(fp), baseID, maxDistance, maxNearDuplicates)
+	if err != nil {
+		return nil, fmt.Errorf("find near duplicates: %w", err)
+	}
+	return scanPapers(rows)
+}
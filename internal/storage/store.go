@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// PaperStore is the read/write surface the API and pipeline need from a paper backend.
+// Listings are newest first, ties broken by ID descending.
+type PaperStore interface {
+	SaveBatch(ctx context.Context, papers []model.Paper) error
+	GetByID(ctx context.Context, id string) (model.Paper, error)
+	List(ctx context.Context, limit, offset int) ([]model.Paper, error)
+	// ListAfter continues a newest-first listing after a keyset cursor.
+	ListAfter(ctx context.Context, after Cursor, limit int) ([]model.Paper, error)
+	Search(ctx context.Context, query string, limit int) ([]model.Paper, error)
+	// ListByAuthor matches author names ignoring case and diacritics.
+	ListByAuthor(ctx context.Context, name string, limit, offset int) ([]model.Paper, error)
+	// ListMinScore returns papers scoring at least the threshold for their primary category.
+	ListMinScore(ctx context.Context, min MinScores, limit, offset int) ([]model.Paper, error)
+	// Count returns the number of stored papers. When exact is false the
+	// backend may answer from planner statistics instead of scanning the table.
+	Count(ctx context.Context, exact bool) (int64, error)
+	GetLatestUpdateTime(ctx context.Context) (time.Time, error)
+
+	// LatestVersions returns the highest stored version of each base ID.
+	LatestVersions(ctx context.Context, baseIDs []string) (map[string]model.Paper, error)
+	// RecordVersionUpdates appends to the version history, skipping duplicates.
+	RecordVersionUpdates(ctx context.Context, updates []model.VersionUpdate) error
+	// ListVersions returns the version history of a base ID, oldest first.
+	ListVersions(ctx context.Context, baseID string) ([]model.VersionUpdate, error)
+}
+
+// PaperStreamer is implemented by backends that can stream every paper
+// for export without loading them all at once.
+type PaperStreamer interface {
+	// StreamPapers calls fn for each paper after the cursor in export
+	// order (see Cursor.Before), stopping at the first error fn returns.
+	StreamPapers(ctx context.Context, after Cursor, fn func(model.Paper) error) error
+}
+
+// InsertReporter is implemented by backends that can report which papers a
+// save inserted rather than updated. The decision is made atomically per
+// ID, so concurrent saves of the same paper never both insert it.
+type InsertReporter interface {
+	// SaveBatchInserted saves like SaveBatch and returns the inserted IDs
+	// and, apart from them, those of papers stored under their base ID
+	// whose Revision it raised; on a partial save only committed papers
+	// are included.
+	SaveBatchInserted(ctx context.Context, papers []model.Paper) (inserted, revised map[string]bool, err error)
+}
+
+// VariantLister is implemented by backends that can load every stored
+// version of a paper, for grouped listings.
+type VariantLister interface {
+	// ListByBaseIDs returns every stored version of the base IDs, newest first.
+	ListByBaseIDs(ctx context.Context, baseIDs []string) ([]model.Paper, error)
+}
+
+// VersionFolder is implemented by backends that can move papers stored
+// under versioned IDs to their base IDs (see model.Paper.StripVersion).
+type VersionFolder interface {
+	// FoldVersions keeps the highest version of each paper, under its
+	// base ID with the version in Revision, and deletes the older ones.
+	// It returns the number of papers renamed and deleted. Only the first
+	// call on a store folds anything; later ones return zero counts.
+	FoldVersions(ctx context.Context) (renamed, deleted int64, err error)
+}
+
+// NearDuplicateFinder is implemented by backends that fingerprint
+// abstracts on save (see similarity.Fingerprint).
+type NearDuplicateFinder interface {
+	// FindNearDuplicates returns stored papers with another base ID whose
+	// abstracts are within maxDistance bits of paper id's, nearest first,
+	// or ErrNotFound if id is not stored.
+	FindNearDuplicates(ctx context.Context, id string, maxDistance int) ([]model.Paper, error)
+	// FindNearFingerprint returns stored papers other than versions of
+	// baseID whose fingerprints are within maxDistance bits of fp.
+	FindNearFingerprint(ctx context.Context, baseID string, fp uint64, maxDistance int) ([]model.Paper, error)
+}
+
+// CategoryLister is implemented by backends that can count their papers
+// by category.
+type CategoryLister interface {
+	// DistinctCategories returns the categories present, most papers
+	// first; with primary set only each paper's first category counts.
+	DistinctCategories(ctx context.Context, primary bool) ([]CategoryCount, error)
+}
+
+// SearchIndexReporter is implemented by backends that bound the text they
+// index for search (see SearchText).
+type SearchIndexReporter interface {
+	// CountSearchTruncated returns the number of papers whose abstract
+	// was cut to fit the index.
+	CountSearchTruncated(ctx context.Context) (int, error)
+}
+
+// SizeReporter is implemented by backends that can report on-disk relation sizes.
+type SizeReporter interface {
+	RelationSizes(ctx context.Context) (RelationSizes, error)
+}
+
+// PDFArchive is implemented by backends that track locally archived PDFs.
+type PDFArchive interface {
+	// ListUnarchived returns papers scoring at least minScore without a local PDF.
+	ListUnarchived(ctx context.Context, minScore, limit int) ([]model.Paper, error)
+	SetPDF(ctx context.Context, id, path string, size int64) error
+	// GetPDF returns the local PDF of a paper, or ErrNotFound if there is none.
+	GetPDF(ctx context.Context, id string) (path string, size int64, err error)
+}
+
+// StoredLister is implemented by backends that record when a paper was first saved.
+type StoredLister interface {
+	// ListStoredBefore returns papers first saved before cutoff, oldest first.
+	ListStoredBefore(ctx context.Context, cutoff time.Time, limit int) ([]model.Paper, error)
+}
+
+// ChangeTracker is implemented by backends that can cheaply report when
+// their papers last changed, for HTTP caching.
+type ChangeTracker interface {
+	DataVersion(ctx context.Context) (DataVersion, error)
+}
+
+// DataVersion identifies the state of the stored papers. Saves move
+// Modified; deletes leave it alone but change Count, so validators built
+// from both fields change on every write.
+type DataVersion struct {
+	Modified time.Time // Last save, or zero if nothing was saved
+	Count    int64     // Stored papers
+}
+
+// SyncHistory records sync runs.
+type SyncHistory interface {
+	StartSync(ctx context.Context, query string) (int, error)
+	CompleteSync(ctx context.Context, id int, fetched, newCount, updated int, timings map[string]int64) error
+	FailSync(ctx context.Context, id int, errMsg string, timings map[string]int64) error
+	GetSyncHistory(ctx context.Context, limit int) ([]SyncLog, error)
+}
+
+// SyncResults is implemented by sync logs that keep the result set of
+// each sync, so a run can be compared with the one before it.
+type SyncResults interface {
+	// RecordSyncResults stores the base ID -> score of every paper sync id
+	// returned (-1 for papers that were not scored).
+	RecordSyncResults(ctx context.Context, id int, results map[string]int) error
+	// PreviousSync returns the latest completed sync of query in scope
+	// other than id that has a result set, or ErrNotFound.
+	PreviousSync(ctx context.Context, query string, scope SyncScope, id int) (SyncLog, map[string]int, error)
+}
+
+// SyncCheckpoints is implemented by sync logs that support incremental
+// syncs, which fetch only what was updated since the last one.
+type SyncCheckpoints interface {
+	// LastCompletedSync returns the latest completed sync of query in
+	// scope, or ErrNotFound.
+	LastCompletedSync(ctx context.Context, query string, scope SyncScope) (SyncLog, error)
+	// RecordSyncWindow stores the last-updated window [from, to) sync id
+	// fetched.
+	RecordSyncWindow(ctx context.Context, id int, from, to time.Time) error
+}
+
+// SyncScopes is implemented by sync logs that record which provider and
+// categories each sync searched, so checkpoints and diffs only follow
+// syncs of the same scope.
+type SyncScopes interface {
+	RecordSyncScope(ctx context.Context, id int, scope SyncScope) error
+}
+
+// ShadowLog records where a candidate filter rule set diverges from the
+// active one.
+type ShadowLog interface {
+	RecordShadowResults(ctx context.Context, results []ShadowResult) error
+	// ShadowReport summarises a rule set (the latest one when ruleSet is
+	// empty), or returns ErrNotFound if nothing is recorded.
+	ShadowReport(ctx context.Context, ruleSet string, limit int) (ShadowReport, error)
+}
+
+// SyncRequestLog records the HTTP requests each sync made.
+type SyncRequestLog interface {
+	RecordSyncRequests(ctx context.Context, id int, requests []SyncRequest) error
+	ListSyncRequests(ctx context.Context, id int) ([]SyncRequest, error)
+	PruneSyncRequests(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// AuditLog records mutating operations for the local activity trail.
+type AuditLog interface {
+	RecordAudit(ctx context.Context, e AuditEntry) error
+	// ListAudit returns the latest entries, newest first; an empty action
+	// matches all.
+	ListAudit(ctx context.Context, action string, limit int) ([]AuditEntry, error)
+	PruneAudit(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// SettingsStore keeps the runtime settings changed over the API.
+type SettingsStore interface {
+	ListSettings(ctx context.Context) ([]Setting, error)
+	// PutSettings stores every setting or none of them.
+	PutSettings(ctx context.Context, settings []Setting) error
+}
+
+// WatchlistStore keeps the watchlists served as feeds over the API.
+type WatchlistStore interface {
+	CreateWatchlist(ctx context.Context, w Watchlist) (Watchlist, error)
+	GetWatchlist(ctx context.Context, id int) (Watchlist, error)
+	ListWatchlists(ctx context.Context) ([]Watchlist, error)
+	DeleteWatchlist(ctx context.Context, id int) error
+	// SetWatchlistFeedKey replaces the key feed tokens must name; an
+	// empty key revokes the feed.
+	SetWatchlistFeedKey(ctx context.Context, id int, key string) error
+}
+
+// WatchlistMatcher is implemented by stores that can list the papers a
+// watchlist matches.
+type WatchlistMatcher interface {
+	// ListWatchlistHits returns up to limit of the newest papers with an
+	// author or title matching w.
+	ListWatchlistHits(ctx context.Context, w Watchlist, limit int) ([]model.Paper, error)
+}
@@ -2,22 +2,67 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/version"
 )
 
 // SyncLog represents a synchronization operation log.
 type SyncLog struct {
-	ID            int
-	Query         string
-	PapersFetched int
-	PapersNew     int
-	PapersUpdated int
-	StartedAt     time.Time
-	CompletedAt   *time.Time
-	Status        string
+	ID            int              `json:"id"`
+	Query         string           `json:"query"`
+	PapersFetched int              `json:"papers_fetched"`
+	PapersNew     int              `json:"papers_new"`
+	PapersUpdated int              `json:"papers_updated"`
+	StartedAt     time.Time        `json:"started_at"`
+	CompletedAt   *time.Time       `json:"completed_at"`
+	Status        string           `json:"status"`
+	Timings       map[string]int64 `json:"timings,omitempty"`  // Stage durations in milliseconds
+	Version       string           `json:"version,omitempty"`  // Build that ran the sync
+	Provider      string           `json:"provider,omitempty"` // Source fetched from (empty for syncs before it was recorded)
+
+	// WindowFrom and WindowTo bound the last updates an incremental sync
+	// fetched; both are nil for a full fetch
+	WindowFrom *time.Time `json:"window_from,omitempty"`
+	WindowTo   *time.Time `json:"window_to,omitempty"`
+}
+
+// SyncScope is what a sync searched besides its query: the provider and
+// the categories it was restricted to. The same query fetches different
+// papers under another scope, so checkpoints and diffs only follow
+// earlier syncs of the same scope.
+type SyncScope struct {
+	Provider   string
+	Categories []string // Any category when empty
+	Strict     bool     // Primary categories only
+}
+
+// Key renders the categories of s as sync_log stores them: sorted,
+// without repeats, comma-separated and prefixed with "primary:" when
+// strict. It is empty for a sync of any category.
+func (s SyncScope) Key() string {
+	if len(s.Categories) == 0 {
+		return ""
+	}
+	cats := slices.Compact(slices.Sorted(slices.Values(s.Categories)))
+	key := strings.Join(cats, ",")
+	if s.Strict {
+		key = "primary:" + key
+	}
+	return key
+}
+
+// Equal reports whether s and other search the same provider and
+// categories, in whatever order they list them.
+func (s SyncScope) Equal(other SyncScope) bool {
+	return s.Provider == other.Provider && s.Key() == other.Key()
 }
 
 // SyncRepository handles sync log persistence.
@@ -34,60 +79,77 @@ func NewSyncRepository(pool *pgxpool.Pool) *SyncRepository {
 func (r *SyncRepository) StartSync(ctx context.Context, query string) (int, error) {
 	var id int
 	err := r.pool.QueryRow(ctx, `
-		INSERT INTO sync_log (query, started_at, status)
-		VALUES ($1, NOW(), 'running')
+		INSERT INTO sync_log (query, started_at, status, version)
+		VALUES ($1, NOW(), 'running', $2)
 		RETURNING id
-	`, query).Scan(&id)
+	`, query, version.String()).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("start sync: %w", err)
 	}
 	return id, nil
 }
 
-// CompleteSync updates a sync log entry with results.
-func (r *SyncRepository) CompleteSync(ctx context.Context, id int, fetched, newCount, updated int) error {
+// CompleteSync updates a sync log entry with results and stage timings
+// (milliseconds per stage).
+func (r *SyncRepository) CompleteSync(ctx context.Context, id int, fetched, newCount, updated int, timings map[string]int64) error {
 	_, err := r.pool.Exec(ctx, `
 		UPDATE sync_log
 		SET papers_fetched = $2,
 		    papers_new = $3,
 		    papers_updated = $4,
+		    timings = $5,
 		    completed_at = NOW(),
 		    status = 'completed'
 		WHERE id = $1
-	`, id, fetched, newCount, updated)
+	`, id, fetched, newCount, updated, timings)
 	if err != nil {
 		return fmt.Errorf("complete sync: %w", err)
 	}
 	return nil
 }
 
-// FailSync marks a sync as failed.
-func (r *SyncRepository) FailSync(ctx context.Context, id int, errMsg string) error {
+// FailSync marks a sync as failed, keeping the timings of the stages that ran.
+func (r *SyncRepository) FailSync(ctx context.Context, id int, errMsg string, timings map[string]int64) error {
 	_, err := r.pool.Exec(ctx, `
 		UPDATE sync_log
-		SET completed_at = NOW(),
+		SET timings = $2,
+		    completed_at = NOW(),
 		    status = 'failed'
 		WHERE id = $1
-	`, id)
+	`, id, timings)
 	if err != nil {
 		return fmt.Errorf("fail sync: %w", err)
 	}
 	return nil
 }
 
+// RecordCancelled logs a sync that was queued but never started.
+func (r *SyncRepository) RecordCancelled(ctx context.Context, query string) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO sync_log (query, started_at, completed_at, status, version)
+		VALUES ($1, NOW(), NOW(), 'cancelled', $2)
+	`, query, version.String())
+	if err != nil {
+		return fmt.Errorf("record cancelled sync: %w", err)
+	}
+	return nil
+}
+
 // GetLatestSync returns the most recent completed sync.
 func (r *SyncRepository) GetLatestSync(ctx context.Context) (*SyncLog, error) {
 	var log SyncLog
 	err := r.pool.QueryRow(ctx, `
 		SELECT id, query, papers_fetched, papers_new, papers_updated,
-		       started_at, completed_at, status
+		       started_at, completed_at, status, COALESCE(timings, '{}'), COALESCE(version, ''),
+		       window_from, window_to
 		FROM sync_log
 		WHERE status = 'completed'
 		ORDER BY completed_at DESC
 		LIMIT 1
 	`).Scan(
 		&log.ID, &log.Query, &log.PapersFetched, &log.PapersNew,
-		&log.PapersUpdated, &log.StartedAt, &log.CompletedAt, &log.Status,
+		&log.PapersUpdated, &log.StartedAt, &log.CompletedAt, &log.Status, &log.Timings, &log.Version,
+		&log.WindowFrom, &log.WindowTo,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("get latest sync: %w", err)
@@ -95,11 +157,57 @@ func (r *SyncRepository) GetLatestSync(ctx context.Context) (*SyncLog, error) {
 	return &log, nil
 }
 
+// LastCompletedSync returns the latest completed sync of query in scope,
+// or ErrNotFound. Syncs logged before their scope was recorded never
+// match.
+func (r *SyncRepository) LastCompletedSync(ctx context.Context, query string, scope SyncScope) (SyncLog, error) {
+	var log SyncLog
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, query, papers_fetched, papers_new, papers_updated,
+		       started_at, completed_at, status, COALESCE(timings, '{}'), COALESCE(version, ''),
+		       window_from, window_to, provider
+		FROM sync_log
+		WHERE query = $1 AND provider = $2 AND scope = $3 AND status = 'completed'
+		ORDER BY completed_at DESC
+		LIMIT 1
+	`, query, scope.Provider, scope.Key()).Scan(
+		&log.ID, &log.Query, &log.PapersFetched, &log.PapersNew,
+		&log.PapersUpdated, &log.StartedAt, &log.CompletedAt, &log.Status, &log.Timings, &log.Version,
+		&log.WindowFrom, &log.WindowTo, &log.Provider,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return SyncLog{}, ErrNotFound
+		}
+		return SyncLog{}, fmt.Errorf("get last completed sync: %w", err)
+	}
+	return log, nil
+}
+
+// RecordSyncWindow stores the last-updated window sync id fetched.
+func (r *SyncRepository) RecordSyncWindow(ctx context.Context, id int, from, to time.Time) error {
+	_, err := r.pool.Exec(ctx, `UPDATE sync_log SET window_from = $2, window_to = $3 WHERE id = $1`, id, from, to)
+	if err != nil {
+		return fmt.Errorf("record sync window: %w", err)
+	}
+	return nil
+}
+
+// RecordSyncScope stores the provider and categories sync id searched.
+func (r *SyncRepository) RecordSyncScope(ctx context.Context, id int, scope SyncScope) error {
+	_, err := r.pool.Exec(ctx, `UPDATE sync_log SET provider = $2, scope = $3 WHERE id = $1`, id, scope.Provider, scope.Key())
+	if err != nil {
+		return fmt.Errorf("record sync scope: %w", err)
+	}
+	return nil
+}
+
 // GetSyncHistory returns recent sync operations.
 func (r *SyncRepository) GetSyncHistory(ctx context.Context, limit int) ([]SyncLog, error) {
 	rows, err := r.pool.Query(ctx, `
 		SELECT id, query, papers_fetched, papers_new, papers_updated,
-		       started_at, completed_at, status
+		       started_at, completed_at, status, COALESCE(timings, '{}'), COALESCE(version, ''),
+		       window_from, window_to, COALESCE(provider, '')
 		FROM sync_log
 		ORDER BY started_at DESC
 		LIMIT $1
@@ -114,7 +222,8 @@ func (r *SyncRepository) GetSyncHistory(ctx context.Context, limit int) ([]SyncL
 		var log SyncLog
 		if err := rows.Scan(
 			&log.ID, &log.Query, &log.PapersFetched, &log.PapersNew,
-			&log.PapersUpdated, &log.StartedAt, &log.CompletedAt, &log.Status,
+			&log.PapersUpdated, &log.StartedAt, &log.CompletedAt, &log.Status, &log.Timings, &log.Version,
+			&log.WindowFrom, &log.WindowTo, &log.Provider,
 		); err != nil {
 			return nil, fmt.Errorf("scan sync log: %w", err)
 		}
@@ -123,3 +232,41 @@ func (r *SyncRepository) GetSyncHistory(ctx context.Context, limit int) ([]SyncL
 
 	return logs, nil
 }
+
+// RecordSyncResults stores the result set of a sync.
+func (r *SyncRepository) RecordSyncResults(ctx context.Context, id int, results map[string]int) error {
+	_, err := r.pool.Exec(ctx, `UPDATE sync_log SET results = $2 WHERE id = $1`, id, results)
+	if err != nil {
+		return fmt.Errorf("record sync results: %w", err)
+	}
+	return nil
+}
+
+// PreviousSync returns the latest completed sync of query in scope before
+// the current one, with its result set. Syncs logged before their scope
+// was recorded never match.
+func (r *SyncRepository) PreviousSync(ctx context.Context, query string, scope SyncScope, id int) (SyncLog, map[string]int, error) {
+	var log SyncLog
+	var results map[string]int
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, query, papers_fetched, papers_new, papers_updated,
+		       started_at, completed_at, status, COALESCE(timings, '{}'), COALESCE(version, ''),
+		       window_from, window_to, provider, results
+		FROM sync_log
+		WHERE query = $1 AND provider = $2 AND scope = $3 AND id <> $4
+		  AND status = 'completed' AND results IS NOT NULL
+		ORDER BY completed_at DESC
+		LIMIT 1
+	`, query, scope.Provider, scope.Key(), id).Scan(
+		&log.ID, &log.Query, &log.PapersFetched, &log.PapersNew,
+		&log.PapersUpdated, &log.StartedAt, &log.CompletedAt, &log.Status, &log.Timings, &log.Version,
+		&log.WindowFrom, &log.WindowTo, &log.Provider, &results,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return SyncLog{}, nil, ErrNotFound
+		}
+		return SyncLog{}, nil, fmt.Errorf("get previous sync: %w", err)
+	}
+	return log, results, nil
+}
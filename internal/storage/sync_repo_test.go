@@ -0,0 +1,27 @@
+package storage
+
+import "testing"
+
+func TestSyncScope_Key(t *testing.T) {
+	tests := []struct {
+		scope SyncScope
+		want  string
+	}{
+		{SyncScope{Provider: "arxiv"}, ""},
+		{SyncScope{Categories: []string{"cs.LG", "cs.CL", "cs.LG"}}, "cs.CL,cs.LG"},
+		{SyncScope{Categories: []string{"cs.CL"}, Strict: true}, "primary:cs.CL"},
+	}
+	for _, tc := range tests {
+		if got := tc.scope.Key(); got != tc.want {
+			t.Errorf("%+v: Key() = %q, want %q", tc.scope, got, tc.want)
+		}
+	}
+
+	a := SyncScope{Provider: "arxiv", Categories: []string{"cs.CL", "cs.LG"}}
+	if !a.Equal(SyncScope{Provider: "arxiv", Categories: []string{"cs.LG", "cs.CL"}}) {
+		t.Error("scopes listing the same categories in another order differ")
+	}
+	if a.Equal(SyncScope{Provider: "openalex", Categories: a.Categories}) {
+		t.Error("scopes of different providers are equal")
+	}
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// baseIDExpr strips the version suffix from papers.id. It matches the
+// expression index idx_papers_base_id.
+const baseIDExpr = `regexp_replace(id, 'v[0-9]+$', '')`
+
+// LatestVersions returns the highest stored version of each base ID, keyed
+// by base ID. Base IDs with no stored paper are absent from the map.
+func (r *PaperRepository) LatestVersions(ctx context.Context, baseIDs []string) (map[string]model.Paper, error) {
+	latest := make(map[string]model.Paper)
+	if len(baseIDs) == 0 {
+		return latest, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, title, abstract, authors, comments, doi, journal_ref, revision
+		FROM papers
+		WHERE `+baseIDExpr+` = ANY($1)
+	`, baseIDs)
+	if err != nil {
+		return nil, fmt.Errorf("latest versions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p model.Paper
+		if err := rows.Scan(&p.ID, &p.Title, &p.Abstract, &p.Authors, &p.Comments, &p.DOI, &p.JournalRef, &p.Revision); err != nil {
+			return nil, fmt.Errorf("scan version: %w", err)
+		}
+		if prev, ok := latest[p.BaseID()]; !ok || p.Version() > prev.Version() {
+			latest[p.BaseID()] = p
+		}
+	}
+
+	return latest, rows.Err()
+}
+
+// ListByBaseIDs returns every stored version of the given base IDs,
+// newest first.
+func (r *PaperRepository) ListByBaseIDs(ctx context.Context, baseIDs []string) ([]model.Paper, error) {
+	if len(baseIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+paperColumns+`
+		FROM papers
+		WHERE `+baseIDExpr+` = ANY($1)
+		ORDER BY updated_at DESC, id DESC
+	`, baseIDs)
+	if err != nil {
+		return nil, fmt.Errorf("list by base IDs: %w", err)
+	}
+	return scanPapers(rows)
+}
+
+// RecordVersionUpdates appends rows to paper_versions. An update already
+// recorded for the same base ID and new version is skipped.
+func (r *PaperRepository) RecordVersionUpdates(ctx context.Context, updates []model.VersionUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, u := range updates {
+		batch.Queue(`
+			INSERT INTO paper_versions (base_id, old_version, new_version, detected_at, content_changed, old_abstract, new_abstract)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (base_id, new_version) DO NOTHING
+		`, u.BaseID, u.OldVersion, u.NewVersion, u.DetectedAt, u.ContentChanged, u.OldAbstract, u.NewAbstract)
+	}
+
+	results := r.pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	for range updates {
+		if _, err := results.Exec(); err != nil {
+			return fmt.Errorf("record version update: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListVersions returns the recorded version history of a paper, oldest first.
+func (r *PaperRepository) ListVersions(ctx context.Context, baseID string) ([]model.VersionUpdate, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT base_id, old_version, new_version, detected_at, content_changed,
+		       COALESCE(old_abstract, ''), COALESCE(new_abstract, '')
+		FROM paper_versions
+		WHERE base_id = $1
+		ORDER BY new_version
+	`, baseID)
+	if err != nil {
+		return nil, fmt.Errorf("list versions: %w", err)
+	}
+	defer rows.Close()
+
+	var updates []model.VersionUpdate
+	for rows.Next() {
+		var u model.VersionUpdate
+		if err := rows.Scan(&u.BaseID, &u.OldVersion, &u.NewVersion, &u.DetectedAt, &u.ContentChanged, &u.OldAbstract, &u.NewAbstract); err != nil {
+			return nil, fmt.Errorf("scan version update: %w", err)
+		}
+		updates = append(updates, u)
+	}
+
+	return updates, rows.Err()
+}
+
+// foldDeleteSQL deletes every stored version of a paper but the highest,
+// preferring a row already under the base ID on a tie.
+const foldDeleteSQL = `
+	WITH versions AS (
+		SELECT id, ` + baseIDExpr + ` AS base,
+		       COALESCE(substring(id from 'v([0-9]+)$')::int, GREATEST(revision, 1)) AS version
+		FROM papers
+		WHERE ` + baseIDExpr + ` IN (SELECT ` + baseIDExpr + ` FROM papers WHERE id ~ 'v[0-9]+$')
+	), ranked AS (
+		SELECT id, row_number() OVER (PARTITION BY base ORDER BY version DESC, id = base DESC) AS rank
+		FROM versions
+	)
+	DELETE FROM papers WHERE id IN (SELECT id FROM ranked WHERE rank > 1)
+`
+
+// foldRenameSQL moves the versions left by foldDeleteSQL to their base IDs.
+const foldRenameSQL = `
+	UPDATE papers
+	SET id = ` + baseIDExpr + `, revision = substring(id from 'v([0-9]+)$')::int
+	WHERE id ~ 'v[0-9]+$'
+`
+
+// foldTask names the fold in maintenance_tasks.
+const foldTask = "fold_versions"
+
+// FoldVersions moves papers saved under versioned IDs to their base IDs in
+// one transaction: the highest version of each paper is kept, with the
+// version in the revision column, and the older ones are deleted. Their
+// differences stay in the version history.
+//
+// The fold runs once per database: it is recorded in maintenance_tasks in
+// the same transaction, and later calls, or calls racing it from another
+// process, return zero counts without scanning the papers. Deleting the
+// fold_versions row lets it run again.
+func (r *PaperRepository) FoldVersions(ctx context.Context) (renamed, deleted int64, err error) {
+	err = pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		// A concurrent fold holds the row until it commits
+		tag, err := tx.Exec(ctx, `INSERT INTO maintenance_tasks (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, foldTask)
+		if err != nil {
+			return fmt.Errorf("record fold: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+
+		tag, err = tx.Exec(ctx, foldDeleteSQL)
+		if err != nil {
+			return fmt.Errorf("delete older versions: %w", err)
+		}
+		deleted = tag.RowsAffected()
+
+		tag, err = tx.Exec(ctx, foldRenameSQL)
+		if err != nil {
+			return fmt.Errorf("rename to base IDs: %w", err)
+		}
+		renamed = tag.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("fold versions: %w", err)
+	}
+	return renamed, deleted, nil
+}
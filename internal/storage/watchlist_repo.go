@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/textutil"
+)
+
+// Watchlist is a saved search for papers by some authors or with some
+// words in the title, served as a feed of its hits.
+type Watchlist struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Authors    []string  `json:"authors"`     // Hits have an author whose name contains one of these
+	TitleTerms []string  `json:"title_terms"` // Or a title containing one of these
+	CreatedAt  time.Time `json:"created_at"`
+
+	// FeedKey is the key feed tokens must name (see package feedtoken);
+	// empty when the feed is revoked
+	FeedKey string `json:"-"`
+}
+
+// WatchlistRepository handles watchlist persistence.
+type WatchlistRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewWatchlistRepository creates a new watchlist repository.
+func NewWatchlistRepository(pool *pgxpool.Pool) *WatchlistRepository {
+	return &WatchlistRepository{pool: pool}
+}
+
+const watchlistColumns = `id, name, authors, title_terms, feed_key, created_at`
+
+// CreateWatchlist stores w and returns it with its ID and creation time.
+func (r *WatchlistRepository) CreateWatchlist(ctx context.Context, w Watchlist) (Watchlist, error) {
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO watchlists (name, authors, title_terms, feed_key)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, w.Name, nonNil(w.Authors), nonNil(w.TitleTerms), w.FeedKey).Scan(&w.ID, &w.CreatedAt)
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("create watchlist: %w", err)
+	}
+	return w, nil
+}
+
+// GetWatchlist returns a watchlist, or ErrNotFound.
+func (r *WatchlistRepository) GetWatchlist(ctx context.Context, id int) (Watchlist, error) {
+	var w Watchlist
+	err := r.pool.QueryRow(ctx, `SELECT `+watchlistColumns+` FROM watchlists WHERE id = $1`, id).
+		Scan(&w.ID, &w.Name, &w.Authors, &w.TitleTerms, &w.FeedKey, &w.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Watchlist{}, ErrNotFound
+	}
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("get watchlist: %w", err)
+	}
+	return w, nil
+}
+
+// ListWatchlists returns every watchlist, oldest first.
+func (r *WatchlistRepository) ListWatchlists(ctx context.Context) ([]Watchlist, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+watchlistColumns+` FROM watchlists ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("list watchlists: %w", err)
+	}
+	defer rows.Close()
+
+	watchlists := []Watchlist{}
+	for rows.Next() {
+		var w Watchlist
+		if err := rows.Scan(&w.ID, &w.Name, &w.Authors, &w.TitleTerms, &w.FeedKey, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan watchlist: %w", err)
+		}
+		watchlists = append(watchlists, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list watchlists: %w", err)
+	}
+	return watchlists, nil
+}
+
+// DeleteWatchlist removes a watchlist, or returns ErrNotFound.
+func (r *WatchlistRepository) DeleteWatchlist(ctx context.Context, id int) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM watchlists WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete watchlist: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetWatchlistFeedKey replaces the key of a watchlist's feed tokens, or
+// returns ErrNotFound. An empty key revokes the feed.
+func (r *WatchlistRepository) SetWatchlistFeedKey(ctx context.Context, id int, key string) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE watchlists SET feed_key = $2 WHERE id = $1`, id, key)
+	if err != nil {
+		return fmt.Errorf("set watchlist feed key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListWatchlistHits returns the newest papers with an author whose name
+// contains one of w's authors or a title containing one of its terms,
+// ignoring case (and diacritics in names, as ListByAuthor).
+func (r *PaperRepository) ListWatchlistHits(ctx context.Context, w Watchlist, limit int) ([]model.Paper, error) {
+	authors := make([]string, len(w.Authors))
+	for i, a := range w.Authors {
+		authors[i] = containsPattern(textutil.FoldName(a))
+	}
+	terms := make([]string, len(w.TitleTerms))
+	for i, t := range w.TitleTerms {
+		terms[i] = containsPattern(strings.ToLower(t))
+	}
+
+	query := `
+		SELECT ` + paperColumns + `
+		FROM papers
+		WHERE EXISTS (SELECT 1 FROM unnest($1::text[]) p WHERE ` + authorMatchOn("p", r.hasUnaccent(ctx)) + `)
+		   OR EXISTS (SELECT 1 FROM unnest($2::text[]) t WHERE lower(title) LIKE t)
+		` + newestFirst + `
+		LIMIT $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, authors, terms, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list watchlist hits: %w", err)
+	}
+
+	return scanPapers(rows)
+}
+
+// nonNil returns s, or an empty slice for nil so NOT NULL array columns
+// get '{}'.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,361 @@
+package syncqueue
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/timing"
+)
+
+// ErrQueueFull is returned by Submit when too many jobs are already waiting.
+var ErrQueueFull = errors.New("sync queue full")
+
+// ErrShutdown is returned by Submit after Shutdown has been called.
+var ErrShutdown = errors.New("sync queue shut down")
+
+// ErrCancelled is the error recorded on jobs that were still queued at shutdown.
+var ErrCancelled = errors.New("sync job cancelled")
+
+// Priority orders jobs waiting for a free slot. Higher values run first.
+type Priority int
+
+const (
+	PriorityBackfill Priority = iota
+	PriorityScheduled
+	PriorityInteractive
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityScheduled:
+		return "scheduled"
+	case PriorityBackfill:
+		return "backfill"
+	default:
+		return "unknown"
+	}
+}
+
+// State is the lifecycle state of a job.
+type State string
+
+const (
+	StateQueued    State = "queued"
+	StateRunning   State = "running"
+	StateCompleted State = "completed"
+	StateFailed    State = "failed"
+	StateCancelled State = "cancelled"
+)
+
+// Job is a unit of sync work.
+type Job struct {
+	Provider string // Jobs for the same provider share its concurrency limit
+	Query    string
+	Priority Priority
+	Run      func(ctx context.Context) error
+	Timings  *timing.Timings // Optional; filled in by Run and reported in Status
+	// Summary is optional; once the job completed, its value is reported
+	// in Status
+	Summary func() any
+
+	id       int
+	seq      uint64
+	state    State
+	err      error
+	done     chan struct{}
+	finished time.Time
+}
+
+// ID returns the identifier assigned by Submit.
+func (j *Job) ID() int { return j.id }
+
+// Done is closed when the job finishes, fails or is cancelled.
+func (j *Job) Done() <-chan struct{} { return j.done }
+
+// Err returns the job error once Done is closed.
+func (j *Job) Err() error { return j.err }
+
+// Status is a point-in-time snapshot of a job.
+type Status struct {
+	ID       int    `json:"id"`
+	Provider string `json:"provider"`
+	Query    string `json:"query"`
+	Priority string `json:"priority"`
+	State    State  `json:"state"`
+	Position int    `json:"position,omitempty"` // 1-based place in line while queued
+	Error    string `json:"error,omitempty"`
+
+	Timings map[string]int64 `json:"timings,omitempty"` // Stage durations in milliseconds
+	Summary any              `json:"summary,omitempty"` // Job.Summary of a completed job
+}
+
+// Config controls queue limits.
+type Config struct {
+	MaxConcurrent int // Running jobs allowed per provider (default: 1)
+	MaxDepth      int // Jobs allowed to wait (default: 10)
+	MaxFinished   int // Finished jobs kept for Status, oldest evicted first (default: 100)
+
+	// FinishedTTL evicts finished jobs this long after they finish, even
+	// under MaxFinished; sync_log keeps the durable record (default: no limit)
+	FinishedTTL time.Duration
+	Clock       clock.Clock // Time source for FinishedTTL (default: system clock)
+
+	// OnCancel is called for every job still queued when Shutdown runs.
+	OnCancel func(job *Job)
+}
+
+// Queue runs sync jobs with per-provider concurrency limits, FIFO within
+// priority classes.
+type Queue struct {
+	cfg Config
+
+	mu      sync.Mutex
+	nextID  int
+	seq     uint64
+	waiting []*Job
+	running map[string]int
+	jobs    map[int]*Job
+	done    []int // IDs of finished jobs still in jobs, oldest first
+	closed  bool
+	wg      sync.WaitGroup
+
+	// ctx is the parent of every job context; cancel stops running jobs
+	// once Shutdown gives up waiting
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New creates a queue with the given limits.
+func New(cfg Config) *Queue {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 1
+	}
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = 10
+	}
+	if cfg.MaxFinished <= 0 {
+		cfg.MaxFinished = 100
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		cfg:     cfg,
+		running: make(map[string]int),
+		jobs:    make(map[int]*Job),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Submit enqueues a job and starts it as soon as its provider has capacity.
+func (q *Queue) Submit(job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrShutdown
+	}
+	if len(q.waiting) >= q.cfg.MaxDepth {
+		return ErrQueueFull
+	}
+
+	q.nextID++
+	q.seq++
+	job.id = q.nextID
+	job.seq = q.seq
+	job.state = StateQueued
+	job.done = make(chan struct{})
+
+	q.jobs[job.id] = job
+	q.waiting = append(q.waiting, job)
+	q.sortWaiting()
+	q.dispatch()
+
+	return nil
+}
+
+// Stats counts the jobs a queue holds in memory.
+type Stats struct {
+	Queued   int `json:"queued"`
+	Running  int `json:"running"`
+	Finished int `json:"finished"` // Kept for Status until MaxFinished or FinishedTTL evicts them
+}
+
+// Stats returns the number of jobs held in each state.
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire()
+	stats := Stats{Queued: len(q.waiting), Finished: len(q.done)}
+	for _, n := range q.running {
+		stats.Running += n
+	}
+	return stats
+}
+
+// Status returns a snapshot of the job with the given ID.
+func (q *Queue) Status(id int) (Status, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Status{}, false
+	}
+
+	status := Status{
+		ID:       job.id,
+		Provider: job.Provider,
+		Query:    job.Query,
+		Priority: job.Priority.String(),
+		State:    job.state,
+	}
+	if job.err != nil {
+		status.Error = job.err.Error()
+	}
+	if job.Timings != nil {
+		status.Timings = job.Timings.Millis()
+	}
+	if job.state == StateCompleted && job.Summary != nil {
+		status.Summary = job.Summary()
+	}
+	if job.state == StateQueued {
+		for i, w := range q.waiting {
+			if w == job {
+				status.Position = i + 1
+				break
+			}
+		}
+	}
+	return status, true
+}
+
+// Shutdown stops accepting jobs, cancels everything still queued and waits
+// for running jobs to finish. If ctx expires first, the contexts of running
+// jobs are cancelled and ctx's error is returned.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	q.closed = true
+	cancelled := q.waiting
+	q.waiting = nil
+	for _, job := range cancelled {
+		job.state = StateCancelled
+		job.err = ErrCancelled
+		q.finish(job)
+	}
+	q.mu.Unlock()
+
+	for _, job := range cancelled {
+		if q.cfg.OnCancel != nil {
+			q.cfg.OnCancel(job)
+		}
+		close(job.done)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		return ctx.Err()
+	}
+}
+
+// finish keeps job for Status, evicting the oldest finished jobs beyond
+// MaxFinished or FinishedTTL. Caller must hold q.mu.
+func (q *Queue) finish(job *Job) {
+	// The closure may hold the whole result; only Summary is reported
+	job.Run = nil
+	job.finished = clock.Or(q.cfg.Clock).Now()
+	q.done = append(q.done, job.id)
+	for len(q.done) > q.cfg.MaxFinished {
+		q.evictOldest()
+	}
+	q.expire()
+}
+
+// expire evicts finished jobs older than FinishedTTL. Caller must hold
+// q.mu.
+func (q *Queue) expire() {
+	if q.cfg.FinishedTTL <= 0 {
+		return
+	}
+	cutoff := clock.Or(q.cfg.Clock).Now().Add(-q.cfg.FinishedTTL)
+	for len(q.done) > 0 && q.jobs[q.done[0]].finished.Before(cutoff) {
+		q.evictOldest()
+	}
+}
+
+// evictOldest forgets the oldest finished job. Caller must hold q.mu.
+func (q *Queue) evictOldest() {
+	delete(q.jobs, q.done[0])
+	q.done = q.done[1:]
+}
+
+// sortWaiting orders waiting jobs by priority, then submission order.
+// Caller must hold q.mu.
+func (q *Queue) sortWaiting() {
+	sort.SliceStable(q.waiting, func(i, j int) bool {
+		if q.waiting[i].Priority != q.waiting[j].Priority {
+			return q.waiting[i].Priority > q.waiting[j].Priority
+		}
+		return q.waiting[i].seq < q.waiting[j].seq
+	})
+}
+
+// dispatch starts every waiting job whose provider has a free slot.
+// Caller must hold q.mu.
+func (q *Queue) dispatch() {
+	remaining := q.waiting[:0]
+	for _, job := range q.waiting {
+		if q.running[job.Provider] >= q.cfg.MaxConcurrent {
+			remaining = append(remaining, job)
+			continue
+		}
+		q.start(job)
+	}
+	q.waiting = remaining
+}
+
+// start runs a job in its own goroutine. Caller must hold q.mu.
+func (q *Queue) start(job *Job) {
+	job.state = StateRunning
+	q.running[job.Provider]++
+	q.wg.Add(1)
+
+	go func() {
+		defer q.wg.Done()
+
+		err := job.Run(q.ctx)
+
+		q.mu.Lock()
+		job.err = err
+		if err != nil {
+			job.state = StateFailed
+		} else {
+			job.state = StateCompleted
+		}
+		q.running[job.Provider]--
+		q.finish(job)
+		if !q.closed {
+			q.dispatch()
+		}
+		q.mu.Unlock()
+
+		close(job.done)
+	}()
+}
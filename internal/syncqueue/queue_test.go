@@ -0,0 +1,357 @@
+package syncqueue
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// slowProvider is a parser.Provider that takes a fixed time per fetch.
+type slowProvider struct {
+	delay time.Duration
+}
+
+func (p slowProvider) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	time.Sleep(p.delay)
+	return []model.Paper{{ID: query}}, nil
+}
+
+// recorder collects the order in which jobs ran.
+type recorder struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (r *recorder) job(provider slowProvider, query string, priority Priority) *Job {
+	return &Job{
+		Provider: "arxiv",
+		Query:    query,
+		Priority: priority,
+		Run: func(ctx context.Context) error {
+			papers, err := provider.FetchPapers(ctx, query, 1)
+			if err != nil {
+				return err
+			}
+			r.mu.Lock()
+			r.order = append(r.order, papers[0].ID)
+			r.mu.Unlock()
+			return nil
+		},
+	}
+}
+
+// blocker returns a job that runs until release is closed.
+func blocker(release <-chan struct{}) *Job {
+	return &Job{
+		Provider: "arxiv",
+		Query:    "blocker",
+		Priority: PriorityBackfill,
+		Run: func(ctx context.Context) error {
+			<-release
+			return nil
+		},
+	}
+}
+
+func TestQueue_PriorityOrder(t *testing.T) {
+	q := New(Config{MaxConcurrent: 1, MaxDepth: 10})
+	provider := slowProvider{delay: 5 * time.Millisecond}
+	rec := &recorder{}
+
+	release := make(chan struct{})
+	if err := q.Submit(blocker(release)); err != nil {
+		t.Fatalf("Submit blocker: %v", err)
+	}
+
+	jobs := []*Job{
+		rec.job(provider, "backfill-1", PriorityBackfill),
+		rec.job(provider, "scheduled-1", PriorityScheduled),
+		rec.job(provider, "interactive-1", PriorityInteractive),
+		rec.job(provider, "scheduled-2", PriorityScheduled),
+		rec.job(provider, "interactive-2", PriorityInteractive),
+	}
+	for _, job := range jobs {
+		if err := q.Submit(job); err != nil {
+			t.Fatalf("Submit %s: %v", job.Query, err)
+		}
+	}
+
+	status, ok := q.Status(jobs[0].ID())
+	if !ok || status.State != StateQueued || status.Position != 5 {
+		t.Errorf("backfill job status = %+v, want queued at position 5", status)
+	}
+	status, _ = q.Status(jobs[4].ID())
+	if status.Position != 2 {
+		t.Errorf("second interactive job position = %d, want 2", status.Position)
+	}
+
+	close(release)
+	for _, job := range jobs {
+		<-job.Done()
+	}
+
+	want := []string{"interactive-1", "interactive-2", "scheduled-1", "scheduled-2", "backfill-1"}
+	if len(rec.order) != len(want) {
+		t.Fatalf("ran %d jobs, want %d", len(rec.order), len(want))
+	}
+	for i := range want {
+		if rec.order[i] != want[i] {
+			t.Errorf("order[%d] = %q, want %q", i, rec.order[i], want[i])
+		}
+	}
+}
+
+func TestQueue_DepthLimit(t *testing.T) {
+	q := New(Config{MaxConcurrent: 1, MaxDepth: 2})
+	provider := slowProvider{delay: time.Millisecond}
+	rec := &recorder{}
+
+	release := make(chan struct{})
+	defer close(release)
+	if err := q.Submit(blocker(release)); err != nil {
+		t.Fatalf("Submit blocker: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := q.Submit(rec.job(provider, "queued", PriorityScheduled)); err != nil {
+			t.Fatalf("Submit %d: %v", i, err)
+		}
+	}
+
+	err := q.Submit(rec.job(provider, "overflow", PriorityInteractive))
+	if !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestQueue_ConcurrencyPerProvider(t *testing.T) {
+	q := New(Config{MaxConcurrent: 1, MaxDepth: 10})
+
+	release := make(chan struct{})
+	if err := q.Submit(blocker(release)); err != nil {
+		t.Fatalf("Submit blocker: %v", err)
+	}
+
+	other := &Job{
+		Provider: "openalex",
+		Query:    "other",
+		Priority: PriorityBackfill,
+		Run:      func(ctx context.Context) error { return nil },
+	}
+	if err := q.Submit(other); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	select {
+	case <-other.Done():
+	case <-time.After(time.Second):
+		t.Fatal("job for an idle provider should not wait behind another provider")
+	}
+	close(release)
+}
+
+func TestQueue_ShutdownCancelsQueued(t *testing.T) {
+	var cancelled []string
+	q := New(Config{
+		MaxConcurrent: 1,
+		MaxDepth:      10,
+		OnCancel:      func(job *Job) { cancelled = append(cancelled, job.Query) },
+	})
+	provider := slowProvider{delay: time.Millisecond}
+	rec := &recorder{}
+
+	release := make(chan struct{})
+	running := blocker(release)
+	if err := q.Submit(running); err != nil {
+		t.Fatalf("Submit blocker: %v", err)
+	}
+	queued := rec.job(provider, "queued", PriorityScheduled)
+	if err := q.Submit(queued); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := q.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if status, _ := q.Status(running.ID()); status.State != StateCompleted {
+		t.Errorf("running job state = %s, want completed", status.State)
+	}
+	if status, _ := q.Status(queued.ID()); status.State != StateCancelled {
+		t.Errorf("queued job state = %s, want cancelled", status.State)
+	}
+	if len(cancelled) != 1 || cancelled[0] != "queued" {
+		t.Errorf("OnCancel called for %v, want [queued]", cancelled)
+	}
+	if len(rec.order) != 0 {
+		t.Errorf("cancelled job ran: %v", rec.order)
+	}
+	if err := q.Submit(rec.job(provider, "late", PriorityInteractive)); !errors.Is(err, ErrShutdown) {
+		t.Errorf("expected ErrShutdown after shutdown, got %v", err)
+	}
+}
+
+func TestQueue_SummaryOnceCompleted(t *testing.T) {
+	q := New(Config{MaxConcurrent: 1, MaxDepth: 10})
+	release := make(chan struct{})
+	job := blocker(release)
+	job.Summary = func() any { return "3 new" }
+	if err := q.Submit(job); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if status, _ := q.Status(job.ID()); status.Summary != nil {
+		t.Errorf("summary before completion = %v, want none", status.Summary)
+	}
+	close(release)
+	<-job.Done()
+	if status, _ := q.Status(job.ID()); status.Summary != "3 new" {
+		t.Errorf("summary = %v, want %q", status.Summary, "3 new")
+	}
+}
+
+func TestQueue_EvictsOldestFinished(t *testing.T) {
+	q := New(Config{MaxConcurrent: 1, MaxDepth: 10, MaxFinished: 2})
+	rec := &recorder{}
+
+	var jobs []*Job
+	for _, query := range []string{"a", "b", "c"} {
+		job := rec.job(slowProvider{}, query, PriorityInteractive)
+		if err := q.Submit(job); err != nil {
+			t.Fatalf("Submit %s: %v", query, err)
+		}
+		<-job.Done()
+		jobs = append(jobs, job)
+	}
+
+	if _, ok := q.Status(jobs[0].ID()); ok {
+		t.Error("oldest finished job still kept, want evicted")
+	}
+	for _, job := range jobs[1:] {
+		if status, ok := q.Status(job.ID()); !ok || status.State != StateCompleted {
+			t.Errorf("job %s status = %+v, %t; want completed", job.Query, status, ok)
+		}
+	}
+}
+
+func TestQueue_ShutdownCancelsRunning(t *testing.T) {
+	q := New(Config{})
+	started := make(chan struct{})
+	job := &Job{
+		Provider: "arxiv",
+		Query:    "stuck",
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	if err := q.Submit(job); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want deadline exceeded", err)
+	}
+
+	select {
+	case <-job.Done():
+	case <-time.After(time.Second):
+		t.Fatal("running job not cancelled by Shutdown")
+	}
+	if !errors.Is(job.Err(), context.Canceled) {
+		t.Errorf("job error = %v, want context.Canceled", job.Err())
+	}
+}
+
+func TestQueue_ExpiresFinishedAfterTTL(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	q := New(Config{MaxFinished: 10, FinishedTTL: time.Hour, Clock: fake})
+	rec := &recorder{}
+
+	first := rec.job(slowProvider{}, "a", PriorityInteractive)
+	q.Submit(first)
+	<-first.Done()
+	fake.Advance(30 * time.Minute)
+	second := rec.job(slowProvider{}, "b", PriorityInteractive)
+	q.Submit(second)
+	<-second.Done()
+
+	fake.Advance(31 * time.Minute)
+	if _, ok := q.Status(first.ID()); ok {
+		t.Error("job finished over an hour ago still kept, want expired")
+	}
+	if _, ok := q.Status(second.ID()); !ok {
+		t.Error("job finished 31 minutes ago expired, want kept")
+	}
+	if stats := q.Stats(); stats != (Stats{Finished: 1}) {
+		t.Errorf("stats = %+v, want one finished job", stats)
+	}
+}
+
+func TestQueue_ManyShortJobsStayBounded(t *testing.T) {
+	before := runtime.NumGoroutine()
+	q := New(Config{MaxConcurrent: 4, MaxDepth: 50, MaxFinished: 20})
+
+	for round := 0; round < 20; round++ {
+		var jobs []*Job
+		for i := 0; i < 50; i++ {
+			// Each result is large, as a sync's papers are
+			result := make([]byte, 64<<10)
+			job := &Job{
+				Provider: "arxiv",
+				Query:    "short",
+				Run:      func(ctx context.Context) error { result[0] = 1; return nil },
+			}
+			if err := q.Submit(job); err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			jobs = append(jobs, job)
+		}
+		for _, job := range jobs {
+			<-job.Done()
+		}
+	}
+
+	if stats := q.Stats(); stats != (Stats{Finished: 20}) {
+		t.Errorf("stats after 1000 jobs = %+v, want only MaxFinished kept", stats)
+	}
+	q.mu.Lock()
+	held, done, capacity := len(q.jobs), len(q.done), cap(q.done)
+	for _, job := range q.jobs {
+		if job.Run != nil {
+			t.Error("finished job still references its Run closure")
+			break
+		}
+	}
+	q.mu.Unlock()
+	if held != 20 || done != 20 || capacity > 64 {
+		t.Errorf("queue holds %d jobs, %d finished IDs (cap %d); want 20", held, done, capacity)
+	}
+
+	// Every job goroutine has exited
+	q.Shutdown(context.Background())
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after the jobs finished, want at most %d", n, before)
+	}
+}
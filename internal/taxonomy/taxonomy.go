@@ -0,0 +1,83 @@
+// Package taxonomy names arXiv subject categories.
+package taxonomy
+
+// names maps arXiv category codes to their names in the arXiv taxonomy.
+// Only the archives this pipeline is commonly pointed at are listed.
+var names = map[string]string{
+	"cs.AI": "Artificial Intelligence",
+	"cs.AR": "Hardware Architecture",
+	"cs.CC": "Computational Complexity",
+	"cs.CE": "Computational Engineering, Finance, and Science",
+	"cs.CG": "Computational Geometry",
+	"cs.CL": "Computation and Language",
+	"cs.CR": "Cryptography and Security",
+	"cs.CV": "Computer Vision and Pattern Recognition",
+	"cs.CY": "Computers and Society",
+	"cs.DB": "Databases",
+	"cs.DC": "Distributed, Parallel, and Cluster Computing",
+	"cs.DL": "Digital Libraries",
+	"cs.DM": "Discrete Mathematics",
+	"cs.DS": "Data Structures and Algorithms",
+	"cs.ET": "Emerging Technologies",
+	"cs.FL": "Formal Languages and Automata Theory",
+	"cs.GL": "General Literature",
+	"cs.GR": "Graphics",
+	"cs.GT": "Computer Science and Game Theory",
+	"cs.HC": "Human-Computer Interaction",
+	"cs.IR": "Information Retrieval",
+	"cs.IT": "Information Theory",
+	"cs.LG": "Machine Learning",
+	"cs.LO": "Logic in Computer Science",
+	"cs.MA": "Multiagent Systems",
+	"cs.MM": "Multimedia",
+	"cs.MS": "Mathematical Software",
+	"cs.NA": "Numerical Analysis",
+	"cs.NE": "Neural and Evolutionary Computing",
+	"cs.NI": "Networking and Internet Architecture",
+	"cs.OH": "Other Computer Science",
+	"cs.OS": "Operating Systems",
+	"cs.PF": "Performance",
+	"cs.PL": "Programming Languages",
+	"cs.RO": "Robotics",
+	"cs.SC": "Symbolic Computation",
+	"cs.SD": "Sound",
+	"cs.SE": "Software Engineering",
+	"cs.SI": "Social and Information Networks",
+	"cs.SY": "Systems and Control",
+
+	"stat.AP": "Applications",
+	"stat.CO": "Computation",
+	"stat.ME": "Methodology",
+	"stat.ML": "Machine Learning",
+	"stat.OT": "Other Statistics",
+	"stat.TH": "Statistics Theory",
+
+	"eess.AS": "Audio and Speech Processing",
+	"eess.IV": "Image and Video Processing",
+	"eess.SP": "Signal Processing",
+	"eess.SY": "Systems and Control",
+
+	"math.NA": "Numerical Analysis",
+	"math.OC": "Optimization and Control",
+	"math.PR": "Probability",
+	"math.ST": "Statistics Theory",
+
+	"q-bio.NC": "Neurons and Cognition",
+	"q-bio.QM": "Quantitative Methods",
+	"q-fin.CP": "Computational Finance",
+	"q-fin.ST": "Statistical Finance",
+
+	"econ.EM":         "Econometrics",
+	"physics.comp-ph": "Computational Physics",
+	"physics.soc-ph":  "Physics and Society",
+	"quant-ph":        "Quantum Physics",
+}
+
+// Name returns the name of an arXiv category, or the code itself for
+// categories it does not know.
+func Name(code string) string {
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return code
+}
@@ -0,0 +1,22 @@
+package taxonomy
+
+import "testing"
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+	}{
+		{"cs.CL", "Computation and Language"},
+		{"stat.ML", "Machine Learning"},
+		{"quant-ph", "Quantum Physics"},
+		{"astro-ph.GA", "astro-ph.GA"},
+		{"", ""},
+	}
+
+	for _, tc := range tests {
+		if got := Name(tc.code); got != tc.expected {
+			t.Errorf("Name(%q) = %q, want %q", tc.code, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package textutil
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// textURL matches URLs, which CleanText leaves alone: "~" is markup in
+// TeX but not in a URL. A URL ends at a brace, as in \url{...}.
+var textURL = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"{}]+`)
+
+// Invisible characters that only get in the way of matching and display.
+var invisible = strings.NewReplacer(
+	"\u00ad", "", // Soft hyphen
+	"\u200b", "", // Zero width space
+	"\u2060", "", // Word joiner
+	"\ufeff", "", // Byte order mark
+)
+
+// CleanText normalises a title, abstract or comment as arXiv serves it:
+// HTML entities are unescaped, text-mode TeX markup is simplified as by
+// StripTeX (accents resolved, \textit{...} and the like reduced to their
+// text, braces dropped), and every run of Unicode whitespace becomes a
+// single space. Math between $ delimiters is kept verbatim, so that
+// RenderTitle can still strip it for plain output and pass it through
+// for BibTeX; so are URLs. Bytes that are not valid UTF-8 become U+FFFD.
+func CleanText(s string) string {
+	s = invisible.Replace(html.UnescapeString(strings.ToValidUTF8(s, "\ufffd")))
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for s != "" {
+		start := mathStart(s)
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start+1:], '$')
+		if end < 0 {
+			break
+		}
+		end += start + 2
+		b.WriteString(cleanTextMode(s[:start]))
+		b.WriteString(s[start:end])
+		s = s[end:]
+	}
+	b.WriteString(cleanTextMode(s))
+
+	return CollapseSpace(norm.NFC.String(b.String()))
+}
+
+// mathStart returns the index of the first $ in s not escaped as \$, or -1.
+func mathStart(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '$':
+			return i
+		}
+	}
+	return -1
+}
+
+// cleanTextMode strips the TeX markup from text outside math, keeping
+// URLs and escaped dollars as they are.
+func cleanTextMode(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range textURL.FindAllStringIndex(s, -1) {
+		b.WriteString(stripTextTeX(s[last:m[0]]))
+		b.WriteString(s[m[0]:m[1]])
+		last = m[1]
+	}
+	b.WriteString(stripTextTeX(s[last:]))
+	return b.String()
+}
+
+// stripTextTeX is StripTeX for text that must keep \$ escaped: a bare $
+// would start math when the text is rendered again.
+func stripTextTeX(s string) string {
+	parts := strings.Split(s, `\$`)
+	for i, p := range parts {
+		parts[i] = StripTeX(p)
+	}
+	return strings.Join(parts, `\$`)
+}
@@ -0,0 +1,49 @@
+package textutil
+
+import "testing"
+
+func TestCleanText(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Retrieval &amp; Reasoning over Long Documents", "Retrieval & Reasoning over Long Documents"},
+		{"When &quot;Less&quot; is More: Data Pruning for LLMs", `When "Less" is More: Data Pruning for LLMs`},
+		{"Scores &lt;0.5 and &#8220;Hard&#8221; Negatives", "Scores <0.5 and “Hard” Negatives"},
+		{`\textit{FlashAttention}: Fast and Memory-Efficient Exact Attention`, "FlashAttention: Fast and Memory-Efficient Exact Attention"},
+		{`\emph{Attention} Is \textbf{Not} All You Need`, "Attention Is Not All You Need"},
+		{`Schr\"odinger Bridges and Erd\H{o}s--R\'enyi Graphs`, "Schrödinger Bridges and Erdős–Rényi Graphs"},
+		{`{BERT} Rediscovers the Classical {NLP} Pipeline`, "BERT Rediscovers the Classical NLP Pipeline"},
+		{`Q\&A over Semi-Structured Tables`, "Q&A over Semi-Structured Tables"},
+		{"Sparse Mixture of Experts", "Sparse Mixture of Experts"},
+		{"Zero\u200bShot Trans\u00adlation\ufeff", "ZeroShot Translation"},
+		{"Graph\u00a0Neural Networks\u2009for\u202fMolecules", "Graph Neural Networks for Molecules"},
+		{"  Training   Multi-line\n    Titles\t", "Training Multi-line Titles"},
+		{"Caf\xe9 Reranking", "Caf\ufffd Reranking"},
+
+		// Math is kept for RenderTitle and BibTeX
+		{`Sub-$O(n \log n)$ Sorting Networks`, `Sub-$O(n \log n)$ Sorting Networks`},
+		{`\textit{Fast} $\alpha$-Divergence with $\ell_1$ Penalties`, `Fast $\alpha$-Divergence with $\ell_1$ Penalties`},
+		{`Costs under \$5 per $10^{6}$ Tokens`, `Costs under \$5 per $10^{6}$ Tokens`},
+
+		// So are URLs
+		{`Code at https://example.org/~user/tool~v2.`, `Code at https://example.org/~user/tool~v2.`},
+		{`See~\url{https://github.com/org/repo}`, "See https://github.com/org/repo"},
+
+		{"Plain Title Without Markup", "Plain Title Without Markup"},
+		{"", ""},
+	}
+
+	for _, tc := range tests {
+		if result := CleanText(tc.input); result != tc.expected {
+			t.Errorf("CleanText(%q) = %q, want %q", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestCleanText_Renders(t *testing.T) {
+	input := `\emph{Sub}-$O(n \log n)$ Sorting in Caf\'e &amp; Bar`
+	if result := RenderTitle(CleanText(input), TitlePlain); result != "Sub-O(n log n) Sorting in Café & Bar" {
+		t.Errorf("RenderTitle(CleanText(%q)) = %q", input, result)
+	}
+}
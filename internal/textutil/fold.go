@@ -0,0 +1,39 @@
+package textutil
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Letters that do not decompose into a base letter plus combining marks.
+var foldSpecial = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "ł", "l", "đ", "d", "ı", "i",
+)
+
+// FoldName lowercases s and strips diacritics so that "José" and "jose",
+// or "LeCun" and "lecun", compare equal. Whitespace is collapsed.
+func FoldName(s string) string {
+	s = strings.ToLower(CollapseSpace(s))
+	s = foldSpecial.Replace(s)
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// FoldNames applies FoldName to every element.
+func FoldNames(names []string) []string {
+	folded := make([]string, len(names))
+	for i, n := range names {
+		folded[i] = FoldName(n)
+	}
+	return folded
+}
@@ -0,0 +1,27 @@
+package textutil
+
+import "testing"
+
+func TestFoldName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"José García", "jose garcia"},
+		{"Jose Garcia", "jose garcia"},
+		{"Yann LeCun", "yann lecun"},
+		{"Jürgen Schmidhuber", "jurgen schmidhuber"},
+		{"Łukasz Kaiser", "lukasz kaiser"},
+		{"Søren Hauberg", "soren hauberg"},
+		{"François  Chollet", "francois chollet"},
+		{"Paul Erdős", "paul erdos"},
+		{"Müller-Straße", "muller-strasse"},
+		{"Zhang Wei 张伟", "zhang wei 张伟"},
+	}
+
+	for _, tc := range tests {
+		if result := FoldName(tc.input); result != tc.expected {
+			t.Errorf("FoldName(%q) = %q, want %q", tc.input, result, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package textutil
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// TitleMode selects how TeX markup in a title is rendered.
+type TitleMode int
+
+const (
+	// TitlePlain strips math delimiters and common TeX commands.
+	// Used for console, CSV, chat and feed output.
+	TitlePlain TitleMode = iota
+	// TitlePassthrough keeps TeX markup for formats that understand it.
+	TitlePassthrough
+)
+
+// TitleModeForFormat returns the title mode an output format expects.
+func TitleModeForFormat(format string) TitleMode {
+	switch strings.ToLower(format) {
+	case "bibtex", "bib", "latex":
+		return TitlePassthrough
+	default:
+		return TitlePlain
+	}
+}
+
+// RenderTitle renders a paper title for the given mode.
+func RenderTitle(title string, mode TitleMode) string {
+	if mode == TitlePassthrough {
+		return CollapseSpace(title)
+	}
+	return CollapseSpace(StripTeX(title))
+}
+
+// CollapseSpace trims s and collapses all whitespace runs into single spaces.
+func CollapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Combining marks for TeX accent commands.
+var accentMarks = map[string]rune{
+	"'":  '́',
+	"`":  '̀',
+	"^":  '̂',
+	"\"": '̈',
+	"~":  '̃',
+	"=":  '̄',
+	".":  '̇',
+	"c":  '̧',
+	"v":  '̌',
+	"u":  '̆',
+	"H":  '̋',
+	"r":  '̊',
+	"k":  '̨',
+}
+
+// Commands replaced by a fixed string.
+var texSymbols = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε",
+	"varepsilon": "ε", "zeta": "ζ", "eta": "η", "theta": "θ", "iota": "ι",
+	"kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π",
+	"rho": "ρ", "sigma": "σ", "tau": "τ", "phi": "φ", "varphi": "φ",
+	"chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ",
+	"Pi": "Π", "Sigma": "Σ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	"ell": "ℓ", "infty": "∞", "partial": "∂", "nabla": "∇",
+	"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "neq": "≠", "ne": "≠",
+	"approx": "≈", "sim": "~", "pm": "±", "times": "×", "cdot": "·",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "in": "∈",
+	"sum": "∑", "prod": "∏", "int": "∫", "sqrt": "√",
+	"ldots": "…", "dots": "…", "cdots": "…",
+	"log": "log", "exp": "exp", "sin": "sin", "cos": "cos",
+	"max": "max", "min": "min", "arg": "arg", "det": "det",
+	"ss": "ß", "o": "ø", "O": "Ø", "ae": "æ", "AE": "Æ", "oe": "œ",
+	"l": "ł", "L": "Ł", "i": "ı", "j": "ȷ",
+	"LaTeX": "LaTeX", "TeX": "TeX",
+	"quad": " ", "qquad": " ",
+}
+
+// Post-processing for TeX text-mode ligatures.
+var texLigatures = strings.NewReplacer(
+	"---", "—",
+	"--", "–",
+	"``", "\"",
+	"''", "\"",
+)
+
+// StripTeX removes TeX markup from s, keeping the readable content of
+// math and formatting commands and resolving accents to Unicode.
+func StripTeX(s string) string {
+	var b strings.Builder
+	inMath := false
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '$':
+			inMath = !inMath
+			i++
+		case c == '\\':
+			i = writeCommand(&b, s, i)
+		case c == '{' || c == '}':
+			i++
+		case inMath && c == '_':
+			i++
+		case !inMath && c == '~':
+			b.WriteByte(' ')
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return norm.NFC.String(texLigatures.Replace(b.String()))
+}
+
+// writeCommand renders the TeX command starting at s[i] (a backslash)
+// and returns the index just past it.
+func writeCommand(b *strings.Builder, s string, i int) int {
+	i++ // skip backslash
+	if i >= len(s) {
+		return i
+	}
+
+	// Control symbols: \' \& \, etc.
+	if !isLetter(s[i]) {
+		sym := s[i : i+1]
+		i++
+		if mark, ok := accentMarks[sym]; ok {
+			return writeAccent(b, s, i, mark)
+		}
+		switch sym {
+		case "&", "%", "$", "#", "_", "{", "}":
+			b.WriteString(sym)
+		case "\\", ",", ";", ":", " ":
+			b.WriteByte(' ')
+		}
+		return i
+	}
+
+	start := i
+	for i < len(s) && isLetter(s[i]) {
+		i++
+	}
+	name := s[start:i]
+
+	if mark, ok := accentMarks[name]; ok {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		return writeAccent(b, s, i, mark)
+	}
+	if name == "frac" {
+		num, next := readGroup(s, i)
+		den, next := readGroup(s, next)
+		b.WriteString(StripTeX(num) + "/" + StripTeX(den))
+		return next
+	}
+	if sym, ok := texSymbols[name]; ok {
+		b.WriteString(sym)
+	}
+	// Formatting commands (\textit, \mathbb, \textsuperscript, ...) and
+	// unknown commands are dropped; their braced argument renders as text.
+	return i
+}
+
+// writeAccent applies mark to the argument starting at s[i].
+func writeAccent(b *strings.Builder, s string, i int, mark rune) int {
+	if i >= len(s) {
+		return i
+	}
+
+	var arg string
+	if s[i] == '{' {
+		arg, i = readGroup(s, i)
+	} else {
+		arg, i = nextRune(s, i)
+	}
+
+	// Dotless i/j take the accent in place of their dot.
+	switch strings.TrimSpace(arg) {
+	case `\i`:
+		arg = "i"
+	case `\j`:
+		arg = "j"
+	default:
+		arg = StripTeX(arg)
+	}
+
+	b.WriteString(arg)
+	b.WriteRune(mark)
+	return i
+}
+
+// readGroup returns the contents of the braced group at s[i] (skipping
+// leading spaces) and the index after its closing brace. A missing group
+// yields the next single character instead.
+func readGroup(s string, i int) (string, int) {
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+	if i >= len(s) {
+		return "", i
+	}
+	if s[i] != '{' {
+		return nextRune(s, i)
+	}
+
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return s[i+1 : j], j + 1
+			}
+		}
+	}
+	return s[i+1:], len(s)
+}
+
+func nextRune(s string, i int) (string, int) {
+	for _, r := range s[i:] {
+		n := len(string(r))
+		return s[i : i+n], i + n
+	}
+	return "", i
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,66 @@
+package textutil
+
+import "testing"
+
+func TestRenderTitle_Plain(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`$\alpha$-Divergence Variational Inference`, "α-Divergence Variational Inference"},
+		{`Sub-$O(n \log n)$ Sorting Networks`, "Sub-O(n log n) Sorting Networks"},
+		{`Caf\'e: Fast Attention for Long Sequences`, "Café: Fast Attention for Long Sequences"},
+		{`Schr\"odinger Bridges for Generative Modeling`, "Schrödinger Bridges for Generative Modeling"},
+		{`G\"{o}del Machines Revisited`, "Gödel Machines Revisited"},
+		{`Pe\~na and the Fran\c{c}ais Benchmark`, "Peña and the Français Benchmark"},
+		{`Erd\H{o}s--R\'enyi Graphs`, "Erdős–Rényi Graphs"},
+		{`Na\"{\i}ve Bayes is Back`, "Naïve Bayes is Back"},
+		{`Report on the 4\textsuperscript{th} Workshop on NLP`, "Report on the 4th Workshop on NLP"},
+		{`\textit{FlashAttention}: Fast and Memory-Efficient Exact Attention`, "FlashAttention: Fast and Memory-Efficient Exact Attention"},
+		{`Learning in $\mathbb{R}^d$ with $\ell_1$ Regularization`, "Learning in R^d with ℓ1 Regularization"},
+		{`Scaling Transformers to $10^{6}$ Tokens`, "Scaling Transformers to 10^6 Tokens"},
+		{`A $\frac{1}{2}$-Approximation for Max-Cut`, "A 1/2-Approximation for Max-Cut"},
+		{`Q\&A over Semi-Structured Tables`, "Q&A over Semi-Structured Tables"},
+		{`{BERT} Rediscovers the Classical {NLP} Pipeline`, "BERT Rediscovers the Classical NLP Pipeline"},
+		{`Training   Multi-line
+    Titles`, "Training Multi-line Titles"},
+		{"Plain Title Without Markup", "Plain Title Without Markup"},
+	}
+
+	for _, tc := range tests {
+		result := RenderTitle(tc.input, TitlePlain)
+		if result != tc.expected {
+			t.Errorf("RenderTitle(%q, TitlePlain) = %q, want %q", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestRenderTitle_Passthrough(t *testing.T) {
+	input := "$\\alpha$-Divergence  for\n Schr\\\"odinger Bridges"
+	expected := "$\\alpha$-Divergence for Schr\\\"odinger Bridges"
+
+	if result := RenderTitle(input, TitlePassthrough); result != expected {
+		t.Errorf("RenderTitle(%q, TitlePassthrough) = %q, want %q", input, result, expected)
+	}
+}
+
+func TestTitleModeForFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected TitleMode
+	}{
+		{"bibtex", TitlePassthrough},
+		{"BibTeX", TitlePassthrough},
+		{"csv", TitlePlain},
+		{"jsonl", TitlePlain},
+		{"slack", TitlePlain},
+		{"atom", TitlePlain},
+		{"", TitlePlain},
+	}
+
+	for _, tc := range tests {
+		if result := TitleModeForFormat(tc.format); result != tc.expected {
+			t.Errorf("TitleModeForFormat(%q) = %d, want %d", tc.format, result, tc.expected)
+		}
+	}
+}
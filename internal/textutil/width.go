@@ -0,0 +1,139 @@
+package textutil
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// RuneWidth returns the number of terminal columns r occupies: 2 for East
+// Asian wide and fullwidth characters, 0 for combining marks and format
+// characters, 1 otherwise.
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.IsControl(r):
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// DisplayWidth returns the number of terminal columns s occupies. ANSI
+// escape sequences (as used for colored diffs) take no space.
+func DisplayWidth(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if j := skipEscape(s, i); j > i {
+			i = j
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		n += RuneWidth(r)
+		i += size
+	}
+	return n
+}
+
+// skipEscape returns the index after the ANSI CSI sequence starting at
+// s[i], or i if there is none.
+func skipEscape(s string, i int) int {
+	if !strings.HasPrefix(s[i:], "\x1b[") {
+		return i
+	}
+	for j := i + 2; j < len(s); j++ {
+		if s[j] >= 0x40 && s[j] <= 0x7e {
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+// segment is an unbreakable piece of text for Wrap.
+type segment struct {
+	text  string
+	space bool // Separated from the previous segment by a space
+}
+
+// segments splits s at spaces and between wide characters, which may be
+// broken between without a space. Text and punctuation directly after a
+// wide character stay with it, so a line never starts with "，" or ",".
+func segments(s string) []segment {
+	var segs []segment
+	var cur strings.Builder
+	space := false
+	flush := func() {
+		if cur.Len() > 0 {
+			segs = append(segs, segment{text: cur.String(), space: space})
+			cur.Reset()
+			space = false
+		}
+	}
+	afterWide := false // cur ends in a wide character
+	for i := 0; i < len(s); {
+		if j := skipEscape(s, i); j > i {
+			cur.WriteString(s[i:j])
+			i = j
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+			space = len(segs) > 0
+			afterWide = false
+		case RuneWidth(r) == 2:
+			if !unicode.IsPunct(r) {
+				flush()
+			}
+			cur.WriteRune(r)
+			afterWide = true
+		default:
+			if afterWide && RuneWidth(r) == 0 {
+				cur.WriteRune(r) // Marks stay with their base character
+				continue
+			}
+			cur.WriteRune(r)
+			afterWide = false
+		}
+	}
+	flush()
+	return segs
+}
+
+// Wrap breaks s into lines of at most width columns, at spaces or between
+// wide characters. A word wider than width (such as a URL) is never cut; it
+// gets a line of its own. Runs of whitespace collapse to one space.
+func Wrap(s string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	used := 0
+	for _, seg := range segments(s) {
+		w := DisplayWidth(seg.text)
+		gap := 0
+		if seg.space && used > 0 {
+			gap = 1
+		}
+		if used > 0 && used+gap+w > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			used, gap = 0, 0
+		}
+		if gap > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(seg.text)
+		used += gap + w
+	}
+	if line.Len() > 0 || len(lines) == 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
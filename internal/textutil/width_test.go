@@ -0,0 +1,96 @@
+package textutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"", 0},
+		{"Sparse Routing", 14},
+		{"稀疏路由", 8},
+		{"LLM 智能体", 10},
+		{"ｆｕｌｌ", 8},                // Fullwidth Latin
+		{"Ｈｅｌｌｏ, world", 17},       // Mixed fullwidth and ASCII
+		{"Café", 4},                // Precomposed
+		{"Café", 4},               // Combining acute accent
+		{"한국어", 6},                 // Hangul syllables
+		{"📚 Results", 10},          // Wide emoji
+		{"\x1b[31m81.2\x1b[0m", 4}, // Color codes take no space
+		{"α-Divergence", 12},
+	}
+
+	for _, tc := range tests {
+		if got := DisplayWidth(tc.input); got != tc.expected {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tc.input, got, tc.expected)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		width    int
+		expected []string
+	}{
+		{
+			name:     "fits",
+			input:    "Sparse Routing",
+			width:    20,
+			expected: []string{"Sparse Routing"},
+		},
+		{
+			name:     "word boundaries",
+			input:    "Scaling Transformers to Million Token Contexts",
+			width:    20,
+			expected: []string{"Scaling Transformers", "to Million Token", "Contexts"},
+		},
+		{
+			name:     "long word kept whole",
+			input:    "see https://github.com/example/a-very-long-repository-name for code",
+			width:    20,
+			expected: []string{"see", "https://github.com/example/a-very-long-repository-name", "for code"},
+		},
+		{
+			name:     "wide characters break anywhere",
+			input:    "大型语言模型的稀疏路由",
+			width:    10,
+			expected: []string{"大型语言模", "型的稀疏路", "由"},
+		},
+		{
+			name:     "mixed scripts",
+			input:    "LLM 智能体 benchmark",
+			width:    12,
+			expected: []string{"LLM 智能体", "benchmark"},
+		},
+		{
+			name:     "punctuation stays with wide characters",
+			input:    "检索增强生成，基准, 评测",
+			width:    14,
+			expected: []string{"检索增强生成，", "基准, 评测"},
+		},
+		{
+			name:     "whitespace collapses",
+			input:    "  Training   Multi-line\n Titles ",
+			width:    80,
+			expected: []string{"Training Multi-line Titles"},
+		},
+		{
+			name:     "empty",
+			input:    "",
+			width:    10,
+			expected: []string{""},
+		},
+	}
+
+	for _, tc := range tests {
+		if got := Wrap(tc.input, tc.width); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s: Wrap(%q, %d) = %q, want %q", tc.name, tc.input, tc.width, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package timing records per-stage durations of pipeline runs. Sync jobs,
+// the CLI and the benchmark runner share it so their numbers are comparable.
+package timing
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stage names recorded for a sync.
+const (
+	StageFetch      = "fetch"
+	StageFilter     = "filter"
+	StageValidation = "validation"
+	StageSave       = "save"
+)
+
+// Stage is one measured step.
+type Stage struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Timings collects stage durations in the order they were measured.
+// The zero value is ready to use and safe for concurrent use.
+type Timings struct {
+	mu     sync.Mutex
+	stages []Stage
+}
+
+// Measure runs fn and records its duration under name, whether or not fn
+// fails. A stage measured twice accumulates.
+func (t *Timings) Measure(name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	t.Add(name, time.Since(start))
+	return err
+}
+
+// Add records d under name.
+func (t *Timings) Add(name string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.stages {
+		if t.stages[i].Name == name {
+			t.stages[i].Duration += d
+			return
+		}
+	}
+	t.stages = append(t.stages, Stage{Name: name, Duration: d})
+}
+
+// Stages returns the recorded stages in measurement order.
+func (t *Timings) Stages() []Stage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Stage(nil), t.stages...)
+}
+
+// Get returns the duration recorded for name.
+func (t *Timings) Get(name string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, s := range t.stages {
+		if s.Name == name {
+			return s.Duration, true
+		}
+	}
+	return 0, false
+}
+
+// Millis returns the stage durations in milliseconds, keyed by stage name.
+// This is the form stored in sync_log.timings and returned by the API.
+func (t *Timings) Millis() map[string]int64 {
+	stages := t.Stages()
+	if len(stages) == 0 {
+		return nil
+	}
+
+	ms := make(map[string]int64, len(stages))
+	for _, s := range stages {
+		ms[s.Name] = s.Duration.Milliseconds()
+	}
+	return ms
+}
+
+// String formats the stages as "fetch 42s | filter 0.3s | save 3.1s".
+func (t *Timings) String() string {
+	stages := t.Stages()
+	parts := make([]string, 0, len(stages))
+	for _, s := range stages {
+		parts = append(parts, s.Name+" "+FormatSeconds(s.Duration))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// FormatSeconds renders d in seconds: whole seconds from 10s, one decimal
+// below that ("42s", "3.1s", "0.3s").
+func FormatSeconds(d time.Duration) string {
+	if d >= 10*time.Second {
+		return strconv.FormatInt(int64(d.Round(time.Second)/time.Second), 10) + "s"
+	}
+	return strconv.FormatFloat(d.Round(100*time.Millisecond).Seconds(), 'f', -1, 64) + "s"
+}
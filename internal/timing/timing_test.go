@@ -0,0 +1,77 @@
+package timing
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimings_Measure(t *testing.T) {
+	var timings Timings
+
+	if err := timings.Measure(StageFetch, func() error { return nil }); err != nil {
+		t.Fatalf("Measure: %v", err)
+	}
+	boom := errors.New("boom")
+	if err := timings.Measure(StageSave, func() error { return boom }); !errors.Is(err, boom) {
+		t.Errorf("Measure error = %v, want %v", err, boom)
+	}
+
+	// A failed stage is still recorded
+	stages := timings.Stages()
+	if len(stages) != 2 || stages[0].Name != StageFetch || stages[1].Name != StageSave {
+		t.Errorf("stages = %+v, want fetch then save", stages)
+	}
+
+	ms := timings.Millis()
+	for _, key := range []string{StageFetch, StageSave} {
+		if _, ok := ms[key]; !ok {
+			t.Errorf("Millis() missing %q: %v", key, ms)
+		}
+	}
+	if _, ok := ms[StageFilter]; ok {
+		t.Errorf("Millis() has a stage that never ran: %v", ms)
+	}
+}
+
+func TestTimings_AddAccumulates(t *testing.T) {
+	var timings Timings
+	timings.Add(StageFetch, time.Second)
+	timings.Add(StageFetch, 500*time.Millisecond)
+
+	if d, _ := timings.Get(StageFetch); d != 1500*time.Millisecond {
+		t.Errorf("fetch = %v, want 1.5s", d)
+	}
+}
+
+func TestTimings_String(t *testing.T) {
+	var timings Timings
+	timings.Add(StageFetch, 42*time.Second+200*time.Millisecond)
+	timings.Add(StageFilter, 310*time.Millisecond)
+	timings.Add(StageSave, 3080*time.Millisecond)
+
+	expected := "fetch 42s | filter 0.3s | save 3.1s"
+	if result := timings.String(); result != expected {
+		t.Errorf("String() = %q, want %q", result, expected)
+	}
+}
+
+func TestFormatSeconds(t *testing.T) {
+	tests := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{40 * time.Millisecond, "0s"},
+		{250 * time.Millisecond, "0.3s"},
+		{9 * time.Second, "9s"},
+		{9960 * time.Millisecond, "10s"},
+		{95 * time.Second, "95s"},
+	}
+
+	for _, tc := range tests {
+		if result := FormatSeconds(tc.input); result != tc.expected {
+			t.Errorf("FormatSeconds(%v) = %q, want %q", tc.input, result, tc.expected)
+		}
+	}
+}
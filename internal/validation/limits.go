@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// Column sizes of the papers table. Values longer than these cannot be
+// stored, so they are rejected (ID) or dropped (DOI) before a save, and
+// file imports name their Source within MaxSourceLength.
+const (
+	MaxIDLength     = 50  // papers.id VARCHAR(50)
+	MaxDOILength    = 100 // papers.doi VARCHAR(100)
+	MaxSourceLength = 100 // papers.source VARCHAR(100)
+)
+
+// Limits caps the size of the fields a save would otherwise send to the
+// store unbounded.
+type Limits struct {
+	MaxAbstract   int // Abstract length in characters (default: 10000)
+	MaxAuthors    int // Authors kept, in order (default: 1000)
+	MaxCategories int // Categories kept, in order (default: 20)
+}
+
+// DefaultLimits returns limits far above any genuine arXiv record.
+func DefaultLimits() Limits {
+	return Limits{MaxAbstract: 10000, MaxAuthors: 1000, MaxCategories: 20}
+}
+
+// Sanitize returns p cut down to the limits, with AbstractTruncated set when
+// its abstract was shortened, and the names of the fields it changed. Zero
+// limits use the DefaultLimits values.
+func (l Limits) Sanitize(p model.Paper) (model.Paper, []string) {
+	def := DefaultLimits()
+	if l.MaxAbstract <= 0 {
+		l.MaxAbstract = def.MaxAbstract
+	}
+	if l.MaxAuthors <= 0 {
+		l.MaxAuthors = def.MaxAuthors
+	}
+	if l.MaxCategories <= 0 {
+		l.MaxCategories = def.MaxCategories
+	}
+
+	var changed []string
+	if utf8.RuneCountInString(p.Abstract) > l.MaxAbstract {
+		p.Abstract = truncateWords(p.Abstract, l.MaxAbstract)
+		p.AbstractTruncated = true
+		changed = append(changed, "Abstract")
+	}
+	if len(p.Authors) > l.MaxAuthors {
+		p.Authors = p.Authors[:l.MaxAuthors:l.MaxAuthors]
+		changed = append(changed, "Authors")
+	}
+	if len(p.Categories) > l.MaxCategories {
+		p.Categories = p.Categories[:l.MaxCategories:l.MaxCategories]
+		changed = append(changed, "Categories")
+	}
+	// A cut DOI would resolve to the wrong record, so it is dropped whole
+	if utf8.RuneCountInString(p.DOI) > MaxDOILength {
+		p.DOI = ""
+		changed = append(changed, "DOI")
+	}
+	return p, changed
+}
+
+// truncateWords cuts s to at most max characters, ending with "…". It cuts
+// at the last space when one is reasonably close to the limit.
+func truncateWords(s string, max int) string {
+	runes := []rune(s)[:max-1]
+	cut := len(runes)
+	for i := cut - 1; i > cut/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + "…"
+}
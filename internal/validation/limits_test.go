@@ -0,0 +1,52 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+func TestLimits_Sanitize(t *testing.T) {
+	l := Limits{MaxAbstract: 20, MaxAuthors: 2, MaxCategories: 1}
+
+	tests := []struct {
+		name     string
+		paper    model.Paper
+		abstract string
+		changed  string
+	}{
+		{"within limits", model.Paper{Abstract: "Short abstract."}, "Short abstract.", ""},
+		{"word boundary", model.Paper{Abstract: "Twenty chars exactly and then some"}, "Twenty chars…", "Abstract"},
+		{"no space near the cut", model.Paper{Abstract: strings.Repeat("x", 30)}, strings.Repeat("x", 19) + "…", "Abstract"},
+		{"multibyte", model.Paper{Abstract: strings.Repeat("语言模型", 10)}, strings.Repeat("语言模型", 4) + "语言模…", "Abstract"},
+		{"authors and categories", model.Paper{Authors: []string{"A", "B", "C"}, Categories: []string{"cs.CL", "cs.AI"}}, "", "Authors,Categories"},
+		{"overlong DOI", model.Paper{DOI: strings.Repeat("d", MaxDOILength+1)}, "", "DOI"},
+	}
+
+	for _, tc := range tests {
+		got, changed := l.Sanitize(tc.paper)
+		if got.Abstract != tc.abstract {
+			t.Errorf("%s: abstract = %q, want %q", tc.name, got.Abstract, tc.abstract)
+		}
+		if n := utf8.RuneCountInString(got.Abstract); n > l.MaxAbstract {
+			t.Errorf("%s: abstract has %d characters, limit %d", tc.name, n, l.MaxAbstract)
+		}
+		if strings.Join(changed, ",") != tc.changed {
+			t.Errorf("%s: changed %v, want %s", tc.name, changed, tc.changed)
+		}
+		if got.AbstractTruncated != strings.Contains(tc.changed, "Abstract") {
+			t.Errorf("%s: AbstractTruncated = %v", tc.name, got.AbstractTruncated)
+		}
+	}
+}
+
+func TestLimits_SanitizeKeepsInput(t *testing.T) {
+	authors := []string{"A", "B", "C"}
+	got, _ := Limits{MaxAuthors: 2}.Sanitize(model.Paper{Authors: authors})
+	got.Authors = append(got.Authors, "D")
+	if authors[2] != "C" {
+		t.Error("appending to the sanitized authors overwrote the input")
+	}
+}
@@ -2,7 +2,9 @@ package validation
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
 )
@@ -22,6 +24,11 @@ type ValidationResult struct {
 	Valid   int
 	Invalid int
 	Errors  []ValidationError
+	ByField map[string]int // Errors per field, counting those left out of Errors
+
+	// Truncated is set when Errors holds only the first errors of some
+	// field (see StreamOptions.MaxErrors)
+	Truncated bool
 }
 
 // ValidatePaper validates a single paper and returns any errors.
@@ -30,6 +37,8 @@ func ValidatePaper(p model.Paper) []ValidationError {
 
 	if strings.TrimSpace(p.ID) == "" {
 		errs = append(errs, ValidationError{Field: "ID", Message: "cannot be empty"})
+	} else if utf8.RuneCountInString(p.ID) > MaxIDLength {
+		errs = append(errs, ValidationError{Field: "ID", Message: fmt.Sprintf("longer than %d characters", MaxIDLength)})
 	}
 
 	if strings.TrimSpace(p.Title) == "" {
@@ -49,13 +58,16 @@ func ValidatePaper(p model.Paper) []ValidationError {
 
 // ValidatePapers validates a batch of papers and returns a summary.
 func ValidatePapers(papers []model.Paper) ValidationResult {
-	result := ValidationResult{}
+	result := ValidationResult{ByField: make(map[string]int)}
 
 	for _, p := range papers {
 		errs := ValidatePaper(p)
 		if len(errs) > 0 {
 			result.Invalid++
 			result.Errors = append(result.Errors, errs...)
+			for _, e := range errs {
+				result.ByField[e.Field]++
+			}
 		} else {
 			result.Valid++
 		}
@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -39,6 +40,25 @@ func TestValidatePaper_EmptyID(t *testing.T) {
 	}
 }
 
+func TestValidatePaper_IDTooLong(t *testing.T) {
+	paper := model.Paper{
+		ID:        strings.Repeat("1", MaxIDLength+1),
+		Title:     "Test Paper",
+		Authors:   []string{"John Doe"},
+		UpdatedAt: time.Now(),
+	}
+
+	errs := ValidatePaper(paper)
+	if len(errs) != 1 || errs[0].Field != "ID" {
+		t.Errorf("expected one ID error, got %v", errs)
+	}
+
+	paper.ID = strings.Repeat("1", MaxIDLength)
+	if errs := ValidatePaper(paper); len(errs) != 0 {
+		t.Errorf("ID at the limit rejected: %v", errs)
+	}
+}
+
 func TestValidatePaper_NoAuthors(t *testing.T) {
 	paper := model.Paper{
 		ID:        "2301.00001",
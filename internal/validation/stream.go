@@ -0,0 +1,145 @@
+package validation
+
+import (
+	"iter"
+	"runtime"
+	"sync"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+const (
+	defaultChunkSize = 1000
+	defaultMaxErrors = 100
+)
+
+// StreamOptions configures ValidateStream.
+type StreamOptions struct {
+	ChunkSize int // Papers validated per task (default: 1000)
+	Workers   int // Chunks validated at once (default: GOMAXPROCS)
+	MaxErrors int // Errors kept per field; the rest are only counted (default: 100)
+
+	// Progress, when set, is called with the papers validated so far
+	// after each chunk, in input order and from the calling goroutine
+	Progress func(validated int)
+}
+
+// ValidateStream validates papers in chunks on a pool of workers, for
+// inputs too large for ValidatePapers. Memory stays bounded: papers are
+// read as workers free up and only the first MaxErrors errors of each
+// field are kept, in input order. Counts are exact.
+func ValidateStream(papers iter.Seq[model.Paper], opts StreamOptions) ValidationResult {
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	maxErrors := opts.MaxErrors
+	if maxErrors <= 0 {
+		maxErrors = defaultMaxErrors
+	}
+
+	type chunk struct {
+		index  int
+		papers []model.Paper
+	}
+	type partial struct {
+		index  int
+		size   int
+		result ValidationResult
+	}
+	chunks := make(chan chunk)
+	partials := make(chan partial)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				partials <- partial{c.index, len(c.papers), validateChunk(c.papers, maxErrors)}
+			}
+		}()
+	}
+	go func() {
+		index := 0
+		buf := make([]model.Paper, 0, chunkSize)
+		for p := range papers {
+			buf = append(buf, p)
+			if len(buf) == chunkSize {
+				chunks <- chunk{index, buf}
+				index++
+				buf = make([]model.Paper, 0, chunkSize)
+			}
+		}
+		if len(buf) > 0 {
+			chunks <- chunk{index, buf}
+		}
+		close(chunks)
+		wg.Wait()
+		close(partials)
+	}()
+
+	// Chunks finish in any order; merge them in input order so the kept
+	// errors are the first ones
+	result := ValidationResult{ByField: make(map[string]int)}
+	kept := make(map[string]int)
+	pending := make(map[int]partial)
+	next, validated := 0, 0
+	for p := range partials {
+		pending[p.index] = p
+		for {
+			p, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			result.Valid += p.result.Valid
+			result.Invalid += p.result.Invalid
+			for field, n := range p.result.ByField {
+				result.ByField[field] += n
+			}
+			result.Truncated = result.Truncated || p.result.Truncated
+			for _, e := range p.result.Errors {
+				if kept[e.Field] < maxErrors {
+					kept[e.Field]++
+					result.Errors = append(result.Errors, e)
+				} else {
+					result.Truncated = true
+				}
+			}
+			validated += p.size
+			if opts.Progress != nil {
+				opts.Progress(validated)
+			}
+		}
+	}
+	return result
+}
+
+// validateChunk validates papers, keeping the first maxErrors errors of
+// each field.
+func validateChunk(papers []model.Paper, maxErrors int) ValidationResult {
+	result := ValidationResult{ByField: make(map[string]int)}
+	for _, p := range papers {
+		errs := ValidatePaper(p)
+		if len(errs) == 0 {
+			result.Valid++
+			continue
+		}
+		result.Invalid++
+		for _, e := range errs {
+			result.ByField[e.Field]++
+			if result.ByField[e.Field] <= maxErrors {
+				result.Errors = append(result.Errors, e)
+			} else {
+				result.Truncated = true
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// mixedPapers returns n papers where every 7th has no ID and every 11th
+// no authors.
+func mixedPapers(n int) []model.Paper {
+	papers := make([]model.Paper, n)
+	for i := range papers {
+		papers[i] = model.Paper{
+			ID:        fmt.Sprintf("2401.%05dv1", i),
+			Title:     fmt.Sprintf("Paper %d", i),
+			Authors:   []string{"A. Author"},
+			UpdatedAt: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		}
+		if i%7 == 0 {
+			papers[i].ID = ""
+		}
+		if i%11 == 0 {
+			papers[i].Authors = nil
+			papers[i].Title = fmt.Sprintf("No authors %d", i)
+		}
+	}
+	return papers
+}
+
+func TestValidateStream_TruncatesErrors(t *testing.T) {
+	papers := mixedPapers(10_000)
+	want := ValidatePapers(papers)
+
+	var progress []int
+	got := ValidateStream(slices.Values(papers), StreamOptions{
+		ChunkSize: 64,
+		Workers:   4,
+		MaxErrors: 50,
+		Progress:  func(n int) { progress = append(progress, n) },
+	})
+
+	if got.Valid != want.Valid || got.Invalid != want.Invalid || !reflect.DeepEqual(got.ByField, want.ByField) {
+		t.Errorf("counts %d/%d %v, want %d/%d %v", got.Valid, got.Invalid, got.ByField, want.Valid, want.Invalid, want.ByField)
+	}
+	if !got.Truncated {
+		t.Error("Truncated not set with more errors than kept")
+	}
+
+	// The kept errors are the first 50 of each field, in input order
+	var first []ValidationError
+	kept := make(map[string]int)
+	for _, e := range want.Errors {
+		if kept[e.Field] < 50 {
+			kept[e.Field]++
+			first = append(first, e)
+		}
+	}
+	if !reflect.DeepEqual(got.Errors, first) {
+		t.Errorf("kept %d errors, want the first 50 of each field (%d)", len(got.Errors), len(first))
+	}
+
+	if len(progress) != 157 || progress[len(progress)-1] != len(papers) || !slices.IsSorted(progress) {
+		t.Errorf("progress reported %d times ending at %v, want 157 increasing calls ending at %d", len(progress), progress[len(progress)-1:], len(papers))
+	}
+}
+
+func TestValidateStream_SmallInput(t *testing.T) {
+	papers := mixedPapers(30)
+	want := ValidatePapers(papers)
+
+	got := ValidateStream(slices.Values(papers), StreamOptions{})
+	if got.Truncated || !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateStream = %+v, want %+v", got, want)
+	}
+
+	empty := ValidateStream(slices.Values([]model.Paper(nil)), StreamOptions{})
+	if empty.Valid != 0 || empty.Invalid != 0 || empty.Truncated || len(empty.Errors) != 0 {
+		t.Errorf("empty input = %+v", empty)
+	}
+}
+
+func BenchmarkValidateStream(b *testing.B) {
+	papers := mixedPapers(200_000)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ValidateStream(slices.Values(papers), StreamOptions{Workers: workers})
+			}
+			b.ReportMetric(float64(len(papers)*b.N)/b.Elapsed().Seconds(), "papers/s")
+		})
+	}
+}
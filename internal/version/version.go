@@ -0,0 +1,61 @@
+// Package version reports which build is running. The variables are set
+// at build time, e.g.
+//
+//	go build -ldflags "-X github.com/1psychoQAQ/genesis-pipeline/internal/version.Version=v1.4.0 \
+//	  -X github.com/1psychoQAQ/genesis-pipeline/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/1psychoQAQ/genesis-pipeline/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/...
+//
+// and read "dev" when they are not.
+package version
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Set with -ldflags "-X"; empty values are reported as "dev".
+var (
+	Version string // Release, e.g. v1.4.0
+	Commit  string // Source commit
+	Date    string // Build date, RFC 3339
+)
+
+const dev = "dev"
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the running build's Info.
+func Get() Info {
+	return Info{
+		Version:   orDev(Version),
+		Commit:    orDev(Commit),
+		Date:      orDev(Date),
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+// String returns the version and commit, e.g. "v1.4.0 (abc1234)".
+func String() string {
+	i := Get()
+	return fmt.Sprintf("%s (%s)", i.Version, i.Commit)
+}
+
+// UserAgent returns the User-Agent sent to paper providers.
+func UserAgent() string {
+	return "genesis-pipeline/" + orDev(Version)
+}
+
+func orDev(s string) string {
+	if s == "" {
+		return dev
+	}
+	return s
+}
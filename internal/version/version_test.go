@@ -0,0 +1,35 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGet_Defaults(t *testing.T) {
+	info := Get()
+	if info.Version != "dev" || info.Commit != "dev" || info.Date != "dev" {
+		t.Errorf("unset build variables should read dev, got %+v", info)
+	}
+	if info.GoVersion != runtime.Version() || info.Platform == "" {
+		t.Errorf("runtime fields not set: %+v", info)
+	}
+	if got := String(); got != "dev (dev)" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := UserAgent(); got != "genesis-pipeline/dev" {
+		t.Errorf("UserAgent() = %q", got)
+	}
+}
+
+func TestGet_LinkerValues(t *testing.T) {
+	defer func(v, c, d string) { Version, Commit, Date = v, c, d }(Version, Commit, Date)
+	Version, Commit, Date = "v1.4.0", "abc1234", "2024-03-01T00:00:00Z"
+
+	info := Get()
+	if info.Version != "v1.4.0" || info.Commit != "abc1234" || info.Date != "2024-03-01T00:00:00Z" {
+		t.Errorf("got %+v", info)
+	}
+	if got := String(); got != "v1.4.0 (abc1234)" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,98 @@
+package genesis
+
+import (
+	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+)
+
+// The public Paper is a copy of model.Paper, so the internal model can
+// change without breaking callers. Score details are public in English
+// and stored with the filter's labels.
+
+func fromModel(p model.Paper) Paper {
+	out := Paper{
+		ID:                p.ID,
+		Title:             p.Title,
+		Abstract:          p.Abstract,
+		Authors:           p.Authors,
+		Categories:        p.Categories,
+		UpdatedAt:         p.UpdatedAt,
+		Comments:          p.Comments,
+		DOI:               p.DOI,
+		JournalRef:        p.JournalRef,
+		Pages:             p.Pages,
+		Figures:           p.Figures,
+		Tables:            p.Tables,
+		AbstractTruncated: p.AbstractTruncated,
+		Source:            p.Source,
+		Announce:          p.Announce,
+		Score:             p.Score,
+		ScoreDetails:      detailsEN(p.ScoreDetails),
+	}
+	for _, l := range p.Links {
+		out.Links = append(out.Links, Link{URL: l.URL, Type: l.Type, Title: l.Title, FoundIn: l.FoundIn})
+	}
+	return out
+}
+
+func toModel(p Paper) model.Paper {
+	out := model.Paper{
+		ID:                p.ID,
+		Title:             p.Title,
+		Abstract:          p.Abstract,
+		Authors:           p.Authors,
+		Categories:        p.Categories,
+		UpdatedAt:         p.UpdatedAt,
+		Comments:          p.Comments,
+		DOI:               p.DOI,
+		JournalRef:        p.JournalRef,
+		Pages:             p.Pages,
+		Figures:           p.Figures,
+		Tables:            p.Tables,
+		AbstractTruncated: p.AbstractTruncated,
+		Source:            p.Source,
+		Announce:          p.Announce,
+		Score:             p.Score,
+	}
+	for _, l := range p.Links {
+		out.Links = append(out.Links, model.Link{URL: l.URL, Type: l.Type, Title: l.Title, FoundIn: l.FoundIn})
+	}
+	for _, d := range p.ScoreDetails {
+		out.ScoreDetails = append(out.ScoreDetails, filter.StoredDetail(d))
+	}
+	return out
+}
+
+func fromModels(papers []model.Paper) []Paper {
+	if papers == nil {
+		return nil
+	}
+	out := make([]Paper, len(papers))
+	for i, p := range papers {
+		out[i] = fromModel(p)
+	}
+	return out
+}
+
+func toModels(papers []Paper) []model.Paper {
+	if papers == nil {
+		return nil
+	}
+	out := make([]model.Paper, len(papers))
+	for i, p := range papers {
+		out[i] = toModel(p)
+	}
+	return out
+}
+
+// detailsEN renders stored score details in English.
+func detailsEN(details []string) []string {
+	if details == nil {
+		return nil
+	}
+	out := make([]string, len(details))
+	for i, d := range details {
+		out[i] = filter.DetailIn(d, filter.LangEN)
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package genesis_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/pkg/genesis"
+)
+
+// papers are fixtures standing in for a provider response.
+func papers() []genesis.Paper {
+	updated := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	return []genesis.Paper{
+		{
+			ID:         "2403.00001v2",
+			Title:      "Sparse Retrieval for RAG",
+			Abstract:   "We evaluate on three benchmark datasets with ablation and baseline experiments.",
+			Authors:    []string{"Ada Lovelace"},
+			Categories: []string{"cs.CL"},
+			Comments:   "Accepted at ACL 2024. 12 pages, 4 figures",
+			UpdatedAt:  updated,
+		},
+		{
+			ID:        "2403.00002v1",
+			Title:     "A Perspective on Retrieval",
+			Abstract:  "We propose a framework.",
+			Authors:   []string{"Alan Turing"},
+			UpdatedAt: updated,
+		},
+		{
+			// No authors: dropped by Fetch
+			ID:        "2403.00003v1",
+			Title:     "Untitled Notes",
+			UpdatedAt: updated,
+		},
+	}
+}
+
+func ExampleClient_Fetch() {
+	c := &genesis.Client{Provider: genesis.FixtureProvider(papers()...)}
+
+	fetched, err := c.Fetch(context.Background(), genesis.Query{Text: "retrieval", Limit: 10})
+	if err != nil {
+		panic(err)
+	}
+	for _, p := range fetched {
+		fmt.Printf("%s: %s (%d pages)\n", p.ID, p.Title, p.Pages)
+	}
+	// Output:
+	// 2403.00001v2: Sparse Retrieval for RAG (12 pages)
+	// 2403.00002v1: A Perspective on Retrieval (0 pages)
+}
+
+func ExampleClient_Filter() {
+	c := &genesis.Client{Provider: genesis.FixtureProvider(papers()...)}
+	fetched, _ := c.Fetch(context.Background(), genesis.Query{Text: "retrieval"})
+
+	for _, r := range c.Filter(fetched, genesis.Options{MinScore: 60}) {
+		fmt.Printf("%s passed=%t score=%d\n", r.Paper.ID, r.Passed, r.Score)
+	}
+	// Output:
+	// 2403.00001v2 passed=true score=70
+	// 2403.00002v1 passed=false score=0
+}
+
+func ExampleClient_Save() {
+	ctx := context.Background()
+	c := &genesis.Client{
+		Provider: genesis.FixtureProvider(papers()...),
+		Store:    genesis.NewMemoryStore(),
+	}
+
+	fetched, _ := c.Fetch(ctx, genesis.Query{})
+	var passed []genesis.Paper
+	for _, r := range c.Filter(fetched, genesis.Options{}) {
+		if r.Passed {
+			passed = append(passed, r.Paper)
+		}
+	}
+	if err := c.Save(ctx, passed); err != nil {
+		panic(err)
+	}
+	fmt.Println("saved", len(passed))
+	// Output:
+	// saved 1
+}
+
+func ExampleValidate() {
+	err := genesis.Validate(genesis.Paper{ID: "2403.00003v1", Title: "Untitled Notes"})
+	fmt.Println(err)
+	// Output:
+	// Authors: must have at least one author
+	// UpdatedAt: cannot be zero
+}
+
+func ExampleExplain() {
+	fmt.Println(genesis.Explain(40, []string{"+30 accepted at a peer-reviewed venue", "+10 links to code"}, "en"))
+	// Output:
+	// Scored 40: accepted at a peer-reviewed venue (+30), links to code (+10).
+}
@@ -0,0 +1,295 @@
+// Package genesis is the public API for embedding the paper pipeline in
+// other Go programs. It covers fetching from providers, metadata
+// validation and quality filtering, with optional saving to a store.
+// The HTTP server, web UI, sync queue, PDF archive and export formats are
+// not part of it.
+//
+// The package is a thin facade over the internal packages. Its names and
+// signatures follow semantic versioning; the internal packages may change
+// freely underneath.
+//
+//	c := genesis.NewClient()
+//	papers, err := c.Fetch(ctx, genesis.Query{Text: "retrieval augmented generation", Limit: 50})
+//	...
+//	for _, r := range c.Filter(papers, genesis.Options{MinScore: 60}) {
+//		if r.Passed {
+//			fmt.Println(r.Score, r.Paper.Title)
+//		}
+//	}
+package genesis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/1psychoQAQ/genesis-pipeline/internal/clock"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/filter"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/model"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxiv"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/parser/arxivrss"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/storage/memory"
+	"github.com/1psychoQAQ/genesis-pipeline/internal/validation"
+)
+
+// Paper is a paper's metadata. Fields may be added in minor releases.
+type Paper struct {
+	ID         string    // ArXiv identifier with version, e.g. "2301.00001v2"
+	Title      string    //
+	Abstract   string    //
+	Authors    []string  //
+	Categories []string  // Primary category first, e.g. "cs.CL"
+	UpdatedAt  time.Time // Last update on the provider
+
+	Comments   string // Author comments, e.g. "Accepted at ACL 2024. 12 pages"
+	DOI        string
+	JournalRef string
+	Links      []Link
+
+	// Extent read from Comments; zero when not stated
+	Pages   int
+	Figures int
+	Tables  int
+
+	AbstractTruncated bool   // Abstract was cut to the storage limit
+	Source            string // Provider that produced the record (SourceArxiv, SourceArxivRSS)
+	Announce          string // Announcement type from SourceArxivRSS, e.g. "new"
+
+	// Set by Client.Filter
+	Score        int
+	ScoreDetails []string // Points per signal, e.g. "+30 accepted at a peer-reviewed venue"
+}
+
+// Link is a related link of a paper.
+type Link struct {
+	URL     string
+	Type    string // "abstract", "pdf", "code", ...
+	Title   string
+	FoundIn string // "abstract" or "comments" for links the authors wrote there; empty for the source's own
+}
+
+// Known values of Paper.Source.
+const (
+	SourceArxiv    = model.SourceArxiv
+	SourceArxivRSS = model.SourceArxivRSS
+)
+
+// ErrNoStore is returned by Client.Save when the client has no store.
+var ErrNoStore = errors.New("no store configured")
+
+// Provider fetches papers matching a query. Providers that also implement
+// ContextProvider are cancelled with Fetch's context.
+type Provider interface {
+	FetchPapers(query string, limit int) ([]Paper, error)
+}
+
+// ContextProvider is a Provider whose requests can be cancelled.
+type ContextProvider interface {
+	FetchPapersContext(ctx context.Context, query string, limit int) ([]Paper, error)
+}
+
+// Store saves papers, inserting new IDs and updating stored ones.
+type Store interface {
+	SaveBatch(ctx context.Context, papers []Paper) error
+}
+
+// ArxivProvider returns a provider for the arXiv search API.
+func ArxivProvider() Provider {
+	return internalProvider{arxiv.NewClient()}
+}
+
+// AnnouncementProvider returns a provider for the arXiv announcement feeds
+// of the given categories, e.g. "cs.CL". The query is matched against the
+// announced titles and abstracts.
+func AnnouncementProvider(categories ...string) Provider {
+	return internalProvider{arxivrss.NewClient(categories)}
+}
+
+// internalProvider exposes an internal provider with public papers.
+type internalProvider struct {
+	p parser.Provider
+}
+
+func (ip internalProvider) FetchPapers(query string, limit int) ([]Paper, error) {
+	return ip.FetchPapersContext(context.Background(), query, limit)
+}
+
+func (ip internalProvider) FetchPapersContext(ctx context.Context, query string, limit int) ([]Paper, error) {
+	papers, err := ip.p.FetchPapers(ctx, query, limit)
+	return fromModels(papers), err
+}
+
+// modelProvider adapts a public provider to parser.Provider.
+type modelProvider struct {
+	p Provider
+}
+
+func (mp modelProvider) FetchPapers(ctx context.Context, query string, limit int) ([]model.Paper, error) {
+	var papers []Paper
+	var err error
+	if cp, ok := mp.p.(ContextProvider); ok {
+		papers, err = cp.FetchPapersContext(ctx, query, limit)
+	} else {
+		papers, err = mp.p.FetchPapers(query, limit)
+	}
+	return toModels(papers), err
+}
+
+// FixtureProvider returns a provider that answers every query with papers,
+// up to the requested limit. It is meant for tests and examples.
+func FixtureProvider(papers ...Paper) Provider {
+	return fixture(papers)
+}
+
+type fixture []Paper
+
+func (f fixture) FetchPapers(query string, limit int) ([]Paper, error) {
+	if limit > 0 && limit < len(f) {
+		return append([]Paper(nil), f[:limit]...), nil
+	}
+	return append([]Paper(nil), f...), nil
+}
+
+// NewMemoryStore returns an in-process store, for programs that want to
+// keep results without a database.
+func NewMemoryStore() Store {
+	return memoryStore{memory.New()}
+}
+
+type memoryStore struct {
+	s *memory.Store
+}
+
+func (m memoryStore) SaveBatch(ctx context.Context, papers []Paper) error {
+	return m.s.SaveBatch(ctx, toModels(papers))
+}
+
+// Query describes what to fetch.
+type Query struct {
+	Text   string        // Provider search query
+	Limit  int           // Maximum papers to request (default: 10)
+	MaxAge time.Duration // Drop papers last updated longer ago (0 = no limit)
+}
+
+// Options are the quality filter settings.
+type Options struct {
+	MinScore int // Score a paper needs to pass (default: 60)
+	// CategoryMinScore overrides MinScore by primary category
+	CategoryMinScore map[string]int
+	// PageTiers awards points by stated page count, keyed by minimum pages
+	PageTiers map[int]int
+}
+
+// FilterResult is the filter's verdict on one paper.
+type FilterResult struct {
+	Paper   Paper    // The paper with Score and ScoreDetails set
+	Passed  bool     // Cleared the evidence gate and the score threshold
+	Score   int      // Quality score, 0-100
+	Details []string // Points per signal, e.g. "+30 accepted at a peer-reviewed venue"
+}
+
+// Client fetches, validates and filters papers.
+type Client struct {
+	Provider Provider         // Source of papers (default: ArxivProvider())
+	Store    Store            // Optional destination for Save
+	Now      func() time.Time // Time source for Query.MaxAge (default: system clock)
+}
+
+// NewClient creates a client fetching from the arXiv search API.
+func NewClient() *Client {
+	return &Client{Provider: ArxivProvider()}
+}
+
+// Fetch runs q against the provider and returns the valid papers, without
+// duplicate IDs, with page/figure/table counts read from their comments
+// and oversized fields cut to the storage limits.
+func (c *Client) Fetch(ctx context.Context, q Query) ([]Paper, error) {
+	provider := c.Provider
+	if provider == nil {
+		provider = ArxivProvider()
+	}
+	if q.Limit <= 0 {
+		q.Limit = 10
+	}
+
+	fetched, err := modelProvider{provider}.FetchPapers(ctx, q.Text, q.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("fetch papers: %w", err)
+	}
+
+	var cutoff time.Time
+	if q.MaxAge > 0 {
+		now := c.Now
+		if now == nil {
+			now = clock.Real{}.Now
+		}
+		cutoff = now().Add(-q.MaxAge)
+	}
+	limits := validation.DefaultLimits()
+	seen := make(map[string]bool, len(fetched))
+	papers := make([]model.Paper, 0, len(fetched))
+	for _, p := range fetched {
+		if seen[p.ID] || !validation.IsValid(p) || p.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		seen[p.ID] = true
+		if p.Pages == 0 && p.Figures == 0 && p.Tables == 0 {
+			p.SetExtent()
+		}
+		p, _ = limits.Sanitize(p)
+		papers = append(papers, p)
+	}
+	return fromModels(papers), nil
+}
+
+// Filter scores papers and reports which pass, in input order.
+func (c *Client) Filter(papers []Paper, opts Options) []FilterResult {
+	f := filter.NewFilter()
+	if opts.MinScore > 0 {
+		f.MinScore = opts.MinScore
+	}
+	f.CategoryMinScore = opts.CategoryMinScore
+	f.PageTiers = opts.PageTiers
+
+	results := make([]FilterResult, 0, len(papers))
+	for _, r := range f.Apply(toModels(papers)) {
+		p := fromModel(r.Paper)
+		p.Score, p.ScoreDetails = r.Score, detailsEN(r.Details)
+		results = append(results, FilterResult{
+			Paper:   p,
+			Passed:  f.Passed(r),
+			Score:   r.Score,
+			Details: p.ScoreDetails,
+		})
+	}
+	return results
+}
+
+// Save writes papers to the client's store.
+func (c *Client) Save(ctx context.Context, papers []Paper) error {
+	if c.Store == nil {
+		return ErrNoStore
+	}
+	if err := c.Store.SaveBatch(ctx, papers); err != nil {
+		return fmt.Errorf("save papers: %w", err)
+	}
+	return nil
+}
+
+// Validate reports why p would be dropped by Fetch, or nil if it is valid.
+func Validate(p Paper) error {
+	var errs []error
+	for _, e := range validation.ValidatePaper(toModel(p)) {
+		errs = append(errs, e)
+	}
+	return errors.Join(errs...)
+}
+
+// Explain describes a score and its details in a sentence; lang is "en"
+// or "zh". Details may be in the form FilterResult.Details uses or as
+// stored by earlier versions.
+func Explain(score int, details []string, lang string) string {
+	return filter.Explain(score, details, lang)
+}